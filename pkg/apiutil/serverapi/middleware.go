@@ -18,6 +18,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/errs"
@@ -32,6 +33,10 @@ const (
 	RedirectorHeader    = "PD-Redirector"
 	AllowFollowerHandle = "PD-Allow-follower-handle"
 	FollowerHandle      = "PD-Follower-handle"
+	// HandleTimeHeader is the time from receiving the request to writing the
+	// response header, it is the handling time of the leader if the request
+	// is redirected.
+	HandleTimeHeader = "PD-Handle-Time"
 )
 
 const (
@@ -79,6 +84,26 @@ func IsServiceAllowed(s *server.Server, group server.ServiceGroup) bool {
 	return false
 }
 
+type handleTimeRecorder struct{}
+
+// NewHandleTimeRecorder reports the handling time of the request by the
+// response header.
+func NewHandleTimeRecorder() negroni.Handler {
+	return handleTimeRecorder{}
+}
+
+func (handleTimeRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	start := time.Now()
+	if rw, ok := w.(negroni.ResponseWriter); ok {
+		rw.Before(func(rw negroni.ResponseWriter) {
+			if rw.Header().Get(HandleTimeHeader) == "" {
+				rw.Header().Set(HandleTimeHeader, time.Since(start).String())
+			}
+		})
+	}
+	next(w, r)
+}
+
 type redirector struct {
 	s *server.Server
 }
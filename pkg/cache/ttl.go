@@ -256,3 +256,21 @@ func (c *TTLString) Pop() (string, interface{}, bool) {
 func (c *TTLString) Get(id string) (interface{}, bool) {
 	return c.ttlCache.get(id)
 }
+
+// GetAllKeys returns all keys.
+func (c *TTLString) GetAllKeys() []string {
+	keys := c.ttlCache.getKeys()
+	var ret []string
+	for _, key := range keys {
+		k, ok := key.(string)
+		if ok {
+			ret = append(ret, k)
+		}
+	}
+	return ret
+}
+
+// Remove removes the key.
+func (c *TTLString) Remove(key string) {
+	c.ttlCache.remove(key)
+}
@@ -0,0 +1,312 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgroup
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRoot = "/sys/fs/cgroup"
+	procCgroup  = "/proc/self/cgroup"
+	numaOnline  = "/sys/devices/system/node/online"
+	// values above this are treated as "no memory limit" by cgroup v1.
+	unlimitedMemory = uint64(math.MaxInt64) &^ 4095
+)
+
+// Limits records the resource limits that applied to the current process.
+type Limits struct {
+	// NumCPU is the number of logical CPUs visible to the process.
+	NumCPU int `json:"num_cpu"`
+	// CPUQuota is the number of CPUs the cgroup is allowed to use.
+	// 0 means there is no quota.
+	CPUQuota float64 `json:"cpu_quota"`
+	// MemoryLimit is the cgroup memory limit in bytes. 0 means there is no limit.
+	MemoryLimit uint64 `json:"memory_limit"`
+	// NUMANodes is the number of online NUMA nodes.
+	NUMANodes int `json:"numa_nodes"`
+}
+
+// CPURatio returns the share of the visible CPUs the process may use, in (0, 1].
+func (l Limits) CPURatio() float64 {
+	if l.CPUQuota <= 0 || l.NumCPU <= 0 || l.CPUQuota >= float64(l.NumCPU) {
+		return 1
+	}
+	return l.CPUQuota / float64(l.NumCPU)
+}
+
+// ThrottleInterval stretches the interval of a background task according to
+// the CPU quota, so that a PD running with a fraction of the host CPUs does
+// not spend the same absolute time on background work as an unlimited one.
+func (l Limits) ThrottleInterval(interval time.Duration) time.Duration {
+	return time.Duration(float64(interval) / l.CPURatio())
+}
+
+// CapMemoryBudget caps the memory budget of a cache to the given share of the
+// memory limit, so that the cache can not push the process out of its cgroup.
+// A budget of 0 means no limit.
+func (l Limits) CapMemoryBudget(budget uint64, share float64) uint64 {
+	if l.MemoryLimit == 0 || share <= 0 {
+		return budget
+	}
+	limit := uint64(float64(l.MemoryLimit) * share)
+	if budget == 0 || limit < budget {
+		return limit
+	}
+	return budget
+}
+
+var (
+	once   sync.Once
+	limits Limits
+)
+
+// GetLimits returns the limits detected from the cgroup of the current process.
+// The detection only runs once.
+func GetLimits() Limits {
+	once.Do(func() {
+		limits = Detect(defaultRoot, procCgroup)
+		log.Info("detected resource limits",
+			zap.Int("num-cpu", limits.NumCPU),
+			zap.Float64("cpu-quota", limits.CPUQuota),
+			zap.Uint64("memory-limit", limits.MemoryLimit),
+			zap.Int("numa-nodes", limits.NUMANodes))
+	})
+	return limits
+}
+
+// Detect reads the cgroup limits of the process under the given cgroup root.
+// Both cgroup v2 (unified) and v1 hierarchies are supported. The cgroups of the
+// process are read from procCgroup, like /proc/self/cgroup, so the nested
+// cgroups are honoured: the tightest limit of the cgroup and its ancestors is
+// used.
+func Detect(root, procCgroup string) Limits {
+	l := Limits{
+		NumCPU:    runtime.NumCPU(),
+		NUMANodes: readNUMANodes(numaOnline),
+	}
+	paths := readProcCgroup(procCgroup)
+	v2Dirs := cgroupDirs(root, "", paths[""])
+	for _, dir := range v2Dirs {
+		if quota, ok := readCPUQuotaV2(filepath.Join(dir, "cpu.max")); ok {
+			l.CPUQuota = minLimit(l.CPUQuota, quota)
+		}
+		if mem, ok := readMemoryLimit(filepath.Join(dir, "memory.max")); ok {
+			l.MemoryLimit = uint64(minLimit(float64(l.MemoryLimit), float64(mem)))
+		}
+	}
+	if l.CPUQuota == 0 {
+		for _, dir := range cgroupDirs(root, "cpu", paths["cpu"]) {
+			if quota, ok := readCPUQuotaV1(filepath.Join(dir, "cpu.cfs_quota_us"), filepath.Join(dir, "cpu.cfs_period_us")); ok {
+				l.CPUQuota = minLimit(l.CPUQuota, quota)
+			}
+		}
+	}
+	if l.MemoryLimit == 0 {
+		for _, dir := range cgroupDirs(root, "memory", paths["memory"]) {
+			if mem, ok := readMemoryLimit(filepath.Join(dir, "memory.limit_in_bytes")); ok {
+				l.MemoryLimit = uint64(minLimit(float64(l.MemoryLimit), float64(mem)))
+			}
+		}
+	}
+	return l
+}
+
+// minLimit returns the smaller limit, 0 means no limit.
+func minLimit(a, b float64) float64 {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// readProcCgroup parses the cgroups of the process, which are formatted as
+// "hierarchy-ID:controller-list:cgroup-path" per line. The path of cgroup v2
+// is keyed by the empty controller.
+func readProcCgroup(path string) map[string]string {
+	paths := make(map[string]string)
+	content, ok := readFile(path)
+	if !ok {
+		return paths
+	}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[1] == "" {
+			paths[""] = fields[2]
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			paths[controller] = fields[2]
+		}
+	}
+	return paths
+}
+
+// cgroupDirs returns the directories of the cgroup and its ancestors up to the
+// mount point of the controller, the cgroup itself comes first. If the cgroup
+// is not visible, like in a container without the cgroup namespace where the
+// cgroup of the container is mounted as the root, only the mount point is
+// returned.
+func cgroupDirs(root, controller, cgroupPath string) []string {
+	mount := filepath.Join(root, controller)
+	dir := filepath.Join(mount, cgroupPath)
+	if _, err := os.Stat(dir); err != nil || cgroupPath == "" {
+		return []string{mount}
+	}
+	var dirs []string
+	for ; dir != mount && strings.HasPrefix(dir, mount); dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+	}
+	return append(dirs, mount)
+}
+
+// Usage records the resource usage of the cgroup of the current process.
+type Usage struct {
+	// CPUUsage is the accumulated CPU time consumed by the cgroup.
+	CPUUsage time.Duration `json:"cpu_usage"`
+	// MemoryUsage is the current memory usage of the cgroup in bytes.
+	MemoryUsage uint64 `json:"memory_usage"`
+}
+
+// GetUsage returns the current usage of the cgroup of the current process.
+func GetUsage() Usage {
+	return ReadUsage(defaultRoot, procCgroup)
+}
+
+// ReadUsage reads the usage of the cgroup of the process, which is read from
+// procCgroup, under the given cgroup root.
+func ReadUsage(root, procCgroup string) Usage {
+	var u Usage
+	paths := readProcCgroup(procCgroup)
+	v2Dir := cgroupDirs(root, "", paths[""])[0]
+	cpuacctDir := cgroupDirs(root, "cpuacct", paths["cpuacct"])[0]
+	memoryDir := cgroupDirs(root, "memory", paths["memory"])[0]
+	if content, ok := readFile(filepath.Join(v2Dir, "cpu.stat")); ok {
+		for _, line := range strings.Split(content, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 2 && fields[0] == "usage_usec" {
+				if usec, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+					u.CPUUsage = time.Duration(usec) * time.Microsecond
+				}
+			}
+		}
+	} else if content, ok := readFile(filepath.Join(cpuacctDir, "cpuacct.usage")); ok {
+		if nsec, err := strconv.ParseUint(content, 10, 64); err == nil {
+			u.CPUUsage = time.Duration(nsec)
+		}
+	}
+	if mem, ok := readMemoryLimit(filepath.Join(v2Dir, "memory.current")); ok {
+		u.MemoryUsage = mem
+	} else if mem, ok := readMemoryLimit(filepath.Join(memoryDir, "memory.usage_in_bytes")); ok {
+		u.MemoryUsage = mem
+	}
+	return u
+}
+
+func readFile(path string) (string, bool) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(data)), true
+}
+
+// readCPUQuotaV2 parses `cpu.max`, which is formatted as "$MAX $PERIOD".
+func readCPUQuotaV2(path string) (float64, bool) {
+	content, ok := readFile(path)
+	if !ok {
+		return 0, false
+	}
+	fields := strings.Fields(content)
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0, false
+	}
+	return parseQuota(fields[0], fields[1])
+}
+
+func readCPUQuotaV1(quotaPath, periodPath string) (float64, bool) {
+	quota, ok := readFile(quotaPath)
+	if !ok {
+		return 0, false
+	}
+	period, ok := readFile(periodPath)
+	if !ok {
+		return 0, false
+	}
+	return parseQuota(quota, period)
+}
+
+func parseQuota(quotaStr, periodStr string) (float64, bool) {
+	quota, err := strconv.ParseInt(quotaStr, 10, 64)
+	if err != nil || quota <= 0 {
+		return 0, false
+	}
+	period, err := strconv.ParseInt(periodStr, 10, 64)
+	if err != nil || period <= 0 {
+		return 0, false
+	}
+	return float64(quota) / float64(period), true
+}
+
+func readMemoryLimit(path string) (uint64, bool) {
+	content, ok := readFile(path)
+	if !ok || content == "max" {
+		return 0, false
+	}
+	mem, err := strconv.ParseUint(content, 10, 64)
+	if err != nil || mem == 0 || mem >= unlimitedMemory {
+		return 0, false
+	}
+	return mem, true
+}
+
+// readNUMANodes parses the node list such as "0-1,3".
+func readNUMANodes(path string) int {
+	content, ok := readFile(path)
+	if !ok || content == "" {
+		return 1
+	}
+	count := 0
+	for _, part := range strings.Split(content, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		if len(bounds) == 1 {
+			count++
+			continue
+		}
+		lo, err1 := strconv.Atoi(bounds[0])
+		hi, err2 := strconv.Atoi(bounds[1])
+		if err1 != nil || err2 != nil || hi < lo {
+			continue
+		}
+		count += hi - lo + 1
+	}
+	if count == 0 {
+		return 1
+	}
+	return count
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgroup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testCgroupSuite{})
+
+type testCgroupSuite struct{}
+
+func writeFile(c *C, path, content string) {
+	c.Assert(os.MkdirAll(filepath.Dir(path), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(path, []byte(content), 0644), IsNil)
+}
+
+func (s *testCgroupSuite) TestDetectV2(c *C) {
+	root := c.MkDir()
+	writeFile(c, filepath.Join(root, "cpu.max"), "150000 100000\n")
+	writeFile(c, filepath.Join(root, "memory.max"), "1073741824\n")
+	l := Detect(root, "")
+	c.Assert(l.CPUQuota, Equals, 1.5)
+	c.Assert(l.MemoryLimit, Equals, uint64(1073741824))
+
+	writeFile(c, filepath.Join(root, "cpu.max"), "max 100000\n")
+	writeFile(c, filepath.Join(root, "memory.max"), "max\n")
+	l = Detect(root, "")
+	c.Assert(l.CPUQuota, Equals, 0.0)
+	c.Assert(l.MemoryLimit, Equals, uint64(0))
+}
+
+func (s *testCgroupSuite) TestDetectV1(c *C) {
+	root := c.MkDir()
+	writeFile(c, filepath.Join(root, "cpu", "cpu.cfs_quota_us"), "200000")
+	writeFile(c, filepath.Join(root, "cpu", "cpu.cfs_period_us"), "100000")
+	writeFile(c, filepath.Join(root, "memory", "memory.limit_in_bytes"), "9223372036854771712")
+	l := Detect(root, "")
+	c.Assert(l.CPUQuota, Equals, 2.0)
+	c.Assert(l.MemoryLimit, Equals, uint64(0))
+
+	writeFile(c, filepath.Join(root, "cpu", "cpu.cfs_quota_us"), "-1")
+	c.Assert(Detect(root, "").CPUQuota, Equals, 0.0)
+}
+
+func (s *testCgroupSuite) TestDetectNested(c *C) {
+	root := c.MkDir()
+	proc := filepath.Join(c.MkDir(), "cgroup")
+	writeFile(c, proc, "0::/kubepods/pod1/pd\n")
+	writeFile(c, filepath.Join(root, "cpu.max"), "max 100000\n")
+	writeFile(c, filepath.Join(root, "kubepods", "pod1", "cpu.max"), "400000 100000\n")
+	writeFile(c, filepath.Join(root, "kubepods", "pod1", "pd", "cpu.max"), "max 100000\n")
+	writeFile(c, filepath.Join(root, "kubepods", "memory.max"), "1073741824\n")
+	writeFile(c, filepath.Join(root, "kubepods", "pod1", "pd", "memory.max"), "2147483648\n")
+	writeFile(c, filepath.Join(root, "kubepods", "pod1", "pd", "memory.current"), "4096\n")
+	l := Detect(root, proc)
+	c.Assert(l.CPUQuota, Equals, 4.0)
+	c.Assert(l.MemoryLimit, Equals, uint64(1073741824))
+	c.Assert(ReadUsage(root, proc).MemoryUsage, Equals, uint64(4096))
+
+	// v1 with the controllers mounted separately.
+	root = c.MkDir()
+	writeFile(c, proc, "5:memory:/docker/pd\n4:cpu,cpuacct:/docker/pd\n")
+	writeFile(c, filepath.Join(root, "cpu", "docker", "pd", "cpu.cfs_quota_us"), "300000")
+	writeFile(c, filepath.Join(root, "cpu", "docker", "pd", "cpu.cfs_period_us"), "100000")
+	writeFile(c, filepath.Join(root, "memory", "docker", "memory.limit_in_bytes"), "536870912")
+	writeFile(c, filepath.Join(root, "memory", "docker", "pd", "memory.limit_in_bytes"), "9223372036854771712")
+	l = Detect(root, proc)
+	c.Assert(l.CPUQuota, Equals, 3.0)
+	c.Assert(l.MemoryLimit, Equals, uint64(536870912))
+
+	// the cgroup of the container is mounted as the root.
+	root = c.MkDir()
+	writeFile(c, proc, "0::/kubepods/pod2/pd\n")
+	writeFile(c, filepath.Join(root, "cpu.max"), "100000 100000\n")
+	c.Assert(Detect(root, proc).CPUQuota, Equals, 1.0)
+}
+
+func (s *testCgroupSuite) TestUsage(c *C) {
+	root := c.MkDir()
+	writeFile(c, filepath.Join(root, "cpu.stat"), "usage_usec 1500000\nuser_usec 1000000\n")
+	writeFile(c, filepath.Join(root, "memory.current"), "4096\n")
+	u := ReadUsage(root, "")
+	c.Assert(u.CPUUsage, Equals, 1500*time.Millisecond)
+	c.Assert(u.MemoryUsage, Equals, uint64(4096))
+}
+
+func (s *testCgroupSuite) TestThrottle(c *C) {
+	l := Limits{NumCPU: 8, CPUQuota: 2}
+	c.Assert(l.CPURatio(), Equals, 0.25)
+	c.Assert(l.ThrottleInterval(10*time.Millisecond), Equals, 40*time.Millisecond)
+
+	l = Limits{NumCPU: 8}
+	c.Assert(l.ThrottleInterval(10*time.Millisecond), Equals, 10*time.Millisecond)
+	l = Limits{NumCPU: 2, CPUQuota: 4}
+	c.Assert(l.CPURatio(), Equals, 1.0)
+}
+
+func (s *testCgroupSuite) TestCapMemoryBudget(c *C) {
+	l := Limits{MemoryLimit: 1 << 30}
+	c.Assert(l.CapMemoryBudget(512<<20, 0.25), Equals, uint64(256<<20))
+	c.Assert(l.CapMemoryBudget(128<<20, 0.25), Equals, uint64(128<<20))
+	c.Assert(l.CapMemoryBudget(0, 0.25), Equals, uint64(256<<20))
+
+	l = Limits{}
+	c.Assert(l.CapMemoryBudget(512<<20, 0.25), Equals, uint64(512<<20))
+	c.Assert(l.CapMemoryBudget(0, 0.25), Equals, uint64(0))
+}
+
+func (s *testCgroupSuite) TestNUMANodes(c *C) {
+	dir := c.MkDir()
+	path := filepath.Join(dir, "online")
+	c.Assert(readNUMANodes(path), Equals, 1)
+	writeFile(c, path, "0-1,3\n")
+	c.Assert(readNUMANodes(path), Equals, 3)
+}
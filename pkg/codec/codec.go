@@ -24,6 +24,7 @@ var (
 	tablePrefix  = []byte{'t'}
 	metaPrefix   = []byte{'m'}
 	recordPrefix = []byte("_r")
+	indexPrefix  = []byte("_i")
 )
 
 const (
@@ -53,6 +54,23 @@ func (k Key) TableID() int64 {
 	return tableID
 }
 
+// IndexID returns the index ID of the key, if the key is not index key, returns 0.
+func (k Key) IndexID() int64 {
+	_, key, err := DecodeBytes(k)
+	if err != nil || !bytes.HasPrefix(key, tablePrefix) {
+		return 0
+	}
+	key, _, err = DecodeInt(key[len(tablePrefix):])
+	if err != nil || !bytes.HasPrefix(key, indexPrefix) {
+		return 0
+	}
+	_, indexID, err := DecodeInt(key[len(indexPrefix):])
+	if err != nil {
+		return 0
+	}
+	return indexID
+}
+
 // MetaOrTable checks if the key is a meta key or table key.
 // If the key is a meta key, it returns true and 0.
 // If the key is a table key, it returns false and table ID.
@@ -184,6 +202,16 @@ func GenerateTableKey(tableID int64) []byte {
 	return buf
 }
 
+// GenerateIndexKey generates an index key without the index values.
+func GenerateIndexKey(tableID, indexID int64) []byte {
+	buf := make([]byte, 0, len(tablePrefix)+len(indexPrefix)+8*2)
+	buf = append(buf, tablePrefix...)
+	buf = EncodeInt(buf, tableID)
+	buf = append(buf, indexPrefix...)
+	buf = EncodeInt(buf, indexID)
+	return buf
+}
+
 // GenerateRowKey generates a row key.
 func GenerateRowKey(tableID, rowID int64) []byte {
 	buf := make([]byte, 0, len(tablePrefix)+len(recordPrefix)+8*2)
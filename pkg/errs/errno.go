@@ -82,16 +82,29 @@ var (
 
 // placement errors
 var (
-	ErrRuleContent   = errors.Normalize("invalid rule content, %s", errors.RFCCodeText("PD:placement:ErrRuleContent"))
-	ErrLoadRule      = errors.Normalize("load rule failed", errors.RFCCodeText("PD:placement:ErrLoadRule"))
-	ErrLoadRuleGroup = errors.Normalize("load rule group failed", errors.RFCCodeText("PD:placement:ErrLoadRuleGroup"))
-	ErrBuildRuleList = errors.Normalize("build rule list failed, %s", errors.RFCCodeText("PD:placement:ErrBuildRuleList"))
+	ErrRuleContent            = errors.Normalize("invalid rule content, %s", errors.RFCCodeText("PD:placement:ErrRuleContent"))
+	ErrLoadRule               = errors.Normalize("load rule failed", errors.RFCCodeText("PD:placement:ErrLoadRule"))
+	ErrLoadRuleGroup          = errors.Normalize("load rule group failed", errors.RFCCodeText("PD:placement:ErrLoadRuleGroup"))
+	ErrLoadLeaderAntiAffinity = errors.Normalize("load leader anti-affinity failed", errors.RFCCodeText("PD:placement:ErrLoadLeaderAntiAffinity"))
+	ErrBuildRuleList          = errors.Normalize("build rule list failed, %s", errors.RFCCodeText("PD:placement:ErrBuildRuleList"))
 )
 
 // cluster errors
 var (
-	ErrNotBootstrapped = errors.Normalize("TiKV cluster not bootstrapped, please start TiKV first", errors.RFCCodeText("PD:cluster:ErrNotBootstrapped"))
-	ErrStoreIsUp       = errors.Normalize("store is still up, please remove store gracefully", errors.RFCCodeText("PD:cluster:ErrStoreIsUp"))
+	ErrNotBootstrapped            = errors.Normalize("TiKV cluster not bootstrapped, please start TiKV first", errors.RFCCodeText("PD:cluster:ErrNotBootstrapped"))
+	ErrStoreIsUp                  = errors.Normalize("store is still up, please remove store gracefully", errors.RFCCodeText("PD:cluster:ErrStoreIsUp"))
+	ErrRegionLabelRule            = errors.Normalize("invalid region label rule, %s", errors.RFCCodeText("PD:cluster:ErrRegionLabelRule"))
+	ErrLoadRegionLabelRule        = errors.Normalize("load region label rule failed", errors.RFCCodeText("PD:cluster:ErrLoadRegionLabelRule"))
+	ErrUnsafeRecoveryIsRunning    = errors.Normalize("unsafe recovery is running", errors.RFCCodeText("PD:cluster:ErrUnsafeRecoveryIsRunning"))
+	ErrUnsafeRecoveryInvalidInput = errors.Normalize("invalid input %s", errors.RFCCodeText("PD:cluster:ErrUnsafeRecoveryInvalidInput"))
+)
+
+// job errors
+var (
+	ErrLoadJob             = errors.Normalize("load job failed", errors.RFCCodeText("PD:job:ErrLoadJob"))
+	ErrJobNotFound         = errors.Normalize("job %d not found", errors.RFCCodeText("PD:job:ErrJobNotFound"))
+	ErrJobTypeNotSupported = errors.Normalize("job type %s is not supported", errors.RFCCodeText("PD:job:ErrJobTypeNotSupported"))
+	ErrJobDone             = errors.Normalize("job %d is already %s", errors.RFCCodeText("PD:job:ErrJobDone"))
 )
 
 // versioninfo errors
@@ -168,6 +181,7 @@ var (
 	ErrEtcdURLMap        = errors.Normalize("etcd url map error", errors.RFCCodeText("PD:etcd:ErrEtcdURLMap"))
 	ErrEtcdGrantLease    = errors.Normalize("etcd lease failed", errors.RFCCodeText("PD:etcd:ErrEtcdGrantLease"))
 	ErrEtcdTxn           = errors.Normalize("etcd Txn failed", errors.RFCCodeText("PD:etcd:ErrEtcdTxn"))
+	ErrKVCondNotMet      = errors.Normalize("the conditions of the batch are not met", errors.RFCCodeText("PD:etcd:ErrKVCondNotMet"))
 	ErrEtcdKVPut         = errors.Normalize("etcd KV put failed", errors.RFCCodeText("PD:etcd:ErrEtcdKVPut"))
 	ErrEtcdKVDelete      = errors.Normalize("etcd KV delete failed", errors.RFCCodeText("PD:etcd:ErrEtcdKVDelete"))
 	ErrEtcdKVGet         = errors.Normalize("etcd KV get failed", errors.RFCCodeText("PD:etcd:ErrEtcdKVGet"))
@@ -205,6 +219,7 @@ var (
 	ErrSendRequest    = errors.Normalize("send HTTP request failed", errors.RFCCodeText("PD:http:ErrSendRequest"))
 	ErrWriteHTTPBody  = errors.Normalize("write HTTP body failed", errors.RFCCodeText("PD:http:ErrWriteHTTPBody"))
 	ErrNewHTTPRequest = errors.Normalize("new HTTP request failed", errors.RFCCodeText("PD:http:ErrNewHTTPRequest"))
+	ErrHTTPStatus     = errors.Normalize("unexpected HTTP status %v", errors.RFCCodeText("PD:http:ErrHTTPStatus"))
 )
 
 // ioutil error
@@ -18,11 +18,13 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/coreos/pkg/capnslog"
 	. "github.com/pingcap/check"
 	zaplog "github.com/pingcap/log"
 	log "github.com/sirupsen/logrus"
+	"github.com/tikv/pd/pkg/typeutil"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -162,3 +164,36 @@ func (s *testLogSuite) TestRedactLog(c *C) {
 		}
 	}
 }
+
+func (s *testLogSuite) TestLogSampling(c *C) {
+	defer SetLogSampling(DefaultLogSamplingConfig())
+	c.Assert(SetLogSampling(LogSamplingConfig{Enable: true, Thereafter: 1}), NotNil)
+	c.Assert(SetLogSampling(LogSamplingConfig{Enable: true, Interval: typeutil.NewDuration(time.Hour)}), NotNil)
+
+	cfg := LogSamplingConfig{Enable: true, Interval: typeutil.NewDuration(time.Hour), Initial: 2, Thereafter: 3}
+	c.Assert(SetLogSampling(cfg), IsNil)
+	c.Assert(GetLogSampling(), DeepEquals, cfg)
+	key := "test-sampling"
+	var logged []uint64
+	for i := 0; i < 10; i++ {
+		if ok, suppressed := Sample(key); ok {
+			logged = append(logged, suppressed)
+		}
+	}
+	// The 1st, 2nd, 5th and 8th messages are logged.
+	c.Assert(logged, DeepEquals, []uint64{0, 0, 2, 2})
+	c.Assert(GetSuppressedLogs()[key], Equals, uint64(6))
+
+	// The counters of the interval are reset by the new config.
+	c.Assert(SetLogSampling(cfg), IsNil)
+	ok, suppressed := Sample(key)
+	c.Assert(ok, IsTrue)
+	c.Assert(suppressed, Equals, uint64(2))
+
+	c.Assert(SetLogSampling(LogSamplingConfig{}), IsNil)
+	for i := 0; i < 10; i++ {
+		ok, _ := Sample(key)
+		c.Assert(ok, IsTrue)
+	}
+	c.Assert(GetSuppressedLogs()[key], Equals, uint64(6))
+}
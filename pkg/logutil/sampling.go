@@ -0,0 +1,146 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logutil
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/tikv/pd/pkg/typeutil"
+)
+
+// The keys of the sampled logs on the hot paths.
+const (
+	SampleKeyHeartbeatReject = "heartbeat-reject"
+	SampleKeyOperatorStep    = "operator-step"
+)
+
+// LogSamplingConfig is the config of sampling the logs on the hot paths, like
+// the rejected region heartbeats and the steps of the operators. In every
+// interval, the first Initial messages of a key are logged, then only every
+// Thereafter-th of them is logged and the others are suppressed.
+type LogSamplingConfig struct {
+	Enable     bool              `json:"enable"`
+	Interval   typeutil.Duration `json:"interval"`
+	Initial    uint64            `json:"initial"`
+	Thereafter uint64            `json:"thereafter"`
+}
+
+// Validate checks whether the config is valid.
+func (c *LogSamplingConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Interval.Duration <= 0 {
+		return errors.New("interval should be positive")
+	}
+	if c.Thereafter == 0 {
+		return errors.New("thereafter should be positive")
+	}
+	return nil
+}
+
+// DefaultLogSamplingConfig returns the default config of the log sampling,
+// the sampling is disabled by default so that no message is lost unless it is
+// turned on explicitly.
+func DefaultLogSamplingConfig() LogSamplingConfig {
+	return LogSamplingConfig{
+		Enable:     false,
+		Interval:   typeutil.NewDuration(time.Second),
+		Initial:    100,
+		Thereafter: 100,
+	}
+}
+
+// sampleCounter counts the messages of a key in the current interval.
+type sampleCounter struct {
+	start      time.Time
+	count      uint64
+	suppressed uint64 // since the last logged message
+	total      uint64 // since the process is started
+}
+
+var logSampler = struct {
+	sync.Mutex
+	cfg      LogSamplingConfig
+	counters map[string]*sampleCounter
+}{
+	cfg:      DefaultLogSamplingConfig(),
+	counters: make(map[string]*sampleCounter),
+}
+
+// SetLogSampling updates the config of the log sampling, the counters of the
+// current interval are reset.
+func SetLogSampling(cfg LogSamplingConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	logSampler.Lock()
+	defer logSampler.Unlock()
+	logSampler.cfg = cfg
+	for _, counter := range logSampler.counters {
+		counter.start, counter.count = time.Time{}, 0
+	}
+	return nil
+}
+
+// GetLogSampling returns the config of the log sampling.
+func GetLogSampling() LogSamplingConfig {
+	logSampler.Lock()
+	defer logSampler.Unlock()
+	return logSampler.cfg
+}
+
+// Sample reports whether a message of the key should be logged. If it should,
+// the number of the messages of the key suppressed since the last logged one
+// is returned as well, which is expected to be attached to the message.
+func Sample(key string) (bool, uint64) {
+	logSampler.Lock()
+	defer logSampler.Unlock()
+	if !logSampler.cfg.Enable {
+		return true, 0
+	}
+	counter, ok := logSampler.counters[key]
+	if !ok {
+		counter = &sampleCounter{}
+		logSampler.counters[key] = counter
+	}
+	now := time.Now()
+	if now.Sub(counter.start) >= logSampler.cfg.Interval.Duration {
+		counter.start, counter.count = now, 0
+	}
+	counter.count++
+	if counter.count > logSampler.cfg.Initial && (counter.count-logSampler.cfg.Initial)%logSampler.cfg.Thereafter != 0 {
+		counter.suppressed++
+		counter.total++
+		suppressedLogCounter.WithLabelValues(key).Inc()
+		return false, 0
+	}
+	suppressed := counter.suppressed
+	counter.suppressed = 0
+	return true, suppressed
+}
+
+// GetSuppressedLogs returns the total number of the suppressed messages of
+// every key.
+func GetSuppressedLogs() map[string]uint64 {
+	logSampler.Lock()
+	defer logSampler.Unlock()
+	suppressed := make(map[string]uint64, len(logSampler.counters))
+	for key, counter := range logSampler.counters {
+		suppressed[key] = counter.total
+	}
+	return suppressed
+}
@@ -40,6 +40,11 @@ func (mc *Cluster) SetEnableOneWayMerge(v bool) {
 	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.EnableOneWayMerge = v })
 }
 
+// SetEnableCrossTableMerge updates the EnableCrossTableMerge configuration.
+func (mc *Cluster) SetEnableCrossTableMerge(v bool) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.EnableCrossTableMerge = v })
+}
+
 // SetMaxSnapshotCount updates the MaxSnapshotCount configuration.
 func (mc *Cluster) SetMaxSnapshotCount(v int) {
 	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.MaxSnapshotCount = uint64(v) })
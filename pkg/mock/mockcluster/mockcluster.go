@@ -154,6 +154,15 @@ func (mc *Cluster) GetRuleManager() *placement.RuleManager {
 	return mc.RuleManager
 }
 
+// PutRegion puts a region and updates the leader anti-affinity indexes.
+func (mc *Cluster) PutRegion(region *core.RegionInfo) []*core.RegionInfo {
+	overlaps := mc.BasicCluster.PutRegion(region)
+	if mc.RuleManager != nil {
+		mc.RuleManager.UpdateAntiAffinityIndex(region, overlaps)
+	}
+	return overlaps
+}
+
 // SetStoreUp sets store state to be up.
 func (mc *Cluster) SetStoreUp(storeID uint64) {
 	store := mc.GetStore(storeID)
@@ -366,6 +375,13 @@ func (mc *Cluster) UpdateStoreRegionWeight(storeID uint64, weight float64) {
 	mc.PutStore(newStore)
 }
 
+// UpdateStoreUtilization updates store utilization.
+func (mc *Cluster) UpdateStoreUtilization(storeID uint64, utilization float64) {
+	store := mc.GetStore(storeID)
+	newStore := store.Clone(core.SetStoreUtilization(utilization))
+	mc.PutStore(newStore)
+}
+
 // UpdateStoreLeaderSize updates store leader size.
 func (mc *Cluster) UpdateStoreLeaderSize(storeID uint64, size int64) {
 	store := mc.GetStore(storeID)
@@ -526,6 +542,7 @@ func (mc *Cluster) UpdateStoreStatus(id uint64) {
 	leaderCount := mc.Regions.GetStoreLeaderCount(id)
 	regionCount := mc.Regions.GetStoreRegionCount(id)
 	pendingPeerCount := mc.Regions.GetStorePendingPeerCount(id)
+	downPeerCount := mc.Regions.GetStoreDownPeerCount(id)
 	leaderSize := mc.Regions.GetStoreLeaderRegionSize(id)
 	regionSize := mc.Regions.GetStoreRegionSize(id)
 	store := mc.Stores.GetStore(id)
@@ -538,6 +555,7 @@ func (mc *Cluster) UpdateStoreStatus(id uint64) {
 		core.SetLeaderCount(leaderCount),
 		core.SetRegionCount(regionCount),
 		core.SetPendingPeerCount(pendingPeerCount),
+		core.SetDownPeerCount(downPeerCount),
 		core.SetLeaderSize(leaderSize),
 		core.SetRegionSize(regionSize),
 	)
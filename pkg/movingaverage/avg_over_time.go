@@ -15,6 +15,7 @@ package movingaverage
 
 import (
 	"time"
+	"unsafe"
 
 	"github.com/phf/go-queue/queue"
 )
@@ -72,3 +73,10 @@ func (aot *AvgOverTime) Set(avg float64) {
 	aot.intervalSum = aot.avgInterval
 	aot.que.PushBack(deltaWithInterval{delta: aot.deltaSum, interval: aot.intervalSum})
 }
+
+// MemorySize returns the estimated memory usage in bytes, including the
+// recorded changes.
+func (aot *AvgOverTime) MemorySize() uint64 {
+	record := unsafe.Sizeof(interface{}(nil)) + unsafe.Sizeof(deltaWithInterval{})
+	return uint64(unsafe.Sizeof(*aot)+unsafe.Sizeof(*aot.que)) + uint64(aot.que.Len())*uint64(record)
+}
@@ -13,7 +13,11 @@
 
 package movingaverage
 
-import "github.com/montanaflynn/stats"
+import (
+	"unsafe"
+
+	"github.com/montanaflynn/stats"
+)
 
 // MedianFilter works as a median filter with specified window size.
 // There are at most `size` data points for calculating.
@@ -56,6 +60,11 @@ func (r *MedianFilter) Reset() {
 	r.count = 0
 }
 
+// MemorySize returns the estimated memory usage in bytes, including the records.
+func (r *MedianFilter) MemorySize() uint64 {
+	return uint64(unsafe.Sizeof(*r)) + uint64(cap(r.records))*uint64(unsafe.Sizeof(float64(0)))
+}
+
 // Set = Reset + Add.
 func (r *MedianFilter) Set(n float64) {
 	r.records[0] = n
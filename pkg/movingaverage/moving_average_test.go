@@ -112,3 +112,16 @@ func (t *testMovingAvg) TestMovingAvg(c *C) {
 		checkSet(c, test.ma, data, test.expected)
 	}
 }
+
+func (t *testMovingAvg) TestTimeMedianMemorySize(c *C) {
+	small := NewTimeMedian(2, 1, 10)
+	large := NewTimeMedian(2, 5, 10)
+	c.Assert(large.MemorySize()-small.MemorySize(), Equals, uint64(4*8))
+
+	// The changes recorded by AvgOverTime are counted until they are averaged.
+	size := large.MemorySize()
+	large.Add(100, 10*time.Second)
+	c.Assert(large.MemorySize(), Greater, size)
+	large.Add(100, 10*time.Second)
+	c.Assert(large.MemorySize(), Equals, size)
+}
@@ -13,7 +13,10 @@
 
 package movingaverage
 
-import "time"
+import (
+	"time"
+	"unsafe"
+)
 
 // TimeMedian is AvgOverTime + MedianFilter
 // Size of MedianFilter should be larger than double size of AvgOverTime to denoisy.
@@ -61,6 +64,12 @@ func (t *TimeMedian) Set(avg float64) {
 	t.mf.Set(avg)
 }
 
+// MemorySize returns the estimated memory usage in bytes, including the
+// histories kept by AvgOverTime and MedianFilter.
+func (t *TimeMedian) MemorySize() uint64 {
+	return uint64(unsafe.Sizeof(*t)) + t.aot.MemorySize() + t.mf.MemorySize()
+}
+
 // GetFilledPeriod returns filled period.
 func (t *TimeMedian) GetFilledPeriod() int { // it is unrelated with mfSize
 	return t.aotSize
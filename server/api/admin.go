@@ -14,25 +14,36 @@
 package api
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server"
 	"github.com/unrolled/render"
 )
 
+const (
+	defaultDrainTimeout          = 30 * time.Second
+	defaultUnsafeRecoveryTimeout = 10 * time.Minute
+)
+
 type adminHandler struct {
-	svr *server.Server
-	rd  *render.Render
+	svr    *server.Server
+	rd     *render.Render
+	tokens *confirmTokens
 }
 
 func newAdminHandler(svr *server.Server, rd *render.Render) *adminHandler {
 	return &adminHandler{
-		svr: svr,
-		rd:  rd,
+		svr:    svr,
+		rd:     rd,
+		tokens: newConfirmTokens(),
 	}
 }
 
@@ -141,3 +152,116 @@ func (h *adminHandler) UpdateWaitAsyncTime(w http.ResponseWriter, r *http.Reques
 	cluster.GetReplicationMode().UpdateMemberWaitAsyncTime(memberID)
 	h.rd.JSON(w, http.StatusOK, nil)
 }
+
+// @Tags admin
+// @Summary Drain the PD server before shutdown: hand off the leadership, stop accepting new TSO and heartbeat streams and wait for the in-flight ones.
+// @Param timeout query string false "How long to wait for the in-flight streams, 30s by default"
+// @Produce json
+// @Success 200 {object} server.DrainStatus
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /admin/drain [post]
+func (h *adminHandler) Drain(w http.ResponseWriter, r *http.Request) {
+	timeout := defaultDrainTimeout
+	if timeoutStr := r.URL.Query().Get("timeout"); timeoutStr != "" {
+		var err error
+		timeout, err = time.ParseDuration(timeoutStr)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	status, err := h.svr.Drain(ctx)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, status)
+}
+
+// @Tags admin
+// @Summary Cancel the draining of the PD server, it accepts new TSO and heartbeat streams and campaigns for the leadership again.
+// @Produce json
+// @Success 200 {object} server.DrainStatus
+// @Router /admin/drain [delete]
+func (h *adminHandler) Undrain(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.Undrain())
+}
+
+// @Tags admin
+// @Summary Get the draining progress of the PD server.
+// @Produce json
+// @Success 200 {object} server.DrainStatus
+// @Router /admin/drain [get]
+func (h *adminHandler) GetDrainStatus(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.GetDrainStatus())
+}
+
+// @Tags admin
+// @Summary Plan removing the failed stores unsafely: return the tikv-ctl commands to recover the regions which lose the majority of the voters, which must be run by the user on the surviving stores, and monitor the progress in a job.
+// @Accept json
+// @Param body body object true "json params, e.g. {\"stores\": [1, 2], \"timeout\": \"10m\"}"
+// @Param PD-Confirm header string false "Opt in the two-phase confirmation of the recovery"
+// @Param confirm_token query string false "The token to confirm the recovery"
+// @Produce json
+// @Success 200 {object} cluster.UnsafeRecoveryStatus
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 409 {string} string "An unsafe recovery is running."
+// @Failure 428 {object} ConfirmTokenResponse "The recovery needs to be confirmed, only if the confirmation is opted in."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /admin/unsafe/plan-remove-failed-stores [post]
+func (h *adminHandler) PlanRemoveFailedStores(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	var input struct {
+		Stores  []uint64 `json:"stores"`
+		Timeout string   `json:"timeout"`
+	}
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	if !h.tokens.confirmed(h.rd, w, r, fmt.Sprintf("plan removing failed stores %v", input.Stores)) {
+		return
+	}
+	timeout := defaultUnsafeRecoveryTimeout
+	if input.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(input.Timeout)
+		if err != nil || timeout <= 0 {
+			h.rd.JSON(w, http.StatusBadRequest, "invalid timeout")
+			return
+		}
+	}
+	status, err := rc.PlanRemoveFailedStores(input.Stores, timeout)
+	switch {
+	case err == nil:
+		h.rd.JSON(w, http.StatusOK, status)
+	case errs.ErrUnsafeRecoveryInvalidInput.Equal(err), errs.ErrStoreNotFound.Equal(err):
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+	case errs.ErrUnsafeRecoveryIsRunning.Equal(err):
+		h.rd.JSON(w, http.StatusConflict, err.Error())
+	default:
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// @Tags admin
+// @Summary Get the progress of the latest unsafe recovery.
+// @Produce json
+// @Success 200 {object} cluster.UnsafeRecoveryStatus
+// @Failure 404 {string} string "There is no unsafe recovery."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /admin/unsafe/plan-remove-failed-stores/show [get]
+func (h *adminHandler) GetUnsafeRecoveryStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := getCluster(r.Context()).GetUnsafeRecoveryStatus()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if status == nil {
+		h.rd.JSON(w, http.StatusNotFound, "there is no unsafe recovery")
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, status)
+}
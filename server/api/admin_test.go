@@ -22,8 +22,11 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/job"
 )
 
 var _ = Suite(&testAdminSuite{})
@@ -83,6 +86,58 @@ func (s *testAdminSuite) TestDropRegion(c *C) {
 	c.Assert(region.GetRegionEpoch().Version, Equals, uint64(50))
 }
 
+func (s *testAdminSuite) TestUnsafeRecovery(c *C) {
+	url := s.urlPrefix + "/admin/unsafe/plan-remove-failed-stores"
+	status, _ := requestStatusBody(c, testDialClient, http.MethodGet, url+"/show")
+	c.Assert(status, Equals, http.StatusNotFound)
+
+	for _, data := range []string{
+		`{"stores": []}`,
+		`{"stores": [100]}`,
+		`{"stores": [1], "timeout": "10"}`,
+	} {
+		c.Assert(postJSON(testDialClient, url, []byte(data)), NotNil, Commentf(data))
+	}
+
+	// The recovery is not started until it is confirmed if the confirmation
+	// is opted in.
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(`{"stores": [1]}`))
+	c.Assert(err, IsNil)
+	req.Header.Set(confirmHeader, "two-phase")
+	resp, err := testDialClient.Do(req)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusPreconditionRequired)
+	status, _ = requestStatusBody(c, testDialClient, http.MethodGet, url+"/show")
+	c.Assert(status, Equals, http.StatusNotFound)
+
+	// The bootstrapped store has never sent heartbeats, so the only region
+	// loses all its peers.
+	var recovery cluster.UnsafeRecoveryStatus
+	resp, err = testDialClient.Post(url, "application/json", strings.NewReader(`{"stores": [1], "timeout": "1m"}`))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	c.Assert(json.NewDecoder(resp.Body).Decode(&recovery), IsNil)
+	resp.Body.Close()
+	c.Assert(recovery.Stage, Equals, cluster.UnsafeRecoveryRecovering)
+	c.Assert(recovery.FailedStores, DeepEquals, []uint64{1})
+	c.Assert(recovery.LostRegions, HasLen, 1)
+	c.Assert(recovery.Plans, HasLen, 0)
+	c.Assert(recovery.Regions, Equals, 0)
+	c.Assert(recovery.JobID, Not(Equals), uint64(0))
+
+	// The lost region can only be recreated, so the recovery is finished.
+	testutil.WaitUntil(c, func(c *C) bool {
+		c.Assert(readJSON(testDialClient, url+"/show", &recovery), IsNil)
+		return recovery.Stage == cluster.UnsafeRecoveryFinished
+	})
+	c.Assert(recovery.LostRegions, HasLen, 1)
+	var j job.Job
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/jobs/%d", s.urlPrefix, recovery.JobID), &j), IsNil)
+	c.Assert(j.Type, Equals, cluster.UnsafeRecoveryJob)
+	c.Assert(j.State, Equals, job.Finished)
+}
+
 var _ = Suite(&testTSOSuite{})
 
 type testTSOSuite struct {
@@ -15,6 +15,7 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/tikv/pd/server"
 	"github.com/unrolled/render"
@@ -55,3 +56,78 @@ func (h *clusterHandler) GetClusterStatus(w http.ResponseWriter, r *http.Request
 	}
 	h.rd.JSON(w, http.StatusOK, status)
 }
+
+// @Tags cluster
+// @Summary Get the status of the import mode.
+// @Produce json
+// @Success 200 {object} server.ImportModeStatus
+// @Router /cluster/import-mode [get]
+func (h *clusterHandler) GetImportMode(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.GetImportModeStatus())
+}
+
+// @Tags cluster
+// @Summary Enter the import mode, the configuration optimized for importing data is applied until the ttl expires.
+// @Param ttl query string true "The duration of the import mode, like 6h."
+// @Produce json
+// @Success 200 {object} server.ImportModeStatus
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /cluster/import-mode [post]
+func (h *clusterHandler) EnableImportMode(w http.ResponseWriter, r *http.Request) {
+	ttl, err := time.ParseDuration(r.URL.Query().Get("ttl"))
+	if err != nil || ttl < time.Second {
+		h.rd.JSON(w, http.StatusBadRequest, "invalid ttl, should be a duration no less than 1s")
+		return
+	}
+	status, err := h.svr.EnableImportMode(ttl)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, status)
+}
+
+// @Tags cluster
+// @Summary Exit the import mode.
+// @Produce json
+// @Success 200 {string} string "The import mode is disabled."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /cluster/import-mode [delete]
+func (h *clusterHandler) DisableImportMode(w http.ResponseWriter, r *http.Request) {
+	if err := h.svr.DisableImportMode(); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The import mode is disabled.")
+}
+
+// @Tags cluster
+// @Summary Cross-check the stores persisted in the storage, the stores in memory, the peers of the regions and the PD members.
+// @Produce json
+// @Success 200 {object} cluster.ConsistencyReport
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /cluster/consistency [get]
+func (h *clusterHandler) CheckConsistency(w http.ResponseWriter, r *http.Request) {
+	report, err := h.svr.CheckConsistency()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, report)
+}
+
+// @Tags cluster
+// @Summary Persist the unpersisted stores again and remove the orphaned store limits and member metadata, it returns what is cleaned up.
+// @Produce json
+// @Success 200 {object} cluster.ConsistencyReport
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /cluster/consistency/cleanup [post]
+func (h *clusterHandler) CleanupInconsistency(w http.ResponseWriter, r *http.Request) {
+	report, err := h.svr.CleanupInconsistency()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, report)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core/storelimit"
 )
 
 var _ = Suite(&testClusterSuite{})
@@ -89,3 +90,64 @@ func (s *testClusterSuite) testGetClusterStatus(c *C) {
 	c.Assert(status.RaftBootstrapTime.After(now), IsTrue)
 	c.Assert(status.IsInitialized, IsTrue)
 }
+
+var _ = Suite(&testImportModeSuite{})
+
+type testImportModeSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testImportModeSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testImportModeSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testImportModeSuite) TestImportMode(c *C) {
+	mustPutStore(c, s.svr, 1, metapb.StoreState_Up, nil)
+	url := fmt.Sprintf("%s/cluster/import-mode", s.urlPrefix)
+	status := &server.ImportModeStatus{}
+	c.Assert(readJSON(testDialClient, url, status), IsNil)
+	c.Assert(status.Enabled, IsFalse)
+	opt := s.svr.GetPersistOptions()
+	mergeLimit := opt.GetMergeScheduleLimit()
+
+	c.Assert(postJSON(testDialClient, url, nil), NotNil)
+	c.Assert(postJSON(testDialClient, url+"?ttl=foo", nil), NotNil)
+	c.Assert(postJSON(testDialClient, url+"?ttl=1h", nil), IsNil)
+	c.Assert(readJSON(testDialClient, url, status), IsNil)
+	c.Assert(status.Enabled, IsTrue)
+	c.Assert(status.ExpireTime.After(time.Now().Add(59*time.Minute)), IsTrue)
+	c.Assert(status.Settings["schedule.merge-schedule-limit"], Equals, "0")
+	c.Assert(opt.GetMergeScheduleLimit(), Equals, uint64(0))
+	c.Assert(opt.GetMaxSnapshotCount(), Equals, uint64(64))
+	c.Assert(opt.GetStoreLimitByType(1, storelimit.AddPeer), Equals, float64(200))
+	// The flow control hints are shown in the config of the stores.
+	items := make(map[string]string)
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/store/1/config", s.urlPrefix), &items), IsNil)
+	c.Assert(items["import-mode"], Equals, "true")
+	c.Assert(items["storage.flow-control.l0-files-threshold"], Equals, "60")
+
+	// The temporary configuration changed by others is kept after disabling.
+	c.Assert(s.svr.SaveTTLConfig(map[string]interface{}{"schedule.max-snapshot-count": 32}, time.Hour), IsNil)
+	_, err := doDelete(testDialClient, url)
+	c.Assert(err, IsNil)
+	status = &server.ImportModeStatus{}
+	c.Assert(readJSON(testDialClient, url, status), IsNil)
+	c.Assert(status.Enabled, IsFalse)
+	c.Assert(opt.GetMergeScheduleLimit(), Equals, mergeLimit)
+	c.Assert(opt.GetMaxSnapshotCount(), Equals, uint64(32))
+	items = make(map[string]string)
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/store/1/config", s.urlPrefix), &items), IsNil)
+	c.Assert(items, HasLen, 0)
+}
@@ -26,11 +26,10 @@ import (
 
 	"github.com/pingcap/errcode"
 	"github.com/pingcap/errors"
-	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/apiutil"
-	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/versioninfo"
 	"github.com/unrolled/render"
 )
 
@@ -55,6 +54,36 @@ func (h *confHandler) Get(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, h.svr.GetConfig())
 }
 
+// @Tags config
+// @Summary Get the config items with their sources, like default, file, flag, persisted or ttl, and the last time they are changed.
+// @Produce json
+// @Success 200 {object} map[string]config.ItemSource
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/source [get]
+func (h *confHandler) GetSource(w http.ResponseWriter, r *http.Request) {
+	sources, err := h.svr.GetConfigItemSources()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, sources)
+}
+
+// @Tags config
+// @Summary Get the persisted config items which differ from the default values, with their sources and the last time they are changed.
+// @Produce json
+// @Success 200 {object} map[string]config.ItemDiff
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/diff [get]
+func (h *confHandler) GetDiff(w http.ResponseWriter, r *http.Request) {
+	diffs, err := h.svr.GetConfigItemDiffs()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, diffs)
+}
+
 // @Tags config
 // @Summary Get default config.
 // @Produce json
@@ -73,9 +102,10 @@ func (h *confHandler) GetDefault(w http.ResponseWriter, r *http.Request) {
 
 // FIXME: details of input json body params
 // @Tags config
-// @Summary Update a config item.
+// @Summary Update config items. All the items are applied or none of them is applied.
 // @Accept json
 // @Param ttlSecond query integer false "ttl". ttl param is only for BR and lightning now. Don't use it.
+// @Param diff query boolean false "Return the changed config items instead of a message."
 // @Param body body object false "json params"
 // @Produce json
 // @Success 200 {string} string "The config is updated."
@@ -118,46 +148,69 @@ func (h *confHandler) Post(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	old := h.svr.GetConfig()
 	for k, v := range conf {
-		if s := strings.Split(k, "."); len(s) > 1 {
-			if err := h.updateConfig(cfg, k, v); err != nil {
-				h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		key := k
+		if s := strings.Split(k, "."); len(s) == 1 {
+			if key = findTag(reflect.TypeOf(config.Config{}), k); key == "" {
+				h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("config item %s not found", k))
 				return
 			}
-			continue
 		}
-		key := findTag(reflect.TypeOf(config.Config{}), k)
-		if key == "" {
-			h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("config item %s not found", k))
-			return
-		}
-		if err := h.updateConfig(cfg, key, v); err != nil {
+		if err := h.mergeConfigItem(cfg, key, v); err != nil {
 			h.rd.JSON(w, http.StatusBadRequest, err.Error())
 			return
 		}
 	}
+	if err := h.svr.SetConfig(cfg); err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
+	if diff, _ := strconv.ParseBool(r.URL.Query().Get("diff")); diff {
+		h.rd.JSON(w, http.StatusOK, diffConfig(old, h.svr.GetConfig()))
+		return
+	}
 	h.rd.JSON(w, http.StatusOK, "The config is updated.")
 }
 
-func (h *confHandler) updateConfig(cfg *config.Config, key string, value interface{}) error {
+// mergeConfigItem merges a config item into the config without applying it, so
+// that all the items of a request are applied or none of them is applied.
+func (h *confHandler) mergeConfigItem(cfg *config.Config, key string, value interface{}) error {
 	kp := strings.Split(key, ".")
 	switch kp[0] {
 	case "schedule":
-		return h.updateSchedule(cfg, kp[len(kp)-1], value)
+		return mergeConfigSection(&cfg.Schedule, map[string]interface{}{kp[len(kp)-1]: value}, kp[len(kp)-1])
 	case "replication":
-		return h.updateReplication(cfg, kp[len(kp)-1], value)
+		return mergeConfigSection(&cfg.Replication, map[string]interface{}{kp[len(kp)-1]: value}, kp[len(kp)-1])
 	case "replication-mode":
 		if len(kp) < 2 {
 			return errors.Errorf("cannot update config prefix %s", kp[0])
 		}
-		return h.updateReplicationModeConfig(cfg, kp[1:], value)
+		return mergeConfigSection(&cfg.ReplicationMode, getConfigMap(make(map[string]interface{}), kp[1:], value), kp[1:])
 	case "pd-server":
-		return h.updatePDServerConfig(cfg, kp[len(kp)-1], value)
+		return mergeConfigSection(&cfg.PDServerCfg, map[string]interface{}{kp[len(kp)-1]: value}, kp[len(kp)-1])
 	case "log":
-		return h.updateLogLevel(kp, value)
+		if len(kp) != 2 || kp[1] != "level" {
+			return errors.Errorf("only support changing log level")
+		}
+		level, ok := value.(string)
+		if !ok {
+			return errors.Errorf("input value %v is illegal", value)
+		}
+		cfg.Log.Level = level
+		return nil
 	case "cluster-version":
-		return h.updateClusterVersion(value)
+		v, ok := value.(string)
+		if !ok {
+			return errors.Errorf("input value %v is illegal", value)
+		}
+		version, err := versioninfo.ParseVersion(v)
+		if err != nil {
+			return err
+		}
+		cfg.ClusterVersion = *version
+		return nil
 	case "label-property": // TODO: support changing label-property
 	}
 	return errors.Errorf("config prefix %s not found", kp[0])
@@ -186,116 +239,37 @@ func findTag(t reflect.Type, tag string) string {
 	return ""
 }
 
-func (h *confHandler) updateSchedule(config *config.Config, key string, value interface{}) error {
-	data, err := json.Marshal(map[string]interface{}{key: value})
+func mergeConfigSection(v interface{}, item map[string]interface{}, key interface{}) error {
+	data, err := json.Marshal(item)
 	if err != nil {
 		return err
 	}
-
-	updated, found, err := h.mergeConfig(&config.Schedule, data)
+	_, found, err := mergeConfig(v, data)
 	if err != nil {
 		return err
 	}
-
 	if !found {
 		return errors.Errorf("config item %s not found", key)
 	}
-
-	if updated {
-		err = h.svr.SetScheduleConfig(config.Schedule)
-	}
-	return err
+	return nil
 }
 
-func (h *confHandler) updateReplication(config *config.Config, key string, value interface{}) error {
-	data, err := json.Marshal(map[string]interface{}{key: value})
-	if err != nil {
-		return err
-	}
-
-	updated, found, err := h.mergeConfig(&config.Replication, data)
-	if err != nil {
-		return err
-	}
-
-	if !found {
-		return errors.Errorf("config item %s not found", key)
-	}
-
-	if updated {
-		err = h.svr.SetReplicationConfig(config.Replication)
-	}
-	return err
+// ConfigChange is the old and new values of a changed config item.
+type ConfigChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
 }
 
-func (h *confHandler) updateReplicationModeConfig(config *config.Config, key []string, value interface{}) error {
-	cfg := make(map[string]interface{})
-	cfg = getConfigMap(cfg, key, value)
-	data, err := json.Marshal(cfg)
-	if err != nil {
-		return err
-	}
-
-	updated, found, err := h.mergeConfig(&config.ReplicationMode, data)
-	if err != nil {
-		return err
-	}
-
-	if !found {
-		return errors.Errorf("config item %s not found", key)
-	}
-
-	if updated {
-		err = h.svr.SetReplicationModeConfig(config.ReplicationMode)
-	}
-	return err
-}
-
-func (h *confHandler) updatePDServerConfig(config *config.Config, key string, value interface{}) error {
-	data, err := json.Marshal(map[string]interface{}{key: value})
-	if err != nil {
-		return err
-	}
-
-	updated, found, err := h.mergeConfig(&config.PDServerCfg, data)
-	if err != nil {
-		return err
-	}
-
-	if !found {
-		return errors.Errorf("config item %s not found", key)
-	}
-
-	if updated {
-		err = h.svr.SetPDServerConfig(config.PDServerCfg)
-	}
-	return err
-}
-
-func (h *confHandler) updateLogLevel(kp []string, value interface{}) error {
-	if len(kp) != 2 || kp[1] != "level" {
-		return errors.Errorf("only support changing log level")
-	}
-	if level, ok := value.(string); ok {
-		err := h.svr.SetLogLevel(level)
-		if err != nil {
-			return err
+// diffConfig returns the changed config items, keyed by their full names.
+func diffConfig(old, cfg *config.Config) map[string]ConfigChange {
+	oldItems, newItems := config.FlattenConfig(old), config.FlattenConfig(cfg)
+	diff := make(map[string]ConfigChange)
+	for k, v := range newItems {
+		if o := oldItems[k]; !reflect.DeepEqual(o, v) {
+			diff[k] = ConfigChange{Old: o, New: v}
 		}
-		log.SetLevel(logutil.StringToZapLogLevel(level))
-		return nil
-	}
-	return errors.Errorf("input value %v is illegal", value)
-}
-
-func (h *confHandler) updateClusterVersion(value interface{}) error {
-	if version, ok := value.(string); ok {
-		err := h.svr.SetClusterVersion(version)
-		if err != nil {
-			return err
-		}
-		return nil
 	}
-	return errors.Errorf("input value %v is illegal", value)
+	return diff
 }
 
 func getConfigMap(cfg map[string]interface{}, key []string, value interface{}) map[string]interface{} {
@@ -309,7 +283,7 @@ func getConfigMap(cfg map[string]interface{}, key []string, value interface{}) m
 	return cfg
 }
 
-func (h *confHandler) mergeConfig(v interface{}, data []byte) (updated bool, found bool, err error) {
+func mergeConfig(v interface{}, data []byte) (updated bool, found bool, err error) {
 	old, _ := json.Marshal(v)
 	if err := json.Unmarshal(data, v); err != nil {
 		return false, false, err
@@ -39,6 +39,7 @@ func (s *testConfigSuite) SetUpSuite(c *C) {
 		cfg.Replication.EnablePlacementRules = false
 	})
 	mustWaitLeader(c, []*server.Server{s.svr})
+	mustBootstrapCluster(c, s.svr)
 
 	addr := s.svr.GetAddr()
 	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
@@ -147,6 +148,83 @@ func (s *testConfigSuite) TestConfigAll(c *C) {
 	c.Assert(strings.Contains(err.Error(), "not found"), IsTrue)
 }
 
+func (s *testConfigSuite) TestConfigAtomic(c *C) {
+	addr := fmt.Sprintf("%s/config", s.urlPrefix)
+	cfg := &config.Config{}
+	err := readJSON(testDialClient, addr, cfg)
+	c.Assert(err, IsNil)
+
+	// The items are not applied if any of them is not found.
+	l := map[string]interface{}{
+		"schedule.leader-schedule-limit": cfg.Schedule.LeaderScheduleLimit + 1,
+		"schedule.region-limit":          10,
+	}
+	postData, err := json.Marshal(l)
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, addr, postData)
+	c.Assert(strings.Contains(err.Error(), "not found"), IsTrue)
+
+	// Nothing is applied if any of the merged sections is invalid.
+	l = map[string]interface{}{
+		"replication.max-replicas": cfg.Replication.MaxReplicas + 2,
+		"schedule.low-space-ratio": 0.1,
+	}
+	postData, err = json.Marshal(l)
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, addr, postData)
+	c.Assert(strings.Contains(err.Error(), "low-space-ratio"), IsTrue)
+	newCfg := &config.Config{}
+	err = readJSON(testDialClient, addr, newCfg)
+	c.Assert(err, IsNil)
+	c.Assert(newCfg, DeepEquals, cfg)
+
+	l = map[string]interface{}{
+		"schedule.leader-schedule-limit": cfg.Schedule.LeaderScheduleLimit + 1,
+		"pd-server.metric-storage":       "http://127.0.0.1:5678",
+		"log.level":                      "foo",
+	}
+	postData, err = json.Marshal(l)
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, addr, postData)
+	c.Assert(strings.Contains(err.Error(), "illegal"), IsTrue)
+	newCfg = &config.Config{}
+	err = readJSON(testDialClient, addr, newCfg)
+	c.Assert(err, IsNil)
+	c.Assert(newCfg, DeepEquals, cfg)
+
+	// The placement rules are not initialized if another section is invalid.
+	l = map[string]interface{}{
+		"replication.enable-placement-rules": "true",
+		"pd-server.dashboard-address":        "http://127.0.0.1:1",
+	}
+	postData, err = json.Marshal(l)
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, addr, postData)
+	c.Assert(strings.Contains(err.Error(), "is not the client url"), IsTrue, Commentf("%v", err))
+	c.Assert(s.svr.GetRaftCluster().GetRuleManager().IsInitialized(), IsFalse)
+	newCfg = &config.Config{}
+	err = readJSON(testDialClient, addr, newCfg)
+	c.Assert(err, IsNil)
+	c.Assert(newCfg, DeepEquals, cfg)
+
+	// The changed items are returned.
+	l = map[string]interface{}{
+		"schedule.leader-schedule-limit": cfg.Schedule.LeaderScheduleLimit + 1,
+		"region-schedule-limit":          cfg.Schedule.RegionScheduleLimit,
+	}
+	postData, err = json.Marshal(l)
+	c.Assert(err, IsNil)
+	var diff map[string]ConfigChange
+	err = postJSON(testDialClient, addr+"?diff=true", postData, func(res []byte, code int) {
+		c.Assert(json.Unmarshal(res, &diff), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(diff, HasLen, 1)
+	change := diff["schedule.leader-schedule-limit"]
+	c.Assert(change.Old, Equals, float64(cfg.Schedule.LeaderScheduleLimit))
+	c.Assert(change.New, Equals, float64(cfg.Schedule.LeaderScheduleLimit+1))
+}
+
 func (s *testConfigSuite) TestConfigSchedule(c *C) {
 	addr := fmt.Sprintf("%s/config/schedule", s.urlPrefix)
 	sc := &config.ScheduleConfig{}
@@ -304,6 +382,30 @@ func assertTTLConfig(c *C, options *config.PersistOptions, checker Checker) {
 	c.Assert(options.GetMergeScheduleLimit(), checker, uint64(999))
 }
 
+func (s *testConfigSuite) TestConfigSource(c *C) {
+	start := time.Now()
+	postData, err := json.Marshal(map[string]interface{}{"max-pending-peer-count": 100})
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, fmt.Sprintf("%s/config", s.urlPrefix), postData)
+	c.Assert(err, IsNil)
+
+	sources := make(map[string]*config.ItemSource)
+	err = readJSON(testDialClient, fmt.Sprintf("%s/config/source", s.urlPrefix), &sources)
+	c.Assert(err, IsNil)
+	source := sources["schedule.max-pending-peer-count"]
+	c.Assert(source.Source, Equals, config.SourcePersisted)
+	c.Assert(source.Value, Equals, 100.0)
+	c.Assert(source.UpdateTime.Before(start), IsFalse)
+
+	diffs := make(map[string]*config.ItemDiff)
+	err = readJSON(testDialClient, fmt.Sprintf("%s/config/diff", s.urlPrefix), &diffs)
+	c.Assert(err, IsNil)
+	diff := diffs["schedule.max-pending-peer-count"]
+	c.Assert(diff.Value, Equals, 100.0)
+	c.Assert(diff.Default, Equals, float64(config.NewTestOptions().GetMaxPendingPeerCount()))
+	c.Assert(diff.UpdateTime, NotNil)
+}
+
 func (s *testConfigSuite) TestConfigTTL(c *C) {
 	addr := fmt.Sprintf("%s/config?ttlSecond=1", s.urlPrefix)
 	postData, err := json.Marshal(ttlConfig)
@@ -311,6 +413,11 @@ func (s *testConfigSuite) TestConfigTTL(c *C) {
 	err = postJSON(testDialClient, addr, postData)
 	c.Assert(err, IsNil)
 	assertTTLConfig(c, s.svr.GetPersistOptions(), Equals)
+	sources := make(map[string]*config.ItemSource)
+	err = readJSON(testDialClient, fmt.Sprintf("%s/config/source", s.urlPrefix), &sources)
+	c.Assert(err, IsNil)
+	c.Assert(sources["schedule.max-snapshot-count"].Source, Equals, config.SourceTTL)
+	c.Assert(sources["schedule.max-snapshot-count"].Value, Equals, "999")
 	time.Sleep(2 * time.Second)
 	assertTTLConfig(c, s.svr.GetPersistOptions(), Not(Equals))
 }
@@ -0,0 +1,118 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/unrolled/render"
+)
+
+const (
+	// confirmHeader opts in the two-phase confirmation, the requests without
+	// it are handled as before, so the existing clients are not affected.
+	confirmHeader     = "PD-Confirm"
+	confirmTokenParam = "confirm_token"
+	confirmTokenTTL   = time.Minute
+)
+
+// ConfirmTokenResponse is returned when an operation needs to be confirmed by
+// requesting it again with the token.
+type ConfirmTokenResponse struct {
+	Message      string    `json:"message"`
+	ConfirmToken string    `json:"confirm_token"`
+	ExpireTime   time.Time `json:"expire_time"`
+}
+
+type confirmToken struct {
+	action string
+	expire time.Time
+}
+
+// confirmTokens guards the dangerous operations with two phases. The first
+// request gets a token, and the operation is only done when the same request is
+// sent again with the token before it expires.
+type confirmTokens struct {
+	sync.Mutex
+	tokens map[string]confirmToken
+}
+
+func newConfirmTokens() *confirmTokens {
+	return &confirmTokens{tokens: make(map[string]confirmToken)}
+}
+
+func (t *confirmTokens) issue(action string, now time.Time) (string, time.Time, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(b)
+	expire := now.Add(confirmTokenTTL)
+
+	t.Lock()
+	defer t.Unlock()
+	for k, v := range t.tokens {
+		if now.After(v.expire) {
+			delete(t.tokens, k)
+		}
+	}
+	t.tokens[token] = confirmToken{action: action, expire: expire}
+	return token, expire, nil
+}
+
+// consume checks whether the token is issued for the action, a token can only
+// be used once.
+func (t *confirmTokens) consume(token, action string, now time.Time) bool {
+	t.Lock()
+	defer t.Unlock()
+	v, ok := t.tokens[token]
+	if !ok || v.action != action {
+		return false
+	}
+	delete(t.tokens, token)
+	return !now.After(v.expire)
+}
+
+// confirmed returns true if the request carries a valid token for the action,
+// or it neither carries a token nor opts in the confirmation by the header.
+// Otherwise it responds with a new token or an error, and the caller should
+// stop handling the request.
+func (t *confirmTokens) confirmed(rd *render.Render, w http.ResponseWriter, r *http.Request, action string) bool {
+	now := time.Now()
+	if token := r.URL.Query().Get(confirmTokenParam); token != "" {
+		if t.consume(token, action, now) {
+			return true
+		}
+		rd.JSON(w, http.StatusBadRequest, "the confirm token is invalid or expired")
+		return false
+	}
+	if r.Header.Get(confirmHeader) == "" {
+		return true
+	}
+	token, expire, err := t.issue(action, now)
+	if err != nil {
+		rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	rd.JSON(w, http.StatusPreconditionRequired, &ConfirmTokenResponse{
+		Message:      "Please confirm to " + action + " by sending the request again with the confirm token.",
+		ConfirmToken: token,
+		ExpireTime:   expire,
+	})
+	return false
+}
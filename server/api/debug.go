@@ -0,0 +1,88 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"runtime"
+
+	"github.com/tikv/pd/pkg/cgroup"
+	"github.com/tikv/pd/server"
+	"github.com/unrolled/render"
+)
+
+type debugHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newDebugHandler(svr *server.Server, rd *render.Render) *debugHandler {
+	return &debugHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// RuntimeInfo contains the resource limits and utilization of the PD process.
+type RuntimeInfo struct {
+	Limits        cgroup.Limits `json:"limits"`
+	Usage         cgroup.Usage  `json:"usage"`
+	ThrottleRatio float64       `json:"throttle_ratio"`
+	GoMaxProcs    int           `json:"go_max_procs"`
+	NumGoroutine  int           `json:"num_goroutine"`
+	HeapAlloc     uint64        `json:"heap_alloc"`
+	HeapSys       uint64        `json:"heap_sys"`
+	// MemoryUtilization is the ratio of the cgroup memory usage to its limit,
+	// it is 0 when there is no memory limit.
+	MemoryUtilization float64 `json:"memory_utilization"`
+}
+
+// @Tags debug
+// @Summary Get the resource limits and utilization of the PD process.
+// @Produce json
+// @Success 200 {object} RuntimeInfo
+// @Router /debug/runtime [get]
+func (h *debugHandler) GetRuntime(w http.ResponseWriter, r *http.Request) {
+	limits := cgroup.GetLimits()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	info := &RuntimeInfo{
+		Limits:        limits,
+		Usage:         cgroup.GetUsage(),
+		ThrottleRatio: limits.CPURatio(),
+		GoMaxProcs:    runtime.GOMAXPROCS(0),
+		NumGoroutine:  runtime.NumGoroutine(),
+		HeapAlloc:     ms.HeapAlloc,
+		HeapSys:       ms.HeapSys,
+	}
+	if limits.MemoryLimit > 0 {
+		info.MemoryUtilization = float64(info.Usage.MemoryUsage) / float64(limits.MemoryLimit)
+	}
+	h.rd.JSON(w, http.StatusOK, info)
+}
+
+// @Tags debug
+// @Summary Get the number and size of the keys in etcd grouped by prefixes, to find out what takes up the etcd quota.
+// @Produce json
+// @Success 200 {object} server.EtcdUsage
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /debug/etcd-usage [get]
+func (h *debugHandler) GetEtcdUsage(w http.ResponseWriter, r *http.Request) {
+	usage, err := h.svr.GetEtcdUsage()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, usage)
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server"
+)
+
+var _ = Suite(&testDebugSuite{})
+
+type testDebugSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testDebugSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testDebugSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testDebugSuite) TestRuntime(c *C) {
+	info := &RuntimeInfo{}
+	err := readJSON(testDialClient, s.urlPrefix+"/debug/runtime", info)
+	c.Assert(err, IsNil)
+	c.Assert(info.Limits.NumCPU, Greater, 0)
+	c.Assert(info.ThrottleRatio > 0 && info.ThrottleRatio <= 1, IsTrue)
+	c.Assert(info.NumGoroutine, Greater, 0)
+}
+
+func (s *testDebugSuite) TestEtcdUsage(c *C) {
+	usage := &server.EtcdUsage{}
+	err := readJSON(testDialClient, s.urlPrefix+"/debug/etcd-usage", usage)
+	c.Assert(err, IsNil)
+	c.Assert(usage.DBSize, Greater, int64(0))
+	c.Assert(usage.Quota, Greater, int64(0))
+	var keys, bytes int64
+	prefixes := make(map[string]*server.EtcdKeyUsage)
+	for _, u := range usage.Prefixes {
+		keys += u.Keys
+		bytes += u.Bytes
+		prefixes[u.Prefix] = u
+	}
+	c.Assert(keys, Equals, usage.TotalKeys)
+	c.Assert(bytes, Equals, usage.TotalBytes)
+	// The bootstrapped cluster has a store and a region.
+	c.Assert(prefixes["raft/s"].Name, Equals, "stores")
+	c.Assert(prefixes["raft/s"].Keys, Equals, int64(1))
+	c.Assert(prefixes["raft/r"].Name, Equals, "regions")
+}
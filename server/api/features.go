@@ -0,0 +1,42 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/tikv/pd/server"
+	"github.com/unrolled/render"
+)
+
+type featuresHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newFeaturesHandler(svr *server.Server, rd *render.Render) *featuresHandler {
+	return &featuresHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// @Tags features
+// @Summary List the experimental features and whether they are enabled.
+// @Produce json
+// @Success 200 {array} config.Feature
+// @Router /features [get]
+func (h *featuresHandler) List(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.GetPersistOptions().GetFeatures())
+}
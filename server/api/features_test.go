@@ -0,0 +1,71 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/config"
+)
+
+var _ = Suite(&testFeaturesSuite{})
+
+type testFeaturesSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testFeaturesSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testFeaturesSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testFeaturesSuite) TestFeatures(c *C) {
+	isEnabled := func(name string) bool {
+		var features []config.Feature
+		c.Assert(readJSON(testDialClient, s.urlPrefix+"/features", &features), IsNil)
+		for _, f := range features {
+			if f.Name == name {
+				return f.Enabled
+			}
+		}
+		c.Fatalf("feature %s is not found", name)
+		return false
+	}
+	c.Assert(isEnabled(config.FeatureHotRegionHistory), IsTrue)
+
+	data, err := json.Marshal(map[string]interface{}{"pd-server.enabled-features": ""})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/config", data), IsNil)
+	c.Assert(isEnabled(config.FeatureHotRegionHistory), IsFalse)
+
+	// The unknown features are ignored rather than rejected.
+	data, err = json.Marshal(map[string]interface{}{"pd-server.enabled-features": "unknown-feature," + config.FeatureHotRegionHistory})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/config", data), IsNil)
+	c.Assert(isEnabled(config.FeatureHotRegionHistory), IsTrue)
+}
@@ -18,9 +18,13 @@ import (
 
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/cluster"
+	"github.com/tikv/pd/server/statistics"
 	"github.com/unrolled/render"
 )
 
+// maxHealthClockDrifts is the number of the worst clock drifts shown in the health detail.
+const maxHealthClockDrifts = 5
+
 type healthHandler struct {
 	svr *server.Server
 	rd  *render.Render
@@ -41,18 +45,56 @@ func newHealthHandler(svr *server.Server, rd *render.Render) *healthHandler {
 	}
 }
 
+// HealthDetail contains the health of members and the status of some subsystems.
+type HealthDetail struct {
+	Members  []Health                         `json:"members"`
+	HotCache *statistics.HotCacheMemoryStatus `json:"hot_cache,omitempty"`
+	// ClockDrifts are the worst clock drifts between the PD leader and other
+	// PD members or stores.
+	ClockDrifts []*cluster.ClockDrift `json:"clock_drifts,omitempty"`
+}
+
+// @Tags health
 // @Summary Health status of PD servers.
 // @Produce json
 // @Success 200 {array} Health
 // @Failure 500 {string} string "PD server failed to proceed the request."
 // @Router /health [get]
 func (h *healthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	client := h.svr.GetClient()
-	members, err := cluster.GetMembers(client)
+	healths, err := h.getMembersHealth()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, healths)
+}
+
+// @Tags health
+// @Summary Get the health of members and the status of some subsystems.
+// @Produce json
+// @Success 200 {object} HealthDetail
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /health/detail [get]
+func (h *healthHandler) GetDetail(w http.ResponseWriter, r *http.Request) {
+	healths, err := h.getMembersHealth()
 	if err != nil {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	detail := &HealthDetail{Members: healths}
+	if rc := h.svr.GetRaftCluster(); rc != nil {
+		detail.HotCache = rc.GetHotCacheMemoryStatus()
+		detail.ClockDrifts = rc.GetClockDrifts(maxHealthClockDrifts)
+	}
+	h.rd.JSON(w, http.StatusOK, detail)
+}
+
+func (h *healthHandler) getMembersHealth() ([]Health, error) {
+	client := h.svr.GetClient()
+	members, err := cluster.GetMembers(client)
+	if err != nil {
+		return nil, err
+	}
 
 	healthMembers := cluster.CheckHealth(h.svr.GetHTTPClient(), members)
 	healths := []Health{}
@@ -68,5 +110,5 @@ func (h *healthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 		healths = append(healths, h)
 	}
-	h.rd.JSON(w, http.StatusOK, healths)
+	return healths, nil
 }
@@ -15,8 +15,12 @@ package api
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/pingcap/errors"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/unrolled/render"
 )
 
@@ -58,6 +62,45 @@ func (h *hotStatusHandler) GetHotReadRegions(w http.ResponseWriter, r *http.Requ
 	h.rd.JSON(w, http.StatusOK, h.Handler.GetHotReadRegions())
 }
 
+// @Tags hotspot
+// @Summary List the hot peers recorded in the time range, the snapshots of the hottest peers are taken every minute in the last 24 hours. The history is kept in the memory of the PD leader only, so it is lost when the leader changes.
+// @Param start query integer false "The start of the time range in unix seconds."
+// @Param end query integer false "The end of the time range in unix seconds, it is now by default."
+// @Param type query string false "The type of the hot regions." Enums(read, write)
+// @Produce json
+// @Success 200 {array} cluster.HotRegionRecord
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /hotspot/regions/history [get]
+func (h *hotStatusHandler) GetHotRegionHistory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var start, end time.Time
+	for _, t := range []struct {
+		name  string
+		value *time.Time
+	}{{"start", &start}, {"end", &end}} {
+		if s := query.Get(t.name); s != "" {
+			sec, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				h.rd.JSON(w, http.StatusBadRequest, errors.Errorf("invalid %s %s", t.name, s).Error())
+				return
+			}
+			*t.value = time.Unix(sec, 0)
+		}
+	}
+	typ := query.Get("type")
+	if typ != "" && typ != cluster.HotRegionTypeRead && typ != cluster.HotRegionTypeWrite {
+		h.rd.JSON(w, http.StatusBadRequest, errors.Errorf("invalid type %s", typ).Error())
+		return
+	}
+	records, err := h.Handler.GetHotRegionHistory(start, end, typ)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, records)
+}
+
 // @Tags hotspot
 // @Summary List the hot stores.
 // @Produce json
@@ -77,3 +120,28 @@ func (h *hotStatusHandler) GetHotStores(w http.ResponseWriter, r *http.Request)
 	}
 	h.rd.JSON(w, http.StatusOK, stats)
 }
+
+// @Tags hotspot
+// @Summary List the read and write rates of the stores, the rates of the hot peers are split by leaders and followers.
+// @Param sort-by query string false "The rate to sort the stores in descending order" Enums(write-bytes, write-keys, read-bytes, read-keys)
+// @Produce json
+// @Success 200 {array} cluster.HotStoreSummary
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /hotspot/stores/summary [get]
+func (h *hotStatusHandler) GetHotStoreSummaries(w http.ResponseWriter, r *http.Request) {
+	sortBy := r.URL.Query().Get("sort-by")
+	if sortBy == "" {
+		sortBy = cluster.HotStoreSortByWriteBytes
+	}
+	if !cluster.IsValidHotStoreSortKey(sortBy) {
+		h.rd.JSON(w, http.StatusBadRequest, "invalid sort-by, should be one of write-bytes, write-keys, read-bytes and read-keys")
+		return
+	}
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, rc.GetHotStoreSummaries(sortBy))
+}
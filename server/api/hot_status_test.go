@@ -15,9 +15,13 @@ package api
 
 import (
 	"fmt"
+	"net/http"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
+	"github.com/tikv/pd/server/config"
 	_ "github.com/tikv/pd/server/schedulers"
 )
 
@@ -48,3 +52,24 @@ func (s testHotStatusSuite) TestGetHotStore(c *C) {
 	err := readJSON(testDialClient, s.urlPrefix+"/stores", &stat)
 	c.Assert(err, IsNil)
 }
+
+func (s testHotStatusSuite) TestGetHotRegionHistory(c *C) {
+	url := fmt.Sprintf("%s/regions/history?start=%d&type=read", s.urlPrefix, time.Now().Add(-time.Hour).Unix())
+	// The history is an experimental feature which is enabled by default.
+	pdServerCfg := s.svr.GetPersistOptions().GetPDServerConfig().Clone()
+	pdServerCfg.EnabledFeatures = []string{}
+	c.Assert(s.svr.SetPDServerConfig(*pdServerCfg), IsNil)
+	status, _ := requestStatusBody(c, testDialClient, http.MethodGet, url)
+	c.Assert(status, Equals, http.StatusNotFound)
+	pdServerCfg.EnabledFeatures = []string{config.FeatureHotRegionHistory}
+	c.Assert(s.svr.SetPDServerConfig(*pdServerCfg), IsNil)
+
+	var records []cluster.HotRegionRecord
+	c.Assert(readJSON(testDialClient, url, &records), IsNil)
+	c.Assert(records, HasLen, 0)
+
+	for _, query := range []string{"start=yesterday", "end=1.5", "type=scan"} {
+		status, _ := requestStatusBody(c, testDialClient, http.MethodGet, s.urlPrefix+"/regions/history?"+query)
+		c.Assert(status, Equals, http.StatusBadRequest, Commentf("%s", query))
+	}
+}
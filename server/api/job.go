@@ -0,0 +1,131 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server"
+	"github.com/unrolled/render"
+)
+
+type jobHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newJobHandler(svr *server.Server, rd *render.Render) *jobHandler {
+	return &jobHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// @Tags job
+// @Summary List all jobs, the jobs done more than 7 days ago are removed.
+// @Produce json
+// @Success 200 {array} job.Job
+// @Router /jobs [get]
+func (h *jobHandler) List(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	h.rd.JSON(w, http.StatusOK, rc.GetJobManager().GetJobs())
+}
+
+// @Tags job
+// @Summary Submit a job, like scatter-range or split-regions.
+// @Accept json
+// @Param body body object true "json params, like {\"type\": \"scatter-range\", \"params\": {\"start_key\": \"\", \"end_key\": \"\"}}"
+// @Produce json
+// @Success 200 {object} job.Job
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /jobs [post]
+func (h *jobHandler) Submit(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	var input struct {
+		Type   string          `json:"type"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	j, err := rc.GetJobManager().Submit(input.Type, input.Params)
+	if err != nil {
+		if errs.ErrJobTypeNotSupported.Equal(err) {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, j)
+}
+
+// @Tags job
+// @Summary Get a job by ID.
+// @Param id path integer true "Job Id"
+// @Produce json
+// @Success 200 {object} job.Job
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The job does not exist."
+// @Router /jobs/{id} [get]
+func (h *jobHandler) Get(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	j, err := rc.GetJobManager().GetJob(id)
+	if err != nil {
+		h.rd.JSON(w, http.StatusNotFound, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, j)
+}
+
+// @Tags job
+// @Summary Cancel a pending or running job.
+// @Param id path integer true "Job Id"
+// @Produce json
+// @Success 200 {string} string "The job is cancelled."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The job does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /jobs/{id} [delete]
+func (h *jobHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := rc.GetJobManager().Cancel(id); err != nil {
+		switch {
+		case errs.ErrJobNotFound.Equal(err):
+			h.rd.JSON(w, http.StatusNotFound, err.Error())
+		case errs.ErrJobDone.Equal(err):
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		default:
+			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The job is cancelled.")
+}
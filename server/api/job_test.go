@@ -0,0 +1,90 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/testutil"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
+	"github.com/tikv/pd/server/job"
+)
+
+var _ = Suite(&testJobSuite{})
+
+type testJobSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testJobSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testJobSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testJobSuite) TestJob(c *C) {
+	url := fmt.Sprintf("%s/jobs", s.urlPrefix)
+	err := postJSON(testDialClient, url, []byte(`{"type": "unknown"}`))
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "not supported"), IsTrue)
+
+	// The job fails because the start key is not hex encoded.
+	input := map[string]interface{}{
+		"type":   cluster.ScatterRangeJob,
+		"params": cluster.ScatterRangeParams{StartKey: "xx"},
+	}
+	data, err := json.Marshal(input)
+	c.Assert(err, IsNil)
+	j := &job.Job{}
+	err = postJSON(testDialClient, url, data, func(res []byte, code int) {
+		c.Assert(json.Unmarshal(res, j), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(j.Type, Equals, cluster.ScatterRangeJob)
+	testutil.WaitUntil(c, func(c *C) bool {
+		c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/%d", url, j.ID), j), IsNil)
+		return j.State == job.Failed
+	})
+	c.Assert(j.Error, Matches, ".*ErrHexDecodingString.*")
+
+	var jobs []*job.Job
+	c.Assert(readJSON(testDialClient, url, &jobs), IsNil)
+	c.Assert(jobs, HasLen, 1)
+
+	err = readJSON(testDialClient, fmt.Sprintf("%s/%d", url, j.ID+1), j)
+	c.Assert(strings.Contains(err.Error(), "404"), IsTrue)
+	resp, err := doDelete(testDialClient, fmt.Sprintf("%s/%d", url, j.ID))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+	resp, err = doDelete(testDialClient, fmt.Sprintf("%s/%d", url, j.ID+1))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusNotFound)
+}
@@ -98,6 +98,16 @@ func (h *labelsHandler) GetStores(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, storesInfo)
 }
 
+// @Tags label
+// @Summary List the label inconsistencies of stores as advice, such as a missing upper level location label or an unknown label value.
+// @Produce json
+// @Success 200 {array} cluster.LabelIssue
+// @Router /labels/lint [get]
+func (h *labelsHandler) Lint(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	h.rd.JSON(w, http.StatusOK, rc.LintStoreLabels())
+}
+
 type storesLabelFilter struct {
 	keyPattern   *regexp.Regexp
 	valuePattern *regexp.Regexp
@@ -19,6 +19,7 @@ import (
 	"net/http"
 
 	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/apiutil"
 	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/server"
 	"github.com/unrolled/render"
@@ -69,3 +70,52 @@ func (h *logHandler) Handle(w http.ResponseWriter, r *http.Request) {
 
 	h.rd.JSON(w, http.StatusOK, "The log level is updated.")
 }
+
+// @Tags admin
+// @Summary Get the config of the log sampling and the number of the suppressed messages.
+// @Produce json
+// @Success 200 {object} logSampling
+// @Router /admin/log/sampling [get]
+func (h *logHandler) GetSampling(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, &logSampling{
+		LogSamplingConfig: h.svr.GetPDServerConfig().LogSampling,
+		Suppressed:        logutil.GetSuppressedLogs(),
+	})
+}
+
+// @Tags admin
+// @Summary Update the config of the log sampling, the omitted fields are unchanged. The config is persisted and applied to every PD member.
+// @Accept json
+// @Param body body object true "json params"
+// @Produce json
+// @Success 200 {string} string "The log sampling is updated."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /admin/log/sampling [post]
+func (h *logHandler) SetSampling(w http.ResponseWriter, r *http.Request) {
+	pdServerCfg := h.svr.GetPDServerConfig()
+	cfg := &pdServerCfg.LogSampling
+	// The config persisted by the older versions has no log sampling.
+	if cfg.Interval.Duration == 0 {
+		*cfg = logutil.DefaultLogSamplingConfig()
+	}
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, cfg); err != nil {
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.svr.SetPDServerConfig(*pdServerCfg); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The log sampling is updated.")
+}
+
+// logSampling is the config of the log sampling with the total number of the
+// suppressed messages of every key.
+type logSampling struct {
+	logutil.LogSamplingConfig
+	Suppressed map[string]uint64 `json:"suppressed"`
+}
@@ -270,10 +270,29 @@ func (h *leaderHandler) Resign(w http.ResponseWriter, r *http.Request) {
 // @Param nextLeader path string true "PD server that transfer leader to"
 // @Produce json
 // @Success 200 {string} string "The transfer command is submitted."
+// @Failure 404 {string} string "The member does not exist."
 // @Failure 500 {string} string "PD server failed to proceed the request."
 // @Router /leader/transfer/{nextLeader} [post]
 func (h *leaderHandler) Transfer(w http.ResponseWriter, r *http.Request) {
-	err := h.svr.GetMember().ResignEtcdLeader(h.svr.Context(), h.svr.Name(), mux.Vars(r)["next_leader"])
+	nextLeader := mux.Vars(r)["next_leader"]
+	members, err := etcdutil.ListEtcdMembers(h.svr.GetClient())
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var found bool
+	for _, m := range members.Members {
+		if m.Name == nextLeader {
+			found = true
+			break
+		}
+	}
+	if !found {
+		h.rd.JSON(w, http.StatusNotFound, fmt.Sprintf("not found, pd: %s", nextLeader))
+		return
+	}
+
+	err = h.svr.GetMember().ResignEtcdLeader(h.svr.Context(), h.svr.Name(), nextLeader)
 	if err != nil {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
@@ -14,6 +14,7 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/tikv/pd/pkg/errs"
@@ -44,3 +45,29 @@ func (m clusterMiddleware) Middleware(h http.Handler) http.Handler {
 		h.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// featureMiddleware serves the endpoints of an experimental feature only if the
+// feature is enabled.
+type featureMiddleware struct {
+	s    *server.Server
+	rd   *render.Render
+	name string
+}
+
+func newFeatureMiddleware(s *server.Server, name string) featureMiddleware {
+	return featureMiddleware{
+		s:    s,
+		rd:   render.New(render.Options{IndentJSON: true}),
+		name: name,
+	}
+}
+
+func (m featureMiddleware) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.s.GetPersistOptions().IsFeatureEnabled(m.name) {
+			m.rd.JSON(w, http.StatusNotFound, fmt.Sprintf("feature %s is not enabled, it can be enabled by adding it to pd-server.enabled-features", m.name))
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
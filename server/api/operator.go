@@ -14,12 +14,18 @@
 package api
 
 import (
+	"bytes"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/pingcap/errors"
 	"github.com/tikv/pd/pkg/apiutil"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/schedule"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/placement"
 	"github.com/unrolled/render"
@@ -65,9 +71,13 @@ func (h *operatorHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 // @Tags operator
 // @Summary List pending operators.
-// @Param kind query string false "Specify the operator kind." Enums(admin, leader, region)
+// @Param kind query string false "Specify the operator kind." Enums(admin, leader, region, merge, waiting)
+// @Param creator query string false "Specify the scheduler or checker which creates the operator, or manual for the operators created by API."
+// @Param min_age query string false "Only list the operators created before the duration, like 10m."
+// @Param sort query string false "Sort the operators." Enums(age, region, creator)
 // @Produce json
 // @Success 200 {array} operator.Operator
+// @Failure 400 {string} string "The input is invalid."
 // @Failure 500 {string} string "PD server failed to proceed the request."
 // @Router /operators [get]
 func (h *operatorHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -95,6 +105,13 @@ func (h *operatorHandler) List(w http.ResponseWriter, r *http.Request) {
 				ops, err = h.GetRegionOperators()
 			case "waiting":
 				ops, err = h.GetWaitingOperators()
+			default:
+				mask, errParse := operator.ParseOperatorKind(kind)
+				if errParse != nil {
+					h.r.JSON(w, http.StatusBadRequest, errParse.Error())
+					return
+				}
+				ops, err = h.GetOperatorsOfKind(mask)
 			}
 			if err != nil {
 				h.r.JSON(w, http.StatusInternalServerError, err.Error())
@@ -104,9 +121,109 @@ func (h *operatorHandler) List(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	results, err = filterOperators(results, r.URL.Query())
+	if err != nil {
+		h.r.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	h.r.JSON(w, http.StatusOK, results)
 }
 
+// @Tags operator
+// @Summary List the operators finished recently, including the canceled, replaced, expired and timeout ones.
+// @Param since query string false "Only list the operators finished within the duration, like 24h."
+// @Param until query string false "Only list the operators finished before the duration ago, like 12h."
+// @Param region_id query integer false "Only list the operators of the region."
+// @Param start_key query string false "Only list the operators of the regions overlapping the key range from the key."
+// @Param end_key query string false "Only list the operators of the regions overlapping the key range to the key, the end of the key space if it is empty."
+// @Produce json
+// @Success 200 {array} schedule.OperatorAudit
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /operators/history [get]
+func (h *operatorHandler) History(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filter schedule.OperatorAuditFilter
+	for _, t := range []struct {
+		name string
+		time *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		if s := query.Get(t.name); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil || d < 0 {
+				h.r.JSON(w, http.StatusBadRequest, errors.Errorf("invalid %s %s", t.name, s).Error())
+				return
+			}
+			*t.time = time.Now().Add(-d)
+		}
+	}
+	if id := query.Get("region_id"); id != "" {
+		var err error
+		if filter.RegionID, err = strconv.ParseUint(id, 10, 64); err != nil {
+			h.r.JSON(w, http.StatusBadRequest, errors.Errorf("invalid region_id %s", id).Error())
+			return
+		}
+	}
+	filter.StartKey, filter.EndKey = []byte(query.Get("start_key")), []byte(query.Get("end_key"))
+	if len(filter.EndKey) > 0 && bytes.Compare(filter.StartKey, filter.EndKey) >= 0 {
+		h.r.JSON(w, http.StatusBadRequest, "start_key should be less than end_key")
+		return
+	}
+	audits, err := h.GetOperatorAudits(filter)
+	if err != nil {
+		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.r.JSON(w, http.StatusOK, audits)
+}
+
+// manualCreator is the creator of the operators created by API.
+const manualCreator = "manual"
+
+func filterOperators(ops []*operator.Operator, query url.Values) ([]*operator.Operator, error) {
+	var minAge time.Duration
+	if age := query.Get("min_age"); age != "" {
+		var err error
+		if minAge, err = time.ParseDuration(age); err != nil {
+			return nil, errors.Errorf("invalid min_age %s", age)
+		}
+	}
+	creator := query.Get("creator")
+	var results []*operator.Operator
+	for _, op := range ops {
+		if creator != "" && operatorCreator(op) != creator {
+			continue
+		}
+		if op.ElapsedTime() < minAge {
+			continue
+		}
+		results = append(results, op)
+	}
+
+	switch by := query.Get("sort"); by {
+	case "":
+	case "age":
+		// the oldest comes first.
+		sort.SliceStable(results, func(i, j int) bool { return results[i].GetCreateTime().Before(results[j].GetCreateTime()) })
+	case "region":
+		sort.SliceStable(results, func(i, j int) bool { return results[i].RegionID() < results[j].RegionID() })
+	case "creator":
+		sort.SliceStable(results, func(i, j int) bool { return operatorCreator(results[i]) < operatorCreator(results[j]) })
+	default:
+		return nil, errors.Errorf("unknown sort key %s", by)
+	}
+	return results, nil
+}
+
+// operatorCreator returns the scheduler or checker which creates the operator,
+// the operators created by API are regarded as manual.
+func operatorCreator(op *operator.Operator) string {
+	if op.Kind()&operator.OpAdmin != 0 {
+		return manualCreator
+	}
+	return op.Desc()
+}
+
 // FIXME: details of input json body params
 // @Tags operator
 // @Summary Create an operator.
@@ -270,6 +387,25 @@ func (h *operatorHandler) Post(w http.ResponseWriter, r *http.Request) {
 			h.r.JSON(w, http.StatusInternalServerError, err.Error())
 			return
 		}
+	case "split-region-by-key":
+		var keys []string
+		ks, _ := input["keys"].([]interface{})
+		for _, k := range ks {
+			key, ok := k.(string)
+			if !ok {
+				h.r.JSON(w, http.StatusBadRequest, "bad format keys")
+				return
+			}
+			keys = append(keys, key)
+		}
+		if len(keys) == 0 {
+			h.r.JSON(w, http.StatusBadRequest, "missing split keys")
+			return
+		}
+		if err := h.AddSplitRegionByKeysOperators(keys); err != nil {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
 	case "scatter-region":
 		regionID, ok := input["region_id"].(float64)
 		if !ok {
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"strings"
 
 	. "github.com/pingcap/check"
@@ -28,6 +29,7 @@ import (
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
 	pdoperator "github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/placement"
 	"github.com/tikv/pd/server/versioninfo"
@@ -35,6 +37,8 @@ import (
 
 var _ = Suite(&testOperatorSuite{})
 
+var _ = Suite(&testOperatorHistorySuite{})
+
 var _ = Suite(&testTransferRegionOperatorSuite{})
 
 type testOperatorSuite struct {
@@ -115,6 +119,121 @@ func (s *testOperatorSuite) TestAddRemovePeer(c *C) {
 	c.Assert(err, NotNil)
 }
 
+func (s *testOperatorSuite) TestListOperators(c *C) {
+	mustPutStore(c, s.svr, 1, metapb.StoreState_Up, nil)
+	mustPutStore(c, s.svr, 2, metapb.StoreState_Up, nil)
+	s.svr.GetHandler().RemoveOperator(1)
+	r1 := newTestRegionInfo(40, 1, []byte("x"), []byte("y"))
+	mustRegionHeartbeat(c, s.svr, r1)
+	r2 := newTestRegionInfo(50, 1, []byte("y"), []byte("z"))
+	mustRegionHeartbeat(c, s.svr, r2)
+
+	op := pdoperator.NewOperator("test-scheduler", "test", 50, r2.GetRegionEpoch(), pdoperator.OpRegion, pdoperator.AddLearner{ToStore: 2, PeerID: 500})
+	c.Assert(s.svr.GetRaftCluster().GetOperatorController().AddOperator(op), IsTrue)
+	err := postJSON(testDialClient, fmt.Sprintf("%s/operators", s.urlPrefix), []byte(`{"name":"add-peer", "region_id": 40, "store_id": 2}`))
+	c.Assert(err, IsNil)
+	defer func() {
+		s.svr.GetHandler().RemoveOperator(40)
+		s.svr.GetHandler().RemoveOperator(50)
+	}()
+
+	listOperators := func(query string) []string {
+		var ops []string
+		c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/operators?%s", s.urlPrefix, query), &ops), IsNil)
+		return ops
+	}
+	ops := listOperators("creator=manual")
+	c.Assert(ops, HasLen, 1)
+	c.Assert(strings.HasPrefix(ops[0], "admin-add-peer"), IsTrue)
+	ops = listOperators("creator=test-scheduler")
+	c.Assert(ops, HasLen, 1)
+	c.Assert(strings.HasPrefix(ops[0], "test-scheduler"), IsTrue)
+	c.Assert(listOperators("kind=merge"), HasLen, 0)
+	c.Assert(listOperators("min_age=1h"), HasLen, 0)
+	c.Assert(listOperators("min_age=0s"), HasLen, 2)
+
+	ops = listOperators("sort=region")
+	c.Assert(ops, HasLen, 2)
+	c.Assert(strings.Contains(ops[0], "region:40("), IsTrue)
+	c.Assert(strings.Contains(ops[1], "region:50("), IsTrue)
+	ops = listOperators("sort=creator")
+	c.Assert(ops, HasLen, 2)
+	c.Assert(strings.HasPrefix(ops[0], "admin-add-peer"), IsTrue)
+	ops = listOperators("sort=age")
+	c.Assert(ops, HasLen, 2)
+	c.Assert(strings.HasPrefix(ops[0], "test-scheduler"), IsTrue)
+
+	for _, query := range []string{"kind=foo", "min_age=foo", "sort=foo"} {
+		resp, err := testDialClient.Get(fmt.Sprintf("%s/operators?%s", s.urlPrefix, query))
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+	}
+}
+
+// testOperatorHistorySuite has its own server, so the history is not mixed
+// with the operators of the other cases.
+type testOperatorHistorySuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testOperatorHistorySuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c, func(cfg *config.Config) { cfg.Replication.MaxReplicas = 1 })
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testOperatorHistorySuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testOperatorHistorySuite) TestOperatorHistory(c *C) {
+	mustPutStore(c, s.svr, 1, metapb.StoreState_Up, nil)
+	mustPutStore(c, s.svr, 2, metapb.StoreState_Up, nil)
+	// The bootstrapped region covers the whole key space, use a newer version to replace it.
+	r := newTestRegionInfo(70, 1, []byte("w"), []byte("x"), core.SetRegionVersion(10))
+	mustRegionHeartbeat(c, s.svr, r)
+	err := postJSON(testDialClient, fmt.Sprintf("%s/operators", s.urlPrefix), []byte(`{"name":"add-peer", "region_id": 70, "store_id": 2}`))
+	c.Assert(err, IsNil)
+	_, err = doDelete(testDialClient, fmt.Sprintf("%s/operators/70", s.urlPrefix))
+	c.Assert(err, IsNil)
+
+	var audits []*schedule.OperatorAudit
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/operators/history?region_id=70&since=1h", s.urlPrefix), &audits), IsNil)
+	c.Assert(audits, HasLen, 1)
+	c.Assert(audits[0].RegionID, Equals, uint64(70))
+	c.Assert(audits[0].Desc, Equals, "admin-add-peer")
+	c.Assert(audits[0].Status, Equals, "Canceled")
+	c.Assert(audits[0].StartKey, Equals, "77")
+	c.Assert(audits[0].EndKey, Equals, "78")
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/operators/history?region_id=71", s.urlPrefix), &audits), IsNil)
+	c.Assert(audits, HasLen, 0)
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/operators/history?until=1h", s.urlPrefix), &audits), IsNil)
+	c.Assert(audits, HasLen, 0)
+
+	// The operators of the regions overlapping the key range.
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/operators/history?start_key=wa&end_key=y&since=1h", s.urlPrefix), &audits), IsNil)
+	c.Assert(audits, HasLen, 1)
+	c.Assert(audits[0].RegionID, Equals, uint64(70))
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/operators/history?start_key=x", s.urlPrefix), &audits), IsNil)
+	for _, audit := range audits {
+		c.Assert(audit.RegionID, Not(Equals), uint64(70))
+	}
+
+	for _, query := range []string{"since=foo", "since=-1h", "until=foo", "region_id=foo", "start_key=b&end_key=a"} {
+		resp, err := testDialClient.Get(fmt.Sprintf("%s/operators/history?%s", s.urlPrefix, query))
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+	}
+}
+
 func (s *testOperatorSuite) TestMergeRegionOperator(c *C) {
 	r1 := newTestRegionInfo(10, 1, []byte(""), []byte("b"), core.SetWrittenBytes(1000), core.SetReadBytes(1000), core.SetRegionConfVer(1), core.SetRegionVersion(1))
 	mustRegionHeartbeat(c, s.svr, r1)
@@ -141,6 +260,25 @@ func (s *testOperatorSuite) TestMergeRegionOperator(c *C) {
 	c.Assert(err, NotNil)
 }
 
+func (s *testOperatorSuite) TestSplitRegionByKeyOperator(c *C) {
+	r1 := newTestRegionInfo(60, 1, []byte("d"), []byte("f"), core.SetRegionConfVer(10), core.SetRegionVersion(10))
+	mustRegionHeartbeat(c, s.svr, r1)
+	r2 := newTestRegionInfo(70, 1, []byte("f"), []byte("h"), core.SetRegionConfVer(10), core.SetRegionVersion(10))
+	mustRegionHeartbeat(c, s.svr, r2)
+
+	url := fmt.Sprintf("%s/operators", s.urlPrefix)
+	c.Assert(postJSON(testDialClient, url, []byte(`{"name":"split-region-by-key"}`)), NotNil)
+	c.Assert(postJSON(testDialClient, url, []byte(`{"name":"split-region-by-key", "keys": ["zz"]}`)), NotNil)
+	// The key 66 is the start key of region 70.
+	c.Assert(postJSON(testDialClient, url, []byte(`{"name":"split-region-by-key", "keys": ["65", "66", "67"]}`)), IsNil)
+	for _, id := range []uint64{60, 70} {
+		op, err := s.svr.GetHandler().GetOperator(id)
+		c.Assert(err, IsNil)
+		c.Assert(strings.Contains(op.String(), "split region with policy USEKEY"), IsTrue, Commentf("%s", op))
+		s.svr.GetHandler().RemoveOperator(id)
+	}
+}
+
 type testTransferRegionOperatorSuite struct {
 	svr       *server.Server
 	cleanup   cleanUpFunc
@@ -14,22 +14,29 @@
 package api
 
 import (
+	"bytes"
 	"container/heap"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/kvproto/pkg/replication_modepb"
 	log "github.com/sirupsen/logrus"
 	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/statistics"
@@ -144,6 +151,27 @@ func (h *regionHandler) GetRegionByID(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, NewRegionInfo(regionInfo))
 }
 
+// @Tags region
+// @Summary Get the latest events of a region, like leader transfers, peer changes, splits and merges.
+// @Param id path integer true "Region Id"
+// @Produce json
+// @Success 200 {array} cluster.RegionEvent
+// @Failure 400 {string} string "The input is invalid."
+// @Router /region/id/{id}/history [get]
+func (h *regionHandler) GetRegionHistory(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	regionID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	history := rc.GetRegionHistory(regionID)
+	if history == nil {
+		history = []cluster.RegionEvent{}
+	}
+	h.rd.JSON(w, http.StatusOK, history)
+}
+
 // @Tags region
 // @Summary Search for a region by a key.
 // @Param key path string true "Region key"
@@ -192,17 +220,187 @@ func convertToAPIRegions(regions []*core.RegionInfo) *RegionsInfo {
 }
 
 // @Tags region
-// @Summary List all regions in the cluster.
+// @Summary List all regions in the cluster. The regions are paginated if start_key or limit is specified, the limit applies to the filtered regions.
+// @Param start_key query string false "List the regions start from the key"
+// @Param limit query integer false "Limit count of a page" default(10240)
+// @Param format query string false "Output a region per line if it is ndjson" Enums(json, ndjson)
+// @Param min_size query integer false "Only list the regions whose approximate sizes in MiB are not less than it"
+// @Param max_size query integer false "Only list the regions whose approximate sizes in MiB are not greater than it"
+// @Param min_keys query integer false "Only list the regions whose approximate keys are not less than it"
+// @Param max_keys query integer false "Only list the regions whose approximate keys are not greater than it"
+// @Param store_id query integer false "Only list the regions with a peer on the store"
+// @Param key_prefix query string false "Only list the regions containing the keys with the prefix"
 // @Produce json
 // @Success 200 {object} RegionsInfo
+// @Failure 400 {string} string "The input is invalid."
 // @Router /regions [get]
 func (h *regionsHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	rc := getCluster(r.Context())
-	regions := rc.GetRegions()
+	query := r.URL.Query()
+	filter, err := newRegionFilter(query)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if query.Get("format") == "ndjson" {
+		writeRegionsNDJSON(w, filterRegions(rc.GetRegions(), filter))
+		return
+	}
+	if _, ok := query["start_key"]; !ok && query.Get("limit") == "" {
+		regions := filterRegions(rc.GetRegions(), filter)
+		regionsInfo := convertToAPIRegions(regions)
+		h.rd.JSON(w, http.StatusOK, regionsInfo)
+		return
+	}
+
+	limit := maxRegionLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			h.rd.JSON(w, http.StatusBadRequest, "limit should be a positive number")
+			return
+		}
+	}
+	if limit > maxRegionLimit {
+		limit = maxRegionLimit
+	}
+	startKey, endKey := []byte(query.Get("start_key")), []byte(nil)
+	if prefix := []byte(query.Get("key_prefix")); len(prefix) > 0 {
+		if bytes.Compare(prefix, startKey) > 0 {
+			startKey = prefix
+		}
+		endKey = prefixEnd(prefix)
+	}
+	regions := scanFilteredRegions(rc, startKey, endKey, limit, filter)
 	regionsInfo := convertToAPIRegions(regions)
 	h.rd.JSON(w, http.StatusOK, regionsInfo)
 }
 
+// scanFilteredRegions scans the regions in batches of the limit until the limit
+// of regions are selected by the filter or the end key is reached, so a page
+// does not cost more than the regions it scans over.
+func scanFilteredRegions(rc *cluster.RaftCluster, startKey, endKey []byte, limit int, filter func(*core.RegionInfo) bool) []*core.RegionInfo {
+	regions := make([]*core.RegionInfo, 0, limit)
+	for len(regions) < limit {
+		batch := rc.ScanRegions(startKey, endKey, limit)
+		regions = append(regions, filterRegions(batch, filter)...)
+		if len(batch) < limit {
+			break
+		}
+		startKey = batch[len(batch)-1].GetEndKey()
+		if len(startKey) == 0 {
+			break
+		}
+	}
+	if len(regions) > limit {
+		regions = regions[:limit]
+	}
+	return regions
+}
+
+// newRegionFilter returns the filter of the regions specified by the query, it
+// returns nil if there is no filter.
+func newRegionFilter(query url.Values) (func(*core.RegionInfo) bool, error) {
+	var conds []func(*core.RegionInfo) bool
+	for _, item := range []struct {
+		name  string
+		value func(*core.RegionInfo) int64
+		less  bool
+	}{
+		{"min_size", (*core.RegionInfo).GetApproximateSize, false},
+		{"max_size", (*core.RegionInfo).GetApproximateSize, true},
+		{"min_keys", (*core.RegionInfo).GetApproximateKeys, false},
+		{"max_keys", (*core.RegionInfo).GetApproximateKeys, true},
+	} {
+		str := query.Get(item.name)
+		if str == "" {
+			continue
+		}
+		bound, err := strconv.ParseInt(str, 10, 64)
+		if err != nil || bound < 0 {
+			return nil, errors.Errorf("%s should be a non-negative number", item.name)
+		}
+		value, less := item.value, item.less
+		conds = append(conds, func(region *core.RegionInfo) bool {
+			if less {
+				return value(region) <= bound
+			}
+			return value(region) >= bound
+		})
+	}
+	if str := query.Get("store_id"); str != "" {
+		storeID, err := strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("store_id should be a number")
+		}
+		conds = append(conds, func(region *core.RegionInfo) bool {
+			return region.GetStorePeer(storeID) != nil
+		})
+	}
+	if prefix := []byte(query.Get("key_prefix")); len(prefix) > 0 {
+		end := prefixEnd(prefix)
+		conds = append(conds, func(region *core.RegionInfo) bool {
+			return (len(end) == 0 || bytes.Compare(region.GetStartKey(), end) < 0) &&
+				(len(region.GetEndKey()) == 0 || bytes.Compare(region.GetEndKey(), prefix) > 0)
+		})
+	}
+	if len(conds) == 0 {
+		return nil, nil
+	}
+	return func(region *core.RegionInfo) bool {
+		for _, cond := range conds {
+			if !cond(region) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// prefixEnd returns the smallest key which is greater than all the keys with
+// the prefix, it returns nil if there is no such key, like the prefix of 0xff.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] != 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// filterRegions returns the regions selected by the filter, all the regions
+// are returned if the filter is nil.
+func filterRegions(regions []*core.RegionInfo, filter func(*core.RegionInfo) bool) []*core.RegionInfo {
+	if filter == nil {
+		return regions
+	}
+	filtered := make([]*core.RegionInfo, 0, len(regions))
+	for _, region := range regions {
+		if filter(region) {
+			filtered = append(filtered, region)
+		}
+	}
+	return filtered
+}
+
+// writeRegionsNDJSON writes a region per line, the regions are converted one
+// by one, so that the memory usage does not grow with the count of regions.
+func writeRegionsNDJSON(w http.ResponseWriter, regions []*core.RegionInfo) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+	var info RegionInfo
+	for _, region := range regions {
+		if err := enc.Encode(InitRegion(region, &info)); err != nil {
+			// the client is gone.
+			return
+		}
+	}
+}
+
 // @Tags region
 // @Summary List regions start from a key.
 // @Param key query string true "Region key"
@@ -232,6 +430,41 @@ func (h *regionsHandler) ScanRegions(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, regionsInfo)
 }
 
+// @Tags region
+// @Summary List regions whose key range overlaps [start_key, end_key).
+// @Param start_key query string false "Start key of the range"
+// @Param end_key query string false "End key of the range, empty means unbounded"
+// @Param limit query integer false "Limit count" default(10240)
+// @Produce json
+// @Success 200 {object} RegionsInfo
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/range [get]
+func (h *regionsHandler) ScanRegionsInRange(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	query := r.URL.Query()
+	startKey, endKey := query.Get("start_key"), query.Get("end_key")
+	if len(endKey) > 0 && startKey >= endKey {
+		h.rd.JSON(w, http.StatusBadRequest, "start_key should be less than end_key")
+		return
+	}
+
+	limit := maxRegionLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			h.rd.JSON(w, http.StatusBadRequest, "limit should be a positive number")
+			return
+		}
+	}
+	if limit > maxRegionLimit {
+		limit = maxRegionLimit
+	}
+	regions := rc.ScanRegions([]byte(startKey), []byte(endKey), limit)
+	regionsInfo := convertToAPIRegions(regions)
+	h.rd.JSON(w, http.StatusOK, regionsInfo)
+}
+
 // @Tags region
 // @Summary Get count of regions.
 // @Produce json
@@ -246,6 +479,7 @@ func (h *regionsHandler) GetRegionCount(w http.ResponseWriter, r *http.Request)
 // @Tags region
 // @Summary List all regions of a specific store.
 // @Param id path integer true "Store Id"
+// @Param role query string false "Only list the regions whose peer on the store has the role" Enums(leader, follower, learner)
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 400 {string} string "The input is invalid."
@@ -259,7 +493,56 @@ func (h *regionsHandler) GetStoreRegions(w http.ResponseWriter, r *http.Request)
 		h.rd.JSON(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	regions := rc.GetStoreRegions(uint64(id))
+	var regions []*core.RegionInfo
+	switch role := r.URL.Query().Get("role"); role {
+	case "":
+		regions = rc.GetStoreRegions(uint64(id))
+	case "leader":
+		regions = rc.GetStoreLeaderRegions(uint64(id))
+	case "follower":
+		regions = rc.GetStoreFollowerRegions(uint64(id))
+	case "learner":
+		regions = rc.GetStoreLearnerRegions(uint64(id))
+	default:
+		h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("invalid role %s", role))
+		return
+	}
+	regionsInfo := convertToAPIRegions(regions)
+	h.rd.JSON(w, http.StatusOK, regionsInfo)
+}
+
+// @Tags region
+// @Summary List the regions which have peers on the stores with all the labels.
+// @Param label query array true "The store labels, each of them is in the form of key=value."
+// @Produce json
+// @Success 200 {object} RegionsInfo
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/label [get]
+func (h *regionsHandler) GetLabelRegions(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	labels, err := parseStoreLabels(r.URL.Query()["label"])
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(labels) == 0 {
+		h.rd.JSON(w, http.StatusBadRequest, "no label is specified")
+		return
+	}
+	found := make(map[uint64]*core.RegionInfo)
+	for _, store := range rc.GetStores() {
+		if store.IsTombstone() || !storeHasLabels(store, labels) {
+			continue
+		}
+		for _, region := range rc.GetStoreRegions(store.GetID()) {
+			found[region.GetID()] = region
+		}
+	}
+	regions := make([]*core.RegionInfo, 0, len(found))
+	for _, region := range found {
+		regions = append(regions, region)
+	}
+	sort.Slice(regions, func(i, j int) bool { return regions[i].GetID() < regions[j].GetID() })
 	regionsInfo := convertToAPIRegions(regions)
 	h.rd.JSON(w, http.StatusOK, regionsInfo)
 }
@@ -383,6 +666,81 @@ func (h *regionsHandler) GetEmptyRegion(w http.ResponseWriter, r *http.Request)
 	h.rd.JSON(w, http.StatusOK, regionsInfo)
 }
 
+// @Tags region
+// @Summary List all regions which are not reported by their leaders for a while.
+// @Param threshold query string false "The duration since the last heartbeat" default(10m)
+// @Param since query string false "Only list the regions last reported within the duration, like 1h."
+// @Param until query string false "Only list the regions last reported before the duration ago, like 10m, which overrides the threshold."
+// @Produce json
+// @Success 200 {object} RegionsInfo
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/check/stale-heartbeat [get]
+func (h *regionsHandler) GetStaleHeartbeatRegions(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	query := r.URL.Query()
+	durations := map[string]time.Duration{"threshold": defaultStaleThreshold}
+	for _, name := range []string{"threshold", "since", "until"} {
+		if s := query.Get(name); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil || d < 0 {
+				h.rd.JSON(w, http.StatusBadRequest, errors.Errorf("invalid %s %s", name, s).Error())
+				return
+			}
+			durations[name] = d
+		}
+	}
+	until, ok := durations["until"]
+	if !ok {
+		until = durations["threshold"]
+	}
+	now := time.Now()
+	var since time.Time
+	if d, ok := durations["since"]; ok {
+		if d < until {
+			h.rd.JSON(w, http.StatusBadRequest, "since should not be less than until")
+			return
+		}
+		since = now.Add(-d)
+	}
+	regions := rc.GetStaleHeartbeatRegions(since, now.Add(-until))
+	regionsInfo := convertToAPIRegions(regions)
+	h.rd.JSON(w, http.StatusOK, regionsInfo)
+}
+
+// @Tags region
+// @Summary List all oversized regions, which reach the region-max-size or region-max-keys of the schedule config.
+// @Produce json
+// @Success 200 {object} RegionsInfo
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /regions/check/oversized-region [get]
+func (h *regionsHandler) GetOversizedRegions(w http.ResponseWriter, r *http.Request) {
+	handler := h.svr.GetHandler()
+	regions, err := handler.GetRegionsByType(statistics.OversizedRegion)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	regionsInfo := convertToAPIRegions(regions)
+	h.rd.JSON(w, http.StatusOK, regionsInfo)
+}
+
+// @Tags region
+// @Summary List all undersized regions, which are small enough to be merged.
+// @Produce json
+// @Success 200 {object} RegionsInfo
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /regions/check/undersized-region [get]
+func (h *regionsHandler) GetUndersizedRegions(w http.ResponseWriter, r *http.Request) {
+	handler := h.svr.GetHandler()
+	regions, err := handler.GetRegionsByType(statistics.UndersizedRegion)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	regionsInfo := convertToAPIRegions(regions)
+	h.rd.JSON(w, http.StatusOK, regionsInfo)
+}
+
 type histItem struct {
 	Start int64 `json:"start"`
 	End   int64 `json:"end"`
@@ -514,16 +872,160 @@ func (h *regionsHandler) GetRegionSiblings(w http.ResponseWriter, r *http.Reques
 	h.rd.JSON(w, http.StatusOK, regionsInfo)
 }
 
+// @Tags region
+// @Summary Merge a region into its sibling, the smaller sibling which can be merged is picked if the side is not specified.
+// @Param id path integer true "Region Id"
+// @Param with query string false "The side of the sibling" Enums(left, right)
+// @Produce json
+// @Success 200 {string} string "The operator is created."
+// @Failure 400 {string} string "The input is invalid or the region can not be merged."
+// @Failure 404 {string} string "The region does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /regions/merge/{id} [post]
+func (h *regionsHandler) MergeRegionWithSibling(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if rc.GetRegion(id) == nil {
+		h.rd.JSON(w, http.StatusNotFound, server.ErrRegionNotFound(id).Error())
+		return
+	}
+
+	targetID, err := h.svr.GetHandler().AddMergeSiblingOperator(id, r.URL.Query().Get("with"))
+	if err != nil {
+		if errors.Cause(err) == server.ErrAddOperator {
+			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		} else {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, fmt.Sprintf("The operator to merge region %d into region %d is created.", id, targetID))
+}
+
+// @Tags region
+// @Summary List the regions with the given IDs, the regions which do not exist are skipped.
+// @Param ids query string true "Comma-separated region IDs, like 1,2,3"
+// @Produce json
+// @Success 200 {object} RegionsInfo
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/by-ids [get]
+func (h *regionsHandler) GetRegionsByIDs(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+
+	idsStr := r.URL.Query().Get("ids")
+	if idsStr == "" {
+		h.rd.JSON(w, http.StatusBadRequest, "ids should not be empty")
+		return
+	}
+	fields := strings.Split(idsStr, ",")
+	if len(fields) > maxRegionLimit {
+		h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("the count of ids should not be greater than %d", maxRegionLimit))
+		return
+	}
+	regions := make([]*core.RegionInfo, 0, len(fields))
+	for _, field := range fields {
+		id, err := strconv.ParseUint(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if region := rc.GetRegion(id); region != nil {
+			regions = append(regions, region)
+		}
+	}
+	regionsInfo := convertToAPIRegions(regions)
+	h.rd.JSON(w, http.StatusOK, regionsInfo)
+}
+
+// @Tags region
+// @Summary Get the latest snapshot of the region topology taken no later than the time.
+// @Param time query integer true "The time in unix seconds."
+// @Param id query integer false "Only return the region with the ID."
+// @Produce json
+// @Success 200 {object} cluster.RegionTopologySnapshot
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "There is no snapshot taken before the time."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /regions/topology [get]
+func (h *regionsHandler) GetRegionTopology(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+
+	query := r.URL.Query()
+	sec, err := strconv.ParseInt(query.Get("time"), 10, 64)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("invalid time %s", query.Get("time")))
+		return
+	}
+	var id uint64
+	if s := query.Get("id"); s != "" {
+		if id, err = strconv.ParseUint(s, 10, 64); err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("invalid id %s", s))
+			return
+		}
+	}
+	snapshot, err := rc.GetRegionTopology(time.Unix(sec, 0), id)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if snapshot == nil {
+		h.rd.JSON(w, http.StatusNotFound, "no region topology snapshot before the time")
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, snapshot)
+}
+
+// @Tags region
+// @Summary Verify the region epochs in PD against the local region epochs of the stores.
+// @Param store query integer false "Only verify the store with the ID."
+// @Produce json
+// @Success 200 {object} cluster.RegionVerifyReport
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The store does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /regions/verify [get]
+func (h *regionsHandler) VerifyRegions(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+
+	var storeID uint64
+	if s := r.URL.Query().Get("store"); s != "" {
+		var err error
+		if storeID, err = strconv.ParseUint(s, 10, 64); err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("invalid store %s", s))
+			return
+		}
+	}
+	report, err := rc.VerifyRegions(r.Context(), storeID)
+	if err != nil {
+		if errs.ErrStoreNotFound.Equal(err) {
+			h.rd.JSON(w, http.StatusNotFound, err.Error())
+			return
+		}
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, report)
+}
+
 const (
 	defaultRegionLimit     = 16
 	maxRegionLimit         = 10240
 	minRegionHistogramSize = 1
 	minRegionHistogramKeys = 1000
+	defaultGCRangeTTL      = time.Hour
+	defaultFreezeTTL       = 10 * time.Minute
+	defaultStaleThreshold  = 10 * time.Minute
 )
 
 // @Tags region
 // @Summary List regions with the highest write flow.
 // @Param limit query integer false "Limit count" default(16)
+// @Param asc query boolean false "List the lowest ones instead" default(false)
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 400 {string} string "The input is invalid."
@@ -535,6 +1037,7 @@ func (h *regionsHandler) GetTopWriteFlow(w http.ResponseWriter, r *http.Request)
 // @Tags region
 // @Summary List regions with the highest read flow.
 // @Param limit query integer false "Limit count" default(16)
+// @Param asc query boolean false "List the lowest ones instead" default(false)
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 400 {string} string "The input is invalid."
@@ -546,6 +1049,7 @@ func (h *regionsHandler) GetTopReadFlow(w http.ResponseWriter, r *http.Request)
 // @Tags region
 // @Summary List regions with the largest conf version.
 // @Param limit query integer false "Limit count" default(16)
+// @Param asc query boolean false "List the lowest ones instead" default(false)
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 400 {string} string "The input is invalid."
@@ -559,6 +1063,7 @@ func (h *regionsHandler) GetTopConfVer(w http.ResponseWriter, r *http.Request) {
 // @Tags region
 // @Summary List regions with the largest version.
 // @Param limit query integer false "Limit count" default(16)
+// @Param asc query boolean false "List the lowest ones instead" default(false)
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 400 {string} string "The input is invalid."
@@ -572,6 +1077,7 @@ func (h *regionsHandler) GetTopVersion(w http.ResponseWriter, r *http.Request) {
 // @Tags region
 // @Summary List regions with the largest size.
 // @Param limit query integer false "Limit count" default(16)
+// @Param asc query boolean false "List the lowest ones instead" default(false)
 // @Produce json
 // @Success 200 {object} RegionsInfo
 // @Failure 400 {string} string "The input is invalid."
@@ -582,6 +1088,20 @@ func (h *regionsHandler) GetTopSize(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// @Tags region
+// @Summary List regions with the most keys.
+// @Param limit query integer false "Limit count" default(16)
+// @Param asc query boolean false "List the lowest ones instead" default(false)
+// @Produce json
+// @Success 200 {object} RegionsInfo
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/keys [get]
+func (h *regionsHandler) GetTopKeys(w http.ResponseWriter, r *http.Request) {
+	h.GetTopNRegions(w, r, func(a, b *core.RegionInfo) bool {
+		return a.GetApproximateKeys() < b.GetApproximateKeys()
+	})
+}
+
 // @Tags region
 // @Summary Accelerate regions scheduling a in given range, only receive hex format for keys
 // @Accept json
@@ -633,6 +1153,172 @@ func (h *regionsHandler) AccelerateRegionsScheduleInRange(w http.ResponseWriter,
 	h.rd.Text(w, http.StatusOK, fmt.Sprintf("Accelerate regions scheduling in a given range [%s,%s)", rawStartKey, rawEndKey))
 }
 
+// @Tags region
+// @Summary Mark a key range as dropped by TRUNCATE or DROP, so its regions are merged as soon as possible and are not balanced. Only receive hex format for keys.
+// @Accept json
+// @Param body body object true "json params, ttl is a duration like 1h and defaults to 1h"
+// @Produce json
+// @Success 200 {object} cluster.GCRange
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /regions/gc-range [post]
+func (h *regionsHandler) AddGCRange(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	var input map[string]interface{}
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	startKey, _, err := parseKey("start_key", input)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	endKey, _, err := parseKey("end_key", input)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(endKey) > 0 && bytes.Compare(startKey, endKey) >= 0 {
+		h.rd.JSON(w, http.StatusBadRequest, "start_key should be less than end_key")
+		return
+	}
+
+	ttl := defaultGCRangeTTL
+	if ttlStr, ok := input["ttl"].(string); ok && ttlStr != "" {
+		ttl, err = time.ParseDuration(ttlStr)
+		if err != nil || ttl <= 0 {
+			h.rd.JSON(w, http.StatusBadRequest, "invalid ttl, should be a positive duration")
+			return
+		}
+	}
+	gcRange, err := rc.AddGCRange(startKey, endKey, ttl)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, gcRange)
+}
+
+// @Tags region
+// @Summary List the key ranges dropped by TRUNCATE or DROP which are not expired.
+// @Produce json
+// @Success 200 {array} cluster.GCRange
+// @Router /regions/gc-range [get]
+func (h *regionsHandler) GetGCRanges(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	h.rd.JSON(w, http.StatusOK, rc.GetGCRanges())
+}
+
+// @Tags region
+// @Summary Freeze the scheduling of the regions overlapping with a key range by a schedule=deny region label rule, no leader transfers or peer moves are made except the ones created by the admin. Only receive hex format for keys.
+// @Accept json
+// @Param body body object true "json params, ttl is a duration like 10m and defaults to 10m"
+// @Produce json
+// @Success 200 {object} cluster.FrozenRange
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /regions/freeze [post]
+func (h *regionsHandler) FreezeRange(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	var input map[string]interface{}
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	startKey, endKey, err := parseFrozenRange(input)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ttl := defaultFreezeTTL
+	if ttlStr, ok := input["ttl"].(string); ok && ttlStr != "" {
+		ttl, err = time.ParseDuration(ttlStr)
+		if err != nil || ttl <= 0 {
+			h.rd.JSON(w, http.StatusBadRequest, "invalid ttl, should be a positive duration")
+			return
+		}
+	}
+	frozen, err := rc.FreezeRange(startKey, endKey, ttl)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, frozen)
+}
+
+// @Tags region
+// @Summary Unfreeze the scheduling of a key range before it expires. Only receive hex format for keys.
+// @Param start_key query string true "The start key of the frozen range in hex."
+// @Param end_key query string true "The end key of the frozen range in hex."
+// @Produce json
+// @Success 200 {string} string "The key range is unfrozen."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The key range is not frozen."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /regions/freeze [delete]
+func (h *regionsHandler) UnfreezeRange(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	input := make(map[string]interface{})
+	for _, name := range []string{"start_key", "end_key"} {
+		if values, ok := r.URL.Query()[name]; ok {
+			input[name] = values[0]
+		}
+	}
+	startKey, endKey, err := parseFrozenRange(input)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ok, err := rc.UnfreezeRange(startKey, endKey)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !ok {
+		h.rd.JSON(w, http.StatusNotFound, "the key range is not frozen")
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The key range is unfrozen.")
+}
+
+func parseFrozenRange(input map[string]interface{}) ([]byte, []byte, error) {
+	startKey, _, err := parseKey("start_key", input)
+	if err != nil {
+		return nil, nil, err
+	}
+	endKey, _, err := parseKey("end_key", input)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(endKey) > 0 && bytes.Compare(startKey, endKey) >= 0 {
+		return nil, nil, errors.New("start_key should be less than end_key")
+	}
+	return startKey, endKey, nil
+}
+
+// @Tags region
+// @Summary List the frozen key ranges which are not expired.
+// @Produce json
+// @Success 200 {array} cluster.FrozenRange
+// @Router /regions/freeze [get]
+func (h *regionsHandler) GetFrozenRanges(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	h.rd.JSON(w, http.StatusOK, rc.GetFrozenRanges())
+}
+
+// @Tags region
+// @Summary List the latest region heartbeats which are rejected because their metadata is older than the record of PD.
+// @Produce json
+// @Success 200 {array} cluster.RejectedHeartbeat
+// @Router /regions/rejected-heartbeats [get]
+func (h *regionsHandler) GetRejectedHeartbeats(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	rejected := rc.GetRejectedHeartbeats()
+	if rejected == nil {
+		rejected = []cluster.RejectedHeartbeat{}
+	}
+	h.rd.JSON(w, http.StatusOK, rejected)
+}
+
 func (h *regionsHandler) GetTopNRegions(w http.ResponseWriter, r *http.Request, less func(a, b *core.RegionInfo) bool) {
 	rc := getCluster(r.Context())
 	limit := defaultRegionLimit
@@ -647,6 +1333,17 @@ func (h *regionsHandler) GetTopNRegions(w http.ResponseWriter, r *http.Request,
 	if limit > maxRegionLimit {
 		limit = maxRegionLimit
 	}
+	if ascStr := r.URL.Query().Get("asc"); ascStr != "" {
+		asc, err := strconv.ParseBool(ascStr)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if asc {
+			desc := less
+			less = func(a, b *core.RegionInfo) bool { return desc(b, a) }
+		}
+	}
 	regions := TopNRegions(rc.GetRegions(), less, limit)
 	regionsInfo := convertToAPIRegions(regions)
 	h.rd.JSON(w, http.StatusOK, regionsInfo)
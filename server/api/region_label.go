@@ -0,0 +1,112 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
+	"github.com/unrolled/render"
+)
+
+type regionLabelHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newRegionLabelHandler(s *server.Server, rd *render.Render) *regionLabelHandler {
+	return &regionLabelHandler{
+		svr: s,
+		rd:  rd,
+	}
+}
+
+// @Tags region_label
+// @Summary List all region label rules.
+// @Produce json
+// @Success 200 {array} cluster.LabelRule
+// @Router /config/region-label/rules [get]
+func (h *regionLabelHandler) GetAllRules(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	h.rd.JSON(w, http.StatusOK, rc.GetRegionLabelRules())
+}
+
+// @Tags region_label
+// @Summary Get a region label rule by ID.
+// @Param id path string true "Rule Id"
+// @Produce json
+// @Success 200 {object} cluster.LabelRule
+// @Failure 404 {string} string "The rule does not exist."
+// @Router /config/region-label/rule/{id} [get]
+func (h *regionLabelHandler) GetRule(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	rule := rc.GetRegionLabelRule(mux.Vars(r)["id"])
+	if rule == nil {
+		h.rd.JSON(w, http.StatusNotFound, "the rule does not exist")
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, rule)
+}
+
+// @Tags region_label
+// @Summary Update a region label rule. The regions labeled with `schedule=deny` are not scheduled except by the operators created by the admin. The rule expires after `ttl` if it is specified. Only receive hex format for keys.
+// @Accept json
+// @Param rule body cluster.LabelRule true "Parameters of region label rule"
+// @Produce json
+// @Success 200 {string} string "Update region label rule successfully."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/region-label/rule [post]
+func (h *regionLabelHandler) SetRule(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	var rule cluster.LabelRule
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &rule); err != nil {
+		return
+	}
+	if err := rc.SetRegionLabelRule(&rule); err != nil {
+		if errs.ErrRegionLabelRule.Equal(err) || errs.ErrHexDecodingString.Equal(err) {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "Update region label rule successfully.")
+}
+
+// @Tags region_label
+// @Summary Delete a region label rule.
+// @Param id path string true "Rule Id"
+// @Produce json
+// @Success 200 {string} string "Delete region label rule successfully."
+// @Failure 404 {string} string "The rule does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/region-label/rule/{id} [delete]
+func (h *regionLabelHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	ok, err := rc.DeleteRegionLabelRule(mux.Vars(r)["id"])
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !ok {
+		h.rd.JSON(w, http.StatusNotFound, "the rule does not exist")
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "Delete region label rule successfully.")
+}
@@ -0,0 +1,98 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
+)
+
+var _ = Suite(&testRegionLabelSuite{})
+
+type testRegionLabelSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testRegionLabelSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1/config/region-label", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testRegionLabelSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testRegionLabelSuite) TestRegionLabelRules(c *C) {
+	r1 := newTestRegionInfo(580, 13, []byte("l1"), []byte("l2"))
+	r2 := newTestRegionInfo(581, 14, []byte("l2"), []byte("l3"))
+	mustRegionHeartbeat(c, s.svr, r1)
+	mustRegionHeartbeat(c, s.svr, r2)
+	ruleURL := s.urlPrefix + "/rule"
+
+	for _, rule := range []*cluster.LabelRule{
+		{ID: "", Labels: []cluster.RegionLabel{{Key: "schedule", Value: "deny"}}},
+		{ID: "bad", Labels: nil},
+		{ID: "bad", Labels: []cluster.RegionLabel{{Key: "schedule"}}},
+		{ID: "bad", Labels: []cluster.RegionLabel{{Key: "schedule", Value: "deny"}}, HexStart: "zz"},
+		{ID: "bad", Labels: []cluster.RegionLabel{{Key: "schedule", Value: "deny"}}, HexStart: "02", HexEnd: "01"},
+	} {
+		data, err := json.Marshal(rule)
+		c.Assert(err, IsNil)
+		c.Assert(postJSON(testDialClient, ruleURL, data), NotNil, Commentf("%s", data))
+	}
+
+	rule := &cluster.LabelRule{
+		ID:       "batch-job",
+		Labels:   []cluster.RegionLabel{{Key: "schedule", Value: "deny"}, {Key: "owner", Value: "etl"}},
+		HexStart: hex.EncodeToString([]byte("l1")),
+		HexEnd:   hex.EncodeToString([]byte("l2")),
+	}
+	data, err := json.Marshal(rule)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, ruleURL, data), IsNil)
+	rc := s.svr.GetRaftCluster()
+	c.Assert(rc.IsRegionScheduleDenied(r1), IsTrue)
+	c.Assert(rc.IsRegionScheduleDenied(r2), IsFalse)
+	c.Assert(rc.GetRegionLabel(r1, "owner"), Equals, "etl")
+
+	var got cluster.LabelRule
+	c.Assert(readJSON(testDialClient, ruleURL+"/batch-job", &got), IsNil)
+	c.Assert(got.Labels, DeepEquals, rule.Labels)
+	c.Assert(got.HexStart, Equals, rule.HexStart)
+	var rules []*cluster.LabelRule
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/rules", &rules), IsNil)
+	c.Assert(rules, HasLen, 1)
+	c.Assert(rules[0].ID, Equals, "batch-job")
+
+	status, _ := requestStatusBody(c, testDialClient, http.MethodDelete, ruleURL+"/batch-job")
+	c.Assert(status, Equals, http.StatusOK)
+	c.Assert(rc.IsRegionScheduleDenied(r1), IsFalse)
+	status, _ = requestStatusBody(c, testDialClient, http.MethodDelete, ruleURL+"/batch-job")
+	c.Assert(status, Equals, http.StatusNotFound)
+	status, _ = requestStatusBody(c, testDialClient, http.MethodGet, ruleURL+"/batch-job")
+	c.Assert(status, Equals, http.StatusNotFound)
+}
@@ -15,20 +15,27 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/versioninfo"
 )
 
 var _ = Suite(&testRegionSuite{})
@@ -99,6 +106,24 @@ func (s *testRegionSuite) TestRegion(c *C) {
 	err = readJSON(testDialClient, url, r2)
 	c.Assert(err, IsNil)
 	c.Assert(r2, DeepEquals, NewRegionInfo(r))
+
+	// The leader is transferred and then transferred back.
+	peer := &metapb.Peer{Id: 20, StoreId: 2}
+	mustRegionHeartbeat(c, s.svr, r.Clone(core.WithAddPeer(peer), core.WithLeader(peer)))
+	mustRegionHeartbeat(c, s.svr, r)
+	url = fmt.Sprintf("%s/region/id/%d/history", s.urlPrefix, r.GetID())
+	var history []cluster.RegionEvent
+	err = readJSON(testDialClient, url, &history)
+	c.Assert(err, IsNil)
+	c.Assert(len(history) >= 2, IsTrue)
+	history = history[len(history)-2:]
+	c.Assert(history[0].Type, Equals, cluster.RegionEventLeaderTransfer)
+	c.Assert(history[0].Detail, Equals, "from store 1 to store 2")
+	c.Assert(history[1].Detail, Equals, "from store 2 to store 1")
+	url = fmt.Sprintf("%s/region/id/%d/history", s.urlPrefix, 100)
+	err = readJSON(testDialClient, url, &history)
+	c.Assert(err, IsNil)
+	c.Assert(history, HasLen, 0)
 }
 
 func (s *testRegionSuite) TestRegionCheck(c *C) {
@@ -138,6 +163,35 @@ func (s *testRegionSuite) TestRegionCheck(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(r5, DeepEquals, &RegionsInfo{Count: 1, Regions: []*RegionInfo{NewRegionInfo(r)}})
 
+	url = fmt.Sprintf("%s/regions/check/%s", s.urlPrefix, "undersized-region")
+	r8 := &RegionsInfo{}
+	err = readJSON(testDialClient, url, r8)
+	c.Assert(err, IsNil)
+	c.Assert(r8, DeepEquals, &RegionsInfo{Count: 1, Regions: []*RegionInfo{NewRegionInfo(r)}})
+
+	url = fmt.Sprintf("%s/regions/check/%s?threshold=1h", s.urlPrefix, "stale-heartbeat")
+	r10 := &RegionsInfo{}
+	err = readJSON(testDialClient, url, r10)
+	c.Assert(err, IsNil)
+	c.Assert(r10, DeepEquals, &RegionsInfo{Count: 0, Regions: []*RegionInfo{}})
+	url = fmt.Sprintf("%s/regions/check/%s?since=1h&until=1ns", s.urlPrefix, "stale-heartbeat")
+	c.Assert(readJSON(testDialClient, url, r10), IsNil)
+	c.Assert(r10.Count, Greater, 0)
+	for _, query := range []string{"threshold=abc", "since=-1h", "since=1m&until=1h"} {
+		url = fmt.Sprintf("%s/regions/check/%s?%s", s.urlPrefix, "stale-heartbeat", query)
+		err = readJSON(testDialClient, url, r10)
+		c.Assert(err, NotNil)
+		c.Assert(strings.Contains(err.Error(), "400"), IsTrue)
+	}
+
+	r = r.Clone(core.SetApproximateSize(int64(s.svr.GetScheduleConfig().RegionMaxSize)))
+	mustRegionHeartbeat(c, s.svr, r)
+	url = fmt.Sprintf("%s/regions/check/%s", s.urlPrefix, "oversized-region")
+	r9 := &RegionsInfo{}
+	err = readJSON(testDialClient, url, r9)
+	c.Assert(err, IsNil)
+	c.Assert(r9, DeepEquals, &RegionsInfo{Count: 1, Regions: []*RegionInfo{NewRegionInfo(r)}})
+
 	r = r.Clone(core.SetApproximateSize(1))
 	mustRegionHeartbeat(c, s.svr, r)
 	url = fmt.Sprintf("%s/regions/check/%s", s.urlPrefix, "hist-size")
@@ -170,8 +224,8 @@ func (s *testRegionSuite) TestRegionCheck(c *C) {
 func (s *testRegionSuite) TestRegions(c *C) {
 	rs := []*core.RegionInfo{
 		newTestRegionInfo(2, 1, []byte("a"), []byte("b")),
-		newTestRegionInfo(3, 1, []byte("b"), []byte("c")),
-		newTestRegionInfo(4, 2, []byte("c"), []byte("d")),
+		newTestRegionInfo(3, 1, []byte("b"), []byte("c"), core.SetApproximateSize(100), core.SetApproximateKeys(1000)),
+		newTestRegionInfo(4, 2, []byte("c"), []byte("d"), core.SetApproximateSize(300), core.SetApproximateKeys(3000)),
 	}
 	regions := make([]*RegionInfo, 0, len(rs))
 	for _, r := range rs {
@@ -191,6 +245,74 @@ func (s *testRegionSuite) TestRegions(c *C) {
 		c.Assert(r.ApproximateSize, Equals, regions[i].ApproximateSize)
 		c.Assert(r.ApproximateKeys, Equals, regions[i].ApproximateKeys)
 	}
+
+	// paginated by start_key and limit
+	c.Assert(readJSON(testDialClient, url+"?limit=2", RegionsInfo), IsNil)
+	c.Assert(RegionsInfo.Count, Equals, 2)
+	c.Assert(RegionsInfo.Regions[0].ID, Equals, uint64(2))
+	c.Assert(RegionsInfo.Regions[1].ID, Equals, uint64(3))
+	c.Assert(readJSON(testDialClient, url+"?start_key=b&limit=2", RegionsInfo), IsNil)
+	c.Assert(RegionsInfo.Count, Equals, 2)
+	c.Assert(RegionsInfo.Regions[0].ID, Equals, uint64(3))
+	c.Assert(RegionsInfo.Regions[1].ID, Equals, uint64(4))
+	c.Assert(readJSON(testDialClient, url+"?start_key=c", RegionsInfo), IsNil)
+	c.Assert(RegionsInfo.Count, Equals, 1)
+	c.Assert(RegionsInfo.Regions[0].ID, Equals, uint64(4))
+	c.Assert(readJSON(testDialClient, url+"?limit=0", RegionsInfo), NotNil)
+	c.Assert(readJSON(testDialClient, url+"?limit=foo", RegionsInfo), NotNil)
+
+	// a region per line
+	resp, err := testDialClient.Get(url + "?format=ndjson")
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.Header.Get("Content-Type"), Equals, "application/x-ndjson")
+	dec := json.NewDecoder(resp.Body)
+	ids := make(map[uint64]struct{})
+	for dec.More() {
+		region := &RegionInfo{}
+		c.Assert(dec.Decode(region), IsNil)
+		ids[region.ID] = struct{}{}
+	}
+	c.Assert(ids, DeepEquals, map[uint64]struct{}{2: {}, 3: {}, 4: {}})
+
+	// filtered by the sizes, the keys, the store and the key prefix
+	for query, expected := range map[string][]uint64{
+		"min_size=100":                   {3, 4},
+		"min_size=50&max_size=200":       {3},
+		"max_keys=1000":                  {2, 3},
+		"min_keys=2000&store_id=2":       {4},
+		"store_id=1":                     {2, 3},
+		"store_id=3":                     {},
+		"key_prefix=b":                   {3},
+		"key_prefix=b&min_size=200":      {},
+		"min_size=0&limit=1":             {2},
+		"start_key=b&min_size=200":       {4},
+		"start_key=a&store_id=1&limit=1": {2},
+		"store_id=2&limit=1":             {4},
+		"key_prefix=c&limit=1":           {4},
+		"start_key=c&key_prefix=b":       {},
+	} {
+		c.Assert(readJSON(testDialClient, url+"?"+query, RegionsInfo), IsNil, Commentf("query %s", query))
+		sort.Slice(RegionsInfo.Regions, func(i, j int) bool {
+			return RegionsInfo.Regions[i].ID < RegionsInfo.Regions[j].ID
+		})
+		ids := make([]uint64, 0, len(RegionsInfo.Regions))
+		for _, r := range RegionsInfo.Regions {
+			ids = append(ids, r.ID)
+		}
+		c.Assert(ids, DeepEquals, expected, Commentf("query %s", query))
+	}
+	c.Assert(readJSON(testDialClient, url+"?min_size=-1", RegionsInfo), NotNil)
+	c.Assert(readJSON(testDialClient, url+"?store_id=foo", RegionsInfo), NotNil)
+
+	// get the regions by ids, the regions which do not exist are skipped
+	url = fmt.Sprintf("%s/regions/by-ids", s.urlPrefix)
+	c.Assert(readJSON(testDialClient, url+"?ids=4,2,100", RegionsInfo), IsNil)
+	c.Assert(RegionsInfo.Count, Equals, 2)
+	c.Assert(RegionsInfo.Regions[0].ID, Equals, uint64(4))
+	c.Assert(RegionsInfo.Regions[1].ID, Equals, uint64(2))
+	c.Assert(readJSON(testDialClient, url+"?ids=2,foo", RegionsInfo), NotNil)
+	c.Assert(readJSON(testDialClient, url, RegionsInfo), NotNil)
 }
 
 func (s *testRegionSuite) TestStoreRegions(c *C) {
@@ -228,6 +350,23 @@ func (s *testRegionSuite) TestStoreRegions(c *C) {
 	err = readJSON(testDialClient, url, r6)
 	c.Assert(err, IsNil)
 	c.Assert(r6.Count, Equals, len(regionIDs))
+
+	url = fmt.Sprintf("%s/regions/store/%d?role=leader", s.urlPrefix, 2)
+	r7 := &RegionsInfo{}
+	err = readJSON(testDialClient, url, r7)
+	c.Assert(err, IsNil)
+	c.Assert(r7.Count, Equals, 1)
+	c.Assert(r7.Regions[0].ID, Equals, uint64(4))
+
+	url = fmt.Sprintf("%s/regions/store/%d?role=follower", s.urlPrefix, 2)
+	r8 := &RegionsInfo{}
+	err = readJSON(testDialClient, url, r8)
+	c.Assert(err, IsNil)
+	c.Assert(r8.Count, Equals, 0)
+
+	url = fmt.Sprintf("%s/regions/store/%d?role=witness", s.urlPrefix, 2)
+	err = readJSON(testDialClient, url, &RegionsInfo{})
+	c.Assert(err, NotNil)
 }
 
 func (s *testRegionSuite) TestTopFlow(c *C) {
@@ -244,6 +383,10 @@ func (s *testRegionSuite) TestTopFlow(c *C) {
 	s.checkTopRegions(c, fmt.Sprintf("%s/regions/confver?limit=2", s.urlPrefix), []uint64{3, 2})
 	s.checkTopRegions(c, fmt.Sprintf("%s/regions/version", s.urlPrefix), []uint64{2, 3, 1})
 	s.checkTopRegions(c, fmt.Sprintf("%s/regions/version?limit=2", s.urlPrefix), []uint64{2, 3})
+	s.checkTopRegions(c, fmt.Sprintf("%s/regions/writeflow?asc=true", s.urlPrefix), []uint64{3, 1, 2})
+	s.checkTopRegions(c, fmt.Sprintf("%s/regions/readflow?asc=true&limit=2", s.urlPrefix), []uint64{2, 3})
+	err := readJSON(testDialClient, fmt.Sprintf("%s/regions/version?asc=foo", s.urlPrefix), &RegionsInfo{})
+	c.Assert(err, NotNil)
 }
 
 func (s *testRegionSuite) TestTopSize(c *C) {
@@ -261,6 +404,21 @@ func (s *testRegionSuite) TestTopSize(c *C) {
 	s.checkTopRegions(c, fmt.Sprintf("%s/regions/size?limit=%d", s.urlPrefix, 2), []uint64{7, 8})
 }
 
+func (s *testRegionSuite) TestTopKeys(c *C) {
+	baseOpt := []core.RegionCreateOption{core.SetRegionConfVer(3), core.SetRegionVersion(3)}
+	opt := core.SetApproximateKeys(1000)
+	r1 := newTestRegionInfo(7, 1, []byte("a"), []byte("b"), append(baseOpt, opt)...)
+	mustRegionHeartbeat(c, s.svr, r1)
+	opt = core.SetApproximateKeys(800)
+	r2 := newTestRegionInfo(8, 1, []byte("b"), []byte("c"), append(baseOpt, opt)...)
+	mustRegionHeartbeat(c, s.svr, r2)
+	opt = core.SetApproximateKeys(900)
+	r3 := newTestRegionInfo(9, 1, []byte("c"), []byte("d"), append(baseOpt, opt)...)
+	mustRegionHeartbeat(c, s.svr, r3)
+	// query with limit
+	s.checkTopRegions(c, fmt.Sprintf("%s/regions/keys?limit=%d", s.urlPrefix, 2), []uint64{7, 9})
+}
+
 func (s *testRegionSuite) TestAccelerateRegionsScheduleInRange(c *C) {
 	r1 := newTestRegionInfo(557, 13, []byte("a1"), []byte("a2"))
 	r2 := newTestRegionInfo(558, 14, []byte("a2"), []byte("a3"))
@@ -299,6 +457,63 @@ func (s *testRegionSuite) TestScatterRegions(c *C) {
 	c.Assert(op2 != nil, Equals, true)
 }
 
+func (s *testRegionSuite) TestRegionTopology(c *C) {
+	// The snapshots are not taken by default.
+	url := fmt.Sprintf("%s/regions/topology?time=%d", s.urlPrefix, time.Now().Unix())
+	status, _ := requestStatusBody(c, testDialClient, http.MethodGet, url)
+	c.Assert(status, Equals, http.StatusNotFound)
+
+	for _, query := range []string{"", "time=now", "time=1&id=-1"} {
+		status, _ := requestStatusBody(c, testDialClient, http.MethodGet, s.urlPrefix+"/regions/topology?"+query)
+		c.Assert(status, Equals, http.StatusBadRequest, Commentf("%s", query))
+	}
+}
+
+func (s *testRegionSuite) TestVerifyRegions(c *C) {
+	// The store reports its local regions by the status server.
+	storeSvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/region/911":
+			w.Write([]byte(`{"id":911,"region_state":{"id":911,"epoch":{"conf_ver":1,"version":1}}}`))
+		case "/region/912":
+			w.Write([]byte(`{"id":912,"region_state":{"id":912,"epoch":{"conf_ver":1,"version":0}}}`))
+		default:
+			http.Error(w, "region not found", http.StatusNotFound)
+		}
+	}))
+	defer storeSvr.Close()
+	_, err := s.svr.PutStore(context.Background(), &pdpb.PutStoreRequest{
+		Header: &pdpb.RequestHeader{ClusterId: s.svr.ClusterID()},
+		Store: &metapb.Store{
+			Id:            31,
+			Address:       "tikv31",
+			StatusAddress: strings.TrimPrefix(storeSvr.URL, "http://"),
+			Version:       versioninfo.MinSupportedVersion(versioninfo.Version2_0).String(),
+		},
+	})
+	c.Assert(err, IsNil)
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(911, 31, []byte("v1"), []byte("v2")))
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(912, 31, []byte("v2"), []byte("v3")))
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(913, 31, []byte("v3"), []byte("v4")))
+
+	report := &cluster.RegionVerifyReport{}
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/regions/verify?store=31", report), IsNil)
+	c.Assert(report.Stores, HasLen, 1)
+	c.Assert(report.Stores[0].Error, Equals, "")
+	c.Assert(report.Stores[0].Checked, Equals, 3)
+	c.Assert(report.Stores[0].Error, Equals, "")
+	c.Assert(report.Mismatches, HasLen, 2)
+	for i, kind := range []string{cluster.RegionMismatchStale, cluster.RegionMismatchMissing} {
+		c.Assert(report.Mismatches[i].RegionID, Equals, uint64(912+i))
+		c.Assert(report.Mismatches[i].Kind, Equals, kind)
+	}
+
+	status, _ := requestStatusBody(c, testDialClient, http.MethodGet, s.urlPrefix+"/regions/verify?store=abc")
+	c.Assert(status, Equals, http.StatusBadRequest)
+	status, _ = requestStatusBody(c, testDialClient, http.MethodGet, s.urlPrefix+"/regions/verify?store=1000")
+	c.Assert(status, Equals, http.StatusNotFound)
+}
+
 func (s *testRegionSuite) TestSplitRegions(c *C) {
 	r1 := newTestRegionInfo(601, 13, []byte("aaa"), []byte("ggg"))
 	r1.GetMeta().Peers = append(r1.GetMeta().Peers, &metapb.Peer{Id: 5, StoreId: 13}, &metapb.Peer{Id: 6, StoreId: 13})
@@ -429,6 +644,37 @@ func (s *testGetRegionSuite) TestScanRegionByKey(c *C) {
 	}
 }
 
+func (s *testGetRegionSuite) TestScanRegionsInRange(c *C) {
+	r1 := newTestRegionInfo(6, 1, []byte("h"), []byte("i"))
+	r2 := newTestRegionInfo(7, 1, []byte("i"), []byte("k"))
+	r3 := newTestRegionInfo(8, 2, []byte("k"), []byte("m"))
+	mustRegionHeartbeat(c, s.svr, r1)
+	mustRegionHeartbeat(c, s.svr, r2)
+	mustRegionHeartbeat(c, s.svr, r3)
+
+	testCases := []struct {
+		query     string
+		regionIDs []uint64
+	}{
+		{"start_key=h&end_key=k", []uint64{6, 7}},
+		{"start_key=j&end_key=l", []uint64{7, 8}},
+		{"start_key=h1&end_key=ka&limit=2", []uint64{6, 7}},
+		{"start_key=i&end_key=i1", []uint64{7}},
+	}
+	for _, testCase := range testCases {
+		url := fmt.Sprintf("%s/regions/range?%s", s.urlPrefix, testCase.query)
+		regions := &RegionsInfo{}
+		c.Assert(readJSON(testDialClient, url, regions), IsNil)
+		c.Assert(regions.Count, Equals, len(testCase.regionIDs))
+		for i, id := range testCase.regionIDs {
+			c.Assert(regions.Regions[i].ID, Equals, id)
+		}
+	}
+	regions := &RegionsInfo{}
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/regions/range?start_key=k&end_key=h", s.urlPrefix), regions), NotNil)
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/regions/range?start_key=h&limit=-1", s.urlPrefix), regions), NotNil)
+}
+
 // Create n regions (0..n) of n stores (0..n).
 // Each region contains np peers, the first peer is the leader.
 // (copied from server/cluster_test.go)
@@ -488,6 +734,29 @@ func BenchmarkHexRegionKey(b *testing.B) {
 	}
 }
 
+func (s *testGetRegionSuite) TestLabelRegions(c *C) {
+	mustPutStore(c, s.svr, 21, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "zone", Value: "z1"}, {Key: "disk", Value: "ssd"}})
+	mustPutStore(c, s.svr, 22, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "zone", Value: "z2"}})
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(801, 21, []byte("1"), []byte("2")))
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(802, 22, []byte("2"), []byte("3")))
+
+	url := fmt.Sprintf("%s/regions/label?label=zone=z1&label=disk=ssd", s.urlPrefix)
+	regions := &RegionsInfo{}
+	c.Assert(readJSON(testDialClient, url, regions), IsNil)
+	c.Assert(regions.Count, Equals, 1)
+	c.Assert(regions.Regions[0].ID, Equals, uint64(801))
+
+	url = fmt.Sprintf("%s/regions/label?label=zone=z2&label=disk=ssd", s.urlPrefix)
+	regions = &RegionsInfo{}
+	c.Assert(readJSON(testDialClient, url, regions), IsNil)
+	c.Assert(regions.Count, Equals, 0)
+
+	url = fmt.Sprintf("%s/regions/label", s.urlPrefix)
+	c.Assert(readJSON(testDialClient, url, &RegionsInfo{}), NotNil)
+	url = fmt.Sprintf("%s/regions/label?label=zone", s.urlPrefix)
+	c.Assert(readJSON(testDialClient, url, &RegionsInfo{}), NotNil)
+}
+
 func BenchmarkHexRegionKeyStr(b *testing.B) {
 	key := []byte("region_number_infinity")
 	b.ResetTimer()
@@ -495,3 +764,129 @@ func BenchmarkHexRegionKeyStr(b *testing.B) {
 		_ = core.HexRegionKeyStr(key)
 	}
 }
+
+var _ = Suite(&testGCRangeSuite{})
+
+type testGCRangeSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testGCRangeSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testGCRangeSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testGCRangeSuite) TestGCRange(c *C) {
+	r1 := newTestRegionInfo(560, 13, []byte("g1"), []byte("g2"))
+	r2 := newTestRegionInfo(561, 14, []byte("g2"), []byte("g3"))
+	mustRegionHeartbeat(c, s.svr, r1)
+	mustRegionHeartbeat(c, s.svr, r2)
+	gcRangeURL := fmt.Sprintf("%s/regions/gc-range", s.urlPrefix)
+
+	body := fmt.Sprintf(`{"start_key":"%s", "end_key": "%s"}`, hex.EncodeToString([]byte("g2")), hex.EncodeToString([]byte("g1")))
+	c.Assert(postJSON(testDialClient, gcRangeURL, []byte(body)), NotNil)
+	body = fmt.Sprintf(`{"start_key":"%s", "end_key": "%s", "ttl": "-1s"}`, hex.EncodeToString([]byte("g1")), hex.EncodeToString([]byte("g2")))
+	c.Assert(postJSON(testDialClient, gcRangeURL, []byte(body)), NotNil)
+
+	body = fmt.Sprintf(`{"start_key":"%s", "end_key": "%s", "ttl": "10m"}`, hex.EncodeToString([]byte("g1")), hex.EncodeToString([]byte("g2")))
+	c.Assert(postJSON(testDialClient, gcRangeURL, []byte(body)), IsNil)
+	rc := s.svr.GetRaftCluster()
+	c.Assert(rc.IsRegionInGCRange(r1), IsTrue)
+	c.Assert(rc.IsRegionInGCRange(r2), IsFalse)
+
+	var ranges []*cluster.GCRange
+	c.Assert(readJSON(testDialClient, gcRangeURL, &ranges), IsNil)
+	c.Assert(ranges, HasLen, 1)
+	c.Assert(ranges[0].HexStart, Equals, hex.EncodeToString([]byte("g1")))
+	c.Assert(ranges[0].HexEnd, Equals, hex.EncodeToString([]byte("g2")))
+}
+
+func (s *testGCRangeSuite) TestFreezeRange(c *C) {
+	r1 := newTestRegionInfo(570, 13, []byte("f1"), []byte("f2"))
+	r2 := newTestRegionInfo(571, 14, []byte("f2"), []byte("f3"))
+	r3 := newTestRegionInfo(572, 14, []byte("f3"), []byte("f4"))
+	mustRegionHeartbeat(c, s.svr, r1)
+	mustRegionHeartbeat(c, s.svr, r2)
+	mustRegionHeartbeat(c, s.svr, r3)
+	freezeURL := fmt.Sprintf("%s/regions/freeze", s.urlPrefix)
+
+	body := fmt.Sprintf(`{"start_key":"%s", "end_key": "%s"}`, hex.EncodeToString([]byte("f3")), hex.EncodeToString([]byte("f1")))
+	c.Assert(postJSON(testDialClient, freezeURL, []byte(body)), NotNil)
+	body = fmt.Sprintf(`{"start_key":"%s", "end_key": "%s", "ttl": "0s"}`, hex.EncodeToString([]byte("f1")), hex.EncodeToString([]byte("f3")))
+	c.Assert(postJSON(testDialClient, freezeURL, []byte(body)), NotNil)
+
+	// The regions overlapping with the range are frozen.
+	body = fmt.Sprintf(`{"start_key":"%s", "end_key": "%s", "ttl": "10m"}`, hex.EncodeToString([]byte("f15")), hex.EncodeToString([]byte("f25")))
+	c.Assert(postJSON(testDialClient, freezeURL, []byte(body)), IsNil)
+	rc := s.svr.GetRaftCluster()
+	c.Assert(rc.IsRegionScheduleDenied(r1), IsTrue)
+	c.Assert(rc.IsRegionScheduleDenied(r2), IsTrue)
+	c.Assert(rc.IsRegionScheduleDenied(r3), IsFalse)
+
+	var ranges []*cluster.FrozenRange
+	c.Assert(readJSON(testDialClient, freezeURL, &ranges), IsNil)
+	c.Assert(ranges, HasLen, 1)
+	c.Assert(ranges[0].HexStart, Equals, hex.EncodeToString([]byte("f15")))
+	c.Assert(ranges[0].ExpireTime.After(time.Now().Add(9*time.Minute)), IsTrue)
+	// The frozen range is a region label rule.
+	c.Assert(rc.GetRegionLabelRules(), HasLen, 1)
+
+	unfreezeURL := fmt.Sprintf("%s?start_key=%s&end_key=%s", freezeURL, hex.EncodeToString([]byte("f15")), hex.EncodeToString([]byte("f25")))
+	status, _ := requestStatusBody(c, testDialClient, http.MethodDelete, unfreezeURL)
+	c.Assert(status, Equals, http.StatusOK)
+	c.Assert(rc.IsRegionScheduleDenied(r1), IsFalse)
+	status, _ = requestStatusBody(c, testDialClient, http.MethodDelete, unfreezeURL)
+	c.Assert(status, Equals, http.StatusNotFound)
+	status, _ = requestStatusBody(c, testDialClient, http.MethodDelete, freezeURL+"?start_key=zz")
+	c.Assert(status, Equals, http.StatusBadRequest)
+}
+
+var _ = Suite(&testRejectedHeartbeatSuite{})
+
+type testRejectedHeartbeatSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testRejectedHeartbeatSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testRejectedHeartbeatSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testRejectedHeartbeatSuite) TestRejectedHeartbeats(c *C) {
+	r := newTestRegionInfo(900, 1, []byte("x1"), []byte("x2"), core.SetRegionConfVer(2), core.SetRegionVersion(2))
+	mustRegionHeartbeat(c, s.svr, r)
+	stale := r.Clone(core.SetRegionConfVer(1))
+	c.Assert(s.svr.GetRaftCluster().HandleRegionHeartbeat(stale), NotNil)
+
+	var rejected []cluster.RejectedHeartbeat
+	err := readJSON(testDialClient, fmt.Sprintf("%s/regions/rejected-heartbeats", s.urlPrefix), &rejected)
+	c.Assert(err, IsNil)
+	c.Assert(len(rejected), Greater, 0)
+	last := rejected[len(rejected)-1]
+	c.Assert(last.RegionID, Equals, uint64(900))
+	c.Assert(last.Reason, Equals, cluster.RejectReasonConfVer)
+	c.Assert(last.Epoch.GetConfVer(), Equals, uint64(1))
+	c.Assert(last.OriginEpoch.GetConfVer(), Equals, uint64(2))
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/tikv/pd/pkg/codec"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/statistics"
+	"github.com/unrolled/render"
+)
+
+type reportHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newReportHandler(svr *server.Server, rd *render.Render) *reportHandler {
+	return &reportHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// KeyRange is a key range in hex format.
+type KeyRange struct {
+	StartKey string `json:"start_key"`
+	EndKey   string `json:"end_key"`
+}
+
+// ReplicationHealth summarizes the replication health of a group of regions.
+type ReplicationHealth struct {
+	// TableID is the table which the regions belong to, 0 means the regions
+	// do not start with a table key.
+	TableID         int64   `json:"table_id,omitempty"`
+	RegionCount     int     `json:"region_count"`
+	FullyReplicated int     `json:"fully_replicated"`
+	Percentage      float64 `json:"fully_replicated_percentage"`
+	MissPeerCount   int     `json:"miss_peer_count"`
+	DownPeerCount   int     `json:"down_peer_count"`
+	// DownPeerRanges are the merged key ranges of the regions with down peers.
+	DownPeerRanges []KeyRange `json:"down_peer_ranges,omitempty"`
+}
+
+// ReplicationReport is the replication health of the cluster.
+type ReplicationReport struct {
+	Summary *ReplicationHealth   `json:"summary"`
+	Tables  []*ReplicationHealth `json:"tables,omitempty"`
+}
+
+// @Tags report
+// @Summary Get the replication health of the cluster.
+// @Param by query string false "Group the regions by" Enums(table)
+// @Produce json
+// @Success 200 {object} ReplicationReport
+// @Failure 400 {string} string "The input is invalid."
+// @Router /report/replication [get]
+func (h *reportHandler) GetReplication(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	by := r.URL.Query().Get("by")
+	if by != "" && by != "table" {
+		h.rd.JSON(w, http.StatusBadRequest, "only support grouping by table")
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, buildReplicationReport(rc, by == "table"))
+}
+
+func buildReplicationReport(rc *cluster.RaftCluster, byTable bool) *ReplicationReport {
+	missPeers := regionIDSet(rc.GetRegionStatsByType(statistics.MissPeer))
+	unhealthy := regionIDSet(rc.GetRegionStatsByType(statistics.PendingPeer))
+	for id := range regionIDSet(rc.GetRegionStatsByType(statistics.OfflinePeer)) {
+		unhealthy[id] = struct{}{}
+	}
+
+	report := &ReplicationReport{Summary: &ReplicationHealth{}}
+	tables := make(map[int64]*ReplicationHealth)
+	// regions are scanned in key order, so that the ranges can be merged.
+	for _, region := range rc.ScanRegions(nil, nil, -1) {
+		groups := []*ReplicationHealth{report.Summary}
+		if byTable {
+			tableID := codec.Key(region.GetStartKey()).TableID()
+			table, ok := tables[tableID]
+			if !ok {
+				table = &ReplicationHealth{TableID: tableID}
+				tables[tableID] = table
+			}
+			groups = append(groups, table)
+		}
+		_, miss := missPeers[region.GetID()]
+		_, bad := unhealthy[region.GetID()]
+		down := len(region.GetDownPeers()) > 0
+		for _, s := range groups {
+			s.observe(region, miss, down, !miss && !bad && !down)
+		}
+	}
+
+	report.Summary.adjust()
+	if byTable {
+		report.Tables = make([]*ReplicationHealth, 0, len(tables))
+		for _, table := range tables {
+			table.adjust()
+			report.Tables = append(report.Tables, table)
+		}
+		// the tables at risk come first.
+		sort.Slice(report.Tables, func(i, j int) bool {
+			if report.Tables[i].Percentage != report.Tables[j].Percentage {
+				return report.Tables[i].Percentage < report.Tables[j].Percentage
+			}
+			return report.Tables[i].TableID < report.Tables[j].TableID
+		})
+	}
+	return report
+}
+
+func (s *ReplicationHealth) observe(region *core.RegionInfo, miss, down, healthy bool) {
+	s.RegionCount++
+	if healthy {
+		s.FullyReplicated++
+	}
+	if miss {
+		s.MissPeerCount++
+	}
+	if !down {
+		return
+	}
+	s.DownPeerCount++
+	startKey := core.HexRegionKeyStr(region.GetStartKey())
+	endKey := core.HexRegionKeyStr(region.GetEndKey())
+	if n := len(s.DownPeerRanges); n > 0 && s.DownPeerRanges[n-1].EndKey == startKey && startKey != "" {
+		s.DownPeerRanges[n-1].EndKey = endKey
+		return
+	}
+	s.DownPeerRanges = append(s.DownPeerRanges, KeyRange{StartKey: startKey, EndKey: endKey})
+}
+
+func (s *ReplicationHealth) adjust() {
+	if s.RegionCount == 0 {
+		s.Percentage = 100
+		return
+	}
+	s.Percentage = float64(s.FullyReplicated) * 100 / float64(s.RegionCount)
+}
+
+func regionIDSet(regions []*core.RegionInfo) map[uint64]struct{} {
+	set := make(map[uint64]struct{}, len(regions))
+	for _, region := range regions {
+		set[region.GetID()] = struct{}{}
+	}
+	return set
+}
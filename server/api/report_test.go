@@ -0,0 +1,94 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/codec"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/core"
+)
+
+var _ = Suite(&testReportSuite{})
+
+type testReportSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testReportSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1/report", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+	for id := uint64(1); id <= 3; id++ {
+		mustPutStore(c, s.svr, id, metapb.StoreState_Up, nil)
+	}
+}
+
+func (s *testReportSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testReportSuite) TestReplication(c *C) {
+	table12 := codec.EncodeBytes(codec.GenerateTableKey(12))
+	row12 := codec.EncodeBytes(codec.GenerateRowKey(12, 5))
+	table13 := codec.EncodeBytes(codec.GenerateTableKey(13))
+	newRegion := func(id uint64, start, end []byte, opts ...core.RegionCreateOption) *core.RegionInfo {
+		opts = append(opts, core.SetPeers([]*metapb.Peer{
+			{Id: id*10 + 1, StoreId: 1},
+			{Id: id*10 + 2, StoreId: 2},
+			{Id: id*10 + 3, StoreId: 3},
+		}))
+		return newTestRegionInfo(id, 1, start, end, opts...)
+	}
+	downPeer := func(id uint64) core.RegionCreateOption {
+		return core.WithDownPeers([]*pdpb.PeerStats{{Peer: &metapb.Peer{Id: id*10 + 3, StoreId: 3}, DownSeconds: 3600}})
+	}
+	mustRegionHeartbeat(c, s.svr, newRegion(2, []byte(""), table12))
+	mustRegionHeartbeat(c, s.svr, newRegion(3, table12, row12, downPeer(3)))
+	mustRegionHeartbeat(c, s.svr, newRegion(4, row12, table13, downPeer(4)))
+	mustRegionHeartbeat(c, s.svr, newRegion(5, table13, []byte("")))
+
+	report := &ReplicationReport{}
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/replication", report), IsNil)
+	c.Assert(report.Summary.RegionCount, Equals, 4)
+	c.Assert(report.Summary.DownPeerCount, Equals, 2)
+	c.Assert(report.Summary.FullyReplicated, Equals, 2)
+	c.Assert(report.Summary.Percentage, Equals, 50.0)
+	c.Assert(report.Tables, HasLen, 0)
+
+	report = &ReplicationReport{}
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/replication?by=table", report), IsNil)
+	c.Assert(report.Tables, HasLen, 3)
+	// the table at risk comes first.
+	c.Assert(report.Tables[0].TableID, Equals, int64(12))
+	c.Assert(report.Tables[0].Percentage, Equals, 0.0)
+	c.Assert(report.Tables[0].DownPeerRanges, DeepEquals, []KeyRange{
+		{StartKey: core.HexRegionKeyStr(table12), EndKey: core.HexRegionKeyStr(table13)},
+	})
+	c.Assert(report.Tables[1].TableID, Equals, int64(0))
+	c.Assert(report.Tables[2].TableID, Equals, int64(13))
+	c.Assert(report.Tables[2].Percentage, Equals, 100.0)
+
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/replication?by=store", report), NotNil)
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/config"
 	"github.com/unrolled/render"
 )
 
@@ -59,6 +60,7 @@ func createRouter(ctx context.Context, prefix string, svr *server.Server) *mux.R
 	operatorHandler := newOperatorHandler(handler, rd)
 	apiRouter.HandleFunc("/operators", operatorHandler.List).Methods("GET")
 	apiRouter.HandleFunc("/operators", operatorHandler.Post).Methods("POST")
+	apiRouter.HandleFunc("/operators/history", operatorHandler.History).Methods("GET")
 	apiRouter.HandleFunc("/operators/{region_id}", operatorHandler.Get).Methods("GET")
 	apiRouter.HandleFunc("/operators/{region_id}", operatorHandler.Delete).Methods("DELETE")
 
@@ -74,11 +76,18 @@ func createRouter(ctx context.Context, prefix string, svr *server.Server) *mux.R
 	clusterHandler := newClusterHandler(svr, rd)
 	apiRouter.Handle("/cluster", clusterHandler).Methods("GET")
 	apiRouter.HandleFunc("/cluster/status", clusterHandler.GetClusterStatus).Methods("GET")
+	clusterRouter.HandleFunc("/cluster/import-mode", clusterHandler.GetImportMode).Methods("GET")
+	clusterRouter.HandleFunc("/cluster/import-mode", clusterHandler.EnableImportMode).Methods("POST")
+	clusterRouter.HandleFunc("/cluster/import-mode", clusterHandler.DisableImportMode).Methods("DELETE")
+	clusterRouter.HandleFunc("/cluster/consistency", clusterHandler.CheckConsistency).Methods("GET")
+	clusterRouter.HandleFunc("/cluster/consistency/cleanup", clusterHandler.CleanupInconsistency).Methods("POST")
 
 	confHandler := newConfHandler(svr, rd)
 	apiRouter.HandleFunc("/config", confHandler.Get).Methods("GET")
 	apiRouter.HandleFunc("/config", confHandler.Post).Methods("POST")
 	apiRouter.HandleFunc("/config/default", confHandler.GetDefault).Methods("GET")
+	apiRouter.HandleFunc("/config/source", confHandler.GetSource).Methods("GET")
+	apiRouter.HandleFunc("/config/diff", confHandler.GetDiff).Methods("GET")
 	apiRouter.HandleFunc("/config/schedule", confHandler.GetSchedule).Methods("GET")
 	apiRouter.HandleFunc("/config/schedule", confHandler.SetSchedule).Methods("POST")
 	apiRouter.HandleFunc("/config/replicate", confHandler.GetReplication).Methods("GET")
@@ -106,6 +115,11 @@ func createRouter(ctx context.Context, prefix string, svr *server.Server) *mux.R
 	clusterRouter.HandleFunc("/config/rule_group/{id}", rulesHandler.DeleteGroupConfig).Methods("DELETE")
 	clusterRouter.HandleFunc("/config/rule_groups", rulesHandler.GetAllGroupConfigs).Methods("GET")
 
+	clusterRouter.HandleFunc("/config/leader_anti_affinity/{id}", rulesHandler.GetLeaderAntiAffinity).Methods("GET")
+	clusterRouter.HandleFunc("/config/leader_anti_affinity", rulesHandler.SetLeaderAntiAffinity).Methods("POST")
+	clusterRouter.HandleFunc("/config/leader_anti_affinity/{id}", rulesHandler.DeleteLeaderAntiAffinity).Methods("DELETE")
+	clusterRouter.HandleFunc("/config/leader_anti_affinities", rulesHandler.GetAllLeaderAntiAffinities).Methods("GET")
+
 	clusterRouter.HandleFunc("/config/placement-rule", rulesHandler.GetAllGroupBundles).Methods("GET")
 	clusterRouter.HandleFunc("/config/placement-rule", rulesHandler.SetAllGroupBundles).Methods("POST")
 	// {group} can be a regular expression, we should enable path encode to
@@ -115,13 +129,24 @@ func createRouter(ctx context.Context, prefix string, svr *server.Server) *mux.R
 	clusterRouter.HandleFunc("/config/placement-rule/{group}", rulesHandler.SetGroupBundle).Methods("POST")
 	escapeRouter.HandleFunc("/config/placement-rule/{group}", rulesHandler.DeleteGroupBundle).Methods("DELETE")
 
+	regionLabelHandler := newRegionLabelHandler(svr, rd)
+	clusterRouter.HandleFunc("/config/region-label/rules", regionLabelHandler.GetAllRules).Methods("GET")
+	clusterRouter.HandleFunc("/config/region-label/rule/{id}", regionLabelHandler.GetRule).Methods("GET")
+	clusterRouter.HandleFunc("/config/region-label/rule", regionLabelHandler.SetRule).Methods("POST")
+	clusterRouter.HandleFunc("/config/region-label/rule/{id}", regionLabelHandler.DeleteRule).Methods("DELETE")
+
 	storeHandler := newStoreHandler(handler, rd)
 	clusterRouter.HandleFunc("/store/{id}", storeHandler.Get).Methods("GET")
+	clusterRouter.HandleFunc("/store/address/{address}", storeHandler.GetByAddress).Methods("GET")
 	clusterRouter.HandleFunc("/store/{id}", storeHandler.Delete).Methods("DELETE")
+	clusterRouter.HandleFunc("/store/{id}/check-delete", storeHandler.CheckDelete).Methods("GET")
 	clusterRouter.HandleFunc("/store/{id}/state", storeHandler.SetState).Methods("POST")
 	clusterRouter.HandleFunc("/store/{id}/label", storeHandler.SetLabels).Methods("POST")
 	clusterRouter.HandleFunc("/store/{id}/weight", storeHandler.SetWeight).Methods("POST")
 	clusterRouter.HandleFunc("/store/{id}/limit", storeHandler.SetLimit).Methods("POST")
+	clusterRouter.HandleFunc("/store/{id}/config", storeHandler.GetConfig).Methods("GET")
+	clusterRouter.HandleFunc("/store/{id}/config", storeHandler.SetConfig).Methods("POST")
+	clusterRouter.HandleFunc("/store/{id}/config/{key}", storeHandler.DeleteConfig).Methods("DELETE")
 	storesHandler := newStoresHandler(handler, rd)
 	clusterRouter.Handle("/stores", storesHandler).Methods("GET")
 	clusterRouter.HandleFunc("/stores/remove-tombstone", storesHandler.RemoveTombStone).Methods("DELETE")
@@ -133,14 +158,20 @@ func createRouter(ctx context.Context, prefix string, svr *server.Server) *mux.R
 	labelsHandler := newLabelsHandler(svr, rd)
 	clusterRouter.HandleFunc("/labels", labelsHandler.Get).Methods("GET")
 	clusterRouter.HandleFunc("/labels/stores", labelsHandler.GetStores).Methods("GET")
+	clusterRouter.HandleFunc("/labels/lint", labelsHandler.Lint).Methods("GET")
 
 	hotStatusHandler := newHotStatusHandler(handler, rd)
 	apiRouter.HandleFunc("/hotspot/regions/write", hotStatusHandler.GetHotWriteRegions).Methods("GET")
 	apiRouter.HandleFunc("/hotspot/regions/read", hotStatusHandler.GetHotReadRegions).Methods("GET")
+	hotRegionHistoryRouter := apiRouter.NewRoute().Subrouter()
+	hotRegionHistoryRouter.Use(newFeatureMiddleware(svr, config.FeatureHotRegionHistory).Middleware)
+	hotRegionHistoryRouter.HandleFunc("/hotspot/regions/history", hotStatusHandler.GetHotRegionHistory).Methods("GET")
 	apiRouter.HandleFunc("/hotspot/stores", hotStatusHandler.GetHotStores).Methods("GET")
+	apiRouter.HandleFunc("/hotspot/stores/summary", hotStatusHandler.GetHotStoreSummaries).Methods("GET")
 
 	regionHandler := newRegionHandler(svr, rd)
 	clusterRouter.HandleFunc("/region/id/{id}", regionHandler.GetRegionByID).Methods("GET")
+	clusterRouter.HandleFunc("/region/id/{id}/history", regionHandler.GetRegionHistory).Methods("GET")
 	clusterRouter.UseEncodedPath().HandleFunc("/region/key/{key}", regionHandler.GetRegionByKey).Methods("GET")
 
 	srd := createStreamingRender()
@@ -149,25 +180,41 @@ func createRouter(ctx context.Context, prefix string, svr *server.Server) *mux.R
 
 	regionsHandler := newRegionsHandler(svr, rd)
 	clusterRouter.HandleFunc("/regions/key", regionsHandler.ScanRegions).Methods("GET")
+	clusterRouter.HandleFunc("/regions/range", regionsHandler.ScanRegionsInRange).Methods("GET")
 	clusterRouter.HandleFunc("/regions/count", regionsHandler.GetRegionCount).Methods("GET")
 	clusterRouter.HandleFunc("/regions/store/{id}", regionsHandler.GetStoreRegions).Methods("GET")
+	clusterRouter.HandleFunc("/regions/label", regionsHandler.GetLabelRegions).Methods("GET")
 	clusterRouter.HandleFunc("/regions/writeflow", regionsHandler.GetTopWriteFlow).Methods("GET")
 	clusterRouter.HandleFunc("/regions/readflow", regionsHandler.GetTopReadFlow).Methods("GET")
 	clusterRouter.HandleFunc("/regions/confver", regionsHandler.GetTopConfVer).Methods("GET")
 	clusterRouter.HandleFunc("/regions/version", regionsHandler.GetTopVersion).Methods("GET")
 	clusterRouter.HandleFunc("/regions/size", regionsHandler.GetTopSize).Methods("GET")
+	clusterRouter.HandleFunc("/regions/keys", regionsHandler.GetTopKeys).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/miss-peer", regionsHandler.GetMissPeerRegions).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/extra-peer", regionsHandler.GetExtraPeerRegions).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/pending-peer", regionsHandler.GetPendingPeerRegions).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/down-peer", regionsHandler.GetDownPeerRegions).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/learner-peer", regionsHandler.GetLearnerPeerRegions).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/empty-region", regionsHandler.GetEmptyRegion).Methods("GET")
+	clusterRouter.HandleFunc("/regions/check/stale-heartbeat", regionsHandler.GetStaleHeartbeatRegions).Methods("GET")
+	clusterRouter.HandleFunc("/regions/check/oversized-region", regionsHandler.GetOversizedRegions).Methods("GET")
+	clusterRouter.HandleFunc("/regions/check/undersized-region", regionsHandler.GetUndersizedRegions).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/offline-peer", regionsHandler.GetOfflinePeer).Methods("GET")
 
 	clusterRouter.HandleFunc("/regions/check/hist-size", regionsHandler.GetSizeHistogram).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/hist-keys", regionsHandler.GetKeysHistogram).Methods("GET")
 	clusterRouter.HandleFunc("/regions/sibling/{id}", regionsHandler.GetRegionSiblings).Methods("GET")
+	clusterRouter.HandleFunc("/regions/merge/{id}", regionsHandler.MergeRegionWithSibling).Methods("POST")
+	clusterRouter.HandleFunc("/regions/by-ids", regionsHandler.GetRegionsByIDs).Methods("GET")
+	clusterRouter.HandleFunc("/regions/topology", regionsHandler.GetRegionTopology).Methods("GET")
+	clusterRouter.HandleFunc("/regions/verify", regionsHandler.VerifyRegions).Methods("GET")
 	clusterRouter.HandleFunc("/regions/accelerate-schedule", regionsHandler.AccelerateRegionsScheduleInRange).Methods("POST")
+	clusterRouter.HandleFunc("/regions/gc-range", regionsHandler.AddGCRange).Methods("POST")
+	clusterRouter.HandleFunc("/regions/gc-range", regionsHandler.GetGCRanges).Methods("GET")
+	clusterRouter.HandleFunc("/regions/freeze", regionsHandler.FreezeRange).Methods("POST")
+	clusterRouter.HandleFunc("/regions/freeze", regionsHandler.UnfreezeRange).Methods("DELETE")
+	clusterRouter.HandleFunc("/regions/freeze", regionsHandler.GetFrozenRanges).Methods("GET")
+	clusterRouter.HandleFunc("/regions/rejected-heartbeats", regionsHandler.GetRejectedHeartbeats).Methods("GET")
 	clusterRouter.HandleFunc("/regions/scatter", regionsHandler.ScatterRegions).Methods("POST")
 	clusterRouter.HandleFunc("/regions/split", regionsHandler.SplitRegions).Methods("POST")
 
@@ -185,8 +232,16 @@ func createRouter(ctx context.Context, prefix string, svr *server.Server) *mux.R
 	apiRouter.HandleFunc("/leader/resign", leaderHandler.Resign).Methods("POST")
 	apiRouter.HandleFunc("/leader/transfer/{next_leader}", leaderHandler.Transfer).Methods("POST")
 
+	tsoHandler := newTSOHandler(svr, rd)
+	apiRouter.HandleFunc("/tso", tsoHandler.Get).Methods("GET")
+
 	statsHandler := newStatsHandler(svr, rd)
 	clusterRouter.HandleFunc("/stats/region", statsHandler.Region).Methods("GET")
+	clusterRouter.HandleFunc("/stats/replication", statsHandler.Replication).Methods("GET")
+	clusterRouter.HandleFunc("/stats/distribution", statsHandler.Distribution).Methods("GET")
+
+	reportHandler := newReportHandler(svr, rd)
+	clusterRouter.HandleFunc("/report/replication", reportHandler.GetReplication).Methods("GET")
 
 	trendHandler := newTrendHandler(svr, rd)
 	apiRouter.HandleFunc("/trend", trendHandler.Handle).Methods("GET")
@@ -196,9 +251,16 @@ func createRouter(ctx context.Context, prefix string, svr *server.Server) *mux.R
 	clusterRouter.HandleFunc("/admin/reset-ts", adminHandler.ResetTS).Methods("POST")
 	apiRouter.HandleFunc("/admin/persist-file/{file_name}", adminHandler.persistFile).Methods("POST")
 	clusterRouter.HandleFunc("/admin/replication_mode/wait-async", adminHandler.UpdateWaitAsyncTime).Methods("POST")
+	apiRouter.HandleFunc("/admin/drain", adminHandler.Drain).Methods("POST")
+	apiRouter.HandleFunc("/admin/drain", adminHandler.GetDrainStatus).Methods("GET")
+	apiRouter.HandleFunc("/admin/drain", adminHandler.Undrain).Methods("DELETE")
+	clusterRouter.HandleFunc("/admin/unsafe/plan-remove-failed-stores", adminHandler.PlanRemoveFailedStores).Methods("POST")
+	clusterRouter.HandleFunc("/admin/unsafe/plan-remove-failed-stores/show", adminHandler.GetUnsafeRecoveryStatus).Methods("GET")
 
 	logHandler := newLogHandler(svr, rd)
 	apiRouter.HandleFunc("/admin/log", logHandler.Handle).Methods("POST")
+	apiRouter.HandleFunc("/admin/log/sampling", logHandler.GetSampling).Methods("GET")
+	apiRouter.HandleFunc("/admin/log/sampling", logHandler.SetSampling).Methods("POST")
 
 	replicationModeHandler := newReplicationModeHandler(svr, rd)
 	clusterRouter.HandleFunc("/replication_mode/status", replicationModeHandler.GetStatus)
@@ -209,17 +271,32 @@ func createRouter(ctx context.Context, prefix string, svr *server.Server) *mux.R
 	clusterRouter.HandleFunc("/component", componentHandler.GetAllAddress).Methods("GET")
 	clusterRouter.HandleFunc("/component/{type}", componentHandler.GetAddress).Methods("GET")
 
+	jobHandler := newJobHandler(svr, rd)
+	clusterRouter.HandleFunc("/jobs", jobHandler.List).Methods("GET")
+	clusterRouter.HandleFunc("/jobs", jobHandler.Submit).Methods("POST")
+	clusterRouter.HandleFunc("/jobs/{id}", jobHandler.Get).Methods("GET")
+	clusterRouter.HandleFunc("/jobs/{id}", jobHandler.Cancel).Methods("DELETE")
+
+	featuresHandler := newFeaturesHandler(svr, rd)
+	apiRouter.HandleFunc("/features", featuresHandler.List).Methods("GET")
+
 	pluginHandler := newPluginHandler(handler, rd)
 	apiRouter.HandleFunc("/plugin", pluginHandler.LoadPlugin).Methods("POST")
 	apiRouter.HandleFunc("/plugin", pluginHandler.UnloadPlugin).Methods("DELETE")
 
-	apiRouter.Handle("/health", newHealthHandler(svr, rd)).Methods("GET")
+	healthHandler := newHealthHandler(svr, rd)
+	apiRouter.Handle("/health", healthHandler).Methods("GET")
+	apiRouter.HandleFunc("/health/detail", healthHandler.GetDetail).Methods("GET")
 	apiRouter.Handle("/diagnose", newDiagnoseHandler(svr, rd)).Methods("GET")
 	apiRouter.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
 	// metric query use to query metric data, the protocol is compatible with prometheus.
 	apiRouter.Handle("/metric/query", newQueryMetric(svr)).Methods("GET", "POST")
 	apiRouter.Handle("/metric/query_range", newQueryMetric(svr)).Methods("GET", "POST")
 
+	debugHandler := newDebugHandler(svr, rd)
+	apiRouter.HandleFunc("/debug/runtime", debugHandler.GetRuntime).Methods("GET")
+	apiRouter.HandleFunc("/debug/etcd-usage", debugHandler.GetEtcdUsage).Methods("GET")
+
 	// profile API
 	apiRouter.HandleFunc("/debug/pprof/profile", pprof.Profile)
 	apiRouter.Handle("/debug/pprof/heap", pprof.Handler("heap"))
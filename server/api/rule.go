@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/tikv/pd/pkg/apiutil"
 	"github.com/tikv/pd/pkg/codec"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/placement"
@@ -562,3 +563,95 @@ func (h *ruleHandler) SetGroupBundle(w http.ResponseWriter, r *http.Request) {
 	}
 	h.rd.JSON(w, http.StatusOK, "Update group and rules successfully.")
 }
+
+// @Tags rule
+// @Summary Get leader anti-affinity by id.
+// @Param id path string true "Leader anti-affinity Id"
+// @Produce json
+// @Success 200 {object} placement.LeaderAntiAffinity
+// @Failure 404 {string} string "The leader anti-affinity does not exist."
+// @Failure 412 {string} string "Placement rules feature is disabled."
+// @Router /config/leader_anti_affinity/{id} [get]
+func (h *ruleHandler) GetLeaderAntiAffinity(w http.ResponseWriter, r *http.Request) {
+	cluster := getCluster(r.Context())
+	if !cluster.GetOpts().IsPlacementRulesEnabled() {
+		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
+		return
+	}
+	a := cluster.GetRuleManager().GetLeaderAntiAffinity(mux.Vars(r)["id"])
+	if a == nil {
+		h.rd.JSON(w, http.StatusNotFound, nil)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, a)
+}
+
+// @Tags rule
+// @Summary Update leader anti-affinity, the leaders of the regions in the two ranges will not share stores.
+// @Accept json
+// @Param rule body placement.LeaderAntiAffinity true "Parameters of leader anti-affinity"
+// @Produce json
+// @Success 200 {string} string "Update leader anti-affinity successfully."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 412 {string} string "Placement rules feature is disabled."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/leader_anti_affinity [post]
+func (h *ruleHandler) SetLeaderAntiAffinity(w http.ResponseWriter, r *http.Request) {
+	cluster := getCluster(r.Context())
+	if !cluster.GetOpts().IsPlacementRulesEnabled() {
+		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
+		return
+	}
+	var a placement.LeaderAntiAffinity
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &a); err != nil {
+		return
+	}
+	if err := cluster.GetRuleManager().SetLeaderAntiAffinity(&a); err != nil {
+		if errs.ErrRuleContent.Equal(err) || errs.ErrHexDecodingString.Equal(err) {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, r := range a.Ranges {
+		cluster.AddSuspectKeyRange(r.StartKey, r.EndKey)
+	}
+	h.rd.JSON(w, http.StatusOK, "Update leader anti-affinity successfully.")
+}
+
+// @Tags rule
+// @Summary Delete leader anti-affinity.
+// @Param id path string true "Leader anti-affinity Id"
+// @Produce json
+// @Success 200 {string} string "Delete leader anti-affinity successfully."
+// @Failure 412 {string} string "Placement rules feature is disabled."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/leader_anti_affinity/{id} [delete]
+func (h *ruleHandler) DeleteLeaderAntiAffinity(w http.ResponseWriter, r *http.Request) {
+	cluster := getCluster(r.Context())
+	if !cluster.GetOpts().IsPlacementRulesEnabled() {
+		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
+		return
+	}
+	if err := cluster.GetRuleManager().DeleteLeaderAntiAffinity(mux.Vars(r)["id"]); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "Delete leader anti-affinity successfully.")
+}
+
+// @Tags rule
+// @Summary List all leader anti-affinities.
+// @Produce json
+// @Success 200 {array} placement.LeaderAntiAffinity
+// @Failure 412 {string} string "Placement rules feature is disabled."
+// @Router /config/leader_anti_affinities [get]
+func (h *ruleHandler) GetAllLeaderAntiAffinities(w http.ResponseWriter, r *http.Request) {
+	cluster := getCluster(r.Context())
+	if !cluster.GetOpts().IsPlacementRulesEnabled() {
+		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, cluster.GetRuleManager().GetLeaderAntiAffinities())
+}
@@ -780,6 +780,33 @@ func (s *testRuleSuite) TestBundleBadRequest(c *C) {
 	}
 }
 
+func (s *testRuleSuite) TestLeaderAntiAffinity(c *C) {
+	data := `{"id":"foo","ranges":[{"start_key":"1111","end_key":"2222"},{"start_key":"3333","end_key":""}]}`
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/leader_anti_affinity", []byte(data)), IsNil)
+	var a placement.LeaderAntiAffinity
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/leader_anti_affinity/foo", &a), IsNil)
+	c.Assert(a.Ranges[1].StartKeyHex, Equals, "3333")
+	var all []*placement.LeaderAntiAffinity
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/leader_anti_affinities", &all), IsNil)
+	c.Assert(all, HasLen, 1)
+
+	for _, data := range []string{
+		`{"id":"","ranges":[{"start_key":"1111","end_key":"2222"},{"start_key":"3333","end_key":""}]}`,
+		`{"id":"bar","ranges":[{"start_key":"1111","end_key":"2222"},{"start_key":"xyz","end_key":""}]}`,
+		`{"id":"bar","ranges":[{"start_key":"1111","end_key":"4444"},{"start_key":"3333","end_key":""}]}`,
+	} {
+		resp, err := testDialClient.Post(s.urlPrefix+"/leader_anti_affinity", "application/json", strings.NewReader(data))
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		c.Assert(resp.StatusCode, Equals, http.StatusBadRequest, Commentf("%s", data))
+	}
+
+	status, _ := requestStatusBody(c, testDialClient, http.MethodDelete, s.urlPrefix+"/leader_anti_affinity/foo")
+	c.Assert(status, Equals, http.StatusOK)
+	status, _ = requestStatusBody(c, testDialClient, http.MethodGet, s.urlPrefix+"/leader_anti_affinity/foo")
+	c.Assert(status, Equals, http.StatusNotFound)
+}
+
 func compareBundle(c *C, b1, b2 placement.GroupBundle) {
 	c.Assert(b1.ID, Equals, b2.ID)
 	c.Assert(b1.Index, Equals, b2.Index)
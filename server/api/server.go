@@ -34,6 +34,7 @@ func NewHandler(ctx context.Context, svr *server.Server) (http.Handler, server.S
 	router := mux.NewRouter()
 	r := createRouter(ctx, apiPrefix, svr)
 	router.PathPrefix(apiPrefix).Handler(negroni.New(
+		serverapi.NewHandleTimeRecorder(),
 		serverapi.NewRuntimeServiceValidator(svr, group),
 		serverapi.NewRedirector(svr),
 		negroni.Wrap(r)),
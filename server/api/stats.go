@@ -14,9 +14,16 @@
 package api
 
 import (
+	"math"
 	"net/http"
+	"sort"
+	"strconv"
 
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/codec"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
+	"github.com/tikv/pd/server/statistics"
 	"github.com/unrolled/render"
 )
 
@@ -45,3 +52,254 @@ func (h *statsHandler) Region(w http.ResponseWriter, r *http.Request) {
 	stats := rc.GetRegionStats([]byte(startKey), []byte(endKey))
 	h.rd.JSON(w, http.StatusOK, stats)
 }
+
+// maxReplicaCountBucket is the last bucket of the replica count histogram, it
+// counts the regions with at least so many replicas. The first bucket counts
+// the regions without any voter.
+const maxReplicaCountBucket = 4
+
+// ReplicaCountBucket is a bucket of the replica count histogram.
+type ReplicaCountBucket struct {
+	Replicas string `json:"replicas"`
+	Count    int    `json:"count"`
+}
+
+// ReplicationStats is the distribution of the regions by replica count and
+// health class, which shows the replication debt after failures.
+type ReplicationStats struct {
+	RegionCount int `json:"region_count"`
+	// ReplicaCount counts the regions by the number of voters.
+	ReplicaCount []*ReplicaCountBucket `json:"replica_count"`
+	// Health counts the regions by health class, a region can be in several
+	// classes at the same time. The healthy regions are in no other class
+	// except learner-peer.
+	Health map[string]int `json:"health"`
+}
+
+var replicationHealthClasses = []struct {
+	name      string
+	typ       statistics.RegionStatisticType
+	unhealthy bool
+}{
+	{"miss-peer", statistics.MissPeer, true},
+	{"extra-peer", statistics.ExtraPeer, true},
+	{"down-peer", statistics.DownPeer, true},
+	{"pending-peer", statistics.PendingPeer, true},
+	{"offline-peer", statistics.OfflinePeer, true},
+	{"learner-peer", statistics.LearnerPeer, false},
+}
+
+// @Tags stats
+// @Summary Get the distribution of regions by replica count and health class.
+// @Produce json
+// @Success 200 {object} ReplicationStats
+// @Router /stats/replication [get]
+func (h *statsHandler) Replication(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	regions := rc.GetRegions()
+	stats := &ReplicationStats{
+		RegionCount:  len(regions),
+		ReplicaCount: make([]*ReplicaCountBucket, maxReplicaCountBucket+1),
+		Health:       make(map[string]int),
+	}
+	for i := range stats.ReplicaCount {
+		stats.ReplicaCount[i] = &ReplicaCountBucket{Replicas: strconv.Itoa(i)}
+	}
+	stats.ReplicaCount[maxReplicaCountBucket].Replicas += "+"
+
+	unhealthy := make(map[uint64]struct{})
+	for _, class := range replicationHealthClasses {
+		classRegions := rc.GetRegionStatsByType(class.typ)
+		stats.Health[class.name] = len(classRegions)
+		if class.unhealthy {
+			for _, region := range classRegions {
+				unhealthy[region.GetID()] = struct{}{}
+			}
+		}
+	}
+	stats.Health["healthy"] = 0
+	for _, region := range regions {
+		i := len(region.GetVoters())
+		if i > maxReplicaCountBucket {
+			i = maxReplicaCountBucket
+		}
+		stats.ReplicaCount[i].Count++
+		if _, ok := unhealthy[region.GetID()]; !ok {
+			stats.Health["healthy"]++
+		}
+	}
+	h.rd.JSON(w, http.StatusOK, stats)
+}
+
+// regionSizeBuckets are the upper bounds in MB of the region size histogram,
+// the last bucket counts the regions larger than the last bound.
+var regionSizeBuckets = []int64{1, 16, 64, 96, 144}
+
+// SizeBucket is a bucket of the region size histogram.
+type SizeBucket struct {
+	// Size is the range of the approximate size in MB, like "16-64".
+	Size  string `json:"size"`
+	Count int    `json:"count"`
+}
+
+// Skew describes how a value is spread among the members of a group.
+type Skew struct {
+	Max    float64 `json:"max"`
+	Min    float64 `json:"min"`
+	Mean   float64 `json:"mean"`
+	Stddev float64 `json:"stddev"`
+}
+
+func newSkew(values []float64) *Skew {
+	if len(values) == 0 {
+		return &Skew{}
+	}
+	s := &Skew{Max: values[0], Min: values[0]}
+	var sum float64
+	for _, v := range values {
+		s.Max, s.Min = math.Max(s.Max, v), math.Min(s.Min, v)
+		sum += v
+	}
+	s.Mean = sum / float64(len(values))
+	var variance float64
+	for _, v := range values {
+		variance += (v - s.Mean) * (v - s.Mean)
+	}
+	s.Stddev = math.Sqrt(variance / float64(len(values)))
+	return s
+}
+
+// StoreDistribution is the regions of a store.
+type StoreDistribution struct {
+	StoreID     uint64 `json:"store_id"`
+	LeaderCount int    `json:"leader_count"`
+	PeerCount   int    `json:"peer_count"`
+	LeaderSize  int64  `json:"leader_size"`
+	PeerSize    int64  `json:"peer_size"`
+}
+
+// TableDistribution is the regions of a table.
+type TableDistribution struct {
+	// TableID is 0 for the regions which do not start with a table key.
+	TableID     int64 `json:"table_id"`
+	RegionCount int   `json:"region_count"`
+	RegionSize  int64 `json:"region_size"`
+}
+
+// RegionDistribution summarizes how the regions are distributed. The sizes
+// are approximate sizes in MB.
+type RegionDistribution struct {
+	RegionCount   int           `json:"region_count"`
+	TotalSize     int64         `json:"total_size"`
+	SizeHistogram []*SizeBucket `json:"size_histogram"`
+	SizeSkew      *Skew         `json:"size_skew"`
+
+	Stores     []*StoreDistribution `json:"stores,omitempty"`
+	LeaderSkew *Skew                `json:"leader_skew,omitempty"`
+	PeerSkew   *Skew                `json:"peer_skew,omitempty"`
+
+	Tables         []*TableDistribution `json:"tables,omitempty"`
+	TableCountSkew *Skew                `json:"table_count_skew,omitempty"`
+	TableSizeSkew  *Skew                `json:"table_size_skew,omitempty"`
+}
+
+// @Tags stats
+// @Summary Get the summary of the region distribution, with the count and size skew among stores or tables.
+// @Param by query string false "Group the regions by" Enums(store, table)
+// @Produce json
+// @Success 200 {object} RegionDistribution
+// @Failure 400 {string} string "The input is invalid."
+// @Router /stats/distribution [get]
+func (h *statsHandler) Distribution(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	by := r.URL.Query().Get("by")
+	if by != "" && by != "store" && by != "table" {
+		h.rd.JSON(w, http.StatusBadRequest, "only support grouping by store or table")
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, buildRegionDistribution(rc, by))
+}
+
+func buildRegionDistribution(rc *cluster.RaftCluster, by string) *RegionDistribution {
+	regions := rc.GetRegions()
+	d := &RegionDistribution{
+		RegionCount:   len(regions),
+		SizeHistogram: make([]*SizeBucket, len(regionSizeBuckets)+1),
+	}
+	var lower int64
+	for i, upper := range regionSizeBuckets {
+		d.SizeHistogram[i] = &SizeBucket{Size: strconv.FormatInt(lower, 10) + "-" + strconv.FormatInt(upper, 10)}
+		lower = upper
+	}
+	d.SizeHistogram[len(regionSizeBuckets)] = &SizeBucket{Size: strconv.FormatInt(lower, 10) + "+"}
+
+	stores := make(map[uint64]*StoreDistribution)
+	if by == "store" {
+		for _, store := range rc.GetStores() {
+			if store.GetState() == metapb.StoreState_Tombstone {
+				continue
+			}
+			stores[store.GetID()] = &StoreDistribution{StoreID: store.GetID()}
+		}
+	}
+	tables := make(map[int64]*TableDistribution)
+	sizes := make([]float64, 0, len(regions))
+	for _, region := range regions {
+		size := region.GetApproximateSize()
+		d.TotalSize += size
+		sizes = append(sizes, float64(size))
+		i := sort.Search(len(regionSizeBuckets), func(i int) bool { return size < regionSizeBuckets[i] })
+		d.SizeHistogram[i].Count++
+
+		switch by {
+		case "store":
+			for _, peer := range region.GetPeers() {
+				store, ok := stores[peer.GetStoreId()]
+				if !ok {
+					store = &StoreDistribution{StoreID: peer.GetStoreId()}
+					stores[peer.GetStoreId()] = store
+				}
+				store.PeerCount++
+				store.PeerSize += size
+				if peer.GetId() == region.GetLeader().GetId() {
+					store.LeaderCount++
+					store.LeaderSize += size
+				}
+			}
+		case "table":
+			tableID := codec.Key(region.GetStartKey()).TableID()
+			table, ok := tables[tableID]
+			if !ok {
+				table = &TableDistribution{TableID: tableID}
+				tables[tableID] = table
+			}
+			table.RegionCount++
+			table.RegionSize += size
+		}
+	}
+	d.SizeSkew = newSkew(sizes)
+
+	switch by {
+	case "store":
+		leaders := make([]float64, 0, len(stores))
+		peers := make([]float64, 0, len(stores))
+		for _, store := range stores {
+			d.Stores = append(d.Stores, store)
+			leaders = append(leaders, float64(store.LeaderCount))
+			peers = append(peers, float64(store.PeerCount))
+		}
+		sort.Slice(d.Stores, func(i, j int) bool { return d.Stores[i].StoreID < d.Stores[j].StoreID })
+		d.LeaderSkew, d.PeerSkew = newSkew(leaders), newSkew(peers)
+	case "table":
+		counts := make([]float64, 0, len(tables))
+		tableSizes := make([]float64, 0, len(tables))
+		for _, table := range tables {
+			d.Tables = append(d.Tables, table)
+			counts = append(counts, float64(table.RegionCount))
+			tableSizes = append(tableSizes, float64(table.RegionSize))
+		}
+		sort.Slice(d.Tables, func(i, j int) bool { return d.Tables[i].TableID < d.Tables[j].TableID })
+		d.TableCountSkew, d.TableSizeSkew = newSkew(counts), newSkew(tableSizes)
+	}
+	return d
+}
@@ -20,6 +20,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/codec"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/statistics"
@@ -175,3 +176,164 @@ func (s *testStatsSuite) TestRegionStats(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(stats, DeepEquals, stats23)
 }
+
+var _ = Suite(&testReplicationStatsSuite{})
+
+type testReplicationStatsSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testReplicationStatsSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testReplicationStatsSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testReplicationStatsSuite) TestReplicationStats(c *C) {
+	newRegion := func(id uint64, start, end string, voters int, opts ...core.RegionCreateOption) *core.RegionInfo {
+		peers := make([]*metapb.Peer, 0, voters)
+		for i := 1; i <= voters; i++ {
+			peers = append(peers, &metapb.Peer{Id: id*10 + uint64(i), StoreId: uint64(i)})
+		}
+		meta := &metapb.Region{
+			Id:          id,
+			StartKey:    []byte(start),
+			EndKey:      []byte(end),
+			Peers:       peers,
+			RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+		}
+		return core.NewRegionInfo(meta, peers[0], opts...)
+	}
+	regions := []*core.RegionInfo{
+		newRegion(2, "a", "b", 1),
+		newRegion(3, "b", "c", 3),
+		newRegion(4, "c", "d", 3),
+		newRegion(5, "d", "e", 5),
+		newRegion(6, "e", "f", 1),
+	}
+	regions[2] = regions[2].Clone(core.WithPendingPeers(regions[2].GetPeers()[1:2]))
+	// The region has only a learner.
+	regions[4] = regions[4].Clone(core.WithLearners(regions[4].GetPeers()))
+	for _, region := range regions {
+		mustRegionHeartbeat(c, s.svr, region)
+	}
+
+	stats := &ReplicationStats{}
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/stats/replication", stats), IsNil)
+	c.Assert(stats.RegionCount, Equals, 5)
+	c.Assert(stats.ReplicaCount, DeepEquals, []*ReplicaCountBucket{
+		{Replicas: "0", Count: 1},
+		{Replicas: "1", Count: 1},
+		{Replicas: "2", Count: 0},
+		{Replicas: "3", Count: 2},
+		{Replicas: "4+", Count: 1},
+	})
+	c.Assert(stats.Health["miss-peer"], Equals, 2)
+	c.Assert(stats.Health["extra-peer"], Equals, 1)
+	c.Assert(stats.Health["pending-peer"], Equals, 1)
+	c.Assert(stats.Health["healthy"], Equals, 1)
+}
+
+var _ = Suite(&testRegionDistributionSuite{})
+
+type testRegionDistributionSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testRegionDistributionSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+	mustPutStore(c, s.svr, 2, metapb.StoreState_Up, nil)
+	mustPutStore(c, s.svr, 3, metapb.StoreState_Up, nil)
+}
+
+func (s *testRegionDistributionSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testRegionDistributionSuite) TestRegionDistribution(c *C) {
+	newRegion := func(id uint64, start, end []byte, size int64, stores ...uint64) *core.RegionInfo {
+		peers := make([]*metapb.Peer, 0, len(stores))
+		for _, storeID := range stores {
+			peers = append(peers, &metapb.Peer{Id: id*10 + storeID, StoreId: storeID})
+		}
+		meta := &metapb.Region{
+			Id:          id,
+			StartKey:    start,
+			EndKey:      end,
+			Peers:       peers,
+			RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 2},
+		}
+		return core.NewRegionInfo(meta, peers[0], core.SetApproximateSize(size))
+	}
+	table1 := codec.EncodeBytes(codec.GenerateTableKey(1))
+	table2 := codec.EncodeBytes(codec.GenerateTableKey(2))
+	table3 := codec.EncodeBytes(codec.GenerateTableKey(3))
+	regions := []*core.RegionInfo{
+		newRegion(2, nil, table1, 0, 1),
+		newRegion(3, table1, table2, 10, 1, 2),
+		newRegion(4, table2, table3, 100, 2, 1),
+		newRegion(5, table3, nil, 200, 1, 2),
+	}
+	for _, region := range regions {
+		mustRegionHeartbeat(c, s.svr, region)
+	}
+
+	d := &RegionDistribution{}
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/stats/distribution", d), IsNil)
+	c.Assert(d.RegionCount, Equals, 4)
+	c.Assert(d.TotalSize, Equals, int64(310))
+	c.Assert(d.SizeHistogram, DeepEquals, []*SizeBucket{
+		{Size: "0-1", Count: 1},
+		{Size: "1-16", Count: 1},
+		{Size: "16-64", Count: 0},
+		{Size: "64-96", Count: 0},
+		{Size: "96-144", Count: 1},
+		{Size: "144+", Count: 1},
+	})
+	c.Assert(d.SizeSkew.Max, Equals, 200.0)
+	c.Assert(d.SizeSkew.Min, Equals, 0.0)
+	c.Assert(d.SizeSkew.Mean, Equals, 77.5)
+	c.Assert(d.Stores, HasLen, 0)
+	c.Assert(d.Tables, HasLen, 0)
+
+	d = &RegionDistribution{}
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/stats/distribution?by=store", d), IsNil)
+	c.Assert(d.Stores, DeepEquals, []*StoreDistribution{
+		{StoreID: 1, LeaderCount: 3, PeerCount: 4, LeaderSize: 210, PeerSize: 310},
+		{StoreID: 2, LeaderCount: 1, PeerCount: 3, LeaderSize: 100, PeerSize: 310},
+		{StoreID: 3},
+	})
+	c.Assert(d.LeaderSkew, DeepEquals, &Skew{Max: 3, Min: 0, Mean: 4.0 / 3, Stddev: d.LeaderSkew.Stddev})
+	c.Assert(d.PeerSkew.Max, Equals, 4.0)
+	c.Assert(d.PeerSkew.Min, Equals, 0.0)
+
+	d = &RegionDistribution{}
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/stats/distribution?by=table", d), IsNil)
+	c.Assert(d.Tables, DeepEquals, []*TableDistribution{
+		{TableID: 0, RegionCount: 1, RegionSize: 0},
+		{TableID: 1, RegionCount: 1, RegionSize: 10},
+		{TableID: 2, RegionCount: 1, RegionSize: 100},
+		{TableID: 3, RegionCount: 1, RegionSize: 200},
+	})
+	c.Assert(d.TableCountSkew.Stddev, Equals, 0.0)
+
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/stats/distribution?by=peer", d), NotNil)
+}
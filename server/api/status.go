@@ -15,6 +15,7 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/versioninfo"
@@ -31,6 +32,9 @@ type status struct {
 	Version        string `json:"version"`
 	GitHash        string `json:"git_hash"`
 	StartTimestamp int64  `json:"start_timestamp"`
+	// CurrentTimestampMs is the unix time of the server in milliseconds, it is
+	// used to detect the clock drift between servers.
+	CurrentTimestampMs int64 `json:"current_timestamp_ms"`
 }
 
 func newStatusHandler(svr *server.Server, rd *render.Render) *statusHandler {
@@ -50,6 +54,8 @@ func (h *statusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		GitHash:        versioninfo.PDGitHash,
 		Version:        versioninfo.PDReleaseVersion,
 		StartTimestamp: h.svr.StartTimestamp(),
+		// use the time after the other fields are collected to be more accurate.
+		CurrentTimestampMs: time.Now().UnixNano() / int64(time.Millisecond),
 	}
 
 	h.rd.JSON(w, http.StatusOK, version)
@@ -16,8 +16,10 @@ package api
 import (
 	"encoding/json"
 	"io/ioutil"
+	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/apiutil/serverapi"
 	"github.com/tikv/pd/server/versioninfo"
 )
 
@@ -44,6 +46,9 @@ func (s *testStatusAPISuite) TestStatus(c *C) {
 		buf, err := ioutil.ReadAll(resp.Body)
 		c.Assert(err, IsNil)
 		checkStatusResponse(c, buf)
+		// The handling time is reported for the requests profiled by pd-ctl.
+		_, err = time.ParseDuration(resp.Header.Get(serverapi.HandleTimeHeader))
+		c.Assert(err, IsNil)
 		resp.Body.Close()
 	}
 }
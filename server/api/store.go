@@ -18,6 +18,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -28,6 +29,7 @@ import (
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/core/storelimit"
@@ -53,19 +55,32 @@ type StoreStatus struct {
 	RegionWeight       float64            `json:"region_weight"`
 	RegionScore        float64            `json:"region_score"`
 	RegionSize         int64              `json:"region_size"`
+	PendingPeerCount   int                `json:"pending_peer_count"`
+	DownPeerCount      int                `json:"down_peer_count"`
 	SendingSnapCount   uint32             `json:"sending_snap_count,omitempty"`
 	ReceivingSnapCount uint32             `json:"receiving_snap_count,omitempty"`
 	ApplyingSnapCount  uint32             `json:"applying_snap_count,omitempty"`
 	IsBusy             bool               `json:"is_busy,omitempty"`
+	CPUUsage           float64            `json:"cpu_usage,omitempty"`
+	DiskIORate         typeutil.ByteSize  `json:"disk_io_rate,omitempty"`
+	Utilization        float64            `json:"utilization,omitempty"`
 	StartTS            *time.Time         `json:"start_ts,omitempty"`
 	LastHeartbeatTS    *time.Time         `json:"last_heartbeat_ts,omitempty"`
 	Uptime             *typeutil.Duration `json:"uptime,omitempty"`
+	// The limits of the store class which the store belongs to, they are used
+	// by the snapshot and pending peer filters instead of the global ones.
+	StoreClass          string `json:"store_class,omitempty"`
+	MaxSnapshotCount    uint64 `json:"max_snapshot_count,omitempty"`
+	MaxPendingPeerCount uint64 `json:"max_pending_peer_count,omitempty"`
 }
 
 // StoreInfo contains information about a store.
 type StoreInfo struct {
 	Store  *MetaStore   `json:"store"`
 	Status *StoreStatus `json:"status"`
+	// Progress is the progress of moving the regions out, it is only
+	// available for the offline stores.
+	Progress *cluster.StoreProgress `json:"progress,omitempty"`
 }
 
 const (
@@ -91,10 +106,15 @@ func newStoreInfo(opt *config.ScheduleConfig, store *core.StoreInfo) *StoreInfo
 			RegionWeight:       store.GetRegionWeight(),
 			RegionScore:        store.RegionScore(opt.HighSpaceRatio, opt.LowSpaceRatio, 0),
 			RegionSize:         store.GetRegionSize(),
+			PendingPeerCount:   store.GetPendingPeerCount(),
+			DownPeerCount:      store.GetDownPeerCount(),
 			SendingSnapCount:   store.GetSendingSnapCount(),
 			ReceivingSnapCount: store.GetReceivingSnapCount(),
 			ApplyingSnapCount:  store.GetApplyingSnapCount(),
 			IsBusy:             store.IsBusy(),
+			CPUUsage:           store.GetCPUUsage(),
+			DiskIORate:         typeutil.ByteSize(store.GetDiskIORate()),
+			Utilization:        store.GetUtilization(),
 		},
 	}
 
@@ -110,6 +130,17 @@ func newStoreInfo(opt *config.ScheduleConfig, store *core.StoreInfo) *StoreInfo
 		s.Status.Uptime = &duration
 	}
 
+	if limit := opt.GetStoreClassLimit(store.GetLabels()); limit != nil {
+		s.Status.StoreClass = limit.Class()
+		s.Status.MaxSnapshotCount, s.Status.MaxPendingPeerCount = opt.MaxSnapshotCount, opt.MaxPendingPeerCount
+		if limit.MaxSnapshotCount > 0 {
+			s.Status.MaxSnapshotCount = limit.MaxSnapshotCount
+		}
+		if limit.MaxPendingPeerCount > 0 {
+			s.Status.MaxPendingPeerCount = limit.MaxPendingPeerCount
+		}
+	}
+
 	if store.GetState() == metapb.StoreState_Up {
 		if store.DownTime() > opt.MaxStoreDownTime.Duration {
 			s.Store.StateName = downStateName
@@ -128,13 +159,15 @@ type StoresInfo struct {
 
 type storeHandler struct {
 	*server.Handler
-	rd *render.Render
+	rd     *render.Render
+	tokens *confirmTokens
 }
 
 func newStoreHandler(handler *server.Handler, rd *render.Render) *storeHandler {
 	return &storeHandler{
 		Handler: handler,
 		rd:      rd,
+		tokens:  newConfirmTokens(),
 	}
 }
 
@@ -163,17 +196,44 @@ func (h *storeHandler) Get(w http.ResponseWriter, r *http.Request) {
 	}
 
 	storeInfo := newStoreInfo(h.GetScheduleConfig(), store)
+	storeInfo.Progress = rc.GetStoreProgress(store.GetID())
+	h.rd.JSON(w, http.StatusOK, storeInfo)
+}
+
+// @Tags store
+// @Summary Get a store's information by its address.
+// @Param address path string true "Store address"
+// @Produce json
+// @Success 200 {object} StoreInfo
+// @Failure 404 {string} string "The store does not exist."
+// @Router /store/address/{address} [get]
+func (h *storeHandler) GetByAddress(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	address := mux.Vars(r)["address"]
+
+	store := rc.GetStoreByAddress(address)
+	if store == nil {
+		h.rd.JSON(w, http.StatusNotFound, fmt.Sprintf("store with address %s not found", address))
+		return
+	}
+
+	storeInfo := newStoreInfo(h.GetScheduleConfig(), store)
+	storeInfo.Progress = rc.GetStoreProgress(store.GetID())
 	h.rd.JSON(w, http.StatusOK, storeInfo)
 }
 
 // @Tags store
 // @Summary Take down a store from the cluster.
 // @Param id path integer true "Store Id"
+// @Param force query string false "Set the store as Tombstone directly, only for the store which is physically destroyed"
+// @Param PD-Confirm header string false "Opt in the two-phase confirmation of the forced deletion"
+// @Param confirm_token query string false "The token to confirm the forced deletion"
 // @Produce json
 // @Success 200 {string} string "The store is set as Offline or Tombstone."
 // @Failure 400 {string} string "The input is invalid."
 // @Failure 404 {string} string "The store does not exist."
 // @Failure 410 {string} string "The store has already been removed."
+// @Failure 428 {object} ConfirmTokenResponse "The forced deletion needs to be confirmed, only if the confirmation is opted in."
 // @Failure 500 {string} string "PD server failed to proceed the request."
 // @Router /store/{id} [delete]
 func (h *storeHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -188,6 +248,9 @@ func (h *storeHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	var err error
 	_, force := r.URL.Query()["force"]
 	if force {
+		if !h.tokens.confirmed(h.rd, w, r, fmt.Sprintf("bury store %d", storeID)) {
+			return
+		}
 		err = rc.BuryStore(storeID, force)
 	} else {
 		err = rc.RemoveStore(storeID)
@@ -206,6 +269,39 @@ func (h *storeHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, "The store is set as Offline or Tombstone.")
 }
 
+// @Tags store
+// @Summary Simulate the deletion of a store, it checks whether the remaining stores can accept the peers on the store and estimates the data to move.
+// @Param id path integer true "Store Id"
+// @Produce json
+// @Success 200 {object} cluster.StoreDeleteCheck
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The store does not exist."
+// @Failure 410 {string} string "The store has already been removed."
+// @Router /store/{id}/check-delete [get]
+func (h *storeHandler) CheckDelete(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	storeID, errParse := apiutil.ParseUint64VarsField(mux.Vars(r), "id")
+	if errParse != nil {
+		apiutil.ErrorResp(h.rd, w, errcode.NewInvalidInputErr(errParse))
+		return
+	}
+
+	check, err := rc.CheckDeleteStore(storeID)
+	if err != nil {
+		if errs.ErrStoreNotFound.Equal(err) {
+			h.rd.JSON(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errs.ErrStoreTombstone.Equal(err) {
+			h.rd.JSON(w, http.StatusGone, err.Error())
+			return
+		}
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, check)
+}
+
 // @Tags store
 // @Summary Set the store's state.
 // @Param id path integer true "Store Id"
@@ -413,6 +509,100 @@ func (h *storeHandler) SetLimit(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, "The store's label is updated.")
 }
 
+// @Tags store
+// @Summary Get the store's dynamic config items.
+// @Param id path integer true "Store Id"
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The store does not exist."
+// @Router /store/{id}/config [get]
+func (h *storeHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	storeID, errParse := apiutil.ParseUint64VarsField(mux.Vars(r), "id")
+	if errParse != nil {
+		apiutil.ErrorResp(h.rd, w, errcode.NewInvalidInputErr(errParse))
+		return
+	}
+
+	items, err := rc.GetStoreConfig(storeID)
+	if err != nil {
+		h.rd.JSON(w, http.StatusNotFound, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, items)
+}
+
+// @Tags store
+// @Summary Set the store's dynamic config items, the items with empty value are removed.
+// @Param id path integer true "Store Id"
+// @Param body body object true "Config items in json format"
+// @Produce json
+// @Success 200 {string} string "The store's config is updated."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The store does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /store/{id}/config [post]
+func (h *storeHandler) SetConfig(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	storeID, errParse := apiutil.ParseUint64VarsField(mux.Vars(r), "id")
+	if errParse != nil {
+		apiutil.ErrorResp(h.rd, w, errcode.NewInvalidInputErr(errParse))
+		return
+	}
+	if rc.GetStore(storeID) == nil {
+		h.rd.JSON(w, http.StatusNotFound, server.ErrStoreNotFound(storeID).Error())
+		return
+	}
+
+	var input map[string]string
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	for k := range input {
+		if k == "" {
+			h.rd.JSON(w, http.StatusBadRequest, "config key should not be empty")
+			return
+		}
+	}
+
+	if err := rc.UpdateStoreConfig(storeID, input); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The store's config is updated.")
+}
+
+// @Tags store
+// @Summary Delete a dynamic config item of the store.
+// @Param id path integer true "Store Id"
+// @Param key path string true "Config key"
+// @Produce json
+// @Success 200 {string} string "The store's config is updated."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The store does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /store/{id}/config/{key} [delete]
+func (h *storeHandler) DeleteConfig(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	vars := mux.Vars(r)
+	storeID, errParse := apiutil.ParseUint64VarsField(vars, "id")
+	if errParse != nil {
+		apiutil.ErrorResp(h.rd, w, errcode.NewInvalidInputErr(errParse))
+		return
+	}
+	if rc.GetStore(storeID) == nil {
+		h.rd.JSON(w, http.StatusNotFound, server.ErrStoreNotFound(storeID).Error())
+		return
+	}
+
+	if err := rc.DeleteStoreConfig(storeID, vars["key"]); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The store's config is updated.")
+}
+
 type storesHandler struct {
 	*server.Handler
 	rd *render.Render
@@ -603,8 +793,10 @@ func (h *storesHandler) GetStoreLimitScene(w http.ResponseWriter, r *http.Reques
 // @Tags store
 // @Summary Get stores in the cluster.
 // @Param state query array true "Specify accepted store states."
+// @Param label query array false "Only list the stores with all the labels, each of them is in the form of key=value."
 // @Produce json
 // @Success 200 {object} StoresInfo
+// @Failure 400 {string} string "The input is invalid."
 // @Failure 500 {string} string "PD server failed to proceed the request."
 // @Router /stores [get]
 func (h *storesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -619,6 +811,11 @@ func (h *storesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	labels, err := parseStoreLabels(r.URL.Query()["label"])
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	stores = urlFilter.filter(rc.GetMetaStores())
 	for _, s := range stores {
@@ -629,7 +826,11 @@ func (h *storesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if !storeHasLabels(store, labels) {
+			continue
+		}
 		storeInfo := newStoreInfo(h.GetScheduleConfig(), store)
+		storeInfo.Progress = rc.GetStoreProgress(store.GetID())
 		StoresInfo.Stores = append(StoresInfo.Stores, storeInfo)
 	}
 	StoresInfo.Count = len(StoresInfo.Stores)
@@ -682,6 +883,28 @@ func (filter *storeStateFilter) filter(stores []*metapb.Store) []*metapb.Store {
 	return ret
 }
 
+// parseStoreLabels parses the labels in the form of key=value.
+func parseStoreLabels(values []string) ([]*metapb.StoreLabel, error) {
+	labels := make([]*metapb.StoreLabel, 0, len(values))
+	for _, v := range values {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errors.Errorf("invalid label %s, it should be key=value", v)
+		}
+		labels = append(labels, &metapb.StoreLabel{Key: kv[0], Value: kv[1]})
+	}
+	return labels, nil
+}
+
+func storeHasLabels(store *core.StoreInfo, labels []*metapb.StoreLabel) bool {
+	for _, label := range labels {
+		if store.GetLabelValue(label.GetKey()) != label.GetValue() {
+			return false
+		}
+	}
+	return true
+}
+
 func getStoreLimitType(input map[string]interface{}) ([]storelimit.Type, error) {
 	typeNameIface, ok := input["type"]
 	var err error
@@ -138,6 +138,15 @@ func (s *testStoreSuite) TestStoresList(c *C) {
 	c.Assert(err, IsNil)
 	checkStoresInfo(c, info.Stores, s.stores[2:3])
 
+	url = fmt.Sprintf("%s/stores?label=zone=z1", s.urlPrefix)
+	info = new(StoresInfo)
+	err = readJSON(testDialClient, url, info)
+	c.Assert(err, IsNil)
+	checkStoresInfo(c, info.Stores, nil)
+
+	url = fmt.Sprintf("%s/stores?label=zone", s.urlPrefix)
+	err = readJSON(testDialClient, url, new(StoresInfo))
+	c.Assert(err, NotNil)
 }
 
 func (s *testStoreSuite) TestStoreGet(c *C) {
@@ -161,6 +170,7 @@ func (s *testStoreSuite) TestStoreGet(c *C) {
 	available, _ := units.RAMInBytes("1.555TiB")
 	c.Assert(int64(info.Status.Capacity), Equals, capacity)
 	c.Assert(int64(info.Status.Available), Equals, available)
+	c.Assert(info.Progress, IsNil)
 	checkStoresInfo(c, []*StoreInfo{info}, s.stores[:1])
 }
 
@@ -235,6 +245,26 @@ func (s *testStoreSuite) TestStoreLabel(c *C) {
 	s.stores[0].Labels = info.Store.Labels
 }
 
+func (s *testStoreSuite) TestStoreConfig(c *C) {
+	url := fmt.Sprintf("%s/store/1/config", s.urlPrefix)
+	items := make(map[string]string)
+	c.Assert(readJSON(testDialClient, url, &items), IsNil)
+	c.Assert(items, HasLen, 0)
+
+	b, err := json.Marshal(map[string]string{"snap-concurrency": "4", "import-mode": "true"})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, url, b), IsNil)
+	_, err = doDelete(testDialClient, url+"/import-mode")
+	c.Assert(err, IsNil)
+	c.Assert(readJSON(testDialClient, url, &items), IsNil)
+	c.Assert(items, DeepEquals, map[string]string{"snap-concurrency": "4"})
+
+	b, err = json.Marshal(map[string]string{"": "4"})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, url, b), NotNil)
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/store/100/config", s.urlPrefix), &items), NotNil)
+}
+
 func (s *testStoreSuite) TestStoreDelete(c *C) {
 	table := []struct {
 		id     int
@@ -256,6 +286,44 @@ func (s *testStoreSuite) TestStoreDelete(c *C) {
 	}
 }
 
+func (s *testStoreSuite) TestStoreForceDelete(c *C) {
+	// The forced deletion is not guarded without opting in the confirmation.
+	mustPutStore(c, s.svr, 9, metapb.StoreState_Offline, nil)
+	status, _ := requestStatusBody(c, testDialClient, http.MethodDelete, fmt.Sprintf("%s/store/9?force", s.urlPrefix))
+	c.Assert(status, Equals, http.StatusOK)
+	c.Assert(s.svr.GetRaftCluster().GetStore(9).GetState(), Equals, metapb.StoreState_Tombstone)
+
+	mustPutStore(c, s.svr, 8, metapb.StoreState_Offline, nil)
+	url := fmt.Sprintf("%s/store/8?force", s.urlPrefix)
+	// The first request only gets a confirm token.
+	req, err := http.NewRequest(http.MethodDelete, url, nil)
+	c.Assert(err, IsNil)
+	req.Header.Set(confirmHeader, "two-phase")
+	r, err := testDialClient.Do(req)
+	c.Assert(err, IsNil)
+	body, err := ioutil.ReadAll(r.Body)
+	c.Assert(err, IsNil)
+	r.Body.Close()
+	c.Assert(r.StatusCode, Equals, http.StatusPreconditionRequired)
+	resp := &ConfirmTokenResponse{}
+	c.Assert(json.Unmarshal(body, resp), IsNil)
+	c.Assert(resp.ConfirmToken, Not(Equals), "")
+	c.Assert(s.svr.GetRaftCluster().GetStore(8).GetState(), Equals, metapb.StoreState_Offline)
+
+	// The token can not be used for other stores.
+	status, _ = requestStatusBody(c, testDialClient, http.MethodDelete, fmt.Sprintf("%s/store/6?force&confirm_token=%s", s.urlPrefix, resp.ConfirmToken))
+	c.Assert(status, Equals, http.StatusBadRequest)
+	c.Assert(s.svr.GetRaftCluster().GetStore(6).GetState(), Equals, metapb.StoreState_Offline)
+
+	status, _ = requestStatusBody(c, testDialClient, http.MethodDelete, url+"&confirm_token="+resp.ConfirmToken)
+	c.Assert(status, Equals, http.StatusOK)
+	c.Assert(s.svr.GetRaftCluster().GetStore(8).GetState(), Equals, metapb.StoreState_Tombstone)
+
+	// The token can only be used once.
+	status, _ = requestStatusBody(c, testDialClient, http.MethodDelete, url+"&confirm_token="+resp.ConfirmToken)
+	c.Assert(status, Equals, http.StatusBadRequest)
+}
+
 func (s *testStoreSuite) TestStoreSetState(c *C) {
 	url := fmt.Sprintf("%s/store/1", s.urlPrefix)
 	info := StoreInfo{}
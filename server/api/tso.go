@@ -0,0 +1,63 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/tikv/pd/pkg/tsoutil"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/config"
+	"github.com/unrolled/render"
+)
+
+type tsoHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newTSOHandler(svr *server.Server, rd *render.Render) *tsoHandler {
+	return &tsoHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// tsoInfo is a timestamp allocated by the global TSO allocator.
+type tsoInfo struct {
+	TSO      uint64    `json:"tso"`
+	Physical time.Time `json:"physical"`
+	Logical  uint64    `json:"logical"`
+}
+
+// @Tags tso
+// @Summary Allocate a timestamp from the global TSO allocator.
+// @Produce json
+// @Success 200 {object} tsoInfo
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /tso [get]
+func (h *tsoHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ts, err := h.svr.GetTSOAllocatorManager().HandleTSORequest(config.GlobalDCLocation, 1)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	physical, logical := tsoutil.ParseTimestamp(ts)
+	h.rd.JSON(w, http.StatusOK, &tsoInfo{
+		TSO:      tsoutil.GenerateTS(&ts),
+		Physical: physical,
+		Logical:  logical,
+	})
+}
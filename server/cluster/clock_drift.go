@@ -0,0 +1,244 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/logutil"
+	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/config"
+	"go.uber.org/zap"
+)
+
+const (
+	clockDriftCheckInterval = time.Minute
+	// the drifts which are not updated for a long time are discarded.
+	clockDriftExpireTime = 10 * clockDriftCheckInterval
+	statusURL            = "/pd/api/v1/status"
+	// allowFollowerHandleHeader asks the member to handle the request by itself
+	// instead of redirecting it to the leader.
+	allowFollowerHandleHeader = "PD-Allow-follower-handle"
+	// storeClockPrecision is the precision of the timestamps in the store
+	// heartbeats, the drift of a store is only exceeded if it exceeds the
+	// threshold by more than that.
+	storeClockPrecision = time.Second
+)
+
+// Components whose clock drifts are monitored.
+const (
+	ClockDriftComponentPD   = "pd"
+	ClockDriftComponentTiKV = "tikv"
+)
+
+// ClockDrift is the clock drift between the PD leader and a PD member or store.
+type ClockDrift struct {
+	Component string `json:"component"`
+	// ID is the member ID or the store ID.
+	ID      uint64 `json:"id"`
+	Address string `json:"address"`
+	// Drift is the clock of the component minus the clock of the PD leader.
+	Drift      typeutil.Duration `json:"drift"`
+	Exceeded   bool              `json:"exceeded"`
+	UpdateTime time.Time         `json:"update_time"`
+}
+
+func (d *ClockDrift) key() string {
+	return d.Component + "-" + strconv.FormatUint(d.ID, 10)
+}
+
+func (d *ClockDrift) abs() time.Duration {
+	if d.Drift.Duration < 0 {
+		return -d.Drift.Duration
+	}
+	return d.Drift.Duration
+}
+
+// clockDriftMonitor records the clock drifts of PD members and stores, and
+// alerts when a drift exceeds the threshold.
+type clockDriftMonitor struct {
+	sync.Mutex
+	opt        *config.PersistOptions
+	httpClient *http.Client
+	drifts     map[string]*ClockDrift
+}
+
+func newClockDriftMonitor(opt *config.PersistOptions, httpClient *http.Client) *clockDriftMonitor {
+	return &clockDriftMonitor{
+		opt:        opt,
+		httpClient: httpClient,
+		drifts:     make(map[string]*ClockDrift),
+	}
+}
+
+func (m *clockDriftMonitor) observe(drift *ClockDrift) {
+	threshold := m.opt.GetMaxClockDrift()
+	if drift.Component == ClockDriftComponentTiKV {
+		threshold += storeClockPrecision
+	}
+	drift.Exceeded = drift.abs() > threshold
+	clockDriftGauge.WithLabelValues(drift.Component, drift.Address).Set(drift.Drift.Seconds())
+
+	m.Lock()
+	old, ok := m.drifts[drift.key()]
+	m.drifts[drift.key()] = drift
+	m.Unlock()
+
+	if !drift.Exceeded {
+		if ok && old.Exceeded {
+			log.Info("clock drift recovers", zap.String("component", drift.Component),
+				zap.Uint64("id", drift.ID), zap.String("address", drift.Address), zap.Duration("drift", drift.Drift.Duration))
+		}
+		return
+	}
+	log.Warn("clock drift exceeds the threshold", zap.String("component", drift.Component),
+		zap.Uint64("id", drift.ID), zap.String("address", drift.Address),
+		zap.Duration("drift", drift.Drift.Duration), zap.Duration("threshold", threshold))
+	// only alert once until it recovers.
+	if webhook := m.opt.GetClockDriftWebhook(); webhook != "" && (!ok || !old.Exceeded) && m.httpClient != nil {
+		go m.postWebhook(webhook, drift)
+	}
+}
+
+func (m *clockDriftMonitor) postWebhook(webhook string, drift *ClockDrift) {
+	defer logutil.LogPanic()
+	data, err := json.Marshal(drift)
+	if err != nil {
+		log.Error("failed to marshal clock drift", errs.ZapError(errs.ErrJSONMarshal, err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), clientTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewBuffer(data))
+	if err != nil {
+		log.Error("failed to new request", errs.ZapError(errs.ErrNewHTTPRequest, err))
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		log.Error("failed to post clock drift alert", zap.String("webhook", webhook), errs.ZapError(errs.ErrSendRequest, err))
+		return
+	}
+	resp.Body.Close()
+}
+
+// observeStore records the clock drift of a store from its heartbeat. The
+// timestamps of the heartbeat only have the precision of seconds, so the drift
+// is compared with the time of PD without truncation and it may be less than
+// the real one by up to a second.
+func (m *clockDriftMonitor) observeStore(storeID uint64, address string, stats *pdpb.StoreStats, now time.Time) {
+	end := stats.GetInterval().GetEndTimestamp()
+	if end == 0 {
+		return
+	}
+	m.observe(&ClockDrift{
+		Component:  ClockDriftComponentTiKV,
+		ID:         storeID,
+		Address:    address,
+		Drift:      typeutil.NewDuration(time.Unix(int64(end), 0).Sub(now)),
+		UpdateTime: now,
+	})
+}
+
+// checkMembers measures the clock drifts of the other PD members by querying
+// their status, the network latency is compensated with half of the round trip.
+func (m *clockDriftMonitor) checkMembers(members []*pdpb.Member, self string) {
+	for _, member := range members {
+		if member.GetName() == self {
+			continue
+		}
+		for _, cURL := range member.GetClientUrls() {
+			drift, err := m.measureMember(cURL)
+			if err != nil {
+				log.Debug("failed to measure the clock drift of member", zap.String("member", member.GetName()), errs.ZapError(err))
+				continue
+			}
+			m.observe(&ClockDrift{
+				Component:  ClockDriftComponentPD,
+				ID:         member.GetMemberId(),
+				Address:    cURL,
+				Drift:      typeutil.NewDuration(drift),
+				UpdateTime: time.Now(),
+			})
+			break
+		}
+	}
+}
+
+func (m *clockDriftMonitor) measureMember(cURL string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), clientTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s%s", cURL, statusURL), nil)
+	if err != nil {
+		return 0, errs.ErrNewHTTPRequest.Wrap(err).GenWithStackByCause()
+	}
+	req.Header.Set(allowFollowerHandleHeader, "true")
+	start := time.Now()
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return 0, errs.ErrSendRequest.Wrap(err).GenWithStackByCause()
+	}
+	defer resp.Body.Close()
+	rtt := time.Since(start)
+	status := struct {
+		CurrentTimestampMs int64 `json:"current_timestamp_ms"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return 0, errs.ErrJSONUnmarshal.Wrap(err).GenWithStackByCause()
+	}
+	if status.CurrentTimestampMs == 0 {
+		return 0, errs.ErrJSONUnmarshal.FastGenByArgs()
+	}
+	remote := time.Unix(0, status.CurrentTimestampMs*int64(time.Millisecond))
+	return remote.Sub(start.Add(rtt / 2)), nil
+}
+
+// getWorstDrifts returns at most limit drifts with the largest absolute values.
+func (m *clockDriftMonitor) getWorstDrifts(limit int) []*ClockDrift {
+	m.Lock()
+	defer m.Unlock()
+	drifts := make([]*ClockDrift, 0, len(m.drifts))
+	for key, drift := range m.drifts {
+		if time.Since(drift.UpdateTime) > clockDriftExpireTime {
+			delete(m.drifts, key)
+			clockDriftGauge.DeleteLabelValues(drift.Component, drift.Address)
+			continue
+		}
+		drifts = append(drifts, drift)
+	}
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].abs() > drifts[j].abs() })
+	if limit > 0 && len(drifts) > limit {
+		drifts = drifts[:limit]
+	}
+	return drifts
+}
+
+func (m *clockDriftMonitor) reset() {
+	m.Lock()
+	defer m.Unlock()
+	m.drifts = make(map[string]*ClockDrift)
+	clockDriftGauge.Reset()
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/mock/mockid"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+)
+
+var _ = Suite(&testClockDriftSuite{})
+
+type testClockDriftSuite struct{}
+
+func (s *testClockDriftSuite) TestStoreDrift(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cluster := newTestRaftCluster(mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+	for _, store := range newTestStores(3, "2.0.0") {
+		c.Assert(cluster.putStoreLocked(store), IsNil)
+	}
+
+	now := uint64(time.Now().Unix())
+	for id, end := range map[uint64]uint64{1: now, 2: now - 10, 3: now + 5} {
+		c.Assert(cluster.HandleStoreHeartbeat(&pdpb.StoreStats{
+			StoreId:  id,
+			Interval: &pdpb.TimeInterval{StartTimestamp: end - 10, EndTimestamp: end},
+		}), IsNil)
+	}
+
+	drifts := cluster.GetClockDrifts(2)
+	c.Assert(drifts, HasLen, 2)
+	c.Assert(drifts[0].ID, Equals, uint64(2))
+	c.Assert(drifts[0].Component, Equals, ClockDriftComponentTiKV)
+	c.Assert(drifts[0].Drift.Duration <= -9*time.Second, IsTrue)
+	c.Assert(drifts[0].Exceeded, IsTrue)
+	c.Assert(drifts[1].ID, Equals, uint64(3))
+	c.Assert(drifts[1].Drift.Duration >= 4*time.Second, IsTrue)
+	c.Assert(cluster.GetClockDrifts(0), HasLen, 3)
+
+	// The drift of a store is not exceeded within the precision of seconds.
+	m := newClockDriftMonitor(opt, nil)
+	threshold := opt.GetMaxClockDrift()
+	for drift, exceeded := range map[time.Duration]bool{
+		threshold + time.Second/2:  false,
+		-threshold - time.Second/2: false,
+		threshold + 2*time.Second:  true,
+	} {
+		now := time.Unix(time.Now().Unix(), 0)
+		end := uint64(now.Add(drift).Unix())
+		m.observeStore(1, "", &pdpb.StoreStats{Interval: &pdpb.TimeInterval{EndTimestamp: end}}, now)
+		c.Assert(m.getWorstDrifts(1)[0].Exceeded, Equals, exceeded, Commentf("drift %s", drift))
+	}
+}
+
+func (s *testClockDriftSuite) TestMemberDrift(c *C) {
+	offset := time.Hour
+	member := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.URL.Path, Equals, statusURL)
+		c.Assert(r.Header.Get(allowFollowerHandleHeader), Not(Equals), "")
+		fmt.Fprintf(w, `{"current_timestamp_ms":%d}`, time.Now().Add(offset).UnixNano()/int64(time.Millisecond))
+	}))
+	defer member.Close()
+	alerts := make(chan *ClockDrift, 2)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		drift := &ClockDrift{}
+		c.Assert(json.NewDecoder(r.Body).Decode(drift), IsNil)
+		alerts <- drift
+	}))
+	defer webhook.Close()
+
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cfg := opt.GetPDServerConfig().Clone()
+	cfg.ClockDriftWebhook = webhook.URL
+	opt.SetPDServerConfig(cfg)
+	m := newClockDriftMonitor(opt, http.DefaultClient)
+	members := []*pdpb.Member{
+		{Name: "pd1", MemberId: 1, ClientUrls: []string{"http://127.0.0.1:1"}},
+		{Name: "pd2", MemberId: 2, ClientUrls: []string{member.URL}},
+	}
+	m.checkMembers(members, "pd1")
+	drifts := m.getWorstDrifts(0)
+	c.Assert(drifts, HasLen, 1)
+	c.Assert(drifts[0].ID, Equals, uint64(2))
+	c.Assert(drifts[0].Drift.Duration > offset-time.Second && drifts[0].Drift.Duration < offset+time.Second, IsTrue)
+	select {
+	case alert := <-alerts:
+		c.Assert(alert.ID, Equals, uint64(2))
+		c.Assert(alert.Exceeded, IsTrue)
+	case <-time.After(3 * time.Second):
+		c.Fatal("no alert is posted")
+	}
+
+	// it does not alert again before it recovers.
+	m.checkMembers(members, "pd1")
+	offset = 0
+	m.checkMembers(members, "pd1")
+	c.Assert(m.getWorstDrifts(0)[0].Exceeded, IsFalse)
+	select {
+	case <-alerts:
+		c.Fatal("unexpected alert")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"path"
 	"sync"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/replication_modepb"
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/cache"
+	"github.com/tikv/pd/pkg/cgroup"
 	"github.com/tikv/pd/pkg/component"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/etcdutil"
@@ -39,6 +41,9 @@ import (
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/core/storelimit"
 	"github.com/tikv/pd/server/id"
+	"github.com/tikv/pd/server/job"
+	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/member"
 	syncer "github.com/tikv/pd/server/region_syncer"
 	"github.com/tikv/pd/server/replication"
 	"github.com/tikv/pd/server/schedule"
@@ -56,6 +61,9 @@ var backgroundJobInterval = 10 * time.Second
 const (
 	clientTimeout              = 3 * time.Second
 	defaultChangedRegionsLimit = 10000
+	// hotCacheMemoryLimitShare is the max share of the cgroup memory limit
+	// the hot cache may use.
+	hotCacheMemoryLimitShare = 0.25
 )
 
 // Server is the interface for cluster.
@@ -68,6 +76,7 @@ type Server interface {
 	GetRaftCluster() *RaftCluster
 	GetBasicCluster() *core.BasicCluster
 	ReplicateFileToAllMembers(ctx context.Context, name string, data []byte) error
+	GetMember() *member.Member
 }
 
 // RaftCluster is used for cluster config management.
@@ -102,9 +111,19 @@ type RaftCluster struct {
 	storesStats     *statistics.StoresStats
 	hotSpotCache    *statistics.HotCache
 
-	coordinator      *coordinator
-	suspectRegions   *cache.TTLUint64 // suspectRegions are regions that may need fix
-	suspectKeyRanges *cache.TTLString // suspect key-range regions that may need fix
+	coordinator        *coordinator
+	suspectRegions     *cache.TTLUint64        // suspectRegions are regions that may need fix
+	suspectKeyRanges   *cache.TTLString        // suspect key-range regions that may need fix
+	gcRanges           gcRanges                // key ranges dropped by the database layer
+	regionLabeler      regionLabeler           // label rules of key ranges
+	regionHeartbeats   regionHeartbeats        // the last heartbeat time of regions
+	regionHistory      regionHistory           // the latest events of regions
+	rejectedHeartbeats rejectedHeartbeats      // the latest heartbeats with stale region metadata
+	storeConfigs       storeConfigs            // the dynamic config items of stores
+	storeProgress      storeProgress           // the samples of the offline stores
+	unsafeRecoveryMu   sync.Mutex              // serializes starting the unsafe recoveries
+	hotRegionHistory   hotRegionHistory        // the snapshots of hot regions
+	regionTopology     regionTopologySnapshots // the persisted snapshots of region topology
 
 	wg           sync.WaitGroup
 	quit         chan struct{}
@@ -113,12 +132,16 @@ type RaftCluster struct {
 	ruleManager *placement.RuleManager
 	etcdClient  *clientv3.Client
 	httpClient  *http.Client
+	// member is nil if the cluster is not started by a server, like in tests.
+	member *member.Member
 
 	replicationMode *replication.ModeManager
 	traceRegionFlow bool
+	clockDrift      *clockDriftMonitor
 
 	// It's used to manage components.
 	componentManager *component.Manager
+	jobManager       *job.Manager
 }
 
 // Status saves some state information.
@@ -205,9 +228,11 @@ func (c *RaftCluster) InitCluster(id id.Allocator, opt *config.PersistOptions, s
 	c.prepareChecker = newPrepareChecker()
 	c.changedRegions = make(chan *core.RegionInfo, defaultChangedRegionsLimit)
 	c.hotSpotCache = statistics.NewHotCache()
+	c.hotSpotCache.SetMemoryBudget(c.hotCacheMemoryBudget())
 	c.suspectRegions = cache.NewIDTTL(c.ctx, time.Minute, 3*time.Minute)
 	c.suspectKeyRanges = cache.NewStringTTL(c.ctx, time.Minute, 3*time.Minute)
 	c.traceRegionFlow = opt.GetPDServerConfig().TraceRegionFlow
+	c.clockDrift = newClockDriftMonitor(opt, c.httpClient)
 }
 
 // Start starts a cluster.
@@ -221,6 +246,7 @@ func (c *RaftCluster) Start(s Server) error {
 	}
 
 	c.InitCluster(s.GetAllocator(), s.GetPersistOptions(), s.GetStorage(), s.GetBasicCluster())
+	c.member = s.GetMember()
 	cluster, err := c.LoadClusterInfo()
 	if err != nil {
 		return err
@@ -243,24 +269,52 @@ func (c *RaftCluster) Start(s Server) error {
 		return err
 	}
 
+	if err = c.storeConfigs.load(c.storage); err != nil {
+		return err
+	}
+	if err = c.gcRanges.load(c.storage); err != nil {
+		return err
+	}
+	if err = c.regionTopology.load(c.storage); err != nil {
+		return err
+	}
+	if err = c.regionLabeler.load(c.storage); err != nil {
+		return err
+	}
+
 	c.replicationMode, err = replication.NewReplicationModeManager(s.GetConfig().ReplicationMode, s.GetStorage(), cluster, s)
 	if err != nil {
 		return err
 	}
 
 	c.coordinator = newCoordinator(c.ctx, cluster, s.GetHBStreams())
+	if err := c.coordinator.opController.LoadOperatorAudits(c.storage); err != nil {
+		log.Error("failed to load operator audits", errs.ZapError(err))
+	}
 	c.regionStats = statistics.NewRegionStatistics(c.opt, c.ruleManager)
 	c.limiter = NewStoreLimiter(s.GetPersistOptions())
+	c.regionHeartbeats.reset()
+	c.regionHistory.reset()
+	c.rejectedHeartbeats.reset()
+	c.hotRegionHistory.reset()
+	c.storeProgress.reset()
 	c.quit = make(chan struct{})
 
-	c.wg.Add(4)
+	c.jobManager = job.NewManager(c.ctx, c.storage, c.id)
+	c.registerJobs(c.jobManager)
+	if err := c.jobManager.Start(); err != nil {
+		return err
+	}
+
+	c.wg.Add(5)
 	go c.runCoordinator()
 	failpoint.Inject("highFrequencyClusterJobs", func() {
 		backgroundJobInterval = 100 * time.Microsecond
 	})
-	go c.runBackgroundJobs(backgroundJobInterval)
+	go c.runBackgroundJobs(cgroup.GetLimits().ThrottleInterval(backgroundJobInterval))
 	go c.syncRegions()
 	go c.runReplicationMode()
+	go c.runClockDriftMonitor(s.GetConfig().Name)
 	c.running = true
 
 	return nil
@@ -320,6 +374,51 @@ func (c *RaftCluster) runBackgroundJobs(interval time.Duration) {
 			c.checkStores()
 			c.collectMetrics()
 			c.coordinator.opController.PruneHistory()
+			c.regionHistory.prune()
+			c.gcRanges.gc(c.storage)
+			c.regionHeartbeats.prune(func(regionID uint64) bool { return c.GetRegion(regionID) != nil })
+			c.saveOperatorAudits()
+			c.observeHotRegions()
+			c.saveRegionTopology()
+		}
+	}
+}
+
+func (c *RaftCluster) saveOperatorAudits() {
+	if err := c.coordinator.opController.SaveOperatorAudits(c.storage, c.leaderConds()...); err != nil {
+		log.Error("failed to save operator audits", errs.ZapError(err))
+	}
+}
+
+// leaderConds returns the conditions of the batch writes which are only done
+// by the leader, the leader key is under the root path of the storage.
+func (c *RaftCluster) leaderConds() []kv.Cond {
+	if c.member == nil {
+		return nil
+	}
+	return []kv.Cond{{Key: path.Base(c.member.GetLeaderPath()), Value: c.member.MemberValue()}}
+}
+
+func (c *RaftCluster) runClockDriftMonitor(self string) {
+	defer logutil.LogPanic()
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(clockDriftCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.quit:
+			c.clockDrift.reset()
+			log.Info("clock drift monitor has been stopped")
+			return
+		case <-ticker.C:
+			members, err := GetMembers(c.etcdClient)
+			if err != nil {
+				log.Error("failed to get members", errs.ZapError(err))
+				continue
+			}
+			c.clockDrift.checkMembers(members, self)
 		}
 	}
 }
@@ -361,6 +460,7 @@ func (c *RaftCluster) Stop() {
 	close(c.quit)
 	c.coordinator.stop()
 	c.Unlock()
+	c.jobManager.Stop()
 	c.wg.Wait()
 }
 
@@ -505,7 +605,10 @@ func (c *RaftCluster) HandleStoreHeartbeat(stats *pdpb.StoreStats) error {
 	if store == nil {
 		return errors.Errorf("store %v not found", storeID)
 	}
-	newStore := store.Clone(core.SetStoreStats(stats), core.SetLastHeartbeatTS(time.Now()))
+	now := time.Now()
+	c.clockDrift.observeStore(storeID, store.GetAddress(), stats, now)
+	newStore := store.Clone(core.SetStoreStats(stats), core.SetLastHeartbeatTS(now))
+	newStore = newStore.ShallowClone(core.SetStoreUtilization(newStore.CalculateUtilization(c.opt.GetStoreIOCapacity())))
 	if newStore.IsLowSpace(c.opt.GetLowSpaceRatio()) {
 		log.Warn("store does not have enough disk space",
 			zap.Uint64("store-id", newStore.GetID()),
@@ -542,11 +645,13 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 	origin, err := c.core.PreCheckPutRegion(region)
 	if err != nil {
 		c.RUnlock()
+		c.rejectedHeartbeats.observe(region, origin)
 		return err
 	}
 	writeItems := c.CheckWriteStatus(region)
 	readItems := c.CheckReadStatus(region)
 	c.RUnlock()
+	c.regionHeartbeats.observe(region.GetID())
 
 	// Save to storage if meta is updated.
 	// Save to cache if meta or leader is updated, or contains any down/pending peer.
@@ -627,14 +732,22 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 		time.Sleep(500 * time.Millisecond)
 	})
 
+	var source string
+	if saveCache {
+		// Get the operator before locking the cluster, because the operator
+		// controller may lock the cluster while holding its lock.
+		source = c.getOperatorDesc(region.GetID())
+	}
+
 	c.Lock()
 	if saveCache {
 		// To prevent a concurrent heartbeat of another region from overriding the up-to-date region info by a stale one,
 		// check its validation again here.
 		//
 		// However it can't solve the race condition of concurrent heartbeats from the same region.
-		if _, err := c.core.PreCheckPutRegion(region); err != nil {
+		if latest, err := c.core.PreCheckPutRegion(region); err != nil {
 			c.Unlock()
+			c.rejectedHeartbeats.observe(region, latest)
 			return err
 		}
 		overlaps := c.core.PutRegion(region)
@@ -648,10 +761,15 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 				}
 			}
 		}
+		c.regionHistory.observe(origin, region, overlaps, source)
+		if c.ruleManager != nil {
+			c.ruleManager.UpdateAntiAffinityIndex(region, overlaps)
+		}
 		for _, item := range overlaps {
 			if c.regionStats != nil {
 				c.regionStats.ClearDefunctRegion(item.GetID())
 			}
+			c.regionHeartbeats.remove(item.GetID())
 			c.labelLevelStats.ClearDefunctRegion(item.GetID(), c.opt.GetLocationLabels())
 		}
 
@@ -714,9 +832,10 @@ func (c *RaftCluster) updateStoreStatusLocked(id uint64) {
 	leaderCount := c.core.GetStoreLeaderCount(id)
 	regionCount := c.core.GetStoreRegionCount(id)
 	pendingPeerCount := c.core.GetStorePendingPeerCount(id)
+	downPeerCount := c.core.GetStoreDownPeerCount(id)
 	leaderRegionSize := c.core.GetStoreLeaderRegionSize(id)
 	regionSize := c.core.GetStoreRegionSize(id)
-	c.core.UpdateStoreStatus(id, leaderCount, regionCount, pendingPeerCount, leaderRegionSize, regionSize)
+	c.core.UpdateStoreStatus(id, leaderCount, regionCount, pendingPeerCount, downPeerCount, leaderRegionSize, regionSize)
 }
 
 //nolint:unused
@@ -777,6 +896,21 @@ func (c *RaftCluster) GetStoreRegions(storeID uint64) []*core.RegionInfo {
 	return c.core.GetStoreRegions(storeID)
 }
 
+// GetStoreLeaderRegions returns all regions whose leader is on the given store.
+func (c *RaftCluster) GetStoreLeaderRegions(storeID uint64) []*core.RegionInfo {
+	return c.core.GetStoreLeaderRegions(storeID)
+}
+
+// GetStoreFollowerRegions returns all regions which have a follower on the given store.
+func (c *RaftCluster) GetStoreFollowerRegions(storeID uint64) []*core.RegionInfo {
+	return c.core.GetStoreFollowerRegions(storeID)
+}
+
+// GetStoreLearnerRegions returns all regions which have a learner on the given store.
+func (c *RaftCluster) GetStoreLearnerRegions(storeID uint64) []*core.RegionInfo {
+	return c.core.GetStoreLearnerRegions(storeID)
+}
+
 // RandLeaderRegion returns a random region that has leader on the store.
 func (c *RaftCluster) RandLeaderRegion(storeID uint64, ranges []core.KeyRange, opts ...core.RegionOption) *core.RegionInfo {
 	return c.core.RandLeaderRegion(storeID, ranges, opts...)
@@ -859,6 +993,10 @@ func (c *RaftCluster) DropCacheRegion(id uint64) {
 	defer c.RUnlock()
 	if region := c.GetRegion(id); region != nil {
 		c.core.RemoveRegion(region)
+		c.regionHeartbeats.remove(id)
+		if c.ruleManager != nil {
+			c.ruleManager.RemoveFromAntiAffinityIndex(region)
+		}
 	}
 }
 
@@ -884,6 +1022,11 @@ func (c *RaftCluster) GetStore(storeID uint64) *core.StoreInfo {
 	return c.core.GetStore(storeID)
 }
 
+// GetStoreByAddress gets store from cluster by its address.
+func (c *RaftCluster) GetStoreByAddress(address string) *core.StoreInfo {
+	return c.core.GetStoreByAddress(address)
+}
+
 // IsRegionHot checks if a region is in hot state.
 func (c *RaftCluster) IsRegionHot(region *core.RegionInfo) bool {
 	c.RLock()
@@ -983,6 +1126,13 @@ func (c *RaftCluster) checkStoreVersion(store *metapb.Store) error {
 }
 
 func (c *RaftCluster) checkStoreLabels(s *core.StoreInfo) error {
+	// The findings of the lint are only advice, the store is not rejected for
+	// them even if strictly-match-label is on.
+	for _, issue := range lintStoreLabels(s, c.opt.GetLocationLabels(), c.opt.GetLocationLabelValues()) {
+		log.Warn("store label may be incorrect",
+			zap.Stringer("store", s.GetMeta()),
+			zap.Stringer("issue", issue))
+	}
 	if c.opt.IsPlacementRulesEnabled() {
 		return nil
 	}
@@ -1038,7 +1188,8 @@ func (c *RaftCluster) RemoveStore(storeID uint64) error {
 		zap.String("store-address", newStore.GetAddress()))
 	err := c.putStoreLocked(newStore)
 	if err == nil {
-		c.SetStoreLimit(storeID, storelimit.RemovePeer, storelimit.Unlimited)
+		// The store is offline even if the limit fails to be persisted.
+		_ = c.SetStoreLimit(storeID, storelimit.RemovePeer, storelimit.Unlimited)
 	}
 	return err
 }
@@ -1076,6 +1227,7 @@ func (c *RaftCluster) BuryStore(storeID uint64, force bool) error {
 	c.onStoreVersionChangeLocked()
 	if err == nil {
 		c.RemoveStoreLimit(storeID)
+		c.removeStoreConfig(storeID)
 	}
 	return err
 }
@@ -1157,7 +1309,9 @@ func (c *RaftCluster) checkStores() {
 	var offlineStores []*metapb.Store
 	var upStoreCount int
 	stores := c.GetStores()
+	now := time.Now()
 	for _, store := range stores {
+		c.storeProgress.observe(store, now)
 		// the store has already been tombstone
 		if store.IsTombstone() {
 			continue
@@ -1212,6 +1366,7 @@ func (c *RaftCluster) RemoveTombStoneRecords() error {
 				return err
 			}
 			c.RemoveStoreLimit(store.GetID())
+			c.removeStoreConfig(store.GetID())
 			log.Info("delete store succeeded",
 				zap.Stringer("store", store.GetMeta()))
 		}
@@ -1264,9 +1419,16 @@ func (c *RaftCluster) collectClusterMetrics() {
 	c.regionStats.Collect()
 	c.labelLevelStats.Collect()
 	// collect hot cache metrics
+	c.hotSpotCache.SetMemoryBudget(c.hotCacheMemoryBudget())
 	c.hotSpotCache.CollectMetrics()
 }
 
+// hotCacheMemoryBudget returns the configured memory budget of the hot cache,
+// capped by a share of the cgroup memory limit.
+func (c *RaftCluster) hotCacheMemoryBudget() uint64 {
+	return cgroup.GetLimits().CapMemoryBudget(c.opt.GetHotRegionCacheMemoryBudget(), hotCacheMemoryLimitShare)
+}
+
 func (c *RaftCluster) resetClusterMetrics() {
 	c.RLock()
 	defer c.RUnlock()
@@ -1467,6 +1629,17 @@ func (c *RaftCluster) RegionWriteStats() map[uint64][]*statistics.HotPeerStat {
 	return c.hotSpotCache.RegionStats(statistics.WriteFlow)
 }
 
+// GetClockDrifts returns at most limit clock drifts with the largest absolute
+// values between the PD leader and other PD members or stores.
+func (c *RaftCluster) GetClockDrifts(limit int) []*ClockDrift {
+	return c.clockDrift.getWorstDrifts(limit)
+}
+
+// GetHotCacheMemoryStatus returns the memory usage and degradation state of the hot cache.
+func (c *RaftCluster) GetHotCacheMemoryStatus() *statistics.HotCacheMemoryStatus {
+	return c.hotSpotCache.GetMemoryStatus()
+}
+
 // CheckWriteStatus checks the write status, returns whether need update statistics and item.
 func (c *RaftCluster) CheckWriteStatus(region *core.RegionInfo) []*statistics.HotPeerStat {
 	return c.hotSpotCache.CheckWrite(region)
@@ -1661,14 +1834,33 @@ func (c *RaftCluster) RemoveStoreLimit(storeID uint64) {
 	c.opt.SetScheduleConfig(cfg)
 }
 
-// SetStoreLimit sets a store limit for a given type and rate.
-func (c *RaftCluster) SetStoreLimit(storeID uint64, typ storelimit.Type, ratePerMin float64) {
+// SetStoreLimit sets a store limit for a given type and rate, the limit is
+// persisted so it is kept after the leader is changed.
+func (c *RaftCluster) SetStoreLimit(storeID uint64, typ storelimit.Type, ratePerMin float64) error {
+	old := c.opt.GetScheduleConfig().Clone()
 	c.opt.SetStoreLimit(storeID, typ, ratePerMin)
+	if err := c.opt.Persist(c.storage); err != nil {
+		// roll back the store limit
+		c.opt.SetScheduleConfig(old)
+		log.Error("persist store limit meet error", errs.ZapError(err))
+		return err
+	}
+	log.Info("store limit changed", zap.Uint64("store-id", storeID), zap.String("type", typ.String()), zap.Float64("rate-per-min", ratePerMin))
+	return nil
 }
 
 // SetAllStoresLimit sets all store limit for a given type and rate.
-func (c *RaftCluster) SetAllStoresLimit(typ storelimit.Type, ratePerMin float64) {
+func (c *RaftCluster) SetAllStoresLimit(typ storelimit.Type, ratePerMin float64) error {
+	old := c.opt.GetScheduleConfig().Clone()
 	c.opt.SetAllStoresLimit(typ, ratePerMin)
+	if err := c.opt.Persist(c.storage); err != nil {
+		// roll back the store limit
+		c.opt.SetScheduleConfig(old)
+		log.Error("persist store limit meet error", errs.ZapError(err))
+		return err
+	}
+	log.Info("all store limit changed", zap.String("type", typ.String()), zap.Float64("rate-per-min", ratePerMin))
+	return nil
 }
 
 // SetAllStoresLimitTTL sets all store limit for a given type and rate with ttl.
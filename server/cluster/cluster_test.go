@@ -15,9 +15,12 @@ package cluster
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/rand"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -26,13 +29,19 @@ import (
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/cache"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/mock/mockid"
+	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/core/storelimit"
 	"github.com/tikv/pd/server/id"
+	"github.com/tikv/pd/server/job"
 	"github.com/tikv/pd/server/kv"
 	"github.com/tikv/pd/server/schedule/opt"
 	"github.com/tikv/pd/server/schedule/placement"
+	"github.com/tikv/pd/server/statistics"
 	"github.com/tikv/pd/server/versioninfo"
 )
 
@@ -91,6 +100,18 @@ func (s *testClusterInfoSuite) TestStoreHeartbeat(c *C) {
 		c.Assert(err, IsNil)
 		c.Assert(tmp, DeepEquals, storeMetasAfterHeartbeat[i])
 	}
+
+	// The utilization is calculated from the heartbeat.
+	cfg := opt.GetScheduleConfig().Clone()
+	cfg.StoreIOCapacity = 100
+	opt.SetScheduleConfig(cfg)
+	storeStats := &pdpb.StoreStats{
+		StoreId:      stores[0].GetID(),
+		CpuUsages:    []*pdpb.RecordPair{{Key: "grpc", Value: 40}},
+		WriteIoRates: []*pdpb.RecordPair{{Key: "sst", Value: 60}},
+	}
+	c.Assert(cluster.HandleStoreHeartbeat(storeStats), IsNil)
+	c.Assert(cluster.GetStore(stores[0].GetID()).GetUtilization(), Equals, 0.6)
 }
 
 func (s *testClusterInfoSuite) TestFilterUnhealthyStore(c *C) {
@@ -424,6 +445,676 @@ func (s *testClusterInfoSuite) TestRegionFlowChanged(c *C) {
 	c.Assert(newRegion.GetBytesRead(), Not(Equals), uint64(0))
 }
 
+func (s *testClusterInfoSuite) TestStaleHeartbeatRegions(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cluster := newTestRaftCluster(mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+	cluster.regionHeartbeats.reset()
+	regions := newTestRegions(3, 3)
+	for _, region := range regions {
+		c.Assert(cluster.processRegionHeartbeat(region), IsNil)
+	}
+	now := time.Now()
+	c.Assert(cluster.GetStaleHeartbeatRegions(time.Time{}, now.Add(-time.Minute)), HasLen, 0)
+	c.Assert(cluster.GetStaleHeartbeatRegions(time.Time{}, time.Now()), HasLen, 3)
+
+	// The region which is not changed is not updated in cache, but its
+	// heartbeat is still recorded.
+	cluster.regionHeartbeats.setLastTime(0, now.Add(-time.Hour))
+	cluster.regionHeartbeats.setLastTime(1, now.Add(-time.Hour))
+	c.Assert(cluster.processRegionHeartbeat(regions[1]), IsNil)
+	stale := cluster.GetStaleHeartbeatRegions(time.Time{}, now.Add(-time.Minute))
+	c.Assert(stale, HasLen, 1)
+	c.Assert(stale[0].GetID(), Equals, uint64(0))
+
+	// The region which is not reported since the cluster is started.
+	cluster.regionHeartbeats.remove(2)
+	atomic.StoreInt64(&cluster.regionHeartbeats.startTime, now.Add(-2*time.Hour).UnixNano())
+	c.Assert(cluster.GetStaleHeartbeatRegions(time.Time{}, now.Add(-time.Minute)), HasLen, 2)
+	// Only the region stopped reporting within 90 minutes.
+	stale = cluster.GetStaleHeartbeatRegions(now.Add(-90*time.Minute), now.Add(-time.Minute))
+	c.Assert(stale, HasLen, 1)
+	c.Assert(stale[0].GetID(), Equals, uint64(0))
+
+	// The heartbeat times of the regions which no longer exist are pruned.
+	cluster.regionHeartbeats.setLastTime(100, now)
+	cluster.regionHeartbeats.prune(func(regionID uint64) bool { return cluster.GetRegion(regionID) != nil })
+	c.Assert(cluster.regionHeartbeats.shard(100).lastTimes, Not(HasKey), uint64(100))
+	c.Assert(cluster.regionHeartbeats.shard(0).lastTimes, HasKey, uint64(0))
+}
+
+func (s *testClusterInfoSuite) TestRegionHistory(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cluster := newTestRaftCluster(mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+	cluster.regionHistory.reset()
+	regions := newTestRegions(3, 3)
+	for _, region := range regions {
+		c.Assert(cluster.processRegionHeartbeat(region), IsNil)
+	}
+	c.Assert(cluster.GetRegionHistory(1), HasLen, 0)
+
+	// leader transfer and peer change
+	region := regions[1].Clone(core.WithLeader(regions[1].GetPeers()[1]))
+	c.Assert(cluster.processRegionHeartbeat(region), IsNil)
+	region = region.Clone(core.WithRemoveStorePeer(0), core.WithIncConfVer())
+	c.Assert(cluster.processRegionHeartbeat(region), IsNil)
+	history := cluster.GetRegionHistory(1)
+	c.Assert(history, HasLen, 2)
+	c.Assert(history[0].Type, Equals, RegionEventLeaderTransfer)
+	c.Assert(history[0].Detail, Equals, "from store 1 to store 2")
+	c.Assert(history[1].Type, Equals, RegionEventPeerChange)
+
+	// split: the new region is reported before the origin one.
+	left := region.Clone(core.WithNewRegionID(10), core.WithEndKey([]byte{1, 0x80}), core.WithIncVersion())
+	c.Assert(cluster.processRegionHeartbeat(left), IsNil)
+	history = cluster.GetRegionHistory(10)
+	c.Assert(history, HasLen, 1)
+	c.Assert(history[0].Type, Equals, RegionEventSplit)
+	c.Assert(history[0].Detail, Equals, "split from region 1")
+	history = cluster.GetRegionHistory(1)
+	c.Assert(history, HasLen, 3)
+	c.Assert(history[2].Detail, Equals, "split into region 10")
+
+	// merge: region 10 is merged into region 0.
+	merged := regions[0].Clone(core.WithEndKey(left.GetEndKey()), core.WithIncVersion(), core.WithIncVersion())
+	c.Assert(cluster.processRegionHeartbeat(merged), IsNil)
+	history = cluster.GetRegionHistory(0)
+	c.Assert(history, HasLen, 1)
+	c.Assert(history[0].Type, Equals, RegionEventMerge)
+	c.Assert(strings.HasPrefix(history[0].Detail, "merged regions [10]"), IsTrue)
+	history = cluster.GetRegionHistory(10)
+	c.Assert(history, HasLen, 2)
+	c.Assert(history[1].Detail, Equals, "merged into region 0")
+
+	// The history is bounded and expired.
+	for i := 0; i <= maxRegionHistory; i++ {
+		leader := merged.GetPeers()[1+i%2]
+		merged = merged.Clone(core.WithLeader(leader))
+		c.Assert(cluster.processRegionHeartbeat(merged), IsNil)
+	}
+	history = cluster.GetRegionHistory(0)
+	c.Assert(history, HasLen, maxRegionHistory)
+	c.Assert(history[0].Type, Equals, RegionEventLeaderTransfer)
+	for _, item := range cluster.regionHistory.events.Elems() {
+		(*item.Value.(*[]RegionEvent))[0].Time = time.Now().Add(-regionHistoryTTL - time.Minute)
+	}
+	cluster.regionHistory.prune()
+	c.Assert(cluster.GetRegionHistory(0), HasLen, maxRegionHistory-1)
+	c.Assert(cluster.GetRegionHistory(1), HasLen, 2)
+	c.Assert(cluster.GetRegionHistory(10), HasLen, 1)
+
+	// The least recently changed region is evicted.
+	cluster.regionHistory.events = cache.NewCache(2, cache.LRUCache)
+	for _, id := range []uint64{1, 2, 1, 3} {
+		cluster.regionHistory.appendLocked(id, RegionEvent{Time: time.Now(), Type: RegionEventSplit})
+	}
+	c.Assert(cluster.GetRegionHistory(1), HasLen, 2)
+	c.Assert(cluster.GetRegionHistory(2), HasLen, 0)
+	c.Assert(cluster.GetRegionHistory(3), HasLen, 1)
+}
+
+func (s *testClusterInfoSuite) TestRejectedHeartbeats(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cluster := newTestRaftCluster(mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+	regions := newTestRegions(3, 3)
+	for _, region := range regions {
+		c.Assert(cluster.processRegionHeartbeat(region), IsNil)
+	}
+	c.Assert(cluster.GetRejectedHeartbeats(), HasLen, 0)
+
+	// The heartbeats with older epoch are rejected and the metadata is kept.
+	c.Assert(cluster.processRegionHeartbeat(regions[1].Clone(core.WithDecVersion())), NotNil)
+	c.Assert(cluster.processRegionHeartbeat(regions[1].Clone(core.WithDecConfVer(), core.WithLeader(regions[1].GetPeers()[1]))), NotNil)
+	overlap := regions[1].Clone(core.WithNewRegionID(10), core.WithEndKey(regions[2].GetEndKey()), core.WithDecVersion())
+	c.Assert(cluster.processRegionHeartbeat(overlap), NotNil)
+	c.Assert(cluster.GetRegion(1).GetRegionEpoch(), DeepEquals, regions[1].GetRegionEpoch())
+	c.Assert(cluster.GetRegion(1).GetLeader(), DeepEquals, regions[1].GetLeader())
+	c.Assert(cluster.GetRegion(10), IsNil)
+
+	rejected := cluster.GetRejectedHeartbeats()
+	c.Assert(rejected, HasLen, 3)
+	c.Assert(rejected[0].RegionID, Equals, uint64(1))
+	c.Assert(rejected[0].Reason, Equals, RejectReasonVersion)
+	c.Assert(rejected[0].Epoch.GetVersion(), Equals, uint64(1))
+	c.Assert(rejected[0].OriginEpoch.GetVersion(), Equals, uint64(2))
+	c.Assert(rejected[1].Reason, Equals, RejectReasonConfVer)
+	c.Assert(rejected[1].StoreID, Equals, regions[1].GetPeers()[1].GetStoreId())
+	c.Assert(rejected[2].RegionID, Equals, uint64(10))
+	c.Assert(rejected[2].Reason, Equals, RejectReasonOverlap)
+	c.Assert(rejected[2].OriginEpoch, IsNil)
+
+	// Only the latest ones are kept.
+	for i := 0; i < maxRejectedHeartbeats; i++ {
+		c.Assert(cluster.processRegionHeartbeat(regions[2].Clone(core.WithDecVersion())), NotNil)
+	}
+	rejected = cluster.GetRejectedHeartbeats()
+	c.Assert(rejected, HasLen, maxRejectedHeartbeats)
+	c.Assert(rejected[0].RegionID, Equals, uint64(2))
+}
+
+func (s *testClusterInfoSuite) TestStoreConfig(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	cluster := newTestRaftCluster(mockid.NewIDAllocator(), opt, storage, core.NewBasicCluster())
+	for _, store := range newTestStores(2, "2.0.0") {
+		c.Assert(cluster.PutStore(store.GetMeta()), IsNil)
+	}
+
+	items, err := cluster.GetStoreConfig(1)
+	c.Assert(err, IsNil)
+	c.Assert(items, HasLen, 0)
+	c.Assert(cluster.UpdateStoreConfig(1, map[string]string{"snap-concurrency": "4", "import-mode": "true"}), IsNil)
+	c.Assert(cluster.UpdateStoreConfig(1, map[string]string{"snap-concurrency": "8"}), IsNil)
+	c.Assert(cluster.UpdateStoreConfig(2, map[string]string{"import-mode": "false"}), IsNil)
+	c.Assert(cluster.DeleteStoreConfig(1, "import-mode"), IsNil)
+	items, err = cluster.GetStoreConfig(1)
+	c.Assert(err, IsNil)
+	c.Assert(items, DeepEquals, map[string]string{"snap-concurrency": "8"})
+	_, err = cluster.GetStoreConfig(3)
+	c.Assert(err, NotNil)
+	c.Assert(cluster.UpdateStoreConfig(3, map[string]string{"import-mode": "true"}), NotNil)
+
+	// The config is loaded by the new leader.
+	var configs storeConfigs
+	c.Assert(configs.load(storage), IsNil)
+	c.Assert(configs.get(1), DeepEquals, map[string]string{"snap-concurrency": "8"})
+	c.Assert(configs.get(2), DeepEquals, map[string]string{"import-mode": "false"})
+	c.Assert(cluster.DeleteStoreConfig(2, "import-mode"), IsNil)
+	c.Assert(configs.load(storage), IsNil)
+	c.Assert(configs.get(2), HasLen, 0)
+
+	// The config is removed with the tombstone store.
+	c.Assert(cluster.BuryStore(1, true), IsNil)
+	c.Assert(cluster.storeConfigs.get(1), HasLen, 0)
+	c.Assert(configs.load(storage), IsNil)
+	c.Assert(configs.get(1), HasLen, 0)
+}
+
+func (s *testClusterInfoSuite) TestRegionLabelRule(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	cluster := newTestRaftCluster(mockid.NewIDAllocator(), opt, storage, core.NewBasicCluster())
+	region := core.NewRegionInfo(&metapb.Region{Id: 1, StartKey: []byte("a"), EndKey: []byte("c")}, nil)
+
+	c.Assert(cluster.SetRegionLabelRule(&LabelRule{ID: "r1", Labels: []RegionLabel{{Key: "k", Value: "v"}}, HexStart: "zz"}), NotNil)
+	c.Assert(cluster.SetRegionLabelRule(&LabelRule{ID: "r2", Labels: []RegionLabel{{Key: "k", Value: "v2"}}, HexStart: "62"}), IsNil)
+	c.Assert(cluster.SetRegionLabelRule(&LabelRule{ID: "r3", Labels: []RegionLabel{{Key: ScheduleLabelKey, Value: ScheduleDeny}}, HexStart: "63"}), IsNil)
+	c.Assert(cluster.GetRegionLabel(region, "k"), Equals, "v2")
+	c.Assert(cluster.IsRegionScheduleDenied(region), IsFalse)
+	// The rule with the smallest ID wins.
+	c.Assert(cluster.SetRegionLabelRule(&LabelRule{ID: "r1", Labels: []RegionLabel{{Key: "k", Value: "v1"}}, HexEnd: "62"}), IsNil)
+	c.Assert(cluster.GetRegionLabel(region, "k"), Equals, "v1")
+
+	// The rules are loaded by the new leader.
+	var labeler regionLabeler
+	c.Assert(labeler.load(storage), IsNil)
+	c.Assert(labeler.list(), HasLen, 3)
+	c.Assert(labeler.getLabel(region, "k"), Equals, "v1")
+	ok, err := cluster.DeleteRegionLabelRule("r1")
+	c.Assert(ok, IsTrue)
+	c.Assert(err, IsNil)
+	ok, err = cluster.DeleteRegionLabelRule("r1")
+	c.Assert(ok, IsFalse)
+	c.Assert(err, IsNil)
+	c.Assert(labeler.load(storage), IsNil)
+	c.Assert(labeler.getLabel(region, "k"), Equals, "v2")
+
+	// The rule with a TTL is ignored after it expires, and it is removed from
+	// the storage when another rule is set.
+	c.Assert(cluster.SetRegionLabelRule(&LabelRule{ID: "r0", Labels: []RegionLabel{{Key: "k", Value: "v0"}}, HexEnd: "62", TTL: "-1h"}), NotNil)
+	c.Assert(cluster.SetRegionLabelRule(&LabelRule{ID: "r0", Labels: []RegionLabel{{Key: "k", Value: "v0"}}, HexEnd: "62", TTL: "1h"}), IsNil)
+	rule := cluster.GetRegionLabelRule("r0")
+	c.Assert(rule.TTL, Equals, "")
+	c.Assert(rule.ExpireTime, NotNil)
+	c.Assert(cluster.GetRegionLabel(region, "k"), Equals, "v0")
+	expireTime := time.Now().Add(-time.Second)
+	rule.ExpireTime = &expireTime
+	c.Assert(cluster.GetRegionLabel(region, "k"), Equals, "v2")
+	c.Assert(cluster.GetRegionLabelRule("r0"), IsNil)
+	c.Assert(cluster.GetRegionLabelRules(), HasLen, 2)
+	c.Assert(labeler.load(storage), IsNil)
+	c.Assert(labeler.list(), HasLen, 3)
+	c.Assert(cluster.SetRegionLabelRule(&LabelRule{ID: "r4", Labels: []RegionLabel{{Key: "k", Value: "v4"}}, HexStart: "64"}), IsNil)
+	c.Assert(labeler.load(storage), IsNil)
+	c.Assert(labeler.list(), HasLen, 3)
+	c.Assert(labeler.rules["r0"], IsNil)
+}
+
+func (s *testClusterInfoSuite) TestStoreProgress(c *C) {
+	var p storeProgress
+	store := core.NewStoreInfo(&metapb.Store{Id: 1, State: metapb.StoreState_Up}, core.SetRegionCount(100), core.SetRegionSize(1000))
+	now := time.Now()
+	p.observe(store, now)
+	c.Assert(p.get(1), IsNil)
+
+	store = store.Clone(core.SetStoreState(metapb.StoreState_Offline))
+	start := now.Add(-2 * storeProgressWindow)
+	p.observe(store, start)
+	progress := p.get(1)
+	c.Assert(progress.Progress, Equals, 0.0)
+	c.Assert(progress.RegionsLeft, Equals, 100)
+	c.Assert(progress.EstimatedCompletionTime, IsNil)
+
+	// The rate is calculated by the samples in the latest window.
+	p.observe(store.Clone(core.SetRegionCount(80), core.SetRegionSize(800)), now.Add(-100*time.Second))
+	p.observe(store.Clone(core.SetRegionCount(50), core.SetRegionSize(500)), now)
+	progress = p.get(1)
+	c.Assert(progress.Progress, Equals, 0.5)
+	c.Assert(progress.RegionsLeft, Equals, 50)
+	c.Assert(uint64(progress.BytesLeft), Equals, uint64(500<<20))
+	c.Assert(uint64(progress.MigrationRate), Equals, uint64(3<<20))
+	c.Assert(progress.StartTime, Equals, start)
+	c.Assert(progress.EstimatedCompletionTime, NotNil)
+	c.Assert(progress.EstimatedCompletionTime.Sub(now).Round(time.Second), Equals, (500 * time.Second / 3).Round(time.Second))
+
+	store = store.Clone(core.SetStoreState(metapb.StoreState_Tombstone))
+	p.observe(store, now)
+	c.Assert(p.get(1), IsNil)
+}
+
+func (s *testClusterInfoSuite) TestConsistency(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	cluster := newTestRaftCluster(mockid.NewIDAllocator(), opt, storage, core.NewBasicCluster())
+	// Store 3 is not persisted, store 4 is stale and store 5 is tombstone.
+	for _, store := range newTestStores(5, "2.0.0") {
+		switch store.GetID() {
+		case 3:
+			cluster.core.PutStore(store.Clone(core.SetLastHeartbeatTS(time.Now())))
+			continue
+		case 4:
+		case 5:
+			store = store.Clone(core.SetStoreState(metapb.StoreState_Tombstone))
+		default:
+			store = store.Clone(core.SetLastHeartbeatTS(time.Now()))
+		}
+		c.Assert(cluster.putStoreLocked(store), IsNil)
+	}
+	cluster.core.PutRegion(core.NewRegionInfo(&metapb.Region{Id: 1, EndKey: []byte("a"), Peers: []*metapb.Peer{{Id: 11, StoreId: 1}, {Id: 12, StoreId: 2}, {Id: 19, StoreId: 9}}}, nil))
+	cluster.core.PutRegion(core.NewRegionInfo(&metapb.Region{Id: 2, StartKey: []byte("a"), Peers: []*metapb.Peer{{Id: 21, StoreId: 1}, {Id: 25, StoreId: 5}}}, nil))
+	c.Assert(cluster.SetStoreLimit(1, storelimit.AddPeer, 10), IsNil)
+	c.Assert(cluster.SetStoreLimit(9, storelimit.AddPeer, 10), IsNil)
+
+	report, err := cluster.CheckConsistency()
+	c.Assert(err, IsNil)
+	c.Assert(report.StaleStores, DeepEquals, []uint64{4})
+	c.Assert(report.UnpersistedStores, DeepEquals, []uint64{3})
+	c.Assert(report.UnknownStorePeers, DeepEquals, []*ConsistencyPeer{
+		{RegionID: 1, PeerID: 19, StoreID: 9},
+		{RegionID: 2, PeerID: 25, StoreID: 5},
+	})
+	c.Assert(report.OrphanedStoreLimits, DeepEquals, []uint64{9})
+
+	cleaned, err := cluster.CleanupInconsistency()
+	c.Assert(err, IsNil)
+	c.Assert(cleaned.UnpersistedStores, DeepEquals, []uint64{3})
+	c.Assert(cleaned.OrphanedStoreLimits, DeepEquals, []uint64{9})
+	ok, err := storage.LoadStore(3, &metapb.Store{})
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	report, err = cluster.CheckConsistency()
+	c.Assert(err, IsNil)
+	c.Assert(report.UnpersistedStores, HasLen, 0)
+	c.Assert(report.OrphanedStoreLimits, HasLen, 0)
+	c.Assert(report.UnknownStorePeers, HasLen, 2)
+	c.Assert(cluster.GetStoreLimitByType(1, storelimit.AddPeer), Equals, float64(10))
+}
+
+func (s *testClusterInfoSuite) TestGCRanges(c *C) {
+	storage := core.NewStorage(kv.NewMemoryKV())
+	var ranges gcRanges
+	r, err := ranges.add(storage, []byte("a"), []byte("b"), time.Minute)
+	c.Assert(err, IsNil)
+	_, err = ranges.add(storage, []byte("c"), nil, time.Millisecond)
+	c.Assert(err, IsNil)
+	region := core.NewRegionInfo(&metapb.Region{Id: 1, StartKey: []byte("a"), EndKey: []byte("a1")}, nil)
+
+	// The ranges are loaded after the leader changes, the expired one is
+	// removed from storage.
+	time.Sleep(10 * time.Millisecond)
+	var loaded gcRanges
+	c.Assert(loaded.load(storage), IsNil)
+	list := loaded.list()
+	c.Assert(list, HasLen, 1)
+	c.Assert(list[0].StartKey, DeepEquals, r.StartKey)
+	c.Assert(list[0].EndKey, DeepEquals, r.EndKey)
+	c.Assert(list[0].ExpireTime.Equal(r.ExpireTime), IsTrue)
+	c.Assert(loaded.contains(region), IsTrue)
+	count := 0
+	c.Assert(storage.LoadGCRanges(func(k, v string) { count++ }), IsNil)
+	c.Assert(count, Equals, 1)
+
+	// The expired range is removed from storage without adding another one.
+	loaded.ranges[0].ExpireTime = time.Now()
+	loaded.gc(storage)
+	c.Assert(loaded.ranges, HasLen, 0)
+	count = 0
+	c.Assert(storage.LoadGCRanges(func(k, v string) { count++ }), IsNil)
+	c.Assert(count, Equals, 0)
+}
+
+func (s *testClusterInfoSuite) TestDrainStore(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	cluster := newTestRaftCluster(mockid.NewIDAllocator(), opt, storage, core.NewBasicCluster())
+	cluster.jobManager = job.NewManager(ctx, storage, cluster.id)
+	cluster.registerJobs(cluster.jobManager)
+	c.Assert(cluster.jobManager.Start(), IsNil)
+	defer cluster.jobManager.Stop()
+	defer func(interval time.Duration) { drainStoreCheckInterval = interval }(drainStoreCheckInterval)
+	drainStoreCheckInterval = 10 * time.Millisecond
+	for _, store := range newTestStores(2, "2.0.0") {
+		c.Assert(cluster.putStoreLocked(store), IsNil)
+	}
+	for id := uint64(1); id <= 4; id++ {
+		peer := &metapb.Peer{Id: id * 10, StoreId: 1}
+		cluster.core.PutRegion(core.NewRegionInfo(&metapb.Region{Id: id, StartKey: []byte{byte(id)}, EndKey: []byte{byte(id + 1)}, Peers: []*metapb.Peer{peer}}, peer))
+	}
+	submit := func(storeID uint64) *job.Job {
+		params, err := json.Marshal(DrainStoreParams{StoreID: storeID})
+		c.Assert(err, IsNil)
+		j, err := cluster.jobManager.Submit(DrainStoreJob, params)
+		c.Assert(err, IsNil)
+		return j
+	}
+	waitJob := func(id uint64, check func(*job.Job) bool) {
+		testutil.WaitUntil(c, func(c *C) bool {
+			j, err := cluster.jobManager.GetJob(id)
+			c.Assert(err, IsNil)
+			return check(j)
+		})
+	}
+
+	// The store is set offline and the progress follows the regions moved out.
+	j := submit(1)
+	waitJob(j.ID, func(j *job.Job) bool { return cluster.GetStore(1).IsOffline() && j.State == job.Running })
+	for id := uint64(1); id <= 2; id++ {
+		peer := &metapb.Peer{Id: id*10 + 2, StoreId: 2}
+		cluster.core.PutRegion(core.NewRegionInfo(&metapb.Region{Id: id, StartKey: []byte{byte(id)}, EndKey: []byte{byte(id + 1)}, Peers: []*metapb.Peer{peer}}, peer))
+	}
+	waitJob(j.ID, func(j *job.Job) bool { return j.Progress == 0.5 })
+	for id := uint64(3); id <= 4; id++ {
+		peer := &metapb.Peer{Id: id*10 + 2, StoreId: 2}
+		cluster.core.PutRegion(core.NewRegionInfo(&metapb.Region{Id: id, StartKey: []byte{byte(id)}, EndKey: []byte{byte(id + 1)}, Peers: []*metapb.Peer{peer}}, peer))
+	}
+	waitJob(j.ID, func(j *job.Job) bool { return j.State == job.Finished && j.Progress == 1 })
+
+	// The job fails if the store does not exist.
+	j = submit(9)
+	waitJob(j.ID, func(j *job.Job) bool { return j.State == job.Failed })
+}
+
+func (s *testClusterInfoSuite) TestUnsafeRecovery(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	cluster := newTestRaftCluster(mockid.NewIDAllocator(), opt, storage, core.NewBasicCluster())
+	startJobManager := func() {
+		cluster.jobManager = job.NewManager(ctx, storage, cluster.id)
+		cluster.registerJobs(cluster.jobManager)
+		c.Assert(cluster.jobManager.Start(), IsNil)
+	}
+	startJobManager()
+	defer func() { cluster.jobManager.Stop() }()
+	defer func(interval time.Duration) { unsafeRecoveryCheckInterval = interval }(unsafeRecoveryCheckInterval)
+	unsafeRecoveryCheckInterval = 10 * time.Millisecond
+	// Store 4 and 5 are failed.
+	for _, store := range newTestStores(5, "2.0.0") {
+		if store.GetID() <= 3 {
+			store = store.Clone(core.SetLastHeartbeatTS(time.Now()))
+		}
+		c.Assert(cluster.putStoreLocked(store), IsNil)
+	}
+	newRegion := func(id uint64, storeIDs ...uint64) *core.RegionInfo {
+		peers := make([]*metapb.Peer, 0, len(storeIDs))
+		for _, storeID := range storeIDs {
+			peers = append(peers, &metapb.Peer{Id: id*10 + storeID, StoreId: storeID})
+		}
+		return core.NewRegionInfo(&metapb.Region{Id: id, StartKey: []byte{byte(id)}, EndKey: []byte{byte(id + 1)}, Peers: peers}, peers[0])
+	}
+	cluster.core.PutRegion(newRegion(1, 1, 4, 5))
+	cluster.core.PutRegion(newRegion(2, 1, 2, 4))
+	cluster.core.PutRegion(newRegion(3, 4, 5))
+	cluster.core.PutRegion(newRegion(4, 2, 4, 5))
+
+	status, err := cluster.GetUnsafeRecoveryStatus()
+	c.Assert(err, IsNil)
+	c.Assert(status, IsNil)
+	_, err = cluster.PlanRemoveFailedStores(nil, time.Minute)
+	c.Assert(errs.ErrUnsafeRecoveryInvalidInput.Equal(err), IsTrue)
+	_, err = cluster.PlanRemoveFailedStores([]uint64{4, 9}, time.Minute)
+	c.Assert(errs.ErrStoreNotFound.Equal(err), IsTrue)
+	_, err = cluster.PlanRemoveFailedStores([]uint64{3, 4}, time.Minute)
+	c.Assert(errs.ErrUnsafeRecoveryInvalidInput.Equal(err), IsTrue)
+
+	status, err = cluster.PlanRemoveFailedStores([]uint64{5, 4}, time.Minute)
+	c.Assert(err, IsNil)
+	c.Assert(status.JobID, Not(Equals), uint64(0))
+	c.Assert(status.FailedStores, DeepEquals, []uint64{4, 5})
+	c.Assert(status.Stage, Equals, UnsafeRecoveryRecovering)
+	// The lost region is not counted, because it can only be recreated.
+	c.Assert(status.Regions, Equals, 2)
+	c.Assert(status.Recovered, Equals, 0)
+	c.Assert(status.LostRegions, DeepEquals, []uint64{3})
+	c.Assert(status.Plans, HasLen, 2)
+	c.Assert(status.Plans[0].StoreID, Equals, uint64(1))
+	c.Assert(status.Plans[0].Regions, DeepEquals, []uint64{1})
+	c.Assert(status.Plans[0].Command, Equals, "tikv-ctl --db <data-dir>/db unsafe-recover remove-fail-stores -s 4,5 -r 1")
+	c.Assert(status.Plans[1].StoreID, Equals, uint64(2))
+	c.Assert(status.Plans[1].Regions, DeepEquals, []uint64{4})
+	_, err = cluster.PlanRemoveFailedStores([]uint64{4}, time.Minute)
+	c.Assert(errs.ErrUnsafeRecoveryIsRunning.Equal(err), IsTrue)
+
+	// The regions are recovered once they have no peer on the failed stores.
+	cluster.core.PutRegion(newRegion(1, 1))
+	status, err = cluster.GetUnsafeRecoveryStatus()
+	c.Assert(err, IsNil)
+	c.Assert(status.Stage, Equals, UnsafeRecoveryRecovering)
+	c.Assert(status.Recovered, Equals, 1)
+
+	// The recovery is resumed from storage after the leader is changed.
+	cluster.jobManager.Stop()
+	startJobManager()
+	cluster.core.PutRegion(newRegion(4, 2))
+	testutil.WaitUntil(c, func(c *C) bool {
+		status, err = cluster.GetUnsafeRecoveryStatus()
+		c.Assert(err, IsNil)
+		return status.Stage == UnsafeRecoveryFinished
+	})
+	c.Assert(status.Recovered, Equals, 2)
+
+	// A new recovery can be started after the last one is done.
+	cluster.core.PutRegion(newRegion(5, 1, 4, 5))
+	status, err = cluster.PlanRemoveFailedStores([]uint64{4, 5}, 0)
+	c.Assert(err, IsNil)
+	testutil.WaitUntil(c, func(c *C) bool {
+		status, err = cluster.GetUnsafeRecoveryStatus()
+		c.Assert(err, IsNil)
+		return status.Stage == UnsafeRecoveryTimeout
+	})
+	c.Assert(status.Regions, Equals, 1)
+	c.Assert(status.Recovered, Equals, 0)
+	c.Assert(status.Error, Not(Equals), "")
+}
+
+func (s *testClusterInfoSuite) TestHotRegionHistory(c *C) {
+	read := &statistics.StoreHotPeersInfos{
+		AsPeer: statistics.StoreHotPeersStat{
+			1: {Stats: []statistics.HotPeerStat{{StoreID: 1, RegionID: 1, ByteRate: 100}}},
+			2: {Stats: []statistics.HotPeerStat{{StoreID: 2, RegionID: 1, ByteRate: 100}}},
+		},
+		AsLeader: statistics.StoreHotPeersStat{
+			1: {Stats: []statistics.HotPeerStat{{StoreID: 1, RegionID: 1, ByteRate: 100}}},
+		},
+	}
+	write := &statistics.StoreHotPeersInfos{
+		AsPeer: statistics.StoreHotPeersStat{
+			3: {Stats: []statistics.HotPeerStat{{StoreID: 3, RegionID: 2, KeyRate: 10}}},
+		},
+	}
+
+	var history hotRegionHistory
+	start := time.Now()
+	history.observe(start, read, write)
+	// No snapshot is taken within the interval.
+	history.observe(start.Add(time.Second), read, write)
+	history.observe(start.Add(hotRegionSnapshotInterval), read, nil)
+
+	records := history.get(start, time.Time{}, "")
+	c.Assert(records, HasLen, 5)
+	c.Assert(history.get(start, start, ""), HasLen, 3)
+	records = history.get(start, start, HotRegionTypeRead)
+	c.Assert(records, HasLen, 2)
+	for _, record := range records {
+		c.Assert(record.RegionID, Equals, uint64(1))
+		c.Assert(record.IsLeader, Equals, record.StoreID == 1)
+	}
+	records = history.get(start.Add(time.Second), time.Time{}, HotRegionTypeWrite)
+	c.Assert(records, HasLen, 0)
+
+	// The expired snapshots are dropped.
+	history.observe(start.Add(hotRegionSnapshotInterval+hotRegionHistoryTTL+time.Second), nil, write)
+	records = history.get(time.Time{}, time.Time{}, "")
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].Type, Equals, HotRegionTypeWrite)
+
+	// Only the hottest peers are kept in a snapshot, the earlier snapshots are
+	// kept until they expire.
+	many := &statistics.StoreHotPeersInfos{AsPeer: statistics.StoreHotPeersStat{1: {}}}
+	for i := 0; i < maxHotPeersPerSnapshot*2; i++ {
+		stat := statistics.HotPeerStat{StoreID: 1, RegionID: uint64(i), ByteRate: float64(i)}
+		many.AsPeer[1].Stats = append(many.AsPeer[1].Stats, stat)
+	}
+	now := start.Add(3*hotRegionSnapshotInterval + hotRegionHistoryTTL)
+	history.observe(now, many, nil)
+	records = history.get(now, time.Time{}, "")
+	c.Assert(records, HasLen, maxHotPeersPerSnapshot)
+	c.Assert(records[0].RegionID, Equals, uint64(maxHotPeersPerSnapshot*2-1))
+	c.Assert(records[maxHotPeersPerSnapshot-1].RegionID, Equals, uint64(maxHotPeersPerSnapshot))
+	c.Assert(history.get(time.Time{}, time.Time{}, ""), HasLen, maxHotPeersPerSnapshot+1)
+}
+
+func (s *testClusterInfoSuite) TestRegionTopology(c *C) {
+	storage := core.NewStorage(kv.NewMemoryKV())
+	regions := newTestRegions(regionTopologyChunkSize+1, 3)
+	getRegions := func() []*core.RegionInfo { return regions }
+	interval, retention := time.Minute, time.Hour
+
+	var snapshots regionTopologySnapshots
+	start := time.Unix(time.Now().Unix(), 0)
+	snapshot, err := snapshots.get(storage, start, 0)
+	c.Assert(err, IsNil)
+	c.Assert(snapshot, IsNil)
+	// No snapshot is taken if it is disabled.
+	c.Assert(snapshots.save(storage, start, 0, retention, getRegions), IsNil)
+	c.Assert(snapshots.metas, HasLen, 0)
+
+	c.Assert(snapshots.save(storage, start, interval, retention, getRegions), IsNil)
+	// No snapshot is taken within the interval.
+	c.Assert(snapshots.save(storage, start.Add(time.Second), interval, retention, getRegions), IsNil)
+	c.Assert(snapshots.metas, HasLen, 1)
+	c.Assert(snapshots.metas[0].Chunks, Equals, 2)
+
+	// The region is moved to another store.
+	regions = []*core.RegionInfo{regions[5].Clone(core.WithAddPeer(&metapb.Peer{Id: 100, StoreId: 100, Role: metapb.PeerRole_Learner}))}
+	c.Assert(snapshots.save(storage, start.Add(interval), interval, retention, getRegions), IsNil)
+
+	// The snapshots survive reloading.
+	snapshots = regionTopologySnapshots{}
+	c.Assert(snapshots.load(storage), IsNil)
+	c.Assert(snapshots.metas, HasLen, 2)
+
+	snapshot, err = snapshots.get(storage, start.Add(time.Second), 0)
+	c.Assert(err, IsNil)
+	c.Assert(snapshot.Time.Equal(start), IsTrue)
+	c.Assert(snapshot.Regions, HasLen, regionTopologyChunkSize+1)
+	snapshot, err = snapshots.get(storage, start, 5)
+	c.Assert(err, IsNil)
+	c.Assert(snapshot.Regions, DeepEquals, []RegionTopology{{ID: 5, Version: 2, ConfVer: 2, Leader: 5, Voters: []uint64{5, 6, 7}}})
+	snapshot, err = snapshots.get(storage, start.Add(interval), 5)
+	c.Assert(err, IsNil)
+	c.Assert(snapshot.Regions, HasLen, 1)
+	c.Assert(snapshot.Regions[0].Learners, DeepEquals, []uint64{100})
+
+	// The expired snapshots are removed.
+	c.Assert(snapshots.save(storage, start.Add(interval+retention+time.Second), 0, retention, getRegions), IsNil)
+	c.Assert(snapshots.metas, HasLen, 0)
+	snapshot, err = snapshots.get(storage, start.Add(interval), 0)
+	c.Assert(err, IsNil)
+	c.Assert(snapshot, IsNil)
+	c.Assert(snapshots.load(storage), IsNil)
+	c.Assert(snapshots.metas, HasLen, 0)
+}
+
+func (s *testClusterInfoSuite) TestRegionTopologyLimits(c *C) {
+	defer func(chunks, max int) {
+		maxRegionTopologyChunks, maxRegionTopologySnapshots = chunks, max
+	}(maxRegionTopologyChunks, maxRegionTopologySnapshots)
+	maxRegionTopologyChunks, maxRegionTopologySnapshots = 1, 2
+	storage := core.NewStorage(kv.NewMemoryKV())
+	regions := newTestRegions(regionTopologyChunkSize+1, 3)
+	getRegions := func() []*core.RegionInfo { return regions }
+	interval, retention := time.Minute, time.Hour
+
+	// The regions beyond the max chunks are not saved.
+	var snapshots regionTopologySnapshots
+	start := time.Unix(time.Now().Unix(), 0)
+	c.Assert(snapshots.save(storage, start, interval, retention, getRegions), IsNil)
+	snapshot, err := snapshots.get(storage, start, 0)
+	c.Assert(err, IsNil)
+	c.Assert(snapshot.Truncated, IsTrue)
+	c.Assert(snapshot.Regions, HasLen, regionTopologyChunkSize)
+
+	// The oldest snapshots are removed beyond the max number.
+	for i := 1; i <= 2; i++ {
+		c.Assert(snapshots.save(storage, start.Add(time.Duration(i)*interval), interval, retention, getRegions), IsNil)
+	}
+	c.Assert(snapshots.metas, HasLen, 2)
+	c.Assert(snapshots.metas[0].Time.Equal(start.Add(interval)), IsTrue)
+	c.Assert(storage.LoadRegionTopologyChunks(start.Unix(), func(k, v string) { c.Fatal("the chunk should be removed") }), IsNil)
+
+	// The snapshot which is not completely saved is removed when it is loaded.
+	pending := regionTopologyMeta{Time: start.Add(3 * interval), Chunks: 1, Regions: 1, Pending: true}
+	c.Assert(storage.SaveRegionTopologyMeta(pending.Time.Unix(), pending), IsNil)
+	c.Assert(storage.SaveRegionTopologyChunk(pending.Time.Unix(), 0, []RegionTopology{{ID: 1}}), IsNil)
+	snapshots = regionTopologySnapshots{}
+	c.Assert(snapshots.load(storage), IsNil)
+	c.Assert(snapshots.metas, HasLen, 2)
+	c.Assert(storage.LoadRegionTopologyChunks(pending.Time.Unix(), func(k, v string) { c.Fatal("the chunk should be removed") }), IsNil)
+	var metas int
+	c.Assert(storage.LoadRegionTopologyMetas(func(k, v string) { metas++ }), IsNil)
+	c.Assert(metas, Equals, 2)
+}
+
+func (s *testClusterInfoSuite) TestDiffRegionEpochs(c *C) {
+	// Regions 0..4 have epoch {2, 2} in PD, and region i is on store i.
+	regions := newTestRegions(5, 1)
+	metas := make(map[uint64]*storeRegionMeta)
+	for _, epoch := range []*metapb.RegionEpoch{{ConfVer: 2, Version: 2}, {ConfVer: 2, Version: 1}, {ConfVer: 3, Version: 1}} {
+		meta := &storeRegionMeta{ID: uint64(len(metas))}
+		meta.RegionState.Epoch = *epoch
+		metas[meta.ID] = meta
+	}
+	mismatches := diffRegionEpochs(0, regions, metas)
+	c.Assert(mismatches, HasLen, 4)
+	for i, kind := range []string{RegionMismatchStale, RegionMismatchDivergent, RegionMismatchMissing, RegionMismatchMissing} {
+		c.Assert(mismatches[i].RegionID, Equals, uint64(i+1))
+		c.Assert(mismatches[i].Kind, Equals, kind)
+	}
+	c.Assert(mismatches[3].StoreEpoch, IsNil)
+	c.Assert(diffRegionEpochs(0, regions[:1], metas), HasLen, 0)
+}
+
 func (s *testClusterInfoSuite) TestConcurrentRegionHeartbeat(c *C) {
 	_, opt, err := newTestScheduleConfig()
 	c.Assert(err, IsNil)
@@ -591,6 +1282,35 @@ func (s *testClusterInfoSuite) TestUpdateStorePendingPeerCount(c *C) {
 	checkPendingPeerCount([]int{0, 0, 0, 1}, tc.RaftCluster, c)
 }
 
+func (s *testClusterInfoSuite) TestUpdateStoreDownPeerCount(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	tc := newTestCluster(opt)
+	stores := newTestStores(4, "2.0.0")
+	for _, s := range stores {
+		c.Assert(tc.putStoreLocked(s), IsNil)
+	}
+	peers := []*metapb.Peer{
+		{Id: 2, StoreId: 1},
+		{Id: 3, StoreId: 2},
+		{Id: 4, StoreId: 3},
+		{Id: 5, StoreId: 4},
+	}
+	origin := core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers[:3]}, peers[0], core.WithDownPeers([]*pdpb.PeerStats{
+		{Peer: peers[1], DownSeconds: 60},
+		{Peer: peers[2], DownSeconds: 60},
+	}))
+	c.Assert(tc.processRegionHeartbeat(origin), IsNil)
+	checkDownPeerCount([]int{0, 1, 1, 0}, tc.RaftCluster, c)
+	newRegion := core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers[1:]}, peers[1], core.WithDownPeers([]*pdpb.PeerStats{
+		{Peer: peers[3], DownSeconds: 60},
+	}))
+	c.Assert(tc.processRegionHeartbeat(newRegion), IsNil)
+	checkDownPeerCount([]int{0, 0, 0, 1}, tc.RaftCluster, c)
+	c.Assert(tc.processRegionHeartbeat(newRegion.Clone(core.WithDownPeers(nil))), IsNil)
+	checkDownPeerCount([]int{0, 0, 0, 0}, tc.RaftCluster, c)
+}
+
 var _ = Suite(&testStoresInfoSuite{})
 
 type testStoresInfoSuite struct{}
@@ -914,6 +1634,13 @@ func checkPendingPeerCount(expect []int, cluster *RaftCluster, c *C) {
 	}
 }
 
+func checkDownPeerCount(expect []int, cluster *RaftCluster, c *C) {
+	for i, e := range expect {
+		s := cluster.core.Stores.GetStore(uint64(i + 1))
+		c.Assert(s.GetDownPeerCount(), Equals, e)
+	}
+}
+
 func checkStaleRegion(origin *metapb.Region, region *metapb.Region) error {
 	o := origin.GetRegionEpoch()
 	e := region.GetRegionEpoch()
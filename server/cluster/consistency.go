@@ -0,0 +1,162 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"sort"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+// ConsistencyPeer is a peer of a region on a store which does not exist or is
+// tombstone.
+type ConsistencyPeer struct {
+	RegionID uint64 `json:"region_id"`
+	PeerID   uint64 `json:"peer_id"`
+	StoreID  uint64 `json:"store_id"`
+}
+
+// ConsistencyReport is the result of cross-checking the stores persisted in
+// the storage, the stores in memory, the peers of the regions and the PD
+// members.
+type ConsistencyReport struct {
+	// StaleStores are not tombstone, but have not sent heartbeats for longer
+	// than max-store-down-time.
+	StaleStores []uint64 `json:"stale_stores"`
+	// UnpersistedStores are in memory but missing from the storage, they are
+	// persisted again by the cleanup.
+	UnpersistedStores []uint64 `json:"unpersisted_stores"`
+	// UnknownStorePeers are on the stores which do not exist or are tombstone.
+	UnknownStorePeers []*ConsistencyPeer `json:"unknown_store_peers"`
+	// OrphanedStoreLimits are the store limits of the stores which do not
+	// exist or are tombstone, they are removed by the cleanup.
+	OrphanedStoreLimits []uint64 `json:"orphaned_store_limits"`
+	// OrphanedMembers are the PD members which are removed from the cluster
+	// but still have the metadata saved, it is removed by the cleanup.
+	OrphanedMembers []uint64 `json:"orphaned_members"`
+}
+
+func newConsistencyReport() *ConsistencyReport {
+	return &ConsistencyReport{
+		StaleStores:         []uint64{},
+		UnpersistedStores:   []uint64{},
+		UnknownStorePeers:   []*ConsistencyPeer{},
+		OrphanedStoreLimits: []uint64{},
+		OrphanedMembers:     []uint64{},
+	}
+}
+
+// CheckConsistency cross-checks the metadata of the cluster.
+func (c *RaftCluster) CheckConsistency() (*ConsistencyReport, error) {
+	persisted := make(map[uint64]struct{})
+	if err := c.storage.LoadStores(func(store *core.StoreInfo) {
+		persisted[store.GetID()] = struct{}{}
+	}); err != nil {
+		return nil, err
+	}
+	report := newConsistencyReport()
+	maxDownTime := c.opt.GetMaxStoreDownTime()
+	for _, store := range c.GetStores() {
+		if _, ok := persisted[store.GetID()]; !ok {
+			report.UnpersistedStores = append(report.UnpersistedStores, store.GetID())
+		}
+		if !store.IsTombstone() && store.DownTime() > maxDownTime {
+			report.StaleStores = append(report.StaleStores, store.GetID())
+		}
+	}
+	for _, region := range c.GetRegions() {
+		for _, peer := range region.GetPeers() {
+			if store := c.GetStore(peer.GetStoreId()); store == nil || store.IsTombstone() {
+				report.UnknownStorePeers = append(report.UnknownStorePeers, &ConsistencyPeer{
+					RegionID: region.GetID(),
+					PeerID:   peer.GetId(),
+					StoreID:  peer.GetStoreId(),
+				})
+			}
+		}
+	}
+	for storeID := range c.opt.GetScheduleConfig().StoreLimit {
+		if store := c.GetStore(storeID); store == nil || store.IsTombstone() {
+			report.OrphanedStoreLimits = append(report.OrphanedStoreLimits, storeID)
+		}
+	}
+	sort.Slice(report.StaleStores, func(i, j int) bool { return report.StaleStores[i] < report.StaleStores[j] })
+	sort.Slice(report.UnpersistedStores, func(i, j int) bool { return report.UnpersistedStores[i] < report.UnpersistedStores[j] })
+	sort.Slice(report.UnknownStorePeers, func(i, j int) bool {
+		return report.UnknownStorePeers[i].RegionID < report.UnknownStorePeers[j].RegionID ||
+			(report.UnknownStorePeers[i].RegionID == report.UnknownStorePeers[j].RegionID && report.UnknownStorePeers[i].PeerID < report.UnknownStorePeers[j].PeerID)
+	})
+	sort.Slice(report.OrphanedStoreLimits, func(i, j int) bool { return report.OrphanedStoreLimits[i] < report.OrphanedStoreLimits[j] })
+	return report, nil
+}
+
+// CleanupInconsistency persists the unpersisted stores again and removes the
+// orphaned store limits found by CheckConsistency. The stale stores and the
+// peers on unknown stores are left to the operators of the cluster, they are
+// handled by `store delete` and `unsafe plan-remove-failed-stores`. It returns the
+// report of the inconsistency which is cleaned up. The PD members are checked
+// and cleaned up by the server.
+func (c *RaftCluster) CleanupInconsistency() (*ConsistencyReport, error) {
+	report, err := c.CheckConsistency()
+	if err != nil {
+		return nil, err
+	}
+	cleaned := newConsistencyReport()
+	for _, storeID := range report.UnpersistedStores {
+		store := c.GetStore(storeID)
+		if store == nil {
+			continue
+		}
+		if err := c.storage.SaveStore(store.GetMeta()); err != nil {
+			return cleaned, err
+		}
+		cleaned.UnpersistedStores = append(cleaned.UnpersistedStores, storeID)
+		log.Info("unpersisted store is persisted", zap.Uint64("store-id", storeID))
+	}
+	// The config is read again as it may be changed since the check, only the
+	// limits which are still orphaned are removed.
+	removed := make(map[uint64]config.StoreLimitConfig)
+	storeLimit := c.opt.GetScheduleConfig().StoreLimit
+	for _, storeID := range report.OrphanedStoreLimits {
+		limit, ok := storeLimit[storeID]
+		if store := c.GetStore(storeID); !ok || (store != nil && !store.IsTombstone()) {
+			continue
+		}
+		removed[storeID] = limit
+		c.RemoveStoreLimit(storeID)
+		cleaned.OrphanedStoreLimits = append(cleaned.OrphanedStoreLimits, storeID)
+	}
+	if len(removed) > 0 {
+		if err := c.opt.Persist(c.storage); err != nil {
+			// Only puts back the removed limits, the config is read again so
+			// the changes made by others in the meantime are kept.
+			cfg := c.opt.GetScheduleConfig().Clone()
+			if cfg.StoreLimit == nil {
+				cfg.StoreLimit = make(map[uint64]config.StoreLimitConfig, len(removed))
+			}
+			for storeID, limit := range removed {
+				cfg.StoreLimit[storeID] = limit
+			}
+			c.opt.SetScheduleConfig(cfg)
+			log.Error("persist store limit meet error", errs.ZapError(err))
+			return cleaned, err
+		}
+		log.Info("orphaned store limits are removed", zap.Uint64s("store-ids", cleaned.OrphanedStoreLimits))
+	}
+	return cleaned, nil
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/cgroup"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/server/config"
@@ -86,13 +87,19 @@ func newCoordinator(ctx context.Context, cluster *RaftCluster, hbStreams *hbstre
 	}
 }
 
+// patrolRegionInterval returns the patrol interval stretched by the CPU quota
+// of the process.
+func (c *coordinator) patrolRegionInterval() time.Duration {
+	return cgroup.GetLimits().ThrottleInterval(c.cluster.GetOpts().GetPatrolRegionInterval())
+}
+
 // patrolRegions is used to scan regions.
 // The checkers will check these regions to decide if they need to do some operations.
 func (c *coordinator) patrolRegions() {
 	defer logutil.LogPanic()
 
 	defer c.wg.Done()
-	timer := time.NewTimer(c.cluster.GetOpts().GetPatrolRegionInterval())
+	timer := time.NewTimer(c.patrolRegionInterval())
 	defer timer.Stop()
 
 	log.Info("coordinator starts patrol regions")
@@ -101,7 +108,7 @@ func (c *coordinator) patrolRegions() {
 	for {
 		select {
 		case <-timer.C:
-			timer.Reset(c.cluster.GetOpts().GetPatrolRegionInterval())
+			timer.Reset(c.patrolRegionInterval())
 		case <-c.ctx.Done():
 			log.Info("patrol regions has been stopped")
 			return
@@ -489,6 +489,43 @@ func (s *testCoordinatorSuite) TestPeerState(c *C) {
 	waitNoResponse(c, stream)
 }
 
+func (s *testCoordinatorSuite) TestFreezeRange(c *C) {
+	tc, co, cleanup := prepare(nil, nil, nil, c)
+	defer cleanup()
+	tc.RaftCluster.coordinator = co
+
+	c.Assert(tc.addRegionStore(1, 1), IsNil)
+	c.Assert(tc.addRegionStore(2, 1), IsNil)
+	c.Assert(tc.addLeaderRegion(1, 1, 2), IsNil)
+	c.Assert(tc.addLeaderRegion(2, 1, 2), IsNil)
+	c.Assert(tc.addLeaderRegion(3, 1, 2), IsNil)
+	transfer := operator.TransferLeader{FromStore: 1, ToStore: 2}
+	op1 := newTestOperator(1, tc.GetRegion(1).GetRegionEpoch(), operator.OpLeader, transfer)
+	op2 := newTestOperator(2, tc.GetRegion(2).GetRegionEpoch(), operator.OpLeader|operator.OpAdmin, transfer)
+	c.Assert(co.opController.AddWaitingOperator(op1), Equals, 1)
+	c.Assert(co.opController.AddWaitingOperator(op2), Equals, 1)
+
+	// The running operators of the frozen regions are canceled, except the
+	// ones created by the admin.
+	r, err := tc.FreezeRange(tc.GetRegion(1).GetStartKey(), tc.GetRegion(3).GetStartKey(), time.Minute)
+	c.Assert(err, IsNil)
+	c.Assert(co.opController.GetOperator(1), IsNil)
+	c.Assert(co.opController.GetOperator(2), NotNil)
+	c.Assert(tc.GetFrozenRanges(), DeepEquals, []*FrozenRange{r})
+	c.Assert(tc.GetRegionLabel(tc.GetRegion(1), ScheduleLabelKey), Equals, ScheduleDeny)
+	c.Assert(tc.IsRegionScheduleDenied(tc.GetRegion(1)), IsTrue)
+	c.Assert(tc.IsRegionScheduleDenied(tc.GetRegion(2)), IsTrue)
+	c.Assert(tc.IsRegionScheduleDenied(tc.GetRegion(3)), IsFalse)
+
+	ok, err := tc.UnfreezeRange(r.StartKey, r.EndKey)
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	ok, err = tc.UnfreezeRange(r.StartKey, r.EndKey)
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsFalse)
+	c.Assert(tc.IsRegionScheduleDenied(tc.GetRegion(1)), IsFalse)
+}
+
 func (s *testCoordinatorSuite) TestShouldRun(c *C) {
 	tc, co, cleanup := prepare(nil, nil, nil, c)
 	defer cleanup()
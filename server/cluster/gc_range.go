@@ -0,0 +1,191 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+// GCRange is a key range dropped by the database layer, e.g. by TRUNCATE or
+// DROP TABLE. The regions in the range will be emptied by GC, so they are
+// merged as soon as possible and are not worth balancing.
+type GCRange struct {
+	StartKey   []byte    `json:"-"`
+	EndKey     []byte    `json:"-"`
+	HexStart   string    `json:"start_key"`
+	HexEnd     string    `json:"end_key"`
+	ExpireTime time.Time `json:"expire_time"`
+}
+
+func (r *GCRange) contains(region *core.RegionInfo) bool {
+	if bytes.Compare(region.GetStartKey(), r.StartKey) < 0 {
+		return false
+	}
+	end := region.GetEndKey()
+	return len(r.EndKey) == 0 || (len(end) > 0 && bytes.Compare(end, r.EndKey) <= 0)
+}
+
+// storageKey is the key of the range in storage, the hex encoded keys have no
+// '-', so the key is unique for a range.
+func (r *GCRange) storageKey() string {
+	return r.HexStart + "-" + r.HexEnd
+}
+
+// gcRanges holds the dropped key ranges until they expire. The ranges are
+// persisted, so the merging is still fast-tracked after the leader changes.
+type gcRanges struct {
+	sync.RWMutex
+	ranges []*GCRange
+}
+
+func (g *gcRanges) load(storage *core.Storage) error {
+	var (
+		ranges []*GCRange
+		err    error
+	)
+	if e := storage.LoadGCRanges(func(k, v string) {
+		r := &GCRange{}
+		if e := json.Unmarshal([]byte(v), r); e != nil {
+			err = errs.ErrJSONUnmarshal.Wrap(e).GenWithStackByCause()
+			return
+		}
+		var e error
+		if r.StartKey, e = hex.DecodeString(r.HexStart); e != nil {
+			err = errs.ErrHexDecodingString.Wrap(e).GenWithStackByCause()
+			return
+		}
+		if r.EndKey, e = hex.DecodeString(r.HexEnd); e != nil {
+			err = errs.ErrHexDecodingString.Wrap(e).GenWithStackByCause()
+			return
+		}
+		ranges = append(ranges, r)
+	}); e != nil {
+		return e
+	}
+	if err != nil {
+		return err
+	}
+
+	g.Lock()
+	defer g.Unlock()
+	g.ranges = ranges
+	g.gcLocked(storage)
+	return nil
+}
+
+func (g *gcRanges) add(storage *core.Storage, startKey, endKey []byte, ttl time.Duration) (*GCRange, error) {
+	g.Lock()
+	defer g.Unlock()
+	g.gcLocked(storage)
+	r := &GCRange{
+		StartKey:   startKey,
+		EndKey:     endKey,
+		HexStart:   hex.EncodeToString(startKey),
+		HexEnd:     hex.EncodeToString(endKey),
+		ExpireTime: time.Now().Add(ttl),
+	}
+	if err := storage.SaveGCRange(r.storageKey(), r); err != nil {
+		return nil, err
+	}
+	for i, old := range g.ranges {
+		if bytes.Equal(old.StartKey, startKey) && bytes.Equal(old.EndKey, endKey) {
+			g.ranges[i] = r
+			return r, nil
+		}
+	}
+	g.ranges = append(g.ranges, r)
+	return r, nil
+}
+
+// gcLocked removes the expired ranges, the range which fails to be removed
+// from storage is removed again next time.
+func (g *gcRanges) gcLocked(storage *core.Storage) {
+	now := time.Now()
+	ranges := g.ranges[:0]
+	for _, r := range g.ranges {
+		if r.ExpireTime.After(now) {
+			ranges = append(ranges, r)
+			continue
+		}
+		if err := storage.DeleteGCRange(r.storageKey()); err != nil {
+			log.Warn("failed to delete the expired gc range", zap.String("start-key", r.HexStart), zap.String("end-key", r.HexEnd), errs.ZapError(err))
+			ranges = append(ranges, r)
+		}
+	}
+	g.ranges = ranges
+}
+
+// gc removes the expired ranges from both memory and storage, it is called
+// periodically so the expired ranges do not stay in storage until the next
+// range is added.
+func (g *gcRanges) gc(storage *core.Storage) {
+	g.Lock()
+	defer g.Unlock()
+	g.gcLocked(storage)
+}
+
+func (g *gcRanges) list() []*GCRange {
+	g.RLock()
+	defer g.RUnlock()
+	now := time.Now()
+	ranges := make([]*GCRange, 0, len(g.ranges))
+	for _, r := range g.ranges {
+		if r.ExpireTime.After(now) {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+func (g *gcRanges) contains(region *core.RegionInfo) bool {
+	g.RLock()
+	defer g.RUnlock()
+	now := time.Now()
+	for _, r := range g.ranges {
+		if r.ExpireTime.After(now) && r.contains(region) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddGCRange records a key range dropped by the database layer for the ttl,
+// and makes the checkers check the regions in the range soon.
+func (c *RaftCluster) AddGCRange(startKey, endKey []byte, ttl time.Duration) (*GCRange, error) {
+	r, err := c.gcRanges.add(c.storage, startKey, endKey, ttl)
+	if err != nil {
+		return nil, err
+	}
+	c.AddSuspectKeyRange(startKey, endKey)
+	return r, nil
+}
+
+// GetGCRanges returns the dropped key ranges which are not expired.
+func (c *RaftCluster) GetGCRanges() []*GCRange {
+	return c.gcRanges.list()
+}
+
+// IsRegionInGCRange returns true if the region is entirely in a dropped key range.
+func (c *RaftCluster) IsRegionInGCRange(region *core.RegionInfo) bool {
+	return c.gcRanges.contains(region)
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/statistics"
+)
+
+const (
+	// hotRegionSnapshotInterval is the interval to take a snapshot of the hot
+	// regions.
+	hotRegionSnapshotInterval = time.Minute
+	// hotRegionHistoryTTL is how long the snapshots are kept.
+	hotRegionHistoryTTL = 24 * time.Hour
+	// maxHotPeersPerSnapshot is the max number of the hot peers of each type
+	// kept in a snapshot, the hottest ones are kept. The history is bounded by
+	// it instead of dropping the earliest snapshots, so it always covers
+	// hotRegionHistoryTTL.
+	maxHotPeersPerSnapshot = 100
+)
+
+// The types of hot regions.
+const (
+	HotRegionTypeRead  = "read"
+	HotRegionTypeWrite = "write"
+)
+
+// HotRegionRecord is a hot peer in a snapshot of the hot regions.
+type HotRegionRecord struct {
+	Time      time.Time `json:"time"`
+	Type      string    `json:"type"`
+	RegionID  uint64    `json:"region_id"`
+	StoreID   uint64    `json:"store_id"`
+	IsLeader  bool      `json:"is_leader"`
+	HotDegree int       `json:"hot_degree"`
+	ByteRate  float64   `json:"flow_bytes"`
+	KeyRate   float64   `json:"flow_keys"`
+}
+
+// hotRegionHistory keeps the snapshots of the hot regions in memory only. It is
+// volatile: the history is lost when the PD leader is changed or restarted,
+// and the new leader starts with an empty one.
+type hotRegionHistory struct {
+	sync.RWMutex
+	// records are sorted by time.
+	records      []HotRegionRecord
+	lastSnapshot time.Time
+}
+
+func (h *hotRegionHistory) reset() {
+	h.Lock()
+	defer h.Unlock()
+	h.records, h.lastSnapshot = nil, time.Time{}
+}
+
+// observe takes a snapshot of the hot regions if it is time to, and drops the
+// expired records.
+func (h *hotRegionHistory) observe(now time.Time, read, write *statistics.StoreHotPeersInfos) {
+	h.Lock()
+	defer h.Unlock()
+	if now.Sub(h.lastSnapshot) < hotRegionSnapshotInterval {
+		return
+	}
+	h.lastSnapshot = now
+	h.records = appendHotRegionRecords(h.records, now, HotRegionTypeRead, read)
+	h.records = appendHotRegionRecords(h.records, now, HotRegionTypeWrite, write)
+
+	expired := now.Add(-hotRegionHistoryTTL)
+	i := sort.Search(len(h.records), func(i int) bool { return !h.records[i].Time.Before(expired) })
+	if i > 0 {
+		h.records = append([]HotRegionRecord(nil), h.records[i:]...)
+	}
+}
+
+func appendHotRegionRecords(records []HotRegionRecord, now time.Time, typ string, infos *statistics.StoreHotPeersInfos) []HotRegionRecord {
+	if infos == nil {
+		return records
+	}
+	type peer struct{ regionID, storeID uint64 }
+	leaders := make(map[peer]struct{})
+	for storeID, stats := range infos.AsLeader {
+		for _, stat := range stats.Stats {
+			leaders[peer{stat.RegionID, storeID}] = struct{}{}
+		}
+	}
+	var snapshot []HotRegionRecord
+	for storeID, stats := range infos.AsPeer {
+		for _, stat := range stats.Stats {
+			_, isLeader := leaders[peer{stat.RegionID, storeID}]
+			snapshot = append(snapshot, HotRegionRecord{
+				Time:      now,
+				Type:      typ,
+				RegionID:  stat.RegionID,
+				StoreID:   storeID,
+				IsLeader:  isLeader,
+				HotDegree: stat.HotDegree,
+				ByteRate:  stat.ByteRate,
+				KeyRate:   stat.KeyRate,
+			})
+		}
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		if snapshot[i].ByteRate != snapshot[j].ByteRate {
+			return snapshot[i].ByteRate > snapshot[j].ByteRate
+		}
+		return snapshot[i].KeyRate > snapshot[j].KeyRate
+	})
+	if len(snapshot) > maxHotPeersPerSnapshot {
+		snapshot = snapshot[:maxHotPeersPerSnapshot]
+	}
+	return append(records, snapshot...)
+}
+
+func (h *hotRegionHistory) get(start, end time.Time, typ string) []HotRegionRecord {
+	h.RLock()
+	defer h.RUnlock()
+	i := sort.Search(len(h.records), func(i int) bool { return !h.records[i].Time.Before(start) })
+	records := make([]HotRegionRecord, 0)
+	for _, record := range h.records[i:] {
+		if !end.IsZero() && record.Time.After(end) {
+			break
+		}
+		if typ == "" || record.Type == typ {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// observeHotRegions takes the snapshots of the hot regions if the feature is
+// enabled, the history is dropped once the feature is disabled.
+func (c *RaftCluster) observeHotRegions() {
+	if !c.opt.IsFeatureEnabled(config.FeatureHotRegionHistory) {
+		c.hotRegionHistory.reset()
+		return
+	}
+	c.hotRegionHistory.observe(time.Now(), c.GetHotReadRegions(), c.GetHotWriteRegions())
+}
+
+// GetHotRegionHistory returns the hot peers recorded in the time range, the
+// snapshots are taken every minute in the last 24 hours since this PD becomes
+// the leader. The type can be read,
+// write or empty for both. A zero end time means now.
+func (c *RaftCluster) GetHotRegionHistory(start, end time.Time, typ string) []HotRegionRecord {
+	return c.hotRegionHistory.get(start, end, typ)
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"sort"
+
+	"github.com/tikv/pd/server/statistics"
+)
+
+// The keys to sort the hot store summaries, the summaries are sorted by the
+// rate in descending order.
+const (
+	HotStoreSortByWriteBytes = "write-bytes"
+	HotStoreSortByWriteKeys  = "write-keys"
+	HotStoreSortByReadBytes  = "read-bytes"
+	HotStoreSortByReadKeys   = "read-keys"
+)
+
+// HotStoreSummary is the read and write rates of a store. The total rates are
+// reported by the store heartbeats, and the rates of the hot peers are split
+// by whether the peers are leaders or followers.
+type HotStoreSummary struct {
+	StoreID uint64 `json:"store_id"`
+	Address string `json:"address"`
+
+	BytesReadRate  float64 `json:"bytes-read-rate"`
+	KeysReadRate   float64 `json:"keys-read-rate"`
+	BytesWriteRate float64 `json:"bytes-write-rate"`
+	KeysWriteRate  float64 `json:"keys-write-rate"`
+
+	HotReadLeader    HotPeersRate `json:"hot-read-leader"`
+	HotReadFollower  HotPeersRate `json:"hot-read-follower"`
+	HotWriteLeader   HotPeersRate `json:"hot-write-leader"`
+	HotWriteFollower HotPeersRate `json:"hot-write-follower"`
+}
+
+// HotPeersRate is the total rates of the hot peers of a store.
+type HotPeersRate struct {
+	BytesRate float64 `json:"bytes-rate"`
+	KeysRate  float64 `json:"keys-rate"`
+	Count     int     `json:"count"`
+}
+
+// IsValidHotStoreSortKey returns whether the key can be used to sort the hot
+// store summaries.
+func IsValidHotStoreSortKey(sortBy string) bool {
+	switch sortBy {
+	case HotStoreSortByWriteBytes, HotStoreSortByWriteKeys, HotStoreSortByReadBytes, HotStoreSortByReadKeys:
+		return true
+	}
+	return false
+}
+
+// GetHotStoreSummaries returns the read and write rates of the stores which
+// are not tombstone, sorted by the key in descending order. The stores with
+// the same rate are sorted by the IDs.
+func (c *RaftCluster) GetHotStoreSummaries(sortBy string) []*HotStoreSummary {
+	bytesRead, keysRead := c.GetStoresBytesReadStat(), c.GetStoresKeysReadStat()
+	bytesWrite, keysWrite := c.GetStoresBytesWriteStat(), c.GetStoresKeysWriteStat()
+	hotRead, hotWrite := c.RegionReadStats(), c.RegionWriteStats()
+
+	var summaries []*HotStoreSummary
+	for _, store := range c.GetStores() {
+		if store.IsTombstone() {
+			continue
+		}
+		id := store.GetID()
+		s := &HotStoreSummary{
+			StoreID:        id,
+			Address:        store.GetAddress(),
+			BytesReadRate:  bytesRead[id],
+			KeysReadRate:   keysRead[id],
+			BytesWriteRate: bytesWrite[id],
+			KeysWriteRate:  keysWrite[id],
+		}
+		s.HotReadLeader, s.HotReadFollower = sumHotPeers(hotRead[id])
+		s.HotWriteLeader, s.HotWriteFollower = sumHotPeers(hotWrite[id])
+		summaries = append(summaries, s)
+	}
+
+	rate := func(s *HotStoreSummary) float64 {
+		switch sortBy {
+		case HotStoreSortByWriteKeys:
+			return s.KeysWriteRate
+		case HotStoreSortByReadBytes:
+			return s.BytesReadRate
+		case HotStoreSortByReadKeys:
+			return s.KeysReadRate
+		default:
+			return s.BytesWriteRate
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		ri, rj := rate(summaries[i]), rate(summaries[j])
+		return ri > rj || (ri == rj && summaries[i].StoreID < summaries[j].StoreID)
+	})
+	return summaries
+}
+
+// sumHotPeers returns the total rates of the hot peers which are leaders and
+// followers.
+func sumHotPeers(peers []*statistics.HotPeerStat) (leader, follower HotPeersRate) {
+	for _, peer := range peers {
+		rate := &follower
+		if peer.IsLeader() {
+			rate = &leader
+		}
+		rate.BytesRate += peer.GetByteRate()
+		rate.KeysRate += peer.GetKeyRate()
+		rate.Count++
+	}
+	return
+}
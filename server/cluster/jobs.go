@@ -0,0 +1,285 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/job"
+	"go.uber.org/zap"
+)
+
+// The types of the jobs run by the cluster.
+const (
+	ScatterRangeJob = "scatter-range"
+	SplitRegionsJob = "split-regions"
+	// UnsafeRecoveryJob monitors an unsafe recovery of the failed stores.
+	UnsafeRecoveryJob = "unsafe-recovery"
+	// DrainStoreJob sets a store offline and waits until all its regions
+	// are moved out.
+	DrainStoreJob = "drain-store"
+)
+
+var drainStoreCheckInterval = time.Second
+
+const (
+	scatterRangeBatchSize = 128
+	splitRegionsBatchSize = 16
+	defaultJobRetryLimit  = 5
+)
+
+// ScatterRangeParams are the params of a scatter-range job.
+type ScatterRangeParams struct {
+	StartKey   string `json:"start_key"`
+	EndKey     string `json:"end_key"`
+	Group      string `json:"group"`
+	RetryLimit int    `json:"retry_limit"`
+}
+
+type scatterRangeCheckpoint struct {
+	NextKey   string `json:"next_key"`
+	Total     int    `json:"total"`
+	Scattered int    `json:"scattered"`
+	Failed    int    `json:"failed"`
+}
+
+// SplitRegionsParams are the params of a split-regions job.
+type SplitRegionsParams struct {
+	SplitKeys  []string `json:"split_keys"`
+	RetryLimit int      `json:"retry_limit"`
+}
+
+type splitRegionsCheckpoint struct {
+	Next   int `json:"next"`
+	Failed int `json:"failed"`
+}
+
+// DrainStoreParams are the params of a drain-store job.
+type DrainStoreParams struct {
+	StoreID uint64 `json:"store_id"`
+}
+
+type drainStoreCheckpoint struct {
+	// RegionCount is the number of the regions on the store when the job
+	// starts, the progress is estimated by it.
+	RegionCount int `json:"region_count"`
+	Left        int `json:"left"`
+}
+
+// GetJobManager returns the job manager.
+func (c *RaftCluster) GetJobManager() *job.Manager {
+	c.RLock()
+	defer c.RUnlock()
+	return c.jobManager
+}
+
+func (c *RaftCluster) registerJobs(m *job.Manager) {
+	m.Register(ScatterRangeJob, c.runScatterRange)
+	m.Register(SplitRegionsJob, c.runSplitRegions)
+	m.Register(UnsafeRecoveryJob, c.runUnsafeRecovery)
+	m.Register(DrainStoreJob, c.runDrainStore)
+}
+
+// runScatterRange scatters the regions in the key range batch by batch.
+func (c *RaftCluster) runScatterRange(ctx context.Context, j *job.Job, r job.Reporter) error {
+	var params ScatterRangeParams
+	if err := json.Unmarshal(j.Params, &params); err != nil {
+		return errs.ErrJSONUnmarshal.Wrap(err).FastGenWithCause()
+	}
+	startKey, err := hex.DecodeString(params.StartKey)
+	if err != nil {
+		return errs.ErrHexDecodingString.Wrap(err).FastGenWithCause()
+	}
+	endKey, err := hex.DecodeString(params.EndKey)
+	if err != nil {
+		return errs.ErrHexDecodingString.Wrap(err).FastGenWithCause()
+	}
+	if params.RetryLimit <= 0 {
+		params.RetryLimit = defaultJobRetryLimit
+	}
+
+	cp := scatterRangeCheckpoint{NextKey: params.StartKey}
+	if len(j.Checkpoint) > 0 {
+		if err := json.Unmarshal(j.Checkpoint, &cp); err != nil {
+			return errs.ErrJSONUnmarshal.Wrap(err).FastGenWithCause()
+		}
+	} else {
+		cp.Total = len(c.ScanRegions(startKey, endKey, -1))
+	}
+	nextKey, err := hex.DecodeString(cp.NextKey)
+	if err != nil {
+		return errs.ErrHexDecodingString.Wrap(err).FastGenWithCause()
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		regions := c.ScanRegions(nextKey, endKey, scatterRangeBatchSize)
+		if len(regions) == 0 {
+			break
+		}
+		regionMap := make(map[uint64]*core.RegionInfo, len(regions))
+		for _, region := range regions {
+			regionMap[region.GetID()] = region
+		}
+		failures := make(map[uint64]error)
+		ops, err := c.GetRegionScatter().ScatterRegions(regionMap, failures, params.Group, params.RetryLimit)
+		if err != nil {
+			return err
+		}
+		for _, op := range ops {
+			if !c.GetOperatorController().AddOperator(op) {
+				failures[op.RegionID()] = errors.Errorf("region %v failed to add operator", op.RegionID())
+			}
+		}
+		if len(failures) > 0 {
+			log.Warn("failed to scatter some regions", zap.Uint64("job-id", j.ID), zap.Int("count", len(failures)))
+		}
+		cp.Scattered += len(regions) - len(failures)
+		cp.Failed += len(failures)
+
+		last := regions[len(regions)-1].GetEndKey()
+		cp.NextKey = hex.EncodeToString(last)
+		done := len(last) == 0 || (len(endKey) > 0 && bytes.Compare(last, endKey) >= 0)
+		progress := 1.0
+		if !done && cp.Total > 0 && cp.Scattered+cp.Failed < cp.Total {
+			progress = float64(cp.Scattered+cp.Failed) / float64(cp.Total)
+		}
+		if err := r.Report(progress, cp); err != nil {
+			return err
+		}
+		if done {
+			break
+		}
+		nextKey = last
+	}
+	if cp.Failed > 0 {
+		return errors.Errorf("failed to scatter %d regions", cp.Failed)
+	}
+	return nil
+}
+
+// runDrainStore sets the store offline, and waits until the regions on it are
+// all moved out or it becomes tombstone.
+func (c *RaftCluster) runDrainStore(ctx context.Context, j *job.Job, r job.Reporter) error {
+	var params DrainStoreParams
+	if err := json.Unmarshal(j.Params, &params); err != nil {
+		return errs.ErrJSONUnmarshal.Wrap(err).FastGenWithCause()
+	}
+	storeID := params.StoreID
+	var cp drainStoreCheckpoint
+	if len(j.Checkpoint) > 0 {
+		if err := json.Unmarshal(j.Checkpoint, &cp); err != nil {
+			return errs.ErrJSONUnmarshal.Wrap(err).FastGenWithCause()
+		}
+	} else {
+		store := c.GetStore(storeID)
+		if store == nil {
+			return errs.ErrStoreNotFound.FastGenByArgs(storeID)
+		}
+		cp.RegionCount = c.GetStoreRegionCount(storeID)
+		cp.Left = cp.RegionCount
+		if !store.IsTombstone() {
+			if err := c.RemoveStore(storeID); err != nil {
+				return err
+			}
+		}
+		if err := r.Report(0, cp); err != nil {
+			return err
+		}
+	}
+	ticker := time.NewTicker(drainStoreCheckInterval)
+	defer ticker.Stop()
+	for {
+		store := c.GetStore(storeID)
+		// The tombstone store may be removed before the check.
+		if store == nil || store.IsTombstone() || c.GetStoreRegionCount(storeID) == 0 {
+			cp.Left = 0
+			log.Info("store is drained", zap.Uint64("job-id", j.ID), zap.Uint64("store-id", storeID))
+			return r.Report(1, cp)
+		}
+		if store.IsUp() {
+			return errors.Errorf("store %d is up again", storeID)
+		}
+		if left := c.GetStoreRegionCount(storeID); left != cp.Left {
+			cp.Left = left
+			var progress float64
+			if cp.RegionCount > 0 && left < cp.RegionCount {
+				progress = float64(cp.RegionCount-left) / float64(cp.RegionCount)
+			}
+			if err := r.Report(progress, cp); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// runSplitRegions splits the regions by the keys batch by batch.
+func (c *RaftCluster) runSplitRegions(ctx context.Context, j *job.Job, r job.Reporter) error {
+	var params SplitRegionsParams
+	if err := json.Unmarshal(j.Params, &params); err != nil {
+		return errs.ErrJSONUnmarshal.Wrap(err).FastGenWithCause()
+	}
+	splitKeys := make([][]byte, 0, len(params.SplitKeys))
+	for _, k := range params.SplitKeys {
+		key, err := hex.DecodeString(k)
+		if err != nil {
+			return errs.ErrHexDecodingString.Wrap(err).FastGenWithCause()
+		}
+		splitKeys = append(splitKeys, key)
+	}
+	if params.RetryLimit <= 0 {
+		params.RetryLimit = defaultJobRetryLimit
+	}
+
+	var cp splitRegionsCheckpoint
+	if len(j.Checkpoint) > 0 {
+		if err := json.Unmarshal(j.Checkpoint, &cp); err != nil {
+			return errs.ErrJSONUnmarshal.Wrap(err).FastGenWithCause()
+		}
+	}
+	for cp.Next < len(splitKeys) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := cp.Next + splitRegionsBatchSize
+		if end > len(splitKeys) {
+			end = len(splitKeys)
+		}
+		batch := splitKeys[cp.Next:end]
+		percentage, _ := c.GetRegionSplitter().SplitRegions(ctx, batch, params.RetryLimit)
+		cp.Failed += len(batch) - len(batch)*percentage/100
+		cp.Next = end
+		if err := r.Report(float64(cp.Next)/float64(len(splitKeys)), cp); err != nil {
+			return err
+		}
+	}
+	if cp.Failed > 0 {
+		return errors.Errorf("failed to split by %d keys", cp.Failed)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/tikv/pd/pkg/slice"
+	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/core"
+)
+
+// maxSuggestionDistance is the max edit distance between a label value and
+// a known value to suggest the known value as a fix for the typo.
+const maxSuggestionDistance = 2
+
+// LabelIssue is an inconsistency found in the labels of a store.
+type LabelIssue struct {
+	StoreID uint64 `json:"store_id"`
+	Address string `json:"address"`
+	Key     string `json:"key"`
+	Value   string `json:"value,omitempty"`
+	Reason  string `json:"reason"`
+	// Suggestion is the known value which is the most similar to the value.
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+func (i *LabelIssue) String() string {
+	if i.Suggestion != "" {
+		return fmt.Sprintf("store %d label %s=%s: %s, did you mean %s", i.StoreID, i.Key, i.Value, i.Reason, i.Suggestion)
+	}
+	return fmt.Sprintf("store %d label %s=%s: %s", i.StoreID, i.Key, i.Value, i.Reason)
+}
+
+// lintStoreLabels checks the labels of a store against the order of the
+// location labels and the known values of each label.
+func lintStoreLabels(s *core.StoreInfo, locationLabels []string, knownValues map[string]typeutil.StringSlice) []*LabelIssue {
+	var issues []*LabelIssue
+	newIssue := func(key, value, reason string) *LabelIssue {
+		issue := &LabelIssue{
+			StoreID: s.GetID(),
+			Address: s.GetAddress(),
+			Key:     key,
+			Value:   value,
+			Reason:  reason,
+		}
+		issues = append(issues, issue)
+		return issue
+	}
+
+	// A lower level label is meaningless if its upper levels are missing,
+	// e.g. `host` is set but `zone` is not.
+	missing := ""
+	for _, k := range locationLabels {
+		v := s.GetLabelValue(k)
+		if len(v) == 0 {
+			if missing == "" {
+				missing = k
+			}
+			continue
+		}
+		if missing != "" {
+			newIssue(k, v, fmt.Sprintf("the upper level label %s is missing", missing))
+		}
+	}
+
+	for _, label := range s.GetLabels() {
+		values, ok := knownValues[label.GetKey()]
+		if !ok || len(values) == 0 || slice.AnyOf(values, func(i int) bool { return values[i] == label.GetValue() }) {
+			continue
+		}
+		issue := newIssue(label.GetKey(), label.GetValue(), "the value is not a known value")
+		minDistance := maxSuggestionDistance + 1
+		for _, known := range values {
+			if d := editDistance(label.GetValue(), known); d < minDistance {
+				minDistance, issue.Suggestion = d, known
+			}
+		}
+	}
+	return issues
+}
+
+// LintStoreLabels returns the label inconsistencies of all stores which are
+// not tombstone.
+func (c *RaftCluster) LintStoreLabels() []*LabelIssue {
+	locationLabels := c.opt.GetLocationLabels()
+	knownValues := c.opt.GetLocationLabelValues()
+	issues := make([]*LabelIssue, 0)
+	for _, s := range c.GetStores() {
+		if s.IsTombstone() {
+			continue
+		}
+		issues = append(issues, lintStoreLabels(s, locationLabels, knownValues)...)
+	}
+	return issues
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"github.com/gogo/protobuf/proto"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/mock/mockid"
+	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+)
+
+var _ = Suite(&testLabelLintSuite{})
+
+type testLabelLintSuite struct{}
+
+func (s *testLabelLintSuite) TestEditDistance(c *C) {
+	c.Assert(editDistance("", ""), Equals, 0)
+	c.Assert(editDistance("us-east-1", "us-east-1"), Equals, 0)
+	c.Assert(editDistance("us-esat-1", "us-east-1"), Equals, 2)
+	c.Assert(editDistance("us-east-2", "us-east-1"), Equals, 1)
+	c.Assert(editDistance("", "abc"), Equals, 3)
+}
+
+func (s *testLabelLintSuite) TestLintStoreLabels(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cfg := opt.GetReplicationConfig().Clone()
+	cfg.LocationLabels = []string{"zone", "rack", "host"}
+	cfg.LocationLabelValues = map[string]typeutil.StringSlice{"zone": {"us-east-1", "us-west-1"}}
+	opt.SetReplicationConfig(cfg)
+	cluster := newTestRaftCluster(mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+
+	stores := newTestStores(3, "2.0.0")
+	stores[0] = stores[0].Clone(core.SetStoreLabels([]*metapb.StoreLabel{
+		{Key: "zone", Value: "us-east-1"}, {Key: "rack", Value: "r1"}, {Key: "host", Value: "h1"},
+	}))
+	stores[1] = stores[1].Clone(core.SetStoreLabels([]*metapb.StoreLabel{
+		{Key: "zone", Value: "us-esat-1"}, {Key: "rack", Value: "r1"}, {Key: "host", Value: "h2"},
+	}))
+	stores[2] = stores[2].Clone(core.SetStoreLabels([]*metapb.StoreLabel{
+		{Key: "zone", Value: "us-west-1"}, {Key: "host", Value: "h3"},
+	}))
+	// The inconsistent labels are only warned.
+	for _, store := range stores {
+		c.Assert(cluster.PutStore(store.GetMeta()), IsNil)
+	}
+
+	issues := cluster.LintStoreLabels()
+	c.Assert(issues, HasLen, 2)
+	for _, issue := range issues {
+		switch issue.StoreID {
+		case 2:
+			c.Assert(issue.Key, Equals, "zone")
+			c.Assert(issue.Value, Equals, "us-esat-1")
+			c.Assert(issue.Suggestion, Equals, "us-east-1")
+		case 3:
+			c.Assert(issue.Key, Equals, "host")
+			c.Assert(issue.Reason, Equals, "the upper level label rack is missing")
+		default:
+			c.Fatalf("unexpected issue %s", issue)
+		}
+	}
+
+	cfg = opt.GetReplicationConfig().Clone()
+	cfg.StrictlyMatchLabel = true
+	opt.SetReplicationConfig(cfg)
+	store := proto.Clone(stores[1].GetMeta()).(*metapb.Store)
+	store.Id = 4
+	store.Address = "mock://tikv-4"
+	// The findings are only advice even if strictly-match-label is on.
+	c.Assert(cluster.PutStore(store), IsNil)
+	c.Assert(cluster.GetStore(4), NotNil)
+}
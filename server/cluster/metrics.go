@@ -32,6 +32,14 @@ var (
 			Help:      "Counter of the region event",
 		}, []string{"event"})
 
+	rejectedHeartbeatCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "cluster",
+			Name:      "rejected_region_heartbeat",
+			Help:      "Counter of the region heartbeats rejected because of stale metadata.",
+		}, []string{"reason"})
+
 	schedulerStatusGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "pd",
@@ -70,14 +78,24 @@ var (
 			Name:      "cluster_state_current",
 			Help:      "Current state of the cluster",
 		}, []string{"state"})
+
+	clockDriftGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "cluster",
+			Name:      "clock_drift_seconds",
+			Help:      "Clock drift between the PD leader and other PD members or stores.",
+		}, []string{"component", "address"})
 )
 
 func init() {
 	prometheus.MustRegister(regionEventCounter)
+	prometheus.MustRegister(rejectedHeartbeatCounter)
 	prometheus.MustRegister(healthStatusGauge)
 	prometheus.MustRegister(schedulerStatusGauge)
 	prometheus.MustRegister(hotSpotStatusGauge)
 	prometheus.MustRegister(patrolCheckRegionsGauge)
 	prometheus.MustRegister(clusterStateCPUGauge)
 	prometheus.MustRegister(clusterStateCurrent)
+	prometheus.MustRegister(clockDriftGauge)
 }
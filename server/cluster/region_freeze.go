@@ -0,0 +1,112 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/operator"
+	"go.uber.org/zap"
+)
+
+// frozenRuleIDPrefix is the ID prefix of the region label rules created by
+// FreezeRange, the rest of the ID is the hex encoded key range.
+const frozenRuleIDPrefix = "freeze-"
+
+// FrozenRange is a key range whose regions are not scheduled until it expires.
+// It is used by backup tools to keep the leaders and peers of a range stable
+// during a fine-grained backup. A frozen range is a region label rule which
+// labels the range with schedule=deny, so it is persisted and shared with the
+// other label rules.
+type FrozenRange struct {
+	StartKey   []byte    `json:"-"`
+	EndKey     []byte    `json:"-"`
+	HexStart   string    `json:"start_key"`
+	HexEnd     string    `json:"end_key"`
+	ExpireTime time.Time `json:"expire_time"`
+}
+
+// overlapsKeyRange returns true if the region overlaps with [startKey, endKey).
+func overlapsKeyRange(startKey, endKey []byte, region *core.RegionInfo) bool {
+	return (len(endKey) == 0 || bytes.Compare(region.GetStartKey(), endKey) < 0) &&
+		(len(region.GetEndKey()) == 0 || bytes.Compare(region.GetEndKey(), startKey) > 0)
+}
+
+func frozenRuleID(startKey, endKey []byte) string {
+	return frozenRuleIDPrefix + hex.EncodeToString(startKey) + "-" + hex.EncodeToString(endKey)
+}
+
+func newFrozenRange(r *LabelRule) *FrozenRange {
+	return &FrozenRange{
+		StartKey:   r.StartKey,
+		EndKey:     r.EndKey,
+		HexStart:   r.HexStart,
+		HexEnd:     r.HexEnd,
+		ExpireTime: *r.ExpireTime,
+	}
+}
+
+// FreezeRange stops scheduling the regions overlapping with the key range for
+// the ttl by setting a schedule=deny label rule. The running operators of the
+// regions are canceled, except the ones created by the admin.
+func (c *RaftCluster) FreezeRange(startKey, endKey []byte, ttl time.Duration) (*FrozenRange, error) {
+	r := &LabelRule{
+		ID:       frozenRuleID(startKey, endKey),
+		Labels:   []RegionLabel{{Key: ScheduleLabelKey, Value: ScheduleDeny}},
+		HexStart: hex.EncodeToString(startKey),
+		HexEnd:   hex.EncodeToString(endKey),
+		TTL:      ttl.String(),
+	}
+	if err := c.SetRegionLabelRule(r); err != nil {
+		return nil, err
+	}
+	return newFrozenRange(r), nil
+}
+
+// removeOperatorsInRange cancels the running operators of the regions
+// overlapping with the key range, except the ones created by the admin.
+func (c *RaftCluster) removeOperatorsInRange(startKey, endKey []byte, reason string) {
+	if c.coordinator == nil {
+		return
+	}
+	for _, op := range c.coordinator.opController.GetOperators() {
+		if op.Kind()&operator.OpAdmin != 0 {
+			continue
+		}
+		if region := c.GetRegion(op.RegionID()); region != nil && overlapsKeyRange(startKey, endKey, region) {
+			c.coordinator.opController.RemoveOperator(op, zap.String("reason", reason))
+		}
+	}
+}
+
+// UnfreezeRange resumes scheduling the key range before it expires by removing
+// its label rule, it returns false if the range is not frozen.
+func (c *RaftCluster) UnfreezeRange(startKey, endKey []byte) (bool, error) {
+	return c.DeleteRegionLabelRule(frozenRuleID(startKey, endKey))
+}
+
+// GetFrozenRanges returns the frozen key ranges which are not expired.
+func (c *RaftCluster) GetFrozenRanges() []*FrozenRange {
+	ranges := make([]*FrozenRange, 0)
+	for _, r := range c.GetRegionLabelRules() {
+		if strings.HasPrefix(r.ID, frozenRuleIDPrefix) && r.ExpireTime != nil {
+			ranges = append(ranges, newFrozenRange(r))
+		}
+	}
+	return ranges
+}
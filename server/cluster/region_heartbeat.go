@@ -0,0 +1,118 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tikv/pd/server/core"
+)
+
+// regionHeartbeatShards is the number of the shards of the heartbeat times, so
+// the heartbeats of the regions in different shards do not contend for a lock.
+const regionHeartbeatShards = 64
+
+type regionHeartbeatShard struct {
+	sync.RWMutex
+	// lastTimes are the unix nanoseconds of the last heartbeats.
+	lastTimes map[uint64]int64
+}
+
+// regionHeartbeats records the last time each region is reported by its
+// leader. The region in cache is not updated if nothing is changed, so its
+// interval can not tell whether the leader is still reporting.
+type regionHeartbeats struct {
+	// startTime is used as the last heartbeat time of the regions which are
+	// loaded from storage and not reported since the cluster is started.
+	startTime int64
+	shards    [regionHeartbeatShards]regionHeartbeatShard
+}
+
+func (h *regionHeartbeats) shard(regionID uint64) *regionHeartbeatShard {
+	return &h.shards[regionID%regionHeartbeatShards]
+}
+
+func (h *regionHeartbeats) reset() {
+	atomic.StoreInt64(&h.startTime, time.Now().UnixNano())
+	for i := range h.shards {
+		shard := &h.shards[i]
+		shard.Lock()
+		shard.lastTimes = make(map[uint64]int64)
+		shard.Unlock()
+	}
+}
+
+func (h *regionHeartbeats) observe(regionID uint64) {
+	h.setLastTime(regionID, time.Now())
+}
+
+func (h *regionHeartbeats) setLastTime(regionID uint64, t time.Time) {
+	shard := h.shard(regionID)
+	shard.Lock()
+	defer shard.Unlock()
+	if shard.lastTimes == nil {
+		shard.lastTimes = make(map[uint64]int64)
+	}
+	shard.lastTimes[regionID] = t.UnixNano()
+}
+
+func (h *regionHeartbeats) remove(regionID uint64) {
+	shard := h.shard(regionID)
+	shard.Lock()
+	defer shard.Unlock()
+	delete(shard.lastTimes, regionID)
+}
+
+func (h *regionHeartbeats) lastTime(regionID uint64) time.Time {
+	shard := h.shard(regionID)
+	shard.RLock()
+	t, ok := shard.lastTimes[regionID]
+	shard.RUnlock()
+	if !ok {
+		t = atomic.LoadInt64(&h.startTime)
+	}
+	return time.Unix(0, t)
+}
+
+// prune removes the heartbeat times of the regions which no longer exist, like
+// the merged or deleted ones which are not removed with the overlaps.
+func (h *regionHeartbeats) prune(exists func(regionID uint64) bool) {
+	for i := range h.shards {
+		shard := &h.shards[i]
+		shard.Lock()
+		for regionID := range shard.lastTimes {
+			if !exists(regionID) {
+				delete(shard.lastTimes, regionID)
+			}
+		}
+		shard.Unlock()
+	}
+}
+
+// GetStaleHeartbeatRegions returns the regions whose last heartbeats are
+// between since and until, which means they are not reported by their leaders
+// since then. The zero since means no lower bound.
+func (c *RaftCluster) GetStaleHeartbeatRegions(since, until time.Time) []*core.RegionInfo {
+	var regions []*core.RegionInfo
+	for _, region := range c.GetRegions() {
+		t := c.regionHeartbeats.lastTime(region.GetID())
+		if t.After(until) || t.Before(since) {
+			continue
+		}
+		regions = append(regions, region)
+	}
+	return regions
+}
@@ -0,0 +1,185 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tikv/pd/pkg/cache"
+	"github.com/tikv/pd/server/core"
+)
+
+const (
+	// maxRegionHistory is the max number of events kept for each region.
+	maxRegionHistory = 32
+	// maxRegionHistoryRegions is the max number of regions whose history is
+	// kept, the history of the least recently changed region is evicted first.
+	maxRegionHistoryRegions = 100000
+	// regionHistoryTTL is how long the events are kept, the history of the
+	// merged regions is removed after that.
+	regionHistoryTTL = 24 * time.Hour
+)
+
+// The types of region events.
+const (
+	RegionEventLeaderTransfer = "leader-transfer"
+	RegionEventPeerChange     = "peer-change"
+	RegionEventSplit          = "split"
+	RegionEventMerge          = "merge"
+)
+
+// RegionEvent is a change of a region found by its heartbeats.
+type RegionEvent struct {
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"`
+	Detail string    `json:"detail"`
+	// Source is the description of the operator which makes the change. It is
+	// empty if the change is not made by PD, like the split of a large region.
+	Source string `json:"source,omitempty"`
+}
+
+// regionHistory keeps the latest events of each region in memory, so it is
+// lost after the leader is changed. The events of each region are kept in a
+// LRU cache as *[]RegionEvent, so the memory is bounded by the region count.
+type regionHistory struct {
+	sync.RWMutex
+	events cache.Cache
+}
+
+func (h *regionHistory) reset() {
+	h.Lock()
+	defer h.Unlock()
+	h.events = cache.NewCache(maxRegionHistoryRegions, cache.LRUCache)
+}
+
+// observe records the changes found by comparing the region with its origin in
+// cache. The overlaps are the regions replaced by the region, the events are
+// recorded for them too.
+func (h *regionHistory) observe(origin, region *core.RegionInfo, overlaps []*core.RegionInfo, source string) {
+	event := RegionEvent{Time: time.Now(), Source: source}
+	h.Lock()
+	defer h.Unlock()
+	if origin == nil {
+		// A new region is created by the split of the overlapped region.
+		for _, item := range overlaps {
+			event.Type = RegionEventSplit
+			event.Detail = fmt.Sprintf("split from region %d", item.GetID())
+			h.appendLocked(region.GetID(), event)
+			event.Detail = fmt.Sprintf("split into region %d", region.GetID())
+			h.appendLocked(item.GetID(), event)
+		}
+		return
+	}
+	r, o := region.GetRegionEpoch(), origin.GetRegionEpoch()
+	if r.GetVersion() > o.GetVersion() {
+		event.Type, event.Detail = RegionEventSplit, core.DiffRegionKeyInfo(origin, region)
+		var merged []uint64
+		for _, item := range overlaps {
+			if item.GetID() != region.GetID() {
+				merged = append(merged, item.GetID())
+			}
+		}
+		if len(merged) > 0 {
+			event.Type = RegionEventMerge
+			event.Detail = fmt.Sprintf("merged regions %v, %s", merged, event.Detail)
+			into := event
+			into.Detail = fmt.Sprintf("merged into region %d", region.GetID())
+			for _, id := range merged {
+				h.appendLocked(id, into)
+			}
+		}
+		h.appendLocked(region.GetID(), event)
+	}
+	if r.GetConfVer() > o.GetConfVer() {
+		event.Type, event.Detail = RegionEventPeerChange, core.DiffRegionPeersInfo(origin, region)
+		h.appendLocked(region.GetID(), event)
+	}
+	if origin.GetLeader().GetId() != 0 && region.GetLeader().GetId() != origin.GetLeader().GetId() {
+		event.Type = RegionEventLeaderTransfer
+		event.Detail = fmt.Sprintf("from store %d to store %d", origin.GetLeader().GetStoreId(), region.GetLeader().GetStoreId())
+		h.appendLocked(region.GetID(), event)
+	}
+}
+
+func (h *regionHistory) appendLocked(regionID uint64, event RegionEvent) {
+	if h.events == nil {
+		h.events = cache.NewCache(maxRegionHistoryRegions, cache.LRUCache)
+	}
+	var history []RegionEvent
+	if v, ok := h.events.Peek(regionID); ok {
+		history = *v.(*[]RegionEvent)
+	}
+	history = append(history, event)
+	if len(history) > maxRegionHistory {
+		history = append([]RegionEvent(nil), history[len(history)-maxRegionHistory:]...)
+	}
+	// Put makes the region the most recently used one.
+	h.events.Put(regionID, &history)
+}
+
+// prune removes the events which are older than the TTL.
+func (h *regionHistory) prune() {
+	h.Lock()
+	defer h.Unlock()
+	if h.events == nil {
+		return
+	}
+	expire := time.Now().Add(-regionHistoryTTL)
+	for _, item := range h.events.Elems() {
+		// The slice is updated in place to keep the order of the cache.
+		history := item.Value.(*[]RegionEvent)
+		i := 0
+		for i < len(*history) && (*history)[i].Time.Before(expire) {
+			i++
+		}
+		if i == len(*history) {
+			h.events.Remove(item.Key)
+		} else if i > 0 {
+			*history = append([]RegionEvent(nil), (*history)[i:]...)
+		}
+	}
+}
+
+func (h *regionHistory) get(regionID uint64) []RegionEvent {
+	h.RLock()
+	defer h.RUnlock()
+	if h.events == nil {
+		return nil
+	}
+	v, ok := h.events.Peek(regionID)
+	if !ok {
+		return nil
+	}
+	return append([]RegionEvent(nil), *v.(*[]RegionEvent)...)
+}
+
+// GetRegionHistory returns the latest events of the region, the earliest one
+// is the first.
+func (c *RaftCluster) GetRegionHistory(regionID uint64) []RegionEvent {
+	return c.regionHistory.get(regionID)
+}
+
+// getOperatorDesc returns the description of the operator of the region, it is
+// empty if there is no such operator.
+func (c *RaftCluster) getOperatorDesc(regionID uint64) string {
+	if c.coordinator == nil {
+		return ""
+	}
+	if op := c.coordinator.opController.GetOperator(regionID); op != nil {
+		return op.Desc()
+	}
+	return ""
+}
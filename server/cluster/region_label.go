@@ -0,0 +1,273 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+const (
+	// ScheduleLabelKey is the region label which controls the scheduling of
+	// the regions.
+	ScheduleLabelKey = "schedule"
+	// ScheduleDeny is the value of ScheduleLabelKey to stop scheduling the
+	// regions, only the operators created by the admin are allowed.
+	ScheduleDeny = "deny"
+)
+
+// RegionLabel is a label attached to regions.
+type RegionLabel struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// LabelRule attaches the labels to the regions overlapping with a key range.
+type LabelRule struct {
+	ID       string        `json:"id"`
+	Labels   []RegionLabel `json:"labels"`
+	StartKey []byte        `json:"-"`
+	EndKey   []byte        `json:"-"`
+	HexStart string        `json:"start_key"`
+	HexEnd   string        `json:"end_key"`
+	// TTL is how long the rule lasts, like 2h, it is converted to ExpireTime
+	// when the rule is set. The rule never expires if TTL is empty.
+	TTL        string     `json:"ttl,omitempty"`
+	ExpireTime *time.Time `json:"expire_time,omitempty"`
+}
+
+func (r *LabelRule) expired(now time.Time) bool {
+	return r.ExpireTime != nil && !r.ExpireTime.After(now)
+}
+
+func (r *LabelRule) String() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// check and adjust the label rule from client or storage.
+func adjustLabelRule(r *LabelRule) error {
+	if r.ID == "" {
+		return errs.ErrRegionLabelRule.FastGenByArgs("id should not be empty")
+	}
+	if len(r.Labels) == 0 {
+		return errs.ErrRegionLabelRule.FastGenByArgs("labels should not be empty")
+	}
+	for _, l := range r.Labels {
+		if l.Key == "" || l.Value == "" {
+			return errs.ErrRegionLabelRule.FastGenByArgs("label key and value should not be empty")
+		}
+	}
+	var err error
+	r.StartKey, err = hex.DecodeString(r.HexStart)
+	if err != nil {
+		return errs.ErrHexDecodingString.FastGenByArgs(r.HexStart)
+	}
+	r.EndKey, err = hex.DecodeString(r.HexEnd)
+	if err != nil {
+		return errs.ErrHexDecodingString.FastGenByArgs(r.HexEnd)
+	}
+	if len(r.EndKey) > 0 && bytes.Compare(r.EndKey, r.StartKey) <= 0 {
+		return errs.ErrRegionLabelRule.FastGenByArgs("end key should be greater than start key")
+	}
+	if r.TTL != "" {
+		ttl, err := time.ParseDuration(r.TTL)
+		if err != nil || ttl <= 0 {
+			return errs.ErrRegionLabelRule.FastGenByArgs("ttl should be a positive duration")
+		}
+		expireTime := time.Now().Add(ttl)
+		r.TTL, r.ExpireTime = "", &expireTime
+	}
+	return nil
+}
+
+// regionLabeler keeps the label rules of key ranges.
+type regionLabeler struct {
+	sync.RWMutex
+	rules map[string]*LabelRule
+}
+
+func (l *regionLabeler) load(storage *core.Storage) error {
+	rules := make(map[string]*LabelRule)
+	if err := storage.LoadRegionLabelRules(func(k, v string) {
+		var r LabelRule
+		if err := json.Unmarshal([]byte(v), &r); err != nil {
+			log.Error("failed to unmarshal region label rule", zap.String("rule-id", k), errs.ZapError(errs.ErrLoadRegionLabelRule, err))
+			return
+		}
+		if err := adjustLabelRule(&r); err != nil {
+			log.Error("region label rule is in bad format", zap.String("rule-id", k), errs.ZapError(errs.ErrLoadRegionLabelRule, err))
+			return
+		}
+		rules[r.ID] = &r
+	}); err != nil {
+		return err
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	l.rules = rules
+	return nil
+}
+
+func (l *regionLabeler) get(id string) *LabelRule {
+	l.RLock()
+	defer l.RUnlock()
+	if r, ok := l.rules[id]; ok && !r.expired(time.Now()) {
+		return r
+	}
+	return nil
+}
+
+func (l *regionLabeler) list() []*LabelRule {
+	l.RLock()
+	defer l.RUnlock()
+	now := time.Now()
+	rules := make([]*LabelRule, 0, len(l.rules))
+	for _, r := range l.rules {
+		if !r.expired(now) {
+			rules = append(rules, r)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules
+}
+
+func (l *regionLabeler) set(storage *core.Storage, r *LabelRule) error {
+	l.Lock()
+	defer l.Unlock()
+	l.gcLocked(storage)
+	if err := storage.SaveRegionLabelRule(r.ID, r); err != nil {
+		return err
+	}
+	if l.rules == nil {
+		l.rules = make(map[string]*LabelRule)
+	}
+	l.rules[r.ID] = r
+	return nil
+}
+
+// gcLocked removes the expired rules, the ones failed to be removed from the
+// storage are retried next time.
+func (l *regionLabeler) gcLocked(storage *core.Storage) {
+	now := time.Now()
+	for id, r := range l.rules {
+		if !r.expired(now) {
+			continue
+		}
+		if err := storage.DeleteRegionLabelRule(id); err != nil {
+			log.Warn("failed to remove expired region label rule", zap.String("rule-id", id), errs.ZapError(err))
+			continue
+		}
+		delete(l.rules, id)
+		log.Info("region label rule expired", zap.String("rule-id", id))
+	}
+}
+
+func (l *regionLabeler) delete(storage *core.Storage, id string) (bool, error) {
+	l.Lock()
+	defer l.Unlock()
+	if r, ok := l.rules[id]; !ok || r.expired(time.Now()) {
+		return false, nil
+	}
+	if err := storage.DeleteRegionLabelRule(id); err != nil {
+		return false, err
+	}
+	delete(l.rules, id)
+	return true, nil
+}
+
+// getLabel returns the value of the label key of the region. If the region is
+// matched by several rules with the key, the value of the rule with the
+// smallest ID is returned. The expired rules are ignored.
+func (l *regionLabeler) getLabel(region *core.RegionInfo, key string) string {
+	l.RLock()
+	defer l.RUnlock()
+	now := time.Now()
+	var id, value string
+	for _, r := range l.rules {
+		if (id != "" && r.ID > id) || r.expired(now) || !overlapsKeyRange(r.StartKey, r.EndKey, region) {
+			continue
+		}
+		for _, label := range r.Labels {
+			if label.Key == key {
+				id, value = r.ID, label.Value
+				break
+			}
+		}
+	}
+	return value
+}
+
+// GetRegionLabelRule returns the region label rule with the ID.
+func (c *RaftCluster) GetRegionLabelRule(id string) *LabelRule {
+	return c.regionLabeler.get(id)
+}
+
+// GetRegionLabelRules returns all the region label rules sorted by IDs.
+func (c *RaftCluster) GetRegionLabelRules() []*LabelRule {
+	return c.regionLabeler.list()
+}
+
+// SetRegionLabelRule inserts or updates a region label rule. The running
+// operators of the regions which are denied to schedule by the rule are
+// canceled, except the ones created by the admin.
+func (c *RaftCluster) SetRegionLabelRule(r *LabelRule) error {
+	if err := adjustLabelRule(r); err != nil {
+		return err
+	}
+	if err := c.regionLabeler.set(c.storage, r); err != nil {
+		return err
+	}
+	log.Info("region label rule updated", zap.String("rule", r.String()))
+	for _, label := range r.Labels {
+		if label.Key == ScheduleLabelKey && label.Value == ScheduleDeny {
+			c.removeOperatorsInRange(r.StartKey, r.EndKey, "region is denied to schedule")
+			break
+		}
+	}
+	return nil
+}
+
+// DeleteRegionLabelRule removes a region label rule, it returns false if the
+// rule does not exist.
+func (c *RaftCluster) DeleteRegionLabelRule(id string) (bool, error) {
+	ok, err := c.regionLabeler.delete(c.storage, id)
+	if err != nil || !ok {
+		return ok, err
+	}
+	log.Info("region label rule removed", zap.String("rule-id", id))
+	return true, nil
+}
+
+// GetRegionLabel returns the value of the label key attached to the region,
+// it returns an empty string if the region does not have the label.
+func (c *RaftCluster) GetRegionLabel(region *core.RegionInfo, key string) string {
+	return c.regionLabeler.getLabel(region, key)
+}
+
+// IsRegionScheduleDenied returns true if the region is labeled to deny
+// scheduling.
+func (c *RaftCluster) IsRegionScheduleDenied(region *core.RegionInfo) bool {
+	return c.GetRegionLabel(region, ScheduleLabelKey) == ScheduleDeny
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/logutil"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+// maxRejectedHeartbeats is the max number of the latest rejected heartbeats
+// kept in memory.
+const maxRejectedHeartbeats = 100
+
+// The reasons why a region heartbeat is rejected.
+const (
+	RejectReasonVersion = "version"
+	RejectReasonConfVer = "conf-ver"
+	RejectReasonTerm    = "term"
+	// RejectReasonOverlap means the version is older than a region which
+	// overlaps with the reported one, like the region is merged or split.
+	RejectReasonOverlap = "overlap"
+)
+
+// RejectedHeartbeat is a region heartbeat which is rejected because its
+// metadata is older than the record of PD, it is usually reported by a store
+// which is lagging behind after network partitions.
+type RejectedHeartbeat struct {
+	Time     time.Time `json:"time"`
+	RegionID uint64    `json:"region_id"`
+	// StoreID is the store of the leader which reports the heartbeat.
+	StoreID uint64              `json:"store_id"`
+	Reason  string              `json:"reason"`
+	Epoch   *metapb.RegionEpoch `json:"epoch"`
+	Term    uint64              `json:"term"`
+	// OriginEpoch and OriginTerm are recorded by PD, they are empty if the
+	// heartbeat is rejected because of an overlapped region.
+	OriginEpoch *metapb.RegionEpoch `json:"origin_epoch,omitempty"`
+	OriginTerm  uint64              `json:"origin_term,omitempty"`
+}
+
+// rejectedHeartbeats keeps the latest rejected heartbeats in memory.
+type rejectedHeartbeats struct {
+	sync.RWMutex
+	records []RejectedHeartbeat
+}
+
+func (h *rejectedHeartbeats) reset() {
+	h.Lock()
+	defer h.Unlock()
+	h.records = nil
+}
+
+// observe records the heartbeat of the region which is rejected. The origin is
+// the region in cache, it is nil if the region is stale compared with an
+// overlapped region.
+func (h *rejectedHeartbeats) observe(region, origin *core.RegionInfo) {
+	record := RejectedHeartbeat{
+		Time:     time.Now(),
+		RegionID: region.GetID(),
+		StoreID:  region.GetLeader().GetStoreId(),
+		Reason:   RejectReasonOverlap,
+		Epoch:    region.GetRegionEpoch(),
+		Term:     region.GetTerm(),
+	}
+	if origin != nil {
+		record.OriginEpoch, record.OriginTerm = origin.GetRegionEpoch(), origin.GetTerm()
+		switch {
+		case record.Epoch.GetVersion() < record.OriginEpoch.GetVersion():
+			record.Reason = RejectReasonVersion
+		case record.Epoch.GetConfVer() < record.OriginEpoch.GetConfVer():
+			record.Reason = RejectReasonConfVer
+		default:
+			record.Reason = RejectReasonTerm
+		}
+	}
+	rejectedHeartbeatCounter.WithLabelValues(record.Reason).Inc()
+	// Only sample if the message is logged, otherwise it is counted as
+	// suppressed.
+	if log.GetLevel() <= zap.DebugLevel {
+		if ok, suppressed := logutil.Sample(logutil.SampleKeyHeartbeatReject); ok {
+			log.Debug("region heartbeat is rejected",
+				zap.Uint64("region-id", record.RegionID),
+				zap.Uint64("store-id", record.StoreID),
+				zap.String("reason", record.Reason),
+				zap.Stringer("epoch", record.Epoch),
+				zap.Uint64("term", record.Term),
+				zap.Uint64("suppressed", suppressed))
+		}
+	}
+
+	h.Lock()
+	defer h.Unlock()
+	h.records = append(h.records, record)
+	if len(h.records) > maxRejectedHeartbeats {
+		h.records = append([]RejectedHeartbeat(nil), h.records[len(h.records)-maxRejectedHeartbeats:]...)
+	}
+}
+
+func (h *rejectedHeartbeats) get() []RejectedHeartbeat {
+	h.RLock()
+	defer h.RUnlock()
+	return append([]RejectedHeartbeat(nil), h.records...)
+}
+
+// GetRejectedHeartbeats returns the latest region heartbeats which are rejected
+// because their metadata is stale, the earliest one is the first.
+func (c *RaftCluster) GetRejectedHeartbeats() []RejectedHeartbeat {
+	return c.rejectedHeartbeats.get()
+}
@@ -0,0 +1,236 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+// regionTopologyChunkSize is the max number of regions saved in a key, so that
+// a snapshot of a large cluster does not exceed the size limit of a value.
+const regionTopologyChunkSize = 4096
+
+var (
+	// maxRegionTopologyChunks caps the size of a snapshot, the regions beyond
+	// it are not saved and the snapshot is marked as truncated.
+	maxRegionTopologyChunks = 64
+	// maxRegionTopologySnapshots caps the number of the snapshots, the oldest
+	// ones are removed even if they are within the retention.
+	maxRegionTopologySnapshots = 288
+)
+
+// RegionTopology is a region in a snapshot of the region topology, the peers
+// are the IDs of the stores.
+type RegionTopology struct {
+	ID       uint64   `json:"id"`
+	Version  uint64   `json:"version"`
+	ConfVer  uint64   `json:"conf_ver"`
+	Leader   uint64   `json:"leader,omitempty"`
+	Voters   []uint64 `json:"voters"`
+	Learners []uint64 `json:"learners,omitempty"`
+}
+
+func newRegionTopology(region *core.RegionInfo) RegionTopology {
+	t := RegionTopology{
+		ID:      region.GetID(),
+		Version: region.GetRegionEpoch().GetVersion(),
+		ConfVer: region.GetRegionEpoch().GetConfVer(),
+		Leader:  region.GetLeader().GetStoreId(),
+	}
+	for _, peer := range region.GetPeers() {
+		if peer.GetRole() == metapb.PeerRole_Learner {
+			t.Learners = append(t.Learners, peer.GetStoreId())
+		} else {
+			t.Voters = append(t.Voters, peer.GetStoreId())
+		}
+	}
+	return t
+}
+
+// RegionTopologySnapshot is the topology of the regions at a time. It is
+// truncated if the cluster has more regions than a snapshot can hold.
+type RegionTopologySnapshot struct {
+	Time      time.Time        `json:"time"`
+	Truncated bool             `json:"truncated,omitempty"`
+	Regions   []RegionTopology `json:"regions"`
+}
+
+// regionTopologyMeta is saved for each snapshot, so that the snapshots can be
+// listed without loading the regions.
+type regionTopologyMeta struct {
+	Time      time.Time `json:"time"`
+	Chunks    int       `json:"chunks"`
+	Regions   int       `json:"regions"`
+	Truncated bool      `json:"truncated,omitempty"`
+	// Pending is true until all the chunks are saved.
+	Pending bool `json:"pending,omitempty"`
+}
+
+// regionTopologySnapshots persists the snapshots of the region topology
+// periodically, and keeps the metas of them in memory.
+type regionTopologySnapshots struct {
+	sync.RWMutex
+	// metas are sorted by time.
+	metas []regionTopologyMeta
+}
+
+// load loads the metas of the snapshots, and removes the snapshots which are
+// not completely saved.
+func (s *regionTopologySnapshots) load(storage *core.Storage) error {
+	var metas []regionTopologyMeta
+	var err error
+	if e := storage.LoadRegionTopologyMetas(func(k, v string) {
+		var meta regionTopologyMeta
+		if e := json.Unmarshal([]byte(v), &meta); e != nil {
+			err = errs.ErrJSONUnmarshal.Wrap(e).GenWithStackByCause()
+			return
+		}
+		metas = append(metas, meta)
+	}); e != nil {
+		return e
+	}
+	if err != nil {
+		return err
+	}
+	completed := metas[:0]
+	for _, meta := range metas {
+		if !meta.Pending {
+			completed = append(completed, meta)
+			continue
+		}
+		if err := storage.RemoveRegionTopology(meta.Time.Unix(), meta.Chunks); err != nil {
+			return err
+		}
+		log.Info("removed the incomplete region topology snapshot", zap.Time("time", meta.Time))
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.metas = completed
+	return nil
+}
+
+// save takes a snapshot if the interval has passed since the last one, and
+// removes the snapshots older than the retention or beyond the max number.
+func (s *regionTopologySnapshots) save(storage *core.Storage, now time.Time, interval, retention time.Duration, getRegions func() []*core.RegionInfo) error {
+	s.Lock()
+	defer s.Unlock()
+	if interval > 0 && (len(s.metas) == 0 || now.Sub(s.metas[len(s.metas)-1].Time) >= interval) {
+		regions := getRegions()
+		meta := regionTopologyMeta{Time: now, Regions: len(regions), Pending: true}
+		if max := maxRegionTopologyChunks * regionTopologyChunkSize; len(regions) > max {
+			regions, meta.Regions, meta.Truncated = regions[:max], max, true
+		}
+		meta.Chunks = (len(regions) + regionTopologyChunkSize - 1) / regionTopologyChunkSize
+		if err := storage.SaveRegionTopologyMeta(now.Unix(), meta); err != nil {
+			return err
+		}
+		for i := 0; i < meta.Chunks; i++ {
+			start, end := i*regionTopologyChunkSize, (i+1)*regionTopologyChunkSize
+			if end > len(regions) {
+				end = len(regions)
+			}
+			chunk := make([]RegionTopology, 0, end-start)
+			for _, region := range regions[start:end] {
+				chunk = append(chunk, newRegionTopology(region))
+			}
+			if err := storage.SaveRegionTopologyChunk(now.Unix(), i, chunk); err != nil {
+				removeIncompleteRegionTopology(storage, meta)
+				return err
+			}
+		}
+		meta.Pending = false
+		if err := storage.SaveRegionTopologyMeta(now.Unix(), meta); err != nil {
+			removeIncompleteRegionTopology(storage, meta)
+			return err
+		}
+		s.metas = append(s.metas, meta)
+	}
+
+	expired := now.Add(-retention)
+	for len(s.metas) > 0 && (s.metas[0].Time.Before(expired) || len(s.metas) > maxRegionTopologySnapshots) {
+		if err := storage.RemoveRegionTopology(s.metas[0].Time.Unix(), s.metas[0].Chunks); err != nil {
+			return err
+		}
+		s.metas = s.metas[1:]
+	}
+	return nil
+}
+
+// removeIncompleteRegionTopology removes a snapshot which fails to be saved,
+// the pending meta is left to be cleaned up by load if it fails to be removed.
+func removeIncompleteRegionTopology(storage *core.Storage, meta regionTopologyMeta) {
+	if err := storage.RemoveRegionTopology(meta.Time.Unix(), meta.Chunks); err != nil {
+		log.Error("failed to remove the incomplete region topology snapshot", zap.Time("time", meta.Time), errs.ZapError(err))
+	}
+}
+
+// get loads the latest snapshot taken no later than the time, only the region
+// is returned if the regionID is not 0. It returns nil if there is no such
+// snapshot.
+func (s *regionTopologySnapshots) get(storage *core.Storage, t time.Time, regionID uint64) (*RegionTopologySnapshot, error) {
+	s.RLock()
+	i := sort.Search(len(s.metas), func(i int) bool { return s.metas[i].Time.After(t) })
+	var meta regionTopologyMeta
+	if i > 0 {
+		meta = s.metas[i-1]
+	}
+	s.RUnlock()
+	if i == 0 {
+		return nil, nil
+	}
+
+	snapshot := &RegionTopologySnapshot{Time: meta.Time, Truncated: meta.Truncated, Regions: make([]RegionTopology, 0)}
+	var err error
+	if e := storage.LoadRegionTopologyChunks(meta.Time.Unix(), func(k, v string) {
+		var chunk []RegionTopology
+		if e := json.Unmarshal([]byte(v), &chunk); e != nil {
+			err = errs.ErrJSONUnmarshal.Wrap(e).GenWithStackByCause()
+			return
+		}
+		for _, region := range chunk {
+			if regionID == 0 || region.ID == regionID {
+				snapshot.Regions = append(snapshot.Regions, region)
+			}
+		}
+	}); e != nil {
+		return nil, e
+	}
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func (c *RaftCluster) saveRegionTopology() {
+	if err := c.regionTopology.save(c.storage, time.Now(), c.opt.GetRegionTopologyInterval(), c.opt.GetRegionTopologyRetention(), c.GetRegions); err != nil {
+		log.Error("failed to save the region topology", errs.ZapError(err))
+	}
+}
+
+// GetRegionTopology returns the latest snapshot of the region topology taken
+// no later than the time, only the region is returned if the regionID is not
+// 0. It returns nil if there is no such snapshot.
+func (c *RaftCluster) GetRegionTopology(t time.Time, regionID uint64) (*RegionTopologySnapshot, error) {
+	return c.regionTopology.get(c.storage, t, regionID)
+}
@@ -0,0 +1,199 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+)
+
+// storeRegionMetaURL is the path of the status server of TiKV which returns
+// the local meta of a region on the store, it responds 404 if the store does
+// not have the region. The status server has no API to list all the regions
+// on a store, so the regions which the store has but PD does not know are not
+// found by the verification.
+const storeRegionMetaURL = "/region/%d"
+
+// Kinds of the region epoch mismatches between PD and a store.
+const (
+	// RegionMismatchMissing means PD has a peer on the store but the store
+	// does not have the region.
+	RegionMismatchMissing = "missing"
+	// RegionMismatchStale means the epoch of the store is older than PD.
+	RegionMismatchStale = "stale"
+	// RegionMismatchDivergent means the epoch of the store is newer than PD
+	// in any part, which PD should never miss.
+	RegionMismatchDivergent = "divergent"
+)
+
+// storeRegionMeta is the part of the local meta of a region reported by the
+// status server of TiKV.
+type storeRegionMeta struct {
+	ID          uint64 `json:"id"`
+	RegionState struct {
+		Epoch metapb.RegionEpoch `json:"epoch"`
+	} `json:"region_state"`
+}
+
+// RegionMismatch is a region whose epoch on a store differs from PD.
+type RegionMismatch struct {
+	RegionID   uint64              `json:"region_id"`
+	StoreID    uint64              `json:"store_id"`
+	Kind       string              `json:"kind"`
+	PDEpoch    *metapb.RegionEpoch `json:"pd_epoch,omitempty"`
+	StoreEpoch *metapb.RegionEpoch `json:"store_epoch,omitempty"`
+}
+
+// StoreVerifyResult is the result of verifying the regions of a store.
+type StoreVerifyResult struct {
+	StoreID uint64 `json:"store_id"`
+	Address string `json:"address"`
+	Checked int    `json:"checked"`
+	// Error is not empty if the store fails to report its regions, the regions
+	// checked before the failure are still reported.
+	Error string `json:"error,omitempty"`
+}
+
+// RegionVerifyReport is the result of verifying the region epochs in PD
+// against the stores.
+type RegionVerifyReport struct {
+	Stores     []*StoreVerifyResult `json:"stores"`
+	Mismatches []*RegionMismatch    `json:"mismatches"`
+}
+
+// VerifyRegions asks the stores for their local region epochs and compares
+// them with the regions in PD, one request is sent for each region which has a
+// peer on the store. All the stores which are not tombstone are
+// verified if the storeID is 0.
+func (c *RaftCluster) VerifyRegions(ctx context.Context, storeID uint64) (*RegionVerifyReport, error) {
+	var stores []*core.StoreInfo
+	if storeID != 0 {
+		store := c.GetStore(storeID)
+		if store == nil {
+			return nil, errs.ErrStoreNotFound.FastGenByArgs(storeID)
+		}
+		stores = append(stores, store)
+	} else {
+		for _, store := range c.GetStores() {
+			if !store.IsTombstone() {
+				stores = append(stores, store)
+			}
+		}
+		sort.Slice(stores, func(i, j int) bool { return stores[i].GetID() < stores[j].GetID() })
+	}
+
+	report := &RegionVerifyReport{Stores: []*StoreVerifyResult{}, Mismatches: []*RegionMismatch{}}
+	for _, store := range stores {
+		result := &StoreVerifyResult{StoreID: store.GetID(), Address: store.GetMeta().GetStatusAddress()}
+		report.Stores = append(report.Stores, result)
+		if result.Address == "" {
+			result.Error = "no status address"
+			continue
+		}
+		regions := c.GetStoreRegions(store.GetID())
+		metas := make(map[uint64]*storeRegionMeta, len(regions))
+		for _, region := range regions {
+			meta, err := c.getStoreRegionMeta(ctx, store, region.GetID())
+			if err != nil {
+				result.Error = err.Error()
+				regions = regions[:result.Checked]
+				break
+			}
+			if meta != nil {
+				metas[region.GetID()] = meta
+			}
+			result.Checked++
+		}
+		report.Mismatches = append(report.Mismatches, diffRegionEpochs(store.GetID(), regions, metas)...)
+	}
+	return report, nil
+}
+
+// getStoreRegionMeta returns the local meta of the region on the store, or nil
+// if the store does not have the region.
+func (c *RaftCluster) getStoreRegionMeta(ctx context.Context, store *core.StoreInfo, regionID uint64) (*storeRegionMeta, error) {
+	scheme := "http"
+	if c.isTLSEnabled() {
+		scheme = "https"
+	}
+	ctx, cancel := context.WithTimeout(ctx, clientTimeout)
+	defer cancel()
+	url := fmt.Sprintf("%s://%s"+storeRegionMetaURL, scheme, store.GetMeta().GetStatusAddress(), regionID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errs.ErrNewHTTPRequest.Wrap(err).GenWithStackByCause()
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errs.ErrSendRequest.Wrap(err).GenWithStackByCause()
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errs.ErrHTTPStatus.FastGenByArgs(resp.StatusCode)
+	}
+	meta := &storeRegionMeta{}
+	if err := json.NewDecoder(resp.Body).Decode(meta); err != nil {
+		return nil, errs.ErrJSONUnmarshal.Wrap(err).GenWithStackByCause()
+	}
+	return meta, nil
+}
+
+// diffRegionEpochs compares the regions which have a peer on the store in PD
+// with the local region metas of the store.
+func diffRegionEpochs(storeID uint64, regions []*core.RegionInfo, metas map[uint64]*storeRegionMeta) []*RegionMismatch {
+	var mismatches []*RegionMismatch
+	for _, region := range regions {
+		pdEpoch := region.GetRegionEpoch()
+		meta, ok := metas[region.GetID()]
+		if !ok {
+			mismatches = append(mismatches, &RegionMismatch{RegionID: region.GetID(), StoreID: storeID, Kind: RegionMismatchMissing, PDEpoch: pdEpoch})
+			continue
+		}
+		storeEpoch := &meta.RegionState.Epoch
+		var kind string
+		switch {
+		case storeEpoch.GetConfVer() > pdEpoch.GetConfVer() || storeEpoch.GetVersion() > pdEpoch.GetVersion():
+			kind = RegionMismatchDivergent
+		case storeEpoch.GetConfVer() < pdEpoch.GetConfVer() || storeEpoch.GetVersion() < pdEpoch.GetVersion():
+			kind = RegionMismatchStale
+		default:
+			continue
+		}
+		mismatches = append(mismatches, &RegionMismatch{RegionID: region.GetID(), StoreID: storeID, Kind: kind, PDEpoch: pdEpoch, StoreEpoch: storeEpoch})
+	}
+	sort.Slice(mismatches, func(i, j int) bool { return mismatches[i].RegionID < mismatches[j].RegionID })
+	return mismatches
+}
+
+// isTLSEnabled reports whether PD is configured with the certificates. The TLS
+// config of the transport is also filled for HTTP/2 once it is used, so only
+// the certificates are checked.
+func (c *RaftCluster) isTLSEnabled() bool {
+	transport, ok := c.httpClient.Transport.(*http.Transport)
+	if !ok || transport.TLSClientConfig == nil {
+		return false
+	}
+	tlsConfig := transport.TLSClientConfig
+	return tlsConfig.RootCAs != nil || len(tlsConfig.Certificates) > 0 || tlsConfig.GetClientCertificate != nil
+}
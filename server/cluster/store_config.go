@@ -0,0 +1,168 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+// StoreConfigMetadataKey is the key of the gRPC header of the store heartbeat
+// response which carries the dynamic config items of the store in JSON. The
+// StoreHeartbeatResponse has no field for them, so they are pushed to the store
+// in the header of every response.
+const StoreConfigMetadataKey = "pd-store-config"
+
+// storeConfigs keeps the dynamic config items of stores, like the hints of
+// snapshot concurrency or the import mode flags. The items are maintained in PD
+// and not interpreted by PD itself.
+type storeConfigs struct {
+	sync.RWMutex
+	configs map[uint64]map[string]string
+}
+
+func (s *storeConfigs) load(storage *core.Storage) error {
+	configs := make(map[uint64]map[string]string)
+	var err error
+	if e := storage.LoadStoreConfigs(func(k, v string) {
+		storeID, e := strconv.ParseUint(k, 10, 64)
+		if e != nil {
+			err = errs.ErrStrconvParseUint.Wrap(e).GenWithStackByCause()
+			return
+		}
+		items := make(map[string]string)
+		if e := json.Unmarshal([]byte(v), &items); e != nil {
+			err = errs.ErrJSONUnmarshal.Wrap(e).GenWithStackByCause()
+			return
+		}
+		configs[storeID] = items
+	}); e != nil {
+		return e
+	}
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.configs = configs
+	return nil
+}
+
+func (s *storeConfigs) get(storeID uint64) map[string]string {
+	s.RLock()
+	defer s.RUnlock()
+	items := make(map[string]string, len(s.configs[storeID]))
+	for k, v := range s.configs[storeID] {
+		items[k] = v
+	}
+	return items
+}
+
+// update merges the items into the config of the store, the items with empty
+// value are removed.
+func (s *storeConfigs) update(storage *core.Storage, storeID uint64, items map[string]string) error {
+	s.Lock()
+	defer s.Unlock()
+	merged := make(map[string]string, len(s.configs[storeID])+len(items))
+	for k, v := range s.configs[storeID] {
+		merged[k] = v
+	}
+	for k, v := range items {
+		if v == "" {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = v
+	}
+
+	var err error
+	if len(merged) == 0 {
+		err = storage.RemoveStoreConfig(storeID)
+	} else {
+		err = storage.SaveStoreConfig(storeID, merged)
+	}
+	if err != nil {
+		return err
+	}
+	if s.configs == nil {
+		s.configs = make(map[uint64]map[string]string)
+	}
+	if len(merged) == 0 {
+		delete(s.configs, storeID)
+	} else {
+		s.configs[storeID] = merged
+	}
+	return nil
+}
+
+// remove removes all the config items of the store.
+func (s *storeConfigs) remove(storage *core.Storage, storeID uint64) error {
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.configs[storeID]; !ok {
+		return nil
+	}
+	if err := storage.RemoveStoreConfig(storeID); err != nil {
+		return err
+	}
+	delete(s.configs, storeID)
+	return nil
+}
+
+// GetStoreConfig returns the dynamic config items of the store. The config
+// hints of all stores in the temporary configuration, like the ones of the
+// import mode, override the items of the store until they expire.
+func (c *RaftCluster) GetStoreConfig(storeID uint64) (map[string]string, error) {
+	if c.GetStore(storeID) == nil {
+		return nil, errs.ErrStoreNotFound.FastGenByArgs(storeID)
+	}
+	items := c.storeConfigs.get(storeID)
+	for k, v := range c.opt.GetStoreConfigHints() {
+		items[k] = v
+	}
+	return items, nil
+}
+
+// UpdateStoreConfig sets the dynamic config items of the store, the items
+// with empty value are removed.
+func (c *RaftCluster) UpdateStoreConfig(storeID uint64, items map[string]string) error {
+	if c.GetStore(storeID) == nil {
+		return errs.ErrStoreNotFound.FastGenByArgs(storeID)
+	}
+	return c.storeConfigs.update(c.storage, storeID, items)
+}
+
+// DeleteStoreConfig removes the dynamic config items of the store by keys.
+func (c *RaftCluster) DeleteStoreConfig(storeID uint64, keys ...string) error {
+	items := make(map[string]string, len(keys))
+	for _, key := range keys {
+		items[key] = ""
+	}
+	return c.UpdateStoreConfig(storeID, items)
+}
+
+// removeStoreConfig removes the dynamic config items of the store which is
+// tombstone or deleted.
+func (c *RaftCluster) removeStoreConfig(storeID uint64) {
+	if err := c.storeConfigs.remove(c.storage, storeID); err != nil {
+		log.Warn("failed to remove the store config", zap.Uint64("store-id", storeID), errs.ZapError(err))
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/core/storelimit"
+	"github.com/tikv/pd/server/schedule/placement"
+)
+
+// UnplaceableRegion is a region of which the peer on the store to delete can
+// not be moved to any of the remaining stores.
+type UnplaceableRegion struct {
+	RegionID uint64 `json:"region_id"`
+	// Rule is the key of the rule which the peer is fitted to, like pd/default.
+	Rule   string `json:"rule"`
+	Reason string `json:"reason"`
+}
+
+// StoreDeleteCheck is the result of simulating the deletion of a store.
+type StoreDeleteCheck struct {
+	StoreID uint64 `json:"store_id"`
+	// Safe is true if the peers of all the regions on the store can be moved
+	// to the remaining stores.
+	Safe bool `json:"safe"`
+	// Issues are the rules which the remaining stores can not satisfy.
+	Issues      []string          `json:"issues"`
+	RegionCount int               `json:"region_count"`
+	BytesToMove typeutil.ByteSize `json:"bytes_to_move"`
+	// AddPeerLimit is the sum of the add-peer limits of the remaining stores
+	// in regions per minute, which bounds the migration since the remove-peer
+	// limit of the store is unlimited once it is deleted.
+	AddPeerLimit float64 `json:"add_peer_limit"`
+	// EstimatedDuration is absent if no store can accept the peers.
+	EstimatedDuration  *typeutil.Duration   `json:"estimated_duration,omitempty"`
+	UnplaceableRegions []*UnplaceableRegion `json:"unplaceable_regions"`
+}
+
+// CheckDeleteStore simulates the deletion of the store without changing
+// anything. It checks whether the remaining stores which are up, not down and
+// not low on space can satisfy the placement rules of the regions on the
+// store, or the max-replicas and the isolation level if the placement rules
+// are disabled, and estimates the data to move and the time at the current
+// store limits.
+func (c *RaftCluster) CheckDeleteStore(storeID uint64) (*StoreDeleteCheck, error) {
+	store := c.GetStore(storeID)
+	if store == nil {
+		return nil, errs.ErrStoreNotFound.FastGenByArgs(storeID)
+	}
+	if store.IsTombstone() {
+		return nil, errs.ErrStoreTombstone.FastGenByArgs(storeID)
+	}
+
+	var targets []*core.StoreInfo
+	check := &StoreDeleteCheck{StoreID: storeID, Issues: []string{}, UnplaceableRegions: []*UnplaceableRegion{}}
+	for _, s := range c.GetStores() {
+		if s.GetID() == storeID || !s.IsUp() || s.DownTime() > c.opt.GetMaxStoreDownTime() || s.IsLowSpace(c.opt.GetLowSpaceRatio()) {
+			continue
+		}
+		targets = append(targets, s)
+		check.AddPeerLimit += c.opt.GetStoreLimitByType(s.GetID(), storelimit.AddPeer)
+	}
+
+	var defaultRules []*placement.Rule
+	if !c.opt.IsPlacementRulesEnabled() {
+		defaultRules = []*placement.Rule{{
+			GroupID:        "pd",
+			ID:             "default",
+			Role:           placement.Voter,
+			Count:          c.opt.GetMaxReplicas(),
+			LocationLabels: c.opt.GetLocationLabels(),
+			IsolationLevel: c.opt.GetIsolationLevel(),
+		}}
+	}
+	checkedRules := make(map[[2]string]struct{})
+	regions := c.GetStoreRegions(storeID)
+	check.RegionCount = len(regions)
+	for _, region := range regions {
+		check.BytesToMove += typeutil.ByteSize(region.GetApproximateSize() * units.MiB)
+		rules := defaultRules
+		if rules == nil {
+			rules = c.ruleManager.GetRulesForApplyRegion(region)
+		}
+		peer := region.GetStorePeer(storeID)
+		fit := placement.FitRegion(c, region, rules)
+		ruleFit := fit.GetRuleFit(peer.GetId())
+		if ruleFit == nil {
+			// The orphan peer is removed without adding a new one.
+			continue
+		}
+		rule := ruleFit.Rule
+		if _, ok := checkedRules[rule.Key()]; !ok {
+			checkedRules[rule.Key()] = struct{}{}
+			if n := countIsolatedStores(rule, targets); n < rule.Count {
+				check.Issues = append(check.Issues, fmt.Sprintf("rule %s needs %d %s but only %d remain", ruleKey(rule), rule.Count, isolationUnit(rule), n))
+			}
+		}
+		if reason := findPeerTarget(c, rule, region, ruleFit, storeID, targets); reason != "" {
+			check.UnplaceableRegions = append(check.UnplaceableRegions, &UnplaceableRegion{RegionID: region.GetID(), Rule: ruleKey(rule), Reason: reason})
+		}
+	}
+	sort.Slice(check.UnplaceableRegions, func(i, j int) bool {
+		return check.UnplaceableRegions[i].RegionID < check.UnplaceableRegions[j].RegionID
+	})
+	sort.Strings(check.Issues)
+	check.Safe = len(check.Issues) == 0 && len(check.UnplaceableRegions) == 0
+	if check.AddPeerLimit > 0 {
+		d := typeutil.NewDuration(time.Duration(float64(check.RegionCount) / check.AddPeerLimit * float64(time.Minute)))
+		check.EstimatedDuration = &d
+	}
+	return check, nil
+}
+
+// findPeerTarget returns the reason if no store can accept the peer of the
+// region on the store to delete, otherwise it returns an empty string.
+func findPeerTarget(stores placement.StoreSet, rule *placement.Rule, region *core.RegionInfo, ruleFit *placement.RuleFit, storeID uint64, targets []*core.StoreInfo) string {
+	// The values of the isolation level of the other peers of the rule.
+	occupied := make(map[string]struct{})
+	for _, p := range ruleFit.Peers {
+		if p.GetStoreId() == storeID {
+			continue
+		}
+		if s := stores.GetStore(p.GetStoreId()); s != nil {
+			occupied[isolationValue(rule, s)] = struct{}{}
+		}
+	}
+	matched := false
+	for _, s := range targets {
+		if region.GetStorePeer(s.GetID()) != nil || !placement.MatchLabelConstraints(s, rule.LabelConstraints) {
+			continue
+		}
+		matched = true
+		if rule.IsolationLevel == "" {
+			return ""
+		}
+		if _, ok := occupied[isolationValue(rule, s)]; !ok {
+			return ""
+		}
+	}
+	if matched {
+		return fmt.Sprintf("no store is isolated from the other peers at %s", rule.IsolationLevel)
+	}
+	return "no store matches the rule without a peer of the region"
+}
+
+// countIsolatedStores returns the number of the stores matching the rule, the
+// stores with the same value of the isolation level are counted once.
+func countIsolatedStores(rule *placement.Rule, stores []*core.StoreInfo) int {
+	values := make(map[string]struct{})
+	for _, s := range stores {
+		if placement.MatchLabelConstraints(s, rule.LabelConstraints) {
+			values[isolationValue(rule, s)] = struct{}{}
+		}
+	}
+	return len(values)
+}
+
+// isolationValue returns the location of the store up to the isolation level
+// of the rule, it is the store ID if there is no isolation level.
+func isolationValue(rule *placement.Rule, store *core.StoreInfo) string {
+	if rule.IsolationLevel == "" {
+		return fmt.Sprint(store.GetID())
+	}
+	var values []string
+	for _, label := range rule.LocationLabels {
+		values = append(values, store.GetLabelValue(label))
+		if label == rule.IsolationLevel {
+			return strings.Join(values, "/")
+		}
+	}
+	return store.GetLabelValue(rule.IsolationLevel)
+}
+
+func isolationUnit(rule *placement.Rule) string {
+	if rule.IsolationLevel == "" {
+		return "stores"
+	}
+	return "isolated " + rule.IsolationLevel + "s"
+}
+
+func ruleKey(rule *placement.Rule) string {
+	return rule.GroupID + "/" + rule.ID
+}
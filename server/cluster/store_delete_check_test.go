@@ -0,0 +1,128 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"time"
+
+	"github.com/docker/go-units"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/placement"
+)
+
+var _ = Suite(&testStoreDeleteCheckSuite{})
+
+type testStoreDeleteCheckSuite struct{}
+
+func (s *testStoreDeleteCheckSuite) TestCheckDeleteStore(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cfg := opt.GetReplicationConfig().Clone()
+	cfg.LocationLabels = []string{"zone", "host"}
+	opt.SetReplicationConfig(cfg)
+	cluster := newTestCluster(opt)
+
+	// The stores 3 and 4 are in the same zone.
+	stats := &pdpb.StoreStats{Capacity: 100 * units.GiB, Available: 80 * units.GiB}
+	for i, zone := range []string{"z1", "z2", "z3", "z3"} {
+		store := core.NewStoreInfo(&metapb.Store{
+			Id:      uint64(i + 1),
+			State:   metapb.StoreState_Up,
+			Version: "2.0.0",
+			Labels:  []*metapb.StoreLabel{{Key: "zone", Value: zone}, {Key: "host", Value: string(rune('a' + i))}},
+		}, core.SetLastHeartbeatTS(time.Now()), core.SetStoreStats(stats))
+		cluster.core.PutStore(store)
+	}
+	for i, storeIDs := range [][]uint64{{1, 2, 3}, {1, 2, 4}} {
+		regionID := uint64(i + 1)
+		var peers []*metapb.Peer
+		for _, storeID := range storeIDs {
+			peers = append(peers, &metapb.Peer{Id: regionID*10 + storeID, StoreId: storeID})
+		}
+		region := core.NewRegionInfo(&metapb.Region{
+			Id:          regionID,
+			StartKey:    []byte{byte(regionID)},
+			EndKey:      []byte{byte(regionID + 1)},
+			Peers:       peers,
+			RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+		}, peers[0], core.SetApproximateSize(96))
+		c.Assert(cluster.processRegionHeartbeat(region), IsNil)
+	}
+
+	// Without the isolation level, the peers can be moved to the other store
+	// in the same zone.
+	check, err := cluster.CheckDeleteStore(1)
+	c.Assert(err, IsNil)
+	c.Assert(check.Safe, IsTrue)
+	c.Assert(check.RegionCount, Equals, 2)
+	c.Assert(int64(check.BytesToMove), Equals, int64(192<<20))
+	c.Assert(check.AddPeerLimit, Greater, 0.0)
+	c.Assert(check.EstimatedDuration, NotNil)
+
+	// The rule isolates the peers at zone.
+	c.Assert(cluster.ruleManager.SetRule(&placement.Rule{
+		GroupID:        "pd",
+		ID:             "default",
+		Role:           placement.Voter,
+		Count:          3,
+		LocationLabels: []string{"zone", "host"},
+		IsolationLevel: "zone",
+	}), IsNil)
+	check, err = cluster.CheckDeleteStore(1)
+	c.Assert(err, IsNil)
+	c.Assert(check.Safe, IsFalse)
+	c.Assert(check.Issues, DeepEquals, []string{"rule pd/default needs 3 isolated zones but only 2 remain"})
+	c.Assert(check.UnplaceableRegions, HasLen, 2)
+	c.Assert(check.UnplaceableRegions[0].RegionID, Equals, uint64(1))
+	c.Assert(check.UnplaceableRegions[0].Rule, Equals, "pd/default")
+	c.Assert(check.UnplaceableRegions[0].Reason, Equals, "no store is isolated from the other peers at zone")
+	// The other store in the zone can take the peer of the region 2.
+	check, err = cluster.CheckDeleteStore(4)
+	c.Assert(err, IsNil)
+	c.Assert(check.Safe, IsTrue)
+	c.Assert(check.RegionCount, Equals, 1)
+
+	// The isolation level of the replication config is used if the placement
+	// rules are disabled.
+	cfg = opt.GetReplicationConfig().Clone()
+	cfg.EnablePlacementRules = false
+	cfg.IsolationLevel = "zone"
+	opt.SetReplicationConfig(cfg)
+	check, err = cluster.CheckDeleteStore(1)
+	c.Assert(err, IsNil)
+	c.Assert(check.Safe, IsFalse)
+	c.Assert(check.UnplaceableRegions, HasLen, 2)
+	cluster.core.PutStore(core.NewStoreInfo(&metapb.Store{
+		Id:      5,
+		State:   metapb.StoreState_Up,
+		Version: "2.0.0",
+		Labels:  []*metapb.StoreLabel{{Key: "zone", Value: "z4"}, {Key: "host", Value: "e"}},
+	}, core.SetLastHeartbeatTS(time.Now()), core.SetStoreStats(stats)))
+	check, err = cluster.CheckDeleteStore(1)
+	c.Assert(err, IsNil)
+	c.Assert(check.Safe, IsTrue)
+	c.Assert(check.Issues, HasLen, 0)
+
+	// The down store can not accept the peers.
+	cluster.core.PutStore(cluster.GetStore(5).Clone(core.SetLastHeartbeatTS(time.Now().Add(-time.Hour))))
+	check, err = cluster.CheckDeleteStore(1)
+	c.Assert(err, IsNil)
+	c.Assert(check.Safe, IsFalse)
+
+	_, err = cluster.CheckDeleteStore(100)
+	c.Assert(err, NotNil)
+}
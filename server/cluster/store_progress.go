@@ -0,0 +1,123 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/core"
+)
+
+// storeProgressWindow is the window of the samples to calculate the migration
+// rate of an offline store.
+const storeProgressWindow = 10 * time.Minute
+
+type storeProgressSample struct {
+	time        time.Time
+	regionCount int
+	regionSize  int64 // in MB
+}
+
+// StoreProgress is the progress of moving the regions out of an offline store.
+type StoreProgress struct {
+	// Progress is the ratio of the regions moved out since the store is found
+	// offline, from 0 to 1.
+	Progress    float64           `json:"progress"`
+	RegionsLeft int               `json:"regions_left"`
+	BytesLeft   typeutil.ByteSize `json:"bytes_left"`
+	// MigrationRate is the bytes moved out per second in the latest window.
+	MigrationRate typeutil.ByteSize `json:"migration_rate"`
+	StartTime     time.Time         `json:"start_time"`
+	// EstimatedCompletionTime is absent if nothing is moved out in the latest
+	// window.
+	EstimatedCompletionTime *time.Time `json:"estimated_completion_time,omitempty"`
+}
+
+// storeProgress keeps the samples of the offline stores in memory, so the
+// progress starts over after the leader is changed.
+type storeProgress struct {
+	sync.RWMutex
+	first   map[uint64]storeProgressSample
+	samples map[uint64][]storeProgressSample
+}
+
+func (p *storeProgress) reset() {
+	p.Lock()
+	defer p.Unlock()
+	p.first = make(map[uint64]storeProgressSample)
+	p.samples = make(map[uint64][]storeProgressSample)
+}
+
+// observe records a sample if the store is offline, otherwise the samples of
+// the store are dropped.
+func (p *storeProgress) observe(store *core.StoreInfo, now time.Time) {
+	p.Lock()
+	defer p.Unlock()
+	if p.first == nil {
+		p.first = make(map[uint64]storeProgressSample)
+		p.samples = make(map[uint64][]storeProgressSample)
+	}
+	storeID := store.GetID()
+	if !store.IsOffline() {
+		delete(p.first, storeID)
+		delete(p.samples, storeID)
+		return
+	}
+	sample := storeProgressSample{time: now, regionCount: store.GetRegionCount(), regionSize: store.GetRegionSize()}
+	if _, ok := p.first[storeID]; !ok {
+		p.first[storeID] = sample
+	}
+	samples := append(p.samples[storeID], sample)
+	expire := now.Add(-storeProgressWindow)
+	i := 0
+	for i < len(samples)-1 && samples[i].time.Before(expire) {
+		i++
+	}
+	p.samples[storeID] = append([]storeProgressSample(nil), samples[i:]...)
+}
+
+func (p *storeProgress) get(storeID uint64) *StoreProgress {
+	p.RLock()
+	defer p.RUnlock()
+	samples := p.samples[storeID]
+	if len(samples) == 0 {
+		return nil
+	}
+	first, oldest, latest := p.first[storeID], samples[0], samples[len(samples)-1]
+	progress := &StoreProgress{
+		RegionsLeft: latest.regionCount,
+		BytesLeft:   typeutil.ByteSize(latest.regionSize * units.MiB),
+		StartTime:   first.time,
+	}
+	if first.regionCount > 0 && latest.regionCount < first.regionCount {
+		progress.Progress = 1 - float64(latest.regionCount)/float64(first.regionCount)
+	}
+	if elapsed := latest.time.Sub(oldest.time).Seconds(); elapsed > 0 && oldest.regionSize > latest.regionSize {
+		progress.MigrationRate = typeutil.ByteSize(float64(oldest.regionSize-latest.regionSize) * units.MiB / elapsed)
+		if progress.MigrationRate > 0 {
+			eta := latest.time.Add(time.Duration(float64(progress.BytesLeft) / float64(progress.MigrationRate) * float64(time.Second)))
+			progress.EstimatedCompletionTime = &eta
+		}
+	}
+	return progress
+}
+
+// GetStoreProgress returns the progress of the offline store, it returns nil
+// if the store is not offline.
+func (c *RaftCluster) GetStoreProgress(storeID uint64) *StoreProgress {
+	return c.storeProgress.get(storeID)
+}
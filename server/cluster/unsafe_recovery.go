@@ -0,0 +1,314 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/job"
+	"go.uber.org/zap"
+)
+
+// The stages of an unsafe recovery.
+const (
+	UnsafeRecoveryRecovering = "recovering"
+	UnsafeRecoveryFinished   = "finished"
+	UnsafeRecoveryTimeout    = "timeout"
+	UnsafeRecoveryCancelled  = "cancelled"
+)
+
+var unsafeRecoveryCheckInterval = time.Second
+
+// UnsafeRecoveryPlan is the regions to recover on a surviving store. The
+// failed peers of the regions are removed by running the command on the
+// store while it is stopped, then the regions elect leaders among the
+// surviving peers after the store is restarted.
+type UnsafeRecoveryPlan struct {
+	StoreID uint64   `json:"store_id"`
+	Address string   `json:"address"`
+	Regions []uint64 `json:"regions"`
+	Command string   `json:"command"`
+}
+
+// UnsafeRecoveryStatus is the progress of removing the failed stores.
+type UnsafeRecoveryStatus struct {
+	// JobID is the job which monitors the recovery.
+	JobID        uint64    `json:"job_id"`
+	FailedStores []uint64  `json:"failed_stores"`
+	Stage        string    `json:"stage"`
+	StartTime    time.Time `json:"start_time"`
+	Deadline     time.Time `json:"deadline"`
+	// Regions is the number of the regions which lose the majority of the
+	// voters but still have surviving peers, and Recovered is the number of
+	// them which have no peer on the failed stores in their latest heartbeats.
+	Regions   int                   `json:"regions"`
+	Recovered int                   `json:"recovered"`
+	Plans     []*UnsafeRecoveryPlan `json:"plans"`
+	// LostRegions have no surviving peer, they need to be recreated on a
+	// store by `tikv-ctl recreate-region`, so they are not counted in Regions.
+	LostRegions []uint64 `json:"lost_regions,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// unsafeRecoveryParams are the params of an unsafe-recovery job.
+type unsafeRecoveryParams struct {
+	Status *UnsafeRecoveryStatus `json:"status"`
+	// Regions are the IDs of the regions to recover.
+	Regions []uint64 `json:"regions"`
+}
+
+type unsafeRecoveryCheckpoint struct {
+	Recovered int `json:"recovered"`
+}
+
+// PlanRemoveFailedStores plans an unsafe recovery of the regions which lose
+// the majority of the voters because of the failed stores. It returns the
+// tikv-ctl commands to remove the failed peers, PD does not send them to the
+// stores, so they must be run by the user on the surviving stores. The
+// recovery is monitored by a job, whose progress is reported by
+// GetUnsafeRecoveryStatus until the timeout.
+func (c *RaftCluster) PlanRemoveFailedStores(storeIDs []uint64, timeout time.Duration) (*UnsafeRecoveryStatus, error) {
+	if len(storeIDs) == 0 {
+		return nil, errs.ErrUnsafeRecoveryInvalidInput.FastGenByArgs("no failed store is specified")
+	}
+	failedStores := make(map[uint64]struct{}, len(storeIDs))
+	for _, storeID := range storeIDs {
+		store := c.GetStore(storeID)
+		if store == nil {
+			return nil, errs.ErrStoreNotFound.FastGenByArgs(storeID)
+		}
+		if store.IsTombstone() {
+			return nil, errs.ErrUnsafeRecoveryInvalidInput.FastGenByArgs(fmt.Sprintf("store %d is tombstone", storeID))
+		}
+		if !store.IsDisconnected() {
+			return nil, errs.ErrUnsafeRecoveryInvalidInput.FastGenByArgs(fmt.Sprintf("store %d is still sending heartbeats", storeID))
+		}
+		failedStores[storeID] = struct{}{}
+	}
+
+	c.unsafeRecoveryMu.Lock()
+	defer c.unsafeRecoveryMu.Unlock()
+	m := c.GetJobManager()
+	if latest := latestUnsafeRecoveryJob(m); latest != nil && !latest.State.IsDone() {
+		return nil, errs.ErrUnsafeRecoveryIsRunning.FastGenByArgs()
+	}
+
+	now := time.Now()
+	status := &UnsafeRecoveryStatus{
+		Stage:     UnsafeRecoveryRecovering,
+		StartTime: now,
+		Deadline:  now.Add(timeout),
+	}
+	for storeID := range failedStores {
+		status.FailedStores = append(status.FailedStores, storeID)
+	}
+	sort.Slice(status.FailedStores, func(i, j int) bool { return status.FailedStores[i] < status.FailedStores[j] })
+
+	plans := make(map[uint64][]uint64)
+	var regions []uint64
+	for _, region := range c.GetRegions() {
+		voters := region.GetVoters()
+		var alive int
+		for _, peer := range voters {
+			if _, ok := failedStores[peer.GetStoreId()]; !ok {
+				alive++
+			}
+		}
+		if alive == len(voters) || alive > len(voters)/2 {
+			continue
+		}
+		var surviving bool
+		for _, peer := range region.GetPeers() {
+			if _, ok := failedStores[peer.GetStoreId()]; !ok {
+				plans[peer.GetStoreId()] = append(plans[peer.GetStoreId()], region.GetID())
+				surviving = true
+			}
+		}
+		if surviving {
+			regions = append(regions, region.GetID())
+		} else {
+			status.LostRegions = append(status.LostRegions, region.GetID())
+		}
+	}
+	sort.Slice(regions, func(i, j int) bool { return regions[i] < regions[j] })
+	sort.Slice(status.LostRegions, func(i, j int) bool { return status.LostRegions[i] < status.LostRegions[j] })
+
+	failed := make([]string, 0, len(status.FailedStores))
+	for _, storeID := range status.FailedStores {
+		failed = append(failed, fmt.Sprint(storeID))
+	}
+	for storeID, ids := range plans {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		plan := &UnsafeRecoveryPlan{StoreID: storeID, Regions: ids}
+		if store := c.GetStore(storeID); store != nil {
+			plan.Address = store.GetAddress()
+		}
+		rs := make([]string, 0, len(ids))
+		for _, id := range ids {
+			rs = append(rs, fmt.Sprint(id))
+		}
+		plan.Command = fmt.Sprintf("tikv-ctl --db <data-dir>/db unsafe-recover remove-fail-stores -s %s -r %s", strings.Join(failed, ","), strings.Join(rs, ","))
+		status.Plans = append(status.Plans, plan)
+	}
+	sort.Slice(status.Plans, func(i, j int) bool { return status.Plans[i].StoreID < status.Plans[j].StoreID })
+	status.Regions = len(regions)
+
+	params, err := json.Marshal(&unsafeRecoveryParams{Status: status, Regions: regions})
+	if err != nil {
+		return nil, errs.ErrJSONMarshal.Wrap(err).FastGenWithCause()
+	}
+	j, err := m.Submit(UnsafeRecoveryJob, params)
+	if err != nil {
+		return nil, err
+	}
+	log.Warn("unsafe recovery is started",
+		zap.Uint64("job-id", j.ID),
+		zap.Uint64s("failed-stores", status.FailedStores),
+		zap.Int("regions", status.Regions),
+		zap.Int("lost-regions", len(status.LostRegions)),
+		zap.Duration("timeout", timeout))
+	return c.unsafeRecoveryStatus(j)
+}
+
+// runUnsafeRecovery waits for the failed peers to be removed from the regions
+// until the deadline.
+func (c *RaftCluster) runUnsafeRecovery(ctx context.Context, j *job.Job, r job.Reporter) error {
+	var params unsafeRecoveryParams
+	if err := json.Unmarshal(j.Params, &params); err != nil {
+		return errs.ErrJSONUnmarshal.Wrap(err).FastGenWithCause()
+	}
+	status := params.Status
+	failedStores := make(map[uint64]struct{}, len(status.FailedStores))
+	for _, storeID := range status.FailedStores {
+		failedStores[storeID] = struct{}{}
+	}
+	var cp unsafeRecoveryCheckpoint
+	if len(j.Checkpoint) > 0 {
+		if err := json.Unmarshal(j.Checkpoint, &cp); err != nil {
+			return errs.ErrJSONUnmarshal.Wrap(err).FastGenWithCause()
+		}
+	}
+	ticker := time.NewTicker(unsafeRecoveryCheckInterval)
+	defer ticker.Stop()
+	for {
+		recovered := c.countUnsafeRecovered(params.Regions, failedStores)
+		if recovered != cp.Recovered {
+			cp.Recovered = recovered
+			progress := 1.0
+			if len(params.Regions) > 0 {
+				progress = float64(recovered) / float64(len(params.Regions))
+			}
+			if err := r.Report(progress, cp); err != nil {
+				return err
+			}
+		}
+		if recovered == len(params.Regions) {
+			log.Info("unsafe recovery is finished", zap.Uint64("job-id", j.ID), zap.Uint64s("failed-stores", status.FailedStores))
+			return nil
+		}
+		if time.Now().After(status.Deadline) {
+			log.Warn("unsafe recovery is timeout", zap.Uint64("job-id", j.ID), zap.Uint64s("failed-stores", status.FailedStores), zap.Int("recovered", recovered), zap.Int("regions", len(params.Regions)))
+			return errors.Errorf("unsafe recovery is timeout, %d of %d regions are recovered", recovered, len(params.Regions))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// countUnsafeRecovered returns the number of the regions which have no peer on
+// the failed stores.
+func (c *RaftCluster) countUnsafeRecovered(regions []uint64, failedStores map[uint64]struct{}) int {
+	recovered := 0
+	for _, id := range regions {
+		region := c.GetRegion(id)
+		if region == nil {
+			continue
+		}
+		ok := true
+		for _, peer := range region.GetPeers() {
+			if _, failed := failedStores[peer.GetStoreId()]; failed {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			recovered++
+		}
+	}
+	return recovered
+}
+
+func latestUnsafeRecoveryJob(m *job.Manager) *job.Job {
+	jobs := m.GetJobs()
+	for i := len(jobs) - 1; i >= 0; i-- {
+		if jobs[i].Type == UnsafeRecoveryJob {
+			return jobs[i]
+		}
+	}
+	return nil
+}
+
+func (c *RaftCluster) unsafeRecoveryStatus(j *job.Job) (*UnsafeRecoveryStatus, error) {
+	var params unsafeRecoveryParams
+	if err := json.Unmarshal(j.Params, &params); err != nil {
+		return nil, errs.ErrJSONUnmarshal.Wrap(err).FastGenWithCause()
+	}
+	status := params.Status
+	status.JobID = j.ID
+	switch j.State {
+	case job.Pending, job.Running:
+		failedStores := make(map[uint64]struct{}, len(status.FailedStores))
+		for _, storeID := range status.FailedStores {
+			failedStores[storeID] = struct{}{}
+		}
+		status.Stage, status.Recovered = UnsafeRecoveryRecovering, c.countUnsafeRecovered(params.Regions, failedStores)
+		return status, nil
+	case job.Finished:
+		status.Stage, status.Recovered = UnsafeRecoveryFinished, status.Regions
+		return status, nil
+	case job.Cancelled:
+		status.Stage = UnsafeRecoveryCancelled
+	default:
+		status.Stage, status.Error = UnsafeRecoveryTimeout, j.Error
+	}
+	var cp unsafeRecoveryCheckpoint
+	if len(j.Checkpoint) > 0 {
+		if err := json.Unmarshal(j.Checkpoint, &cp); err != nil {
+			return nil, errs.ErrJSONUnmarshal.Wrap(err).FastGenWithCause()
+		}
+	}
+	status.Recovered = cp.Recovered
+	return status, nil
+}
+
+// GetUnsafeRecoveryStatus returns the progress of the latest unsafe recovery,
+// it returns nil if there is no unsafe recovery.
+func (c *RaftCluster) GetUnsafeRecoveryStatus() (*UnsafeRecoveryStatus, error) {
+	latest := latestUnsafeRecoveryJob(c.GetJobManager())
+	if latest == nil {
+		return nil, nil
+	}
+	return c.unsafeRecoveryStatus(latest)
+}
@@ -134,6 +134,12 @@ type Config struct {
 	LabelProperty LabelPropertyConfig `toml:"label-property" json:"label-property"`
 
 	configFile string
+	// fileItems are the items defined in the config file, like
+	// "schedule.max-merge-region-size".
+	fileItems map[string]struct{}
+	// flagItems are the items set by the command line flags, like
+	// "log.level".
+	flagItems map[string]struct{}
 
 	// For all warnings during parsing.
 	WarningMsgs []string
@@ -218,8 +224,12 @@ const (
 	defaultUseRegionStorage = true
 	defaultTraceRegionFlow  = true
 	defaultMaxResetTSGap    = 24 * time.Hour
+	defaultMaxClockDrift    = 2 * time.Second
 	defaultKeyType          = "table"
 
+	defaultRegionTopologyRetention = 72 * time.Hour
+	minRegionTopologyInterval      = time.Minute
+
 	defaultStrictlyMatchLabel   = false
 	defaultEnablePlacementRules = true
 	defaultEnableGRPCGateway    = true
@@ -240,6 +250,7 @@ const (
 var (
 	defaultEnableTelemetry = true
 	defaultRuntimeServices = []string{}
+	defaultEnabledFeatures = []string{FeatureHotRegionHistory}
 	defaultLocationLabels  = []string{}
 	// DefaultStoreLimit is the default store limit of add peer and remove peer.
 	DefaultStoreLimit = StoreLimit{AddPeer: 15, RemovePeer: 15}
@@ -377,6 +388,10 @@ func (c *Config) Parse(arguments []string) error {
 			msg := fmt.Sprintf("disable-telemetry in %s is deprecated, use enable-telemetry instead", c.configFile)
 			c.WarningMsgs = append(c.WarningMsgs, msg)
 		}
+		c.fileItems = make(map[string]struct{})
+		for _, key := range meta.Keys() {
+			c.fileItems[key.String()] = struct{}{}
+		}
 	}
 
 	// Parse again to replace with command line options.
@@ -388,6 +403,12 @@ func (c *Config) Parse(arguments []string) error {
 	if len(c.flagSet.Args()) != 0 {
 		return errors.Errorf("'%s' is an invalid flag", c.flagSet.Arg(0))
 	}
+	c.flagItems = make(map[string]struct{})
+	c.flagSet.Visit(func(f *flag.Flag) {
+		if key, ok := flagItems[f.Name]; ok {
+			c.flagItems[key] = struct{}{}
+		}
+	})
 
 	err = c.Adjust(meta)
 	return err
@@ -607,6 +628,11 @@ type ScheduleConfig struct {
 	// it will try to merge with adjacent regions.
 	MaxMergeRegionSize uint64 `toml:"max-merge-region-size" json:"max-merge-region-size"`
 	MaxMergeRegionKeys uint64 `toml:"max-merge-region-keys" json:"max-merge-region-keys"`
+	// RegionMaxSize (MB) and RegionMaxKeys are the region-max-size and
+	// region-max-keys of the stores, a region reaching either of them is
+	// reported as oversized since it is expected to be split by the store.
+	RegionMaxSize uint64 `toml:"region-max-size" json:"region-max-size"`
+	RegionMaxKeys uint64 `toml:"region-max-keys" json:"region-max-keys"`
 	// SplitMergeInterval is the minimum interval time to permit merge after split.
 	SplitMergeInterval typeutil.Duration `toml:"split-merge-interval" json:"split-merge-interval"`
 	// EnableOneWayMerge is the option to enable one way merge. This means a Region can only be merged into the next region of it.
@@ -621,8 +647,12 @@ type ScheduleConfig struct {
 	MaxStoreDownTime typeutil.Duration `toml:"max-store-down-time" json:"max-store-down-time"`
 	// LeaderScheduleLimit is the max coexist leader schedules.
 	LeaderScheduleLimit uint64 `toml:"leader-schedule-limit" json:"leader-schedule-limit"`
-	// LeaderSchedulePolicy is the option to balance leader, there are some policies supported: ["count", "size"], default: "count"
+	// LeaderSchedulePolicy is the option to balance leader, there are some policies supported: ["count", "size", "utilization"], default: "count"
 	LeaderSchedulePolicy string `toml:"leader-schedule-policy" json:"leader-schedule-policy"`
+	// StoreIOCapacity is the disk IO capacity per second of a store, which is
+	// used to calculate the utilization of stores. 0 means the disk IO is not
+	// considered in the utilization.
+	StoreIOCapacity typeutil.ByteSize `toml:"store-io-capacity" json:"store-io-capacity"`
 	// RegionScheduleLimit is the max coexist region schedules.
 	RegionScheduleLimit uint64 `toml:"region-schedule-limit" json:"region-schedule-limit"`
 	// ReplicaScheduleLimit is the max coexist replica schedules.
@@ -635,6 +665,10 @@ type ScheduleConfig struct {
 	// If the number of times a region hits the hot cache is greater than this
 	// threshold, it is considered a hot region.
 	HotRegionCacheHitsThreshold uint64 `toml:"hot-region-cache-hits-threshold" json:"hot-region-cache-hits-threshold"`
+	// HotRegionCacheMemoryBudget is the memory budget (in MB) of the hot region
+	// cache. When the estimated usage exceeds it, the cache degrades to a shorter
+	// retention and coarser rolling statistics. 0 means no limit.
+	HotRegionCacheMemoryBudget uint64 `toml:"hot-region-cache-memory-budget" json:"hot-region-cache-memory-budget"`
 	// StoreBalanceRate is the maximum of balance rate for each store.
 	// WARN: StoreBalanceRate is deprecated.
 	StoreBalanceRate float64 `toml:"store-balance-rate" json:"store-balance-rate,omitempty"`
@@ -708,6 +742,39 @@ type ScheduleConfig struct {
 	// is overwritten, the value is fixed until it is deleted.
 	// Default: manual
 	StoreLimitMode string `toml:"store-limit-mode" json:"store-limit-mode"`
+
+	// StoreClassLimits overrides MaxSnapshotCount and MaxPendingPeerCount for
+	// the stores with a label, like the HDD stores which allow fewer
+	// concurrent snapshots than the NVMe stores.
+	StoreClassLimits []StoreClassLimit `toml:"store-class-limits" json:"store-class-limits"`
+}
+
+// StoreClassLimit is the limits of the stores with the label Key=Value, the
+// label key is case insensitive like the location labels. The zero limits
+// are not overridden.
+type StoreClassLimit struct {
+	Key                 string `toml:"key" json:"key"`
+	Value               string `toml:"value" json:"value"`
+	MaxSnapshotCount    uint64 `toml:"max-snapshot-count" json:"max-snapshot-count"`
+	MaxPendingPeerCount uint64 `toml:"max-pending-peer-count" json:"max-pending-peer-count"`
+}
+
+// Class returns the label of the store class, like disk=hdd.
+func (l StoreClassLimit) Class() string {
+	return l.Key + "=" + l.Value
+}
+
+// GetStoreClassLimit returns the limit of the first store class which the
+// store with the labels belongs to, it returns nil if there is none.
+func (c *ScheduleConfig) GetStoreClassLimit(labels []*metapb.StoreLabel) *StoreClassLimit {
+	for i, limit := range c.StoreClassLimits {
+		for _, label := range labels {
+			if strings.EqualFold(label.GetKey(), limit.Key) && label.GetValue() == limit.Value {
+				return &c.StoreClassLimits[i]
+			}
+		}
+	}
+	return nil
 }
 
 // Clone returns a cloned scheduling configuration.
@@ -723,6 +790,7 @@ func (c *ScheduleConfig) Clone() *ScheduleConfig {
 	cfg := *c
 	cfg.StoreLimit = storeLimit
 	cfg.Schedulers = schedulers
+	cfg.StoreClassLimits = append(c.StoreClassLimits[:0:0], c.StoreClassLimits...)
 	cfg.SchedulersPayload = nil
 	return &cfg
 }
@@ -733,6 +801,8 @@ const (
 	defaultMaxPendingPeerCount    = 16
 	defaultMaxMergeRegionSize     = 20
 	defaultMaxMergeRegionKeys     = 200000
+	defaultRegionMaxSize          = 144
+	defaultRegionMaxKeys          = 1440000
 	defaultSplitMergeInterval     = 1 * time.Hour
 	defaultPatrolRegionInterval   = 100 * time.Millisecond
 	defaultMaxStoreDownTime       = 30 * time.Minute
@@ -747,6 +817,7 @@ const (
 	// defaultHotRegionCacheHitsThreshold is the low hit number threshold of the
 	// hot region.
 	defaultHotRegionCacheHitsThreshold = 3
+	defaultHotRegionCacheMemoryBudget  = 256
 	defaultSchedulerMaxWaitingOperator = 5
 	defaultLeaderSchedulePolicy        = "count"
 	defaultStoreLimitMode              = "manual"
@@ -767,6 +838,8 @@ func (c *ScheduleConfig) adjust(meta *configMetaData) error {
 	if !meta.IsDefined("max-merge-region-keys") {
 		adjustUint64(&c.MaxMergeRegionKeys, defaultMaxMergeRegionKeys)
 	}
+	adjustUint64(&c.RegionMaxSize, defaultRegionMaxSize)
+	adjustUint64(&c.RegionMaxKeys, defaultRegionMaxKeys)
 	adjustDuration(&c.SplitMergeInterval, defaultSplitMergeInterval)
 	adjustDuration(&c.PatrolRegionInterval, defaultPatrolRegionInterval)
 	adjustDuration(&c.MaxStoreDownTime, defaultMaxStoreDownTime)
@@ -788,6 +861,9 @@ func (c *ScheduleConfig) adjust(meta *configMetaData) error {
 	if !meta.IsDefined("hot-region-cache-hits-threshold") {
 		adjustUint64(&c.HotRegionCacheHitsThreshold, defaultHotRegionCacheHitsThreshold)
 	}
+	if !meta.IsDefined("hot-region-cache-memory-budget") {
+		adjustUint64(&c.HotRegionCacheMemoryBudget, defaultHotRegionCacheMemoryBudget)
+	}
 	if !meta.IsDefined("tolerant-size-ratio") {
 		adjustFloat64(&c.TolerantSizeRatio, defaultTolerantSizeRatio)
 	}
@@ -894,6 +970,17 @@ func (c *ScheduleConfig) Validate() error {
 			return errors.Errorf("create func of %v is not registered, maybe misspelled", scheduleConfig.Type)
 		}
 	}
+	classes := make(map[string]struct{}, len(c.StoreClassLimits))
+	for _, limit := range c.StoreClassLimits {
+		if err := ValidateLabels([]*metapb.StoreLabel{{Key: limit.Key, Value: limit.Value}}); err != nil {
+			return errors.Errorf("invalid store class %s: %v", limit.Class(), err)
+		}
+		class := strings.ToLower(limit.Key) + "=" + limit.Value
+		if _, ok := classes[class]; ok {
+			return errors.Errorf("duplicated store class %s", limit.Class())
+		}
+		classes[class] = struct{}{}
+	}
 	return nil
 }
 
@@ -972,6 +1059,10 @@ type ReplicationConfig struct {
 	LocationLabels typeutil.StringSlice `toml:"location-labels" json:"location-labels"`
 	// StrictlyMatchLabel strictly checks if the label of TiKV is matched with LocationLabels.
 	StrictlyMatchLabel bool `toml:"strictly-match-label" json:"strictly-match-label,string"`
+	// LocationLabelValues are the known values of each label key. A store label whose value
+	// is not known is reported as advice by the label lint, which catches typos like
+	// `zone=us-esat-1`. The store is not rejected for it.
+	LocationLabelValues map[string]typeutil.StringSlice `toml:"location-label-values" json:"location-label-values"`
 
 	// When PlacementRules feature is enabled. MaxReplicas, LocationLabels and IsolationLabels are not used any more.
 	EnablePlacementRules bool `toml:"enable-placement-rules" json:"enable-placement-rules,string"`
@@ -990,8 +1081,16 @@ type ReplicationConfig struct {
 // Clone makes a deep copy of the config.
 func (c *ReplicationConfig) Clone() *ReplicationConfig {
 	locationLabels := append(c.LocationLabels[:0:0], c.LocationLabels...)
+	var labelValues map[string]typeutil.StringSlice
+	if c.LocationLabelValues != nil {
+		labelValues = make(map[string]typeutil.StringSlice, len(c.LocationLabelValues))
+		for k, v := range c.LocationLabelValues {
+			labelValues[k] = append(v[:0:0], v...)
+		}
+	}
 	cfg := *c
 	cfg.LocationLabels = locationLabels
+	cfg.LocationLabelValues = labelValues
 	return &cfg
 }
 
@@ -1011,6 +1110,13 @@ func (c *ReplicationConfig) Validate() error {
 	if c.IsolationLevel != "" && !foundIsolationLevel {
 		return errors.New("isolation-level must be one of location-labels or empty")
 	}
+	for key, values := range c.LocationLabelValues {
+		for _, value := range values {
+			if err := ValidateLabels([]*metapb.StoreLabel{{Key: key, Value: value}}); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
@@ -1046,10 +1152,27 @@ type PDServerConfig struct {
 	DashboardAddress string `toml:"dashboard-address" json:"dashboard-address"`
 	// TraceRegionFlow the option to update flow information of regions
 	TraceRegionFlow bool `toml:"trace-region-flow" json:"trace-region-flow,string"`
+	// MaxClockDrift is the max clock drift allowed between the PD leader and
+	// other PD members or stores, a warning is raised when it is exceeded.
+	MaxClockDrift typeutil.Duration `toml:"max-clock-drift" json:"max-clock-drift"`
+	// ClockDriftWebhook is the URL which the clock drift alerts are posted to.
+	ClockDriftWebhook string `toml:"clock-drift-webhook" json:"clock-drift-webhook"`
+	// EnabledFeatures are the experimental features enabled in the cluster.
+	EnabledFeatures typeutil.StringSlice `toml:"enabled-features" json:"enabled-features"`
+	// RegionTopologyInterval is the interval to persist the snapshots of the
+	// region topology, 0 disables the snapshots.
+	RegionTopologyInterval typeutil.Duration `toml:"region-topology-interval" json:"region-topology-interval"`
+	// RegionTopologyRetention is how long the snapshots of the region topology
+	// are kept.
+	RegionTopologyRetention typeutil.Duration `toml:"region-topology-retention" json:"region-topology-retention"`
+	// LogSampling is the config of sampling the logs on the hot paths.
+	LogSampling logutil.LogSamplingConfig `toml:"log-sampling" json:"log-sampling"`
 }
 
 func (c *PDServerConfig) adjust(meta *configMetaData) error {
 	adjustDuration(&c.MaxResetTSGap, defaultMaxResetTSGap)
+	adjustDuration(&c.MaxClockDrift, defaultMaxClockDrift)
+	adjustDuration(&c.RegionTopologyRetention, defaultRegionTopologyRetention)
 	if !meta.IsDefined("use-region-storage") {
 		c.UseRegionStorage = defaultUseRegionStorage
 	}
@@ -1065,14 +1188,31 @@ func (c *PDServerConfig) adjust(meta *configMetaData) error {
 	if !meta.IsDefined("trace-region-flow") {
 		c.TraceRegionFlow = defaultTraceRegionFlow
 	}
+	if !meta.IsDefined("enabled-features") {
+		c.EnabledFeatures = defaultEnabledFeatures
+	}
+	c.adjustLogSampling(meta.Child("log-sampling"))
 	return c.Validate()
 }
 
+func (c *PDServerConfig) adjustLogSampling(meta *configMetaData) {
+	defaultCfg := logutil.DefaultLogSamplingConfig()
+	adjustDuration(&c.LogSampling.Interval, defaultCfg.Interval.Duration)
+	if !meta.IsDefined("initial") {
+		c.LogSampling.Initial = defaultCfg.Initial
+	}
+	if !meta.IsDefined("thereafter") {
+		c.LogSampling.Thereafter = defaultCfg.Thereafter
+	}
+}
+
 // Clone returns a cloned PD server config.
 func (c *PDServerConfig) Clone() *PDServerConfig {
 	runtimeServices := append(c.RuntimeServices[:0:0], c.RuntimeServices...)
+	enabledFeatures := append(c.EnabledFeatures[:0:0], c.EnabledFeatures...)
 	cfg := *c
 	cfg.RuntimeServices = runtimeServices
+	cfg.EnabledFeatures = enabledFeatures
 	return &cfg
 }
 
@@ -1086,6 +1226,18 @@ func (c *PDServerConfig) Validate() error {
 			return err
 		}
 	}
+	if c.ClockDriftWebhook != "" {
+		if err := ValidateURLWithScheme(c.ClockDriftWebhook); err != nil {
+			return err
+		}
+	}
+	warnUnknownFeatures(c.EnabledFeatures)
+	if err := c.LogSampling.Validate(); err != nil {
+		return errors.Annotate(err, "invalid log-sampling")
+	}
+	if c.RegionTopologyInterval.Duration != 0 && c.RegionTopologyInterval.Duration < minRegionTopologyInterval {
+		return errors.Errorf("region-topology-interval should be 0 or at least %s", minRegionTopologyInterval)
+	}
 
 	return nil
 }
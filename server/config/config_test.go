@@ -16,6 +16,7 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path"
 	"strings"
@@ -24,6 +25,7 @@ import (
 
 	"github.com/BurntSushi/toml"
 	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/kv"
 )
@@ -114,6 +116,78 @@ func (s *testConfigSuite) TestReloadUpgrade(c *C) {
 	c.Assert(newOpt.GetPDServerConfig().KeyType, Equals, defaultKeyType) // should be set to default value.
 }
 
+func (s *testConfigSuite) TestItemSources(c *C) {
+	filePath := path.Join(c.MkDir(), "pd.toml")
+	cfgData := `
+name = "pd-test"
+[schedule]
+max-merge-region-size = 30
+`
+	c.Assert(ioutil.WriteFile(filePath, []byte(cfgData), 0644), IsNil)
+	cfg := NewConfig()
+	c.Assert(cfg.Parse([]string{"-config", filePath, "-L", "warn"}), IsNil)
+	opt := NewPersistOptions(cfg)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	c.Assert(opt.Persist(storage), IsNil)
+
+	sources, err := opt.GetItemSources(cfg)
+	c.Assert(err, IsNil)
+	c.Assert(sources["schedule.max-merge-region-size"].Source, Equals, SourceFile)
+	c.Assert(sources["schedule.max-merge-region-size"].Value, Equals, 30.0)
+	c.Assert(sources["schedule.max-merge-region-size"].UpdateTime, IsNil)
+	c.Assert(sources["schedule.region-schedule-limit"].Source, Equals, SourceDefault)
+	c.Assert(sources["replication.max-replicas"].Source, Equals, SourceDefault)
+	// The items which are not persisted are reported from the config of the
+	// server.
+	c.Assert(sources["log.level"].Source, Equals, SourceFlag)
+	c.Assert(sources["log.level"].Value, Equals, "warn")
+	c.Assert(sources["name"].Source, Equals, SourceFile)
+	c.Assert(sources["lease"].Source, Equals, SourceDefault)
+
+	// The changed items are persisted with the time they are changed.
+	scheduleCfg := opt.GetScheduleConfig().Clone()
+	scheduleCfg.MaxMergeRegionSize = defaultMaxMergeRegionSize
+	scheduleCfg.RegionScheduleLimit = 100
+	opt.SetScheduleConfig(scheduleCfg)
+	start := time.Now()
+	c.Assert(opt.Persist(storage), IsNil)
+
+	newOpt := NewPersistOptions(NewConfig())
+	c.Assert(newOpt.Reload(storage), IsNil)
+	sources, err = newOpt.GetItemSources(cfg)
+	c.Assert(err, IsNil)
+	for _, key := range []string{"schedule.max-merge-region-size", "schedule.region-schedule-limit"} {
+		c.Assert(sources[key].Source, Equals, SourcePersisted)
+		c.Assert(sources[key].UpdateTime.Before(start), IsFalse)
+	}
+	c.Assert(sources["schedule.max-merge-region-size"].Value, Equals, float64(defaultMaxMergeRegionSize))
+	c.Assert(sources["schedule.region-schedule-limit"].Value, Equals, 100.0)
+	c.Assert(sources["schedule.max-snapshot-count"].Source, Equals, SourceDefault)
+	c.Assert(sources["schedule.max-snapshot-count"].UpdateTime, IsNil)
+}
+
+func (s *testConfigSuite) TestItemDiffs(c *C) {
+	cfg := NewConfig()
+	c.Assert(cfg.Adjust(nil), IsNil)
+	opt := NewPersistOptions(cfg)
+	diffs, err := opt.GetItemDiffs(cfg)
+	c.Assert(err, IsNil)
+	c.Assert(diffs, HasLen, 0)
+
+	scheduleCfg := opt.GetScheduleConfig().Clone()
+	scheduleCfg.RegionScheduleLimit = 100
+	opt.SetScheduleConfig(scheduleCfg)
+	c.Assert(opt.Persist(core.NewStorage(kv.NewMemoryKV())), IsNil)
+	diffs, err = opt.GetItemDiffs(cfg)
+	c.Assert(err, IsNil)
+	c.Assert(diffs, HasLen, 1)
+	diff := diffs["schedule.region-schedule-limit"]
+	c.Assert(diff.Value, Equals, 100.0)
+	c.Assert(diff.Default, Equals, float64(defaultRegionScheduleLimit))
+	c.Assert(diff.Source, Equals, SourcePersisted)
+	c.Assert(diff.UpdateTime, NotNil)
+}
+
 func (s *testConfigSuite) TestValidation(c *C) {
 	cfg := NewConfig()
 	c.Assert(cfg.Adjust(nil), IsNil)
@@ -134,6 +208,12 @@ func (s *testConfigSuite) TestValidation(c *C) {
 	c.Assert(cfg.Schedule.Validate(), IsNil)
 	cfg.Schedule.TolerantSizeRatio = -0.6
 	c.Assert(cfg.Schedule.Validate(), NotNil)
+	// check pd-server config
+	c.Assert(cfg.PDServerCfg.RegionTopologyRetention.Duration, Equals, defaultRegionTopologyRetention)
+	cfg.PDServerCfg.RegionTopologyInterval.Duration = time.Second
+	c.Assert(cfg.PDServerCfg.Validate(), NotNil)
+	cfg.PDServerCfg.RegionTopologyInterval.Duration = time.Hour
+	c.Assert(cfg.PDServerCfg.Validate(), IsNil)
 	// check quota
 	c.Assert(cfg.QuotaBackendBytes, Equals, defaultQuotaBackendBytes)
 }
@@ -362,6 +442,60 @@ dashboard-address = "foo"
 	}
 }
 
+func (s *testConfigSuite) TestLogSampling(c *C) {
+	cfg := NewConfig()
+	c.Assert(cfg.Adjust(nil), IsNil)
+	c.Assert(cfg.PDServerCfg.LogSampling, DeepEquals, logutil.DefaultLogSamplingConfig())
+	c.Assert(cfg.PDServerCfg.LogSampling.Enable, IsFalse)
+
+	cfgData := `
+[pd-server.log-sampling]
+enable = true
+initial = 0
+`
+	cfg = NewConfig()
+	meta, err := toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta), IsNil)
+	c.Assert(cfg.PDServerCfg.LogSampling.Enable, IsTrue)
+	c.Assert(cfg.PDServerCfg.LogSampling.Initial, Equals, uint64(0))
+	c.Assert(cfg.PDServerCfg.LogSampling.Thereafter, Equals, logutil.DefaultLogSamplingConfig().Thereafter)
+
+	cfgData = `
+[pd-server.log-sampling]
+enable = true
+thereafter = 0
+`
+	cfg = NewConfig()
+	meta, err = toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta), NotNil)
+}
+
+func (s *testConfigSuite) TestFeatures(c *C) {
+	cfg := NewConfig()
+	c.Assert(cfg.Adjust(nil), IsNil)
+	opt := NewPersistOptions(cfg)
+	// The hot region history is enabled by default.
+	c.Assert(opt.IsFeatureEnabled(FeatureHotRegionHistory), IsTrue)
+	features := opt.GetFeatures()
+	c.Assert(len(features), Greater, 0)
+	for _, f := range features {
+		c.Assert(f.Enabled, Equals, f.Name == FeatureHotRegionHistory)
+	}
+
+	// The unknown features are ignored.
+	pdServerCfg := opt.GetPDServerConfig().Clone()
+	pdServerCfg.EnabledFeatures = []string{"unknown-feature"}
+	c.Assert(pdServerCfg.Validate(), IsNil)
+	opt.SetPDServerConfig(pdServerCfg)
+	c.Assert(opt.IsFeatureEnabled(FeatureHotRegionHistory), IsFalse)
+	for _, f := range opt.GetFeatures() {
+		c.Assert(f.Enabled, IsFalse)
+	}
+	c.Assert(func() { RegisterFeature(FeatureHotRegionHistory, "") }, PanicMatches, ".*registered twice")
+}
+
 func (s *testConfigSuite) TestDashboardConfig(c *C) {
 	cfgData := `
 [dashboard]
@@ -0,0 +1,105 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+// The experimental features which are registered by PD itself.
+const (
+	// FeatureHotRegionHistory keeps the snapshots of the hot regions and
+	// serves them by `/hotspot/regions/history`.
+	FeatureHotRegionHistory = "hot-region-history"
+)
+
+// Feature is an experimental feature, it is enabled per cluster by adding its
+// name to `pd-server.enabled-features`, which only enables the hot region
+// history by default.
+type Feature struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+}
+
+var features = struct {
+	sync.RWMutex
+	descriptions map[string]string
+}{descriptions: make(map[string]string)}
+
+func init() {
+	RegisterFeature(FeatureHotRegionHistory, "keep the snapshots of the hot regions in the last 24 hours in the memory of the PD leader")
+}
+
+// RegisterFeature registers an experimental feature, so that it can be enabled
+// by the config. It panics if the name is registered twice.
+func RegisterFeature(name, description string) {
+	features.Lock()
+	defer features.Unlock()
+	if _, ok := features.descriptions[name]; ok {
+		panic("feature " + name + " is registered twice")
+	}
+	features.descriptions[name] = description
+}
+
+// IsFeatureRegistered returns whether the feature is registered.
+func IsFeatureRegistered(name string) bool {
+	features.RLock()
+	defer features.RUnlock()
+	_, ok := features.descriptions[name]
+	return ok
+}
+
+// warnUnknownFeatures logs the features which are not registered, they are
+// ignored rather than rejected, e.g. the ones enabled by a newer PD in a rolling
+// upgrade.
+func warnUnknownFeatures(names []string) {
+	for _, name := range names {
+		if !IsFeatureRegistered(name) {
+			log.Warn("unknown feature is ignored", zap.String("feature", name))
+		}
+	}
+}
+
+// GetFeatures returns the registered features sorted by names, and whether
+// they are enabled.
+func (o *PersistOptions) GetFeatures() []Feature {
+	enabled := make(map[string]struct{})
+	for _, name := range o.GetPDServerConfig().EnabledFeatures {
+		enabled[name] = struct{}{}
+	}
+	features.RLock()
+	defer features.RUnlock()
+	list := make([]Feature, 0, len(features.descriptions))
+	for name, description := range features.descriptions {
+		_, ok := enabled[name]
+		list = append(list, Feature{Name: name, Description: description, Enabled: ok})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// IsFeatureEnabled returns whether the experimental feature is enabled.
+func (o *PersistOptions) IsFeatureEnabled(name string) bool {
+	for _, n := range o.GetPDServerConfig().EnabledFeatures {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 	"unsafe"
@@ -26,6 +27,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/cache"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/etcdutil"
 	"github.com/tikv/pd/pkg/slice"
 	"github.com/tikv/pd/pkg/typeutil"
@@ -45,6 +47,7 @@ type PersistOptions struct {
 	replicationMode atomic.Value
 	labelProperty   atomic.Value
 	clusterVersion  unsafe.Pointer
+	changes         changeRecorder
 }
 
 // NewPersistOptions creates a new PersistOptions instance.
@@ -56,6 +59,7 @@ func NewPersistOptions(cfg *Config) *PersistOptions {
 	o.replicationMode.Store(&cfg.ReplicationMode)
 	o.labelProperty.Store(cfg.LabelProperty)
 	o.SetClusterVersion(&cfg.ClusterVersion)
+	o.changes.reset(cfg, nil)
 	o.ttl = nil
 	return o
 }
@@ -147,6 +151,11 @@ func (o *PersistOptions) SetPlacementRuleEnabled(enabled bool) {
 	o.SetReplicationConfig(v)
 }
 
+// GetLocationLabelValues returns the known values of each label key.
+func (o *PersistOptions) GetLocationLabelValues() map[string]typeutil.StringSlice {
+	return o.GetReplicationConfig().LocationLabelValues
+}
+
 // GetStrictlyMatchLabel returns whether check label strict.
 func (o *PersistOptions) GetStrictlyMatchLabel() bool {
 	return o.GetReplicationConfig().StrictlyMatchLabel
@@ -187,11 +196,39 @@ func (o *PersistOptions) GetMaxPendingPeerCount() uint64 {
 	return o.getTTLUintOr(maxPendingPeerCountKey, o.GetScheduleConfig().MaxPendingPeerCount)
 }
 
+// GetStoreMaxSnapshotCount returns the number of the max snapshot of the store
+// with the labels, the limit of its store class takes effect if any.
+func (o *PersistOptions) GetStoreMaxSnapshotCount(labels []*metapb.StoreLabel) uint64 {
+	if limit := o.GetScheduleConfig().GetStoreClassLimit(labels); limit != nil && limit.MaxSnapshotCount > 0 {
+		return limit.MaxSnapshotCount
+	}
+	return o.GetMaxSnapshotCount()
+}
+
+// GetStoreMaxPendingPeerCount returns the number of the max pending peers of
+// the store with the labels, the limit of its store class takes effect if any.
+func (o *PersistOptions) GetStoreMaxPendingPeerCount(labels []*metapb.StoreLabel) uint64 {
+	if limit := o.GetScheduleConfig().GetStoreClassLimit(labels); limit != nil && limit.MaxPendingPeerCount > 0 {
+		return limit.MaxPendingPeerCount
+	}
+	return o.GetMaxPendingPeerCount()
+}
+
 // GetMaxMergeRegionSize returns the max region size.
 func (o *PersistOptions) GetMaxMergeRegionSize() uint64 {
 	return o.getTTLUintOr(maxMergeRegionSizeKey, o.GetScheduleConfig().MaxMergeRegionSize)
 }
 
+// GetRegionMaxSize returns the size (MB) of the oversized regions.
+func (o *PersistOptions) GetRegionMaxSize() uint64 {
+	return o.GetScheduleConfig().RegionMaxSize
+}
+
+// GetRegionMaxKeys returns the number of keys of the oversized regions.
+func (o *PersistOptions) GetRegionMaxKeys() uint64 {
+	return o.GetScheduleConfig().RegionMaxKeys
+}
+
 // GetMaxMergeRegionKeys returns the max number of keys.
 func (o *PersistOptions) GetMaxMergeRegionKeys() uint64 {
 	return o.getTTLUintOr(maxMergeRegionKeysKey, o.GetScheduleConfig().MaxMergeRegionKeys)
@@ -395,6 +432,11 @@ func (o *PersistOptions) GetLeaderSchedulePolicy() core.SchedulePolicy {
 	return core.StringToSchedulePolicy(o.GetScheduleConfig().LeaderSchedulePolicy)
 }
 
+// GetStoreIOCapacity returns the disk IO capacity per second of a store.
+func (o *PersistOptions) GetStoreIOCapacity() uint64 {
+	return uint64(o.GetScheduleConfig().StoreIOCapacity)
+}
+
 // GetKeyType is to get key type.
 func (o *PersistOptions) GetKeyType() core.KeyType {
 	return core.StringToKeyType(o.GetPDServerConfig().KeyType)
@@ -405,11 +447,33 @@ func (o *PersistOptions) GetMaxResetTSGap() time.Duration {
 	return o.GetPDServerConfig().MaxResetTSGap.Duration
 }
 
+// GetMaxClockDrift gets the max clock drift allowed between PD and other components.
+func (o *PersistOptions) GetMaxClockDrift() time.Duration {
+	return o.GetPDServerConfig().MaxClockDrift.Duration
+}
+
+// GetClockDriftWebhook gets the URL which the clock drift alerts are posted to.
+func (o *PersistOptions) GetClockDriftWebhook() string {
+	return o.GetPDServerConfig().ClockDriftWebhook
+}
+
 // GetDashboardAddress gets dashboard address.
 func (o *PersistOptions) GetDashboardAddress() string {
 	return o.GetPDServerConfig().DashboardAddress
 }
 
+// GetRegionTopologyInterval returns the interval to persist the snapshots of
+// the region topology.
+func (o *PersistOptions) GetRegionTopologyInterval() time.Duration {
+	return o.GetPDServerConfig().RegionTopologyInterval.Duration
+}
+
+// GetRegionTopologyRetention returns how long the snapshots of the region
+// topology are kept.
+func (o *PersistOptions) GetRegionTopologyRetention() time.Duration {
+	return o.GetPDServerConfig().RegionTopologyRetention.Duration
+}
+
 // IsUseRegionStorage returns if the independent region storage is enabled.
 func (o *PersistOptions) IsUseRegionStorage() bool {
 	return o.GetPDServerConfig().UseRegionStorage
@@ -462,6 +526,11 @@ func (o *PersistOptions) GetHotRegionCacheHitsThreshold() int {
 	return int(o.GetScheduleConfig().HotRegionCacheHitsThreshold)
 }
 
+// GetHotRegionCacheMemoryBudget returns the memory budget of the hot region cache in bytes.
+func (o *PersistOptions) GetHotRegionCacheMemoryBudget() uint64 {
+	return o.GetScheduleConfig().HotRegionCacheMemoryBudget * (1 << 20)
+}
+
 // GetSchedulers gets the scheduler configurations.
 func (o *PersistOptions) GetSchedulers() SchedulerConfigs {
 	return o.GetScheduleConfig().Schedulers
@@ -520,7 +589,11 @@ func (o *PersistOptions) DeleteLabelProperty(typ, labelKey, labelValue string) {
 
 // Persist saves the configuration to the storage.
 func (o *PersistOptions) Persist(storage *core.Storage) error {
-	cfg := &Config{
+	return o.changes.persist(o.persistedConfig(), storage)
+}
+
+func (o *PersistOptions) persistedConfig() *Config {
+	return &Config{
 		Schedule:        *o.GetScheduleConfig(),
 		Replication:     *o.GetReplicationConfig(),
 		PDServerCfg:     *o.GetPDServerConfig(),
@@ -528,7 +601,6 @@ func (o *PersistOptions) Persist(storage *core.Storage) error {
 		LabelProperty:   o.GetLabelPropertyConfig(),
 		ClusterVersion:  *o.GetClusterVersion(),
 	}
-	return storage.SaveConfig(cfg)
 }
 
 // Reload reloads the configuration from the storage.
@@ -550,6 +622,11 @@ func (o *PersistOptions) Reload(storage *core.Storage) error {
 		o.labelProperty.Store(cfg.LabelProperty)
 		o.SetClusterVersion(&cfg.ClusterVersion)
 	}
+	var times map[string]time.Time
+	if _, err := storage.LoadConfigChangeTimes(&times); err != nil {
+		return err
+	}
+	o.changes.reset(o.persistedConfig(), times)
 	return nil
 }
 
@@ -593,6 +670,86 @@ func (o *PersistOptions) SetTTLData(parCtx context.Context, client *clientv3.Cli
 	return nil
 }
 
+// SetTTLDataBatch sets the temporary configuration items under one lease in a
+// transaction, so either all of them are set or none.
+func (o *PersistOptions) SetTTLDataBatch(parCtx context.Context, client *clientv3.Client, data map[string]string, ttl time.Duration) error {
+	if o.ttl == nil {
+		o.ttl = cache.NewStringTTL(parCtx, time.Second*5, time.Minute*5)
+	}
+	grantResp, err := client.Grant(parCtx, int64(ttl.Seconds()))
+	if err != nil {
+		return errs.ErrEtcdGrantLease.Wrap(err).GenWithStackByCause()
+	}
+	ops := make([]clientv3.Op, 0, len(data))
+	for k, v := range data {
+		ops = append(ops, clientv3.OpPut(ttlConfigPrefix+"/"+k, v, clientv3.WithLease(grantResp.ID)))
+	}
+	if _, err := client.Txn(parCtx).Then(ops...).Commit(); err != nil {
+		return errs.ErrEtcdTxn.Wrap(err).GenWithStackByCause()
+	}
+	for k, v := range data {
+		o.ttl.PutWithTTL(k, v, ttl)
+	}
+	return nil
+}
+
+// RemoveTTLDataBatch removes the temporary configuration items before they
+// expire in a transaction. An item is removed only if it still has the given
+// value, the ones changed by others since then are kept.
+func (o *PersistOptions) RemoveTTLDataBatch(parCtx context.Context, client *clientv3.Client, data map[string]string) error {
+	keys := make([]string, 0, len(data))
+	ops := make([]clientv3.Op, 0, len(data))
+	for k, v := range data {
+		key := ttlConfigPrefix + "/" + k
+		keys = append(keys, k)
+		ops = append(ops, clientv3.OpTxn(
+			[]clientv3.Cmp{clientv3.Compare(clientv3.Value(key), "=", v)},
+			[]clientv3.Op{clientv3.OpDelete(key)},
+			nil))
+	}
+	resp, err := client.Txn(parCtx).Then(ops...).Commit()
+	if err != nil {
+		return errs.ErrEtcdTxn.Wrap(err).GenWithStackByCause()
+	}
+	if o.ttl == nil {
+		return nil
+	}
+	for i, r := range resp.Responses {
+		if r.GetResponseTxn().GetSucceeded() {
+			o.ttl.Remove(keys[i])
+		}
+	}
+	return nil
+}
+
+// StoreConfigHintPrefix is the key prefix of the temporary configuration items
+// which are the config hints of all stores, like the flow control hints in the
+// import mode.
+const StoreConfigHintPrefix = "store-config."
+
+// GetStoreConfigHints returns the config hints of all stores in the temporary
+// configuration, the keys are without StoreConfigHintPrefix.
+func (o *PersistOptions) GetStoreConfigHints() map[string]string {
+	hints := make(map[string]string)
+	if o.ttl == nil {
+		return hints
+	}
+	for _, k := range o.ttl.GetAllKeys() {
+		if !strings.HasPrefix(k, StoreConfigHintPrefix) {
+			continue
+		}
+		if v, ok := o.getTTLData(k); ok {
+			hints[strings.TrimPrefix(k, StoreConfigHintPrefix)] = v
+		}
+	}
+	return hints
+}
+
+// GetTTLData returns the temporary configuration if it is not expired.
+func (o *PersistOptions) GetTTLData(key string) (string, bool) {
+	return o.getTTLData(key)
+}
+
 func (o *PersistOptions) getTTLUint(key string) (uint64, bool, error) {
 	stringForm, ok := o.getTTLData(key)
 	if !ok {
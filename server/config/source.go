@@ -0,0 +1,269 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tikv/pd/server/core"
+)
+
+// The sources of the persisted config items.
+const (
+	// SourceDefault means the item is not changed from its default value.
+	SourceDefault = "default"
+	// SourceFile means the item is set by the config file when the cluster
+	// is bootstrapped, and not changed since then.
+	SourceFile = "file"
+	// SourcePersisted means the item is changed by the API or pd-ctl.
+	SourcePersisted = "persisted"
+	// SourceTTL means the item is overridden temporarily until its TTL expires.
+	SourceTTL = "ttl"
+	// SourceFlag means the item is set by the command line flag of the server.
+	SourceFlag = "flag"
+)
+
+// flagItems maps the command line flags of the server to the config items they
+// set, the flags which are not config items, like -config, are not included.
+var flagItems = map[string]string{
+	"name":                  "name",
+	"data-dir":              "data-dir",
+	"client-urls":           "client-urls",
+	"advertise-client-urls": "advertise-client-urls",
+	"peer-urls":             "peer-urls",
+	"advertise-peer-urls":   "advertise-peer-urls",
+	"initial-cluster":       "initial-cluster",
+	"join":                  "join",
+	"metrics-addr":          "metric.address",
+	"L":                     "log.level",
+	"log-file":              "log.file.filename",
+	"cacert":                "security.cacert-path",
+	"cert":                  "security.cert-path",
+	"key":                   "security.key-path",
+	"force-new-cluster":     "force-new-cluster",
+}
+
+// persistedSections are the sections of Config which are persisted.
+var persistedSections = []string{"schedule", "replication", "pd-server", "replication-mode", "label-property", "cluster-version"}
+
+// ItemSource is the value of a config item and where the value comes from.
+type ItemSource struct {
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+	// UpdateTime is the last time the item is changed, it is nil if the item
+	// is not changed since the cluster is bootstrapped.
+	UpdateTime *time.Time `json:"update-time,omitempty"`
+}
+
+// FlattenConfig flattens the config into items, the key of an item is the
+// path of json tags like "schedule.max-merge-region-size".
+func FlattenConfig(cfg interface{}) map[string]interface{} {
+	data, _ := json.Marshal(cfg)
+	m := make(map[string]interface{})
+	_ = json.Unmarshal(data, &m)
+	items := make(map[string]interface{})
+	var flatten func(prefix string, v interface{})
+	flatten = func(prefix string, v interface{}) {
+		if sub, ok := v.(map[string]interface{}); ok {
+			for k, v := range sub {
+				flatten(prefix+"."+k, v)
+			}
+			return
+		}
+		items[prefix] = v
+	}
+	for k, v := range m {
+		flatten(k, v)
+	}
+	return items
+}
+
+func persistedItems(cfg *Config) map[string]interface{} {
+	items := FlattenConfig(cfg)
+	for key := range items {
+		section := strings.SplitN(key, ".", 2)[0]
+		if !isPersistedSection(section) {
+			delete(items, key)
+		}
+	}
+	return items
+}
+
+func isPersistedSection(section string) bool {
+	for _, s := range persistedSections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// changeRecorder records the last time each persisted item is changed.
+type changeRecorder struct {
+	sync.Mutex
+	// items are the persisted items, they are compared with the new ones to
+	// find the changed items.
+	items map[string]interface{}
+	times map[string]time.Time
+}
+
+func (r *changeRecorder) reset(cfg *Config, times map[string]time.Time) {
+	r.Lock()
+	defer r.Unlock()
+	r.items = persistedItems(cfg)
+	r.times = times
+}
+
+// persist saves the config to the storage, and records the time of the items
+// which are changed since last saved.
+func (r *changeRecorder) persist(cfg *Config, storage *core.Storage) error {
+	r.Lock()
+	defer r.Unlock()
+	items := persistedItems(cfg)
+	now := time.Now()
+	times := make(map[string]time.Time, len(r.times))
+	for key, t := range r.times {
+		times[key] = t
+	}
+	var changed bool
+	for key, value := range items {
+		if !reflect.DeepEqual(r.items[key], value) {
+			times[key], changed = now, true
+		}
+	}
+	if err := storage.SaveConfig(cfg); err != nil {
+		return err
+	}
+	r.items = items
+	if !changed {
+		return nil
+	}
+	r.times = times
+	return storage.SaveConfigChangeTimes(times)
+}
+
+func (r *changeRecorder) get(key string) (time.Time, bool) {
+	r.Lock()
+	defer r.Unlock()
+	t, ok := r.times[key]
+	return t, ok
+}
+
+// ItemDiff is a config item whose value differs from the default value.
+type ItemDiff struct {
+	ItemSource
+	Default interface{} `json:"default"`
+}
+
+func defaultPersistedItems() (map[string]interface{}, error) {
+	defaultCfg := NewConfig()
+	if err := defaultCfg.Adjust(nil); err != nil {
+		return nil, err
+	}
+	return persistedItems(defaultCfg), nil
+}
+
+// GetItemSources returns the config items with their sources. The cfg is the
+// config which the server is started with, the items which are not persisted,
+// like log.level, are reported from it.
+func (o *PersistOptions) GetItemSources(cfg *Config) (map[string]*ItemSource, error) {
+	defaults, err := defaultPersistedItems()
+	if err != nil {
+		return nil, err
+	}
+	items := persistedItems(o.persistedConfig())
+	sources := make(map[string]*ItemSource, len(items))
+	for key, value := range items {
+		source := &ItemSource{Value: value}
+		if t, ok := o.changes.get(key); ok {
+			source.UpdateTime = &t
+		}
+		ttlValue, isTTL := o.getTTLData(key)
+		switch {
+		case isTTL:
+			source.Value, source.Source = ttlValue, SourceTTL
+		case source.UpdateTime != nil:
+			source.Source = SourcePersisted
+		case reflect.DeepEqual(value, defaults[key]):
+			source.Source = SourceDefault
+		case cfg.isSetByFlag(key):
+			source.Source = SourceFlag
+		case cfg.isDefinedInFile(key):
+			source.Source = SourceFile
+		default:
+			// The item may be set by the config file of another PD which
+			// bootstraps the cluster.
+			source.Source = SourcePersisted
+		}
+		sources[key] = source
+	}
+	for key, value := range FlattenConfig(cfg) {
+		if isPersistedSection(strings.SplitN(key, ".", 2)[0]) {
+			continue
+		}
+		source := &ItemSource{Value: value, Source: SourceDefault}
+		if cfg.isSetByFlag(key) {
+			source.Source = SourceFlag
+		} else if cfg.isDefinedInFile(key) {
+			source.Source = SourceFile
+		}
+		sources[key] = source
+	}
+	return sources, nil
+}
+
+// GetItemDiffs returns the persisted config items whose values differ from
+// the default values, the values overridden by TTL are compared instead of the
+// persisted ones.
+func (o *PersistOptions) GetItemDiffs(cfg *Config) (map[string]*ItemDiff, error) {
+	defaults, err := defaultPersistedItems()
+	if err != nil {
+		return nil, err
+	}
+	sources, err := o.GetItemSources(cfg)
+	if err != nil {
+		return nil, err
+	}
+	diffs := make(map[string]*ItemDiff)
+	for key, source := range sources {
+		if _, ok := defaults[key]; !ok {
+			continue
+		}
+		equal := reflect.DeepEqual(source.Value, defaults[key])
+		// The values overridden by TTL are kept as strings.
+		if v, ok := source.Value.(string); ok && source.Source == SourceTTL {
+			equal = v == fmt.Sprint(defaults[key])
+		}
+		if equal {
+			continue
+		}
+		diffs[key] = &ItemDiff{ItemSource: *source, Default: defaults[key]}
+	}
+	return diffs, nil
+}
+
+func (c *Config) isDefinedInFile(key string) bool {
+	_, ok := c.fileItems[key]
+	return ok
+}
+
+func (c *Config) isSetByFlag(key string) bool {
+	_, ok := c.flagItems[key]
+	return ok
+}
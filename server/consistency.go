@@ -0,0 +1,91 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sort"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/cluster"
+	"go.uber.org/zap"
+)
+
+// CheckConsistency cross-checks the metadata of the cluster and the PD members.
+func (s *Server) CheckConsistency() (*cluster.ConsistencyReport, error) {
+	rc := s.GetRaftCluster()
+	if rc == nil {
+		return nil, errs.ErrNotBootstrapped.GenWithStackByArgs()
+	}
+	report, err := rc.CheckConsistency()
+	if err != nil {
+		return nil, err
+	}
+	if report.OrphanedMembers, err = s.getOrphanedMembers(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// CleanupInconsistency cleans up the inconsistency of the cluster, and removes
+// the metadata of the PD members which are removed from the cluster.
+func (s *Server) CleanupInconsistency() (*cluster.ConsistencyReport, error) {
+	rc := s.GetRaftCluster()
+	if rc == nil {
+		return nil, errs.ErrNotBootstrapped.GenWithStackByArgs()
+	}
+	cleaned, err := rc.CleanupInconsistency()
+	if err != nil {
+		return cleaned, err
+	}
+	// The members are listed again so a member which is added since the
+	// check is not treated as an orphan.
+	orphaned, err := s.getOrphanedMembers()
+	if err != nil {
+		return cleaned, err
+	}
+	for _, id := range orphaned {
+		if err := s.member.DeleteMemberMeta(id); err != nil {
+			return cleaned, err
+		}
+		cleaned.OrphanedMembers = append(cleaned.OrphanedMembers, id)
+		log.Info("metadata of removed member is deleted", zap.Uint64("member-id", id))
+	}
+	return cleaned, nil
+}
+
+// getOrphanedMembers returns the IDs of the members which have the metadata
+// saved but are not in the cluster.
+func (s *Server) getOrphanedMembers() ([]uint64, error) {
+	members, err := cluster.GetMembers(s.client)
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[uint64]struct{}, len(members))
+	for _, member := range members {
+		exists[member.GetMemberId()] = struct{}{}
+	}
+	ids, err := s.member.GetMemberMetaIDs()
+	if err != nil {
+		return nil, err
+	}
+	orphaned := []uint64{}
+	for _, id := range ids {
+		if _, ok := exists[id]; !ok {
+			orphaned = append(orphaned, id)
+		}
+	}
+	sort.Slice(orphaned, func(i, j int) bool { return orphaned[i] < orphaned[j] })
+	return orphaned, nil
+}
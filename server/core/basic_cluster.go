@@ -61,6 +61,13 @@ func (bc *BasicCluster) GetStore(storeID uint64) *StoreInfo {
 	return bc.Stores.GetStore(storeID)
 }
 
+// GetStoreByAddress searches for a store by its address.
+func (bc *BasicCluster) GetStoreByAddress(address string) *StoreInfo {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Stores.GetStoreByAddress(address)
+}
+
 // GetRegion searches for a region by ID.
 func (bc *BasicCluster) GetRegion(regionID uint64) *RegionInfo {
 	bc.RLock()
@@ -89,6 +96,27 @@ func (bc *BasicCluster) GetStoreRegions(storeID uint64) []*RegionInfo {
 	return bc.Regions.GetStoreRegions(storeID)
 }
 
+// GetStoreLeaderRegions gets all RegionInfo whose leader is on the given store.
+func (bc *BasicCluster) GetStoreLeaderRegions(storeID uint64) []*RegionInfo {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.GetStoreLeaderRegions(storeID)
+}
+
+// GetStoreFollowerRegions gets all RegionInfo which has a follower on the given store.
+func (bc *BasicCluster) GetStoreFollowerRegions(storeID uint64) []*RegionInfo {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.GetStoreFollowerRegions(storeID)
+}
+
+// GetStoreLearnerRegions gets all RegionInfo which has a learner on the given store.
+func (bc *BasicCluster) GetStoreLearnerRegions(storeID uint64) []*RegionInfo {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.GetStoreLearnerRegions(storeID)
+}
+
 // GetRegionStores returns all Stores that contains the region's peer.
 func (bc *BasicCluster) GetRegionStores(region *RegionInfo) []*StoreInfo {
 	bc.RLock()
@@ -153,10 +181,10 @@ func (bc *BasicCluster) AttachAvailableFunc(storeID uint64, limitType storelimit
 }
 
 // UpdateStoreStatus updates the information of the store.
-func (bc *BasicCluster) UpdateStoreStatus(storeID uint64, leaderCount int, regionCount int, pendingPeerCount int, leaderSize int64, regionSize int64) {
+func (bc *BasicCluster) UpdateStoreStatus(storeID uint64, leaderCount int, regionCount int, pendingPeerCount int, downPeerCount int, leaderSize int64, regionSize int64) {
 	bc.Lock()
 	defer bc.Unlock()
-	bc.Stores.UpdateStoreStatus(storeID, leaderCount, regionCount, pendingPeerCount, leaderSize, regionSize)
+	bc.Stores.UpdateStoreStatus(storeID, leaderCount, regionCount, pendingPeerCount, downPeerCount, leaderSize, regionSize)
 }
 
 const randomRegionMaxRetry = 10
@@ -247,6 +275,13 @@ func (bc *BasicCluster) GetStorePendingPeerCount(storeID uint64) int {
 	return bc.Regions.GetStorePendingPeerCount(storeID)
 }
 
+// GetStoreDownPeerCount gets the total count of a store's down peers.
+func (bc *BasicCluster) GetStoreDownPeerCount(storeID uint64) int {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.GetStoreDownPeerCount(storeID)
+}
+
 // GetStoreLeaderRegionSize get total size of store's leader regions.
 func (bc *BasicCluster) GetStoreLeaderRegionSize(storeID uint64) int64 {
 	bc.RLock()
@@ -66,6 +66,8 @@ const (
 	ByCount SchedulePolicy = iota
 	// BySize indicates that balance by size
 	BySize
+	// ByUtilization indicates that balance by count weighted by the utilization of stores
+	ByUtilization
 )
 
 func (k SchedulePolicy) String() string {
@@ -74,6 +76,8 @@ func (k SchedulePolicy) String() string {
 		return "count"
 	case BySize:
 		return "size"
+	case ByUtilization:
+		return "utilization"
 	default:
 		return "unknown"
 	}
@@ -86,6 +90,8 @@ func StringToSchedulePolicy(input string) SchedulePolicy {
 		return BySize
 	case ByCount.String():
 		return ByCount
+	case ByUtilization.String():
+		return ByUtilization
 	default:
 		panic("invalid schedule policy: " + input)
 	}
@@ -352,6 +352,17 @@ func (r *RegionInfo) GetApproximateKeys() int64 {
 	return r.approximateKeys
 }
 
+// IsOversized returns true if the size or keys of the region reaches the limit.
+func (r *RegionInfo) IsOversized(maxSize, maxKeys int64) bool {
+	return r.approximateSize >= maxSize || r.approximateKeys >= maxKeys
+}
+
+// NeedMerge returns true if both the size and keys of the region are small
+// enough to be merged.
+func (r *RegionInfo) NeedMerge(mergeSize, mergeKeys int64) bool {
+	return r.approximateSize <= mergeSize && r.approximateKeys <= mergeKeys
+}
+
 // GetInterval returns the interval information of the region.
 func (r *RegionInfo) GetInterval() *pdpb.TimeInterval {
 	return r.interval
@@ -566,6 +577,7 @@ type RegionsInfo struct {
 	followers    map[uint64]*regionSubTree // storeID -> regionSubTree
 	learners     map[uint64]*regionSubTree // storeID -> regionSubTree
 	pendingPeers map[uint64]*regionSubTree // storeID -> regionSubTree
+	downPeers    map[uint64]int            // storeID -> count of down peers
 }
 
 // NewRegionsInfo creates RegionsInfo with tree, regions, leaders and followers
@@ -577,6 +589,7 @@ func NewRegionsInfo() *RegionsInfo {
 		followers:    make(map[uint64]*regionSubTree),
 		learners:     make(map[uint64]*regionSubTree),
 		pendingPeers: make(map[uint64]*regionSubTree),
+		downPeers:    make(map[uint64]int),
 	}
 }
 
@@ -642,7 +655,11 @@ func (r *RegionsInfo) AddRegion(region *RegionInfo) []*RegionInfo {
 		}
 	}
 	// Add to regions.
+	if origin := r.GetRegion(region.GetID()); origin != nil {
+		r.updateDownPeers(origin, -1)
+	}
 	r.regions.Put(region)
+	r.updateDownPeers(region, 1)
 
 	// Add to leaders and followers.
 	for _, peer := range region.GetVoters() {
@@ -700,11 +717,25 @@ func (r *RegionsInfo) RemoveRegion(region *RegionInfo) {
 
 // removeRegionFromTreeAndMap removes RegionInfo from regionTree and regionMap
 func (r *RegionsInfo) removeRegionFromTreeAndMap(region *RegionInfo) {
+	if origin := r.GetRegion(region.GetID()); origin != nil {
+		r.updateDownPeers(origin, -1)
+	}
 	// Remove from tree and regions.
 	r.tree.remove(region)
 	r.regions.Delete(region.GetID())
 }
 
+// updateDownPeers adds delta to the down peer count of the stores which the
+// down peers of the region are on.
+func (r *RegionsInfo) updateDownPeers(region *RegionInfo, delta int) {
+	for _, peer := range region.GetDownPeers() {
+		storeID := peer.GetPeer().GetStoreId()
+		if r.downPeers[storeID] += delta; r.downPeers[storeID] <= 0 {
+			delete(r.downPeers, storeID)
+		}
+	}
+}
+
 // removeRegionFromSubTree removes RegionInfo from regionSubTrees
 func (r *RegionsInfo) removeRegionFromSubTree(region *RegionInfo) {
 	// Remove from leaders and followers.
@@ -795,6 +826,30 @@ func (r *RegionsInfo) GetStoreRegions(storeID uint64) []*RegionInfo {
 	return regions
 }
 
+// GetStoreLeaderRegions gets all RegionInfo whose leader is on the given store
+func (r *RegionsInfo) GetStoreLeaderRegions(storeID uint64) []*RegionInfo {
+	if leaders, ok := r.leaders[storeID]; ok {
+		return leaders.scanRanges()
+	}
+	return nil
+}
+
+// GetStoreFollowerRegions gets all RegionInfo which has a follower on the given store
+func (r *RegionsInfo) GetStoreFollowerRegions(storeID uint64) []*RegionInfo {
+	if followers, ok := r.followers[storeID]; ok {
+		return followers.scanRanges()
+	}
+	return nil
+}
+
+// GetStoreLearnerRegions gets all RegionInfo which has a learner on the given store
+func (r *RegionsInfo) GetStoreLearnerRegions(storeID uint64) []*RegionInfo {
+	if learners, ok := r.learners[storeID]; ok {
+		return learners.scanRanges()
+	}
+	return nil
+}
+
 // GetStoreLeaderRegionSize get total size of store's leader regions
 func (r *RegionsInfo) GetStoreLeaderRegionSize(storeID uint64) int64 {
 	return r.leaders[storeID].TotalSize()
@@ -839,6 +894,11 @@ func (r *RegionsInfo) GetStorePendingPeerCount(storeID uint64) int {
 	return r.pendingPeers[storeID].length()
 }
 
+// GetStoreDownPeerCount gets the total count of a store's down peers
+func (r *RegionsInfo) GetStoreDownPeerCount(storeID uint64) int {
+	return r.downPeers[storeID]
+}
+
 // GetStoreLeaderCount get the total count of a store's leader RegionInfo
 func (r *RegionsInfo) GetStoreLeaderCount(storeID uint64) int {
 	return r.leaders[storeID].length()
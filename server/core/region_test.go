@@ -22,6 +22,7 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/mock/mockid"
 	"github.com/tikv/pd/server/id"
 )
@@ -157,12 +158,15 @@ func (*testRegionKey) TestSetRegion(c *C) {
 	}, peer1)
 	region.learners = append(region.learners, peer2)
 	region.pendingPeers = append(region.pendingPeers, peer3)
+	region.downPeers = append(region.downPeers, &pdpb.PeerStats{Peer: peer3, DownSeconds: 60})
 	regions.SetRegion(region)
 	checkRegions(c, regions)
 	c.Assert(regions.tree.length(), Equals, 97)
 	c.Assert(len(regions.GetRegions()), Equals, 97)
+	c.Assert(regions.GetStoreDownPeerCount(1), Equals, 1)
 
 	regions.SetRegion(region)
+	c.Assert(regions.GetStoreDownPeerCount(1), Equals, 1)
 	peer1 = &metapb.Peer{StoreId: uint64(2), Id: uint64(101)}
 	peer2 = &metapb.Peer{StoreId: uint64(3), Id: uint64(102)}
 	peer3 = &metapb.Peer{StoreId: uint64(1), Id: uint64(103)}
@@ -250,6 +254,7 @@ func checkRegions(c *C, regions *RegionsInfo) {
 	followerMap := make(map[uint64]uint64)
 	learnerMap := make(map[uint64]uint64)
 	pendingPeerMap := make(map[uint64]uint64)
+	downPeerMap := make(map[uint64]int)
 	for _, item := range regions.GetRegions() {
 		if leaderCount, ok := leaderMap[item.leader.StoreId]; ok {
 			leaderMap[item.leader.StoreId] = leaderCount + 1
@@ -277,6 +282,9 @@ func checkRegions(c *C, regions *RegionsInfo) {
 				pendingPeerMap[pendingPeer.StoreId] = 1
 			}
 		}
+		for _, downPeer := range item.GetDownPeers() {
+			downPeerMap[downPeer.GetPeer().GetStoreId()]++
+		}
 	}
 	for key, value := range regions.leaders {
 		c.Assert(value.length(), Equals, int(leaderMap[key]))
@@ -290,6 +298,7 @@ func checkRegions(c *C, regions *RegionsInfo) {
 	for key, value := range regions.pendingPeers {
 		c.Assert(value.length(), Equals, int(pendingPeerMap[key]))
 	}
+	c.Assert(regions.downPeers, DeepEquals, downPeerMap)
 }
 
 func BenchmarkRandomRegion(b *testing.B) {
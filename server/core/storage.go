@@ -37,14 +37,22 @@ import (
 const (
 	clusterPath                = "raft"
 	configPath                 = "config"
+	configChangeTimePath       = "config_change_time"
 	schedulePath               = "schedule"
 	gcPath                     = "gc"
 	rulesPath                  = "rules"
 	ruleGroupPath              = "rule_group"
+	leaderAntiAffinityPath     = "rule_leader_anti_affinity"
 	replicationPath            = "replication_mode"
 	componentPath              = "component"
 	customScheduleConfigPath   = "scheduler_config"
 	encryptionKeysPath         = "encryption_keys"
+	operatorAuditPath          = "operator_audit"
+	storeConfigPath            = "store_config"
+	regionTopologyPath         = "region_topology"
+	regionTopologyMetaPath     = "region_topology_meta"
+	regionLabelPath            = "region_label"
+	gcRangePath                = "gc_range"
 	gcWorkerServiceSafePointID = "gc_worker"
 )
 
@@ -256,6 +264,31 @@ func (s *Storage) LoadConfig(cfg interface{}) (bool, error) {
 	return true, nil
 }
 
+// SaveConfigChangeTimes stores the last change time of each config item.
+func (s *Storage) SaveConfigChangeTimes(times interface{}) error {
+	value, err := json.Marshal(times)
+	if err != nil {
+		return errs.ErrJSONMarshal.Wrap(err).GenWithStackByCause()
+	}
+	return s.Save(configChangeTimePath, string(value))
+}
+
+// LoadConfigChangeTimes loads the last change time of each config item.
+func (s *Storage) LoadConfigChangeTimes(times interface{}) (bool, error) {
+	value, err := s.Load(configChangeTimePath)
+	if err != nil {
+		return false, err
+	}
+	if value == "" {
+		return false, nil
+	}
+	err = json.Unmarshal([]byte(value), times)
+	if err != nil {
+		return false, errs.ErrJSONUnmarshal.Wrap(err).GenWithStackByCause()
+	}
+	return true, nil
+}
+
 // SaveRule stores a rule cfg to the rulesPath.
 func (s *Storage) SaveRule(ruleKey string, rule interface{}) error {
 	return s.SaveJSON(rulesPath, ruleKey, rule)
@@ -286,6 +319,141 @@ func (s *Storage) LoadRuleGroups(f func(k, v string)) error {
 	return s.LoadRangeByPrefix(ruleGroupPath+"/", f)
 }
 
+// SaveLeaderAntiAffinity stores a leader anti-affinity to storage.
+func (s *Storage) SaveLeaderAntiAffinity(id string, antiAffinity interface{}) error {
+	return s.SaveJSON(leaderAntiAffinityPath, id, antiAffinity)
+}
+
+// DeleteLeaderAntiAffinity removes a leader anti-affinity from storage.
+func (s *Storage) DeleteLeaderAntiAffinity(id string) error {
+	return s.Remove(path.Join(leaderAntiAffinityPath, id))
+}
+
+// LoadLeaderAntiAffinities loads all leader anti-affinities from storage.
+func (s *Storage) LoadLeaderAntiAffinities(f func(k, v string)) error {
+	return s.LoadRangeByPrefix(leaderAntiAffinityPath+"/", f)
+}
+
+func operatorAuditKey(seq uint64) string {
+	return fmt.Sprintf("%020d", seq)
+}
+
+// SaveOperatorAudits saves the finished operators of the audit log by their
+// seqs, and removes the ones which are out of the audit log, in a batch. The
+// batch is not written if the conditions are not met.
+func (s *Storage) SaveOperatorAudits(audits map[uint64]interface{}, removed []uint64, conds ...kv.Cond) error {
+	ops := make([]kv.Op, 0, len(audits)+len(removed))
+	for seq, audit := range audits {
+		value, err := json.Marshal(audit)
+		if err != nil {
+			return errs.ErrJSONMarshal.Wrap(err).GenWithStackByArgs()
+		}
+		ops = append(ops, kv.Op{Key: path.Join(operatorAuditPath, operatorAuditKey(seq)), Value: string(value)})
+	}
+	for _, seq := range removed {
+		ops = append(ops, kv.Op{Key: path.Join(operatorAuditPath, operatorAuditKey(seq)), Remove: true})
+	}
+	return s.SaveBatch(ops, conds...)
+}
+
+// LoadOperatorAudits loads the finished operators of the audit log in the
+// order of their seq.
+func (s *Storage) LoadOperatorAudits(f func(k, v string)) error {
+	return s.LoadRangeByPrefix(operatorAuditPath+"/", f)
+}
+
+func storeConfigKey(storeID uint64) string {
+	return fmt.Sprintf("%020d", storeID)
+}
+
+// SaveStoreConfig stores the dynamic config items of a store.
+func (s *Storage) SaveStoreConfig(storeID uint64, items map[string]string) error {
+	return s.SaveJSON(storeConfigPath, storeConfigKey(storeID), items)
+}
+
+// RemoveStoreConfig removes the dynamic config items of a store.
+func (s *Storage) RemoveStoreConfig(storeID uint64) error {
+	return s.Remove(path.Join(storeConfigPath, storeConfigKey(storeID)))
+}
+
+// LoadStoreConfigs loads the dynamic config items of all stores.
+func (s *Storage) LoadStoreConfigs(f func(k, v string)) error {
+	return s.LoadRangeByPrefix(storeConfigPath+"/", f)
+}
+
+// SaveRegionLabelRule stores a region label rule to storage.
+func (s *Storage) SaveRegionLabelRule(id string, rule interface{}) error {
+	return s.SaveJSON(regionLabelPath, id, rule)
+}
+
+// DeleteRegionLabelRule removes a region label rule from storage.
+func (s *Storage) DeleteRegionLabelRule(id string) error {
+	return s.Remove(path.Join(regionLabelPath, id))
+}
+
+// LoadRegionLabelRules loads all region label rules from storage.
+func (s *Storage) LoadRegionLabelRules(f func(k, v string)) error {
+	return s.LoadRangeByPrefix(regionLabelPath+"/", f)
+}
+
+// SaveGCRange stores a key range dropped by the database layer.
+func (s *Storage) SaveGCRange(key string, r interface{}) error {
+	return s.SaveJSON(gcRangePath, key, r)
+}
+
+// DeleteGCRange removes a dropped key range from storage.
+func (s *Storage) DeleteGCRange(key string) error {
+	return s.Remove(path.Join(gcRangePath, key))
+}
+
+// LoadGCRanges loads all the dropped key ranges from storage.
+func (s *Storage) LoadGCRanges(f func(k, v string)) error {
+	return s.LoadRangeByPrefix(gcRangePath+"/", f)
+}
+
+func regionTopologyKey(ts int64) string {
+	return fmt.Sprintf("%020d", ts)
+}
+
+func regionTopologyChunkKey(ts int64, chunk int) string {
+	return path.Join(regionTopologyKey(ts), fmt.Sprintf("%020d", chunk))
+}
+
+// SaveRegionTopologyMeta stores the meta of a region topology snapshot, it is
+// saved as pending before the chunks and saved again after them, so that a
+// snapshot is complete once its meta is not pending.
+func (s *Storage) SaveRegionTopologyMeta(ts int64, meta interface{}) error {
+	return s.SaveJSON(regionTopologyMetaPath, regionTopologyKey(ts), meta)
+}
+
+// LoadRegionTopologyMetas loads the metas of the region topology snapshots in
+// the order of time.
+func (s *Storage) LoadRegionTopologyMetas(f func(k, v string)) error {
+	return s.LoadRangeByPrefix(regionTopologyMetaPath+"/", f)
+}
+
+// SaveRegionTopologyChunk stores a chunk of the regions of a region topology
+// snapshot.
+func (s *Storage) SaveRegionTopologyChunk(ts int64, chunk int, regions interface{}) error {
+	return s.SaveJSON(regionTopologyPath, regionTopologyChunkKey(ts, chunk), regions)
+}
+
+// LoadRegionTopologyChunks loads the chunks of a region topology snapshot.
+func (s *Storage) LoadRegionTopologyChunks(ts int64, f func(k, v string)) error {
+	return s.LoadRangeByPrefix(path.Join(regionTopologyPath, regionTopologyKey(ts))+"/", f)
+}
+
+// RemoveRegionTopology removes a region topology snapshot with its chunks in
+// a batch.
+func (s *Storage) RemoveRegionTopology(ts int64, chunks int) error {
+	ops := make([]kv.Op, 0, chunks+1)
+	for i := 0; i < chunks; i++ {
+		ops = append(ops, kv.Op{Key: path.Join(regionTopologyPath, regionTopologyChunkKey(ts, i)), Remove: true})
+	}
+	ops = append(ops, kv.Op{Key: path.Join(regionTopologyMetaPath, regionTopologyKey(ts)), Remove: true})
+	return s.SaveBatch(ops)
+}
+
 // SaveJSON saves json format data to storage.
 func (s *Storage) SaveJSON(prefix, key string, data interface{}) error {
 	value, err := json.Marshal(data)
@@ -43,9 +43,11 @@ type StoreInfo struct {
 	leaderSize          int64
 	regionSize          int64
 	pendingPeerCount    int
+	downPeerCount       int
 	lastPersistTime     time.Time
 	leaderWeight        float64
 	regionWeight        float64
+	utilization         float64
 	available           map[storelimit.Type]func() bool
 }
 
@@ -75,9 +77,11 @@ func (s *StoreInfo) Clone(opts ...StoreCreateOption) *StoreInfo {
 		leaderSize:          s.leaderSize,
 		regionSize:          s.regionSize,
 		pendingPeerCount:    s.pendingPeerCount,
+		downPeerCount:       s.downPeerCount,
 		lastPersistTime:     s.lastPersistTime,
 		leaderWeight:        s.leaderWeight,
 		regionWeight:        s.regionWeight,
+		utilization:         s.utilization,
 		available:           s.available,
 	}
 
@@ -98,9 +102,11 @@ func (s *StoreInfo) ShallowClone(opts ...StoreCreateOption) *StoreInfo {
 		leaderSize:          s.leaderSize,
 		regionSize:          s.regionSize,
 		pendingPeerCount:    s.pendingPeerCount,
+		downPeerCount:       s.downPeerCount,
 		lastPersistTime:     s.lastPersistTime,
 		leaderWeight:        s.leaderWeight,
 		regionWeight:        s.regionWeight,
+		utilization:         s.utilization,
 		available:           s.available,
 	}
 
@@ -259,6 +265,11 @@ func (s *StoreInfo) GetPendingPeerCount() int {
 	return s.pendingPeerCount
 }
 
+// GetDownPeerCount returns the down peer count of the store.
+func (s *StoreInfo) GetDownPeerCount() int {
+	return s.downPeerCount
+}
+
 // GetLeaderWeight returns the leader weight of the store.
 func (s *StoreInfo) GetLeaderWeight() float64 {
 	return s.leaderWeight
@@ -279,6 +290,49 @@ func (s *StoreInfo) NeedPersist() bool {
 	return s.GetLastHeartbeatTS().Sub(s.lastPersistTime) > storePersistInterval
 }
 
+// GetUtilization returns the utilization of the store in [0, 1].
+func (s *StoreInfo) GetUtilization() float64 {
+	return s.utilization
+}
+
+// GetCPUUsage returns the average CPU usage of the threads in the store, the
+// usage of a thread is in percent.
+func (s *StoreInfo) GetCPUUsage() float64 {
+	usages := s.GetStoreStats().GetCpuUsages()
+	if len(usages) == 0 {
+		return 0
+	}
+	var total uint64
+	for _, usage := range usages {
+		total += usage.GetValue()
+	}
+	return float64(total) / float64(len(usages))
+}
+
+// GetDiskIORate returns the total disk read and write rate of the threads in
+// the store.
+func (s *StoreInfo) GetDiskIORate() uint64 {
+	var total uint64
+	for _, rate := range s.GetStoreStats().GetReadIoRates() {
+		total += rate.GetValue()
+	}
+	for _, rate := range s.GetStoreStats().GetWriteIoRates() {
+		total += rate.GetValue()
+	}
+	return total
+}
+
+// CalculateUtilization returns the utilization of the store, which is the
+// larger one of the CPU utilization and the disk IO utilization against the
+// capacity. The disk IO is ignored if the capacity is 0.
+func (s *StoreInfo) CalculateUtilization(ioCapacity uint64) float64 {
+	utilization := s.GetCPUUsage() / 100
+	if ioCapacity > 0 {
+		utilization = math.Max(utilization, float64(s.GetDiskIORate())/float64(ioCapacity))
+	}
+	return math.Min(utilization, 1)
+}
+
 const minWeight = 1e-6
 const maxScore = 1024 * 1024 * 1024
 
@@ -289,6 +343,9 @@ func (s *StoreInfo) LeaderScore(policy SchedulePolicy, delta int64) float64 {
 		return float64(s.GetLeaderSize()+delta) / math.Max(s.GetLeaderWeight(), minWeight)
 	case ByCount:
 		return float64(int64(s.GetLeaderCount())+delta) / math.Max(s.GetLeaderWeight(), minWeight)
+	case ByUtilization:
+		// A fully utilized store is scored as if it had twice the leaders.
+		return float64(int64(s.GetLeaderCount())+delta) * (1 + s.utilization) / math.Max(s.GetLeaderWeight(), minWeight)
 	default:
 		return 0
 	}
@@ -515,12 +572,16 @@ L:
 // StoresInfo contains information about all stores.
 type StoresInfo struct {
 	stores map[uint64]*StoreInfo
+	// addresses indexes the stores by their addresses. If several stores
+	// share an address, the one which is not tombstone wins.
+	addresses map[string]uint64
 }
 
 // NewStoresInfo create a StoresInfo with map of storeID to StoreInfo
 func NewStoresInfo() *StoresInfo {
 	return &StoresInfo{
-		stores: make(map[uint64]*StoreInfo),
+		stores:    make(map[uint64]*StoreInfo),
+		addresses: make(map[string]uint64),
 	}
 }
 
@@ -542,9 +603,47 @@ func (s *StoresInfo) TakeStore(storeID uint64) *StoreInfo {
 	return store
 }
 
+// GetStoreByAddress returns the StoreInfo with the specified address.
+func (s *StoresInfo) GetStoreByAddress(address string) *StoreInfo {
+	storeID, ok := s.addresses[address]
+	if !ok {
+		return nil
+	}
+	return s.stores[storeID]
+}
+
 // SetStore sets a StoreInfo with storeID.
 func (s *StoresInfo) SetStore(store *StoreInfo) {
+	if old, ok := s.stores[store.GetID()]; ok && old.GetAddress() != store.GetAddress() {
+		s.removeAddress(old)
+	}
 	s.stores[store.GetID()] = store
+	indexed := s.GetStoreByAddress(store.GetAddress())
+	switch {
+	case indexed == nil, indexed.GetID() != store.GetID() && indexed.IsTombstone():
+		s.addresses[store.GetAddress()] = store.GetID()
+	case indexed.GetID() == store.GetID() && store.IsTombstone():
+		// Prefers another store which reuses the address.
+		s.removeAddress(store)
+		if _, ok := s.addresses[store.GetAddress()]; !ok {
+			s.addresses[store.GetAddress()] = store.GetID()
+		}
+	}
+}
+
+func (s *StoresInfo) removeAddress(store *StoreInfo) {
+	if s.addresses[store.GetAddress()] != store.GetID() {
+		return
+	}
+	delete(s.addresses, store.GetAddress())
+	// Falls back to another store with the same address.
+	for _, other := range s.stores {
+		if other.GetID() != store.GetID() && other.GetAddress() == store.GetAddress() {
+			if indexed := s.GetStoreByAddress(other.GetAddress()); indexed == nil || indexed.IsTombstone() {
+				s.addresses[other.GetAddress()] = other.GetID()
+			}
+		}
+	}
 }
 
 // PauseLeaderTransfer pauses a StoreInfo with storeID.
@@ -598,6 +697,9 @@ func (s *StoresInfo) GetMetaStores() []*metapb.Store {
 
 // DeleteStore deletes tombstone record form store
 func (s *StoresInfo) DeleteStore(store *StoreInfo) {
+	if old, ok := s.stores[store.GetID()]; ok {
+		s.removeAddress(old)
+	}
 	delete(s.stores, store.GetID())
 }
 
@@ -642,11 +744,12 @@ func (s *StoresInfo) SetRegionSize(storeID uint64, regionSize int64) {
 }
 
 // UpdateStoreStatus updates the information of the store.
-func (s *StoresInfo) UpdateStoreStatus(storeID uint64, leaderCount int, regionCount int, pendingPeerCount int, leaderSize int64, regionSize int64) {
+func (s *StoresInfo) UpdateStoreStatus(storeID uint64, leaderCount int, regionCount int, pendingPeerCount int, downPeerCount int, leaderSize int64, regionSize int64) {
 	if store, ok := s.stores[storeID]; ok {
 		newStore := store.ShallowClone(SetLeaderCount(leaderCount),
 			SetRegionCount(regionCount),
 			SetPendingPeerCount(pendingPeerCount),
+			SetDownPeerCount(downPeerCount),
 			SetLeaderSize(leaderSize),
 			SetRegionSize(regionSize))
 		s.SetStore(newStore)
@@ -119,6 +119,13 @@ func SetPendingPeerCount(pendingPeerCount int) StoreCreateOption {
 	}
 }
 
+// SetDownPeerCount sets the down peer count for the store.
+func SetDownPeerCount(downPeerCount int) StoreCreateOption {
+	return func(store *StoreInfo) {
+		store.downPeerCount = downPeerCount
+	}
+}
+
 // SetLeaderSize sets the leader size for the store.
 func SetLeaderSize(leaderSize int64) StoreCreateOption {
 	return func(store *StoreInfo) {
@@ -147,6 +154,13 @@ func SetRegionWeight(regionWeight float64) StoreCreateOption {
 	}
 }
 
+// SetStoreUtilization sets the utilization for the store.
+func SetStoreUtilization(utilization float64) StoreCreateOption {
+	return func(store *StoreInfo) {
+		store.utilization = utilization
+	}
+}
+
 // SetLastHeartbeatTS sets the time of last heartbeat for the store.
 func SetLastHeartbeatTS(lastHeartbeatTS time.Time) StoreCreateOption {
 	return func(store *StoreInfo) {
@@ -114,3 +114,24 @@ func (s *testStoreSuite) TestRegionScore(c *C) {
 	// Region score should never be NaN, or /store API would fail.
 	c.Assert(math.IsNaN(score), Equals, false)
 }
+
+func (s *testStoreSuite) TestUtilization(c *C) {
+	stats := &pdpb.StoreStats{
+		CpuUsages:    []*pdpb.RecordPair{{Key: "grpc-1", Value: 20}, {Key: "grpc-2", Value: 40}},
+		ReadIoRates:  []*pdpb.RecordPair{{Key: "sst-1", Value: 30 * mb}},
+		WriteIoRates: []*pdpb.RecordPair{{Key: "sst-1", Value: 50 * mb}},
+	}
+	store := NewStoreInfo(&metapb.Store{Id: 1}, SetStoreStats(stats))
+	c.Assert(store.GetCPUUsage(), Equals, float64(30))
+	c.Assert(store.GetDiskIORate(), Equals, uint64(80*mb))
+	// The disk IO is ignored without the capacity.
+	c.Assert(store.CalculateUtilization(0), Equals, 0.3)
+	c.Assert(store.CalculateUtilization(100*mb), Equals, 0.8)
+	c.Assert(store.CalculateUtilization(40*mb), Equals, float64(1))
+
+	store = store.Clone(SetLeaderCount(10), SetStoreUtilization(0.5))
+	c.Assert(store.LeaderScore(ByCount, 0), Equals, float64(10))
+	c.Assert(store.LeaderScore(ByUtilization, 0), Equals, float64(15))
+	c.Assert(store.LeaderScore(ByUtilization, 2), Equals, float64(18))
+	c.Assert(StringToSchedulePolicy("utilization"), Equals, ByUtilization)
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+const drainCheckInterval = 100 * time.Millisecond
+
+// DrainStatus is the progress of draining a PD server before shutdown.
+type DrainStatus struct {
+	Name     string `json:"name"`
+	Draining bool   `json:"draining"`
+	IsLeader bool   `json:"is_leader"`
+	// InflightStreams is the number of the TSO and region heartbeat streams
+	// still being served.
+	InflightStreams int64 `json:"inflight_streams"`
+	// ReadyForShutdown means the server has handed off its leadership and
+	// there is no stream being served, it is safe to stop the server.
+	ReadyForShutdown bool `json:"ready_for_shutdown"`
+}
+
+// IsDraining returns whether the server is draining.
+func (s *Server) IsDraining() bool {
+	return atomic.LoadInt64(&s.isDraining) == 1
+}
+
+// acceptStream checks whether a new stream can be served, and tracks it until
+// the returned function is called.
+func (s *Server) acceptStream() (func(), error) {
+	if s.IsDraining() {
+		return nil, ErrDraining
+	}
+	atomic.AddInt64(&s.inflightStreams, 1)
+	return func() { atomic.AddInt64(&s.inflightStreams, -1) }, nil
+}
+
+// Drain prepares the server for shutdown. It stops accepting new TSO and region
+// heartbeat streams, transfers the leadership away if the server is the leader,
+// and waits for the in-flight streams to finish until the context is done.
+func (s *Server) Drain(ctx context.Context) (*DrainStatus, error) {
+	if atomic.CompareAndSwapInt64(&s.isDraining, 0, 1) {
+		log.Info("start to drain the server", zap.String("server-name", s.Name()))
+	}
+	if s.member.IsLeader() || s.member.GetEtcdLeader() == s.member.ID() {
+		if err := s.member.ResignEtcdLeader(ctx, s.Name(), ""); err != nil {
+			atomic.StoreInt64(&s.isDraining, 0)
+			return nil, err
+		}
+	}
+
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+	for {
+		status := s.GetDrainStatus()
+		if status.ReadyForShutdown {
+			log.Info("the server is ready for shutdown", zap.String("server-name", s.Name()))
+			return status, nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return status, nil
+		}
+	}
+}
+
+// Undrain cancels the draining of the server, e.g. when the shutdown is called
+// off. The server accepts new streams and campaigns for the leadership again.
+func (s *Server) Undrain() *DrainStatus {
+	if atomic.CompareAndSwapInt64(&s.isDraining, 1, 0) {
+		log.Info("stop draining the server", zap.String("server-name", s.Name()))
+	}
+	return s.GetDrainStatus()
+}
+
+// GetDrainStatus returns the progress of draining the server.
+func (s *Server) GetDrainStatus() *DrainStatus {
+	status := &DrainStatus{
+		Name:            s.Name(),
+		Draining:        s.IsDraining(),
+		IsLeader:        s.member.IsLeader(),
+		InflightStreams: atomic.LoadInt64(&s.inflightStreams),
+	}
+	status.ReadyForShutdown = status.Draining && !status.IsLeader && status.InflightStreams == 0
+	return status
+}
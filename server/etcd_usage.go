@@ -0,0 +1,137 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/tikv/pd/pkg/etcdutil"
+	"go.etcd.io/etcd/clientv3"
+)
+
+// etcdUsageScanLimit is the max number of keys loaded by a request when
+// scanning etcd for the key usage.
+const etcdUsageScanLimit = 1000
+
+// etcdPrefixNames are the readable names of the well-known PD prefixes.
+var etcdPrefixNames = map[string]string{
+	"raft/s":                    "stores",
+	"raft/r":                    "regions",
+	"raft/status":               "cluster status",
+	"config":                    "configs",
+	"scheduler_config":          "scheduler configs",
+	"rules":                     "placement rules",
+	"rule_group":                "placement rule groups",
+	"rule_leader_anti_affinity": "leader anti-affinities",
+	"region_label":              "region label rules",
+	"gc":                        "gc safepoints",
+	"operator_audit":            "operator audits",
+	"region_topology":           "region topology snapshots",
+	"store_config":              "store configs",
+	"encryption_keys":           "encryption keys",
+}
+
+// EtcdKeyUsage is the number and size of the keys under a prefix.
+type EtcdKeyUsage struct {
+	Prefix string `json:"prefix"`
+	// Name is the readable name of the prefix, it is empty if the prefix is
+	// not known by PD.
+	Name  string `json:"name,omitempty"`
+	Keys  int64  `json:"keys"`
+	Bytes int64  `json:"bytes"`
+}
+
+// EtcdUsage is the breakdown of the keys in etcd by prefixes.
+type EtcdUsage struct {
+	// DBSize is the physically allocated size of the etcd backend, and
+	// DBSizeInUse is the size logically in use.
+	DBSize      int64 `json:"db_size"`
+	DBSizeInUse int64 `json:"db_size_in_use"`
+	// Quota is the size which raises the alarm when it is exceeded by DBSize.
+	Quota int64 `json:"quota"`
+	// TotalKeys and TotalBytes are the usage of all the keys, including the
+	// ones not of PD.
+	TotalKeys  int64 `json:"total_keys"`
+	TotalBytes int64 `json:"total_bytes"`
+	// Prefixes are sorted by the bytes in descending order. The keys under
+	// the root path of PD are grouped by the first part of the key after the
+	// root path, and by two parts for the `raft` prefix, e.g. `raft/s`. The
+	// others are grouped by the first part of the key, like `/tidb`.
+	Prefixes []*EtcdKeyUsage `json:"prefixes"`
+}
+
+// etcdUsagePrefix returns the prefix of the key to group the usage by.
+func etcdUsagePrefix(rootPath, key string) string {
+	if !strings.HasPrefix(key, rootPath+"/") {
+		parts := strings.SplitN(strings.TrimPrefix(key, "/"), "/", 2)
+		if strings.HasPrefix(key, "/") {
+			return "/" + parts[0]
+		}
+		return parts[0]
+	}
+	parts := strings.SplitN(strings.TrimPrefix(key, rootPath+"/"), "/", 3)
+	if parts[0] == "raft" && len(parts) > 2 {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
+
+// GetEtcdUsage scans all the keys in etcd and reports their number and size
+// by prefixes.
+func (s *Server) GetEtcdUsage() (*EtcdUsage, error) {
+	usage := &EtcdUsage{Quota: int64(s.cfg.QuotaBackendBytes)}
+	if etcd := s.member.Etcd(); etcd != nil {
+		usage.DBSize = etcd.Server.Backend().Size()
+		usage.DBSizeInUse = etcd.Server.Backend().SizeInUse()
+	}
+	prefixes := make(map[string]*EtcdKeyUsage)
+	nextKey := "\x00"
+	for {
+		resp, err := etcdutil.EtcdKVGet(s.client, nextKey, clientv3.WithFromKey(), clientv3.WithLimit(etcdUsageScanLimit))
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range resp.Kvs {
+			key := string(kv.Key)
+			prefix := etcdUsagePrefix(s.rootPath, key)
+			u, ok := prefixes[prefix]
+			if !ok {
+				u = &EtcdKeyUsage{Prefix: prefix, Name: etcdPrefixNames[prefix]}
+				prefixes[prefix] = u
+			}
+			size := int64(len(kv.Key) + len(kv.Value))
+			u.Keys++
+			u.Bytes += size
+			usage.TotalKeys++
+			usage.TotalBytes += size
+		}
+		if !resp.More || len(resp.Kvs) == 0 {
+			break
+		}
+		nextKey = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+
+	usage.Prefixes = make([]*EtcdKeyUsage, 0, len(prefixes))
+	for _, u := range prefixes {
+		usage.Prefixes = append(usage.Prefixes, u)
+	}
+	sort.Slice(usage.Prefixes, func(i, j int) bool {
+		if usage.Prefixes[i].Bytes != usage.Prefixes[j].Bytes {
+			return usage.Prefixes[i].Bytes > usage.Prefixes[j].Bytes
+		}
+		return usage.Prefixes[i].Prefix < usage.Prefixes[j].Prefix
+	})
+	return usage, nil
+}
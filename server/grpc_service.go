@@ -15,6 +15,7 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -36,7 +37,9 @@ import (
 	"github.com/tikv/pd/server/tso"
 	"github.com/tikv/pd/server/versioninfo"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -48,6 +51,8 @@ var (
 	// TODO: work as proxy.
 	ErrNotLeader  = status.Errorf(codes.Unavailable, "not leader")
 	ErrNotStarted = status.Errorf(codes.Unavailable, "server not started")
+	// ErrDraining is returned when the server is draining and does not accept new streams.
+	ErrDraining = status.Errorf(codes.Unavailable, "server is draining")
 )
 
 // GetMembers implements gRPC PDServer.
@@ -86,6 +91,11 @@ func (s *Server) GetMembers(context.Context, *pdpb.GetMembersRequest) (*pdpb.Get
 
 // Tso implements gRPC PDServer.
 func (s *Server) Tso(stream pdpb.PD_TsoServer) error {
+	done, err := s.acceptStream()
+	if err != nil {
+		return err
+	}
+	defer done()
 	for {
 		request, err := stream.Recv()
 		if err == io.EOF {
@@ -324,6 +334,15 @@ func (s *Server) StoreHeartbeat(ctx context.Context, request *pdpb.StoreHeartbea
 
 	storeHeartbeatHandleDuration.WithLabelValues(storeAddress, storeLabel).Observe(time.Since(start).Seconds())
 
+	// The store config is pushed even if it is empty, so the removed items
+	// are dropped by the store as well.
+	if items, err := rc.GetStoreConfig(storeID); err == nil {
+		if data, err := json.Marshal(items); err == nil {
+			// The header fails to be set if the request is not from gRPC.
+			_ = grpc.SetHeader(ctx, metadata.Pairs(cluster.StoreConfigMetadataKey, string(data)))
+		}
+	}
+
 	return &pdpb.StoreHeartbeatResponse{
 		Header:            s.header(),
 		ReplicationStatus: rc.GetReplicationMode().GetReplicationStatus(),
@@ -374,6 +393,11 @@ func (s *heartbeatServer) Recv() (*pdpb.RegionHeartbeatRequest, error) {
 
 // RegionHeartbeat implements gRPC PDServer.
 func (s *Server) RegionHeartbeat(stream pdpb.PD_RegionHeartbeatServer) error {
+	done, err := s.acceptStream()
+	if err != nil {
+		return err
+	}
+	defer done()
 	server := &heartbeatServer{stream: stream}
 	rc := s.GetRaftCluster()
 	if rc == nil {
@@ -417,7 +441,9 @@ func (s *Server) RegionHeartbeat(stream pdpb.PD_RegionHeartbeatServer) error {
 
 		region := core.RegionFromHeartbeat(request)
 		if region.GetLeader() == nil {
-			log.Error("invalid request, the leader is nil", zap.Reflect("request", request), errs.ZapError(errs.ErrLeaderNil))
+			if ok, suppressed := logutil.Sample(logutil.SampleKeyHeartbeatReject); ok {
+				log.Error("invalid request, the leader is nil", zap.Reflect("request", request), zap.Uint64("suppressed", suppressed), errs.ZapError(errs.ErrLeaderNil))
+			}
 			continue
 		}
 		if region.GetID() == 0 {
@@ -429,8 +455,11 @@ func (s *Server) RegionHeartbeat(stream pdpb.PD_RegionHeartbeatServer) error {
 
 		// If the region peer count is 0, then we should not handle this.
 		if len(region.GetPeers()) == 0 {
-			log.Warn("invalid region, zero region peer count",
-				logutil.ZapRedactStringer("region-meta", core.RegionToHexMeta(region.GetMeta())))
+			if ok, suppressed := logutil.Sample(logutil.SampleKeyHeartbeatReject); ok {
+				log.Warn("invalid region, zero region peer count",
+					logutil.ZapRedactStringer("region-meta", core.RegionToHexMeta(region.GetMeta())),
+					zap.Uint64("suppressed", suppressed))
+			}
 			regionHeartbeatCounter.WithLabelValues(storeAddress, storeLabel, "report", "err").Inc()
 			msg := fmt.Sprintf("invalid region, zero region peer count: %v", logutil.RedactStringer(core.RegionToHexMeta(region.GetMeta())))
 			s.hbStreams.SendErr(pdpb.ErrorType_UNKNOWN, msg, request.GetLeader())
@@ -34,6 +34,7 @@ import (
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/core/storelimit"
 	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/checker"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/opt"
 	"github.com/tikv/pd/server/schedule/placement"
@@ -62,6 +63,10 @@ var (
 	ErrRegionAbnormalPeer = func(regionID uint64) error {
 		return errors.Errorf("region %v has abnormal peer", regionID)
 	}
+	// ErrRegionCannotMerge is error info for region can not be merged with its sibling.
+	ErrRegionCannotMerge = func(regionID uint64, reason string) error {
+		return errors.Errorf("region %v can not be merged: %s", regionID, reason)
+	}
 	// ErrStoreNotFound is error info for store not found.
 	ErrStoreNotFound = func(storeID uint64) error {
 		return errors.Errorf("store %v not found", storeID)
@@ -171,6 +176,15 @@ func (h *Handler) GetHotReadRegions() *statistics.StoreHotPeersInfos {
 	return c.GetHotReadRegions()
 }
 
+// GetHotRegionHistory returns the hot peers recorded in the time range.
+func (h *Handler) GetHotRegionHistory(start, end time.Time, typ string) ([]cluster.HotRegionRecord, error) {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return nil, err
+	}
+	return rc.GetHotRegionHistory(start, end, typ), nil
+}
+
 // GetHotBytesWriteStores gets all hot write stores stats.
 func (h *Handler) GetHotBytesWriteStores() map[uint64]float64 {
 	rc := h.s.GetRaftCluster()
@@ -406,6 +420,15 @@ func (h *Handler) GetOperatorsOfKind(mask operator.OpKind) ([]*operator.Operator
 	return results, nil
 }
 
+// GetOperatorAudits returns the finished operators selected by the filter.
+func (h *Handler) GetOperatorAudits(filter schedule.OperatorAuditFilter) ([]*schedule.OperatorAudit, error) {
+	c, err := h.GetOperatorController()
+	if err != nil {
+		return nil, err
+	}
+	return c.GetOperatorAudits(filter), nil
+}
+
 // GetHistory returns finished operators' history since start.
 func (h *Handler) GetHistory(start time.Time) ([]operator.OpHistory, error) {
 	c, err := h.GetOperatorController()
@@ -421,8 +444,7 @@ func (h *Handler) SetAllStoresLimit(ratePerMin float64, limitType storelimit.Typ
 	if err != nil {
 		return err
 	}
-	c.SetAllStoresLimit(limitType, ratePerMin)
-	return nil
+	return c.SetAllStoresLimit(limitType, ratePerMin)
 }
 
 // SetAllStoresLimitTTL is used to set limit of all stores with ttl
@@ -445,7 +467,9 @@ func (h *Handler) SetLabelStoresLimit(ratePerMin float64, limitType storelimit.T
 		for _, label := range labels {
 			for _, sl := range store.GetLabels() {
 				if label.Key == sl.Key && label.Value == sl.Value {
-					c.SetStoreLimit(store.GetID(), limitType, ratePerMin)
+					if err := c.SetStoreLimit(store.GetID(), limitType, ratePerMin); err != nil {
+						return err
+					}
 				}
 			}
 		}
@@ -468,8 +492,7 @@ func (h *Handler) SetStoreLimit(storeID uint64, ratePerMin float64, limitType st
 	if err != nil {
 		return err
 	}
-	c.SetStoreLimit(storeID, limitType, ratePerMin)
-	return nil
+	return c.SetStoreLimit(storeID, limitType, ratePerMin)
 }
 
 // AddTransferLeaderOperator adds an operator to transfer leader to the store.
@@ -725,6 +748,86 @@ func (h *Handler) AddMergeRegionOperator(regionID uint64, targetID uint64) error
 	return nil
 }
 
+// AddMergeSiblingOperator adds an operator to merge a region into its sibling
+// on the side of "left" or "right", the smaller sibling which can be merged is
+// picked if the side is empty. Like the merge checker, the region should not
+// be larger than max-merge-region-size and max-merge-region-keys. It returns
+// the ID of the sibling.
+func (h *Handler) AddMergeSiblingOperator(regionID uint64, side string) (uint64, error) {
+	c, err := h.GetRaftCluster()
+	if err != nil {
+		return 0, err
+	}
+
+	region := c.GetRegion(regionID)
+	if region == nil {
+		return 0, ErrRegionNotFound(regionID)
+	}
+	if maxSize := c.GetOpts().GetMaxMergeRegionSize(); region.GetApproximateSize() > int64(maxSize) {
+		return 0, ErrRegionCannotMerge(regionID, fmt.Sprintf("the approximate size %d MiB is larger than max-merge-region-size %d MiB", region.GetApproximateSize(), maxSize))
+	}
+	if maxKeys := c.GetOpts().GetMaxMergeRegionKeys(); region.GetApproximateKeys() > int64(maxKeys) {
+		return 0, ErrRegionCannotMerge(regionID, fmt.Sprintf("the approximate keys %d is larger than max-merge-region-keys %d", region.GetApproximateKeys(), maxKeys))
+	}
+	if !opt.IsRegionHealthy(c, region) || !opt.IsRegionReplicated(c, region) {
+		return 0, ErrRegionAbnormalPeer(regionID)
+	}
+
+	left, right := c.GetAdjacentRegions(region)
+	var siblings []*core.RegionInfo
+	switch side {
+	case "left":
+		if left == nil {
+			return 0, ErrRegionCannotMerge(regionID, "no left sibling")
+		}
+		siblings = append(siblings, left)
+	case "right":
+		if right == nil {
+			return 0, ErrRegionCannotMerge(regionID, "no right sibling")
+		}
+		siblings = append(siblings, right)
+	case "":
+		for _, sibling := range []*core.RegionInfo{right, left} {
+			if sibling != nil {
+				siblings = append(siblings, sibling)
+			}
+		}
+		if len(siblings) == 0 {
+			return 0, ErrRegionCannotMerge(regionID, "no sibling")
+		}
+	default:
+		return 0, errors.Errorf("invalid side %s, should be left or right", side)
+	}
+
+	var (
+		target  *core.RegionInfo
+		reasons []string
+	)
+	for _, sibling := range siblings {
+		switch {
+		case !opt.IsRegionHealthy(c, sibling) || !opt.IsRegionReplicated(c, sibling):
+			reasons = append(reasons, fmt.Sprintf("sibling region %d has abnormal peer", sibling.GetID()))
+		case !checker.AllowMerge(c, region, sibling):
+			reasons = append(reasons, fmt.Sprintf("sibling region %d is in another table or separated by placement rules", sibling.GetID()))
+		case target == nil || sibling.GetApproximateSize() < target.GetApproximateSize():
+			target = sibling
+		}
+	}
+	if target == nil {
+		return 0, ErrRegionCannotMerge(regionID, strings.Join(reasons, ", "))
+	}
+
+	ops, err := operator.CreateMergeRegionOperator("admin-merge-region", c, region, target, operator.OpAdmin)
+	if err != nil {
+		log.Debug("fail to create merge region operator", errs.ZapError(err))
+		return 0, err
+	}
+	if ok := c.GetOperatorController().AddOperator(ops...); !ok {
+		return 0, errors.WithStack(ErrAddOperator)
+	}
+	return target.GetID(), nil
+}
+
 // AddSplitRegionOperator adds an operator to split a region.
 func (h *Handler) AddSplitRegionOperator(regionID uint64, policyStr string, keys []string) error {
 	c, err := h.GetRaftCluster()
@@ -760,6 +863,47 @@ func (h *Handler) AddSplitRegionOperator(regionID uint64, policyStr string, keys
 	return nil
 }
 
+// AddSplitRegionByKeysOperators adds operators to split the regions at the
+// keys, the keys are grouped by the regions containing them and the keys which
+// are already region boundaries are skipped.
+func (h *Handler) AddSplitRegionByKeysOperators(keys []string) error {
+	c, err := h.GetRaftCluster()
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("no split key is specified")
+	}
+
+	var regions []*core.RegionInfo
+	splitKeys := make(map[uint64][][]byte)
+	for _, key := range keys {
+		k, err := hex.DecodeString(key)
+		if err != nil {
+			return errors.Errorf("split key %s is not in hex format", key)
+		}
+		region := c.GetRegionByKey(k)
+		if region == nil {
+			return errors.Errorf("region of key %s is not found", key)
+		}
+		if bytes.Equal(region.GetStartKey(), k) {
+			continue
+		}
+		if _, ok := splitKeys[region.GetID()]; !ok {
+			regions = append(regions, region)
+		}
+		splitKeys[region.GetID()] = append(splitKeys[region.GetID()], k)
+	}
+
+	for _, region := range regions {
+		op := operator.CreateSplitRegionOperator("admin-split-region", region, operator.OpAdmin, pdpb.CheckPolicy_USEKEY, splitKeys[region.GetID()])
+		if ok := c.GetOperatorController().AddOperator(op); !ok {
+			return errors.WithStack(ErrAddOperator)
+		}
+	}
+	return nil
+}
+
 // AddScatterRegionOperator adds an operator to scatter a region.
 func (h *Handler) AddScatterRegionOperator(regionID uint64, group string) error {
 	c, err := h.GetRaftCluster()
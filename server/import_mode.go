@@ -0,0 +1,166 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/config"
+	"go.uber.org/zap"
+)
+
+const (
+	// importModeKey marks the import mode in the temporary configuration, its
+	// value is an importModeMarker.
+	importModeKey = "import-mode"
+	// importModeStoreLimit is the rate per minute of adding and removing peers
+	// on a store in the import mode.
+	importModeStoreLimit = 200
+)
+
+// importModeSchedule is the schedule configuration in the import mode. The merge
+// is paused, and more snapshots and pending peers are allowed on a store.
+var importModeSchedule = map[string]interface{}{
+	"schedule.merge-schedule-limit":   0,
+	"schedule.max-merge-region-size":  0,
+	"schedule.max-merge-region-keys":  0,
+	"schedule.max-snapshot-count":     64,
+	"schedule.max-pending-peer-count": 64,
+}
+
+// importModeStoreHints are the config hints of the stores in the import mode,
+// the flow control of the stores is relaxed to tolerate the compaction pending
+// bytes and the L0 files of the ingested SSTs. They are shown in the config of
+// every store while the import mode is enabled.
+var importModeStoreHints = map[string]string{
+	"import-mode": "true",
+	"storage.flow-control.l0-files-threshold":                  "60",
+	"storage.flow-control.soft-pending-compaction-bytes-limit": "384GiB",
+	"storage.flow-control.hard-pending-compaction-bytes-limit": "2048GiB",
+}
+
+// importModeMarker records the temporary configuration items set by the import
+// mode, so only they are removed when the import mode is disabled.
+type importModeMarker struct {
+	ExpireTime int64             `json:"expire_time"`
+	Settings   map[string]string `json:"settings"`
+}
+
+// ImportModeStatus is the status of the import mode.
+type ImportModeStatus struct {
+	Enabled    bool              `json:"enabled"`
+	ExpireTime *time.Time        `json:"expire_time,omitempty"`
+	Settings   map[string]string `json:"settings,omitempty"`
+}
+
+// importModeSettings returns the temporary configuration applied in the import
+// mode. The store limits are set for every store, because the default store
+// limit does not apply to the stores which have their own limits.
+func (s *Server) importModeSettings() map[string]string {
+	settings := make(map[string]string, len(importModeSchedule)+len(importModeStoreHints))
+	for k, v := range importModeSchedule {
+		settings[k] = fmt.Sprint(v)
+	}
+	for k, v := range importModeStoreHints {
+		settings[config.StoreConfigHintPrefix+k] = v
+	}
+	settings["default-add-peer"] = fmt.Sprint(importModeStoreLimit)
+	settings["default-remove-peer"] = fmt.Sprint(importModeStoreLimit)
+	if rc := s.GetRaftCluster(); rc != nil {
+		for _, store := range rc.GetMetaStores() {
+			settings[fmt.Sprintf("add-peer-%v", store.GetId())] = fmt.Sprint(importModeStoreLimit)
+			settings[fmt.Sprintf("remove-peer-%v", store.GetId())] = fmt.Sprint(importModeStoreLimit)
+		}
+	}
+	return settings
+}
+
+// getImportModeMarker returns the marker of the import mode and its raw value,
+// it returns nil if the import mode is not enabled.
+func (s *Server) getImportModeMarker() (*importModeMarker, string) {
+	v, ok := s.persistOptions.GetTTLData(importModeKey)
+	if !ok {
+		return nil, ""
+	}
+	marker := &importModeMarker{}
+	if err := json.Unmarshal([]byte(v), marker); err != nil {
+		log.Warn("failed to parse the import mode marker", zap.String("marker", v), errs.ZapError(errs.ErrJSONUnmarshal, err))
+		return nil, ""
+	}
+	return marker, v
+}
+
+// EnableImportMode applies the configuration optimized for importing data in a
+// transaction. It is reverted automatically after the ttl, or by
+// DisableImportMode.
+func (s *Server) EnableImportMode(ttl time.Duration) (*ImportModeStatus, error) {
+	settings := s.importModeSettings()
+	marker, err := json.Marshal(&importModeMarker{
+		ExpireTime: time.Now().Add(ttl).Unix(),
+		Settings:   settings,
+	})
+	if err != nil {
+		return nil, errs.ErrJSONMarshal.Wrap(err).GenWithStackByCause()
+	}
+	data := make(map[string]string, len(settings)+1)
+	for k, v := range settings {
+		data[k] = v
+	}
+	data[importModeKey] = string(marker)
+	if err := s.persistOptions.SetTTLDataBatch(s.ctx, s.client, data, ttl); err != nil {
+		return nil, err
+	}
+	log.Info("import mode is enabled", zap.Duration("ttl", ttl))
+	return s.GetImportModeStatus(), nil
+}
+
+// DisableImportMode reverts the configuration of the import mode in a
+// transaction. The items changed by others since the import mode is enabled,
+// like a store limit set by the user, are kept.
+func (s *Server) DisableImportMode() error {
+	marker, v := s.getImportModeMarker()
+	if marker == nil {
+		return nil
+	}
+	data := make(map[string]string, len(marker.Settings)+1)
+	for k, v := range marker.Settings {
+		data[k] = v
+	}
+	data[importModeKey] = v
+	if err := s.persistOptions.RemoveTTLDataBatch(s.ctx, s.client, data); err != nil {
+		return err
+	}
+	log.Info("import mode is disabled")
+	return nil
+}
+
+// GetImportModeStatus returns whether the import mode is enabled.
+func (s *Server) GetImportModeStatus() *ImportModeStatus {
+	marker, _ := s.getImportModeMarker()
+	if marker == nil {
+		return &ImportModeStatus{}
+	}
+	expire := time.Unix(marker.ExpireTime, 0)
+	status := &ImportModeStatus{Enabled: true, ExpireTime: &expire, Settings: make(map[string]string)}
+	for k := range marker.Settings {
+		if v, ok := s.persistOptions.GetTTLData(k); ok {
+			status.Settings[k] = v
+		}
+	}
+	return status
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// State is the state of a job.
+//
+//	pending -> running -> finished
+//	                   -> failed
+//	pending/running -> cancelled
+type State string
+
+// The states of a job.
+const (
+	Pending   State = "pending"
+	Running   State = "running"
+	Finished  State = "finished"
+	Failed    State = "failed"
+	Cancelled State = "cancelled"
+)
+
+// IsDone returns true if the job will not be run anymore.
+func (s State) IsDone() bool {
+	return s == Finished || s == Failed || s == Cancelled
+}
+
+// Job is a long-running admin task. It is persisted so that a new leader can
+// resume it from the last checkpoint.
+type Job struct {
+	ID       uint64  `json:"id"`
+	Type     string  `json:"type"`
+	State    State   `json:"state"`
+	Progress float64 `json:"progress"`
+	// Error is the reason why the job is failed.
+	Error string `json:"error,omitempty"`
+	// Params are the arguments of the job, they are decoded by the Runner.
+	Params json.RawMessage `json:"params,omitempty"`
+	// Checkpoint is saved by the Runner to resume the job after leader changed.
+	Checkpoint json.RawMessage `json:"checkpoint,omitempty"`
+	CreateTime time.Time       `json:"create_time"`
+	UpdateTime time.Time       `json:"update_time"`
+}
+
+// Clone returns a copy of the job.
+func (j *Job) Clone() *Job {
+	job := *j
+	return &job
+}
+
+// Reporter reports the progress of a running job.
+type Reporter interface {
+	// Report updates the progress which is in [0, 1] and persists the
+	// checkpoint, which is passed to the Runner when the job is resumed.
+	Report(progress float64, checkpoint interface{}) error
+}
+
+// Runner runs a type of jobs. It should check the context regularly and
+// return when the context is done, because the job is cancelled or the
+// leader is changed. The job is a copy, modifying it has no effect.
+type Runner func(ctx context.Context, job *Job, r Reporter) error
@@ -0,0 +1,316 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/logutil"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/id"
+	"go.uber.org/zap"
+)
+
+const (
+	jobPath = "jobs"
+	// jobRetention is how long the jobs are kept after they are done.
+	jobRetention = 7 * 24 * time.Hour
+	gcInterval   = time.Hour
+)
+
+func jobKey(id uint64) string {
+	return fmt.Sprintf("%020d", id)
+}
+
+// Manager persists the jobs and runs them in background. It only works on
+// the leader, the unfinished jobs are resumed by the new leader.
+type Manager struct {
+	sync.RWMutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	storage *core.Storage
+	idAlloc id.Allocator
+
+	runners map[string]Runner
+	jobs    map[uint64]*Job
+	cancels map[uint64]context.CancelFunc
+}
+
+// NewManager creates a job manager.
+func NewManager(ctx context.Context, storage *core.Storage, idAlloc id.Allocator) *Manager {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Manager{
+		ctx:     ctx,
+		cancel:  cancel,
+		storage: storage,
+		idAlloc: idAlloc,
+		runners: make(map[string]Runner),
+		jobs:    make(map[uint64]*Job),
+		cancels: make(map[uint64]context.CancelFunc),
+	}
+}
+
+// Register registers the runner of a type of jobs. It should be called
+// before Start.
+func (m *Manager) Register(typ string, runner Runner) {
+	m.Lock()
+	defer m.Unlock()
+	m.runners[typ] = runner
+}
+
+// Start loads the jobs from storage and resumes the unfinished ones. The jobs
+// which can not be decoded are skipped, so they do not block the leader from
+// serving.
+func (m *Manager) Start() error {
+	m.Lock()
+	defer m.Unlock()
+	err := m.storage.LoadRangeByPrefix(jobPath+"/", func(k, v string) {
+		job := &Job{}
+		if e := json.Unmarshal([]byte(v), job); e != nil {
+			log.Error("skip the job which can not be loaded", zap.String("key", k), errs.ZapError(errs.ErrLoadJob.Wrap(e).FastGenWithCause()))
+			return
+		}
+		m.jobs[job.ID] = job
+	})
+	if err != nil {
+		return errs.ErrLoadJob.Wrap(err).FastGenWithCause()
+	}
+	m.gcLocked(time.Now())
+	for _, job := range m.jobs {
+		if !job.State.IsDone() {
+			log.Info("resume job", zap.Uint64("job-id", job.ID), zap.String("type", job.Type), zap.String("state", string(job.State)))
+			m.runLocked(job)
+		}
+	}
+	m.wg.Add(1)
+	go m.gcLoop()
+	return nil
+}
+
+func (m *Manager) gcLoop() {
+	defer logutil.LogPanic()
+	defer m.wg.Done()
+	ticker := time.NewTicker(gcInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.Lock()
+			m.gcLocked(now)
+			m.Unlock()
+		}
+	}
+}
+
+// gcLocked removes the jobs which are done before the retention, the ones
+// failed to be removed from storage are tried again next time.
+func (m *Manager) gcLocked(now time.Time) {
+	expire := now.Add(-jobRetention)
+	for id, job := range m.jobs {
+		if !job.State.IsDone() || !job.UpdateTime.Before(expire) {
+			continue
+		}
+		if err := m.storage.Remove(path.Join(jobPath, jobKey(id))); err != nil {
+			log.Error("failed to remove the expired job", zap.Uint64("job-id", id), errs.ZapError(err))
+			continue
+		}
+		delete(m.jobs, id)
+	}
+}
+
+// Stop stops all running jobs and waits for them to exit. The jobs are kept
+// in their current state so that they can be resumed later.
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// Submit creates a job and runs it in background.
+func (m *Manager) Submit(typ string, params json.RawMessage) (*Job, error) {
+	m.Lock()
+	defer m.Unlock()
+	if _, ok := m.runners[typ]; !ok {
+		return nil, errs.ErrJobTypeNotSupported.FastGenByArgs(typ)
+	}
+	id, err := m.idAlloc.Alloc()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	job := &Job{
+		ID:         id,
+		Type:       typ,
+		State:      Pending,
+		Params:     params,
+		CreateTime: now,
+		UpdateTime: now,
+	}
+	if err := m.saveLocked(job); err != nil {
+		return nil, err
+	}
+	m.jobs[id] = job
+	log.Info("submit job", zap.Uint64("job-id", id), zap.String("type", typ), zap.ByteString("params", params))
+	m.runLocked(job)
+	return job.Clone(), nil
+}
+
+// GetJob returns the job with the ID.
+func (m *Manager) GetJob(id uint64) (*Job, error) {
+	m.RLock()
+	defer m.RUnlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, errs.ErrJobNotFound.FastGenByArgs(id)
+	}
+	return job.Clone(), nil
+}
+
+// GetJobs returns all jobs ordered by ID.
+func (m *Manager) GetJobs() []*Job {
+	m.RLock()
+	defer m.RUnlock()
+	jobs := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job.Clone())
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	return jobs
+}
+
+// Cancel cancels a pending or running job.
+func (m *Manager) Cancel(id uint64) error {
+	m.Lock()
+	defer m.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return errs.ErrJobNotFound.FastGenByArgs(id)
+	}
+	if job.State.IsDone() {
+		return errs.ErrJobDone.FastGenByArgs(id, job.State)
+	}
+	if err := m.updateLocked(job, func(j *Job) { j.State = Cancelled }); err != nil {
+		return err
+	}
+	if cancel, ok := m.cancels[id]; ok {
+		cancel()
+	}
+	log.Info("cancel job", zap.Uint64("job-id", id), zap.String("type", job.Type))
+	return nil
+}
+
+func (m *Manager) runLocked(job *Job) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.cancels[job.ID] = cancel
+	runner := m.runners[job.Type]
+	m.wg.Add(1)
+	go func() {
+		defer logutil.LogPanic()
+		defer m.wg.Done()
+		defer cancel()
+		if runner == nil {
+			m.finish(job.ID, errs.ErrJobTypeNotSupported.FastGenByArgs(job.Type))
+			return
+		}
+		m.Lock()
+		if job.State.IsDone() {
+			delete(m.cancels, job.ID)
+			m.Unlock()
+			return
+		}
+		err := m.updateLocked(job, func(j *Job) { j.State = Running })
+		cloned := job.Clone()
+		m.Unlock()
+		if err != nil {
+			log.Error("failed to start job", zap.Uint64("job-id", job.ID), errs.ZapError(err))
+			return
+		}
+		m.finish(job.ID, runner(ctx, cloned, &reporter{m: m, id: job.ID}))
+	}()
+}
+
+func (m *Manager) finish(id uint64, err error) {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.cancels, id)
+	job := m.jobs[id]
+	// the job is cancelled, or the manager is stopped and the job will be
+	// resumed later.
+	if job.State.IsDone() || m.ctx.Err() != nil {
+		return
+	}
+	if e := m.updateLocked(job, func(j *Job) {
+		if err != nil {
+			j.State, j.Error = Failed, err.Error()
+		} else {
+			j.State, j.Progress = Finished, 1
+		}
+	}); e != nil {
+		log.Error("failed to finish job", zap.Uint64("job-id", id), errs.ZapError(e))
+		return
+	}
+	if err != nil {
+		log.Warn("job failed", zap.Uint64("job-id", id), zap.String("type", job.Type), errs.ZapError(err))
+		return
+	}
+	log.Info("job finished", zap.Uint64("job-id", id), zap.String("type", job.Type))
+}
+
+// updateLocked applies the change to a copy of the job, and replaces the job
+// only if the copy is persisted.
+func (m *Manager) updateLocked(job *Job, f func(*Job)) error {
+	updated := job.Clone()
+	f(updated)
+	updated.UpdateTime = time.Now()
+	if err := m.saveLocked(updated); err != nil {
+		return err
+	}
+	*job = *updated
+	return nil
+}
+
+func (m *Manager) saveLocked(job *Job) error {
+	return m.storage.SaveJSON(jobPath, jobKey(job.ID), job)
+}
+
+type reporter struct {
+	m  *Manager
+	id uint64
+}
+
+func (r *reporter) Report(progress float64, checkpoint interface{}) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return errs.ErrJSONMarshal.Wrap(err).FastGenWithCause()
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	job := r.m.jobs[r.id]
+	if job.State != Running {
+		return errs.ErrJobDone.FastGenByArgs(r.id, job.State)
+	}
+	return r.m.updateLocked(job, func(j *Job) {
+		j.Progress, j.Checkpoint = progress, data
+	})
+}
@@ -0,0 +1,258 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/mock/mockid"
+	"github.com/tikv/pd/pkg/testutil"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+	"go.uber.org/goleak"
+)
+
+func TestJob(t *testing.T) {
+	TestingT(t)
+}
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m, testutil.LeakOptions...)
+}
+
+var _ = Suite(&testManagerSuite{})
+
+type testManagerSuite struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	storage *core.Storage
+	idAlloc *mockid.IDAllocator
+}
+
+func (s *testManagerSuite) SetUpTest(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.storage = core.NewStorage(kv.NewMemoryKV())
+	s.idAlloc = mockid.NewIDAllocator()
+}
+
+func (s *testManagerSuite) TearDownTest(c *C) {
+	s.cancel()
+}
+
+// blockingRunner reports the steps it has done, and blocks at each step
+// until it is told to continue.
+type blockingRunner struct {
+	steps chan struct{}
+	start chan int
+}
+
+func newBlockingRunner() *blockingRunner {
+	return &blockingRunner{steps: make(chan struct{}), start: make(chan int, 10)}
+}
+
+func (b *blockingRunner) run(ctx context.Context, job *Job, r Reporter) error {
+	var step int
+	if len(job.Checkpoint) > 0 {
+		if err := json.Unmarshal(job.Checkpoint, &step); err != nil {
+			return err
+		}
+	}
+	b.start <- step
+	for ; step < 4; step++ {
+		select {
+		case <-b.steps:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := r.Report(float64(step+1)/4, step+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *testManagerSuite) newManager() *Manager {
+	m := NewManager(s.ctx, s.storage, s.idAlloc)
+	m.Register("fail", func(ctx context.Context, job *Job, r Reporter) error {
+		return errors.New("mock error")
+	})
+	return m
+}
+
+func (s *testManagerSuite) waitState(c *C, m *Manager, id uint64, state State) *Job {
+	var job *Job
+	testutil.WaitUntil(c, func(c *C) bool {
+		var err error
+		job, err = m.GetJob(id)
+		c.Assert(err, IsNil)
+		return job.State == state
+	})
+	return job
+}
+
+func (s *testManagerSuite) TestRun(c *C) {
+	m := s.newManager()
+	runner := newBlockingRunner()
+	m.Register("block", runner.run)
+	c.Assert(m.Start(), IsNil)
+	defer m.Stop()
+
+	_, err := m.Submit("unknown", nil)
+	c.Assert(errs.ErrJobTypeNotSupported.Equal(err), IsTrue)
+
+	job, err := m.Submit("fail", nil)
+	c.Assert(err, IsNil)
+	job = s.waitState(c, m, job.ID, Failed)
+	c.Assert(job.Error, Equals, "mock error")
+
+	job, err = m.Submit("block", json.RawMessage(`{"key":"value"}`))
+	c.Assert(err, IsNil)
+	c.Assert(<-runner.start, Equals, 0)
+	runner.steps <- struct{}{}
+	runner.steps <- struct{}{}
+	testutil.WaitUntil(c, func(c *C) bool {
+		job, err = m.GetJob(job.ID)
+		c.Assert(err, IsNil)
+		return job.Progress == 0.5
+	})
+	c.Assert(job.State, Equals, Running)
+	c.Assert(string(job.Params), Equals, `{"key":"value"}`)
+	c.Assert(string(job.Checkpoint), Equals, "2")
+	runner.steps <- struct{}{}
+	runner.steps <- struct{}{}
+	job = s.waitState(c, m, job.ID, Finished)
+	c.Assert(job.Progress, Equals, 1.0)
+
+	jobs := m.GetJobs()
+	c.Assert(jobs, HasLen, 2)
+	c.Assert(jobs[0].State, Equals, Failed)
+	c.Assert(jobs[1].State, Equals, Finished)
+	c.Assert(errs.ErrJobDone.Equal(m.Cancel(job.ID)), IsTrue)
+	_, err = m.GetJob(100)
+	c.Assert(errs.ErrJobNotFound.Equal(err), IsTrue)
+}
+
+func (s *testManagerSuite) TestCancel(c *C) {
+	m := s.newManager()
+	runner := newBlockingRunner()
+	m.Register("block", runner.run)
+	c.Assert(m.Start(), IsNil)
+	defer m.Stop()
+
+	job, err := m.Submit("block", nil)
+	c.Assert(err, IsNil)
+	<-runner.start
+	runner.steps <- struct{}{}
+	testutil.WaitUntil(c, func(c *C) bool {
+		job, err = m.GetJob(job.ID)
+		c.Assert(err, IsNil)
+		return string(job.Checkpoint) == "1"
+	})
+	c.Assert(m.Cancel(job.ID), IsNil)
+	job = s.waitState(c, m, job.ID, Cancelled)
+	c.Assert(errs.ErrJobNotFound.Equal(m.Cancel(100)), IsTrue)
+
+	// The cancelled job is not resumed.
+	m.Stop()
+	m = s.newManager()
+	m.Register("block", runner.run)
+	c.Assert(m.Start(), IsNil)
+	defer m.Stop()
+	job, err = m.GetJob(job.ID)
+	c.Assert(err, IsNil)
+	c.Assert(job.State, Equals, Cancelled)
+	c.Assert(string(job.Checkpoint), Equals, "1")
+}
+
+func (s *testManagerSuite) TestResume(c *C) {
+	m := s.newManager()
+	runner := newBlockingRunner()
+	m.Register("block", runner.run)
+	c.Assert(m.Start(), IsNil)
+
+	job, err := m.Submit("block", nil)
+	c.Assert(err, IsNil)
+	<-runner.start
+	runner.steps <- struct{}{}
+	runner.steps <- struct{}{}
+	runner.steps <- struct{}{}
+	testutil.WaitUntil(c, func(c *C) bool {
+		job, err = m.GetJob(job.ID)
+		c.Assert(err, IsNil)
+		return string(job.Checkpoint) == "3"
+	})
+	// The job is kept running when the manager is stopped, like the leader
+	// is changed.
+	m.Stop()
+	job, err = m.GetJob(job.ID)
+	c.Assert(err, IsNil)
+	c.Assert(job.State, Equals, Running)
+
+	// The new manager resumes the job from the checkpoint.
+	m = s.newManager()
+	m.Register("block", runner.run)
+	c.Assert(m.Start(), IsNil)
+	defer m.Stop()
+	c.Assert(<-runner.start, Equals, 3)
+	runner.steps <- struct{}{}
+	job = s.waitState(c, m, job.ID, Finished)
+	c.Assert(job.Progress, Equals, 1.0)
+
+	// The job is failed if its type is not registered anymore.
+	job, err = m.Submit("block", nil)
+	c.Assert(err, IsNil)
+	<-runner.start
+	m.Stop()
+	m = s.newManager()
+	c.Assert(m.Start(), IsNil)
+	defer m.Stop()
+	job = s.waitState(c, m, job.ID, Failed)
+	c.Assert(job.Error, Matches, ".*not supported.*")
+}
+
+func (s *testManagerSuite) TestGC(c *C) {
+	m := s.newManager()
+	c.Assert(m.Start(), IsNil)
+	job, err := m.Submit("fail", nil)
+	c.Assert(err, IsNil)
+	s.waitState(c, m, job.ID, Failed)
+	m.Stop()
+
+	// The job which can not be decoded is skipped.
+	c.Assert(s.storage.Save(path.Join(jobPath, jobKey(100)), "{"), IsNil)
+	m = s.newManager()
+	c.Assert(m.Start(), IsNil)
+	defer m.Stop()
+	c.Assert(m.GetJobs(), HasLen, 1)
+
+	// The done job is removed after the retention.
+	m.Lock()
+	m.gcLocked(time.Now())
+	m.Unlock()
+	c.Assert(m.GetJobs(), HasLen, 1)
+	m.Lock()
+	m.gcLocked(time.Now().Add(jobRetention + time.Second))
+	m.Unlock()
+	c.Assert(m.GetJobs(), HasLen, 0)
+	var keys []string
+	c.Assert(s.storage.LoadRangeByPrefix(jobPath+"/", func(k, v string) { keys = append(keys, k) }), IsNil)
+	c.Assert(keys, DeepEquals, []string{jobKey(100)})
+}
@@ -115,6 +115,34 @@ func (kv *etcdKVBase) Remove(key string) error {
 	return nil
 }
 
+func (kv *etcdKVBase) SaveBatch(ops []Op, conds ...Cond) error {
+	cmps := make([]clientv3.Cmp, 0, len(conds))
+	for _, cond := range conds {
+		cmps = append(cmps, clientv3.Compare(clientv3.Value(path.Join(kv.rootPath, cond.Key)), "=", cond.Value))
+	}
+	etcdOps := make([]clientv3.Op, 0, len(ops))
+	for _, op := range ops {
+		key := path.Join(kv.rootPath, op.Key)
+		if op.Remove {
+			etcdOps = append(etcdOps, clientv3.OpDelete(key))
+		} else {
+			etcdOps = append(etcdOps, clientv3.OpPut(key, op.Value))
+		}
+	}
+
+	txn := NewSlowLogTxn(kv.client)
+	resp, err := txn.If(cmps...).Then(etcdOps...).Commit()
+	if err != nil {
+		e := errs.ErrEtcdKVPut.Wrap(err).GenWithStackByCause()
+		log.Error("save batch to etcd meet error", zap.Int("ops", len(ops)), errs.ZapError(e))
+		return e
+	}
+	if !resp.Succeeded {
+		return errs.ErrKVCondNotMet.FastGenByArgs()
+	}
+	return nil
+}
+
 // SlowLogTxn wraps etcd transaction and log slow one.
 type SlowLogTxn struct {
 	clientv3.Txn
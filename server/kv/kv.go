@@ -19,4 +19,20 @@ type Base interface {
 	LoadRange(key, endKey string, limit int) (keys []string, values []string, err error)
 	Save(key, value string) error
 	Remove(key string) error
+	// SaveBatch writes the ops atomically only if all the conditions are met,
+	// otherwise nothing is written and ErrKVCondNotMet is returned.
+	SaveBatch(ops []Op, conds ...Cond) error
+}
+
+// Op is a write in a batch, the key is removed if Remove is set.
+type Op struct {
+	Key    string
+	Value  string
+	Remove bool
+}
+
+// Cond is a condition of a batch, the key should have the value.
+type Cond struct {
+	Key   string
+	Value string
 }
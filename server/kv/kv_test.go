@@ -54,6 +54,7 @@ func (s *testKVSuite) TestEtcd(c *C) {
 	kv := NewEtcdKVBase(client, rootPath)
 	s.testReadWrite(c, kv)
 	s.testRange(c, kv)
+	s.testBatch(c, kv)
 }
 
 func (s *testKVSuite) TestLevelDB(c *C) {
@@ -65,12 +66,14 @@ func (s *testKVSuite) TestLevelDB(c *C) {
 
 	s.testReadWrite(c, kv)
 	s.testRange(c, kv)
+	s.testBatch(c, kv)
 }
 
 func (s *testKVSuite) TestMemKV(c *C) {
 	kv := NewMemoryKV()
 	s.testReadWrite(c, kv)
 	s.testRange(c, kv)
+	s.testBatch(c, kv)
 }
 
 func (s *testKVSuite) testReadWrite(c *C, kv Base) {
@@ -126,6 +129,21 @@ func (s *testKVSuite) testRange(c *C, kv Base) {
 	}
 }
 
+func (s *testKVSuite) testBatch(c *C, kv Base) {
+	c.Assert(kv.Save("batch/a", "a"), IsNil)
+	c.Assert(kv.Save("batch/leader", "pd1"), IsNil)
+	ops := []Op{{Key: "batch/a", Remove: true}, {Key: "batch/b", Value: "b"}, {Key: "batch/c", Value: "c"}}
+	// Nothing is written if the conditions are not met.
+	c.Assert(kv.SaveBatch(ops, Cond{Key: "batch/leader", Value: "pd2"}), NotNil)
+	keys, _, err := kv.LoadRange("batch/", clientv3.GetPrefixRangeEnd("batch/"), 0)
+	c.Assert(err, IsNil)
+	c.Assert(keys, DeepEquals, []string{"batch/a", "batch/leader"})
+	c.Assert(kv.SaveBatch(ops, Cond{Key: "batch/leader", Value: "pd1"}), IsNil)
+	keys, _, err = kv.LoadRange("batch/", clientv3.GetPrefixRangeEnd("batch/"), 0)
+	c.Assert(err, IsNil)
+	c.Assert(keys, DeepEquals, []string{"batch/b", "batch/c", "batch/leader"})
+}
+
 func newTestSingleConfig() *embed.Config {
 	cfg := embed.NewConfig()
 	cfg.Name = "test_etcd"
@@ -76,6 +76,32 @@ func (kv *LeveldbKV) Remove(key string) error {
 	return errors.WithStack(kv.Delete([]byte(key), nil))
 }
 
+// SaveBatch writes the ops in a leveldb batch, the conditions are checked
+// before the batch is written.
+func (kv *LeveldbKV) SaveBatch(ops []Op, conds ...Cond) error {
+	for _, cond := range conds {
+		value, err := kv.Load(cond.Key)
+		if err != nil {
+			return err
+		}
+		if value != cond.Value {
+			return errs.ErrKVCondNotMet.FastGenByArgs()
+		}
+	}
+	batch := new(leveldb.Batch)
+	for _, op := range ops {
+		if op.Remove {
+			batch.Delete([]byte(op.Key))
+		} else {
+			batch.Put([]byte(op.Key), []byte(op.Value))
+		}
+	}
+	if err := kv.Write(batch, nil); err != nil {
+		return errs.ErrLevelDBWrite.Wrap(err).GenWithStackByCause()
+	}
+	return nil
+}
+
 // SaveRegions stores some regions.
 func (kv *LeveldbKV) SaveRegions(regions map[string]*metapb.Region) error {
 	batch := new(leveldb.Batch)
@@ -17,6 +17,7 @@ import (
 	"sync"
 
 	"github.com/google/btree"
+	"github.com/tikv/pd/pkg/errs"
 )
 
 type memoryKV struct {
@@ -79,3 +80,25 @@ func (kv *memoryKV) Remove(key string) error {
 	kv.tree.Delete(memoryKVItem{key, ""})
 	return nil
 }
+
+func (kv *memoryKV) SaveBatch(ops []Op, conds ...Cond) error {
+	kv.Lock()
+	defer kv.Unlock()
+	for _, cond := range conds {
+		var value string
+		if item := kv.tree.Get(memoryKVItem{cond.Key, ""}); item != nil {
+			value = item.(memoryKVItem).value
+		}
+		if value != cond.Value {
+			return errs.ErrKVCondNotMet.FastGenByArgs()
+		}
+	}
+	for _, op := range ops {
+		if op.Remove {
+			kv.tree.Delete(memoryKVItem{op.Key, ""})
+		} else {
+			kv.tree.ReplaceOrInsert(memoryKVItem{op.Key, op.Value})
+		}
+	}
+	return nil
+}
@@ -293,6 +293,47 @@ func (m *Member) ResignEtcdLeader(ctx context.Context, from string, nextEtcdLead
 	return m.MoveEtcdLeader(ctx, m.ID(), nextEtcdLeaderID)
 }
 
+func (m *Member) getMemberMetaPrefix() string {
+	return path.Join(m.rootPath, "member") + "/"
+}
+
+// GetMemberMetaIDs returns the IDs of the members whose metadata, like the
+// leader priority and the binary version, is saved in etcd.
+func (m *Member) GetMemberMetaIDs() ([]uint64, error) {
+	prefix := m.getMemberMetaPrefix()
+	res, err := etcdutil.EtcdKVGet(m.client, prefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint64
+	seen := make(map[uint64]struct{})
+	for _, kv := range res.Kvs {
+		idStr := strings.SplitN(strings.TrimPrefix(string(kv.Key), prefix), "/", 2)[0]
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// DeleteMemberMeta removes all the metadata of a member saved in etcd.
+func (m *Member) DeleteMemberMeta(id uint64) error {
+	key := m.getMemberMetaPrefix() + strconv.FormatUint(id, 10) + "/"
+	res, err := m.leadership.LeaderTxn().Then(clientv3.OpDelete(key, clientv3.WithPrefix())).Commit()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !res.Succeeded {
+		return errors.New("delete member meta failed, maybe not pd leader")
+	}
+	return nil
+}
+
 func (m *Member) getMemberLeaderPriorityPath(id uint64) string {
 	return path.Join(m.rootPath, fmt.Sprintf("member/%d/leader_priority", id))
 }
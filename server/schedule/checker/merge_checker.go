@@ -60,15 +60,21 @@ func (m *MergeChecker) RecordRegionSplit(regionIDs []uint64) {
 
 // Check verifies a region's replicas, creating an Operator if need.
 func (m *MergeChecker) Check(region *core.RegionInfo) []*operator.Operator {
-	expireTime := m.startTime.Add(m.opts.GetSplitMergeInterval())
-	if time.Now().Before(expireTime) {
-		checkerCounter.WithLabelValues("merge_checker", "recently-start").Inc()
-		return nil
-	}
+	// regions in a dropped key range are going to be emptied, merge them
+	// without waiting for the split merge interval.
+	if opt.IsRegionInGCRange(m.cluster, region) {
+		checkerCounter.WithLabelValues("merge_checker", "gc-range").Inc()
+	} else {
+		expireTime := m.startTime.Add(m.opts.GetSplitMergeInterval())
+		if time.Now().Before(expireTime) {
+			checkerCounter.WithLabelValues("merge_checker", "recently-start").Inc()
+			return nil
+		}
 
-	if m.splitCache.Exists(region.GetID()) {
-		checkerCounter.WithLabelValues("merge_checker", "recently-split").Inc()
-		return nil
+		if m.splitCache.Exists(region.GetID()) {
+			checkerCounter.WithLabelValues("merge_checker", "recently-split").Inc()
+			return nil
+		}
 	}
 
 	checkerCounter.WithLabelValues("merge_checker", "check").Inc()
@@ -141,7 +147,8 @@ func (m *MergeChecker) Check(region *core.RegionInfo) []*operator.Operator {
 
 func (m *MergeChecker) checkTarget(region, adjacent *core.RegionInfo) bool {
 	return adjacent != nil && !m.cluster.IsRegionHot(adjacent) && AllowMerge(m.cluster, region, adjacent) &&
-		opt.IsRegionHealthy(m.cluster, adjacent) && opt.IsRegionReplicated(m.cluster, adjacent)
+		opt.IsRegionHealthy(m.cluster, adjacent) && opt.IsRegionReplicated(m.cluster, adjacent) &&
+		!opt.IsRegionScheduleDenied(m.cluster, adjacent)
 }
 
 // AllowMerge returns true if two regions can be merged according to the key type.
@@ -169,6 +176,10 @@ func AllowMerge(cluster opt.Cluster, region *core.RegionInfo, adjacent *core.Reg
 		if cluster.GetOpts().IsCrossTableMergeEnabled() {
 			return true
 		}
+		// both regions are dropped, the table boundary is meaningless.
+		if opt.IsRegionInGCRange(cluster, region) && opt.IsRegionInGCRange(cluster, adjacent) {
+			return true
+		}
 		return isTableIDSame(region, adjacent)
 	case core.Raw:
 		return true
@@ -21,6 +21,7 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/codec"
 	"github.com/tikv/pd/pkg/mock/mockcluster"
 	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/server/config"
@@ -476,3 +477,35 @@ func (s *testMergeCheckerSuite) TestCache(c *C) {
 	ops = s.mc.Check(s.regions[1])
 	c.Assert(ops, NotNil)
 }
+
+type gcRangeCluster struct {
+	*mockcluster.Cluster
+	dropped map[uint64]bool
+}
+
+func (c *gcRangeCluster) IsRegionInGCRange(region *core.RegionInfo) bool {
+	return c.dropped[region.GetID()]
+}
+
+func (s *testMergeCheckerSuite) TestGCRange(c *C) {
+	s.cluster.SetSplitMergeInterval(time.Hour)
+	cluster := &gcRangeCluster{Cluster: s.cluster, dropped: map[uint64]bool{}}
+	mc := NewMergeChecker(s.ctx, cluster)
+	mc.RecordRegionSplit([]uint64{s.regions[2].GetID()})
+	c.Assert(mc.Check(s.regions[2]), IsNil)
+
+	// Regions in a dropped range skip the split merge interval.
+	cluster.dropped[s.regions[2].GetID()] = true
+	ops := mc.Check(s.regions[2])
+	c.Assert(ops, NotNil)
+	c.Assert(ops[0].RegionID(), Equals, s.regions[2].GetID())
+	c.Assert(ops[1].RegionID(), Equals, s.regions[1].GetID())
+
+	// Dropped regions can be merged across tables.
+	s.cluster.SetEnableCrossTableMerge(false)
+	table1 := s.regions[1].Clone(core.WithStartKey(codec.EncodeBytes(codec.GenerateTableKey(1))), core.WithEndKey(codec.EncodeBytes(codec.GenerateTableKey(2))))
+	table2 := s.regions[2].Clone(core.WithStartKey(codec.EncodeBytes(codec.GenerateTableKey(2))), core.WithEndKey(codec.EncodeBytes(codec.GenerateTableKey(3))))
+	c.Assert(AllowMerge(cluster, table2, table1), IsFalse)
+	cluster.dropped[table1.GetID()] = true
+	c.Assert(AllowMerge(cluster, table2, table1), IsTrue)
+}
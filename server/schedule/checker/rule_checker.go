@@ -65,6 +65,9 @@ func (c *RuleChecker) Check(region *core.RegionInfo) *operator.Operator {
 			return op
 		}
 	}
+	if op := c.fixLeaderAntiAffinity(region); op != nil {
+		return op
+	}
 	op, err := c.fixOrphanPeers(region, fit)
 	if err != nil {
 		log.Debug("fail to fix orphan peer", errs.ZapError(err))
@@ -201,6 +204,36 @@ func (c *RuleChecker) fixBetterLocation(region *core.RegionInfo, rf *placement.R
 	return operator.CreateMovePeerOperator("move-to-better-location", c.cluster, region, operator.OpReplica, oldStore, newPeer)
 }
 
+// fixLeaderAntiAffinity transfers the leader away if it shares the store with
+// the leaders of the ranges which have anti-affinity with the region.
+func (c *RuleChecker) fixLeaderAntiAffinity(region *core.RegionInfo) *operator.Operator {
+	source := c.cluster.GetLeaderStore(region)
+	if source == nil {
+		return nil
+	}
+	stores := c.ruleManager.GetLeaderAntiAffinityStores(c.cluster, region)
+	if _, ok := stores[source.GetID()]; !ok {
+		return nil
+	}
+	checkerCounter.WithLabelValues("rule_checker", "fix-leader-anti-affinity").Inc()
+	// The placement safeguard excludes the stores of the anti-affinity, and
+	// keeps the leader fit the rules.
+	target := filter.NewCandidates(c.cluster.GetFollowerStores(region)).
+		FilterTarget(c.cluster.GetOpts(), &filter.StoreStateFilter{ActionScope: c.name, TransferLeader: true},
+			filter.NewPlacementLeaderSafeguard(c.name, c.cluster, region, source)).
+		RandomPick()
+	if target == nil {
+		checkerCounter.WithLabelValues("rule_checker", "no-new-leader").Inc()
+		return nil
+	}
+	op, err := operator.CreateTransferLeaderOperator("fix-leader-anti-affinity", c.cluster, region, source.GetID(), target.GetID(), 0)
+	if err != nil {
+		log.Debug("fail to fix leader anti-affinity", errs.ZapError(err))
+		return nil
+	}
+	return op
+}
+
 func (c *RuleChecker) fixOrphanPeers(region *core.RegionInfo, fit *placement.RegionFit) (*operator.Operator, error) {
 	if len(fit.OrphanPeers) == 0 {
 		return nil, nil
@@ -336,3 +336,30 @@ func (s *testRuleCheckerSuite) TestIssue2419(c *C) {
 	c.Assert(op.Step(1).(operator.PromoteLearner).ToStore, Equals, uint64(4))
 	c.Assert(op.Step(2).(operator.RemovePeer).FromStore, Equals, uint64(3))
 }
+
+func (s *testRuleCheckerSuite) TestFixLeaderAntiAffinity(c *C) {
+	s.cluster.AddLeaderStore(1, 1)
+	s.cluster.AddLeaderStore(2, 1)
+	s.cluster.AddLeaderStore(3, 1)
+	s.cluster.AddLeaderStore(4, 1)
+	s.cluster.AddLeaderRegionWithRange(1, "a", "b", 1, 2, 3)
+	s.cluster.AddLeaderRegionWithRange(2, "c", "d", 1, 2, 4)
+	c.Assert(s.rc.Check(s.cluster.GetRegion(1)), IsNil)
+
+	c.Assert(s.ruleManager.SetLeaderAntiAffinity(&placement.LeaderAntiAffinity{
+		ID: "test",
+		Ranges: [2]placement.AntiAffinityRange{
+			{StartKeyHex: hex.EncodeToString([]byte("a")), EndKeyHex: hex.EncodeToString([]byte("b"))},
+			{StartKeyHex: hex.EncodeToString([]byte("c")), EndKeyHex: hex.EncodeToString([]byte("f"))},
+		},
+	}), IsNil)
+	op := s.rc.Check(s.cluster.GetRegion(1))
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "fix-leader-anti-affinity")
+	c.Assert(op.Step(0).(operator.TransferLeader).ToStore, Not(Equals), uint64(1))
+
+	// No follower can be the leader.
+	s.cluster.AddLeaderRegionWithRange(3, "d", "e", 2, 1, 4)
+	s.cluster.AddLeaderRegionWithRange(4, "e", "f", 3, 1, 4)
+	c.Assert(s.rc.Check(s.cluster.GetRegion(1)), IsNil)
+}
@@ -62,6 +62,12 @@ func (c *CheckerController) CheckRegion(region *core.RegionInfo) (bool, []*opera
 		return false, []*operator.Operator{op}
 	}
 
+	// The region is still allowed to leave the joint state above, which keeps
+	// the raft group safe, but nothing else is scheduled.
+	if opt.IsRegionScheduleDenied(c.cluster, region) {
+		return false, nil
+	}
+
 	if c.opts.IsPlacementRulesEnabled() {
 		if opController.OperatorCount(operator.OpReplica) < c.opts.GetReplicaScheduleLimit() {
 			checkerIsBusy = false
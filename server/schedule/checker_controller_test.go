@@ -0,0 +1,67 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/mock/mockcluster"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/hbstream"
+)
+
+var _ = Suite(&testCheckerControllerSuite{})
+
+type testCheckerControllerSuite struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *testCheckerControllerSuite) SetUpSuite(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+func (s *testCheckerControllerSuite) TearDownSuite(c *C) {
+	s.cancel()
+}
+
+type denyCluster struct {
+	*mockcluster.Cluster
+	denied map[uint64]bool
+}
+
+func (c *denyCluster) IsRegionScheduleDenied(region *core.RegionInfo) bool {
+	return c.denied[region.GetID()]
+}
+
+func (s *testCheckerControllerSuite) TestCheckScheduleDeniedRegion(c *C) {
+	opt := config.NewTestOptions()
+	tc := &denyCluster{Cluster: mockcluster.NewCluster(opt), denied: map[uint64]bool{1: true}}
+	stream := hbstream.NewTestHeartbeatStreams(s.ctx, tc.ID, tc, false /* no need to run */)
+	oc := NewOperatorController(s.ctx, tc, stream)
+	cc := NewCheckerController(s.ctx, tc, tc.GetRuleManager(), oc)
+	tc.AddRegionStore(1, 1)
+	tc.AddRegionStore(2, 1)
+	tc.AddRegionStore(3, 1)
+	tc.AddLeaderRegion(1, 1, 2)
+	tc.AddLeaderRegion(2, 1, 2)
+
+	// The region lacks a replica but it is denied to schedule.
+	_, ops := cc.CheckRegion(tc.GetRegion(1))
+	c.Assert(ops, HasLen, 0)
+	_, ops = cc.CheckRegion(tc.GetRegion(2))
+	c.Assert(ops, HasLen, 1)
+}
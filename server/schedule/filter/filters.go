@@ -300,16 +300,18 @@ func (f *StoreStateFilter) exceedAddLimit(opt *config.PersistOptions, store *cor
 
 func (f *StoreStateFilter) tooManySnapshots(opt *config.PersistOptions, store *core.StoreInfo) bool {
 	f.Reason = "too-many-snapshot"
-	return !f.AllowTemporaryStates && (uint64(store.GetSendingSnapCount()) > opt.GetMaxSnapshotCount() ||
-		uint64(store.GetReceivingSnapCount()) > opt.GetMaxSnapshotCount() ||
-		uint64(store.GetApplyingSnapCount()) > opt.GetMaxSnapshotCount())
+	maxSnapshotCount := opt.GetStoreMaxSnapshotCount(store.GetLabels())
+	return !f.AllowTemporaryStates && (uint64(store.GetSendingSnapCount()) > maxSnapshotCount ||
+		uint64(store.GetReceivingSnapCount()) > maxSnapshotCount ||
+		uint64(store.GetApplyingSnapCount()) > maxSnapshotCount)
 }
 
 func (f *StoreStateFilter) tooManyPendingPeers(opt *config.PersistOptions, store *core.StoreInfo) bool {
 	f.Reason = "too-many-pending-peer"
+	maxPendingPeerCount := opt.GetStoreMaxPendingPeerCount(store.GetLabels())
 	return !f.AllowTemporaryStates &&
-		opt.GetMaxPendingPeerCount() > 0 &&
-		store.GetPendingPeerCount() > int(opt.GetMaxPendingPeerCount())
+		maxPendingPeerCount > 0 &&
+		store.GetPendingPeerCount() > int(maxPendingPeerCount)
 }
 
 func (f *StoreStateFilter) hasRejectLeaderProperty(opts *config.PersistOptions, store *core.StoreInfo) bool {
@@ -461,22 +463,25 @@ func (f *ruleFitFilter) Target(opt *config.PersistOptions, store *core.StoreInfo
 }
 
 type ruleLeaderFitFilter struct {
-	scope            string
-	fitter           RegionFitter
-	region           *core.RegionInfo
-	oldFit           *placement.RegionFit
-	oldLeaderStoreID uint64
+	scope              string
+	fitter             RegionFitter
+	region             *core.RegionInfo
+	oldFit             *placement.RegionFit
+	oldLeaderStoreID   uint64
+	antiAffinityStores map[uint64]struct{}
 }
 
 // newRuleLeaderFitFilter creates a filter that ensures after transfer leader with new store,
-// the isolation level will not decrease.
-func newRuleLeaderFitFilter(scope string, fitter RegionFitter, region *core.RegionInfo, oldLeaderStoreID uint64) Filter {
+// the isolation level will not decrease, and the leader anti-affinities are not
+// broken.
+func newRuleLeaderFitFilter(scope string, fitter RegionFitter, region *core.RegionInfo, oldLeaderStoreID uint64, antiAffinityStores map[uint64]struct{}) Filter {
 	return &ruleLeaderFitFilter{
-		scope:            scope,
-		fitter:           fitter,
-		region:           region,
-		oldFit:           fitter.FitRegion(region),
-		oldLeaderStoreID: oldLeaderStoreID,
+		scope:              scope,
+		fitter:             fitter,
+		region:             region,
+		oldFit:             fitter.FitRegion(region),
+		oldLeaderStoreID:   oldLeaderStoreID,
+		antiAffinityStores: antiAffinityStores,
 	}
 }
 
@@ -493,6 +498,9 @@ func (f *ruleLeaderFitFilter) Source(opt *config.PersistOptions, store *core.Sto
 }
 
 func (f *ruleLeaderFitFilter) Target(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	if _, ok := f.antiAffinityStores[store.GetID()]; ok {
+		return false
+	}
 	targetPeer := f.region.GetStorePeer(store.GetID())
 	if targetPeer == nil {
 		log.Warn("ruleLeaderFitFilter couldn't find peer on target Store", zap.Uint64("target-store", store.GetID()))
@@ -515,11 +523,13 @@ func NewPlacementSafeguard(scope string, cluster opt.Cluster, region *core.Regio
 }
 
 // NewPlacementLeaderSafeguard creates a filter that ensures after transfer a leader with
-// existed peer, the placement restriction will not become worse.
+// existed peer, the placement restriction will not become worse, and the leader is
+// not moved to the stores which break the leader anti-affinities.
 // Note that it only worked when PlacementRules enabled otherwise it will always permit the sourceStore.
 func NewPlacementLeaderSafeguard(scope string, cluster opt.Cluster, region *core.RegionInfo, sourceStore *core.StoreInfo) Filter {
 	if cluster.GetOpts().IsPlacementRulesEnabled() {
-		return newRuleLeaderFitFilter(scope, cluster, region, sourceStore.GetID())
+		antiAffinityStores := cluster.GetRuleManager().GetLeaderAntiAffinityStores(cluster, region)
+		return newRuleLeaderFitFilter(scope, cluster, region, sourceStore.GetID(), antiAffinityStores)
 	}
 	return nil
 }
@@ -131,6 +131,29 @@ func (s *testFiltersSuite) TestRuleFitFilter(c *C) {
 	}
 }
 
+func (s *testFiltersSuite) TestLeaderAntiAffinity(c *C) {
+	opt := config.NewTestOptions()
+	testCluster := mockcluster.NewCluster(opt)
+	testCluster.SetEnablePlacementRules(true)
+	for id := uint64(1); id <= 4; id++ {
+		testCluster.AddLeaderStore(id, 1)
+	}
+	testCluster.AddLeaderRegionWithRange(1, "a", "b", 1, 2, 3)
+	testCluster.AddLeaderRegionWithRange(2, "c", "d", 2, 1, 4)
+	c.Assert(testCluster.RuleManager.SetLeaderAntiAffinity(&placement.LeaderAntiAffinity{
+		ID: "test",
+		Ranges: [2]placement.AntiAffinityRange{
+			{StartKeyHex: "61", EndKeyHex: "62"},
+			{StartKeyHex: "63", EndKeyHex: "64"},
+		},
+	}), IsNil)
+
+	region := testCluster.GetRegion(1)
+	filter := NewPlacementLeaderSafeguard("", testCluster, region, testCluster.GetStore(1))
+	c.Assert(filter.Target(testCluster.GetOpts(), testCluster.GetStore(2)), IsFalse)
+	c.Assert(filter.Target(testCluster.GetOpts(), testCluster.GetStore(3)), IsTrue)
+}
+
 func (s *testFiltersSuite) TestStoreStateFilter(c *C) {
 	filters := []Filter{
 		&StoreStateFilter{TransferLeader: true},
@@ -182,6 +205,42 @@ func (s *testFiltersSuite) TestStoreStateFilter(c *C) {
 	check(store, testCases)
 }
 
+func (s *testFiltersSuite) TestStoreClassLimit(c *C) {
+	opt := config.NewTestOptions()
+	cfg := opt.GetScheduleConfig().Clone()
+	cfg.MaxSnapshotCount, cfg.MaxPendingPeerCount = 3, 16
+	cfg.StoreClassLimits = []config.StoreClassLimit{
+		{Key: "disk", Value: "hdd", MaxSnapshotCount: 1, MaxPendingPeerCount: 4},
+		{Key: "disk", Value: "sata", MaxSnapshotCount: 2},
+	}
+	opt.SetScheduleConfig(cfg)
+	filter := &StoreStateFilter{MoveRegion: true}
+
+	testCases := []struct {
+		disk         string
+		snapshots    uint32
+		pendingPeers int
+		target       bool
+	}{
+		{"hdd", 1, 4, true},
+		{"hdd", 2, 0, false},
+		{"hdd", 0, 5, false},
+		{"sata", 2, 16, true},
+		{"sata", 3, 0, false},
+		{"sata", 0, 17, false},
+		{"nvme", 3, 16, true},
+		{"nvme", 4, 0, false},
+	}
+	for _, tc := range testCases {
+		store := core.NewStoreInfoWithLabel(1, 0, map[string]string{"disk": tc.disk}).Clone(
+			core.SetLastHeartbeatTS(time.Now()),
+			core.SetStoreStats(&pdpb.StoreStats{ReceivingSnapCount: tc.snapshots}),
+			core.SetPendingPeerCount(tc.pendingPeers),
+		)
+		c.Assert(filter.Target(opt, store), Equals, tc.target, Commentf("%+v", tc))
+	}
+}
+
 func (s *testFiltersSuite) TestIsolationFilter(c *C) {
 	opt := config.NewTestOptions()
 	testCluster := mockcluster.NewCluster(opt)
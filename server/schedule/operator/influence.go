@@ -47,7 +47,7 @@ func (s StoreInfluence) ResourceProperty(kind core.ScheduleKind) int64 {
 	switch kind.Resource {
 	case core.LeaderKind:
 		switch kind.Policy {
-		case core.ByCount:
+		case core.ByCount, core.ByUtilization:
 			return s.LeaderCount
 		case core.BySize:
 			return s.LeaderSize
@@ -0,0 +1,271 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+const (
+	// operatorAuditRetention is how long the finished operators are kept in
+	// the audit log.
+	operatorAuditRetention = 24 * time.Hour
+	// maxOperatorAudits is the max number of the records kept in the audit
+	// log, the earliest ones are dropped first even if they are in the
+	// retention.
+	maxOperatorAudits = 10000
+	// maxOperatorAuditBatch is the max number of the records saved or removed
+	// in a batch, it is below the default limit of the operations in an etcd
+	// transaction.
+	maxOperatorAuditBatch = 64
+)
+
+// OperatorAudit is an operator which is finished, canceled, replaced, expired
+// or timeout, it is kept in the audit log to find out why a region is changed.
+type OperatorAudit struct {
+	// Seq increases for each finished operator, and is kept after the leader
+	// is changed.
+	Seq        uint64    `json:"seq"`
+	RegionID   uint64    `json:"region_id"`
+	Desc       string    `json:"desc"`
+	Kind       string    `json:"kind"`
+	Status     string    `json:"status"`
+	CreateTime time.Time `json:"create_time"`
+	FinishTime time.Time `json:"finish_time"`
+	// Operator is the description of the operator with its steps.
+	Operator string `json:"operator"`
+	// StartKey and EndKey are the hex encoded key range of the region when
+	// the operator is finished, both are empty if the region is unknown.
+	StartKey string `json:"start_key,omitempty"`
+	EndKey   string `json:"end_key,omitempty"`
+}
+
+// OperatorAuditFilter selects the operators in the audit log, the zero value
+// selects all of them.
+type OperatorAuditFilter struct {
+	// Since and Until select the operators finished in the time window, the
+	// zero Until means now.
+	Since, Until time.Time
+	RegionID     uint64
+	// StartKey and EndKey select the operators of the regions overlapping the
+	// key range, the empty EndKey means the end of the key space. The
+	// operators of which the key ranges are unknown are not selected if any
+	// of them is set.
+	StartKey, EndKey []byte
+}
+
+func (f *OperatorAuditFilter) match(record *OperatorAudit) bool {
+	if record.FinishTime.Before(f.Since) || (!f.Until.IsZero() && record.FinishTime.After(f.Until)) {
+		return false
+	}
+	if f.RegionID != 0 && record.RegionID != f.RegionID {
+		return false
+	}
+	if len(f.StartKey) == 0 && len(f.EndKey) == 0 {
+		return true
+	}
+	if record.StartKey == "" && record.EndKey == "" {
+		return false
+	}
+	startKey, err1 := hex.DecodeString(record.StartKey)
+	endKey, err2 := hex.DecodeString(record.EndKey)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return (len(f.EndKey) == 0 || bytes.Compare(startKey, f.EndKey) < 0) &&
+		(len(endKey) == 0 || bytes.Compare(endKey, f.StartKey) > 0)
+}
+
+func newOperatorAudit(op *operator.Operator, region *core.RegionInfo) *OperatorAudit {
+	finishTime := op.GetReachTimeOf(op.Status())
+	if finishTime.IsZero() {
+		finishTime = time.Now()
+	}
+	record := &OperatorAudit{
+		RegionID:   op.RegionID(),
+		Desc:       op.Desc(),
+		Kind:       op.Kind().String(),
+		Status:     operator.OpStatusToString(op.Status()),
+		CreateTime: op.GetCreateTime(),
+		FinishTime: finishTime,
+		Operator:   op.String(),
+	}
+	if region != nil {
+		record.StartKey = core.HexRegionKeyStr(region.GetStartKey())
+		record.EndKey = core.HexRegionKeyStr(region.GetEndKey())
+	}
+	return record
+}
+
+// operatorAudits keeps the latest operators finished in the retention in
+// memory as a ring buffer, they are saved to storage in background so the
+// audit log survives leader changes.
+type operatorAudits struct {
+	sync.RWMutex
+	records []*OperatorAudit
+	nextSeq uint64
+	// savedSeq is the seq of the first record which is not saved yet.
+	savedSeq uint64
+	// removedSeq is the seq of the first record which is not removed from
+	// storage after it is out of the audit log.
+	removedSeq uint64
+}
+
+func (a *operatorAudits) put(op *operator.Operator, region *core.RegionInfo) {
+	record := newOperatorAudit(op, region)
+	a.Lock()
+	defer a.Unlock()
+	record.Seq = a.nextSeq
+	a.nextSeq++
+	a.records = append(a.records, record)
+	a.records = pruneOperatorAudits(a.records, time.Now())
+}
+
+// pruneOperatorAudits drops the records finished before the retention and the
+// earliest ones beyond the max count, the records are in the order of their
+// seq, which is also roughly the order of the finish time.
+func pruneOperatorAudits(records []*OperatorAudit, now time.Time) []*OperatorAudit {
+	expire := now.Add(-operatorAuditRetention)
+	i := 0
+	if len(records) > maxOperatorAudits {
+		i = len(records) - maxOperatorAudits
+	}
+	for i < len(records) && records[i].FinishTime.Before(expire) {
+		i++
+	}
+	// The dropped records are released, and the array is reallocated with
+	// only the kept ones once it is full.
+	for j := 0; j < i; j++ {
+		records[j] = nil
+	}
+	return records[i:]
+}
+
+func (a *operatorAudits) get(filter OperatorAuditFilter) []*OperatorAudit {
+	a.RLock()
+	defer a.RUnlock()
+	records := make([]*OperatorAudit, 0, len(a.records))
+	for _, record := range a.records {
+		if filter.match(record) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+func (a *operatorAudits) load(storage *core.Storage) error {
+	var (
+		records []*OperatorAudit
+		err     error
+	)
+	if e := storage.LoadOperatorAudits(func(k, v string) {
+		record := &OperatorAudit{}
+		if e := json.Unmarshal([]byte(v), record); e != nil {
+			err = errs.ErrJSONUnmarshal.Wrap(e).GenWithStackByCause()
+			return
+		}
+		records = append(records, record)
+	}); e != nil {
+		return e
+	}
+	if err != nil {
+		return err
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	a.records, a.nextSeq, a.savedSeq, a.removedSeq = nil, 0, 0, 0
+	if len(records) == 0 {
+		return nil
+	}
+	// The keys are sorted by seq, so the first one is the earliest.
+	a.removedSeq = records[0].Seq
+	a.nextSeq = records[len(records)-1].Seq + 1
+	a.savedSeq = a.nextSeq
+	a.records = pruneOperatorAudits(records, time.Now())
+	return nil
+}
+
+// save saves the records which are not saved yet, and removes the records
+// which are out of the audit log from storage, in batches. The batches are
+// only written if the conditions are met, like the server is still the
+// leader, so the seqs do not collide with the ones of the next leader. It
+// must not be called concurrently.
+func (a *operatorAudits) save(storage *core.Storage, conds ...kv.Cond) error {
+	a.RLock()
+	var unsaved []*OperatorAudit
+	for _, record := range a.records {
+		if record.Seq >= a.savedSeq {
+			unsaved = append(unsaved, record)
+		}
+	}
+	saved, removed, removeTo := a.savedSeq, a.removedSeq, a.nextSeq
+	if len(a.records) > 0 {
+		removeTo = a.records[0].Seq
+	}
+	a.RUnlock()
+
+	var err error
+	for err == nil && (len(unsaved) > 0 || removed < removeTo) {
+		batch := make(map[uint64]interface{})
+		for len(unsaved) > 0 && len(batch) < maxOperatorAuditBatch {
+			batch[unsaved[0].Seq] = unsaved[0]
+			unsaved = unsaved[1:]
+		}
+		var removes []uint64
+		for seq := removed; seq < removeTo && len(batch)+len(removes) < maxOperatorAuditBatch; seq++ {
+			removes = append(removes, seq)
+		}
+		if err = storage.SaveOperatorAudits(batch, removes, conds...); err != nil {
+			break
+		}
+		for seq := range batch {
+			if seq >= saved {
+				saved = seq + 1
+			}
+		}
+		removed += uint64(len(removes))
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	a.savedSeq, a.removedSeq = saved, removed
+	return err
+}
+
+// GetOperatorAudits returns the operators selected by the filter, the earliest
+// one is the first.
+func (oc *OperatorController) GetOperatorAudits(filter OperatorAuditFilter) []*OperatorAudit {
+	return oc.audits.get(filter)
+}
+
+// LoadOperatorAudits loads the audit log saved by the previous leader.
+func (oc *OperatorController) LoadOperatorAudits(storage *core.Storage) error {
+	return oc.audits.load(storage)
+}
+
+// SaveOperatorAudits saves the operators finished since last saved, only if
+// the conditions are met.
+func (oc *OperatorController) SaveOperatorAudits(storage *core.Storage, conds ...kv.Cond) error {
+	return oc.audits.save(storage, conds...)
+}
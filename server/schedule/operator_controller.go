@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/cache"
 	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/core/storelimit"
 	"github.com/tikv/pd/server/schedule/hbstream"
@@ -64,6 +65,7 @@ type OperatorController struct {
 	histories       *list.List
 	counts          map[operator.OpKind]uint64
 	opRecords       *OperatorRecords
+	audits          operatorAudits
 	storesLimit     map[uint64]map[storelimit.Type]*storelimit.StoreLimit
 	wop             WaitingOperator
 	wopStatus       *WaitingOperatorStatus
@@ -576,6 +578,7 @@ func (oc *OperatorController) buryOperator(op *operator.Operator, extraFields ..
 	}
 
 	oc.opRecords.Put(op)
+	oc.audits.put(op, oc.cluster.GetRegion(op.RegionID()))
 }
 
 // GetOperatorStatus gets the operator and its status with the specify id.
@@ -617,10 +620,13 @@ func (oc *OperatorController) GetWaitingOperators() []*operator.Operator {
 
 // SendScheduleCommand sends a command to the region.
 func (oc *OperatorController) SendScheduleCommand(region *core.RegionInfo, step operator.OpStep, source string) {
-	log.Info("send schedule command",
-		zap.Uint64("region-id", region.GetID()),
-		zap.Stringer("step", step),
-		zap.String("source", source))
+	if ok, suppressed := logutil.Sample(logutil.SampleKeyOperatorStep); ok {
+		log.Info("send schedule command",
+			zap.Uint64("region-id", region.GetID()),
+			zap.Stringer("step", step),
+			zap.String("source", source),
+			zap.Uint64("suppressed", suppressed))
+	}
 
 	var cmd *pdpb.RegionHeartbeatResponse
 	switch st := step.(type) {
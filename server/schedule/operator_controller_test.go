@@ -29,6 +29,7 @@ import (
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/core/storelimit"
+	"github.com/tikv/pd/server/kv"
 	"github.com/tikv/pd/server/schedule/checker"
 	"github.com/tikv/pd/server/schedule/hbstream"
 	"github.com/tikv/pd/server/schedule/operator"
@@ -131,6 +132,108 @@ func (t *testOperatorControllerSuite) TestOperatorStatus(c *C) {
 	c.Assert(oc.GetOperatorStatus(2).Status, Equals, pdpb.OperatorStatus_SUCCESS)
 }
 
+func (t *testOperatorControllerSuite) TestOperatorAudits(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+	stream := hbstream.NewTestHeartbeatStreams(t.ctx, tc.ID, tc, false /* no need to run */)
+	oc := NewOperatorController(t.ctx, tc, stream)
+	tc.AddLeaderStore(1, 2)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderRegionWithRange(1, "a", "b", 1, 2)
+	tc.AddLeaderRegionWithRange(2, "b", "c", 1, 2)
+	steps := []operator.OpStep{
+		operator.RemovePeer{FromStore: 2},
+	}
+	op1 := operator.NewOperator("test", "test", 1, &metapb.RegionEpoch{}, operator.OpRegion, steps...)
+	op2 := operator.NewOperator("test", "test", 2, &metapb.RegionEpoch{}, operator.OpRegion, steps...)
+	c.Assert(op1.Start(), IsTrue)
+	oc.SetOperator(op1)
+	c.Assert(op2.Start(), IsTrue)
+	oc.SetOperator(op2)
+	c.Assert(oc.GetOperatorAudits(OperatorAuditFilter{}), HasLen, 0)
+	c.Assert(oc.RemoveOperator(op1), IsTrue)
+	ApplyOperator(tc, op2)
+	oc.Dispatch(tc.GetRegion(2), "test")
+
+	audits := oc.GetOperatorAudits(OperatorAuditFilter{})
+	c.Assert(audits, HasLen, 2)
+	c.Assert(audits[0].RegionID, Equals, uint64(1))
+	c.Assert(audits[0].Status, Equals, "Canceled")
+	c.Assert(audits[1].RegionID, Equals, uint64(2))
+	c.Assert(audits[1].Status, Equals, "Success")
+	c.Assert(audits[1].Seq, Equals, uint64(1))
+	audits = oc.GetOperatorAudits(OperatorAuditFilter{RegionID: 2})
+	c.Assert(audits, HasLen, 1)
+	c.Assert(audits[0].RegionID, Equals, uint64(2))
+	c.Assert(oc.GetOperatorAudits(OperatorAuditFilter{Since: time.Now().Add(time.Minute)}), HasLen, 0)
+	c.Assert(oc.GetOperatorAudits(OperatorAuditFilter{Until: time.Now().Add(-time.Minute)}), HasLen, 0)
+
+	// The operators are selected by the key ranges of the regions.
+	c.Assert(audits[0].StartKey, Equals, "62")
+	c.Assert(audits[0].EndKey, Equals, "63")
+	for _, t := range []struct {
+		startKey, endKey string
+		regionIDs        []uint64
+	}{
+		{"a", "b", []uint64{1}},
+		{"a", "bb", []uint64{1, 2}},
+		{"bb", "", []uint64{2}},
+		{"", "a", nil},
+		{"c", "", nil},
+	} {
+		var regionIDs []uint64
+		for _, audit := range oc.GetOperatorAudits(OperatorAuditFilter{StartKey: []byte(t.startKey), EndKey: []byte(t.endKey)}) {
+			regionIDs = append(regionIDs, audit.RegionID)
+		}
+		c.Assert(regionIDs, DeepEquals, t.regionIDs, Commentf("[%s, %s)", t.startKey, t.endKey))
+	}
+
+	// The audit log is loaded by the next leader.
+	storage := core.NewStorage(kv.NewMemoryKV())
+	c.Assert(oc.SaveOperatorAudits(storage), IsNil)
+	oc = NewOperatorController(t.ctx, tc, stream)
+	c.Assert(oc.LoadOperatorAudits(storage), IsNil)
+	c.Assert(oc.GetOperatorAudits(OperatorAuditFilter{RegionID: 1}), HasLen, 1)
+	c.Assert(oc.GetOperatorAudits(OperatorAuditFilter{}), HasLen, 2)
+
+	// The records out of the retention are removed from storage in batches.
+	for _, record := range oc.audits.records {
+		record.FinishTime = record.FinishTime.Add(-operatorAuditRetention)
+	}
+	n := maxOperatorAuditBatch*2 + 1
+	for i := 0; i < n; i++ {
+		oc.audits.put(op1, nil)
+	}
+	audits = oc.GetOperatorAudits(OperatorAuditFilter{})
+	c.Assert(audits, HasLen, n)
+	c.Assert(audits[0].Seq, Equals, uint64(2))
+	// The operators of the unknown regions are not selected by the key range.
+	c.Assert(oc.GetOperatorAudits(OperatorAuditFilter{StartKey: []byte("a")}), HasLen, 0)
+	// The audit log is not saved if the conditions are not met, like the
+	// leadership is lost.
+	c.Assert(storage.Save("leader", "pd1"), IsNil)
+	c.Assert(oc.SaveOperatorAudits(storage, kv.Cond{Key: "leader", Value: "pd2"}), NotNil)
+	var keys []string
+	c.Assert(storage.LoadOperatorAudits(func(k, v string) { keys = append(keys, k) }), IsNil)
+	c.Assert(keys, HasLen, 2)
+	c.Assert(oc.SaveOperatorAudits(storage, kv.Cond{Key: "leader", Value: "pd1"}), IsNil)
+	keys = nil
+	c.Assert(storage.LoadOperatorAudits(func(k, v string) { keys = append(keys, k) }), IsNil)
+	c.Assert(keys, HasLen, n)
+
+	// The earliest records are dropped beyond the max count.
+	for i := 0; i < maxOperatorAudits; i++ {
+		oc.audits.put(op1, nil)
+	}
+	audits = oc.GetOperatorAudits(OperatorAuditFilter{})
+	c.Assert(audits, HasLen, maxOperatorAudits)
+	c.Assert(audits[0].Seq, Equals, uint64(2+n))
+	c.Assert(oc.SaveOperatorAudits(storage), IsNil)
+	keys = nil
+	c.Assert(storage.LoadOperatorAudits(func(k, v string) { keys = append(keys, k) }), IsNil)
+	c.Assert(keys, HasLen, maxOperatorAudits)
+}
+
 func (t *testOperatorControllerSuite) TestFastFailOperator(c *C) {
 	opt := config.NewTestOptions()
 	tc := mockcluster.NewCluster(opt)
@@ -59,3 +59,29 @@ func IsRegionReplicated(cluster Cluster, region *core.RegionInfo) bool {
 func ReplicatedRegion(cluster Cluster) func(*core.RegionInfo) bool {
 	return func(region *core.RegionInfo) bool { return IsRegionReplicated(cluster, region) }
 }
+
+// IsRegionInGCRange checks if a region is entirely in a key range dropped by
+// the database layer, which means the region is going to be emptied by GC.
+func IsRegionInGCRange(cluster Cluster, region *core.RegionInfo) bool {
+	type withGCRanges interface {
+		IsRegionInGCRange(region *core.RegionInfo) bool
+	}
+	cl, ok := cluster.(withGCRanges)
+	return ok && cl.IsRegionInGCRange(region)
+}
+
+// IsRegionScheduleDenied checks if a region is labeled to deny scheduling by
+// a region label rule.
+func IsRegionScheduleDenied(cluster Cluster, region *core.RegionInfo) bool {
+	type withRegionLabels interface {
+		IsRegionScheduleDenied(region *core.RegionInfo) bool
+	}
+	cl, ok := cluster.(withRegionLabels)
+	return ok && cl.IsRegionScheduleDenied(region)
+}
+
+// ScheduleAllowedRegion returns a function that checks if a region is not
+// labeled to deny scheduling.
+func ScheduleAllowedRegion(cluster Cluster) func(*core.RegionInfo) bool {
+	return func(region *core.RegionInfo) bool { return !IsRegionScheduleDenied(cluster, region) }
+}
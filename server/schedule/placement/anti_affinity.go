@@ -0,0 +1,291 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+// AntiAffinityRange is a key range of a LeaderAntiAffinity.
+type AntiAffinityRange struct {
+	StartKey    []byte `json:"-"`         // range start key
+	StartKeyHex string `json:"start_key"` // hex format start key, for marshal/unmarshal
+	EndKey      []byte `json:"-"`         // range end key
+	EndKeyHex   string `json:"end_key"`   // hex format end key, for marshal/unmarshal
+}
+
+func (r *AntiAffinityRange) overlaps(region *core.RegionInfo) bool {
+	return (len(r.EndKey) == 0 || bytes.Compare(region.GetStartKey(), r.EndKey) < 0) &&
+		(len(region.GetEndKey()) == 0 || bytes.Compare(region.GetEndKey(), r.StartKey) > 0)
+}
+
+// RegionScanner represents the region container which can scan a key range.
+type RegionScanner interface {
+	ScanRegions(startKey, endKey []byte, limit int) []*core.RegionInfo
+}
+
+// LeaderAntiAffinity is a constraint between two key ranges that the leaders of
+// the regions in one range must not share stores with the leaders of the
+// regions in the other. It is useful to isolate the load of two hot tables
+// from each other without dedicating stores to them.
+type LeaderAntiAffinity struct {
+	ID     string               `json:"id"`
+	Ranges [2]AntiAffinityRange `json:"ranges"`
+}
+
+func (a *LeaderAntiAffinity) String() string {
+	b, _ := json.Marshal(a)
+	return string(b)
+}
+
+// check and adjust leader anti-affinity from client or storage.
+func adjustLeaderAntiAffinity(a *LeaderAntiAffinity) error {
+	if a.ID == "" {
+		return errs.ErrRuleContent.FastGenByArgs("ID should not be empty")
+	}
+	for i := range a.Ranges {
+		r := &a.Ranges[i]
+		var err error
+		r.StartKey, err = hex.DecodeString(r.StartKeyHex)
+		if err != nil {
+			return errs.ErrHexDecodingString.FastGenByArgs(r.StartKeyHex)
+		}
+		r.EndKey, err = hex.DecodeString(r.EndKeyHex)
+		if err != nil {
+			return errs.ErrHexDecodingString.FastGenByArgs(r.EndKeyHex)
+		}
+		if len(r.EndKey) > 0 && bytes.Compare(r.EndKey, r.StartKey) <= 0 {
+			return errs.ErrRuleContent.FastGenByArgs("endKey should be greater than startKey")
+		}
+	}
+	a0, a1 := a.Ranges[0], a.Ranges[1]
+	if (len(a0.EndKey) == 0 || bytes.Compare(a1.StartKey, a0.EndKey) < 0) &&
+		(len(a1.EndKey) == 0 || bytes.Compare(a0.StartKey, a1.EndKey) < 0) {
+		return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("the ranges of leader anti-affinity %s overlap", a.ID))
+	}
+	return nil
+}
+
+func (m *RuleManager) loadLeaderAntiAffinities() error {
+	return m.store.LoadLeaderAntiAffinities(func(k, v string) {
+		var a LeaderAntiAffinity
+		if err := json.Unmarshal([]byte(v), &a); err != nil {
+			log.Error("failed to unmarshal leader anti-affinity", zap.String("anti-affinity-id", k), errs.ZapError(errs.ErrLoadLeaderAntiAffinity, err))
+			return
+		}
+		if err := adjustLeaderAntiAffinity(&a); err != nil {
+			log.Error("leader anti-affinity is in bad format", zap.String("anti-affinity-id", k), errs.ZapError(errs.ErrLoadLeaderAntiAffinity, err))
+			return
+		}
+		m.antiAffinities[a.ID] = &a
+	})
+}
+
+// GetLeaderAntiAffinity returns the LeaderAntiAffinity with the ID.
+func (m *RuleManager) GetLeaderAntiAffinity(id string) *LeaderAntiAffinity {
+	m.RLock()
+	defer m.RUnlock()
+	return m.antiAffinities[id]
+}
+
+// GetLeaderAntiAffinities returns all the LeaderAntiAffinity sorted by IDs.
+func (m *RuleManager) GetLeaderAntiAffinities() []*LeaderAntiAffinity {
+	m.RLock()
+	defer m.RUnlock()
+	antiAffinities := make([]*LeaderAntiAffinity, 0, len(m.antiAffinities))
+	for _, a := range m.antiAffinities {
+		antiAffinities = append(antiAffinities, a)
+	}
+	sort.Slice(antiAffinities, func(i, j int) bool { return antiAffinities[i].ID < antiAffinities[j].ID })
+	return antiAffinities
+}
+
+// SetLeaderAntiAffinity inserts or updates a LeaderAntiAffinity.
+func (m *RuleManager) SetLeaderAntiAffinity(a *LeaderAntiAffinity) error {
+	if err := adjustLeaderAntiAffinity(a); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+	if err := m.store.SaveLeaderAntiAffinity(a.ID, a); err != nil {
+		return err
+	}
+	m.antiAffinities[a.ID] = a
+	m.resetAntiAffinityIndex(a.ID)
+	log.Info("leader anti-affinity updated", zap.String("anti-affinity", a.String()))
+	return nil
+}
+
+// DeleteLeaderAntiAffinity removes a LeaderAntiAffinity.
+func (m *RuleManager) DeleteLeaderAntiAffinity(id string) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.store.DeleteLeaderAntiAffinity(id); err != nil {
+		return err
+	}
+	delete(m.antiAffinities, id)
+	m.resetAntiAffinityIndex(id)
+	log.Info("leader anti-affinity removed", zap.String("anti-affinity-id", id))
+	return nil
+}
+
+// antiAffinityIndex is the leaders of the regions in a range of a
+// LeaderAntiAffinity. It is built by scanning the range once, then kept up to
+// date by UpdateAntiAffinityIndex, so that looking up the stores is not proportional to
+// the number of the regions.
+type antiAffinityIndex struct {
+	r       AntiAffinityRange
+	leaders map[uint64]uint64 // region ID -> store ID of the leader
+	stores  map[uint64]int    // store ID -> the number of the leaders
+}
+
+type antiAffinityIndexes struct {
+	a      *LeaderAntiAffinity
+	ranges [2]*antiAffinityIndex
+}
+
+func newAntiAffinityIndex(r AntiAffinityRange, regions RegionScanner) *antiAffinityIndex {
+	idx := &antiAffinityIndex{
+		r:       r,
+		leaders: make(map[uint64]uint64),
+		stores:  make(map[uint64]int),
+	}
+	for _, region := range regions.ScanRegions(r.StartKey, r.EndKey, 0) {
+		idx.observe(region)
+	}
+	return idx
+}
+
+func (idx *antiAffinityIndex) observe(region *core.RegionInfo) {
+	if region.GetLeader() == nil || !idx.r.overlaps(region) {
+		idx.remove(region.GetID())
+		return
+	}
+	storeID := region.GetLeader().GetStoreId()
+	if old, ok := idx.leaders[region.GetID()]; ok {
+		if old == storeID {
+			return
+		}
+		idx.remove(region.GetID())
+	}
+	idx.leaders[region.GetID()] = storeID
+	idx.stores[storeID]++
+}
+
+func (idx *antiAffinityIndex) remove(regionID uint64) {
+	storeID, ok := idx.leaders[regionID]
+	if !ok {
+		return
+	}
+	delete(idx.leaders, regionID)
+	if idx.stores[storeID]--; idx.stores[storeID] == 0 {
+		delete(idx.stores, storeID)
+	}
+}
+
+// UpdateAntiAffinityIndex updates the indexes of the leader anti-affinities with the
+// region and the overlapped regions which are replaced by it. It should be
+// called after the region is updated in the region tree.
+func (m *RuleManager) UpdateAntiAffinityIndex(region *core.RegionInfo, overlaps []*core.RegionInfo) {
+	m.indexMu.Lock()
+	defer m.indexMu.Unlock()
+	for _, indexes := range m.antiAffinityIndexes {
+		for _, idx := range indexes.ranges {
+			if idx == nil {
+				continue
+			}
+			for _, item := range overlaps {
+				idx.remove(item.GetID())
+			}
+			idx.observe(region)
+		}
+	}
+}
+
+// RemoveFromAntiAffinityIndex removes the region from the indexes of the leader
+// anti-affinities.
+func (m *RuleManager) RemoveFromAntiAffinityIndex(region *core.RegionInfo) {
+	m.indexMu.Lock()
+	defer m.indexMu.Unlock()
+	for _, indexes := range m.antiAffinityIndexes {
+		for _, idx := range indexes.ranges {
+			if idx != nil {
+				idx.remove(region.GetID())
+			}
+		}
+	}
+}
+
+func (m *RuleManager) resetAntiAffinityIndex(id string) {
+	m.indexMu.Lock()
+	defer m.indexMu.Unlock()
+	delete(m.antiAffinityIndexes, id)
+}
+
+// GetLeaderAntiAffinityStores returns the stores which the leader of the
+// region must not be on, they are the stores of the leaders in the ranges which
+// have anti-affinity with the region.
+func (m *RuleManager) GetLeaderAntiAffinityStores(regions RegionScanner, region *core.RegionInfo) map[uint64]struct{} {
+	type antiAffinityRange struct {
+		a *LeaderAntiAffinity
+		i int
+	}
+	m.RLock()
+	var ranges []antiAffinityRange
+	for _, a := range m.antiAffinities {
+		for i := range a.Ranges {
+			if a.Ranges[i].overlaps(region) {
+				ranges = append(ranges, antiAffinityRange{a: a, i: 1 - i})
+			}
+		}
+	}
+	m.RUnlock()
+	if len(ranges) == 0 {
+		return nil
+	}
+	m.indexMu.Lock()
+	defer m.indexMu.Unlock()
+	stores := make(map[uint64]struct{})
+	for _, r := range ranges {
+		// The indexes are rebuilt if the LeaderAntiAffinity is updated.
+		indexes := m.antiAffinityIndexes[r.a.ID]
+		if indexes == nil || indexes.a != r.a {
+			indexes = &antiAffinityIndexes{a: r.a}
+			m.antiAffinityIndexes[r.a.ID] = indexes
+		}
+		idx := indexes.ranges[r.i]
+		if idx == nil {
+			idx = newAntiAffinityIndex(r.a.Ranges[r.i], regions)
+			indexes.ranges[r.i] = idx
+		}
+		self, inRange := idx.leaders[region.GetID()]
+		for storeID, count := range idx.stores {
+			// The region across the ranges is not isolated from itself.
+			if inRange && storeID == self && count == 1 {
+				continue
+			}
+			stores[storeID] = struct{}{}
+		}
+	}
+	return stores
+}
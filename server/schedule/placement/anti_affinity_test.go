@@ -0,0 +1,102 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server/core"
+)
+
+type testRegionScanner struct {
+	*core.RegionsInfo
+}
+
+func (s testRegionScanner) ScanRegions(startKey, endKey []byte, limit int) []*core.RegionInfo {
+	return s.ScanRange(startKey, endKey, limit)
+}
+
+func newTestAntiAffinity(id, start1, end1, start2, end2 string) *LeaderAntiAffinity {
+	return &LeaderAntiAffinity{
+		ID: id,
+		Ranges: [2]AntiAffinityRange{
+			{StartKeyHex: start1, EndKeyHex: end1},
+			{StartKeyHex: start2, EndKeyHex: end2},
+		},
+	}
+}
+
+func (s *testManagerSuite) TestAdjustLeaderAntiAffinity(c *C) {
+	c.Assert(s.manager.SetLeaderAntiAffinity(newTestAntiAffinity("a", "01", "02", "03", "04")), IsNil)
+	for _, a := range []*LeaderAntiAffinity{
+		newTestAntiAffinity("", "01", "02", "03", "04"),
+		newTestAntiAffinity("a", "0", "02", "03", "04"),
+		newTestAntiAffinity("a", "02", "01", "03", "04"),
+		newTestAntiAffinity("a", "01", "03", "02", "04"),
+		newTestAntiAffinity("a", "", "", "03", "04"),
+		newTestAntiAffinity("a", "03", "", "01", "04"),
+	} {
+		c.Assert(s.manager.SetLeaderAntiAffinity(a), NotNil, Commentf("%s", a))
+	}
+	c.Assert(s.manager.GetLeaderAntiAffinities(), HasLen, 1)
+}
+
+func (s *testManagerSuite) TestSaveLoadLeaderAntiAffinity(c *C) {
+	c.Assert(s.manager.SetLeaderAntiAffinity(newTestAntiAffinity("b", "03", "04", "", "02")), IsNil)
+	c.Assert(s.manager.SetLeaderAntiAffinity(newTestAntiAffinity("a", "01", "02", "03", "")), IsNil)
+
+	m2 := NewRuleManager(s.store)
+	c.Assert(m2.Initialize(3, []string{"no", "labels"}), IsNil)
+	antiAffinities := m2.GetLeaderAntiAffinities()
+	c.Assert(antiAffinities, HasLen, 2)
+	c.Assert(antiAffinities[0].ID, Equals, "a")
+	c.Assert(antiAffinities[0].Ranges[1].StartKey, DeepEquals, []byte{3})
+	c.Assert(antiAffinities[1], DeepEquals, s.manager.GetLeaderAntiAffinity("b"))
+
+	c.Assert(m2.DeleteLeaderAntiAffinity("b"), IsNil)
+	c.Assert(m2.GetLeaderAntiAffinity("b"), IsNil)
+	m3 := NewRuleManager(s.store)
+	c.Assert(m3.Initialize(3, []string{"no", "labels"}), IsNil)
+	c.Assert(m3.GetLeaderAntiAffinities(), HasLen, 1)
+}
+
+func (s *testManagerSuite) TestGetLeaderAntiAffinityStores(c *C) {
+	regions := testRegionScanner{core.NewRegionsInfo()}
+	// The leader of region i is on store i.
+	for i, keys := range [][2]string{{"", "\x01"}, {"\x01", "\x02"}, {"\x02", "\x03"}, {"\x03", "\x04"}, {"\x04", "\x06"}, {"\x06", ""}} {
+		id := uint64(i)
+		leader := &metapb.Peer{Id: id, StoreId: id}
+		meta := &metapb.Region{Id: id, StartKey: []byte(keys[0]), EndKey: []byte(keys[1]), Peers: []*metapb.Peer{leader}}
+		regions.SetRegion(core.NewRegionInfo(meta, leader))
+	}
+	c.Assert(s.manager.GetLeaderAntiAffinityStores(regions, regions.GetRegion(1)), HasLen, 0)
+
+	c.Assert(s.manager.SetLeaderAntiAffinity(newTestAntiAffinity("a", "01", "03", "04", "05")), IsNil)
+	c.Assert(s.manager.GetLeaderAntiAffinityStores(regions, regions.GetRegion(1)), DeepEquals, map[uint64]struct{}{4: {}})
+	c.Assert(s.manager.GetLeaderAntiAffinityStores(regions, regions.GetRegion(4)), DeepEquals, map[uint64]struct{}{1: {}, 2: {}})
+	c.Assert(s.manager.GetLeaderAntiAffinityStores(regions, regions.GetRegion(3)), HasLen, 0)
+
+	// Region 4 is across the ranges, its own leader is not excluded.
+	c.Assert(s.manager.SetLeaderAntiAffinity(newTestAntiAffinity("b", "02", "05", "05", "")), IsNil)
+	c.Assert(s.manager.GetLeaderAntiAffinityStores(regions, regions.GetRegion(5)), DeepEquals, map[uint64]struct{}{2: {}, 3: {}, 4: {}})
+	c.Assert(s.manager.GetLeaderAntiAffinityStores(regions, regions.GetRegion(4)), DeepEquals, map[uint64]struct{}{1: {}, 2: {}, 3: {}, 5: {}})
+
+	// The indexes are updated by the regions instead of scanning again.
+	leader := &metapb.Peer{Id: 10, StoreId: 3}
+	merged := core.NewRegionInfo(&metapb.Region{Id: 1, StartKey: []byte("\x01"), EndKey: []byte("\x03"), Peers: []*metapb.Peer{leader}}, leader)
+	s.manager.UpdateAntiAffinityIndex(merged, []*core.RegionInfo{regions.GetRegion(1), regions.GetRegion(2)})
+	c.Assert(s.manager.GetLeaderAntiAffinityStores(regions, regions.GetRegion(4)), DeepEquals, map[uint64]struct{}{3: {}, 5: {}})
+	s.manager.RemoveFromAntiAffinityIndex(regions.GetRegion(5))
+	c.Assert(s.manager.GetLeaderAntiAffinityStores(regions, regions.GetRegion(4)), DeepEquals, map[uint64]struct{}{3: {}})
+}
@@ -34,16 +34,25 @@ import (
 type RuleManager struct {
 	store *core.Storage
 	sync.RWMutex
-	initialized bool
-	ruleConfig  *ruleConfig
-	ruleList    ruleList
+	initialized    bool
+	ruleConfig     *ruleConfig
+	ruleList       ruleList
+	antiAffinities map[string]*LeaderAntiAffinity
+
+	// indexMu protects antiAffinityIndexes, which are updated by the region
+	// heartbeats and should not wait for the rules.
+	indexMu             sync.Mutex
+	antiAffinityIndexes map[string]*antiAffinityIndexes
 }
 
 // NewRuleManager creates a RuleManager instance.
 func NewRuleManager(store *core.Storage) *RuleManager {
 	return &RuleManager{
-		store:      store,
-		ruleConfig: newRuleConfig(),
+		store:          store,
+		ruleConfig:     newRuleConfig(),
+		antiAffinities: make(map[string]*LeaderAntiAffinity),
+
+		antiAffinityIndexes: make(map[string]*antiAffinityIndexes),
 	}
 }
 
@@ -62,6 +71,9 @@ func (m *RuleManager) Initialize(maxReplica int, locationLabels []string) error
 	if err := m.loadGroups(); err != nil {
 		return err
 	}
+	if err := m.loadLeaderAntiAffinities(); err != nil {
+		return err
+	}
 	if len(m.ruleConfig.rules) == 0 {
 		// migrate from old config.
 		defaultRule := &Rule{
@@ -251,6 +251,29 @@ func (s *testBalanceLeaderSchedulerSuite) TestBalanceLeaderSchedulePolicy(c *C)
 	c.Check(s.schedule(), NotNil)
 }
 
+func (s *testBalanceLeaderSchedulerSuite) TestBalanceLeaderByUtilization(c *C) {
+	// Stores:          1       2       3       4
+	// Leader Count:    100     100     100     100
+	// Utilization:     0.9     0.1     0.1     0.1
+	// Region1:         L       F       F       F
+	s.tc.AddLeaderStore(1, 100)
+	s.tc.AddLeaderStore(2, 100)
+	s.tc.AddLeaderStore(3, 100)
+	s.tc.AddLeaderStore(4, 100)
+	s.tc.AddLeaderRegion(1, 1, 2, 3, 4)
+	s.tc.UpdateStoreUtilization(1, 0.9)
+	for id := uint64(2); id <= 4; id++ {
+		s.tc.UpdateStoreUtilization(id, 0.1)
+	}
+	c.Check(s.schedule(), IsNil)
+	s.tc.SetLeaderSchedulePolicy(core.ByUtilization.String())
+	c.Check(s.schedule(), NotNil)
+
+	// The utilization of all stores are close.
+	s.tc.UpdateStoreUtilization(1, 0.15)
+	c.Check(s.schedule(), IsNil)
+}
+
 func (s *testBalanceLeaderSchedulerSuite) TestBalanceLeaderTolerantRatio(c *C) {
 	s.tc.SetTolerantSizeRatio(2.5)
 	// test schedule leader by count, with tolerantSizeRatio=2.5
@@ -273,6 +296,31 @@ func (s *testBalanceLeaderSchedulerSuite) TestBalanceLeaderTolerantRatio(c *C) {
 	c.Check(s.schedule(), IsNil)
 }
 
+// denyCluster denies scheduling the regions in the denied set.
+type denyCluster struct {
+	*mockcluster.Cluster
+	denied map[uint64]bool
+}
+
+func (c *denyCluster) IsRegionScheduleDenied(region *core.RegionInfo) bool {
+	return c.denied[region.GetID()]
+}
+
+func (s *testBalanceLeaderSchedulerSuite) TestBalanceLeaderScheduleDenied(c *C) {
+	// Stores:     1    2    3    4
+	// Leaders:    16   0    0    0
+	// Region1:    L    F    F    F
+	s.tc.AddLeaderStore(1, 16)
+	s.tc.AddLeaderStore(2, 0)
+	s.tc.AddLeaderStore(3, 0)
+	s.tc.AddLeaderStore(4, 0)
+	s.tc.AddLeaderRegion(1, 1, 2, 3, 4)
+	tc := &denyCluster{Cluster: s.tc, denied: map[uint64]bool{1: true}}
+	c.Check(s.lb.Schedule(tc), IsNil)
+	tc.denied[1] = false
+	c.Check(s.lb.Schedule(tc), NotNil)
+}
+
 func (s *testBalanceLeaderSchedulerSuite) TestScheduleWithOpInfluence(c *C) {
 	s.tc.SetTolerantSizeRatio(2.5)
 	// Stores:     1    2    3    4
@@ -215,7 +215,7 @@ func (s *evictLeaderScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
 func (s *evictLeaderScheduler) scheduleOnce(cluster opt.Cluster) []*operator.Operator {
 	var ops []*operator.Operator
 	for id, ranges := range s.conf.StoreIDWithRanges {
-		region := cluster.RandLeaderRegion(id, ranges, opt.HealthRegion(cluster))
+		region := cluster.RandLeaderRegion(id, ranges, opt.HealthRegion(cluster), opt.ScheduleAllowedRegion(cluster))
 		if region == nil {
 			schedulerCounter.WithLabelValues(s.GetName(), "no-leader").Inc()
 			continue
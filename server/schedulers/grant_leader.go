@@ -212,7 +212,7 @@ func (s *grantLeaderScheduler) Schedule(cluster opt.Cluster) []*operator.Operato
 	s.conf.mu.RLock()
 	defer s.conf.mu.RUnlock()
 	for id, ranges := range s.conf.StoreIDWithRanges {
-		region := cluster.RandFollowerRegion(id, ranges, opt.HealthRegion(cluster))
+		region := cluster.RandFollowerRegion(id, ranges, opt.HealthRegion(cluster), opt.ScheduleAllowedRegion(cluster))
 		if region == nil {
 			schedulerCounter.WithLabelValues(s.GetName(), "no-follower").Inc()
 			continue
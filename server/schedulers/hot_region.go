@@ -691,6 +691,11 @@ func (bs *balanceSolver) isRegionAvailable(region *core.RegionInfo) bool {
 		return false
 	}
 
+	if opt.IsRegionScheduleDenied(bs.cluster, region) {
+		schedulerCounter.WithLabelValues(bs.sche.GetName(), "schedule-denied").Inc()
+		return false
+	}
+
 	return true
 }
 
@@ -104,7 +104,7 @@ func (s *randomMergeScheduler) Schedule(cluster opt.Cluster) []*operator.Operato
 		schedulerCounter.WithLabelValues(s.GetName(), "no-source-store").Inc()
 		return nil
 	}
-	region := cluster.RandLeaderRegion(store.GetID(), s.conf.Ranges, opt.HealthRegion(cluster))
+	region := cluster.RandLeaderRegion(store.GetID(), s.conf.Ranges, opt.HealthRegion(cluster), opt.ScheduleAllowedRegion(cluster))
 	if region == nil {
 		schedulerCounter.WithLabelValues(s.GetName(), "no-region").Inc()
 		return nil
@@ -143,5 +143,8 @@ func (s *randomMergeScheduler) allowMerge(cluster opt.Cluster, region, target *c
 	if cluster.IsRegionHot(region) || cluster.IsRegionHot(target) {
 		return false
 	}
+	if opt.IsRegionScheduleDenied(cluster, target) {
+		return false
+	}
 	return checker.AllowMerge(cluster, region, target)
 }
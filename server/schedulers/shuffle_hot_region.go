@@ -156,7 +156,8 @@ func (s *shuffleHotRegionScheduler) randomSchedule(cluster opt.Cluster, loadDeta
 		r := detail.HotPeers[i]
 		// select src region
 		srcRegion := cluster.GetRegion(r.RegionID)
-		if srcRegion == nil || len(srcRegion.GetDownPeers()) != 0 || len(srcRegion.GetPendingPeers()) != 0 {
+		if srcRegion == nil || len(srcRegion.GetDownPeers()) != 0 || len(srcRegion.GetPendingPeers()) != 0 ||
+			opt.IsRegionScheduleDenied(cluster, srcRegion) {
 			continue
 		}
 		srcStoreID := srcRegion.GetLeader().GetStoreId()
@@ -88,6 +88,10 @@ type Server struct {
 
 	// Server state.
 	isServing int64
+	// isDraining is set when the server is preparing for shutdown.
+	isDraining int64
+	// inflightStreams is the number of the TSO and heartbeat streams being served.
+	inflightStreams int64
 
 	// Server start timestamp
 	startTimestamp int64
@@ -228,6 +232,7 @@ func CreateServer(ctx context.Context, cfg *config.Config, serviceBuilders ...Ha
 	}
 
 	s.handler = newHandler(s)
+	s.applyLogSampling()
 
 	// Adjust etcd config.
 	etcdCfg, err := s.cfg.GenEmbedEtcdConfig()
@@ -771,6 +776,18 @@ func (s *Server) GetConfig() *config.Config {
 	return cfg
 }
 
+// GetConfigItemSources returns the config items with their sources and the
+// last time they are changed.
+func (s *Server) GetConfigItemSources() (map[string]*config.ItemSource, error) {
+	return s.persistOptions.GetItemSources(s.cfg)
+}
+
+// GetConfigItemDiffs returns the persisted config items which differ from the
+// default values, with their sources and the last time they are changed.
+func (s *Server) GetConfigItemDiffs() (map[string]*config.ItemDiff, error) {
+	return s.persistOptions.GetItemDiffs(s.cfg)
+}
+
 // GetScheduleConfig gets the balance config information.
 func (s *Server) GetScheduleConfig() *config.ScheduleConfig {
 	cfg := &config.ScheduleConfig{}
@@ -778,12 +795,109 @@ func (s *Server) GetScheduleConfig() *config.ScheduleConfig {
 	return cfg
 }
 
-// SetScheduleConfig sets the balance config information.
-func (s *Server) SetScheduleConfig(cfg config.ScheduleConfig) error {
+// SetConfig applies the changed sections of the config. All of them are
+// validated first and then persisted in one write, so either all of them are
+// applied or none.
+func (s *Server) SetConfig(cfg *config.Config) error {
+	old := s.getPersistedConfig()
+	c := &config.Config{
+		Schedule:        cfg.Schedule,
+		Replication:     cfg.Replication,
+		PDServerCfg:     cfg.PDServerCfg,
+		ReplicationMode: cfg.ReplicationMode,
+		ClusterVersion:  cfg.ClusterVersion,
+	}
+	c.Schedule.SchedulersPayload = nil
+	if !reflect.DeepEqual(old.Schedule, c.Schedule) {
+		if err := validateScheduleConfig(&c.Schedule); err != nil {
+			return err
+		}
+	}
+	if !reflect.DeepEqual(old.Replication, c.Replication) {
+		if err := s.validateReplicationConfig(&c.Replication); err != nil {
+			return err
+		}
+	}
+	if !reflect.DeepEqual(old.PDServerCfg, c.PDServerCfg) {
+		if err := s.adjustPDServerConfig(&c.PDServerCfg); err != nil {
+			return err
+		}
+	}
+	replicationModeChanged := !reflect.DeepEqual(old.ReplicationMode, c.ReplicationMode)
+	if replicationModeChanged {
+		if err := validateReplicationModeConfig(&c.ReplicationMode); err != nil {
+			return err
+		}
+	}
+	logLevelChanged := s.cfg.Log.Level != cfg.Log.Level
+	if logLevelChanged && !isLevelLegal(cfg.Log.Level) {
+		return errors.Errorf("log level %s is illegal", cfg.Log.Level)
+	}
+
+	if !reflect.DeepEqual(old, c) {
+		// The rule manager is initialized after all the sections are valid.
+		if err := s.initRuleManager(&c.Replication); err != nil {
+			return err
+		}
+		s.setPersistedConfig(c)
+		if err := s.persistOptions.Persist(s.storage); err != nil {
+			s.setPersistedConfig(old)
+			log.Error("failed to update config",
+				zap.Reflect("new", c),
+				zap.Reflect("old", old),
+				errs.ZapError(err))
+			return err
+		}
+		if cluster := s.GetRaftCluster(); replicationModeChanged && cluster != nil {
+			if err := cluster.GetReplicationMode().UpdateConfig(c.ReplicationMode); err != nil {
+				log.Warn("failed to update replication mode", errs.ZapError(err))
+				s.setPersistedConfig(old)
+				if revertErr := s.persistOptions.Persist(s.storage); revertErr != nil {
+					log.Error("failed to revert persistent config", errs.ZapError(revertErr))
+				}
+				return err
+			}
+		}
+		s.applyLogSampling()
+		log.Info("config is updated", zap.Reflect("new", c), zap.Reflect("old", old))
+	}
+	if logLevelChanged {
+		return s.SetLogLevel(cfg.Log.Level)
+	}
+	return nil
+}
+
+// getPersistedConfig returns a copy of the config sections which are persisted
+// and can be changed online, except the label property.
+func (s *Server) getPersistedConfig() *config.Config {
+	return &config.Config{
+		Schedule:        *s.persistOptions.GetScheduleConfig().Clone(),
+		Replication:     *s.persistOptions.GetReplicationConfig().Clone(),
+		PDServerCfg:     *s.persistOptions.GetPDServerConfig().Clone(),
+		ReplicationMode: *s.persistOptions.GetReplicationModeConfig(),
+		ClusterVersion:  *s.persistOptions.GetClusterVersion(),
+	}
+}
+
+func (s *Server) setPersistedConfig(cfg *config.Config) {
+	schedule, replication, pdServer, replicationMode, version := cfg.Schedule, cfg.Replication, cfg.PDServerCfg, cfg.ReplicationMode, cfg.ClusterVersion
+	s.persistOptions.SetScheduleConfig(&schedule)
+	s.persistOptions.SetReplicationConfig(&replication)
+	s.persistOptions.SetPDServerConfig(&pdServer)
+	s.persistOptions.SetReplicationModeConfig(&replicationMode)
+	s.persistOptions.SetClusterVersion(&version)
+}
+
+func validateScheduleConfig(cfg *config.ScheduleConfig) error {
 	if err := cfg.Validate(); err != nil {
 		return err
 	}
-	if err := cfg.Deprecated(); err != nil {
+	return cfg.Deprecated()
+}
+
+// SetScheduleConfig sets the balance config information.
+func (s *Server) SetScheduleConfig(cfg config.ScheduleConfig) error {
+	if err := validateScheduleConfig(&cfg); err != nil {
 		return err
 	}
 	old := s.persistOptions.GetScheduleConfig()
@@ -808,8 +922,9 @@ func (s *Server) GetReplicationConfig() *config.ReplicationConfig {
 	return cfg
 }
 
-// SetReplicationConfig sets the replication config.
-func (s *Server) SetReplicationConfig(cfg config.ReplicationConfig) error {
+// validateReplicationConfig checks the replication config against the current
+// one, it has no side effect so that it can be checked with other sections.
+func (s *Server) validateReplicationConfig(cfg *config.ReplicationConfig) error {
 	if err := cfg.Validate(); err != nil {
 		return err
 	}
@@ -819,12 +934,7 @@ func (s *Server) SetReplicationConfig(cfg config.ReplicationConfig) error {
 		if raftCluster == nil {
 			return errs.ErrNotBootstrapped.GenWithStackByArgs()
 		}
-		if cfg.EnablePlacementRules {
-			// initialize rule manager.
-			if err := raftCluster.GetRuleManager().Initialize(int(cfg.MaxReplicas), cfg.LocationLabels); err != nil {
-				return err
-			}
-		} else {
+		if !cfg.EnablePlacementRules {
 			// NOTE: can be removed after placement rules feature is enabled by default.
 			for _, s := range raftCluster.GetStores() {
 				if !s.IsTombstone() && core.IsTiFlashStore(s.GetMeta()) {
@@ -842,7 +952,32 @@ func (s *Server) SetReplicationConfig(cfg config.ReplicationConfig) error {
 			return errors.New("cannot update LocationLabels when placement rules feature is enabled, please update rule instead")
 		}
 	}
+	return nil
+}
+
+// initRuleManager initializes the rule manager if the placement rules are
+// enabled by the replication config, it is called after the config is
+// validated.
+func (s *Server) initRuleManager(cfg *config.ReplicationConfig) error {
+	if !cfg.EnablePlacementRules || s.persistOptions.IsPlacementRulesEnabled() {
+		return nil
+	}
+	raftCluster := s.GetRaftCluster()
+	if raftCluster == nil {
+		return errs.ErrNotBootstrapped.GenWithStackByArgs()
+	}
+	return raftCluster.GetRuleManager().Initialize(int(cfg.MaxReplicas), cfg.LocationLabels)
+}
 
+// SetReplicationConfig sets the replication config.
+func (s *Server) SetReplicationConfig(cfg config.ReplicationConfig) error {
+	if err := s.validateReplicationConfig(&cfg); err != nil {
+		return err
+	}
+	if err := s.initRuleManager(&cfg); err != nil {
+		return err
+	}
+	old := s.persistOptions.GetReplicationConfig()
 	s.persistOptions.SetReplicationConfig(&cfg)
 	if err := s.persistOptions.Persist(s.storage); err != nil {
 		s.persistOptions.SetReplicationConfig(old)
@@ -863,8 +998,9 @@ func (s *Server) GetPDServerConfig() *config.PDServerConfig {
 	return cfg
 }
 
-// SetPDServerConfig sets the server config.
-func (s *Server) SetPDServerConfig(cfg config.PDServerConfig) error {
+// adjustPDServerConfig completes the dashboard address and validates the
+// server config.
+func (s *Server) adjustPDServerConfig(cfg *config.PDServerConfig) error {
 	switch cfg.DashboardAddress {
 	case "auto":
 	case "none":
@@ -876,10 +1012,14 @@ func (s *Server) SetPDServerConfig(cfg config.PDServerConfig) error {
 			return errors.Errorf("%s is not the client url of any member", cfg.DashboardAddress)
 		}
 	}
-	if err := cfg.Validate(); err != nil {
+	return cfg.Validate()
+}
+
+// SetPDServerConfig sets the server config.
+func (s *Server) SetPDServerConfig(cfg config.PDServerConfig) error {
+	if err := s.adjustPDServerConfig(&cfg); err != nil {
 		return err
 	}
-
 	old := s.persistOptions.GetPDServerConfig()
 	s.persistOptions.SetPDServerConfig(&cfg)
 	if err := s.persistOptions.Persist(s.storage); err != nil {
@@ -890,10 +1030,19 @@ func (s *Server) SetPDServerConfig(cfg config.PDServerConfig) error {
 			errs.ZapError(err))
 		return err
 	}
+	s.applyLogSampling()
 	log.Info("PD server config is updated", zap.Reflect("new", cfg), zap.Reflect("old", old))
 	return nil
 }
 
+// applyLogSampling makes the persisted config of the log sampling take effect.
+func (s *Server) applyLogSampling() {
+	cfg := s.persistOptions.GetPDServerConfig().LogSampling
+	if err := logutil.SetLogSampling(cfg); err != nil {
+		log.Warn("failed to apply the log sampling", zap.Reflect("config", cfg), errs.ZapError(err))
+	}
+}
+
 // SetLabelPropertyConfig sets the label property config.
 func (s *Server) SetLabelPropertyConfig(cfg config.LabelPropertyConfig) error {
 	old := s.persistOptions.GetLabelPropertyConfig()
@@ -1066,11 +1215,18 @@ func (s *Server) GetReplicationModeConfig() *config.ReplicationModeConfig {
 	return s.persistOptions.GetReplicationModeConfig()
 }
 
-// SetReplicationModeConfig sets the replication mode.
-func (s *Server) SetReplicationModeConfig(cfg config.ReplicationModeConfig) error {
+func validateReplicationModeConfig(cfg *config.ReplicationModeConfig) error {
 	if config.NormalizeReplicationMode(cfg.ReplicationMode) == "" {
 		return errors.Errorf("invalid replication mode: %v", cfg.ReplicationMode)
 	}
+	return nil
+}
+
+// SetReplicationModeConfig sets the replication mode.
+func (s *Server) SetReplicationModeConfig(cfg config.ReplicationModeConfig) error {
+	if err := validateReplicationModeConfig(&cfg); err != nil {
+		return err
+	}
 
 	old := s.persistOptions.GetReplicationModeConfig()
 	s.persistOptions.SetReplicationModeConfig(&cfg)
@@ -1137,6 +1293,12 @@ func (s *Server) leaderLoop() {
 			log.Info("pd leader has changed, try to re-campaign a pd leader")
 		}
 
+		if s.IsDraining() {
+			log.Info("skip campaigning of pd leader since the server is draining", zap.String("server-name", s.Name()))
+			time.Sleep(200 * time.Millisecond)
+			continue
+		}
+
 		// To make sure the etcd leader and PD leader are on the same server.
 		etcdLeader := s.member.GetEtcdLeader()
 		if etcdLeader != s.member.ID() {
@@ -1246,6 +1408,7 @@ func (s *Server) reloadConfigFromKV() error {
 	if err != nil {
 		return err
 	}
+	s.applyLogSampling()
 	if s.persistOptions.IsUseRegionStorage() {
 		s.storage.SwitchToRegionStorage()
 		log.Info("server enable region storage")
@@ -15,26 +15,72 @@ package statistics
 
 import (
 	"math/rand"
+	"sync/atomic"
+	"time"
 
+	"github.com/pingcap/log"
 	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
 )
 
 // Denoising is an option to calculate flow base on the real heartbeats. Should
 // only turned off by the simulator and the test.
 var Denoising = true
 
+// The degrade levels of the hot cache. The retention of the items and the
+// rolling windows size shrink as the level goes up.
+const (
+	hotCacheNormal = iota
+	hotCacheDegraded
+	hotCacheSeverelyDegraded
+)
+
+var (
+	hotCacheLevelNames       = []string{"normal", "degraded", "severely-degraded"}
+	hotCacheLevelTTLs        = []time.Duration{topNTTL, topNTTL / 2, topNTTL / 4}
+	hotCacheLevelWindowsSize = []int{rollingWindowsSize, 3, 1}
+	// hotCacheRecoverRatio is used to avoid the level flapping around a threshold.
+	hotCacheRecoverRatio = 0.8
+)
+
+// HotCacheMemoryStatus is the memory usage and degradation state of the hot cache.
+type HotCacheMemoryStatus struct {
+	Budget    uint64 `json:"budget"`
+	Estimated uint64 `json:"estimated"`
+	Items     int    `json:"items"`
+	Level     int    `json:"level"`
+	State     string `json:"state"`
+}
+
 // HotCache is a cache hold hot regions.
 type HotCache struct {
 	writeFlow *hotPeerCache
 	readFlow  *hotPeerCache
+
+	// memoryBudget, items, itemSize and level can be read without the
+	// cluster lock.
+	memoryBudget uint64
+	items        int64
+	// itemSize is the average memory usage of the items measured by
+	// measureMemory, it is used to estimate the memory usage between
+	// the measurements.
+	itemSize uint64
+	level    int32
 }
 
 // NewHotCache creates a new hot spot cache.
 func NewHotCache() *HotCache {
-	return &HotCache{
+	cache := &HotCache{
 		writeFlow: NewHotStoresStats(WriteFlow),
 		readFlow:  NewHotStoresStats(ReadFlow),
 	}
+	// Before the first measurement, takes the size of a new item.
+	newItem := &HotPeerStat{
+		rollingByteRate: cache.writeFlow.getDefaultTimeMedian(),
+		rollingKeyRate:  cache.writeFlow.getDefaultTimeMedian(),
+	}
+	cache.itemSize = newItem.memorySize()
+	return cache
 }
 
 // CheckWrite checks the write status, returns update items.
@@ -63,6 +109,69 @@ func (w *HotCache) Update(item *HotPeerStat) {
 	} else {
 		w.incMetrics("update_item", item.StoreID, item.Kind)
 	}
+	w.checkMemory()
+}
+
+// SetMemoryBudget sets the memory budget in bytes. 0 means no limit.
+func (w *HotCache) SetMemoryBudget(budget uint64) {
+	atomic.StoreUint64(&w.memoryBudget, budget)
+}
+
+// GetMemoryStatus returns the memory usage and degradation state.
+func (w *HotCache) GetMemoryStatus() *HotCacheMemoryStatus {
+	items := int(atomic.LoadInt64(&w.items))
+	level := int(atomic.LoadInt32(&w.level))
+	return &HotCacheMemoryStatus{
+		Budget:    atomic.LoadUint64(&w.memoryBudget),
+		Estimated: uint64(items) * atomic.LoadUint64(&w.itemSize),
+		Items:     items,
+		Level:     level,
+		State:     hotCacheLevelNames[level],
+	}
+}
+
+// measureMemory walks the items to measure their average memory usage, which
+// is taken by checkMemory on the next update. It is more expensive than
+// checkMemory, so it only runs when collecting the metrics.
+func (w *HotCache) measureMemory() {
+	writeItems, writeSize := w.writeFlow.memoryUsage()
+	readItems, readSize := w.readFlow.memoryUsage()
+	if items := writeItems + readItems; items > 0 {
+		atomic.StoreUint64(&w.itemSize, (writeSize+readSize)/uint64(items))
+	}
+}
+
+// checkMemory estimates the memory usage and adjusts the degrade level.
+func (w *HotCache) checkMemory() {
+	items := w.writeFlow.itemCount() + w.readFlow.itemCount()
+	atomic.StoreInt64(&w.items, int64(items))
+	estimated := float64(uint64(items) * atomic.LoadUint64(&w.itemSize))
+	budget := float64(atomic.LoadUint64(&w.memoryBudget))
+	level := int(atomic.LoadInt32(&w.level))
+
+	target := level
+	switch {
+	case budget == 0:
+		target = hotCacheNormal
+	case estimated > 2*budget:
+		target = hotCacheSeverelyDegraded
+	case estimated > budget && level < hotCacheDegraded:
+		target = hotCacheDegraded
+	case level > hotCacheNormal && estimated < hotCacheRecoverRatio*budget*float64(level):
+		// Only recovers when the usage is well below the threshold of the current level.
+		target = level - 1
+	}
+	if target == level {
+		return
+	}
+	log.Warn("hot cache degrade level changed",
+		zap.String("from", hotCacheLevelNames[level]),
+		zap.String("to", hotCacheLevelNames[target]),
+		zap.Int("items", items),
+		zap.Float64("budget", budget))
+	atomic.StoreInt32(&w.level, int32(target))
+	w.writeFlow.setRetention(hotCacheLevelTTLs[target], hotCacheLevelWindowsSize[target])
+	w.readFlow.setRetention(hotCacheLevelTTLs[target], hotCacheLevelWindowsSize[target])
 }
 
 // RegionStats returns hot items according to kind
@@ -98,11 +207,17 @@ func (w *HotCache) IsRegionHot(region *core.RegionInfo, hotDegree int) bool {
 func (w *HotCache) CollectMetrics() {
 	w.writeFlow.CollectMetrics("write")
 	w.readFlow.CollectMetrics("read")
+	w.measureMemory()
+	status := w.GetMemoryStatus()
+	hotCacheMemoryGauge.WithLabelValues("budget").Set(float64(status.Budget))
+	hotCacheMemoryGauge.WithLabelValues("estimated").Set(float64(status.Estimated))
+	hotCacheMemoryGauge.WithLabelValues("degrade_level").Set(float64(status.Level))
 }
 
 // ResetMetrics resets the hot cache metrics.
 func (w *HotCache) ResetMetrics() {
 	hotCacheStatusGauge.Reset()
+	hotCacheMemoryGauge.Reset()
 }
 
 func (w *HotCache) incMetrics(name string, storeID uint64, kind FlowKind) {
@@ -16,6 +16,7 @@ package statistics
 import (
 	"math"
 	"time"
+	"unsafe"
 
 	"github.com/tikv/pd/pkg/movingaverage"
 )
@@ -70,6 +71,20 @@ func (stat *HotPeerStat) Less(k int, than TopNItem) bool {
 	}
 }
 
+// memorySize returns the estimated memory usage of the stat in bytes,
+// including the histories of its rolling statistics and the index entries
+// in TopN.
+func (stat *HotPeerStat) memorySize() uint64 {
+	size := uint64(unsafe.Sizeof(*stat)) + topNIndexSize(dimLen)
+	if stat.rollingByteRate != nil {
+		size += stat.rollingByteRate.MemorySize()
+	}
+	if stat.rollingKeyRate != nil {
+		size += stat.rollingKeyRate.MemorySize()
+	}
+	return size
+}
+
 // IsNeedDelete to delete the item in cache.
 func (stat *HotPeerStat) IsNeedDelete() bool {
 	return stat.needDelete
@@ -15,6 +15,7 @@ package statistics
 
 import (
 	"math"
+	"sync/atomic"
 	"time"
 
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -52,6 +53,10 @@ type hotPeerCache struct {
 	kind           FlowKind
 	peersOfStore   map[uint64]*TopN               // storeID -> hot peers
 	storesOfRegion map[uint64]map[uint64]struct{} // regionID -> storeIDs
+	ttl            time.Duration
+	// windowsSize is the size of the rolling windows of the new items, it
+	// can be read without the protection of the cluster lock.
+	windowsSize int32
 }
 
 // NewHotStoresStats creates a HotStoresStats
@@ -60,6 +65,43 @@ func NewHotStoresStats(kind FlowKind) *hotPeerCache {
 		kind:           kind,
 		peersOfStore:   make(map[uint64]*TopN),
 		storesOfRegion: make(map[uint64]map[uint64]struct{}),
+		ttl:            topNTTL,
+		windowsSize:    rollingWindowsSize,
+	}
+}
+
+// itemCount returns the number of the items in the cache.
+func (f *hotPeerCache) itemCount() int {
+	count := 0
+	for _, peers := range f.peersOfStore {
+		count += peers.Len()
+	}
+	return count
+}
+
+// memoryUsage walks the items and returns their number and estimated memory
+// usage in bytes.
+func (f *hotPeerCache) memoryUsage() (int, uint64) {
+	var (
+		count int
+		size  uint64
+	)
+	for _, peers := range f.peersOfStore {
+		for _, item := range peers.GetAll() {
+			count++
+			size += item.(*HotPeerStat).memorySize()
+		}
+	}
+	return count, size
+}
+
+// setRetention changes the retention of the items and the rolling windows
+// size of the new items.
+func (f *hotPeerCache) setRetention(ttl time.Duration, windowsSize int) {
+	f.ttl = ttl
+	atomic.StoreInt32(&f.windowsSize, int32(windowsSize))
+	for _, peers := range f.peersOfStore {
+		peers.SetTTL(ttl)
 	}
 }
 
@@ -90,7 +132,7 @@ func (f *hotPeerCache) Update(item *HotPeerStat) {
 	} else {
 		peers, ok := f.peersOfStore[item.StoreID]
 		if !ok {
-			peers = NewTopN(dimLen, topNN, topNTTL)
+			peers = NewTopN(dimLen, topNN, f.ttl)
 			f.peersOfStore[item.StoreID] = peers
 		}
 		peers.Put(item)
@@ -310,7 +352,7 @@ func (f *hotPeerCache) isRegionHotWithPeer(region *core.RegionInfo, peer *metapb
 }
 
 func (f *hotPeerCache) getDefaultTimeMedian() *movingaverage.TimeMedian {
-	return movingaverage.NewTimeMedian(DefaultAotSize, rollingWindowsSize, RegionHeartBeatReportInterval)
+	return movingaverage.NewTimeMedian(DefaultAotSize, int(atomic.LoadInt32(&f.windowsSize)), RegionHeartBeatReportInterval)
 }
 
 func (f *hotPeerCache) updateHotPeerStat(newItem, oldItem *HotPeerStat, bytes, keys float64, interval time.Duration) *HotPeerStat {
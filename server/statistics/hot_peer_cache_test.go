@@ -15,12 +15,18 @@ package statistics
 
 import (
 	"math/rand"
+	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/tikv/pd/server/core"
 )
 
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
 var _ = Suite(&testHotPeerCache{})
 
 type testHotPeerCache struct{}
@@ -211,3 +217,59 @@ func newPeers(n int, pid genID, sid genID) []*metapb.Peer {
 	}
 	return peers
 }
+
+func (t *testHotPeerCache) TestMemoryBudget(c *C) {
+	cache := NewHotCache()
+	itemSize := cache.itemSize
+	put := func(regionID uint64) {
+		cache.Update(&HotPeerStat{StoreID: 1, RegionID: regionID, Kind: WriteFlow})
+	}
+	for i := uint64(1); i <= 10; i++ {
+		put(i)
+	}
+	status := cache.GetMemoryStatus()
+	c.Assert(status.Items, Equals, 10)
+	c.Assert(status.State, Equals, "normal")
+
+	// 10 items exceed the budget of 8 items.
+	cache.SetMemoryBudget(8 * itemSize)
+	put(11)
+	c.Assert(cache.GetMemoryStatus().State, Equals, "degraded")
+	c.Assert(cache.writeFlow.ttl, Equals, topNTTL/2)
+	c.Assert(cache.writeFlow.windowsSize, Equals, int32(3))
+
+	cache.SetMemoryBudget(4 * itemSize)
+	put(12)
+	c.Assert(cache.GetMemoryStatus().Level, Equals, hotCacheSeverelyDegraded)
+
+	// Recovers step by step once the usage is well below the budget.
+	cache.SetMemoryBudget(100 * itemSize)
+	put(13)
+	c.Assert(cache.GetMemoryStatus().Level, Equals, hotCacheDegraded)
+	put(14)
+	c.Assert(cache.GetMemoryStatus().Level, Equals, hotCacheNormal)
+	c.Assert(cache.writeFlow.ttl, Equals, topNTTL)
+
+	cache.SetMemoryBudget(0)
+	put(15)
+	c.Assert(cache.GetMemoryStatus().Level, Equals, hotCacheNormal)
+
+	// The measurement takes the actual size of the items.
+	cache.CollectMetrics()
+	c.Assert(cache.itemSize, Equals, (&HotPeerStat{}).memorySize())
+	c.Assert(cache.GetMemoryStatus().Estimated, Equals, 15*cache.itemSize)
+}
+
+func (t *testHotPeerCache) TestMemorySize(c *C) {
+	cache := NewHotStoresStats(WriteFlow)
+	stat := &HotPeerStat{}
+	size := stat.memorySize()
+	stat.rollingByteRate = cache.getDefaultTimeMedian()
+	stat.rollingKeyRate = cache.getDefaultTimeMedian()
+	c.Assert(stat.memorySize(), Equals, size+2*stat.rollingByteRate.MemorySize())
+
+	// The histories of the rolling statistics are counted.
+	size = stat.memorySize()
+	stat.rollingByteRate.Add(1024, RegionHeartBeatReportInterval*time.Second)
+	c.Assert(stat.memorySize(), Greater, size)
+}
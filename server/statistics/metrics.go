@@ -24,6 +24,14 @@ var (
 			Help:      "Status of the hotspot.",
 		}, []string{"name", "store", "type"})
 
+	hotCacheMemoryGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "hotcache",
+			Name:      "memory",
+			Help:      "Memory budget, estimated usage and degrade level of the hotspot cache.",
+		}, []string{"type"})
+
 	storeStatusGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "pd",
@@ -123,6 +131,7 @@ var (
 
 func init() {
 	prometheus.MustRegister(hotCacheStatusGauge)
+	prometheus.MustRegister(hotCacheMemoryGauge)
 	prometheus.MustRegister(storeStatusGauge)
 	prometheus.MustRegister(regionStatusGauge)
 	prometheus.MustRegister(clusterStatusGauge)
@@ -32,6 +32,8 @@ const (
 	OfflinePeer
 	LearnerPeer
 	EmptyRegion
+	OversizedRegion
+	UndersizedRegion
 )
 
 const nonIsolation = "none"
@@ -58,6 +60,8 @@ func NewRegionStatistics(opt *config.PersistOptions, ruleManager *placement.Rule
 	r.stats[OfflinePeer] = make(map[uint64]*core.RegionInfo)
 	r.stats[LearnerPeer] = make(map[uint64]*core.RegionInfo)
 	r.stats[EmptyRegion] = make(map[uint64]*core.RegionInfo)
+	r.stats[OversizedRegion] = make(map[uint64]*core.RegionInfo)
+	r.stats[UndersizedRegion] = make(map[uint64]*core.RegionInfo)
 	r.ruleManager = ruleManager
 	return r
 }
@@ -128,6 +132,14 @@ func (r *RegionStatistics) Observe(region *core.RegionInfo, stores []*core.Store
 		peerTypeIndex |= EmptyRegion
 	}
 
+	if region.IsOversized(int64(r.opt.GetRegionMaxSize()), int64(r.opt.GetRegionMaxKeys())) {
+		r.stats[OversizedRegion][regionID] = region
+		peerTypeIndex |= OversizedRegion
+	} else if region.NeedMerge(int64(r.opt.GetMaxMergeRegionSize()), int64(r.opt.GetMaxMergeRegionKeys())) {
+		r.stats[UndersizedRegion][regionID] = region
+		peerTypeIndex |= UndersizedRegion
+	}
+
 	for _, store := range stores {
 		if store.IsOffline() {
 			peer := region.GetStorePeer(store.GetID())
@@ -161,6 +173,8 @@ func (r *RegionStatistics) Collect() {
 	regionStatusGauge.WithLabelValues("offline-peer-region-count").Set(float64(len(r.stats[OfflinePeer])))
 	regionStatusGauge.WithLabelValues("learner-peer-region-count").Set(float64(len(r.stats[LearnerPeer])))
 	regionStatusGauge.WithLabelValues("empty-region-count").Set(float64(len(r.stats[EmptyRegion])))
+	regionStatusGauge.WithLabelValues("oversized-region-count").Set(float64(len(r.stats[OversizedRegion])))
+	regionStatusGauge.WithLabelValues("undersized-region-count").Set(float64(len(r.stats[UndersizedRegion])))
 }
 
 // Reset resets the metrics of the regions' status.
@@ -77,6 +77,8 @@ func (t *testRegionStatisticsSuite) TestRegionStatistics(c *C) {
 	c.Assert(len(regionStats.stats[ExtraPeer]), Equals, 1)
 	c.Assert(len(regionStats.stats[LearnerPeer]), Equals, 1)
 	c.Assert(len(regionStats.stats[EmptyRegion]), Equals, 1)
+	c.Assert(len(regionStats.stats[UndersizedRegion]), Equals, 1)
+	c.Assert(len(regionStats.stats[OversizedRegion]), Equals, 0)
 
 	region1 = region1.Clone(
 		core.WithDownPeers(downPeers),
@@ -90,6 +92,19 @@ func (t *testRegionStatisticsSuite) TestRegionStatistics(c *C) {
 	c.Assert(len(regionStats.stats[PendingPeer]), Equals, 1)
 	c.Assert(len(regionStats.stats[LearnerPeer]), Equals, 1)
 	c.Assert(len(regionStats.stats[EmptyRegion]), Equals, 0)
+	c.Assert(len(regionStats.stats[UndersizedRegion]), Equals, 0)
+	c.Assert(len(regionStats.stats[OversizedRegion]), Equals, 1)
+	// The threshold of the oversized regions follows the schedule config.
+	cfg := opt.GetScheduleConfig().Clone()
+	cfg.RegionMaxSize = 256
+	opt.SetScheduleConfig(cfg)
+	regionStats.Observe(region1, stores)
+	c.Assert(len(regionStats.stats[OversizedRegion]), Equals, 0)
+	cfg = cfg.Clone()
+	cfg.RegionMaxSize = 144
+	opt.SetScheduleConfig(cfg)
+	regionStats.Observe(region1, stores)
+	c.Assert(len(regionStats.stats[OversizedRegion]), Equals, 1)
 
 	region2 = region2.Clone(core.WithDownPeers(downPeers[0:1]))
 	regionStats.Observe(region2, stores[0:2])
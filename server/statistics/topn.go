@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"sync"
 	"time"
+	"unsafe"
 )
 
 // TopNItem represents a single object in TopN.
@@ -99,6 +100,14 @@ func (tn *TopN) Put(item TopNItem) (isUpdate bool) {
 	return
 }
 
+// SetTTL changes the TTL of the items, the expired items are removed at once.
+func (tn *TopN) SetTTL(ttl time.Duration) {
+	tn.rw.Lock()
+	defer tn.rw.Unlock()
+	tn.ttlLst.setTTL(ttl)
+	tn.maintain()
+}
+
 // RemoveExpired deletes all expired items.
 func (tn *TopN) RemoveExpired() {
 	tn.rw.Lock()
@@ -319,6 +328,15 @@ func (hp *indexedHeap) Remove(id uint64) TopNItem {
 	return nil
 }
 
+// topNIndexSize returns the estimated memory usage of the index entries of an
+// item in a k-dimensional TopN: a heap slot and an index map entry in each
+// dimension, and the TTL list element with its index map entry.
+func topNIndexSize(k int) uint64 {
+	heapEntry := unsafe.Sizeof(TopNItem(nil)) + unsafe.Sizeof(uint64(0)) + unsafe.Sizeof(int(0))
+	ttlEntry := unsafe.Sizeof(list.Element{}) + unsafe.Sizeof(ttlItem{}) + unsafe.Sizeof(uint64(0)) + unsafe.Sizeof(&list.Element{})
+	return uint64(k)*uint64(heapEntry) + uint64(ttlEntry)
+}
+
 type ttlItem struct {
 	id     uint64
 	expire time.Time
@@ -357,6 +375,21 @@ func (tl *ttlList) TakeExpired() []uint64 {
 	return expired
 }
 
+// setTTL changes the TTL and shifts the expire time of the existing items
+// accordingly, the order of the list is kept.
+func (tl *ttlList) setTTL(ttl time.Duration) {
+	delta := ttl - tl.ttl
+	tl.ttl = ttl
+	if delta == 0 {
+		return
+	}
+	for ele := tl.lst.Front(); ele != nil; ele = ele.Next() {
+		item := ele.Value.(ttlItem)
+		item.expire = item.expire.Add(delta)
+		ele.Value = item
+	}
+}
+
 func (tl *ttlList) Put(id uint64) (isUpdate bool) {
 	item := ttlItem{id: id}
 	if ele, ok := tl.index[id]; ok {
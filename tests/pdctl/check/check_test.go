@@ -0,0 +1,95 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/core/storelimit"
+	"github.com/tikv/pd/tests"
+	"github.com/tikv/pd/tests/pdctl"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&checkTestSuite{})
+
+type checkTestSuite struct{}
+
+func (s *checkTestSuite) SetUpSuite(c *C) {
+	server.EnableZap = true
+}
+
+func (s *checkTestSuite) TestClusterConsistency(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tc, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	defer tc.Destroy()
+	c.Assert(tc.RunInitialServers(), IsNil)
+	tc.WaitLeader()
+	pdAddr := tc.GetConfig().GetClientURL()
+
+	leaderServer := tc.GetServer(tc.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 1, metapb.StoreState_Up, nil)
+	// The region has a peer on the unknown store 9, which has a store limit.
+	peers := []*metapb.Peer{{Id: 11, StoreId: 1}, {Id: 19, StoreId: 9}}
+	region := core.NewRegionInfo(&metapb.Region{
+		Id:          10,
+		StartKey:    []byte("a"),
+		EndKey:      []byte("b"),
+		Peers:       peers,
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+	}, peers[0])
+	c.Assert(tc.HandleRegionHeartbeat(region), IsNil)
+	rc := leaderServer.GetRaftCluster()
+	c.Assert(rc.SetStoreLimit(9, storelimit.AddPeer, 10), IsNil)
+	// The member 1234 is removed but its leader priority is left.
+	member := leaderServer.GetServer().GetMember()
+	c.Assert(member.SetMemberLeaderPriority(1234, 1), IsNil)
+
+	// check cluster-consistency
+	args := []string{"-u", pdAddr, "check", "cluster-consistency"}
+	_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	var report cluster.ConsistencyReport
+	c.Assert(json.Unmarshal(output, &report), IsNil)
+	c.Assert(report.UnknownStorePeers, DeepEquals, []*cluster.ConsistencyPeer{{RegionID: 10, PeerID: 19, StoreID: 9}})
+	c.Assert(report.OrphanedStoreLimits, DeepEquals, []uint64{9})
+	c.Assert(report.OrphanedMembers, DeepEquals, []uint64{1234})
+
+	// check cluster-consistency --cleanup
+	args = []string{"-u", pdAddr, "check", "cluster-consistency", "--cleanup"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	var cleaned cluster.ConsistencyReport
+	c.Assert(json.Unmarshal(output, &cleaned), IsNil)
+	c.Assert(cleaned.OrphanedStoreLimits, DeepEquals, []uint64{9})
+	c.Assert(cleaned.OrphanedMembers, DeepEquals, []uint64{1234})
+	after, err := leaderServer.GetServer().CheckConsistency()
+	c.Assert(err, IsNil)
+	c.Assert(after.OrphanedMembers, HasLen, 0)
+	_, ok := leaderServer.GetServer().GetPersistOptions().GetScheduleConfig().StoreLimit[9]
+	c.Assert(ok, IsFalse)
+}
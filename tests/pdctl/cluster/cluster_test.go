@@ -21,10 +21,15 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/grpcutil"
 	"github.com/tikv/pd/server"
 	clusterpkg "github.com/tikv/pd/server/cluster"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/tests"
 	"github.com/tikv/pd/tests/pdctl"
+	"github.com/tikv/pd/tools/pd-ctl/pdctl/command"
 )
 
 func Test(t *testing.T) {
@@ -90,3 +95,121 @@ func (s *clusterTestSuite) TestClusterAndPing(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(output, NotNil)
 }
+
+func (s *clusterTestSuite) TestImportMode(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	err = cluster.GetServer(cluster.GetLeader()).BootstrapCluster()
+	c.Assert(err, IsNil)
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctl.InitCommand()
+	defer cluster.Destroy()
+
+	// cluster import-mode enable --ttl=<duration>
+	args := []string{"-u", pdAddr, "cluster", "import-mode", "enable", "--ttl", "1h"}
+	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	status := &server.ImportModeStatus{}
+	c.Assert(json.Unmarshal(output, status), IsNil)
+	c.Assert(status.Enabled, IsTrue)
+	c.Assert(cluster.GetServer(cluster.GetLeader()).GetServer().GetPersistOptions().GetMergeScheduleLimit(), Equals, uint64(0))
+
+	// cluster import-mode
+	args = []string{"-u", pdAddr, "cluster", "import-mode"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	status = &server.ImportModeStatus{}
+	c.Assert(json.Unmarshal(output, status), IsNil)
+	c.Assert(status.Enabled, IsTrue)
+
+	// cluster import-mode disable
+	args = []string{"-u", pdAddr, "cluster", "import-mode", "disable"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(string(output), Equals, "Success!\n")
+	args = []string{"-u", pdAddr, "cluster", "import-mode"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	status = &server.ImportModeStatus{}
+	c.Assert(json.Unmarshal(output, status), IsNil)
+	c.Assert(status.Enabled, IsFalse)
+}
+
+func (s *clusterTestSuite) TestTLS(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1, func(conf *config.Config, serverName string) {
+		conf.Security.TLSConfig = grpcutil.TLSConfig{
+			KeyPath:  "../../client/cert/pd-server-key.pem",
+			CertPath: "../../client/cert/pd-server.pem",
+			CAPath:   "../../client/cert/ca.pem",
+		}
+		conf.AdvertiseClientUrls = strings.ReplaceAll(conf.AdvertiseClientUrls, "http", "https")
+		conf.ClientUrls = strings.ReplaceAll(conf.ClientUrls, "http", "https")
+		conf.AdvertisePeerUrls = strings.ReplaceAll(conf.AdvertisePeerUrls, "http", "https")
+		conf.PeerUrls = strings.ReplaceAll(conf.PeerUrls, "http", "https")
+		conf.InitialCluster = strings.ReplaceAll(conf.InitialCluster, "http", "https")
+	})
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+	c.Assert(cluster.RunInitialServers(), IsNil)
+	cluster.WaitLeader()
+	c.Assert(cluster.GetServer(cluster.GetLeader()).BootstrapCluster(), IsNil)
+	// The address without scheme uses https if the TLS files are specified.
+	pdAddr := cluster.GetConfig().GetClientURL()
+	pdAddr = pdAddr[strings.Index(pdAddr, "://")+3:]
+
+	echo := pdctl.GetEcho([]string{"-u", pdAddr, "--cacert=../../client/cert/ca.pem", "--cert=../../client/cert/client.pem", "cluster"})
+	c.Assert(strings.Contains(echo, "should be specified together"), IsTrue)
+
+	echo = pdctl.GetEcho([]string{"-u", pdAddr, "--cacert=../../client/cert/ca.pem",
+		"--cert=../../client/cert/client.pem", "--key=../../client/cert/client-key.pem", "cluster"})
+	ci := &metapb.Cluster{}
+	c.Assert(json.Unmarshal([]byte(echo), ci), IsNil)
+	c.Assert(ci, DeepEquals, cluster.GetCluster())
+}
+
+func (s *clusterTestSuite) TestClusterHealth(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1, func(conf *config.Config, serverName string) {
+		conf.Replication.MaxReplicas = 1
+	})
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+	c.Assert(cluster.RunInitialServers(), IsNil)
+	cluster.WaitLeader()
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	pdAddr := cluster.GetConfig().GetClientURL()
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 1, metapb.StoreState_Up, nil)
+	pdctl.MustPutRegion(c, cluster, 1, 1, []byte("a"), []byte("b"))
+
+	// cluster health
+	args := []string{"-u", pdAddr, "cluster", "health"}
+	_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "members:   PASS 1/1 healthy"), IsTrue)
+	c.Assert(strings.Contains(string(output), "stores:    PASS 1 up"), IsTrue)
+	c.Assert(strings.Contains(string(output), "summary:   PASS"), IsTrue)
+
+	// The pending operators over the max count are warned.
+	args = []string{"-u", pdAddr, "cluster", "health", "--max-pending-operators=-1"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(command.ExitCodeOf(err), Equals, command.ExitCheckWarning)
+	c.Assert(strings.Contains(string(output), "operators: WARN 0 pending"), IsTrue)
+	c.Assert(strings.Contains(string(output), "summary:   WARN"), IsTrue)
+
+	// The regions with down peers fail the check.
+	pdctl.MustPutRegion(c, cluster, 2, 1, []byte("b"), []byte("c"), core.WithDownPeers([]*pdpb.PeerStats{{Peer: &metapb.Peer{Id: 3, StoreId: 2}, DownSeconds: 3600}}))
+	args = []string{"-u", pdAddr, "cluster", "health"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(command.ExitCodeOf(err), Equals, command.ExitCheckFailed)
+	c.Assert(strings.Contains(string(output), "regions:   FAIL 0 miss-peer, 1 down-peer"), IsTrue)
+	c.Assert(strings.Contains(string(output), "summary:   FAIL"), IsTrue)
+}
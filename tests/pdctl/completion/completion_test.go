@@ -14,9 +14,14 @@
 package completion_test
 
 import (
+	"context"
+	"strings"
 	"testing"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/tests"
 	"github.com/tikv/pd/tests/pdctl"
 )
 
@@ -28,6 +33,10 @@ var _ = Suite(&completionTestSuite{})
 
 type completionTestSuite struct{}
 
+func (s *completionTestSuite) SetUpSuite(c *C) {
+	server.EnableZap = true
+}
+
 func (s *completionTestSuite) TestCompletion(c *C) {
 	cmd := pdctl.InitCommand()
 
@@ -40,4 +49,48 @@ func (s *completionTestSuite) TestCompletion(c *C) {
 	args = []string{"completion", "zsh"}
 	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
 	c.Assert(err, IsNil)
+
+	for _, shell := range []string{"fish", "powershell"} {
+		args = []string{"completion", shell}
+		_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+		c.Assert(err, IsNil)
+		c.Assert(len(output), Greater, 0, Commentf("%s", shell))
+	}
+}
+
+func (s *completionTestSuite) TestDynamicCompletion(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctl.InitCommand()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 1, metapb.StoreState_Up, nil)
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 2, metapb.StoreState_Up, nil)
+	defer cluster.Destroy()
+
+	args := []string{"__complete", "-u", pdAddr, "store", "delete", ""}
+	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "1\ttikv1\n2\ttikv2\n:4\n"), IsTrue, Commentf("%s", output))
+
+	args = []string{"-u", pdAddr, "scheduler", "add", "grant-leader-scheduler", "1"}
+	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	args = []string{"__complete", "-u", pdAddr, "scheduler", "remove", ""}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "grant-leader-scheduler\n"), IsTrue, Commentf("%s", output))
+
+	// Only the first argument is completed.
+	args = []string{"__complete", "-u", pdAddr, "store", "label", "1", ""}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(string(output), ":4\n"), IsTrue, Commentf("%s", output))
 }
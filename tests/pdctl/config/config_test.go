@@ -15,6 +15,7 @@ package config_test
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"io/ioutil"
 	"os"
@@ -26,8 +27,10 @@ import (
 	"github.com/coreos/go-semver/semver"
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/codec"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/api"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/schedule/placement"
 	"github.com/tikv/pd/tests"
@@ -188,7 +191,7 @@ func (s *configTestSuite) TestConfig(c *C) {
 		// write
 		args1 = []string{"-u", pdAddr, "config", "set", item.name, reflect.TypeOf(item.value).String()}
 		_, _, err = pdctl.ExecuteCommandC(cmd, args1...)
-		c.Assert(err, IsNil)
+		c.Assert(err, NotNil)
 		// read
 		args2 = []string{"-u", pdAddr, "config", "show"}
 		_, output, err = pdctl.ExecuteCommandC(cmd, args2...)
@@ -202,13 +205,54 @@ func (s *configTestSuite) TestConfig(c *C) {
 	// test error or deprecated config name
 	args1 = []string{"-u", pdAddr, "config", "set", "foo-bar", "1"}
 	_, output, err = pdctl.ExecuteCommandC(cmd, args1...)
-	c.Assert(err, IsNil)
+	c.Assert(err, NotNil)
 	c.Assert(strings.Contains(string(output), "not found"), IsTrue)
 	args1 = []string{"-u", pdAddr, "config", "set", "disable-remove-down-replica", "true"}
 	_, output, err = pdctl.ExecuteCommandC(cmd, args1...)
-	c.Assert(err, IsNil)
+	c.Assert(err, NotNil)
 	c.Assert(strings.Contains(string(output), "already been deprecated"), IsTrue)
 
+	// set several options at once, none of them is applied if one is invalid.
+	scheduleCfg = *svr.GetScheduleConfig()
+	args1 = []string{"-u", pdAddr, "config", "set", "leader-schedule-limit=100", "foo-bar=1"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args1...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "not found"), IsTrue)
+	c.Assert(*svr.GetScheduleConfig(), DeepEquals, scheduleCfg)
+	args1 = []string{"-u", pdAddr, "config", "set", "leader-schedule-limit=100", "region-schedule-limit=200"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args1...)
+	c.Assert(err, IsNil)
+	diff := make(map[string]api.ConfigChange)
+	c.Assert(json.Unmarshal(output, &diff), IsNil)
+	c.Assert(diff, HasLen, 2)
+	c.Assert(diff["schedule.leader-schedule-limit"].Old, Equals, float64(scheduleCfg.LeaderScheduleLimit))
+	c.Assert(diff["schedule.leader-schedule-limit"].New, Equals, float64(100))
+	c.Assert(svr.GetScheduleConfig().RegionScheduleLimit, Equals, uint64(200))
+
+	// config show source [<option>...] shows where the values come from.
+	args1 = []string{"-u", pdAddr, "config", "show", "source", "region-schedule-limit", "replication.max-replicas"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args1...)
+	c.Assert(err, IsNil)
+	sources := make(map[string]*config.ItemSource)
+	c.Assert(json.Unmarshal(output, &sources), IsNil)
+	c.Assert(sources, HasLen, 2)
+	c.Assert(sources["schedule.region-schedule-limit"].Source, Equals, config.SourcePersisted)
+	c.Assert(sources["schedule.region-schedule-limit"].Value, Equals, float64(200))
+	c.Assert(sources["schedule.region-schedule-limit"].UpdateTime, NotNil)
+	c.Assert(sources["replication.max-replicas"].Source, Equals, config.SourceDefault)
+
+	// config show --diff shows the items which differ from the default values,
+	// a new command is used since the flag is kept for the later executions.
+	args1 = []string{"-u", pdAddr, "config", "show", "--diff"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args1...)
+	c.Assert(err, IsNil)
+	diffs := make(map[string]*config.ItemDiff)
+	c.Assert(json.Unmarshal(output, &diffs), IsNil)
+	c.Assert(diffs["schedule.region-schedule-limit"].Value, Equals, float64(200))
+	c.Assert(diffs["schedule.region-schedule-limit"].Default, Equals, float64(config.NewTestOptions().GetRegionScheduleLimit()))
+	_, ok := diffs["replication.max-replicas"]
+	c.Assert(ok, IsFalse)
+
 	// set enable-placement-rules twice, make sure it does not return error.
 	args1 = []string{"-u", pdAddr, "config", "set", "enable-placement-rules", "true"}
 	_, _, err = pdctl.ExecuteCommandC(cmd, args1...)
@@ -303,6 +347,36 @@ func (s *configTestSuite) TestPlacementRules(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(rules, HasLen, 1)
 	c.Assert(rules[0].Key(), Equals, [2]string{"pd", "test1"})
+
+	// test set rule
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), "-u", pdAddr, "config", "placement-rules", "set", "pd", "zone-a",
+		"--role=learner", "--count=2", "--constraints=zone=a|b,!disk", "--location-labels=zone,host", "--isolation-level=zone")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), "-u", pdAddr, "config", "placement-rules", "show", "--group=pd", "--id=zone-a")
+	c.Assert(err, IsNil)
+	var rule placement.Rule
+	c.Assert(json.Unmarshal(output, &rule), IsNil)
+	c.Assert(rule.Role, Equals, placement.Learner)
+	c.Assert(rule.Count, Equals, 2)
+	c.Assert(rule.IsolationLevel, Equals, "zone")
+	c.Assert(rule.LocationLabels, DeepEquals, []string{"zone", "host"})
+	c.Assert(rule.LabelConstraints, DeepEquals, []placement.LabelConstraint{
+		{Key: "zone", Op: placement.In, Values: []string{"a", "b"}},
+		{Key: "disk", Op: placement.NotExists},
+	})
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), "-u", pdAddr, "config", "placement-rules", "set", "pd", "bad", "--count=1", "--constraints=!=a")
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "invalid constraint"), IsTrue)
+
+	// test delete rule
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), "-u", pdAddr, "config", "placement-rules", "delete", "pd", "zone-a")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), "-u", pdAddr, "config", "placement-rules", "show", "--group=pd")
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, &rules), IsNil)
+	c.Assert(rules, HasLen, 1)
 }
 
 func (s *configTestSuite) TestPlacementRuleGroups(c *C) {
@@ -364,7 +438,59 @@ func (s *configTestSuite) TestPlacementRuleGroups(c *C) {
 
 	// show again
 	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "placement-rules", "rule-group", "show", "group2")
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "404"), IsTrue)
+}
+
+func (s *configTestSuite) TestLeaderAntiAffinity(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	defer cluster.Destroy()
+
+	_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), "-u", pdAddr, "config", "placement-rules", "enable")
 	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+
+	// set by keys and tables
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), "-u", pdAddr, "config", "placement-rules", "leader-anti-affinity", "set", "keys", "11", "22", "33", "")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), "-u", pdAddr, "config", "placement-rules", "leader-anti-affinity", "set", "tables", "--tables", "100,200")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), "-u", pdAddr, "config", "placement-rules", "leader-anti-affinity", "set", "bad", "--tables", "100")
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "two table IDs"), IsTrue)
+
+	// show
+	var a placement.LeaderAntiAffinity
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), "-u", pdAddr, "config", "placement-rules", "leader-anti-affinity", "show", "tables")
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, &a), IsNil)
+	c.Assert(a.Ranges[0].StartKeyHex, Equals, hex.EncodeToString(codec.EncodeBytes(codec.GenerateTableKey(100))))
+	c.Assert(a.Ranges[1].EndKeyHex, Equals, hex.EncodeToString(codec.EncodeBytes(codec.GenerateTableKey(201))))
+	var all []placement.LeaderAntiAffinity
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), "-u", pdAddr, "config", "placement-rules", "leader-anti-affinity", "show")
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, &all), IsNil)
+	c.Assert(all, HasLen, 2)
+	c.Assert(all[0].ID, Equals, "keys")
+
+	// delete
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), "-u", pdAddr, "config", "placement-rules", "leader-anti-affinity", "delete", "keys")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), "-u", pdAddr, "config", "placement-rules", "leader-anti-affinity", "show", "keys")
+	c.Assert(err, NotNil)
 	c.Assert(strings.Contains(string(output), "404"), IsTrue)
 }
 
@@ -394,11 +520,11 @@ func (s *configTestSuite) TestPlacementRuleBundle(c *C) {
 	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
 
 	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "set", "max-replicas", "1")
-	c.Assert(err, IsNil)
+	c.Assert(err, NotNil)
 	c.Assert(strings.Contains(string(output), "please update rule instead"), IsTrue)
 
 	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "set", "location-labels", "dc,rack")
-	c.Assert(err, IsNil)
+	c.Assert(err, NotNil)
 	c.Assert(strings.Contains(string(output), "please update rule instead"), IsTrue)
 
 	// test get
@@ -567,3 +693,57 @@ func (s *configTestSuite) TestReplicationMode(c *C) {
 	conf.DRAutoSync.PrimaryReplicas = 5
 	check()
 }
+
+func (s *configTestSuite) TestStoreClassLimit(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	svr := leaderServer.GetServer()
+	pdctl.MustPutStore(c, svr, 1, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "disk", Value: "hdd"}})
+	pdctl.MustPutStore(c, svr, 2, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "disk", Value: "nvme"}})
+	defer cluster.Destroy()
+
+	run := func(args ...string) string {
+		_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), append([]string{"-u", pdAddr}, args...)...)
+		c.Assert(err, IsNil)
+		return string(output)
+	}
+
+	// config set store-class-limit <key> <value>
+	run("config", "set", "store-class-limit", "disk", "hdd", "--max-snapshot-count=1")
+	run("config", "set", "store-class-limit", "disk", "sata", "--max-pending-peer-count=8")
+	run("config", "set", "store-class-limit", "disk", "hdd", "--max-snapshot-count=2", "--max-pending-peer-count=4")
+	c.Assert(svr.GetScheduleConfig().StoreClassLimits, DeepEquals, []config.StoreClassLimit{
+		{Key: "disk", Value: "hdd", MaxSnapshotCount: 2, MaxPendingPeerCount: 4},
+		{Key: "disk", Value: "sata", MaxPendingPeerCount: 8},
+	})
+	c.Assert(svr.GetPersistOptions().GetStoreMaxSnapshotCount([]*metapb.StoreLabel{{Key: "disk", Value: "hdd"}}), Equals, uint64(2))
+
+	// the limits of the store class are shown in the store status
+	store := &api.StoreInfo{}
+	c.Assert(json.Unmarshal([]byte(run("store", "1")), store), IsNil)
+	c.Assert(store.Status.StoreClass, Equals, "disk=hdd")
+	c.Assert(store.Status.MaxSnapshotCount, Equals, uint64(2))
+	c.Assert(store.Status.MaxPendingPeerCount, Equals, uint64(4))
+	store = &api.StoreInfo{}
+	c.Assert(json.Unmarshal([]byte(run("store", "2")), store), IsNil)
+	c.Assert(store.Status.StoreClass, Equals, "")
+
+	// the invalid label is rejected
+	_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), "-u", pdAddr, "config", "set", "store-class-limit", "disk", "h d d", "--max-snapshot-count=1")
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "invalid store class"), IsTrue, Commentf("%s", output))
+
+	// config delete store-class-limit <key> <value>
+	run("config", "delete", "store-class-limit", "disk", "hdd")
+	run("config", "delete", "store-class-limit", "disk", "sata")
+	c.Assert(svr.GetScheduleConfig().StoreClassLimits, HasLen, 0)
+}
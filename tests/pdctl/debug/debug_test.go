@@ -0,0 +1,203 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/api"
+	"github.com/tikv/pd/tests"
+	"github.com/tikv/pd/tests/pdctl"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&debugTestSuite{})
+
+type debugTestSuite struct{}
+
+func (s *debugTestSuite) SetUpSuite(c *C) {
+	server.EnableZap = true
+}
+
+func (s *debugTestSuite) TestDump(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctl.InitCommand()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 1, metapb.StoreState_Up, nil)
+	pdctl.MustPutRegion(c, cluster, 1, 1, []byte("a"), []byte("b"))
+	defer cluster.Destroy()
+
+	out := filepath.Join(c.MkDir(), "cluster.tar.gz")
+	args := []string{"-u", pdAddr, "debug", "dump", "--out", out}
+	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "to "+out), IsTrue, Commentf("%s", output))
+
+	files := readArchive(c, out)
+	for _, name := range []string{"cluster.json", "members.json", "config.json", "stores.json", "regions.json", "schedulers.json", "operators.json", "hot_stores.json", "etcd_usage.json"} {
+		_, ok := files[name]
+		c.Assert(ok, IsTrue, Commentf("%s", name))
+	}
+	regions := &api.RegionsInfo{}
+	c.Assert(json.Unmarshal(files["regions.json"], regions), IsNil)
+	c.Assert(regions.Count, Equals, 1)
+	stores := &api.StoresInfo{}
+	c.Assert(json.Unmarshal(files["stores.json"], stores), IsNil)
+	c.Assert(stores.Count, Equals, 1)
+}
+
+func (s *debugTestSuite) TestAnonymize(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 1, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "zone", Value: "z1"}})
+	pdctl.MustPutRegion(c, cluster, 1, 1, []byte("a"), []byte("b"))
+	defer cluster.Destroy()
+
+	dir := c.MkDir()
+	out, mapping := filepath.Join(dir, "cluster.tar.gz"), filepath.Join(dir, "mapping.json")
+	// The mapping file should be specified explicitly.
+	args := []string{"-u", pdAddr, "debug", "dump", "--out", out, "--anonymize"}
+	_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "--anonymize-map should be specified"), IsTrue, Commentf("%s", output))
+	_, err = os.Stat(out)
+	c.Assert(os.IsNotExist(err), IsTrue)
+
+	args = []string{"-u", pdAddr, "debug", "dump", "--out", out, "--anonymize", "--anonymize-map", mapping}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "mapped in "+mapping), IsTrue, Commentf("%s", output))
+
+	files := readArchive(c, out)
+	stores := &api.StoresInfo{}
+	c.Assert(json.Unmarshal(files["stores.json"], stores), IsNil)
+	c.Assert(stores.Count, Equals, 1)
+	store := stores.Stores[0].Store
+	c.Assert(strings.HasPrefix(store.Address, "addr-"), IsTrue, Commentf("%s", store.Address))
+	c.Assert(store.Labels[0].Key, Equals, "zone")
+	c.Assert(strings.HasPrefix(store.Labels[0].Value, "label-"), IsTrue, Commentf("%s", store.Labels[0].Value))
+	regions := &api.RegionsInfo{}
+	c.Assert(json.Unmarshal(files["regions.json"], regions), IsNil)
+	c.Assert(regions.Count, Equals, 1)
+	c.Assert(strings.HasPrefix(regions.Regions[0].StartKey, "key-"), IsTrue, Commentf("%s", regions.Regions[0].StartKey))
+	// The names, the paths and the URLs of the members are anonymized too.
+	cfg := leaderServer.GetConfig()
+	for name, data := range files {
+		for _, value := range []string{"tikv1", cfg.Name, cfg.DataDir, cfg.AdvertiseClientUrls, cfg.AdvertisePeerUrls} {
+			c.Assert(strings.Contains(string(data), value), IsFalse, Commentf("%s contains %s", name, value))
+		}
+	}
+	var config map[string]interface{}
+	c.Assert(json.Unmarshal(files["config.json"], &config), IsNil)
+	c.Assert(strings.HasPrefix(config["name"].(string), "name-"), IsTrue, Commentf("%v", config["name"]))
+	c.Assert(strings.HasPrefix(config["data-dir"].(string), "path-"), IsTrue, Commentf("%v", config["data-dir"]))
+
+	// The values are anonymized the same with the same mapping file.
+	args = []string{"-u", pdAddr, "store", "1", "--anonymize", "--anonymize-map", mapping}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), store.Address), IsTrue, Commentf("%s", output))
+	c.Assert(strings.Contains(string(output), "tikv1"), IsFalse, Commentf("%s", output))
+
+	cmd := pdctl.InitCommand()
+	cmd.SetIn(strings.NewReader("the store " + store.Address + " in " + store.Labels[0].Value + " is slow\n"))
+	args = []string{"debug", "deanonymize", "--anonymize-map", mapping}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(string(output), Equals, "the store tikv1 in z1 is slow\n")
+}
+
+func (s *debugTestSuite) TestEtcdUsage(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 1, metapb.StoreState_Up, nil)
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 2, metapb.StoreState_Up, nil)
+	defer cluster.Destroy()
+
+	args := []string{"-u", pdAddr, "debug", "etcd-usage"}
+	_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	usage := &server.EtcdUsage{}
+	c.Assert(json.Unmarshal(output, usage), IsNil)
+	c.Assert(usage.TotalKeys, Greater, int64(0))
+	for _, u := range usage.Prefixes {
+		if u.Prefix == "raft/s" {
+			c.Assert(u.Keys, Equals, int64(2))
+			return
+		}
+	}
+	c.Fatalf("stores are not found in %s", output)
+}
+
+func readArchive(c *C, name string) map[string][]byte {
+	f, err := os.Open(name)
+	c.Assert(err, IsNil)
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	c.Assert(err, IsNil)
+	tr := tar.NewReader(gr)
+	files := make(map[string][]byte)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		c.Assert(err, IsNil)
+		data, err := ioutil.ReadAll(tr)
+		c.Assert(err, IsNil)
+		files[header.Name] = data
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package features_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/tests"
+	"github.com/tikv/pd/tests/pdctl"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&featuresTestSuite{})
+
+type featuresTestSuite struct{}
+
+func (s *featuresTestSuite) SetUpSuite(c *C) {
+	server.EnableZap = true
+}
+
+func (s *featuresTestSuite) TestFeatures(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctl.InitCommand()
+	defer cluster.Destroy()
+
+	isEnabled := func(name string) bool {
+		args := []string{"-u", pdAddr, "features"}
+		_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+		c.Assert(err, IsNil)
+		var features []config.Feature
+		c.Assert(json.Unmarshal(output, &features), IsNil)
+		for _, f := range features {
+			if f.Name == name {
+				return f.Enabled
+			}
+		}
+		c.Fatalf("feature %s is not found", name)
+		return false
+	}
+	c.Assert(isEnabled(config.FeatureHotRegionHistory), IsTrue)
+
+	args := []string{"-u", pdAddr, "features", "disable", config.FeatureHotRegionHistory}
+	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+	c.Assert(isEnabled(config.FeatureHotRegionHistory), IsFalse)
+	c.Assert(cluster.GetServer(cluster.GetLeader()).GetServer().GetPersistOptions().IsFeatureEnabled(config.FeatureHotRegionHistory), IsFalse)
+
+	args = []string{"-u", pdAddr, "features", "enable", config.FeatureHotRegionHistory}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+	c.Assert(isEnabled(config.FeatureHotRegionHistory), IsTrue)
+
+	args = []string{"-u", pdAddr, "features", "enable", "unknown-feature"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "Unknown feature unknown-feature"), IsTrue)
+}
@@ -78,4 +78,14 @@ func (s *healthTestSuite) TestHealth(c *C) {
 	c.Assert(json.Unmarshal(output, &h), IsNil)
 	c.Assert(err, IsNil)
 	c.Assert(h, DeepEquals, healths)
+
+	// health --detail command
+	args = []string{"-u", pdAddr, "health", "--detail"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	detail := &api.HealthDetail{}
+	c.Assert(json.Unmarshal(output, detail), IsNil)
+	c.Assert(detail.Members, DeepEquals, healths)
+	c.Assert(detail.HotCache, NotNil)
+	c.Assert(detail.HotCache.State, Equals, "normal")
 }
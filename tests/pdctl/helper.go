@@ -44,9 +44,12 @@ func InitCommand() *cobra.Command {
 	rootCmd.Flags().StringVar(&commandFlags.CAPath, "cacert", "", "")
 	rootCmd.Flags().StringVar(&commandFlags.CertPath, "cert", "", "")
 	rootCmd.Flags().StringVar(&commandFlags.KeyPath, "key", "", "")
+	rootCmd.PersistentFlags().StringVarP(&commandFlags.Output, "output", "o", command.OutputJSON, "")
+	rootCmd.PersistentFlags().StringVar(&commandFlags.Sort, "sort", "", "")
 	rootCmd.AddCommand(
 		command.NewConfigCommand(),
 		command.NewRegionCommand(),
+		command.NewRegionLabelCommand(),
 		command.NewStoreCommand(),
 		command.NewStoresCommand(),
 		command.NewMemberCommand(),
@@ -59,8 +62,17 @@ func InitCommand() *cobra.Command {
 		command.NewHotSpotCommand(),
 		command.NewClusterCommand(),
 		command.NewHealthCommand(),
+		command.NewReportCommand(),
 		command.NewLogCommand(),
 		command.NewPluginCommand(),
+		command.NewJobCommand(),
+		command.NewKeyRangeCommand(),
+		command.NewDebugCommand(),
+		command.NewStatsCommand(),
+		command.NewFeaturesCommand(),
+		command.NewUnsafeCommand(),
+		command.NewVersionCommand(),
+		command.NewCheckCommand(),
 		command.NewCompletionCommand(),
 	)
 	return rootCmd
@@ -69,10 +81,14 @@ func InitCommand() *cobra.Command {
 // ExecuteCommandC is used for test purpose.
 func ExecuteCommandC(root *cobra.Command, args ...string) (c *cobra.Command, output []byte, err error) {
 	buf := new(bytes.Buffer)
-	root.SetOutput(buf)
+	root.SetOut(buf)
+	root.SetErr(buf)
 	root.SetArgs(args)
 
-	c, err = root.ExecuteC()
+	err = command.Execute(context.Background(), root)
+	// The error is printed to the output like pd-ctl does.
+	command.PrintError(root, err)
+	c, _, _ = root.Find(args)
 	return c, buf.Bytes(), err
 }
 
@@ -148,15 +164,15 @@ func MustPutRegion(c *check.C, cluster *tests.TestCluster, regionID, storeID uin
 	return r
 }
 
-// GetEcho is used to get echo from stdout.
+// GetEcho is used to get echo from stdout and stderr.
 func GetEcho(args []string) string {
 	filename := filepath.Join(os.TempDir(), "stdout")
-	old := os.Stdout
+	oldStdout, oldStderr := os.Stdout, os.Stderr
 	temp, _ := os.Create(filename)
-	os.Stdout = temp
+	os.Stdout, os.Stderr = temp, temp
 	pdctl.Start(args)
 	temp.Close()
-	os.Stdout = old
+	os.Stdout, os.Stderr = oldStdout, oldStderr
 	out, _ := ioutil.ReadFile(filename)
 	_ = os.Remove(filename)
 	return string(out)
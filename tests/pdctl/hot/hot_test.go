@@ -16,6 +16,7 @@ package hot_test
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -25,6 +26,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/api"
+	pdcluster "github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/statistics"
 	"github.com/tikv/pd/tests"
@@ -118,4 +120,51 @@ func (s *hotTestSuite) TestHot(c *C) {
 	time.Sleep(5000 * time.Millisecond)
 	testHot(hotReadRegionID, hotStoreID, "read")
 	testHot(hotWriteRegionID, hotStoreID, "write")
+
+	// test hot store summary
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 2, metapb.StoreState_Up, nil)
+	newStats = proto.Clone(leaderServer.GetStore(2).GetStoreStats()).(*pdpb.StoreStats)
+	newStats.BytesRead = 2 * bytesRead
+	for i := statistics.DefaultWriteMfSize; i > 0; i-- {
+		newStats.Interval = &pdpb.TimeInterval{StartTimestamp: uint64(now - 10*i), EndTimestamp: uint64(now - 10*i + 10)}
+		rc.GetStoresStats().Observe(2, newStats)
+	}
+	var summaries []*pdcluster.HotStoreSummary
+	args = []string{"-u", pdAddr, "hot", "store", "summary"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, &summaries), IsNil, Commentf("%s", output))
+	c.Assert(summaries, HasLen, 2)
+	c.Assert(summaries[0].StoreID, Equals, uint64(1))
+	c.Assert(summaries[0].BytesWriteRate, Equals, float64(bytesWritten)/10)
+	c.Assert(summaries[0].HotReadLeader.Count, Equals, 1)
+	c.Assert(summaries[0].HotWriteLeader.Count, Equals, 1)
+	c.Assert(summaries[0].HotWriteFollower.Count, Equals, 0)
+	args = []string{"-u", pdAddr, "hot", "store", "summary", "--sort-by=read-bytes"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, &summaries), IsNil, Commentf("%s", output))
+	c.Assert(summaries, HasLen, 2)
+	c.Assert(summaries[0].StoreID, Equals, uint64(2))
+	c.Assert(summaries[0].BytesReadRate, Equals, float64(2*bytesRead)/10)
+	args = []string{"-u", pdAddr, "hot", "store", "summary", "--sort-by=size"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "Invalid sort-by size"), IsTrue)
+
+	// test hot history
+	args = []string{"-u", pdAddr, "hot", "history", "--start", time.Now().Add(-time.Hour).Format(time.RFC3339), "--type", "read"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	var records []struct {
+		Type string `json:"type"`
+	}
+	c.Assert(json.Unmarshal(output, &records), IsNil)
+	for _, record := range records {
+		c.Assert(record.Type, Equals, "read")
+	}
+	args = []string{"-u", pdAddr, "hot", "history", "--start", "yesterday"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "invalid time yesterday"), IsTrue)
 }
@@ -0,0 +1,114 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/job"
+	"github.com/tikv/pd/tests"
+	"github.com/tikv/pd/tests/pdctl"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&jobTestSuite{})
+
+type jobTestSuite struct{}
+
+func (s *jobTestSuite) SetUpSuite(c *C) {
+	server.EnableZap = true
+}
+
+func (s *jobTestSuite) TestJob(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctl.InitCommand()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	defer cluster.Destroy()
+
+	m := leaderServer.GetRaftCluster().GetJobManager()
+	m.Register("test", func(ctx context.Context, j *job.Job, r job.Reporter) error {
+		if err := r.Report(0.5, nil); err != nil {
+			return err
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	j1, err := m.Submit("test", nil)
+	c.Assert(err, IsNil)
+	j2, err := m.Submit("test", nil)
+	c.Assert(err, IsNil)
+
+	// job list command
+	args := []string{"-u", pdAddr, "job", "list"}
+	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	var jobs []*job.Job
+	c.Assert(json.Unmarshal(output, &jobs), IsNil)
+	c.Assert(jobs, HasLen, 2)
+	c.Assert(jobs[0].ID, Equals, j1.ID)
+	c.Assert(jobs[1].ID, Equals, j2.ID)
+
+	// job cancel <job_id> command
+	args = []string{"-u", pdAddr, "job", "cancel", "1a"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "job_id should be a number"), IsTrue)
+	args = []string{"-u", pdAddr, "job", "cancel", "99999"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "[404]"), IsTrue)
+	args = []string{"-u", pdAddr, "job", "cancel", idString(j1)}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "is already cancelled"), IsTrue)
+
+	// job show <job_id> command
+	args = []string{"-u", pdAddr, "job", "show", idString(j1)}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	j := &job.Job{}
+	c.Assert(json.Unmarshal(output, j), IsNil)
+	c.Assert(j.State, Equals, job.Cancelled)
+	c.Assert(j.Progress, Equals, 0.5)
+	args = []string{"-u", pdAddr, "job", "show", idString(j2)}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, j), IsNil)
+	c.Assert(j.State, Equals, job.Running)
+}
+
+func idString(j *job.Job) string {
+	data, _ := json.Marshal(j.ID)
+	return string(data)
+}
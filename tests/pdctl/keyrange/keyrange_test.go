@@ -0,0 +1,104 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyrange_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/tests"
+	"github.com/tikv/pd/tests/pdctl"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&keyRangeTestSuite{})
+
+type keyRangeTestSuite struct{}
+
+func (s *keyRangeTestSuite) SetUpSuite(c *C) {
+	server.EnableZap = true
+}
+
+func (s *keyRangeTestSuite) TestSplitEven(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 1, metapb.StoreState_Up, nil)
+	pdctl.MustPutRegion(c, cluster, 1, 1, []byte(""), []byte("b"), core.SetApproximateSize(10))
+	pdctl.MustPutRegion(c, cluster, 2, 1, []byte("b"), []byte("c"), core.SetApproximateSize(10))
+	pdctl.MustPutRegion(c, cluster, 3, 1, []byte("c"), []byte("d"), core.SetApproximateSize(10))
+	pdctl.MustPutRegion(c, cluster, 4, 1, []byte("d"), []byte(""), core.SetApproximateSize(10))
+	defer cluster.Destroy()
+
+	splitEven := func(args ...string) []string {
+		args = append([]string{"-u", pdAddr, "keyrange", "split-even"}, args...)
+		_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+		c.Assert(err, IsNil)
+		var keys struct {
+			SplitKeys []string `json:"split_keys"`
+		}
+		c.Assert(json.Unmarshal(output, &keys), IsNil, Commentf("%s", output))
+		return keys.SplitKeys
+	}
+	// The region boundaries are used if there are enough of them.
+	c.Assert(splitEven("--format=raw", "--start-key=a", "--end-key=d", "2"), DeepEquals, []string{"62", "63"})
+	c.Assert(splitEven("--format=raw", "--start-key=", "--end-key=", "1"), DeepEquals, []string{"63"})
+	// The keys are interpolated in the regions otherwise.
+	c.Assert(splitEven("--format=raw", "--start-key=a", "--end-key=b", "3"), DeepEquals, []string{"6140", "6180", "61c0"})
+	c.Assert(splitEven("--format=hex", "--start-key=64", "1"), DeepEquals, []string{"b2"})
+	c.Assert(splitEven("--format=raw", "--start-key=b", "--end-key=d", "3"), HasLen, 3)
+
+	// keyrange split-even --file=<file> <n>
+	file := filepath.Join(c.MkDir(), "keys.json")
+	args := []string{"-u", pdAddr, "keyrange", "split-even", "--format=raw", "--start-key=a", "--end-key=d", "--file", file, "2"}
+	_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Wrote 2 split keys"), IsTrue)
+	data, err := ioutil.ReadFile(file)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(data), `"62"`), IsTrue)
+
+	for _, testCase := range []struct {
+		args   []string
+		expect string
+	}{
+		{[]string{"keyrange", "split-even", "--format=raw", "--start-key=a", "0"}, "n should be a positive number"},
+		{[]string{"keyrange", "split-even", "--format=raw", "--start-key=c", "--end-key=b", "1"}, "start-key should be less than end-key"},
+		{[]string{"region", "split-keys", "--file", filepath.Join(c.MkDir(), "none.json")}, "Failed to read split keys"},
+	} {
+		args = append([]string{"-u", pdAddr}, testCase.args...)
+		_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+		c.Assert(err, NotNil)
+		c.Assert(strings.Contains(string(output), testCase.expect), IsTrue, Commentf("%s", output))
+	}
+}
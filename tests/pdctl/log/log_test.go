@@ -15,10 +15,14 @@ package log_test
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/tests"
 	"github.com/tikv/pd/tests/pdctl"
@@ -89,4 +93,32 @@ func (s *logTestSuite) TestLog(c *C) {
 		c.Assert(err, IsNil)
 		c.Assert(svr.GetConfig().Log.Level, Equals, testCase.expect)
 	}
+
+	// log sampling set/show
+	defer logutil.SetLogSampling(logutil.DefaultLogSamplingConfig())
+	c.Assert(logutil.GetLogSampling().Enable, IsFalse)
+	args := []string{"-u", pdAddr, "log", "sampling", "set", "--enable", "--initial=10", "--interval=5s"}
+	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+	cfg := logutil.GetLogSampling()
+	c.Assert(svr.GetPDServerConfig().LogSampling, DeepEquals, cfg)
+	c.Assert(cfg.Enable, IsTrue)
+	c.Assert(cfg.Initial, Equals, uint64(10))
+	c.Assert(cfg.Thereafter, Equals, uint64(100))
+	c.Assert(cfg.Interval.Duration, Equals, 5*time.Second)
+	args = []string{"-u", pdAddr, "log", "sampling", "set", "--thereafter=0"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "thereafter should be positive"), IsTrue)
+	args = []string{"-u", pdAddr, "log", "sampling", "show"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	var sampling struct {
+		logutil.LogSamplingConfig
+		Suppressed map[string]uint64 `json:"suppressed"`
+	}
+	c.Assert(json.Unmarshal(output, &sampling), IsNil)
+	c.Assert(sampling.LogSamplingConfig, DeepEquals, cfg)
+	c.Assert(sampling.Suppressed, NotNil)
 }
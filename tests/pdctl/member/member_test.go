@@ -69,6 +69,10 @@ func (s *memberTestSuite) TestMember(c *C) {
 	c.Assert(&leader, DeepEquals, svr.GetLeader())
 
 	// member leader transfer <member_name>
+	args = []string{"-u", pdAddr, "member", "leader", "transfer", "pd4"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "not found, pd: pd4"), IsTrue)
 	args = []string{"-u", pdAddr, "member", "leader", "transfer", "pd2"}
 	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
 	c.Assert(err, IsNil)
@@ -94,6 +98,12 @@ func (s *memberTestSuite) TestMember(c *C) {
 	priority, err := svr.GetServer().GetMember().GetMemberLeaderPriority(id)
 	c.Assert(err, IsNil)
 	c.Assert(priority, Equals, 100)
+	args = []string{"-u", pdAddr, "member", "leader-priority", name, "50"}
+	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	priority, err = svr.GetServer().GetMember().GetMemberLeaderPriority(id)
+	c.Assert(err, IsNil)
+	c.Assert(priority, Equals, 50)
 
 	// member delete name <member_name>
 	err = svr.Destroy()
@@ -101,7 +111,7 @@ func (s *memberTestSuite) TestMember(c *C) {
 	members, err := etcdutil.ListEtcdMembers(client)
 	c.Assert(err, IsNil)
 	c.Assert(len(members.Members), Equals, 3)
-	args = []string{"-u", pdAddr, "member", "delete", "name", name}
+	args = []string{"-u", pdAddr, "member", "delete", "name", name, "--yes"}
 	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
 	c.Assert(err, IsNil)
 	members, err = etcdutil.ListEtcdMembers(client)
@@ -111,9 +121,108 @@ func (s *memberTestSuite) TestMember(c *C) {
 	// member delete id <member_id>
 	args = []string{"-u", pdAddr, "member", "delete", "id", fmt.Sprint(id)}
 	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
-	c.Assert(err, IsNil)
+	c.Assert(err, NotNil)
 	members, err = etcdutil.ListEtcdMembers(client)
 	c.Assert(err, IsNil)
 	c.Assert(len(members.Members), Equals, 2)
 	c.Succeed()
 }
+
+func (s *memberTestSuite) TestDrain(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 3)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	leaderName := cluster.WaitLeader()
+	c.Assert(cluster.GetServer(leaderName).BootstrapCluster(), IsNil)
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctl.InitCommand()
+	defer cluster.Destroy()
+
+	// member drain <member_name>
+	args := []string{"-u", pdAddr, "member", "drain", leaderName}
+	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	status := &server.DrainStatus{}
+	c.Assert(json.Unmarshal(output, status), IsNil)
+	c.Assert(status.Name, Equals, leaderName)
+	c.Assert(status.Draining, IsTrue)
+	c.Assert(status.ReadyForShutdown, IsTrue)
+	newLeader := cluster.WaitLeader()
+	c.Assert(newLeader, Not(Equals), leaderName)
+	c.Assert(cluster.GetServer(leaderName).GetServer().IsDraining(), IsTrue)
+
+	// member undrain <member_name>
+	args = []string{"-u", pdAddr, "member", "undrain", leaderName}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	status = &server.DrainStatus{}
+	c.Assert(json.Unmarshal(output, status), IsNil)
+	c.Assert(status.Draining, IsFalse)
+	c.Assert(cluster.GetServer(leaderName).GetServer().IsDraining(), IsFalse)
+
+	args = []string{"-u", pdAddr, "member", "drain", "unknown"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "member not found"), IsTrue)
+}
+
+func (s *memberTestSuite) TestFailover(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 3)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+	c.Assert(cluster.RunInitialServers(), IsNil)
+	cluster.WaitLeader()
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	cmd := pdctl.InitCommand()
+
+	// The request is sent to the next address if the first one is down.
+	var follower, stopped *tests.TestServer
+	for _, svr := range cluster.GetServers() {
+		if svr == leaderServer {
+			continue
+		}
+		if stopped == nil {
+			stopped = svr
+		} else {
+			follower = svr
+		}
+	}
+	c.Assert(stopped.Stop(), IsNil)
+	pdAddr := stopped.GetAddr() + "," + follower.GetAddr()
+	args := []string{"-u", pdAddr, "member", "leader", "show"}
+	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	leader := pdpb.Member{}
+	c.Assert(json.Unmarshal(output, &leader), IsNil)
+	c.Assert(leader.GetName(), Equals, leaderServer.GetServer().Name())
+
+	// The request rejected by PD is not retried with other members.
+	args = []string{"-u", pdAddr, "config", "set", "foo-bar", "1"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "not found"), IsTrue)
+	c.Assert(strings.Contains(string(output), "after trying all endpoints"), IsFalse)
+
+	// The request is sent to the members got by the address once it is down.
+	pdAddr = leaderServer.GetAddr()
+	args = []string{"-u", pdAddr, "member", "leader", "show"}
+	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(stopped.Run(), IsNil)
+	cluster.WaitLeader()
+	c.Assert(leaderServer.Stop(), IsNil)
+	newLeader := cluster.WaitLeader()
+	c.Assert(newLeader, Not(Equals), "")
+	c.Assert(newLeader, Not(Equals), leaderServer.GetServer().Name())
+	// The members may not know the new leader yet.
+	testutil.WaitUntil(c, func(c *C) bool {
+		_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+		return err == nil && json.Unmarshal(output, &leader) == nil && leader.GetName() == newLeader
+	})
+}
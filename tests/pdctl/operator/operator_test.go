@@ -15,6 +15,7 @@ package operator_test
 
 import (
 	"context"
+	"encoding/json"
 	"strings"
 	"testing"
 	"time"
@@ -24,8 +25,10 @@ import (
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
 	"github.com/tikv/pd/tests"
 	"github.com/tikv/pd/tests/pdctl"
+	"github.com/tikv/pd/tools/pd-ctl/pdctl/command"
 )
 
 func Test(t *testing.T) {
@@ -173,14 +176,60 @@ func (s *operatorTestSuite) TestOperator(c *C) {
 		c.Assert(e, IsNil)
 	}
 
+	// operator add split-region-by-key <key>... [--format=raw|encode|hex]
+	args := []string{"-u", pdAddr, "operator", "add", "split-region-by-key", "b", "bb", "--format=raw"}
+	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue, Commentf("%s", output))
+	// The key b is already a region boundary.
+	args = []string{"-u", pdAddr, "operator", "check", "1"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "split region"), IsFalse)
+	args = []string{"-u", pdAddr, "operator", "check", "3"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "split region with policy USEKEY"), IsTrue, Commentf("%s", output))
+	args = []string{"-u", pdAddr, "operator", "remove", "3"}
+	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	args = []string{"-u", pdAddr, "operator", "add", "split-region-by-key", "zz", "--format=raw"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "region of key 7a7a is not found"), IsTrue, Commentf("%s", output))
+	args = []string{"-u", pdAddr, "operator", "add", "split-region-by-key", "zz"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "Error"), IsTrue, Commentf("%s", output))
+
 	// operator add merge-region <source_region_id> <target_region_id>
-	args := []string{"-u", pdAddr, "operator", "add", "merge-region", "1", "3"}
+	args = []string{"-u", pdAddr, "operator", "add", "merge-region", "1", "3"}
 	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
 	c.Assert(err, IsNil)
 	args = []string{"-u", pdAddr, "operator", "show"}
-	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
 	c.Assert(err, IsNil)
 	c.Assert(strings.Contains(string(output), "merge region 1 into region 3"), IsTrue)
+	// operator show [kind] --creator=<creator> --min-age=<duration> --sort-by=<key>
+	args = []string{"-u", pdAddr, "operator", "show", "merge", "--creator", "manual", "--sort-by", "region"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	var ops []string
+	c.Assert(json.Unmarshal(output, &ops), IsNil)
+	c.Assert(ops, HasLen, 2)
+	c.Assert(strings.Contains(ops[0], "region:1("), IsTrue)
+	c.Assert(strings.Contains(ops[1], "region:3("), IsTrue)
+	args = []string{"-u", pdAddr, "operator", "show", "--creator", "balance-region", "--sort-by", ""}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.TrimSpace(string(output)), Equals, "null")
+	args = []string{"-u", pdAddr, "operator", "show", "--creator", "", "--min-age", "1h"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.TrimSpace(string(output)), Equals, "null")
+	args = []string{"-u", pdAddr, "operator", "show", "--min-age", "0s"}
+	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
 	args = []string{"-u", pdAddr, "operator", "remove", "1"}
 	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
 	c.Assert(err, IsNil)
@@ -188,6 +237,36 @@ func (s *operatorTestSuite) TestOperator(c *C) {
 	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
 	c.Assert(err, IsNil)
 
+	// operator history --region=<region_id> --since=<duration>
+	args = []string{"-u", pdAddr, "operator", "history", "--region", "3", "--since", "1h"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	var audits []*schedule.OperatorAudit
+	c.Assert(json.Unmarshal(output, &audits), IsNil)
+	c.Assert(len(audits), Greater, 0)
+	for _, audit := range audits {
+		c.Assert(audit.RegionID, Equals, uint64(3))
+	}
+	c.Assert(strings.Contains(audits[len(audits)-1].Operator, "merge region 1 into region 3"), IsTrue)
+	c.Assert(audits[len(audits)-1].Status, Equals, "Canceled")
+	// operator history --start-key=<key> --end-key=<key> --since=<duration>
+	args = []string{"-u", pdAddr, "operator", "history", "--start-key", "b", "--end-key", "c", "--format", "raw", "--since", "1h"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, &audits), IsNil)
+	c.Assert(len(audits), Greater, 0)
+	for _, audit := range audits {
+		c.Assert(audit.StartKey, Equals, "62")
+		c.Assert(audit.EndKey, Equals, "63")
+	}
+	args = []string{"-u", pdAddr, "operator", "history", "--start-key", "63"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, &audits), IsNil)
+	for _, audit := range audits {
+		c.Assert(audit.RegionID, Not(Equals), uint64(3))
+	}
+
 	// operator add scatter-region <region_id>
 	args = []string{"-u", pdAddr, "operator", "add", "scatter-region", "3"}
 	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
@@ -213,18 +292,94 @@ func (s *operatorTestSuite) TestOperator(c *C) {
 	_, _, err = pdctl.ExecuteCommandC(cmd, "config", "set", "enable-placement-rules", "true")
 	c.Assert(err, IsNil)
 	_, output, err = pdctl.ExecuteCommandC(cmd, "operator", "add", "transfer-region", "1", "2", "3")
-	c.Assert(err, IsNil)
+	c.Assert(err, NotNil)
 	c.Assert(strings.Contains(string(output), "not supported"), IsTrue)
 	_, output, err = pdctl.ExecuteCommandC(cmd, "operator", "add", "transfer-region", "1", "2", "follower", "3")
-	c.Assert(err, IsNil)
+	c.Assert(err, NotNil)
 	c.Assert(strings.Contains(string(output), "not match"), IsTrue)
 	_, output, err = pdctl.ExecuteCommandC(cmd, "operator", "add", "transfer-region", "1", "2", "follower", "leader", "3", "follower")
-	c.Assert(err, IsNil)
+	c.Assert(err, NotNil)
 	c.Assert(strings.Contains(string(output), "invalid"), IsTrue)
 	_, output, err = pdctl.ExecuteCommandC(cmd, "operator", "add", "transfer-region", "1", "leader", "2", "follower", "3")
-	c.Assert(err, IsNil)
+	c.Assert(err, NotNil)
 	c.Assert(strings.Contains(string(output), "invalid"), IsTrue)
 	_, output, err = pdctl.ExecuteCommandC(cmd, "operator", "add", "transfer-region", "1", "2", "leader", "3", "follower")
 	c.Assert(err, IsNil)
 	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
 }
+
+func (s *operatorTestSuite) TestAddBulkOperator(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1, func(conf *config.Config, serverName string) {
+		conf.Replication.MaxReplicas = 2
+		conf.Replication.EnablePlacementRules = false
+	})
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	defer cluster.Destroy()
+	for id := uint64(1); id <= 3; id++ {
+		pdctl.MustPutStore(c, leaderServer.GetServer(), id, metapb.StoreState_Up, nil)
+	}
+	// The leaders of region 1 and 2 are on store 1, and the leader of region 3
+	// is on store 2.
+	for i, leaderStore := range []uint64{1, 1, 2} {
+		id := uint64(i + 1)
+		key := byte('a' + i)
+		pdctl.MustPutRegion(c, cluster, id, leaderStore, []byte{key}, []byte{key + 1}, core.SetPeers([]*metapb.Peer{
+			{Id: id, StoreId: leaderStore},
+			{Id: id + 10, StoreId: 3 - leaderStore},
+		}))
+	}
+
+	testCases := []struct {
+		args   []string
+		expect string
+		code   int
+	}{
+		{[]string{"--select-jq=.leader.store_id == 1", "--action=split-region", "--to-store=2"}, "Invalid action split-region", command.ExitUsage},
+		{[]string{"--select-jq=.leader.store_id == 1", "--action=transfer-leader"}, "--to-store is required by transfer-leader", command.ExitUsage},
+		{[]string{"--select-jq=.leader.store_id ==", "--action=transfer-leader", "--to-store=2"}, "Failed to parse jq query", command.ExitUsage},
+		{[]string{"--select-jq=.leader.store_id == 3", "--action=transfer-leader", "--to-store=2"}, "No region is selected.", command.ExitOK},
+		{[]string{"--select-jq=.leader.store_id == 1", "--action=transfer-leader", "--to-store=2", "--dry-run"}, "2 regions are selected: 1, 2", command.ExitOK},
+		{[]string{"--select-jq=.leader.store_id == 1", "--action=transfer-leader", "--to-store=2", "--limit=1"}, "exceed the limit 1", command.ExitFailure},
+	}
+	for _, testCase := range testCases {
+		args := append([]string{"-u", pdAddr, "operator", "add-bulk"}, testCase.args...)
+		_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+		c.Assert(command.ExitCodeOf(err), Equals, testCase.code, Commentf("%v: %s", testCase.args, output))
+		c.Assert(strings.Contains(string(output), testCase.expect), IsTrue, Commentf("%v: %s", testCase.args, output))
+	}
+	oc := leaderServer.GetRaftCluster().GetOperatorController()
+	c.Assert(oc.GetOperators(), HasLen, 0)
+
+	// The operators are added after the confirmation.
+	args := []string{"-u", pdAddr, "operator", "add-bulk", "--select-jq=select(.leader.store_id == 1)", "--action=transfer-leader", "--to-store=2"}
+	cmd := pdctl.InitCommand()
+	cmd.SetIn(strings.NewReader("1\n"))
+	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "Aborted."), IsTrue, Commentf("%s", output))
+	c.Assert(oc.GetOperators(), HasLen, 0)
+	cmd = pdctl.InitCommand()
+	cmd.SetIn(strings.NewReader("2\n"))
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success! 2 operators are added"), IsTrue, Commentf("%s", output))
+	c.Assert(oc.GetOperator(1).Desc(), Equals, "admin-transfer-leader")
+	c.Assert(oc.GetOperator(2).Desc(), Equals, "admin-transfer-leader")
+	c.Assert(oc.GetOperator(3), IsNil)
+
+	// The regions which already have operators fail.
+	args = []string{"-u", pdAddr, "operator", "add-bulk", "--select-jq=true", "--action=remove-peer", "--from-store=2", "--yes"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "Failed to add operator for region 1"), IsTrue, Commentf("%s", output))
+	c.Assert(strings.Contains(string(output), "Failed! 1 of 3 operators are added"), IsTrue, Commentf("%s", output))
+	c.Assert(oc.GetOperator(3), NotNil)
+}
@@ -15,18 +15,28 @@ package region_test
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/codec"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/api"
+	pdcluster "github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/job"
 	"github.com/tikv/pd/tests"
 	"github.com/tikv/pd/tests/pdctl"
+	"github.com/tikv/pd/tools/pd-ctl/pdctl/command"
 )
 
 func Test(t *testing.T) {
@@ -62,6 +72,236 @@ func (s *regionTestSuite) TestRegionKeyFormat(c *C) {
 	c.Assert(strings.Contains(echo, "unknown flag"), IsFalse)
 }
 
+func (s *regionTestSuite) TestRegionScatter(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	defer cluster.Destroy()
+	for id := uint64(1); id <= 3; id++ {
+		pdctl.MustPutStore(c, leaderServer.GetServer(), id, metapb.StoreState_Up, nil)
+	}
+	for i, key := range []string{"a", "b", "c"} {
+		id := uint64(i + 1)
+		pdctl.MustPutRegion(c, cluster, id, 1, []byte(key), []byte{key[0] + 1}, core.SetPeers([]*metapb.Peer{
+			{Id: id, StoreId: 1},
+			{Id: id + 10, StoreId: 2},
+			{Id: id + 20, StoreId: 3},
+		}))
+	}
+
+	// region scatter --start-key=<key> --end-key=<key> --group=<name> command
+	// scatters the regions in the range.
+	args := []string{"-u", pdAddr, "region", "scatter", "--format=raw", "--start-key=a", "--end-key=c", "--group=import"}
+	_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	// It runs as a scatter-range job and prints the job once it is done.
+	var scattered job.Job
+	c.Assert(json.Unmarshal(output, &scattered), IsNil, Commentf("%s", output))
+	c.Assert(scattered.Type, Equals, "scatter-range")
+	c.Assert(scattered.State, Equals, job.Finished)
+	c.Assert(scattered.Progress, Equals, 1.0)
+	// The end key can be empty to scatter the regions to the end.
+	args = []string{"-u", pdAddr, "region", "scatter", "--format=raw", "--start-key=c", "--end-key="}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	scattered = job.Job{}
+	c.Assert(json.Unmarshal(output, &scattered), IsNil, Commentf("%s", output))
+	c.Assert(scattered.State, Equals, job.Finished)
+	args = []string{"-u", pdAddr, "region", "scatter", "--format=raw", "--start-key=", "--end-key="}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "should not be empty"), IsTrue)
+}
+
+func (s *regionTestSuite) TestHumanOutput(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	defer cluster.Destroy()
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 1, metapb.StoreState_Up, nil)
+	// 1.5 MiB/s in the heartbeat interval of 60s
+	pdctl.MustPutRegion(c, cluster, 1, 1, []byte("a"), []byte("b"),
+		core.SetWrittenBytes(60*3<<19), core.SetReadBytes(0), core.SetApproximateSize(2560))
+	pdctl.MustPutRegion(c, cluster, 2, 1, []byte("b"), []byte("c"),
+		core.SetWrittenBytes(0), core.SetReadBytes(60<<20), core.SetApproximateSize(512))
+
+	run := func(args ...string) string {
+		_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), append([]string{"-u", pdAddr}, args...)...)
+		c.Assert(err, IsNil)
+		return string(output)
+	}
+
+	output := run("region", "1", "--human")
+	c.Assert(strings.Contains(output, `"written_bytes": "1.50 MiB/s"`), IsTrue, Commentf("%s", output))
+	c.Assert(strings.Contains(output, `"read_bytes": "0.00 MiB/s"`), IsTrue, Commentf("%s", output))
+	c.Assert(strings.Contains(output, `"approximate_size": "2.50 GiB"`), IsTrue, Commentf("%s", output))
+	c.Assert(strings.Contains(run("region", "1"), `"approximate_size": 2560`), IsTrue)
+
+	// the regions are sorted by the raw sizes
+	output = run("region", "--human", "--sort", "approximate_size", "-o", "csv")
+	lines := strings.Split(output, "\n")
+	c.Assert(strings.Contains(lines[1], "0.50 GiB"), IsTrue, Commentf("%s", output))
+	c.Assert(strings.Contains(lines[2], "2.50 GiB"), IsTrue, Commentf("%s", output))
+
+	output = run("store", "1", "--human")
+	c.Assert(strings.Contains(output, `"region_size": "3.00 GiB"`), IsTrue, Commentf("%s", output))
+}
+
+func (s *regionTestSuite) TestRegionCompare(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	defer cluster.Destroy()
+	for id := uint64(1); id <= 2; id++ {
+		pdctl.MustPutStore(c, leaderServer.GetServer(), id, metapb.StoreState_Up, nil)
+	}
+
+	run := func(args ...string) string {
+		_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), append([]string{"-u", pdAddr}, args...)...)
+		c.Assert(err, IsNil)
+		return string(output)
+	}
+	dir := c.MkDir()
+	before, after := filepath.Join(dir, "before.json"), filepath.Join(dir, "after.json")
+
+	pdctl.MustPutRegion(c, cluster, 1, 1, []byte("a"), []byte("c"))
+	pdctl.MustPutRegion(c, cluster, 2, 1, []byte("c"), []byte("d"))
+	pdctl.MustPutRegion(c, cluster, 3, 1, []byte("d"), []byte("e"))
+	pdctl.MustPutRegion(c, cluster, 4, 1, []byte("e"), []byte("f"))
+	c.Assert(ioutil.WriteFile(before, []byte(run("region")), 0644), IsNil)
+
+	// region 1 is split, region 3 is merged into region 4, and a peer is
+	// added to region 2 which becomes its leader.
+	pdctl.MustPutRegion(c, cluster, 1, 1, []byte("a"), []byte("b"), core.SetRegionVersion(2))
+	pdctl.MustPutRegion(c, cluster, 5, 1, []byte("b"), []byte("c"), core.SetRegionVersion(2))
+	pdctl.MustPutRegion(c, cluster, 4, 1, []byte("d"), []byte("f"), core.SetRegionVersion(2))
+	peers := []*metapb.Peer{{Id: 2, StoreId: 1}, {Id: 6, StoreId: 2}}
+	pdctl.MustPutRegion(c, cluster, 2, 1, []byte("c"), []byte("d"), core.SetRegionConfVer(2), core.SetPeers(peers), core.WithLeader(peers[1]))
+	c.Assert(ioutil.WriteFile(after, []byte(run("region", "flat")), 0644), IsNil)
+
+	var comparison struct {
+		BeforeCount int `json:"before_count"`
+		AfterCount  int `json:"after_count"`
+		Splits      []struct {
+			RegionID uint64   `json:"region_id"`
+			Into     []uint64 `json:"into"`
+		} `json:"splits"`
+		Merges []struct {
+			RegionID uint64   `json:"region_id"`
+			From     []uint64 `json:"from"`
+		} `json:"merges"`
+		LeaderMoves []struct {
+			RegionID  uint64 `json:"region_id"`
+			FromStore uint64 `json:"from_store"`
+			ToStore   uint64 `json:"to_store"`
+		} `json:"leader_moves"`
+		PeerChanges []struct {
+			RegionID      uint64   `json:"region_id"`
+			AddedStores   []uint64 `json:"added_stores"`
+			RemovedStores []uint64 `json:"removed_stores"`
+		} `json:"peer_changes"`
+	}
+	output := run("region", "compare", "--before", before, "--after", after)
+	c.Assert(json.Unmarshal([]byte(output), &comparison), IsNil, Commentf("%s", output))
+	c.Assert(comparison.BeforeCount, Equals, 4)
+	c.Assert(comparison.AfterCount, Equals, 4)
+	c.Assert(comparison.Splits, HasLen, 1)
+	c.Assert(comparison.Splits[0].RegionID, Equals, uint64(1))
+	c.Assert(comparison.Splits[0].Into, DeepEquals, []uint64{1, 5})
+	c.Assert(comparison.Merges, HasLen, 1)
+	c.Assert(comparison.Merges[0].RegionID, Equals, uint64(4))
+	c.Assert(comparison.Merges[0].From, DeepEquals, []uint64{3, 4})
+	c.Assert(comparison.LeaderMoves, HasLen, 1)
+	c.Assert(comparison.LeaderMoves[0].RegionID, Equals, uint64(2))
+	c.Assert(comparison.LeaderMoves[0].FromStore, Equals, uint64(1))
+	c.Assert(comparison.LeaderMoves[0].ToStore, Equals, uint64(2))
+	c.Assert(comparison.PeerChanges, HasLen, 1)
+	c.Assert(comparison.PeerChanges[0].AddedStores, DeepEquals, []uint64{2})
+	c.Assert(comparison.PeerChanges[0].RemovedStores, IsNil)
+
+	// Nothing changes between the same dumps.
+	output = run("region", "compare", "--before", after, "--after", after)
+	c.Assert(json.Unmarshal([]byte(output), &comparison), IsNil)
+	c.Assert(comparison.Splits, HasLen, 0)
+	c.Assert(comparison.Merges, HasLen, 0)
+	c.Assert(comparison.LeaderMoves, HasLen, 0)
+	c.Assert(comparison.PeerChanges, HasLen, 0)
+
+	_, out, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), "-u", pdAddr, "region", "compare", "--before", before)
+	c.Assert(command.ExitCodeOf(err), Equals, command.ExitUsage)
+	c.Assert(strings.Contains(string(out), "Usage"), IsTrue)
+}
+
+func (s *regionTestSuite) TestRegionMerge(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	defer cluster.Destroy()
+	for id := uint64(1); id <= 3; id++ {
+		pdctl.MustPutStore(c, leaderServer.GetServer(), id, metapb.StoreState_Up, nil)
+	}
+	for i, size := range []int64{1, 5, 100} {
+		id := uint64(i + 1)
+		key := byte('a' + i)
+		pdctl.MustPutRegion(c, cluster, id, 1, []byte{key}, []byte{key + 1}, core.SetApproximateSize(size), core.SetPeers([]*metapb.Peer{
+			{Id: id, StoreId: 1},
+			{Id: id + 10, StoreId: 2},
+			{Id: id + 20, StoreId: 3},
+		}))
+	}
+
+	testCases := []struct {
+		args   []string
+		expect string
+		code   int
+	}{
+		{[]string{"2", "--with=up"}, "Invalid side up", command.ExitUsage},
+		{[]string{"4"}, "region 4 not found", command.ExitClientError},
+		{[]string{"1", "--with=left"}, "region 1 can not be merged: no left sibling", command.ExitClientError},
+		{[]string{"3"}, "region 3 can not be merged: the approximate size 100 MiB is larger than max-merge-region-size 20 MiB", command.ExitClientError},
+		// The smaller sibling is picked.
+		{[]string{"2"}, "merge region 2 into region 1", command.ExitOK},
+		{[]string{"2", "--with=right"}, "failed to add operator", command.ExitServerError},
+	}
+	for _, testCase := range testCases {
+		args := append([]string{"-u", pdAddr, "region", "merge"}, testCase.args...)
+		_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+		c.Assert(command.ExitCodeOf(err), Equals, testCase.code, Commentf("%v: %s", testCase.args, output))
+		c.Assert(strings.Contains(string(output), testCase.expect), IsTrue, Commentf("%v: %s", testCase.args, output))
+	}
+	op := leaderServer.GetRaftCluster().GetOperatorController().GetOperator(2)
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "admin-merge-region")
+}
+
 func (s *regionTestSuite) TestRegion(c *C) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -83,7 +323,7 @@ func (s *regionTestSuite) TestRegion(c *C) {
 
 	downPeer := &metapb.Peer{Id: 8, StoreId: 3}
 	r1 := pdctl.MustPutRegion(c, cluster, 1, 1, []byte("a"), []byte("b"),
-		core.SetWrittenBytes(1000), core.SetReadBytes(1000), core.SetRegionConfVer(1), core.SetRegionVersion(1), core.SetApproximateSize(10),
+		core.SetWrittenBytes(1000), core.SetReadBytes(1000), core.SetRegionConfVer(1), core.SetRegionVersion(1), core.SetApproximateSize(10), core.SetApproximateKeys(100),
 		core.SetPeers([]*metapb.Peer{
 			{Id: 1, StoreId: 1},
 			{Id: 5, StoreId: 2},
@@ -91,13 +331,13 @@ func (s *regionTestSuite) TestRegion(c *C) {
 			{Id: 7, StoreId: 4},
 		}))
 	r2 := pdctl.MustPutRegion(c, cluster, 2, 1, []byte("b"), []byte("c"),
-		core.SetWrittenBytes(2000), core.SetReadBytes(0), core.SetRegionConfVer(2), core.SetRegionVersion(3), core.SetApproximateSize(20))
+		core.SetWrittenBytes(2000), core.SetReadBytes(0), core.SetRegionConfVer(2), core.SetRegionVersion(3), core.SetApproximateSize(20), core.SetApproximateKeys(300))
 	r3 := pdctl.MustPutRegion(c, cluster, 3, 1, []byte("c"), []byte("d"),
-		core.SetWrittenBytes(500), core.SetReadBytes(800), core.SetRegionConfVer(3), core.SetRegionVersion(2), core.SetApproximateSize(30),
+		core.SetWrittenBytes(500), core.SetReadBytes(800), core.SetRegionConfVer(3), core.SetRegionVersion(2), core.SetApproximateSize(30), core.SetApproximateKeys(200),
 		core.WithDownPeers([]*pdpb.PeerStats{{Peer: downPeer, DownSeconds: 3600}}),
 		core.WithPendingPeers([]*metapb.Peer{downPeer}), core.WithLearners([]*metapb.Peer{{Id: 3, StoreId: 1}}))
 	r4 := pdctl.MustPutRegion(c, cluster, 4, 1, []byte("d"), []byte("e"),
-		core.SetWrittenBytes(100), core.SetReadBytes(100), core.SetRegionConfVer(1), core.SetRegionVersion(1), core.SetApproximateSize(10))
+		core.SetWrittenBytes(100), core.SetReadBytes(100), core.SetRegionConfVer(1), core.SetRegionVersion(1), core.SetApproximateSize(10), core.SetApproximateKeys(50))
 	defer cluster.Destroy()
 
 	var testRegionsCases = []struct {
@@ -127,6 +367,19 @@ func (s *regionTestSuite) TestRegion(c *C) {
 		{[]string{"region", "topsize", "2"}, api.TopNRegions(leaderServer.GetRegions(), func(a, b *core.RegionInfo) bool {
 			return a.GetApproximateSize() < b.GetApproximateSize()
 		}, 2)},
+		// region topkeys [limit] command
+		{[]string{"region", "topkeys", "2"}, api.TopNRegions(leaderServer.GetRegions(), func(a, b *core.RegionInfo) bool {
+			return a.GetApproximateKeys() < b.GetApproximateKeys()
+		}, 2)},
+		// region top --by=<metric> [--limit=<limit>] [--asc] command
+		{[]string{"region", "top", "--by=read", "--limit=2"}, []*core.RegionInfo{r1, r3}},
+		{[]string{"region", "top", "--by=keys", "--limit=2", "--asc"}, []*core.RegionInfo{r4, r1}},
+		{[]string{"region", "top", "--by=version", "--limit=0", "--asc=false"}, api.TopNRegions(leaderServer.GetRegions(), func(a, b *core.RegionInfo) bool {
+			return a.GetMeta().GetRegionEpoch().GetVersion() < b.GetMeta().GetRegionEpoch().GetVersion()
+		}, 16)},
+		// region ids <region_id>,... command
+		{[]string{"region", "ids", "3,1"}, []*core.RegionInfo{r3, r1}},
+		{[]string{"region", "ids", "2", "4,100"}, []*core.RegionInfo{r2, r4}},
 		// region check extra-peer command
 		{[]string{"region", "check", "extra-peer"}, []*core.RegionInfo{r1}},
 		// region check miss-peer command
@@ -137,10 +390,23 @@ func (s *regionTestSuite) TestRegion(c *C) {
 		{[]string{"region", "check", "down-peer"}, []*core.RegionInfo{r3}},
 		// region check learner-peer command
 		{[]string{"region", "check", "learner-peer"}, []*core.RegionInfo{r3}},
+		// region check undersized-region command
+		{[]string{"region", "check", "undersized-region"}, []*core.RegionInfo{r1, r2, r4}},
+		// region check leader-on-store <store_id> command
+		{[]string{"region", "check", "leader-on-store", "1"}, []*core.RegionInfo{r1, r2, r4}},
+		// region check follower-on-store <store_id> command
+		{[]string{"region", "check", "follower-on-store", "2"}, []*core.RegionInfo{r1}},
+		// region check learner-on-store <store_id> command
+		{[]string{"region", "check", "learner-on-store", "1"}, []*core.RegionInfo{r3}},
 		// region startkey --format=raw <key> command
 		{[]string{"region", "startkey", "--format=raw", "b", "2"}, []*core.RegionInfo{r2, r3}},
 		// region startkey --format=hex <key> command
 		{[]string{"region", "startkey", "--format=hex", "63", "2"}, []*core.RegionInfo{r3, r4}},
+		// region keys --start-key=<key> --end-key=<key> command
+		{[]string{"region", "keys", "--format=raw", "--start-key=b", "--end-key=d"}, []*core.RegionInfo{r2, r3}},
+		{[]string{"region", "keys", "--format=hex", "--start-key=6262", "--end-key=6462"}, []*core.RegionInfo{r2, r3, r4}},
+		{[]string{"region", "keys", "--format=raw", "--start-key=b", "--end-key=", "--limit=1"}, []*core.RegionInfo{r2}},
+		{[]string{"region", "keys", "--format=raw", "--start-key=", "--end-key=b", "--limit=0"}, []*core.RegionInfo{r1}},
 	}
 
 	for _, testCase := range testRegionsCases {
@@ -152,6 +418,32 @@ func (s *regionTestSuite) TestRegion(c *C) {
 		pdctl.CheckRegionsInfo(c, regionsInfo, testCase.expect)
 	}
 
+	// region [--min-size --max-size --min-keys --max-keys --store --key-prefix] command
+	var testRegionFilterCases = []struct {
+		args   []string
+		expect []*core.RegionInfo
+	}{
+		{[]string{"region", "--min-size=20"}, []*core.RegionInfo{r2, r3}},
+		{[]string{"region", "--max-size=10", "--min-keys=100"}, []*core.RegionInfo{r1}},
+		{[]string{"region", "--max-keys=200"}, []*core.RegionInfo{r1, r3, r4}},
+		{[]string{"region", "--store=2"}, []*core.RegionInfo{r1}},
+		{[]string{"region", "--min-size=10", "--store=5"}, []*core.RegionInfo{}},
+		{[]string{"region", "--key-prefix=63"}, []*core.RegionInfo{r3}},
+		{[]string{"region", "--key-prefix=c", "--format=raw", "--min-size=40"}, []*core.RegionInfo{}},
+	}
+	for _, testCase := range testRegionFilterCases {
+		args := append([]string{"-u", pdAddr}, testCase.args...)
+		_, output, e := pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+		c.Assert(e, IsNil)
+		regionsInfo := api.RegionsInfo{}
+		c.Assert(json.Unmarshal(output, &regionsInfo), IsNil)
+		pdctl.CheckRegionsInfo(c, regionsInfo, testCase.expect)
+	}
+	args := []string{"-u", pdAddr, "region", "1", "--min-size=10"}
+	_, output, e := pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, NotNil)
+	c.Assert(strings.Contains(string(output), "the filters can not be used with region_id"), IsTrue)
+
 	var testRegionCases = []struct {
 		args   []string
 		expect *api.RegionInfo
@@ -164,6 +456,8 @@ func (s *regionTestSuite) TestRegion(c *C) {
 		{[]string{"region", "key", "--format=hex", "62"}, api.NewRegionInfo(r2)},
 		// issue #2351
 		{[]string{"region", "key", "--format=hex", "622f62"}, api.NewRegionInfo(r2)},
+		// the upper-case hex key printed by TiKV logs
+		{[]string{"region", "key", "--format=hex", "622F62"}, api.NewRegionInfo(r2)},
 	}
 
 	for _, testCase := range testRegionCases {
@@ -174,4 +468,394 @@ func (s *regionTestSuite) TestRegion(c *C) {
 		c.Assert(json.Unmarshal(output, &regionInfo), IsNil)
 		c.Assert(&regionInfo, DeepEquals, testCase.expect)
 	}
+
+	// region flat command outputs a region per line
+	args = []string{"-u", pdAddr, "region", "flat"}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, IsNil)
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	c.Assert(lines, HasLen, 4)
+	flat := api.RegionsInfo{Count: len(lines)}
+	for _, line := range lines {
+		region := &api.RegionInfo{}
+		c.Assert(json.Unmarshal([]byte(line), region), IsNil)
+		flat.Regions = append(flat.Regions, region)
+	}
+	pdctl.CheckRegionsInfo(c, flat, []*core.RegionInfo{r1, r2, r3, r4})
+
+	// the same keys are anonymized the same
+	args = []string{"-u", pdAddr, "region", "flat", "--anonymize", "--anonymize-map", filepath.Join(c.MkDir(), "mapping.json")}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, IsNil)
+	lines = strings.Split(strings.TrimSpace(string(output)), "\n")
+	c.Assert(lines, HasLen, 4)
+	keys := make(map[string]string)
+	for _, r := range flat.Regions {
+		keys[r.StartKey], keys[r.EndKey] = "", ""
+	}
+	anonymized := make(map[string]string)
+	for _, line := range lines {
+		region := &api.RegionInfo{}
+		c.Assert(json.Unmarshal([]byte(line), region), IsNil)
+		var origin *api.RegionInfo
+		for _, r := range flat.Regions {
+			if r.ID == region.ID {
+				origin = r
+			}
+		}
+		c.Assert(origin, NotNil)
+		for k, v := range map[string]string{origin.StartKey: region.StartKey, origin.EndKey: region.EndKey} {
+			c.Assert(k == "" && v == "" || strings.HasPrefix(v, "key-"), IsTrue, Commentf("%s", line))
+			if prev, ok := anonymized[v]; ok {
+				c.Assert(prev, Equals, k)
+			}
+			if keys[k] != "" {
+				c.Assert(keys[k], Equals, v)
+			}
+			keys[k], anonymized[v] = v, k
+		}
+	}
+
+	// region store --label <key>=<value> command, a new command is used since
+	// the slice flag appends the values of the previous executions.
+	args = []string{"-u", pdAddr, "store", "label", "1", "zone", "z1"}
+	_, _, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, IsNil)
+	args = []string{"-u", pdAddr, "region", "store", "--label", "zone=z1"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, IsNil)
+	regionsInfo := api.RegionsInfo{}
+	c.Assert(json.Unmarshal(output, &regionsInfo), IsNil)
+	pdctl.CheckRegionsInfo(c, regionsInfo, []*core.RegionInfo{r1, r2, r3, r4})
+	args = []string{"-u", pdAddr, "region", "store", "--label", "zone=z2"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, IsNil)
+	c.Assert(json.Unmarshal(output, &regionsInfo), IsNil)
+	c.Assert(regionsInfo.Count, Equals, 0)
+	// region store --label <key> command shows the distribution per value.
+	args = []string{"-u", pdAddr, "region", "store", "--label", "zone"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, IsNil)
+	var distributions []struct {
+		Label      string `json:"label"`
+		StoreCount int    `json:"store_count"`
+	}
+	c.Assert(json.Unmarshal(output, &distributions), IsNil)
+	c.Assert(distributions, HasLen, 1)
+	c.Assert(distributions[0].Label, Equals, "zone=z1")
+	c.Assert(distributions[0].StoreCount, Equals, 1)
+
+	// region stats [--by-store|--by-table] command
+	args = []string{"-u", pdAddr, "region", "stats", "--by-store"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, IsNil)
+	distribution := api.RegionDistribution{}
+	c.Assert(json.Unmarshal(output, &distribution), IsNil)
+	c.Assert(distribution.RegionCount, Equals, 4)
+	c.Assert(distribution.Stores, Not(HasLen), 0)
+	c.Assert(distribution.PeerSkew, NotNil)
+	args = []string{"-u", pdAddr, "region", "stats", "--by-store", "--by-table"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, NotNil)
+	c.Assert(strings.Contains(string(output), "only one of"), IsTrue)
+
+	// region at --time=<time> [--id=<region_id>] command, no snapshot is taken
+	// since it is disabled by default.
+	args = []string{"-u", pdAddr, "region", "at", "--time", "2020-01-01T00:00:00Z", "--id", "1"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, NotNil)
+	c.Assert(strings.Contains(string(output), "no region topology snapshot"), IsTrue)
+	args = []string{"-u", pdAddr, "region", "at", "--time", "yesterday"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, NotNil)
+	c.Assert(strings.Contains(string(output), "invalid time"), IsTrue)
+
+	// region verify [--store=<store_id>] command, the store without a status
+	// address can not be verified.
+	args = []string{"-u", pdAddr, "region", "verify", "--store", "1", "--jq", ".stores[] | .error"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, IsNil)
+	c.Assert(string(output), Equals, "\"no status address\"\n")
+	args = []string{"-u", pdAddr, "region", "verify", "--store", "100"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, NotNil)
+	c.Assert(strings.Contains(string(output), "store 100 not found"), IsTrue)
+
+	// region <region_id> --jq="<query string>" command
+	args = []string{"-u", pdAddr, "region", "1", "--jq", ".peers | map(.store_id)"}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, IsNil)
+	c.Assert(string(output), Equals, "[1,2,3,4]\n")
+	// region topsize <limit> --jq="<query string>" command outputs a line for each result.
+	args = []string{"-u", pdAddr, "region", "topsize", "2", "--jq", ".regions[] | .id"}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, IsNil)
+	c.Assert(string(output), Equals, "3\n2\n")
+	args = []string{"-u", pdAddr, "region", "topsize", "2", "--jq", ".regions["}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, NotNil)
+	c.Assert(strings.Contains(string(output), "Failed to parse jq query"), IsTrue)
+
+	// region scan --limit=<limit> --start-key=<key> command outputs the regions page by page.
+	args = []string{"-u", pdAddr, "region", "scan", "--limit", "2", "--jq", ".count"}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, IsNil)
+	c.Assert(string(output), Equals, "2\n2\n0\n")
+	args = []string{"-u", pdAddr, "region", "scan", "--limit", "2", "--start-key", "63", "--jq", ".regions[] | .id"}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, IsNil)
+	c.Assert(string(output), Equals, "3\n4\n")
+	args = []string{"-u", pdAddr, "region", "scan", "--limit", "0"}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, NotNil)
+	c.Assert(strings.Contains(string(output), "limit should be a positive number"), IsTrue)
+
+	// region gc-range --start-key=<key> --end-key=<key> command marks the range as dropped.
+	args = []string{"-u", pdAddr, "region", "gc-range", "--format=raw", "--start-key=b", "--end-key=d", "--ttl=10m"}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, IsNil)
+	c.Assert(strings.Contains(string(output), "expire_time"), IsTrue)
+	rc := leaderServer.GetRaftCluster()
+	c.Assert(rc.IsRegionInGCRange(r1), IsFalse)
+	c.Assert(rc.IsRegionInGCRange(r2), IsTrue)
+	c.Assert(rc.IsRegionInGCRange(r3), IsTrue)
+	args = []string{"-u", pdAddr, "region", "gc-range", "show"}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, IsNil)
+	var ranges []*pdcluster.GCRange
+	c.Assert(json.Unmarshal(output, &ranges), IsNil)
+	c.Assert(ranges, HasLen, 1)
+	c.Assert(ranges[0].HexStart, Equals, "62")
+	c.Assert(ranges[0].HexEnd, Equals, "64")
+
+	// region freeze --start-key=<key> --end-key=<key> --ttl=<duration> command
+	// freezes the scheduling of the range until it is unfrozen.
+	args = []string{"-u", pdAddr, "region", "freeze", "--format=raw", "--start-key=a", "--end-key=b", "--ttl=10m"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, IsNil)
+	c.Assert(strings.Contains(string(output), "expire_time"), IsTrue)
+	c.Assert(rc.IsRegionScheduleDenied(r1), IsTrue)
+	c.Assert(rc.IsRegionScheduleDenied(r2), IsFalse)
+	args = []string{"-u", pdAddr, "region", "freeze", "show"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, IsNil)
+	var frozenRanges []*pdcluster.FrozenRange
+	c.Assert(json.Unmarshal(output, &frozenRanges), IsNil)
+	c.Assert(frozenRanges, HasLen, 1)
+	c.Assert(frozenRanges[0].HexStart, Equals, "61")
+	args = []string{"-u", pdAddr, "region", "unfreeze", "--format=raw", "--start-key=a", "--end-key=b"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, IsNil)
+	c.Assert(strings.Contains(string(output), "unfrozen"), IsTrue)
+	c.Assert(rc.IsRegionScheduleDenied(r1), IsFalse)
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, NotNil)
+	c.Assert(strings.Contains(string(output), "not frozen"), IsTrue)
+
+	// region-label set <id> <key>=<value> --start-key=<key> --end-key=<key>
+	// command denies the scheduling of the range until the rule is deleted.
+	args = []string{"-u", pdAddr, "region-label", "set", "batch-job", "schedule=deny", "owner=etl", "--format=raw", "--start-key=a", "--end-key=b"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+	c.Assert(rc.IsRegionScheduleDenied(r1), IsTrue)
+	c.Assert(rc.IsRegionScheduleDenied(r2), IsFalse)
+	args = []string{"-u", pdAddr, "region-label", "set", "bad", "schedule"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, NotNil)
+	c.Assert(strings.Contains(string(output), "Invalid label"), IsTrue)
+	args = []string{"-u", pdAddr, "region-label", "show"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, IsNil)
+	var labelRules []*pdcluster.LabelRule
+	c.Assert(json.Unmarshal(output, &labelRules), IsNil)
+	c.Assert(labelRules, HasLen, 1)
+	c.Assert(labelRules[0].HexStart, Equals, "61")
+	c.Assert(labelRules[0].Labels, HasLen, 2)
+	args = []string{"-u", pdAddr, "region-label", "delete", "batch-job"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, IsNil)
+	c.Assert(rc.IsRegionScheduleDenied(r1), IsFalse)
+	args = []string{"-u", pdAddr, "region-label", "show", "batch-job"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, NotNil)
+	c.Assert(strings.Contains(string(output), "does not exist"), IsTrue)
+
+	// config region-label set <key>=<value> --ttl=<duration> command generates
+	// the id by the range and the rule expires after the TTL.
+	args = []string{"-u", pdAddr, "config", "region-label", "set", "schedule=deny", "--format=raw", "--start-key=c", "--end-key=d", "--ttl=2h"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, IsNil)
+	c.Assert(strings.Contains(string(output), "Success! The region label rule is range-63-64"), IsTrue)
+	c.Assert(rc.IsRegionScheduleDenied(r3), IsTrue)
+	args = []string{"-u", pdAddr, "config", "region-label", "show", "range-63-64"}
+	_, output, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, IsNil)
+	var labelRule pdcluster.LabelRule
+	c.Assert(json.Unmarshal(output, &labelRule), IsNil)
+	c.Assert(labelRule.ExpireTime, NotNil)
+	c.Assert(labelRule.ExpireTime.Sub(time.Now()) > time.Hour, IsTrue)
+	args = []string{"-u", pdAddr, "config", "region-label", "delete", "range-63-64"}
+	_, _, e = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(e, IsNil)
+	c.Assert(rc.IsRegionScheduleDenied(r3), IsFalse)
+
+	// region topkeys <limit> --output csv command outputs a row for each region.
+	args = []string{"-u", pdAddr, "region", "topkeys", "2", "--output", "csv"}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, IsNil)
+	records, err := csv.NewReader(strings.NewReader(string(output))).ReadAll()
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 3)
+	columns := make(map[string]int)
+	for i, column := range records[0] {
+		columns[column] = i
+	}
+	for _, column := range []string{"id", "start_key", "end_key", "leader.store_id", "peers.store_id", "approximate_size", "approximate_keys", "written_bytes", "read_bytes"} {
+		_, ok := columns[column]
+		c.Assert(ok, IsTrue, Commentf("column %s is missing", column))
+	}
+	c.Assert(records[1][columns["id"]], Equals, "2")
+	c.Assert(records[1][columns["approximate_keys"]], Equals, "300")
+	c.Assert(records[2][columns["id"]], Equals, "3")
+	// The stores of the peers are joined in a column.
+	args = []string{"-u", pdAddr, "region", "1", "--jq=", "--output", "csv"}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, IsNil)
+	records, err = csv.NewReader(strings.NewReader(string(output))).ReadAll()
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	for i, column := range records[0] {
+		columns[column] = i
+	}
+	c.Assert(records[1][columns["peers.store_id"]], Equals, "1 2 3 4")
+	c.Assert(records[1][columns["leader.store_id"]], Equals, "1")
+	args = []string{"-u", pdAddr, "region", "1", "--output", "json"}
+	_, _, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, IsNil)
+	// region check stale-heartbeat --threshold=<duration> command
+	args = []string{"-u", pdAddr, "region", "check", "stale-heartbeat", "--threshold=1h"}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, IsNil)
+	regionsInfo = api.RegionsInfo{}
+	c.Assert(json.Unmarshal(output, &regionsInfo), IsNil)
+	c.Assert(regionsInfo.Count, Equals, 0)
+	args = []string{"-u", pdAddr, "region", "check", "stale-heartbeat", "--threshold=1ns"}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, IsNil)
+	regionsInfo = api.RegionsInfo{}
+	c.Assert(json.Unmarshal(output, &regionsInfo), IsNil)
+	pdctl.CheckRegionsInfo(c, regionsInfo, []*core.RegionInfo{r1, r2, r3, r4})
+
+	// region --sort=<field> command sorts the regions by the field.
+	for _, testCase := range []struct {
+		field  string
+		expect []uint64
+	}{
+		{"approximate_keys", []uint64{4, 1, 3, 2}},
+		{"start_key", []uint64{1, 2, 3, 4}},
+	} {
+		args = []string{"-u", pdAddr, "region", "--jq=", "--sort=" + testCase.field}
+		_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+		c.Assert(e, IsNil)
+		regionsInfo = api.RegionsInfo{}
+		c.Assert(json.Unmarshal(output, &regionsInfo), IsNil)
+		ids := make([]uint64, 0, len(regionsInfo.Regions))
+		for _, region := range regionsInfo.Regions {
+			ids = append(ids, region.ID)
+		}
+		c.Assert(ids, DeepEquals, testCase.expect)
+	}
+	args = []string{"-u", pdAddr, "region", "--sort=foo"}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, NotNil)
+	c.Assert(strings.Contains(string(output), "unknown sort field foo"), IsTrue)
+	args = []string{"-u", pdAddr, "region", "--sort="}
+	_, _, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, IsNil)
+
+	// region top without --by prints the supported metrics.
+	args = []string{"-u", pdAddr, "region", "top", "--by="}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, NotNil)
+	c.Assert(strings.Contains(string(output), "--by should be one of read|write|size|keys|version|confver"), IsTrue)
+
+	// region history <region_id> command
+	pdctl.MustPutRegion(c, cluster, 1, 1, []byte("a"), []byte("b"),
+		core.SetRegionConfVer(1), core.SetRegionVersion(1), core.SetPeers(r1.GetPeers()),
+		core.WithLeader(&metapb.Peer{Id: 5, StoreId: 2}))
+	args = []string{"-u", pdAddr, "region", "history", "1"}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, IsNil)
+	var events []pdcluster.RegionEvent
+	c.Assert(json.Unmarshal(output, &events), IsNil)
+	c.Assert(len(events), Greater, 0)
+	c.Assert(events[len(events)-1].Type, Equals, pdcluster.RegionEventLeaderTransfer)
+	c.Assert(events[len(events)-1].Detail, Equals, "from store 1 to store 2")
+	args = []string{"-u", pdAddr, "region", "history", "a"}
+	_, output, e = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(e, NotNil)
+	c.Assert(strings.Contains(string(output), "region_id should be a number"), IsTrue)
+}
+
+func (s *regionTestSuite) TestRegionDecode(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctl.InitCommand()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 1, metapb.StoreState_Up, nil)
+	defer cluster.Destroy()
+
+	tableKey := codec.EncodeBytes(codec.GenerateTableKey(100))
+	indexKey := codec.EncodeBytes(codec.GenerateIndexKey(100, 2))
+	rowKey := codec.EncodeBytes(codec.GenerateRowKey(100, 1))
+	pdctl.MustPutRegion(c, cluster, 1, 1, []byte(""), tableKey)
+	pdctl.MustPutRegion(c, cluster, 2, 1, tableKey, indexKey)
+	pdctl.MustPutRegion(c, cluster, 3, 1, indexKey, rowKey)
+	pdctl.MustPutRegion(c, cluster, 4, 1, rowKey, []byte(""))
+
+	type decodedRegion struct {
+		ID      uint64 `json:"id"`
+		TableID int64  `json:"table_id"`
+		IndexID int64  `json:"index_id"`
+	}
+	args := []string{"-u", pdAddr, "region", "--decode"}
+	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	var regions struct {
+		Count   int              `json:"count"`
+		Regions []*decodedRegion `json:"regions"`
+	}
+	c.Assert(json.Unmarshal(output, &regions), IsNil)
+	c.Assert(regions.Count, Equals, 4)
+	sort.Slice(regions.Regions, func(i, j int) bool { return regions.Regions[i].ID < regions.Regions[j].ID })
+	c.Assert(regions.Regions, DeepEquals, []*decodedRegion{
+		{ID: 1},
+		{ID: 2, TableID: 100},
+		{ID: 3, TableID: 100, IndexID: 2},
+		{ID: 4, TableID: 100},
+	})
+
+	// region <region_id> --decode command
+	args = []string{"-u", pdAddr, "region", "3", "--decode"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	region := &decodedRegion{}
+	c.Assert(json.Unmarshal(output, region), IsNil)
+	c.Assert(region, DeepEquals, &decodedRegion{ID: 3, TableID: 100, IndexID: 2})
+	c.Assert(strings.Index(string(output), `"index_id"`) > strings.Index(string(output), `"end_key"`), IsTrue)
+
+	// The annotated fields can be used in the jq query.
+	args = []string{"-u", pdAddr, "region", "scan", "--start-key", hex.EncodeToString(indexKey), "--decode", "--jq", ".regions[] | .index_id"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(string(output), Equals, "2\nnull\n")
 }
@@ -0,0 +1,81 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/codec"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/api"
+	"github.com/tikv/pd/tests"
+	"github.com/tikv/pd/tests/pdctl"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&reportTestSuite{})
+
+type reportTestSuite struct{}
+
+func (s *reportTestSuite) SetUpSuite(c *C) {
+	server.EnableZap = true
+}
+
+func (s *reportTestSuite) TestReplication(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctl.InitCommand()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 1, metapb.StoreState_Up, nil)
+	defer cluster.Destroy()
+
+	table1 := codec.EncodeBytes(codec.GenerateTableKey(1))
+	pdctl.MustPutRegion(c, cluster, 1, 1, []byte(""), table1)
+	pdctl.MustPutRegion(c, cluster, 2, 1, table1, []byte(""))
+
+	// report replication command
+	args := []string{"-u", pdAddr, "report", "replication"}
+	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	report := &api.ReplicationReport{}
+	c.Assert(json.Unmarshal(output, report), IsNil)
+	c.Assert(report.Summary.RegionCount, Equals, 2)
+	c.Assert(report.Tables, HasLen, 0)
+
+	// report replication --by-table command
+	args = []string{"-u", pdAddr, "report", "replication", "--by-table"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	report = &api.ReplicationReport{}
+	c.Assert(json.Unmarshal(output, report), IsNil)
+	c.Assert(report.Tables, HasLen, 2)
+	for _, table := range report.Tables {
+		c.Assert(table.RegionCount, Equals, 1)
+	}
+}
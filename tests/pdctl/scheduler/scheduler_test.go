@@ -288,6 +288,16 @@ func (s *schedulerTestSuite) TestScheduler(c *C) {
 	})
 	mustExec([]string{"-u", pdAddr, "scheduler", "resume", "balance-leader-scheduler"}, nil)
 	checkSchedulerWithStatusCommand(nil, "paused", nil)
+	mustExec([]string{"-u", pdAddr, "scheduler", "pause", "balance-leader-scheduler", "30m"}, nil)
+	checkSchedulerWithStatusCommand(nil, "paused", []string{
+		"balance-leader-scheduler",
+	})
+	mustExec([]string{"-u", pdAddr, "scheduler", "resume", "balance-leader-scheduler"}, nil)
+	checkSchedulerWithStatusCommand(nil, "paused", nil)
+	_, output, err := pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "scheduler", "pause", "balance-leader-scheduler", "500ms")
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "invalid delay"), IsTrue)
+	checkSchedulerWithStatusCommand(nil, "paused", nil)
 
 	// set label scheduler to disabled manually.
 	cfg := leaderServer.GetServer().GetScheduleConfig()
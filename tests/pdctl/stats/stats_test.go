@@ -0,0 +1,80 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/api"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/tests"
+	"github.com/tikv/pd/tests/pdctl"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&statsTestSuite{})
+
+type statsTestSuite struct{}
+
+func (s *statsTestSuite) SetUpSuite(c *C) {
+	server.EnableZap = true
+}
+
+func (s *statsTestSuite) TestReplication(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctl.InitCommand()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	for i := uint64(1); i <= 3; i++ {
+		pdctl.MustPutStore(c, leaderServer.GetServer(), i, metapb.StoreState_Up, nil)
+	}
+	defer cluster.Destroy()
+
+	pdctl.MustPutRegion(c, cluster, 1, 1, []byte("a"), []byte("b"))
+	pdctl.MustPutRegion(c, cluster, 2, 1, []byte("b"), []byte("c"), core.SetPeers([]*metapb.Peer{
+		{Id: 2, StoreId: 1},
+		{Id: 3, StoreId: 2},
+		{Id: 4, StoreId: 3},
+	}))
+
+	// stats replication command
+	args := []string{"-u", pdAddr, "stats", "replication"}
+	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	stats := &api.ReplicationStats{}
+	c.Assert(json.Unmarshal(output, stats), IsNil)
+	c.Assert(stats.RegionCount, Equals, 2)
+	c.Assert(stats.ReplicaCount, HasLen, 5)
+	c.Assert(stats.ReplicaCount[0].Count, Equals, 0)
+	c.Assert(stats.ReplicaCount[1].Count, Equals, 1)
+	c.Assert(stats.ReplicaCount[3].Count, Equals, 1)
+	c.Assert(stats.Health["miss-peer"], Equals, 1)
+	c.Assert(stats.Health["healthy"], Equals, 1)
+}
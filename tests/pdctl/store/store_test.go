@@ -19,13 +19,21 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/docker/go-units"
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/api"
+	"github.com/tikv/pd/server/cluster"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/core/storelimit"
 	"github.com/tikv/pd/tests"
 	"github.com/tikv/pd/tests/pdctl"
+	"github.com/tikv/pd/tools/pd-ctl/pdctl/command"
+	"gopkg.in/yaml.v2"
 )
 
 func Test(t *testing.T) {
@@ -96,6 +104,37 @@ func (s *storeTestSuite) TestStore(c *C) {
 	c.Assert(json.Unmarshal(output, &storesInfo), IsNil)
 	pdctl.CheckStoresInfo(c, storesInfo.Stores, stores)
 
+	// store --sort=<field> command, id is short for store.id.
+	args = []string{"-u", pdAddr, "store", "--state", "Up,Tombstone", "--sort=id"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	storesInfo = new(api.StoresInfo)
+	c.Assert(json.Unmarshal(output, &storesInfo), IsNil)
+	c.Assert(storesInfo.Stores, HasLen, 3)
+	for i, store := range storesInfo.Stores {
+		c.Assert(store.Store.GetId(), Equals, uint64(i+1))
+	}
+	args = []string{"-u", pdAddr, "store", "--sort="}
+	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+
+	// store scores command
+	args = []string{"-u", pdAddr, "store", "scores"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	var scores []struct {
+		ID          uint64  `json:"id"`
+		LeaderScore float64 `json:"leader_score"`
+		DiskIORate  string  `json:"disk_io_rate"`
+		Utilization float64 `json:"utilization"`
+	}
+	c.Assert(json.Unmarshal(output, &scores), IsNil)
+	c.Assert(scores, HasLen, 2)
+	c.Assert(scores[0].ID, Equals, uint64(1))
+	c.Assert(scores[1].ID, Equals, uint64(3))
+	c.Assert(scores[0].DiskIORate, Equals, "0B")
+	c.Assert(scores[0].Utilization, Equals, float64(0))
+
 	// store <store_id> command
 	args = []string{"-u", pdAddr, "store", "1"}
 	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
@@ -104,6 +143,19 @@ func (s *storeTestSuite) TestStore(c *C) {
 	c.Assert(json.Unmarshal(output, &storeInfo), IsNil)
 	pdctl.CheckStoresInfo(c, []*api.StoreInfo{storeInfo}, stores[:1])
 
+	// store --addr=<address> command, a new command is used so the flag does
+	// not stick to the shared one.
+	args = []string{"-u", pdAddr, "store", "--addr", "tikv3"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	addrStoreInfo := new(api.StoreInfo)
+	c.Assert(json.Unmarshal(output, &addrStoreInfo), IsNil)
+	pdctl.CheckStoresInfo(c, []*api.StoreInfo{addrStoreInfo}, stores[1:2])
+	args = []string{"-u", pdAddr, "store", "--addr", "tikv4"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "not found"), IsTrue)
+
 	// store label <store_id> <key> <value> [<key> <value>]... [flags] command
 	c.Assert(storeInfo.Store.Labels, IsNil)
 	args = []string{"-u", pdAddr, "store", "label", "1", "zone", "cn"}
@@ -145,6 +197,20 @@ func (s *storeTestSuite) TestStore(c *C) {
 	c.Assert(label0.Value, Equals, "uk")
 	c.Assert(len(storeInfo.Store.Labels), Equals, 1)
 
+	// store --labels <key>=<value> command, a new command is used since the
+	// slice flag appends the values of the previous executions.
+	args = []string{"-u", pdAddr, "store", "--labels", "zone=uk"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	storesInfo = new(api.StoresInfo)
+	c.Assert(json.Unmarshal(output, &storesInfo), IsNil)
+	c.Assert(storesInfo.Count, Equals, 1)
+	c.Assert(storesInfo.Stores[0].Store.GetId(), Equals, uint64(1))
+	args = []string{"-u", pdAddr, "store", "--labels", "zone"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "Invalid label zone"), IsTrue)
+
 	// store weight <store_id> <leader_weight> <region_weight> command
 	c.Assert(storeInfo.Status.LeaderWeight, Equals, float64(1))
 	c.Assert(storeInfo.Status.RegionWeight, Equals, float64(1))
@@ -158,6 +224,21 @@ func (s *storeTestSuite) TestStore(c *C) {
 	c.Assert(storeInfo.Status.LeaderWeight, Equals, float64(5))
 	c.Assert(storeInfo.Status.RegionWeight, Equals, float64(10))
 
+	// store config <store_id> set <key> <value> [<key> <value>]... command
+	args = []string{"-u", pdAddr, "store", "config", "1", "set", "snap-concurrency", "4", "import-mode", "true"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+	args = []string{"-u", pdAddr, "store", "config", "1", "delete", "import-mode"}
+	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	args = []string{"-u", pdAddr, "store", "config", "1"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	storeConfig := make(map[string]string)
+	c.Assert(json.Unmarshal(output, &storeConfig), IsNil)
+	c.Assert(storeConfig, DeepEquals, map[string]string{"snap-concurrency": "4"})
+
 	// store limit <store_id> <rate>
 	args = []string{"-u", pdAddr, "store", "limit", "1", "10"}
 	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
@@ -176,6 +257,25 @@ func (s *storeTestSuite) TestStore(c *C) {
 	limit = leaderServer.GetRaftCluster().GetStoreLimitByType(1, storelimit.AddPeer)
 	c.Assert(limit, Equals, float64(10))
 
+	// store limit <store_id> --type <type> <rate>
+	args = []string{"-u", pdAddr, "store", "limit", "1", "--type", "add-peer", "15"}
+	_, _, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	limit = leaderServer.GetRaftCluster().GetStoreLimitByType(1, storelimit.AddPeer)
+	c.Assert(limit, Equals, float64(15))
+	limit = leaderServer.GetRaftCluster().GetStoreLimitByType(1, storelimit.RemovePeer)
+	c.Assert(limit, Equals, float64(5))
+	args = []string{"-u", pdAddr, "store", "limit", "1", "--type", "bad", "15"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "type should be"), IsTrue)
+	// The limit is persisted.
+	persisted := config.NewConfig()
+	loaded, err := leaderServer.GetServer().GetStorage().LoadConfig(persisted)
+	c.Assert(err, IsNil)
+	c.Assert(loaded, IsTrue)
+	c.Assert(persisted.Schedule.StoreLimit[1], DeepEquals, config.StoreLimitConfig{AddPeer: 15, RemovePeer: 5})
+
 	// store limit all <rate>
 	args = []string{"-u", pdAddr, "store", "limit", "all", "20"}
 	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
@@ -214,7 +314,7 @@ func (s *storeTestSuite) TestStore(c *C) {
 	// store limit all 0 is invalid
 	args = []string{"-u", pdAddr, "store", "limit", "all", "0"}
 	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
-	c.Assert(err, IsNil)
+	c.Assert(err, NotNil)
 	c.Assert(strings.Contains(string(output), "rate should be a number that > 0"), IsTrue)
 
 	// store limit <type>
@@ -237,8 +337,20 @@ func (s *storeTestSuite) TestStore(c *C) {
 	// store delete <store_id> command
 	c.Assert(storeInfo.Store.State, Equals, metapb.StoreState_Up)
 	args = []string{"-u", pdAddr, "store", "delete", "1"}
-	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
+	cmd.SetIn(strings.NewReader("2\n"))
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "Aborted."), IsTrue)
+	args = []string{"-u", pdAddr, "store", "1"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
 	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, &storeInfo), IsNil)
+	c.Assert(storeInfo.Store.State, Equals, metapb.StoreState_Up)
+	args = []string{"-u", pdAddr, "store", "delete", "1"}
+	cmd.SetIn(strings.NewReader("1\n"))
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasSuffix(string(output), "Success!\n"), IsTrue)
 	args = []string{"-u", pdAddr, "store", "1"}
 	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
 	c.Assert(err, IsNil)
@@ -246,7 +358,7 @@ func (s *storeTestSuite) TestStore(c *C) {
 	c.Assert(storeInfo.Store.State, Equals, metapb.StoreState_Offline)
 
 	// store delete addr <address>
-	args = []string{"-u", pdAddr, "store", "delete", "addr", "tikv3"}
+	args = []string{"-u", pdAddr, "store", "delete", "addr", "tikv3", "--yes"}
 	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
 	c.Assert(string(output), Equals, "Success!\n")
 	c.Assert(err, IsNil)
@@ -256,6 +368,53 @@ func (s *storeTestSuite) TestStore(c *C) {
 	c.Assert(json.Unmarshal(output, &storeInfo), IsNil)
 	c.Assert(storeInfo.Store.State, Equals, metapb.StoreState_Offline)
 
+	// store delete <store_id> --wait --progress=json prints the progress to
+	// stderr until the store is Tombstone.
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 4, metapb.StoreState_Up, nil)
+	args = []string{"-u", pdAddr, "store", "delete", "4", "--force", "--yes", "--wait", "--progress=json"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	progressLines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	c.Assert(progressLines, HasLen, 3)
+	c.Assert(progressLines[0], Equals, "Success!")
+	var progress struct {
+		Percent float64 `json:"percent"`
+		Step    string  `json:"step"`
+	}
+	c.Assert(json.Unmarshal([]byte(progressLines[1]), &progress), IsNil)
+	c.Assert(progress.Percent, Equals, float64(100))
+	c.Assert(progress.Step, Equals, metapb.StoreState_Tombstone.String())
+	c.Assert(progressLines[2], Equals, "Store 4 is Tombstone now")
+	args = []string{"-u", pdAddr, "store", "delete", "4", "--wait", "--progress=xml"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "unknown progress format"), IsTrue)
+
+	// store drain <store_id> --wait prints the progress in text until no
+	// region is left, draining an Offline store again is harmless.
+	args = []string{"-u", pdAddr, "store", "drain", "3", "--wait"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	progressLines = strings.Split(strings.TrimSpace(string(output)), "\n")
+	c.Assert(strings.HasPrefix(progressLines[0], "Store 3 is draining by job "), IsTrue, Commentf("%s", output))
+	c.Assert(strings.HasSuffix(progressLines[0], ", 0 regions left"), IsTrue, Commentf("%s", output))
+	c.Assert(progressLines[len(progressLines)-2:], DeepEquals, []string{"100.0%, 0 regions left", "Store 3 is drained"})
+	args = []string{"-u", pdAddr, "store", "drain", "4"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	c.Assert(string(output), Equals, "Store 4 is already Tombstone\n")
+
+	// store delete <store_id> --force
+	args = []string{"-u", pdAddr, "store", "delete", "1", "--force", "--yes"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(string(output), Equals, "Success!\n")
+	args = []string{"-u", pdAddr, "store", "1"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, &storeInfo), IsNil)
+	c.Assert(storeInfo.Store.State, Equals, metapb.StoreState_Tombstone)
+
 	// store remove-tombstone
 	args = []string{"-u", pdAddr, "store", "remove-tombstone"}
 	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
@@ -296,7 +455,7 @@ func (s *storeTestSuite) TestStore(c *C) {
 	c.Assert(scene.Idle, Equals, 200)
 
 	// store limit-scene <scene> <rate> <type>
-	args = []string{"-u", pdAddr, "store", "limit-scene", "idle", "100", "remove-peer"}
+	args = []string{"-u", pdAddr, "store", "limit-scene", "idle", "120", "remove-peer"}
 	_, _, err = pdctl.ExecuteCommandC(cmd, args...)
 	c.Assert(err, IsNil)
 	args = []string{"-u", pdAddr, "store", "limit-scene", "remove-peer"}
@@ -305,6 +464,138 @@ func (s *storeTestSuite) TestStore(c *C) {
 	c.Assert(err, IsNil)
 	err = json.Unmarshal(output, scene)
 	c.Assert(err, IsNil)
-	c.Assert(scene.Idle, Equals, 100)
+	c.Assert(scene.Idle, Equals, 120)
+
+	// store <store_id> --output yaml
+	args = []string{"-u", pdAddr, "store", "3", "--output", "yaml"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	var yamlInfo struct {
+		Store struct {
+			ID      uint64 `yaml:"id"`
+			Address string `yaml:"address"`
+		} `yaml:"store"`
+	}
+	c.Assert(yaml.Unmarshal(output, &yamlInfo), IsNil)
+	c.Assert(yamlInfo.Store.ID, Equals, uint64(3))
+	c.Assert(yamlInfo.Store.Address, Equals, "tikv3")
+
+	// store --output table
+	args = []string{"-u", pdAddr, "store", "--state", "Up,Offline", "--output", "table"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	c.Assert(lines, HasLen, 2)
+	header := strings.Fields(lines[0])
+	c.Assert(header[0], Equals, "STORE.ID")
+	c.Assert(header[1], Equals, "STORE.ADDRESS")
+	c.Assert(strings.Fields(lines[1])[:2], DeepEquals, []string{"3", "tikv3"})
+
+	// store --output csv
+	args = []string{"-u", pdAddr, "store", "--state", "Up,Offline", "--output", "csv"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	lines = strings.Split(strings.TrimSpace(string(output)), "\n")
+	c.Assert(lines, HasLen, 2)
+	c.Assert(strings.HasPrefix(lines[0], "store.id,store.address,"), IsTrue)
+	c.Assert(strings.HasPrefix(lines[1], "3,tikv3,"), IsTrue)
 
+	// unknown output format
+	args = []string{"-u", pdAddr, "store", "--output", "xml"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "unknown output format xml"), IsTrue)
+
+	args = []string{"-u", pdAddr, "store", "--output", "json"}
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	storesInfo = new(api.StoresInfo)
+	c.Assert(json.Unmarshal(output, &storesInfo), IsNil)
+	c.Assert(storesInfo.Count, Equals, 1)
+}
+
+func (s *storeTestSuite) TestLintStoreLabels(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	testCluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = testCluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	testCluster.WaitLeader()
+	pdAddr := testCluster.GetConfig().GetClientURL()
+	cmd := pdctl.InitCommand()
+	defer testCluster.Destroy()
+
+	leaderServer := testCluster.GetServer(testCluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	cfg := leaderServer.GetServer().GetReplicationConfig()
+	cfg.LocationLabelValues = map[string]typeutil.StringSlice{"zone": {"us-east-1", "us-west-1"}}
+	c.Assert(leaderServer.GetServer().SetReplicationConfig(*cfg), IsNil)
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 1, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "zone", Value: "us-east-1"}})
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 2, metapb.StoreState_Up, []*metapb.StoreLabel{{Key: "zone", Value: "us-esat-1"}})
+
+	// store lint-labels
+	args := []string{"-u", pdAddr, "store", "lint-labels"}
+	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	var issues []*cluster.LabelIssue
+	c.Assert(json.Unmarshal(output, &issues), IsNil)
+	c.Assert(issues, HasLen, 1)
+	c.Assert(issues[0].StoreID, Equals, uint64(2))
+	c.Assert(issues[0].Suggestion, Equals, "us-east-1")
+}
+
+func (s *storeTestSuite) TestCheckDeleteStore(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	testCluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = testCluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	testCluster.WaitLeader()
+	pdAddr := testCluster.GetConfig().GetClientURL()
+	defer testCluster.Destroy()
+
+	leaderServer := testCluster.GetServer(testCluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	svr := leaderServer.GetServer()
+	putStore := func(id uint64) {
+		pdctl.MustPutStore(c, svr, id, metapb.StoreState_Up, nil)
+		_, err := svr.StoreHeartbeat(context.Background(), &pdpb.StoreHeartbeatRequest{
+			Header: &pdpb.RequestHeader{ClusterId: svr.ClusterID()},
+			Stats:  &pdpb.StoreStats{StoreId: id, Capacity: 100 * units.GiB, Available: 80 * units.GiB},
+		})
+		c.Assert(err, IsNil)
+	}
+	for id := uint64(1); id <= 3; id++ {
+		putStore(id)
+	}
+	pdctl.MustPutRegion(c, testCluster, 1, 1, []byte("a"), []byte("b"), core.SetApproximateSize(64), core.SetPeers([]*metapb.Peer{
+		{Id: 11, StoreId: 1}, {Id: 12, StoreId: 2}, {Id: 13, StoreId: 3},
+	}))
+
+	// store check-delete <store_id>
+	args := []string{"-u", pdAddr, "store", "check-delete", "1"}
+	_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	check := &cluster.StoreDeleteCheck{}
+	c.Assert(json.Unmarshal(output, check), IsNil)
+	c.Assert(check.Safe, IsFalse)
+	c.Assert(check.RegionCount, Equals, 1)
+	c.Assert(check.Issues, DeepEquals, []string{"rule pd/default needs 3 stores but only 2 remain"})
+	c.Assert(check.UnplaceableRegions, HasLen, 1)
+	c.Assert(check.UnplaceableRegions[0].RegionID, Equals, uint64(1))
+	c.Assert(command.ExitCodeOf(err), Equals, command.ExitCheckFailed)
+
+	putStore(4)
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	check = &cluster.StoreDeleteCheck{}
+	c.Assert(json.Unmarshal(output, check), IsNil)
+	c.Assert(check.Safe, IsTrue)
+	c.Assert(int64(check.BytesToMove), Equals, int64(64*units.MiB))
+	c.Assert(check.EstimatedDuration, NotNil)
+
+	args = []string{"-u", pdAddr, "store", "check-delete", "100"}
+	_, _, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(command.ExitCodeOf(err), Equals, command.ExitClientError)
 }
@@ -14,13 +14,17 @@
 package tso_test
 
 import (
+	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/tsoutil"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/tests"
 	"github.com/tikv/pd/tests/pdctl"
 )
 
@@ -58,3 +62,37 @@ func (s *tsoTestSuite) TestTSO(c *C) {
 	str := fmt.Sprintln("system: ", physicalTime) + fmt.Sprintln("logic: ", logicalTime)
 	c.Assert(str, Equals, string(output))
 }
+
+func (s *tsoTestSuite) TestTSONow(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	c.Assert(cluster.RunInitialServers(), IsNil)
+	defer cluster.Destroy()
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctl.InitCommand()
+
+	// tso now
+	before := time.Now()
+	args := []string{"-u", pdAddr, "tso", "now"}
+	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	c.Assert(lines, HasLen, 3)
+	c.Assert(strings.HasPrefix(lines[0], "tso: "), IsTrue)
+	ts, err := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(lines[0], "tso: ")), 10, 64)
+	c.Assert(err, IsNil)
+	physicalTime, logical := tsoutil.ParseTS(ts)
+	c.Assert(physicalTime.After(before.Add(-time.Second)), IsTrue)
+	c.Assert(lines[1], Equals, strings.TrimSpace(fmt.Sprintln("system: ", physicalTime)))
+	c.Assert(lines[2], Equals, strings.TrimSpace(fmt.Sprintln("logic: ", logical)))
+
+	// The timestamps are increasing.
+	_, output, err = pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, IsNil)
+	next, err := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(strings.Split(string(output), "\n")[0], "tso: ")), 10, 64)
+	c.Assert(err, IsNil)
+	c.Assert(next > ts, IsTrue)
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unsafe_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/testutil"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/versioninfo"
+	"github.com/tikv/pd/tests"
+	"github.com/tikv/pd/tests/pdctl"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&unsafeTestSuite{})
+
+type unsafeTestSuite struct{}
+
+func (s *unsafeTestSuite) SetUpSuite(c *C) {
+	server.EnableZap = true
+}
+
+func (s *unsafeTestSuite) TestPlanRemoveFailedStores(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tc, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = tc.RunInitialServers()
+	c.Assert(err, IsNil)
+	tc.WaitLeader()
+	pdAddr := tc.GetConfig().GetClientURL()
+	cmd := pdctl.InitCommand()
+
+	leaderServer := tc.GetServer(tc.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	pdctl.MustPutStore(c, leaderServer.GetServer(), 1, metapb.StoreState_Up, nil)
+	// Store 2 has never sent heartbeats, so it is regarded as failed.
+	rc := leaderServer.GetRaftCluster()
+	c.Assert(rc.PutStore(&metapb.Store{
+		Id:      2,
+		Address: "tikv2",
+		State:   metapb.StoreState_Up,
+		Version: versioninfo.MinSupportedVersion(versioninfo.Version2_0).String(),
+	}), IsNil)
+	peers := []*metapb.Peer{{Id: 11, StoreId: 1}, {Id: 12, StoreId: 2}}
+	region := core.NewRegionInfo(&metapb.Region{
+		Id:          10,
+		StartKey:    []byte("a"),
+		EndKey:      []byte("b"),
+		Peers:       peers,
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+	}, peers[0])
+	c.Assert(tc.HandleRegionHeartbeat(region), IsNil)
+	defer tc.Destroy()
+
+	args := []string{"-u", pdAddr, "unsafe", "plan-remove-failed-stores", "show"}
+	_, output, err := pdctl.ExecuteCommandC(cmd, args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "there is no unsafe recovery"), IsTrue, Commentf("%s", output))
+
+	args = []string{"-u", pdAddr, "unsafe", "plan-remove-failed-stores", "2,x", "-y"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "store_id should be a number"), IsTrue)
+
+	args = []string{"-u", pdAddr, "unsafe", "plan-remove-failed-stores", "1", "-y"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "still sending heartbeats"), IsTrue, Commentf("%s", output))
+
+	args = []string{"-u", pdAddr, "unsafe", "plan-remove-failed-stores", "2", "--timeout=1m", "-y"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	var status cluster.UnsafeRecoveryStatus
+	c.Assert(json.Unmarshal(output, &status), IsNil, Commentf("%s", output))
+	c.Assert(status.Stage, Equals, cluster.UnsafeRecoveryRecovering)
+	c.Assert(status.Plans, HasLen, 1)
+	c.Assert(status.Plans[0].Address, Equals, "tikv1")
+	c.Assert(status.Plans[0].Regions, DeepEquals, []uint64{10})
+
+	// The recovery is done once the failed peer is removed from the region.
+	c.Assert(tc.HandleRegionHeartbeat(region.Clone(core.WithRemoveStorePeer(2), core.WithIncConfVer())), IsNil)
+	args = []string{"-u", pdAddr, "unsafe", "plan-remove-failed-stores", "show"}
+	testutil.WaitUntil(c, func(c *C) bool {
+		_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+		c.Assert(err, IsNil)
+		c.Assert(json.Unmarshal(output, &status), IsNil, Commentf("%s", output))
+		return status.Stage == cluster.UnsafeRecoveryFinished
+	})
+	c.Assert(status.Recovered, Equals, 1)
+}
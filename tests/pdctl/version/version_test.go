@@ -0,0 +1,92 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/versioninfo"
+	"github.com/tikv/pd/tests"
+	"github.com/tikv/pd/tests/pdctl"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&versionTestSuite{})
+
+type versionTestSuite struct{}
+
+func (s *versionTestSuite) SetUpSuite(c *C) {
+	server.EnableZap = true
+}
+
+func (s *versionTestSuite) TestCheckCompat(c *C) {
+	// The client and the servers share the version in the test.
+	defer func(v string) { versioninfo.PDReleaseVersion = v }(versioninfo.PDReleaseVersion)
+	versioninfo.PDReleaseVersion = "v4.0.8"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	defer cluster.Destroy()
+
+	args := []string{"-u", pdAddr, "version", "--check-compat"}
+	_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "pd-ctl: v4.0.8"), IsTrue, Commentf("%s", output))
+	c.Assert(strings.Contains(string(output), "(leader) "+pdAddr+": v4.0.8, compatible"), IsTrue, Commentf("%s", output))
+	c.Assert(strings.Contains(string(output), "Warning"), IsFalse)
+
+	versioninfo.PDReleaseVersion = "v4.1.0"
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "pd-ctl is newer than the server"), IsTrue, Commentf("%s", output))
+	c.Assert(strings.Contains(string(output), "Warning: pd-ctl is not compatible with 1 PD server(s)"), IsTrue)
+
+	// The compatibility is not checked by default.
+	args = []string{"-u", pdAddr, "version"}
+	_, output, err = pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Warning"), IsFalse)
+}
+
+func (s *versionTestSuite) TestUnsupportedAPI(c *C) {
+	// The server is older than all the APIs except the status.
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/pd/api/v1/status" {
+			w.Write([]byte(`{"version": "v3.0.0"}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer svr.Close()
+
+	args := []string{"-u", svr.URL, "region", "1"}
+	_, output, err := pdctl.ExecuteCommandC(pdctl.InitCommand(), args...)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(string(output), "the API /pd/api/v1/region/id/1 is not supported by the PD server of version v3.0.0"), IsTrue, Commentf("%s", output))
+}
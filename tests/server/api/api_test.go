@@ -137,10 +137,14 @@ func (s *testRedirectorSuite) TestRedirect(c *C) {
 	c.Assert(leader, NotNil)
 	header := mustRequestSuccess(c, leader.GetServer())
 	header.Del("Date")
+	c.Assert(header.Get(serverapi.HandleTimeHeader), Not(Equals), "")
+	header.Del(serverapi.HandleTimeHeader)
 	for _, svr := range s.cluster.GetServers() {
 		if svr != leader {
 			h := mustRequestSuccess(c, svr.GetServer())
 			h.Del("Date")
+			c.Assert(h.Get(serverapi.HandleTimeHeader), Not(Equals), "")
+			h.Del(serverapi.HandleTimeHeader)
 			c.Assert(header, DeepEquals, h)
 		}
 	}
@@ -15,6 +15,7 @@ package cluster_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 	"testing"
@@ -39,6 +40,8 @@ import (
 	syncer "github.com/tikv/pd/server/region_syncer"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/tests"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 func Test(t *testing.T) {
@@ -797,6 +800,44 @@ func (s *clusterTestSuite) TestReplicationModeStatus(c *C) {
 	c.Assert(hbRes.GetReplicationStatus().GetMode(), Equals, replication_modepb.ReplicationMode_DR_AUTO_SYNC) // check status in store heartbeat response
 }
 
+func (s *clusterTestSuite) TestStoreConfigInHeartbeat(c *C) {
+	tc, err := tests.NewTestCluster(s.ctx, 1)
+	defer tc.Destroy()
+	c.Assert(err, IsNil)
+	err = tc.RunInitialServers()
+	c.Assert(err, IsNil)
+	tc.WaitLeader()
+	leaderServer := tc.GetServer(tc.GetLeader())
+	grpcPDClient := testutil.MustNewGrpcClient(c, leaderServer.GetAddr())
+	clusterID := leaderServer.GetClusterID()
+	bootstrapCluster(c, clusterID, grpcPDClient, "127.0.0.1:0")
+	rc := leaderServer.GetRaftCluster()
+	c.Assert(rc, NotNil)
+	store := &metapb.Store{Id: 11, Address: "127.0.0.1:1", Version: "v4.1.0"}
+	_, err = putStore(c, grpcPDClient, clusterID, store)
+	c.Assert(err, IsNil)
+
+	getStoreConfig := func() map[string]string {
+		req := &pdpb.StoreHeartbeatRequest{
+			Header: testutil.NewRequestHeader(clusterID),
+			Stats:  &pdpb.StoreStats{StoreId: store.GetId()},
+		}
+		var md metadata.MD
+		_, err := grpcPDClient.StoreHeartbeat(context.Background(), req, grpc.Header(&md))
+		c.Assert(err, IsNil)
+		values := md.Get(cluster.StoreConfigMetadataKey)
+		c.Assert(values, HasLen, 1)
+		items := make(map[string]string)
+		c.Assert(json.Unmarshal([]byte(values[0]), &items), IsNil)
+		return items
+	}
+	c.Assert(getStoreConfig(), HasLen, 0)
+	c.Assert(rc.UpdateStoreConfig(store.GetId(), map[string]string{"snap-concurrency": "4"}), IsNil)
+	c.Assert(getStoreConfig(), DeepEquals, map[string]string{"snap-concurrency": "4"})
+	c.Assert(rc.DeleteStoreConfig(store.GetId(), "snap-concurrency"), IsNil)
+	c.Assert(getStoreConfig(), HasLen, 0)
+}
+
 func newIsBootstrapRequest(clusterID uint64) *pdpb.IsBootstrappedRequest {
 	req := &pdpb.IsBootstrappedRequest{
 		Header: testutil.NewRequestHeader(clusterID),
@@ -29,6 +29,19 @@ func main() {
 	if pdAddr != "" {
 		os.Args = append(os.Args, "-u", pdAddr)
 	}
+	// The TLS files can be specified by the environment variables, so that
+	// they are not repeated in every command. They are put before the
+	// arguments, so the flags have higher priority.
+	var tlsArgs []string
+	for _, env := range []struct{ name, flag string }{
+		{"PD_CACERT", "--cacert"},
+		{"PD_CERT", "--cert"},
+		{"PD_KEY", "--key"},
+	} {
+		if path := os.Getenv(env.name); path != "" {
+			tlsArgs = append(tlsArgs, env.flag, path)
+		}
+	}
 
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc,
@@ -48,9 +61,11 @@ func main() {
 		}
 	}()
 
+	args := append(tlsArgs, os.Args[1:]...)
 	var input []string
 	stat, _ := os.Stdin.Stat()
-	if (stat.Mode() & os.ModeCharDevice) == 0 {
+	// The commands are read from stdin line by line in the batch mode.
+	if (stat.Mode()&os.ModeCharDevice) == 0 && !pdctl.IsBatch(args) {
 		b, err := ioutil.ReadAll(os.Stdin)
 		if err != nil {
 			fmt.Println(err)
@@ -59,5 +74,5 @@ func main() {
 		input = strings.Split(strings.TrimSpace(string(b[:])), " ")
 	}
 
-	pdctl.MainStart(append(os.Args[1:], input...))
+	pdctl.MainStart(append(args, input...))
 }
@@ -0,0 +1,112 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pdctl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-shellwords"
+	"github.com/tikv/pd/tools/pd-ctl/pdctl/command"
+)
+
+// stdinFile is the file name to read the commands from stdin in the batch mode.
+const stdinFile = "-"
+
+var (
+	batchFile       string
+	continueOnError bool
+)
+
+// IsBatch returns whether pd-ctl reads the commands from a file or stdin, like
+// `pd-ctl --file commands.txt` and `pd-ctl -`.
+func IsBatch(args []string) bool {
+	_, ok := batchInput(args)
+	return ok
+}
+
+// batchInput returns the file to read the commands from. The `--file` flag of
+// the subcommands, like `region split --file`, is not for the batch mode.
+func batchInput(args []string) (string, bool) {
+	rootCmd := getMainCmd(args)
+	switch rest := rootCmd.Flags().Args(); {
+	case len(rest) == 1 && rest[0] == stdinFile:
+		return stdinFile, true
+	case len(rest) == 0 && batchFile != "":
+		return batchFile, true
+	}
+	return "", false
+}
+
+func startBatch(file string) int {
+	in := io.Reader(os.Stdin)
+	if file != stdinFile {
+		f, err := os.Open(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to open the command file:", err)
+			return command.ExitUsage
+		}
+		defer f.Close()
+		in = f
+	}
+	return runBatch(in, continueOnError, os.Stderr)
+}
+
+// runBatch executes the commands one per line like the interactive mode, so
+// the connections to PD are shared by the commands. The empty lines and the
+// lines starting with # are skipped. The destructive commands need --yes since
+// they can not be confirmed. It stops at the first failed command
+// unless continueOnError is set, and returns the exit code of the first
+// failure.
+func runBatch(in io.Reader, continueOnError bool, errOut io.Writer) int {
+	command.SetBatchMode(true)
+	defer command.SetBatchMode(false)
+	code := command.ExitOK
+	fail := func(c int) {
+		if code == command.ExitOK {
+			code = c
+		}
+	}
+	scanner := bufio.NewScanner(in)
+	// The commands with the JSON arguments can be long.
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "exit" || line == "quit" {
+			break
+		}
+		args, err := shellwords.Parse(line)
+		if err != nil {
+			fmt.Fprintf(errOut, "Failed to parse line %d: %v\n", lineNum, err)
+			fail(command.ExitUsage)
+		} else {
+			fail(Start(args))
+		}
+		if code != command.ExitOK && !continueOnError {
+			fmt.Fprintf(errOut, "Stopped at line %d: %s\n", lineNum, line)
+			return code
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintln(errOut, "Failed to read the commands:", err)
+		fail(command.ExitFailure)
+	}
+	return code
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pdctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pingcap/errors"
+	"github.com/spf13/cobra"
+	"github.com/tikv/pd/tools/pd-ctl/pdctl/command"
+)
+
+// configFileName is the file in the home directory to keep the profiles of the
+// clusters.
+const configFileName = ".pd-ctl.toml"
+
+// clusterProfile is how to connect to a cluster, it is configured in the config
+// file like:
+//
+//	[clusters.prod-a]
+//	pd = "https://10.0.1.1:2379,https://10.0.1.2:2379"
+//	cacert = "/path/to/ca.pem"
+//	cert = "/path/to/client.pem"
+//	key = "/path/to/client-key.pem"
+type clusterProfile struct {
+	URL      string `toml:"pd"`
+	CAPath   string `toml:"cacert"`
+	CertPath string `toml:"cert"`
+	KeyPath  string `toml:"key"`
+}
+
+type ctlConfig struct {
+	Clusters map[string]clusterProfile `toml:"clusters"`
+}
+
+func configFile() string {
+	if commandFlags.Config != "" {
+		return commandFlags.Config
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return configFileName
+	}
+	return filepath.Join(home, configFileName)
+}
+
+// loadClusterProfiles returns the profiles of the clusters by names.
+func loadClusterProfiles(path string, names []string) (map[string]clusterProfile, error) {
+	cfg := &ctlConfig{}
+	if _, err := toml.DecodeFile(path, cfg); err != nil {
+		return nil, errors.Annotatef(err, "failed to load the config file %s", path)
+	}
+	profiles := make(map[string]clusterProfile, len(names))
+	for _, name := range names {
+		p, ok := cfg.Clusters[name]
+		if !ok {
+			return nil, errors.Errorf("cluster %s is not found in the config file %s", name, path)
+		}
+		if p.URL == "" {
+			return nil, errors.Errorf("the pd address of cluster %s is not configured", name)
+		}
+		profiles[name] = p
+	}
+	return profiles, nil
+}
+
+// fanOut executes the read-only command against the clusters concurrently, and
+// prints the outputs keyed by the cluster names. The outputs are merged into a
+// JSON object with the json format, or printed one by one otherwise.
+func fanOut(rootCmd *cobra.Command, args []string) int {
+	var names []string
+	for _, name := range strings.Split(commandFlags.Clusters, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	profiles, err := loadClusterProfiles(configFile(), names)
+	if err != nil {
+		fmt.Fprintln(rootCmd.ErrOrStderr(), err)
+		return command.ExitUsage
+	}
+
+	outputs := make([]string, len(names))
+	codes := make([]int, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, p clusterProfile) {
+			defer wg.Done()
+			outputs[i], codes[i] = executeOnCluster(args, p)
+		}(i, profiles[name])
+	}
+	wg.Wait()
+	// The exit code is the one of the first cluster which fails.
+	code := command.ExitOK
+	for _, c := range codes {
+		if c != command.ExitOK {
+			code = c
+			break
+		}
+	}
+
+	if commandFlags.Output != command.OutputJSON {
+		for i, name := range names {
+			rootCmd.Printf("[%s]\n%s\n", name, strings.TrimRight(outputs[i], "\n"))
+		}
+		return code
+	}
+	results := make(map[string]json.RawMessage, len(names))
+	for i, name := range names {
+		output := bytes.TrimSpace([]byte(outputs[i]))
+		if !json.Valid(output) {
+			// The messages like errors are kept as strings.
+			output, _ = json.Marshal(string(output))
+		}
+		results[name] = output
+	}
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		fmt.Fprintln(rootCmd.ErrOrStderr(), err)
+		return command.ExitFailure
+	}
+	rootCmd.Println(string(data))
+	return code
+}
+
+// executeOnCluster executes the command with a new root command, so that the
+// flags are not shared with the other clusters. It returns the output and the
+// exit code of the command.
+func executeOnCluster(args []string, p clusterProfile) (string, int) {
+	client, err := command.NewHTTPClient(p.CAPath, p.CertPath, p.KeyPath)
+	if err != nil {
+		return err.Error(), command.ExitUsage
+	}
+	var flags CommandFlags
+	rootCmd := newRootCmd(&flags)
+	var buf bytes.Buffer
+	rootCmd.SetOutput(&buf)
+	// The address in the profile overrides the one in the args.
+	rootCmd.SetArgs(append(append([]string(nil), args...), "--pd", p.URL))
+	code := command.PrintError(rootCmd, command.Execute(command.WithReadOnlyClient(context.Background(), client), rootCmd))
+	return buf.String(), code
+}
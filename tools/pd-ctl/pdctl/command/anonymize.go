@@ -0,0 +1,317 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/spf13/cobra"
+)
+
+// The prefixes of the anonymized values, which tell what the values are.
+const (
+	anonymizedKey     = "key-"
+	anonymizedAddress = "addr-"
+	anonymizedLabel   = "label-"
+	anonymizedPath    = "path-"
+	anonymizedName    = "name-"
+	anonymizedRule    = "rule-"
+)
+
+// anonymizedPattern matches the anonymized values in the text to de-anonymize.
+var anonymizedPattern = regexp.MustCompile(`\b(key|addr|label|path|name|rule)-[0-9a-f]{16}\b`)
+
+// anonymizedFields are the fields of which the string values are anonymized
+// with the prefixes, the label values are recognized by the objects with a
+// key and a value or values instead. The numeric IDs are kept, only the rule
+// IDs which are strings are anonymized.
+var anonymizedFields = map[string]string{
+	"start_key":             anonymizedKey,
+	"end_key":               anonymizedKey,
+	"address":               anonymizedAddress,
+	"status_address":        anonymizedAddress,
+	"peer_address":          anonymizedAddress,
+	"client_urls":           anonymizedAddress,
+	"peer_urls":             anonymizedAddress,
+	"client-urls":           anonymizedAddress,
+	"peer-urls":             anonymizedAddress,
+	"advertise-client-urls": anonymizedAddress,
+	"advertise-peer-urls":   anonymizedAddress,
+	"initial-cluster":       anonymizedAddress,
+	"join":                  anonymizedAddress,
+	"dashboard-address":     anonymizedAddress,
+	"deploy_path":           anonymizedPath,
+	"data-dir":              anonymizedPath,
+	"filename":              anonymizedPath,
+	"cacert-path":           anonymizedPath,
+	"cert-path":             anonymizedPath,
+	"key-path":              anonymizedPath,
+	"name":                  anonymizedName,
+	"job":                   anonymizedName,
+	"id":                    anonymizedRule,
+	"group_id":              anonymizedRule,
+}
+
+// anonymizeMapping is the content of the mapping file. The secret makes the
+// anonymized values stable across the runs with the same file, and the values
+// are mapped back to the original ones to de-anonymize the answers.
+type anonymizeMapping struct {
+	Secret string            `json:"secret"`
+	Values map[string]string `json:"values"`
+}
+
+// anonymizer replaces the region keys, the addresses, the paths, the names,
+// the rule IDs and the label values in the responses with their HMACs, so the
+// diagnostics can be shared without leaking the schema or the hostnames. The
+// same value is always replaced with the same one, so the topology is kept,
+// like the adjacent regions and the stores with the same labels, but the order
+// of the keys is lost.
+type anonymizer struct {
+	file    string
+	secret  []byte
+	mapping anonymizeMapping
+}
+
+// addAnonymizeFlags adds the flags to anonymize the output of the command.
+func addAnonymizeFlags(c *cobra.Command) {
+	c.Flags().Bool("anonymize", false, "replace the region keys, the addresses, the paths, the names, the rule IDs and the label values with stable hashes, so the output can be shared externally")
+	addAnonymizeMapFlag(c)
+}
+
+func addAnonymizeMapFlag(c *cobra.Command) {
+	c.Flags().String("anonymize-map", "", "the local file mapping the anonymized values to the original ones, keep it private")
+}
+
+// getAnonymizeMapFile returns the mapping file, it must be specified so that
+// the original values are not written to an unexpected place.
+func getAnonymizeMapFile(cmd *cobra.Command) (string, error) {
+	file, _ := cmd.Flags().GetString("anonymize-map")
+	if file == "" {
+		return "", errors.New("--anonymize-map should be specified")
+	}
+	return file, nil
+}
+
+// newAnonymizer returns the anonymizer if `--anonymize` is set, otherwise it
+// returns nil. The secret is loaded from the mapping file if it exists, so
+// the same values are anonymized the same as the previous runs.
+func newAnonymizer(cmd *cobra.Command) (*anonymizer, error) {
+	if enable, _ := cmd.Flags().GetBool("anonymize"); !enable {
+		return nil, nil
+	}
+	file, err := getAnonymizeMapFile(cmd)
+	if err != nil {
+		return nil, err
+	}
+	a, err := loadAnonymizer(file)
+	if err != nil {
+		return nil, err
+	}
+	if a.secret == nil {
+		a.secret = make([]byte, 16)
+		if _, err := rand.Read(a.secret); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		a.mapping.Secret = hex.EncodeToString(a.secret)
+	}
+	return a, nil
+}
+
+// loadAnonymizer loads the mapping file, the secret is nil if the file does
+// not exist.
+func loadAnonymizer(file string) (*anonymizer, error) {
+	a := &anonymizer{file: file, mapping: anonymizeMapping{Values: make(map[string]string)}}
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := json.Unmarshal(data, &a.mapping); err != nil {
+		return nil, errors.Annotatef(err, "invalid mapping file %s", file)
+	}
+	if a.secret, err = hex.DecodeString(a.mapping.Secret); err != nil || len(a.secret) == 0 {
+		return nil, errors.Errorf("invalid secret in mapping file %s", file)
+	}
+	if a.mapping.Values == nil {
+		a.mapping.Values = make(map[string]string)
+	}
+	return a, nil
+}
+
+// save writes the mapping file, it is only readable by the owner because the
+// original values are in it.
+func (a *anonymizer) save() error {
+	data, err := json.MarshalIndent(a.mapping, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(ioutil.WriteFile(a.file, data, 0600))
+}
+
+func (a *anonymizer) hash(prefix, value string) string {
+	if value == "" {
+		return value
+	}
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(prefix + value))
+	anonymized := prefix + hex.EncodeToString(mac.Sum(nil))[:16]
+	a.mapping.Values[anonymized] = value
+	return anonymized
+}
+
+// anonymize returns the anonymized JSON. It fails if the data is not JSON,
+// because the values in it can not be recognized.
+func (a *anonymizer) anonymize(data []byte) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, errors.Annotate(err, "only JSON can be anonymized")
+	}
+	out, err := json.MarshalIndent(a.anonymizeValue(v), "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return out, nil
+}
+
+func (a *anonymizer) anonymizeValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if _, ok := v["key"].(string); ok {
+			if value, ok := v["value"].(string); ok {
+				v["value"] = a.hash(anonymizedLabel, value)
+			}
+			if values, ok := v["values"].([]interface{}); ok {
+				v["values"] = a.anonymizeStrings(anonymizedLabel, values)
+			}
+		}
+		for field, value := range v {
+			// The config items with sources are keyed by their paths, like
+			// "log.file.filename", and their values are in the objects.
+			if prefix, ok := anonymizedFields[field[strings.LastIndex(field, ".")+1:]]; ok {
+				if anonymized, ok := a.anonymizeField(prefix, value); ok {
+					v[field] = anonymized
+					continue
+				}
+				if item, ok := value.(map[string]interface{}); ok && item["source"] != nil {
+					for _, name := range []string{"value", "default"} {
+						if anonymized, ok := a.anonymizeField(prefix, item[name]); ok {
+							item[name] = anonymized
+						}
+					}
+					continue
+				}
+			}
+			v[field] = a.anonymizeValue(value)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = a.anonymizeValue(v[i])
+		}
+	}
+	return v
+}
+
+// anonymizeField anonymizes the value of an anonymized field, it returns false
+// if the value is neither a string nor a list.
+func (a *anonymizer) anonymizeField(prefix string, value interface{}) (interface{}, bool) {
+	switch value := value.(type) {
+	case string:
+		return a.hash(prefix, value), true
+	case []interface{}:
+		return a.anonymizeStrings(prefix, value), true
+	}
+	return nil, false
+}
+
+func (a *anonymizer) anonymizeStrings(prefix string, values []interface{}) []interface{} {
+	for i, value := range values {
+		if s, ok := value.(string); ok {
+			values[i] = a.hash(prefix, s)
+		}
+	}
+	return values
+}
+
+// deanonymize replaces the anonymized values in the text with the original
+// ones, the unknown values are kept.
+func (a *anonymizer) deanonymize(text []byte) []byte {
+	return anonymizedPattern.ReplaceAllFunc(text, func(anonymized []byte) []byte {
+		if value, ok := a.mapping.Values[string(anonymized)]; ok {
+			return []byte(value)
+		}
+		return anonymized
+	})
+}
+
+// anonymizeWriter anonymizes the JSON per line, it is used for the streamed
+// output like the regions in NDJSON.
+type anonymizeWriter struct {
+	a   *anonymizer
+	w   io.Writer
+	buf []byte
+}
+
+func (w *anonymizeWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if err := w.writeLine(w.buf[:i]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Flush writes the last line which does not end with a newline.
+func (w *anonymizeWriter) Flush() error {
+	if len(bytes.TrimSpace(w.buf)) == 0 {
+		return nil
+	}
+	err := w.writeLine(w.buf)
+	w.buf = nil
+	return err
+}
+
+func (w *anonymizeWriter) writeLine(line []byte) error {
+	d := json.NewDecoder(bytes.NewReader(line))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return errors.Annotate(err, "only JSON can be anonymized")
+	}
+	out, err := json.Marshal(w.a.anonymizeValue(v))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = w.w.Write(append(out, '\n'))
+	return err
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"net/http"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	clusterConsistencyPrefix        = "pd/api/v1/cluster/consistency"
+	clusterConsistencyCleanupPrefix = "pd/api/v1/cluster/consistency/cleanup"
+)
+
+// NewCheckCommand returns a check subcommand of rootCmd.
+func NewCheckCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "check <subcommand>",
+		Short: "check the metadata of the cluster",
+	}
+	consistency := &cobra.Command{
+		Use:   "cluster-consistency [--cleanup]",
+		Short: "cross-check the persisted stores, the stores sending heartbeats, the peers of the regions and the PD members, --cleanup persists the unpersisted stores again and removes the orphaned store limits and member metadata",
+		RunE:  checkClusterConsistencyCommandFunc,
+	}
+	consistency.Flags().Bool("cleanup", false, "clean up the inconsistency which can be fixed safely")
+	c.AddCommand(consistency)
+	return c
+}
+
+func checkClusterConsistencyCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	if cleanup, _ := cmd.Flags().GetBool("cleanup"); cleanup {
+		r, err := doRequest(cmd, clusterConsistencyCleanupPrefix, http.MethodPost)
+		if err != nil {
+			return failf("Failed to clean up the inconsistency: %s\n", err)
+		}
+		return printResponse(cmd, r)
+	}
+	r, err := doRequest(cmd, clusterConsistencyPrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to check the cluster consistency: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
@@ -14,22 +14,27 @@
 package command
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 const clusterPrefix = "pd/api/v1/cluster"
 const clusterStatusPrefix = "pd/api/v1/cluster/status"
+const importModePrefix = "pd/api/v1/cluster/import-mode"
 
 // NewClusterCommand return a cluster subcommand of rootCmd
 func NewClusterCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "cluster",
 		Short: "show the cluster information",
-		Run:   showClusterCommandFunc,
+		RunE:  showClusterCommandFunc,
 	}
 	cmd.AddCommand(NewClusterStatusCommand())
+	cmd.AddCommand(NewClusterHealthCommand())
+	cmd.AddCommand(NewImportModeCommand())
 	return cmd
 }
 
@@ -38,25 +43,72 @@ func NewClusterStatusCommand() *cobra.Command {
 	r := &cobra.Command{
 		Use:   "status",
 		Short: "show the cluster status",
-		Run:   showClusterStatusCommandFunc,
+		RunE:  showClusterStatusCommandFunc,
 	}
 	return r
 }
 
-func showClusterCommandFunc(cmd *cobra.Command, args []string) {
+// NewImportModeCommand return a import-mode subcommand of clusterCmd
+func NewImportModeCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "import-mode [enable|disable]",
+		Short: "show the status of the import mode",
+		RunE:  showImportModeCommandFunc,
+	}
+	enable := &cobra.Command{
+		Use:   "enable",
+		Short: "apply the configuration optimized for importing data until the ttl expires",
+		RunE:  enableImportModeCommandFunc,
+	}
+	enable.Flags().Duration("ttl", 6*time.Hour, "the duration of the import mode")
+	r.AddCommand(enable)
+	r.AddCommand(&cobra.Command{
+		Use:   "disable",
+		Short: "revert the configuration of the import mode",
+		RunE:  disableImportModeCommandFunc,
+	})
+	return r
+}
+
+func showClusterCommandFunc(cmd *cobra.Command, args []string) error {
 	r, err := doRequest(cmd, clusterPrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get the cluster information: %s\n", err)
-		return
+		return failf("Failed to get the cluster information: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
-func showClusterStatusCommandFunc(cmd *cobra.Command, args []string) {
+func showClusterStatusCommandFunc(cmd *cobra.Command, args []string) error {
 	r, err := doRequest(cmd, clusterStatusPrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get the cluster status: %s\n", err)
-		return
+		return failf("Failed to get the cluster status: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+func showImportModeCommandFunc(cmd *cobra.Command, args []string) error {
+	r, err := doRequest(cmd, importModePrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get the import mode: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+func enableImportModeCommandFunc(cmd *cobra.Command, args []string) error {
+	ttl, _ := cmd.Flags().GetDuration("ttl")
+	prefix := fmt.Sprintf("%s?ttl=%s", importModePrefix, ttl)
+	r, err := doRequest(cmd, prefix, http.MethodPost)
+	if err != nil {
+		return failf("Failed to enable the import mode: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+func disableImportModeCommandFunc(cmd *cobra.Command, args []string) error {
+	_, err := doRequest(cmd, importModePrefix, http.MethodDelete)
+	if err != nil {
+		return failf("Failed to disable the import mode: %s\n", err)
 	}
-	cmd.Println(r)
+	cmd.Println("Success!")
+	return nil
 }
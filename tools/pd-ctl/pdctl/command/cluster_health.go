@@ -0,0 +1,188 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// The results of the cluster health checks, the exit code of pd-ctl depends on
+// the worst result of the checks.
+const (
+	healthPass = iota
+	healthWarn
+	healthFail
+)
+
+var healthResultNames = [...]string{"PASS", "WARN", "FAIL"}
+
+// healthCheck is the result of checking a part of the cluster.
+type healthCheck struct {
+	name   string
+	result int
+	detail string
+}
+
+// NewClusterHealthCommand returns a health subcommand of clusterCmd.
+func NewClusterHealthCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "health [--max-pending-operators=<count>]",
+		Short: "check the members, stores, region replicas and operators, the exit code is 0 for PASS, 3 for WARN and 2 for FAIL",
+		RunE:  showClusterHealthCommandFunc,
+	}
+	r.Flags().Int("max-pending-operators", 100, "warn if the number of the pending operators exceeds it")
+	return r
+}
+
+func showClusterHealthCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	maxOperators, _ := cmd.Flags().GetInt("max-pending-operators")
+	checks := []*healthCheck{
+		checkMembersHealth(cmd),
+		checkStoresHealth(cmd),
+		checkRegionsHealth(cmd),
+		checkOperatorsHealth(cmd, maxOperators),
+	}
+	summary := healthPass
+	for _, c := range checks {
+		cmd.Printf("%-10s %s %s\n", c.name+":", healthResultNames[c.result], c.detail)
+		if c.result > summary {
+			summary = c.result
+		}
+	}
+	cmd.Printf("%-10s %s\n", "summary:", healthResultNames[summary])
+	switch summary {
+	case healthWarn:
+		return checkError(ExitCheckWarning)
+	case healthFail:
+		return checkError(ExitCheckFailed)
+	}
+	return nil
+}
+
+// checkMembersHealth fails if the unhealthy PD members break the quorum, and
+// warns if any of them is unhealthy.
+func checkMembersHealth(cmd *cobra.Command) *healthCheck {
+	c := &healthCheck{name: "members"}
+	var members []struct {
+		Name   string `json:"name"`
+		Health bool   `json:"health"`
+	}
+	if err := getHealthJSON(cmd, healthPrefix, &members); err != nil {
+		c.result, c.detail = healthFail, err.Error()
+		return c
+	}
+	var unhealthy []string
+	for _, m := range members {
+		if !m.Health {
+			unhealthy = append(unhealthy, m.Name)
+		}
+	}
+	c.detail = fmt.Sprintf("%d/%d healthy", len(members)-len(unhealthy), len(members))
+	switch {
+	case len(unhealthy) == 0:
+	case len(unhealthy) >= (len(members)+1)/2:
+		c.result = healthFail
+	default:
+		c.result = healthWarn
+	}
+	if len(unhealthy) > 0 {
+		c.detail += ", unhealthy: " + strings.Join(unhealthy, ",")
+	}
+	return c
+}
+
+// checkStoresHealth fails if any store is down, and warns if any store is
+// disconnected or offline.
+func checkStoresHealth(cmd *cobra.Command) *healthCheck {
+	c := &healthCheck{name: "stores"}
+	var stores struct {
+		Stores []struct {
+			Store struct {
+				StateName string `json:"state_name"`
+			} `json:"store"`
+		} `json:"stores"`
+	}
+	if err := getHealthJSON(cmd, storesPrefix, &stores); err != nil {
+		c.result, c.detail = healthFail, err.Error()
+		return c
+	}
+	states := make(map[string]int)
+	for _, s := range stores.Stores {
+		states[s.Store.StateName]++
+	}
+	c.detail = fmt.Sprintf("%d up, %d disconnected, %d down, %d offline", states["Up"], states["Disconnected"], states["Down"], states["Offline"])
+	switch {
+	case states["Down"] > 0:
+		c.result = healthFail
+	case states["Disconnected"] > 0 || states["Offline"] > 0:
+		c.result = healthWarn
+	}
+	return c
+}
+
+// checkRegionsHealth fails if any region has down peers, and warns if any
+// region misses peers.
+func checkRegionsHealth(cmd *cobra.Command) *healthCheck {
+	c := &healthCheck{name: "regions"}
+	var missPeer, downPeer struct {
+		Count int `json:"count"`
+	}
+	if err := getHealthJSON(cmd, regionsCheckPrefix+"/miss-peer", &missPeer); err != nil {
+		c.result, c.detail = healthFail, err.Error()
+		return c
+	}
+	if err := getHealthJSON(cmd, regionsCheckPrefix+"/down-peer", &downPeer); err != nil {
+		c.result, c.detail = healthFail, err.Error()
+		return c
+	}
+	c.detail = fmt.Sprintf("%d miss-peer, %d down-peer", missPeer.Count, downPeer.Count)
+	switch {
+	case downPeer.Count > 0:
+		c.result = healthFail
+	case missPeer.Count > 0:
+		c.result = healthWarn
+	}
+	return c
+}
+
+// checkOperatorsHealth warns if the pending operators exceed the max count.
+func checkOperatorsHealth(cmd *cobra.Command, maxOperators int) *healthCheck {
+	c := &healthCheck{name: "operators"}
+	var operators []json.RawMessage
+	if err := getHealthJSON(cmd, operatorsPrefix, &operators); err != nil {
+		c.result, c.detail = healthFail, err.Error()
+		return c
+	}
+	c.detail = fmt.Sprintf("%d pending", len(operators))
+	if len(operators) > maxOperators {
+		c.result = healthWarn
+	}
+	return c
+}
+
+func getHealthJSON(cmd *cobra.Command, prefix string, v interface{}) error {
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(r), v)
+}
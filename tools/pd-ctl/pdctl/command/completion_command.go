@@ -15,19 +15,24 @@ package command
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
-	"os"
+	"net/http"
 
 	"github.com/spf13/cobra"
 )
 
 const (
 	completionLongDesc = `
-Output shell completion code for the specified shell (bash or zsh).
+Output shell completion code for the specified shell (bash, zsh, fish or powershell).
 The shell code must be evaluated to provide interactive
 completion of pd-ctl commands.  This can be done by sourcing it from
 the .bash_profile.
 
+The store IDs and scheduler names are completed by querying the cluster,
+the address is taken from the -u flag on the command line being completed.
+
 Note for zsh users: [1] zsh completions are only supported in versions of zsh >= 5.2
 `
 
@@ -58,13 +63,23 @@ Note for zsh users: [1] zsh completions are only supported in versions of zsh >=
 	    source <(pd-ctl completion zsh)
 	# Set the pd-ctl completion code for zsh[1] to autoload on startup
 	    pd-ctl completion zsh > "${fpath[1]}/_pd-ctl"
+
+	# Load the pd-ctl completion code for fish into the current shell
+	    pd-ctl completion fish | source
+	# Set the pd-ctl completion code for fish to autoload on startup
+	    pd-ctl completion fish > ~/.config/fish/completions/pd-ctl.fish
+
+	# Load the pd-ctl completion code for powershell into the current shell
+	    pd-ctl completion powershell | Out-String | Invoke-Expression
 `
 )
 
 var (
 	completionShells = map[string]func(out io.Writer, cmd *cobra.Command) error{
-		"bash": runCompletionBash,
-		"zsh":  runCompletionZsh,
+		"bash":       runCompletionBash,
+		"zsh":        runCompletionZsh,
+		"fish":       runCompletionFish,
+		"powershell": runCompletionPowerShell,
 	}
 )
 
@@ -78,10 +93,10 @@ func NewCompletionCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:                   "completion SHELL",
 		DisableFlagsInUseLine: true,
-		Short:                 "Output shell completion code for the specified shell (bash)",
+		Short:                 "Output shell completion code for the specified shell (bash, zsh, fish or powershell)",
 		Long:                  completionLongDesc,
 		Example:               completionExample,
-		Run:                   RunCompletion,
+		RunE:                  RunCompletion,
 		ValidArgs:             shells,
 	}
 
@@ -89,31 +104,36 @@ func NewCompletionCommand() *cobra.Command {
 }
 
 // RunCompletion wrapped the bash and zsh completion scripts
-func RunCompletion(cmd *cobra.Command, args []string) {
+func RunCompletion(cmd *cobra.Command, args []string) error {
 	if len(args) == 0 {
-		cmd.Println("Shell not specified.")
-		cmd.Usage()
-		return
+		return usageErrorln("Shell not specified.\n" + cmd.UsageString())
 	}
 	if len(args) > 1 {
-		cmd.Println("Too many arguments. Expected only the shell type.")
-		cmd.Usage()
-		return
+		return usageErrorln("Too many arguments. Expected only the shell type.\n" + cmd.UsageString())
 	}
 	run, found := completionShells[args[0]]
 	if !found {
-		cmd.Printf("Unsupported shell type %q.\n", args[0])
-		cmd.Usage()
-		return
+		return usageErrorf("Unsupported shell type %q.\n%s", args[0], cmd.UsageString())
 	}
 
-	run(os.Stdout, cmd.Root())
+	if err := run(cmd.OutOrStdout(), cmd.Root()); err != nil {
+		return failf("Failed to generate the completion code: %s\n", err)
+	}
+	return nil
 }
 
 func runCompletionBash(out io.Writer, cmd *cobra.Command) error {
 	return cmd.GenBashCompletion(out)
 }
 
+func runCompletionFish(out io.Writer, cmd *cobra.Command) error {
+	return cmd.GenFishCompletion(out, true)
+}
+
+func runCompletionPowerShell(out io.Writer, cmd *cobra.Command) error {
+	return cmd.GenPowerShellCompletion(out)
+}
+
 func runCompletionZsh(out io.Writer, cmd *cobra.Command) error {
 	zshHead := "#compdef pd-ctl\n"
 
@@ -277,3 +297,48 @@ _complete pd-ctl 2>/dev/null
 	out.Write([]byte(zshTail))
 	return nil
 }
+
+// completeStoreIDs completes the first argument with the IDs of the stores in
+// the cluster, the addresses are shown as the descriptions.
+func completeStoreIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	r, err := doRequest(cmd, storesPrefix, http.MethodGet)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var stores struct {
+		Stores []struct {
+			Store struct {
+				ID      uint64 `json:"id"`
+				Address string `json:"address"`
+			} `json:"store"`
+		} `json:"stores"`
+	}
+	if err := json.Unmarshal([]byte(r), &stores); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	ids := make([]string, 0, len(stores.Stores))
+	for _, s := range stores.Stores {
+		ids = append(ids, fmt.Sprintf("%d\t%s", s.Store.ID, s.Store.Address))
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSchedulerNames completes the first argument with the names of the
+// schedulers in the cluster.
+func completeSchedulerNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	r, err := doRequest(cmd, schedulersPrefix, http.MethodGet)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var names []string
+	if err := json.Unmarshal([]byte(r), &names); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -15,6 +15,7 @@ package command
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
@@ -24,7 +25,9 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/pingcap/errors"
 	"github.com/spf13/cobra"
+	"github.com/tikv/pd/pkg/codec"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/schedule/placement"
 )
@@ -41,7 +44,11 @@ var (
 	ruleGroupPrefix       = "pd/api/v1/config/rule_group"
 	ruleGroupsPrefix      = "pd/api/v1/config/rule_groups"
 	replicationModePrefix = "pd/api/v1/config/replication-mode"
+	configSourcePrefix    = "pd/api/v1/config/source"
+	configDiffPrefix      = "pd/api/v1/config/diff"
 	ruleBundlePrefix      = "pd/api/v1/config/placement-rule"
+	antiAffinityPrefix    = "pd/api/v1/config/leader_anti_affinity"
+	antiAffinitiesPrefix  = "pd/api/v1/config/leader_anti_affinities"
 )
 
 // NewConfigCommand return a config subcommand of rootCmd
@@ -54,6 +61,7 @@ func NewConfigCommand() *cobra.Command {
 	conf.AddCommand(NewSetConfigCommand())
 	conf.AddCommand(NewDeleteConfigCommand())
 	conf.AddCommand(NewPlacementRulesCommand())
+	conf.AddCommand(NewRegionLabelCommand())
 	return conf
 }
 
@@ -62,7 +70,7 @@ func NewShowConfigCommand() *cobra.Command {
 	sc := &cobra.Command{
 		Use:   "show [replication|label-property|all]",
 		Short: "show replication and schedule config of PD",
-		Run:   showConfigCommandFunc,
+		RunE:  showConfigCommandFunc,
 	}
 	sc.AddCommand(NewShowAllConfigCommand())
 	sc.AddCommand(NewShowScheduleConfigCommand())
@@ -70,6 +78,8 @@ func NewShowConfigCommand() *cobra.Command {
 	sc.AddCommand(NewShowLabelPropertyCommand())
 	sc.AddCommand(NewShowClusterVersionCommand())
 	sc.AddCommand(newShowReplicationModeCommand())
+	sc.AddCommand(newShowConfigSourceCommand())
+	sc.Flags().Bool("diff", false, "only show the items which differ from the default values, with the source and the last time they are changed")
 	return sc
 }
 
@@ -78,7 +88,7 @@ func NewShowAllConfigCommand() *cobra.Command {
 	sc := &cobra.Command{
 		Use:   "all",
 		Short: "show all config of PD",
-		Run:   showAllConfigCommandFunc,
+		RunE:  showAllConfigCommandFunc,
 	}
 	return sc
 }
@@ -88,7 +98,7 @@ func NewShowScheduleConfigCommand() *cobra.Command {
 	sc := &cobra.Command{
 		Use:   "schedule",
 		Short: "show schedule config of PD",
-		Run:   showScheduleConfigCommandFunc,
+		RunE:  showScheduleConfigCommandFunc,
 	}
 	return sc
 }
@@ -98,7 +108,7 @@ func NewShowReplicationConfigCommand() *cobra.Command {
 	sc := &cobra.Command{
 		Use:   "replication",
 		Short: "show replication config of PD",
-		Run:   showReplicationConfigCommandFunc,
+		RunE:  showReplicationConfigCommandFunc,
 	}
 	return sc
 }
@@ -108,7 +118,7 @@ func NewShowLabelPropertyCommand() *cobra.Command {
 	sc := &cobra.Command{
 		Use:   "label-property",
 		Short: "show label property config",
-		Run:   showLabelPropertyConfigCommandFunc,
+		RunE:  showLabelPropertyConfigCommandFunc,
 	}
 	return sc
 }
@@ -118,7 +128,7 @@ func NewShowClusterVersionCommand() *cobra.Command {
 	sc := &cobra.Command{
 		Use:   "cluster-version",
 		Short: "show the cluster version",
-		Run:   showClusterVersionCommandFunc,
+		RunE:  showClusterVersionCommandFunc,
 	}
 	return sc
 }
@@ -127,20 +137,40 @@ func newShowReplicationModeCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "replication-mode",
 		Short: "show replication mode config",
-		Run:   showReplicationModeCommandFunc,
+		RunE:  showReplicationModeCommandFunc,
+	}
+}
+
+func newShowConfigSourceCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "source [<option>...]",
+		Short: "show the config with the source of each value, one of default, file, flag, persisted and ttl, and the last time it is changed",
+		RunE:  showConfigSourceCommandFunc,
 	}
 }
 
 // NewSetConfigCommand return a set subcommand of configCmd
 func NewSetConfigCommand() *cobra.Command {
 	sc := &cobra.Command{
-		Use:   "set <option> <value>, set label-property <type> <key> <value>, set cluster-version <version>",
+		Use:   "set <option> <value>, set <option>=<value> [<option>=<value>...], set label-property <type> <key> <value>, set cluster-version <version>",
 		Short: "set the option with value",
-		Run:   setConfigCommandFunc,
+		RunE:  setConfigCommandFunc,
 	}
 	sc.AddCommand(NewSetLabelPropertyCommand())
 	sc.AddCommand(NewSetClusterVersionCommand())
 	sc.AddCommand(newSetReplicationModeCommand())
+	sc.AddCommand(newSetStoreClassLimitCommand())
+	return sc
+}
+
+func newSetStoreClassLimitCommand() *cobra.Command {
+	sc := &cobra.Command{
+		Use:   "store-class-limit <key> <value> [--max-snapshot-count=<count>] [--max-pending-peer-count=<count>]",
+		Short: "set the max snapshot count and max pending peer count of the stores with the label, 0 means the global one is used",
+		RunE:  setStoreClassLimitCommandFunc,
+	}
+	sc.Flags().Uint64("max-snapshot-count", 0, "the max snapshot count of the stores with the label")
+	sc.Flags().Uint64("max-pending-peer-count", 0, "the max pending peer count of the stores with the label")
 	return sc
 }
 
@@ -149,7 +179,7 @@ func NewSetLabelPropertyCommand() *cobra.Command {
 	sc := &cobra.Command{
 		Use:   "label-property <type> <key> <value>",
 		Short: "set a label property config item",
-		Run:   setLabelPropertyConfigCommandFunc,
+		RunE:  setLabelPropertyConfigCommandFunc,
 	}
 	return sc
 }
@@ -159,7 +189,7 @@ func NewSetClusterVersionCommand() *cobra.Command {
 	sc := &cobra.Command{
 		Use:   "cluster-version <version>",
 		Short: "set cluster version",
-		Run:   setClusterVersionCommandFunc,
+		RunE:  setClusterVersionCommandFunc,
 	}
 	return sc
 }
@@ -168,17 +198,22 @@ func newSetReplicationModeCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "replication-mode <mode> [<key>, <value>]",
 		Short: "set replication mode config",
-		Run:   setReplicationModeCommandFunc,
+		RunE:  setReplicationModeCommandFunc,
 	}
 }
 
 // NewDeleteConfigCommand a set subcommand of cfgCmd
 func NewDeleteConfigCommand() *cobra.Command {
 	sc := &cobra.Command{
-		Use:   "delete label-property",
+		Use:   "delete label-property|store-class-limit",
 		Short: "delete the config option",
 	}
 	sc.AddCommand(NewDeleteLabelPropertyConfigCommand())
+	sc.AddCommand(&cobra.Command{
+		Use:   "store-class-limit <key> <value>",
+		Short: "delete the limits of the stores with the label",
+		RunE:  deleteStoreClassLimitCommandFunc,
+	})
 	return sc
 }
 
@@ -187,22 +222,27 @@ func NewDeleteLabelPropertyConfigCommand() *cobra.Command {
 	sc := &cobra.Command{
 		Use:   "label-property <type> <key> <value>",
 		Short: "delete a label property config item",
-		Run:   deleteLabelPropertyConfigCommandFunc,
+		RunE:  deleteLabelPropertyConfigCommandFunc,
 	}
 	return sc
 }
 
-func showConfigCommandFunc(cmd *cobra.Command, args []string) {
+func showConfigCommandFunc(cmd *cobra.Command, args []string) error {
+	if diff, _ := cmd.Flags().GetBool("diff"); diff {
+		r, err := doRequest(cmd, configDiffPrefix, http.MethodGet)
+		if err != nil {
+			return failf("Failed to get config diff: %s\n", err)
+		}
+		return printResponse(cmd, r)
+	}
 	allR, err := doRequest(cmd, configPrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get config: %s\n", err)
-		return
+		return failf("Failed to get config: %s\n", err)
 	}
 	allData := make(map[string]interface{})
 	err = json.Unmarshal([]byte(allR), &allData)
 	if err != nil {
-		cmd.Printf("Failed to unmarshal config: %s\n", err)
-		return
+		return failf("Failed to unmarshal config: %s\n", err)
 	}
 
 	data := make(map[string]interface{})
@@ -210,13 +250,11 @@ func showConfigCommandFunc(cmd *cobra.Command, args []string) {
 	scheduleConfig := make(map[string]interface{})
 	scheduleConfigData, err := json.Marshal(allData["schedule"])
 	if err != nil {
-		cmd.Printf("Failed to marshal schedule config: %s\n", err)
-		return
+		return failf("Failed to marshal schedule config: %s\n", err)
 	}
 	err = json.Unmarshal(scheduleConfigData, &scheduleConfig)
 	if err != nil {
-		cmd.Printf("Failed to unmarshal schedule config: %s\n", err)
-		return
+		return failf("Failed to unmarshal schedule config: %s\n", err)
 	}
 
 	delete(scheduleConfig, "schedulers-v2")
@@ -225,64 +263,87 @@ func showConfigCommandFunc(cmd *cobra.Command, args []string) {
 	data["schedule"] = scheduleConfig
 	r, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
-		cmd.Printf("Failed to marshal config: %s\n", err)
-		return
+		return failf("Failed to marshal config: %s\n", err)
 	}
 	cmd.Println(string(r))
+	return nil
 }
 
-func showScheduleConfigCommandFunc(cmd *cobra.Command, args []string) {
+func showScheduleConfigCommandFunc(cmd *cobra.Command, args []string) error {
 	r, err := doRequest(cmd, schedulePrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get config: %s\n", err)
-		return
+		return failf("Failed to get config: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
-func showReplicationConfigCommandFunc(cmd *cobra.Command, args []string) {
+func showReplicationConfigCommandFunc(cmd *cobra.Command, args []string) error {
 	r, err := doRequest(cmd, replicatePrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get config: %s\n", err)
-		return
+		return failf("Failed to get config: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
-func showLabelPropertyConfigCommandFunc(cmd *cobra.Command, args []string) {
+func showLabelPropertyConfigCommandFunc(cmd *cobra.Command, args []string) error {
 	r, err := doRequest(cmd, labelPropertyPrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get config: %s\n", err)
-		return
+		return failf("Failed to get config: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
-func showAllConfigCommandFunc(cmd *cobra.Command, args []string) {
+func showAllConfigCommandFunc(cmd *cobra.Command, args []string) error {
 	r, err := doRequest(cmd, configPrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get config: %s\n", err)
-		return
+		return failf("Failed to get config: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
-func showClusterVersionCommandFunc(cmd *cobra.Command, args []string) {
+func showClusterVersionCommandFunc(cmd *cobra.Command, args []string) error {
 	r, err := doRequest(cmd, clusterVersionPrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get cluster version: %s\n", err)
-		return
+		return failf("Failed to get cluster version: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
-func showReplicationModeCommandFunc(cmd *cobra.Command, args []string) {
+func showConfigSourceCommandFunc(cmd *cobra.Command, args []string) error {
+	r, err := doRequest(cmd, configSourcePrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get config source: %s\n", err)
+	}
+	if len(args) == 0 {
+		return printResponse(cmd, r)
+	}
+	var sources map[string]interface{}
+	if err := json.Unmarshal([]byte(r), &sources); err != nil {
+		return failf("Failed to unmarshal config source: %s\n", err)
+	}
+	// The option can be either the full key like schedule.max-merge-region-size
+	// or the last part of it.
+	matched := make(map[string]interface{})
+	for key, source := range sources {
+		for _, option := range args {
+			if key == option || strings.HasSuffix(key, "."+option) {
+				matched[key] = source
+			}
+		}
+	}
+	data, err := json.Marshal(matched)
+	if err != nil {
+		return failf("Failed to marshal config source: %s\n", err)
+	}
+	return printResponse(cmd, string(data))
+}
+
+func showReplicationModeCommandFunc(cmd *cobra.Command, args []string) error {
 	r, err := doRequest(cmd, replicationModePrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get replication mode config: %s\n", err)
-		return
+		return failf("Failed to get replication mode config: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
 func postConfigDataWithPath(cmd *cobra.Command, key, value, path string) error {
@@ -305,32 +366,61 @@ func postConfigDataWithPath(cmd *cobra.Command, key, value, path string) error {
 	return nil
 }
 
-func setConfigCommandFunc(cmd *cobra.Command, args []string) {
+func setConfigCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 && strings.Contains(args[0], "=") {
+		return setConfigItemsCommandFunc(cmd, args)
+	}
 	if len(args) != 2 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	opt, val := args[0], args[1]
 	err := postConfigDataWithPath(cmd, opt, val, configPrefix)
 	if err != nil {
-		cmd.Printf("Failed to set config: %s\n", err)
-		return
+		return failf("Failed to set config: %s\n", err)
 	}
 	cmd.Println("Success!")
+	return nil
+}
+
+// setConfigItemsCommandFunc sets several options in one request, the server
+// applies all of them or none of them, and prints the changed options.
+func setConfigItemsCommandFunc(cmd *cobra.Command, args []string) error {
+	data := make(map[string]interface{})
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return usageErrorln(cmd.UsageString())
+		}
+		val, err := strconv.ParseFloat(kv[1], 64)
+		if err != nil {
+			data[kv[0]] = kv[1]
+			continue
+		}
+		data[kv[0]] = val
+	}
+	reqData, err := json.Marshal(data)
+	if err != nil {
+		return failf("Failed to set config: %s\n", err)
+	}
+	r, err := doRequest(cmd, configPrefix+"?diff=true", http.MethodPost,
+		WithBody("application/json", bytes.NewBuffer(reqData)))
+	if err != nil {
+		return failf("Failed to set config: %s\n", err)
+	}
+	return printResponse(cmd, r)
 }
 
-func setLabelPropertyConfigCommandFunc(cmd *cobra.Command, args []string) {
-	postLabelProperty(cmd, "set", args)
+func setLabelPropertyConfigCommandFunc(cmd *cobra.Command, args []string) error {
+	return postLabelProperty(cmd, "set", args)
 }
 
-func deleteLabelPropertyConfigCommandFunc(cmd *cobra.Command, args []string) {
-	postLabelProperty(cmd, "delete", args)
+func deleteLabelPropertyConfigCommandFunc(cmd *cobra.Command, args []string) error {
+	return postLabelProperty(cmd, "delete", args)
 }
 
-func postLabelProperty(cmd *cobra.Command, action string, args []string) {
+func postLabelProperty(cmd *cobra.Command, action string, args []string) error {
 	if len(args) != 3 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	input := map[string]interface{}{
 		"type":        args[0],
@@ -339,39 +429,103 @@ func postLabelProperty(cmd *cobra.Command, action string, args []string) {
 		"label-value": args[2],
 	}
 	prefix := path.Join(labelPropertyPrefix)
-	postJSON(cmd, prefix, input)
+	return postJSON(cmd, prefix, input)
+}
+
+// storeClassLimit is the limits of the stores with the label in the schedule
+// config.
+type storeClassLimit struct {
+	Key                 string `json:"key"`
+	Value               string `json:"value"`
+	MaxSnapshotCount    uint64 `json:"max-snapshot-count"`
+	MaxPendingPeerCount uint64 `json:"max-pending-peer-count"`
+}
+
+func setStoreClassLimitCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return usageErrorln(cmd.UsageString())
+	}
+	snapshot, _ := cmd.Flags().GetUint64("max-snapshot-count")
+	pending, _ := cmd.Flags().GetUint64("max-pending-peer-count")
+	return updateStoreClassLimits(cmd, func(limits []storeClassLimit) []storeClassLimit {
+		limit := storeClassLimit{Key: args[0], Value: args[1], MaxSnapshotCount: snapshot, MaxPendingPeerCount: pending}
+		for i := range limits {
+			if strings.EqualFold(limits[i].Key, limit.Key) && limits[i].Value == limit.Value {
+				limits[i] = limit
+				return limits
+			}
+		}
+		return append(limits, limit)
+	})
+}
+
+func deleteStoreClassLimitCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return usageErrorln(cmd.UsageString())
+	}
+	return updateStoreClassLimits(cmd, func(limits []storeClassLimit) []storeClassLimit {
+		kept := limits[:0]
+		for _, limit := range limits {
+			if !strings.EqualFold(limit.Key, args[0]) || limit.Value != args[1] {
+				kept = append(kept, limit)
+			}
+		}
+		return kept
+	})
 }
 
-func setClusterVersionCommandFunc(cmd *cobra.Command, args []string) {
+// updateStoreClassLimits updates the store class limits in the schedule config,
+// the order of the classes is kept since the first matched one takes effect.
+func updateStoreClassLimits(cmd *cobra.Command, update func([]storeClassLimit) []storeClassLimit) error {
+	r, err := doRequest(cmd, schedulePrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get config: %s\n", err)
+	}
+	var schedule struct {
+		StoreClassLimits []storeClassLimit `json:"store-class-limits"`
+	}
+	if err := json.Unmarshal([]byte(r), &schedule); err != nil {
+		return failf("Failed to get config: %s\n", err)
+	}
+	limits := update(schedule.StoreClassLimits)
+	if limits == nil {
+		limits = []storeClassLimit{}
+	}
+	return postJSON(cmd, configPrefix, map[string]interface{}{"store-class-limits": limits})
+}
+
+func setClusterVersionCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	input := map[string]interface{}{
 		"cluster-version": args[0],
 	}
-	postJSON(cmd, clusterVersionPrefix, input)
+	return postJSON(cmd, clusterVersionPrefix, input)
 }
 
-func setReplicationModeCommandFunc(cmd *cobra.Command, args []string) {
+func setReplicationModeCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) == 1 {
-		postJSON(cmd, replicationModePrefix, map[string]interface{}{"replication-mode": args[0]})
+		if err := postJSON(cmd, replicationModePrefix, map[string]interface{}{"replication-mode": args[0]}); err != nil {
+			return err
+		}
 	} else if len(args) == 3 {
 		t := findFieldByJSONTag(reflect.TypeOf(config.ReplicationModeConfig{}), []string{args[0], args[1]})
 		if t != nil && t.Kind() != reflect.String {
 			// convert to number for numberic fields.
 			arg2, err := strconv.ParseInt(args[2], 10, 64)
 			if err != nil {
-				cmd.Printf("value %v cannot covert to number: %v", args[2], err)
-				return
+				return usageErrorf("value %v cannot covert to number: %v", args[2], err)
 			}
-			postJSON(cmd, replicationModePrefix, map[string]interface{}{args[0]: map[string]interface{}{args[1]: arg2}})
-			return
+			return postJSON(cmd, replicationModePrefix, map[string]interface{}{args[0]: map[string]interface{}{args[1]: arg2}})
+		}
+		if err := postJSON(cmd, replicationModePrefix, map[string]interface{}{args[0]: map[string]string{args[1]: args[2]}}); err != nil {
+			return err
 		}
-		postJSON(cmd, replicationModePrefix, map[string]interface{}{args[0]: map[string]string{args[1]: args[2]}})
 	} else {
-		cmd.Println(cmd.UsageString())
+		return usageErrorln(cmd.UsageString())
 	}
+	return nil
 }
 
 func findFieldByJSONTag(t reflect.Type, tags []string) reflect.Type {
@@ -402,17 +556,17 @@ func NewPlacementRulesCommand() *cobra.Command {
 	enable := &cobra.Command{
 		Use:   "enable",
 		Short: "enable placement rules",
-		Run:   enablePlacementRulesFunc,
+		RunE:  enablePlacementRulesFunc,
 	}
 	disable := &cobra.Command{
 		Use:   "disable",
 		Short: "disable placement rules",
-		Run:   disablePlacementRulesFunc,
+		RunE:  disablePlacementRulesFunc,
 	}
 	show := &cobra.Command{
 		Use:   "show",
 		Short: "show placement rules",
-		Run:   getPlacementRulesFunc,
+		RunE:  getPlacementRulesFunc,
 	}
 	show.Flags().String("group", "", "group id")
 	show.Flags().String("id", "", "rule id")
@@ -420,7 +574,7 @@ func NewPlacementRulesCommand() *cobra.Command {
 	load := &cobra.Command{
 		Use:   "load",
 		Short: "load placement rules to a file",
-		Run:   getPlacementRulesFunc,
+		RunE:  getPlacementRulesFunc,
 	}
 	load.Flags().String("group", "", "group id")
 	load.Flags().String("id", "", "rule id")
@@ -429,9 +583,31 @@ func NewPlacementRulesCommand() *cobra.Command {
 	save := &cobra.Command{
 		Use:   "save",
 		Short: "save rules from file",
-		Run:   putPlacementRulesFunc,
+		RunE:  putPlacementRulesFunc,
 	}
 	save.Flags().String("in", "rules.json", "the filename contains rules")
+	set := &cobra.Command{
+		Use:   "set <group_id> <id> --role=<role> --count=<count> [--constraints=<constraint>,...] [--location-labels=<label>,...]",
+		Short: "add or update a placement rule",
+		Long: "add or update a placement rule, e.g. `set pd zone-a --role=voter --count=3 --constraints=zone=a` and " +
+			"`set pd zone-b --role=learner --count=1 --constraints=zone=b`. A constraint is one of " +
+			"`key=v1|v2` (in), `key!=v1|v2` (notIn), `key` (exists) and `!key` (notExists)",
+		RunE: setPlacementRuleFunc,
+	}
+	set.Flags().String("role", "voter", "the role of the peers, one of voter|leader|follower|learner")
+	set.Flags().Int("count", 0, "the count of the peers")
+	set.Flags().StringSlice("constraints", nil, "the label constraints of the stores to place the peers")
+	set.Flags().StringSlice("location-labels", nil, "the labels to isolate the peers physically")
+	set.Flags().String("isolation-level", "", "the label level which the peers must be isolated at")
+	set.Flags().String("start-key", "", "the start key of the rule in hex")
+	set.Flags().String("end-key", "", "the end key of the rule in hex")
+	set.Flags().Int("index", 0, "the apply order of the rule in the group")
+	set.Flags().Bool("override", false, "disable the rules with less indexes")
+	del := &cobra.Command{
+		Use:   "delete <group_id> <id>",
+		Short: "delete a placement rule",
+		RunE:  deletePlacementRuleFunc,
+	}
 	ruleGroup := &cobra.Command{
 		Use:   "rule-group",
 		Short: "rule group configurations",
@@ -439,17 +615,17 @@ func NewPlacementRulesCommand() *cobra.Command {
 	ruleGroupShow := &cobra.Command{
 		Use:   "show [id]",
 		Short: "show rule group configuration(s)",
-		Run:   showRuleGroupFunc,
+		RunE:  showRuleGroupFunc,
 	}
 	ruleGroupSet := &cobra.Command{
 		Use:   "set <id> <index> <override>",
 		Short: "update rule group configuration",
-		Run:   updateRuleGroupFunc,
+		RunE:  updateRuleGroupFunc,
 	}
 	ruleGroupDelete := &cobra.Command{
 		Use:   "delete <id>",
 		Short: "delete rule group configuration",
-		Run:   deleteRuleGroupFunc,
+		RunE:  deleteRuleGroupFunc,
 	}
 	ruleGroup.AddCommand(ruleGroupShow, ruleGroupSet, ruleGroupDelete)
 	ruleBundle := &cobra.Command{
@@ -459,58 +635,79 @@ func NewPlacementRulesCommand() *cobra.Command {
 	ruleBundleGet := &cobra.Command{
 		Use:   "get <id>",
 		Short: "get rule group config and its rules by group id",
-		Run:   getRuleBundle,
+		RunE:  getRuleBundle,
 	}
 	ruleBundleGet.Flags().String("out", "", "the output file")
 	ruleBundleSet := &cobra.Command{
 		Use:   "set",
 		Short: "set rule group config and its rules from file",
-		Run:   setRuleBundle,
+		RunE:  setRuleBundle,
 	}
 	ruleBundleSet.Flags().String("in", "group.json", "the file contains one group config and its rules")
 	ruleBundleDelete := &cobra.Command{
 		Use:   "delete <id>",
 		Short: "delete rule group config and its rules by group id",
-		Run:   delRuleBundle,
+		RunE:  delRuleBundle,
 	}
 	ruleBundleDelete.Flags().Bool("regexp", false, "match group id by regular expression")
 	ruleBundleLoad := &cobra.Command{
 		Use:   "load",
 		Short: "load all group configs and rules to file",
-		Run:   loadRuleBundle,
+		RunE:  loadRuleBundle,
 	}
 	ruleBundleLoad.Flags().String("out", "rules.json", "the output file")
 	ruleBundleSave := &cobra.Command{
 		Use:   "save",
 		Short: "save all group configs and rules from file",
-		Run:   saveRuleBundle,
+		RunE:  saveRuleBundle,
 	}
 	ruleBundleSave.Flags().String("in", "rules.json", "the file contains all group configs and all rules")
 	ruleBundleSave.Flags().Bool("partial", false, "do not drop all old configurations, partial update")
 	ruleBundle.AddCommand(ruleBundleGet, ruleBundleSet, ruleBundleDelete, ruleBundleLoad, ruleBundleSave)
-	c.AddCommand(enable, disable, show, load, save, ruleGroup, ruleBundle)
+	antiAffinity := &cobra.Command{
+		Use:   "leader-anti-affinity",
+		Short: "leader anti-affinity configurations, the leaders of the regions in the two ranges do not share stores",
+	}
+	antiAffinityShow := &cobra.Command{
+		Use:   "show [id]",
+		Short: "show leader anti-affinity configuration(s)",
+		RunE:  showLeaderAntiAffinityFunc,
+	}
+	antiAffinitySet := &cobra.Command{
+		Use:   "set <id> (<start_key> <end_key> <start_key> <end_key> | --tables <table_id>,<table_id>)",
+		Short: "update leader anti-affinity configuration, the keys are in hex format",
+		RunE:  updateLeaderAntiAffinityFunc,
+	}
+	antiAffinitySet.Flags().String("tables", "", "set the ranges by the IDs of two tables instead of the keys")
+	antiAffinityDelete := &cobra.Command{
+		Use:   "delete <id>",
+		Short: "delete leader anti-affinity configuration",
+		RunE:  deleteLeaderAntiAffinityFunc,
+	}
+	antiAffinity.AddCommand(antiAffinityShow, antiAffinitySet, antiAffinityDelete)
+	c.AddCommand(enable, disable, show, load, save, set, del, ruleGroup, ruleBundle, antiAffinity)
 	return c
 }
 
-func enablePlacementRulesFunc(cmd *cobra.Command, args []string) {
+func enablePlacementRulesFunc(cmd *cobra.Command, args []string) error {
 	err := postConfigDataWithPath(cmd, "enable-placement-rules", "true", configPrefix)
 	if err != nil {
-		cmd.Printf("Failed to set config: %s\n", err)
-		return
+		return failf("Failed to set config: %s\n", err)
 	}
 	cmd.Println("Success!")
+	return nil
 }
 
-func disablePlacementRulesFunc(cmd *cobra.Command, args []string) {
+func disablePlacementRulesFunc(cmd *cobra.Command, args []string) error {
 	err := postConfigDataWithPath(cmd, "enable-placement-rules", "false", configPrefix)
 	if err != nil {
-		cmd.Printf("Failed to set config: %s\n", err)
-		return
+		return failf("Failed to set config: %s\n", err)
 	}
 	cmd.Println("Success!")
+	return nil
 }
 
-func getPlacementRulesFunc(cmd *cobra.Command, args []string) {
+func getPlacementRulesFunc(cmd *cobra.Command, args []string) error {
 	getFlag := func(key string) string {
 		if f := cmd.Flag(key); f != nil {
 			return f.Value.String()
@@ -525,8 +722,7 @@ func getPlacementRulesFunc(cmd *cobra.Command, args []string) {
 	case region == "" && group == "" && id == "": // all rules
 		reqPath = rulesPrefix
 	case region == "" && group == "" && id != "":
-		cmd.Println(`"id" should be specified along with "group"`)
-		return
+		return usageErrorln(`"id" should be specified along with "group"`)
 	case region == "" && group != "" && id == "": // all rules in a group
 		reqPath = path.Join(rulesPrefix, "group", group)
 	case region == "" && group != "" && id != "": // single rule
@@ -534,44 +730,40 @@ func getPlacementRulesFunc(cmd *cobra.Command, args []string) {
 	case region != "" && group == "" && id == "": // rules matches a region
 		reqPath = path.Join(rulesPrefix, "region", region)
 	default:
-		cmd.Println(`"region" should not be specified with "group" or "id" at the same time`)
-		return
+		return usageErrorln(`"region" should not be specified with "group" or "id" at the same time`)
 	}
 	res, err := doRequest(cmd, reqPath, http.MethodGet)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 	if file == "" {
 		cmd.Println(res)
-		return
+		return nil
 	}
 	if !respIsList {
 		res = "[\n" + res + "]\n"
 	}
 	err = ioutil.WriteFile(file, []byte(res), 0644)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 	cmd.Println("rules saved to file " + file)
+	return nil
 }
 
-func putPlacementRulesFunc(cmd *cobra.Command, args []string) {
+func putPlacementRulesFunc(cmd *cobra.Command, args []string) error {
 	var file string
 	if f := cmd.Flag("in"); f != nil {
 		file = f.Value.String()
 	}
 	content, err := ioutil.ReadFile(file)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 
 	var opts []*placement.RuleOp
 	if err = json.Unmarshal(content, &opts); err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 
 	validOpts := opts[:0]
@@ -588,17 +780,88 @@ func putPlacementRulesFunc(cmd *cobra.Command, args []string) {
 	b, _ := json.Marshal(validOpts)
 	_, err = doRequest(cmd, rulesBatchPrefix, http.MethodPost, WithBody("application/json", bytes.NewBuffer(b)))
 	if err != nil {
-		cmd.Printf("failed to save rules %s: %s\n", b, err)
-		return
+		return failf("failed to save rules %s: %s\n", b, err)
 	}
 
 	cmd.Println("Success!")
+	return nil
 }
 
-func showRuleGroupFunc(cmd *cobra.Command, args []string) {
+// parseLabelConstraint parses a label constraint like `key=v1|v2`, `key!=v1|v2`,
+// `key` or `!key`.
+func parseLabelConstraint(s string) (placement.LabelConstraint, error) {
+	var constraint placement.LabelConstraint
+	switch {
+	case strings.Contains(s, "!="):
+		kv := strings.SplitN(s, "!=", 2)
+		constraint = placement.LabelConstraint{Key: kv[0], Op: placement.NotIn, Values: strings.Split(kv[1], "|")}
+	case strings.Contains(s, "="):
+		kv := strings.SplitN(s, "=", 2)
+		constraint = placement.LabelConstraint{Key: kv[0], Op: placement.In, Values: strings.Split(kv[1], "|")}
+	case strings.HasPrefix(s, "!"):
+		constraint = placement.LabelConstraint{Key: s[1:], Op: placement.NotExists}
+	default:
+		constraint = placement.LabelConstraint{Key: s, Op: placement.Exists}
+	}
+	if constraint.Key == "" {
+		return constraint, errors.Errorf("invalid constraint %s", s)
+	}
+	return constraint, nil
+}
+
+func setPlacementRuleFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return usageErrorln(cmd.UsageString())
+	}
+	role, _ := cmd.Flags().GetString("role")
+	count, _ := cmd.Flags().GetInt("count")
+	constraints, _ := cmd.Flags().GetStringSlice("constraints")
+	locationLabels, _ := cmd.Flags().GetStringSlice("location-labels")
+	isolationLevel, _ := cmd.Flags().GetString("isolation-level")
+	startKey, _ := cmd.Flags().GetString("start-key")
+	endKey, _ := cmd.Flags().GetString("end-key")
+	index, _ := cmd.Flags().GetInt("index")
+	override, _ := cmd.Flags().GetBool("override")
+
+	labelConstraints := make([]placement.LabelConstraint, 0, len(constraints))
+	for _, s := range constraints {
+		constraint, err := parseLabelConstraint(s)
+		if err != nil {
+			return failln(err)
+		}
+		labelConstraints = append(labelConstraints, constraint)
+	}
+	input := map[string]interface{}{
+		"group_id":          args[0],
+		"id":                args[1],
+		"index":             index,
+		"override":          override,
+		"start_key":         startKey,
+		"end_key":           endKey,
+		"role":              role,
+		"count":             count,
+		"label_constraints": labelConstraints,
+		"location_labels":   locationLabels,
+		"isolation_level":   isolationLevel,
+	}
+	return postJSON(cmd, rulePrefix, input)
+}
+
+func deletePlacementRuleFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return usageErrorln(cmd.UsageString())
+	}
+	_, err := doRequest(cmd, path.Join(rulePrefix, args[0], args[1]), http.MethodDelete)
+	if err != nil {
+		return failf("Failed to delete rule: %s\n", err)
+	}
+	cmd.Println("Success!")
+	return nil
+}
+
+func showRuleGroupFunc(cmd *cobra.Command, args []string) error {
 	if len(args) > 1 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	reqPath := ruleGroupsPrefix
@@ -608,21 +871,19 @@ func showRuleGroupFunc(cmd *cobra.Command, args []string) {
 
 	res, err := doRequest(cmd, reqPath, http.MethodGet)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 	cmd.Println(res)
+	return nil
 }
 
-func updateRuleGroupFunc(cmd *cobra.Command, args []string) {
+func updateRuleGroupFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 3 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	index, err := strconv.ParseInt(args[1], 10, 64)
 	if err != nil {
-		cmd.Printf("index %s should be a number\n", args[1])
-		return
+		return usageErrorf("index %s should be a number\n", args[1])
 	}
 	var override bool
 	switch strings.ToLower(args[2]) {
@@ -630,41 +891,99 @@ func updateRuleGroupFunc(cmd *cobra.Command, args []string) {
 	case "true":
 		override = true
 	default:
-		cmd.Printf("override %s should be a boolean\n", args[2])
-		return
+		return usageErrorf("override %s should be a boolean\n", args[2])
 	}
-	postJSON(cmd, ruleGroupPrefix, map[string]interface{}{
+	return postJSON(cmd, ruleGroupPrefix, map[string]interface{}{
 		"id":       args[0],
 		"index":    index,
 		"override": override,
 	})
 }
 
-func deleteRuleGroupFunc(cmd *cobra.Command, args []string) {
+func deleteRuleGroupFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	_, err := doRequest(cmd, path.Join(ruleGroupPrefix, args[0]), http.MethodDelete)
 	if err != nil {
-		cmd.Printf("Failed to remove rule group config: %s \n", err)
-		return
+		return failf("Failed to remove rule group config: %s \n", err)
+	}
+	cmd.Println("Success!")
+	return nil
+}
+
+func showLeaderAntiAffinityFunc(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return usageErrorln(cmd.UsageString())
+	}
+
+	reqPath := antiAffinitiesPrefix
+	if len(args) > 0 {
+		reqPath = path.Join(antiAffinityPrefix, args[0])
+	}
+
+	res, err := doRequest(cmd, reqPath, http.MethodGet)
+	if err != nil {
+		return failln(err)
+	}
+	cmd.Println(res)
+	return nil
+}
+
+func updateLeaderAntiAffinityFunc(cmd *cobra.Command, args []string) error {
+	tables, _ := cmd.Flags().GetString("tables")
+	var keys []string
+	switch {
+	case tables == "" && len(args) == 5:
+		keys = args[1:]
+	case tables != "" && len(args) == 1:
+		ids := strings.Split(tables, ",")
+		if len(ids) != 2 {
+			return usageErrorln("tables should be two table IDs")
+		}
+		for _, s := range ids {
+			id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return usageErrorf("table ID %s should be a number\n", s)
+			}
+			keys = append(keys,
+				hex.EncodeToString(codec.EncodeBytes(codec.GenerateTableKey(id))),
+				hex.EncodeToString(codec.EncodeBytes(codec.GenerateTableKey(id+1))))
+		}
+	default:
+		return usageErrorln(cmd.UsageString())
+	}
+	return postJSON(cmd, antiAffinityPrefix, map[string]interface{}{
+		"id": args[0],
+		"ranges": []map[string]string{
+			{"start_key": keys[0], "end_key": keys[1]},
+			{"start_key": keys[2], "end_key": keys[3]},
+		},
+	})
+}
+
+func deleteLeaderAntiAffinityFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return usageErrorln(cmd.UsageString())
+	}
+	_, err := doRequest(cmd, path.Join(antiAffinityPrefix, args[0]), http.MethodDelete)
+	if err != nil {
+		return failf("Failed to remove leader anti-affinity config: %s \n", err)
 	}
 	cmd.Println("Success!")
+	return nil
 }
 
-func getRuleBundle(cmd *cobra.Command, args []string) {
+func getRuleBundle(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	reqPath := path.Join(ruleBundlePrefix, args[0])
 
 	res, err := doRequest(cmd, reqPath, http.MethodGet)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 
 	file := ""
@@ -673,51 +992,48 @@ func getRuleBundle(cmd *cobra.Command, args []string) {
 	}
 	if file == "" {
 		cmd.Println(res)
-		return
+		return nil
 	}
 
 	err = ioutil.WriteFile(file, []byte(res), 0644)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 	cmd.Printf("rule group saved to file %s\n", file)
+	return nil
 }
 
-func setRuleBundle(cmd *cobra.Command, args []string) {
+func setRuleBundle(cmd *cobra.Command, args []string) error {
 	var file string
 	if f := cmd.Flag("in"); f != nil {
 		file = f.Value.String()
 	}
 	content, err := ioutil.ReadFile(file)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 
 	id := struct {
 		GroupID string `json:"group_id"`
 	}{}
 	if err = json.Unmarshal(content, &id); err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 
 	reqPath := path.Join(ruleBundlePrefix, id.GroupID)
 
 	res, err := doRequest(cmd, reqPath, http.MethodPost, WithBody("application/json", bytes.NewReader(content)))
 	if err != nil {
-		cmd.Printf("failed to save rule bundle %s: %s\n", content, err)
-		return
+		return failf("failed to save rule bundle %s: %s\n", content, err)
 	}
 
 	cmd.Println(res)
+	return nil
 }
 
-func delRuleBundle(cmd *cobra.Command, args []string) {
+func delRuleBundle(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	reqPath := path.Join(ruleBundlePrefix, url.PathEscape(args[0]))
@@ -728,18 +1044,17 @@ func delRuleBundle(cmd *cobra.Command, args []string) {
 
 	res, err := doRequest(cmd, reqPath, http.MethodDelete)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 
 	cmd.Println(res)
+	return nil
 }
 
-func loadRuleBundle(cmd *cobra.Command, args []string) {
+func loadRuleBundle(cmd *cobra.Command, args []string) error {
 	res, err := doRequest(cmd, ruleBundlePrefix, http.MethodGet)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 
 	file := ""
@@ -748,26 +1063,25 @@ func loadRuleBundle(cmd *cobra.Command, args []string) {
 	}
 	if file == "" {
 		cmd.Println(res)
-		return
+		return nil
 	}
 
 	err = ioutil.WriteFile(file, []byte(res), 0644)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 	cmd.Printf("rule group saved to file %s\n", file)
+	return nil
 }
 
-func saveRuleBundle(cmd *cobra.Command, args []string) {
+func saveRuleBundle(cmd *cobra.Command, args []string) error {
 	var file string
 	if f := cmd.Flag("in"); f != nil {
 		file = f.Value.String()
 	}
 	content, err := ioutil.ReadFile(file)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 
 	path := ruleBundlePrefix
@@ -777,9 +1091,9 @@ func saveRuleBundle(cmd *cobra.Command, args []string) {
 
 	res, err := doRequest(cmd, path, http.MethodPost, WithBody("application/json", bytes.NewReader(content)))
 	if err != nil {
-		cmd.Printf("failed to save rule bundles %s: %s\n", content, err)
-		return
+		return failf("failed to save rule bundles %s: %s\n", content, err)
 	}
 
 	cmd.Println(res)
+	return nil
 }
@@ -0,0 +1,124 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// addConfirmFlag adds the flag to skip the confirmation of a destructive
+// command, it is inherited by the subcommands.
+func addConfirmFlag(c *cobra.Command) {
+	c.PersistentFlags().BoolP("yes", "y", false, "skip the confirmation")
+}
+
+// batchMode is set when the commands are read from a file or stdin, where no
+// one can type the confirmation.
+var batchMode bool
+
+// SetBatchMode sets whether the commands are executed in the batch mode.
+func SetBatchMode(batch bool) {
+	batchMode = batch
+}
+
+// confirm asks the user to type the name of the resource before doing the
+// destructive action, unless the confirmation is skipped by the flag. It
+// returns an error if the action is aborted. In the batch mode, the flag is
+// required instead, otherwise the next lines of the input would be consumed
+// as the confirmation.
+func confirm(cmd *cobra.Command, action, name string) error {
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		return nil
+	}
+	if batchMode {
+		return usageErrorf("This will %s. It can not be confirmed in the batch mode, add --yes to the command.\n", action)
+	}
+	cmd.Printf("This will %s. Type %q to confirm: ", action, name)
+	line, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if strings.TrimSpace(line) != name {
+		return failln("Aborted.")
+	}
+	return nil
+}
+
+// confirmHeader opts in the two-phase confirmation of the server.
+const confirmHeader = "PD-Confirm"
+
+// doConfirmedRequest sends the request to an endpoint which is guarded by the
+// two-phase confirmation of the server. It gets the confirm token first, and
+// then sends the request again with the token.
+func doConfirmedRequest(cmd *cobra.Command, prefix string, method string, body []byte) (string, error) {
+	var resp string
+	err := tryEndpoints(cmd, func(endpoint string) error {
+		u, err := url.Parse(endpoint + "/" + prefix)
+		if err != nil {
+			return err
+		}
+		token, err := requestConfirmToken(cmd, u.String(), method, body)
+		if err != nil {
+			return err
+		}
+		query := u.Query()
+		query.Set("confirm_token", token)
+		u.RawQuery = query.Encode()
+		req, err := newRequest(cmd, method, u.String(), bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err = dial(req)
+		return err
+	})
+	return resp, err
+}
+
+func requestConfirmToken(cmd *cobra.Command, url string, method string, body []byte) (string, error) {
+	req, err := newRequest(cmd, method, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(confirmHeader, "two-phase")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPreconditionRequired {
+		return "", readStatusError(resp)
+	}
+	msg, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	token := struct {
+		ConfirmToken string `json:"confirm_token"`
+	}{}
+	if err := json.Unmarshal(msg, &token); err != nil {
+		return "", err
+	}
+	return token.ConfirmToken, nil
+}
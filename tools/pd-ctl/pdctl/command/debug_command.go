@@ -0,0 +1,215 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/spf13/cobra"
+)
+
+var debugEtcdUsagePrefix = "pd/api/v1/debug/etcd-usage"
+
+// debugDumpItems are the files in the dump archive and the APIs they are
+// fetched from.
+var debugDumpItems = []struct {
+	name   string
+	prefix string
+}{
+	{"cluster.json", clusterPrefix},
+	{"cluster_status.json", clusterStatusPrefix},
+	{"members.json", membersPrefix},
+	{"health.json", healthPrefix},
+	{"config.json", configPrefix},
+	{"config_source.json", configSourcePrefix},
+	{"placement_rules.json", rulesPrefix},
+	{"stores.json", storesPrefix},
+	{"regions.json", regionsPrefix},
+	{"schedulers.json", schedulersPrefix},
+	{"operators.json", operatorsPrefix},
+	{"hot_read_regions.json", hotReadRegionsPrefix},
+	{"hot_write_regions.json", hotWriteRegionsPrefix},
+	{"hot_stores.json", hotStoresPrefix},
+	{"etcd_usage.json", debugEtcdUsagePrefix},
+}
+
+// NewDebugCommand returns a debug subcommand of rootCmd.
+func NewDebugCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "debug <subcommand>",
+		Short: "debug utilities",
+	}
+	c.AddCommand(NewDebugDumpCommand())
+	c.AddCommand(NewDebugEtcdUsageCommand())
+	c.AddCommand(NewDebugDeanonymizeCommand())
+	return c
+}
+
+// NewDebugDumpCommand returns a dump subcommand of debugCmd.
+func NewDebugDumpCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "dump [--out=<file>] [--anonymize --anonymize-map=<file>]",
+		Short: "dump the regions, stores, config, schedulers, operators, hot regions and members into an archive for offline diagnosis",
+		RunE:  debugDumpCommandFunc,
+	}
+	c.Flags().String("out", "cluster.tar.gz", "the tar.gz file to write the dump")
+	addAnonymizeFlags(c)
+	return c
+}
+
+// NewDebugDeanonymizeCommand returns a deanonymize subcommand of debugCmd.
+func NewDebugDeanonymizeCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "deanonymize [<file>] --anonymize-map=<file>",
+		Short: "replace the anonymized values in the file or stdin with the original ones in the mapping file",
+		RunE:  debugDeanonymizeCommandFunc,
+	}
+	addAnonymizeMapFlag(c)
+	return c
+}
+
+// NewDebugEtcdUsageCommand returns an etcd-usage subcommand of debugCmd.
+func NewDebugEtcdUsageCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "etcd-usage",
+		Short: "show the number and size of the keys in etcd grouped by prefixes",
+		RunE:  debugEtcdUsageCommandFunc,
+	}
+}
+
+func debugEtcdUsageCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	r, err := doRequest(cmd, debugEtcdUsagePrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get the etcd usage: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+func debugDeanonymizeCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return usageErrorln(cmd.UsageString())
+	}
+	file, err := getAnonymizeMapFile(cmd)
+	if err != nil {
+		return usageErrorln(err)
+	}
+	a, err := loadAnonymizer(file)
+	if err != nil {
+		return failf("Failed to load the mapping file: %s\n", err)
+	}
+	if a.secret == nil {
+		return usageErrorf("The mapping file %s does not exist\n", file)
+	}
+	var text []byte
+	if len(args) == 1 {
+		text, err = ioutil.ReadFile(args[0])
+	} else {
+		text, err = ioutil.ReadAll(cmd.InOrStdin())
+	}
+	if err != nil {
+		return failf("Failed to read the text: %s\n", err)
+	}
+	cmd.Print(string(a.deanonymize(text)))
+	return nil
+}
+
+func debugDumpCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	anonymizer, err := newAnonymizer(cmd)
+	if err != nil {
+		return failf("Failed to load the mapping file: %s\n", err)
+	}
+	out := cmd.Flag("out").Value.String()
+	f, err := os.Create(out)
+	if err != nil {
+		return failf("Failed to create the dump file: %s\n", err)
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	// The items which fail to fetch are recorded in the archive instead of
+	// aborting the dump, so the others are still useful.
+	var failures []string
+	now := time.Now()
+	for _, item := range debugDumpItems {
+		r, err := doRequest(cmd, item.prefix, http.MethodGet)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", item.name, err))
+			continue
+		}
+		data := []byte(r)
+		if anonymizer != nil {
+			// The item which can not be anonymized is left out of the dump.
+			if data, err = anonymizer.anonymize(data); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %s", item.name, err))
+				continue
+			}
+		}
+		if err := writeTarFile(tw, item.name, data, now); err != nil {
+			return failf("Failed to write the dump file: %s\n", err)
+		}
+	}
+	if len(failures) > 0 {
+		if err := writeTarFile(tw, "errors.txt", []byte(strings.Join(failures, "\n")+"\n"), now); err != nil {
+			return failf("Failed to write the dump file: %s\n", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return failf("Failed to write the dump file: %s\n", err)
+	}
+	if err := gw.Close(); err != nil {
+		return failf("Failed to write the dump file: %s\n", err)
+	}
+	for _, failure := range failures {
+		printErrf(cmd, "Failed to dump %s\n", failure)
+	}
+	if anonymizer != nil {
+		if err := anonymizer.save(); err != nil {
+			return failf("Failed to write the mapping file: %s\n", err)
+		}
+		cmd.Printf("The anonymized values are mapped in %s, keep it private\n", anonymizer.file)
+	}
+	cmd.Printf("Dumped %d items to %s\n", len(debugDumpItems)-len(failures), out)
+	if len(failures) > 0 {
+		return failf("Failed to dump %d items\n", len(failures))
+	}
+	return nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err := tw.Write(data)
+	return errors.WithStack(err)
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// The exit codes of pd-ctl in the non-interactive mode, they follow the
+// conventions of sysexits.h, except the results of the checks, like `cluster
+// health` and `store check-delete`.
+const (
+	ExitOK = 0
+	// ExitFailure is for the failures which are not classified, like failing
+	// to write a file.
+	ExitFailure = 1
+	// ExitCheckFailed is for the checks which fail, like `cluster health`
+	// with FAIL and `store check-delete` if the deletion is unsafe.
+	ExitCheckFailed = 2
+	// ExitCheckWarning is for the checks which pass with warnings, like
+	// `cluster health` with WARN.
+	ExitCheckWarning = 3
+	// ExitUsage is for the invalid commands, flags and arguments.
+	ExitUsage = 64
+	// ExitClientError is for the HTTP 4xx responses of PD.
+	ExitClientError = 65
+	// ExitNetworkError is for the failures to connect to PD.
+	ExitNetworkError = 69
+	// ExitServerError is for the HTTP 5xx responses of PD.
+	ExitServerError = 70
+)
+
+// exitError is the error returned by a command, the message is printed as it
+// is. The exit code is taken from the cause if the code is not specified.
+type exitError struct {
+	code  int
+	msg   string
+	cause error
+}
+
+func (e *exitError) Error() string {
+	return e.msg
+}
+
+func newExitError(code int, msg string, a []interface{}) error {
+	e := &exitError{code: code, msg: strings.TrimSuffix(msg, "\n")}
+	for _, v := range a {
+		if err, ok := v.(error); ok {
+			e.cause = err
+			break
+		}
+	}
+	return e
+}
+
+// failln returns the error of a failed command, the cause of the failure is
+// the first error in the arguments.
+func failln(a ...interface{}) error {
+	return newExitError(ExitOK, fmt.Sprintln(a...), a)
+}
+
+// failf is like failln but formats the message.
+func failf(format string, a ...interface{}) error {
+	return newExitError(ExitOK, fmt.Sprintf(format, a...), a)
+}
+
+// usageErrorln returns the error of the invalid arguments.
+func usageErrorln(a ...interface{}) error {
+	return newExitError(ExitUsage, fmt.Sprintln(a...), nil)
+}
+
+// usageErrorf is like usageErrorln but formats the message.
+func usageErrorf(format string, a ...interface{}) error {
+	return newExitError(ExitUsage, fmt.Sprintf(format, a...), nil)
+}
+
+// checkError returns the error of a check which does not pass, nothing is
+// printed for it since the result of the check is already in the output.
+func checkError(code int) error {
+	return &exitError{code: code}
+}
+
+// ExitCodeOf returns the exit code of the error returned by a command.
+func ExitCodeOf(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	for err != nil {
+		switch e := err.(type) {
+		case *exitError:
+			if e.code != ExitOK {
+				return e.code
+			}
+			err = e.cause
+			continue
+		case *statusError:
+			switch {
+			case e.code >= http.StatusInternalServerError:
+				return ExitServerError
+			case e.code >= http.StatusBadRequest:
+				return ExitClientError
+			}
+			return ExitFailure
+		case *url.Error:
+			return ExitNetworkError
+		}
+		c, ok := err.(interface{ Cause() error })
+		if !ok {
+			break
+		}
+		err = c.Cause()
+	}
+	return ExitFailure
+}
+
+// Execute executes the command with the context. The errors of cobra itself,
+// like the unknown commands and flags, are returned as usage errors after
+// printing the usage.
+func Execute(ctx context.Context, rootCmd *cobra.Command) error {
+	var run bool
+	rootCmd.PersistentPreRun = func(*cobra.Command, []string) { run = true }
+	usageCmd := rootCmd
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		usageCmd = cmd
+		return err
+	})
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+	err := rootCmd.ExecuteContext(ctx)
+	if err == nil || run {
+		return err
+	}
+	usageCmd.Println(usageCmd.UsageString())
+	return newExitError(ExitUsage, "Error: "+err.Error(), nil)
+}
+
+// PrintError prints the error of a command to its error output, and returns
+// the exit code.
+func PrintError(cmd *cobra.Command, err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	if msg := err.Error(); msg != "" {
+		printErrln(cmd, msg)
+	}
+	return ExitCodeOf(err)
+}
+
+// printErrln prints the message to the error output of the command, unlike
+// PrintErrln of cobra v1.0.0 which prints to the standard output.
+func printErrln(cmd *cobra.Command, a ...interface{}) {
+	fmt.Fprintln(cmd.ErrOrStderr(), a...)
+}
+
+// printErrf is like printErrln but formats the message.
+func printErrf(cmd *cobra.Command, format string, a ...interface{}) {
+	fmt.Fprintf(cmd.ErrOrStderr(), format, a...)
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tikv/pd/server/config"
+)
+
+var featuresPrefix = "pd/api/v1/features"
+
+// NewFeaturesCommand returns a features subcommand of rootCmd.
+func NewFeaturesCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "features",
+		Short: "show the experimental features and whether they are enabled",
+		RunE:  showFeaturesCommandFunc,
+	}
+	c.AddCommand(&cobra.Command{
+		Use:   "enable <name>",
+		Short: "enable an experimental feature",
+		RunE:  func(cmd *cobra.Command, args []string) error { return setFeatureCommandFunc(cmd, args, true) },
+	}, &cobra.Command{
+		Use:   "disable <name>",
+		Short: "disable an experimental feature",
+		RunE:  func(cmd *cobra.Command, args []string) error { return setFeatureCommandFunc(cmd, args, false) },
+	})
+	return c
+}
+
+func showFeaturesCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	r, err := doRequest(cmd, featuresPrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get the features: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+func setFeatureCommandFunc(cmd *cobra.Command, args []string, enable bool) error {
+	if len(args) != 1 {
+		return usageErrorln(cmd.UsageString())
+	}
+	r, err := doRequest(cmd, featuresPrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get the features: %s\n", err)
+	}
+	var features []config.Feature
+	if err := json.Unmarshal([]byte(r), &features); err != nil {
+		return failf("Failed to get the features: %s\n", err)
+	}
+	var enabled []string
+	found := false
+	for _, f := range features {
+		if f.Name == args[0] {
+			found = true
+			if enable {
+				enabled = append(enabled, f.Name)
+			}
+		} else if f.Enabled {
+			enabled = append(enabled, f.Name)
+		}
+	}
+	if !found {
+		return usageErrorf("Unknown feature %s\n", args[0])
+	}
+	return postJSON(cmd, configPrefix, map[string]interface{}{"pd-server.enabled-features": strings.Join(enabled, ",")})
+}
@@ -28,7 +28,7 @@ func NewServiceGCSafepointCommand() *cobra.Command {
 	l := &cobra.Command{
 		Use:   "service-gc-safepoint",
 		Short: "show all service gc safepoint",
-		Run:   showSSPs,
+		RunE:  showSSPs,
 	}
 	l.AddCommand(NewDeleteServiceGCSafepointCommand())
 	return l
@@ -39,32 +39,29 @@ func NewDeleteServiceGCSafepointCommand() *cobra.Command {
 	l := &cobra.Command{
 		Use:    "delete <service ID>",
 		Short:  "delete a service gc safepoint",
-		Run:    deleteSSP,
+		RunE:   deleteSSP,
 		Hidden: true,
 	}
 	return l
 }
 
-func showSSPs(cmd *cobra.Command, args []string) {
+func showSSPs(cmd *cobra.Command, args []string) error {
 	r, err := doRequest(cmd, serviceGCSafepointPrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get service GC safepoint: %s\n", err)
-		return
+		return failf("Failed to get service GC safepoint: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
-func deleteSSP(cmd *cobra.Command, args []string) {
+func deleteSSP(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Usage()
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	serviceID := args[0]
 	deleteURL := serviceGCSafepointPrefix + "/" + serviceID
 	r, err := doRequest(cmd, deleteURL, http.MethodDelete)
 	if err != nil {
-		cmd.Printf("Failed to delete service GC safepoint: %s\n", err)
-		return
+		return failf("Failed to delete service GC safepoint: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
@@ -15,26 +15,69 @@ package command
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pingcap/errors"
 	"github.com/spf13/cobra"
 	"go.etcd.io/etcd/pkg/transport"
 )
 
+// maxIdleConnsPerHost is the max number of the idle connections kept for each
+// PD member, the connections are reused by the commands in the interactive and
+// batch mode.
+const maxIdleConnsPerHost = 16
+
+// maxRetryBackoff is the max interval between the retries of a GET request.
+const maxRetryBackoff = 3 * time.Second
+
+// retryBackoff is the interval before the first retry of a GET request, it is
+// doubled for each retry.
+var retryBackoff = 200 * time.Millisecond
+
 var (
-	dialClient = &http.Client{}
+	dialClient = &http.Client{Transport: newDialTransport(nil)}
 	pingPrefix = "pd/api/v1/ping"
+	// tlsPaths are the paths of the files used by the https client. The client
+	// is reused if the paths are not changed, so that the connections to PD
+	// are kept alive across the commands in the interactive mode.
+	tlsPaths [3]string
 )
 
 // InitHTTPSClient creates https client with ca file
 func InitHTTPSClient(CAPath, CertPath, KeyPath string) error {
+	paths := [3]string{CAPath, CertPath, KeyPath}
+	if paths == tlsPaths {
+		return nil
+	}
+	client, err := NewHTTPClient(CAPath, CertPath, KeyPath)
+	if err != nil {
+		return err
+	}
+	dialClient = client
+	tlsPaths = paths
+
+	return nil
+}
+
+// NewHTTPClient creates a client to PD, it is https if any of the paths is
+// specified.
+func NewHTTPClient(CAPath, CertPath, KeyPath string) (*http.Client, error) {
+	if CAPath == "" && CertPath == "" && KeyPath == "" {
+		return &http.Client{Transport: newDialTransport(nil)}, nil
+	}
+	if (CertPath == "") != (KeyPath == "") {
+		return nil, errors.New("the client certificate and key should be specified together")
+	}
 	tlsInfo := transport.TLSInfo{
 		CertFile:      CertPath,
 		KeyFile:       KeyPath,
@@ -42,21 +85,87 @@ func InitHTTPSClient(CAPath, CertPath, KeyPath string) error {
 	}
 	tlsConfig, err := tlsInfo.ClientConfig()
 	if err != nil {
-		return errors.WithStack(err)
+		return nil, errors.WithStack(err)
+	}
+	return &http.Client{Transport: newDialTransport(tlsConfig)}, nil
+}
+
+type readOnlyClientKey struct{}
+
+// errReadOnly is returned when a command tries to change a cluster with a
+// read-only client.
+var errReadOnly = errors.New("only the read-only commands are allowed")
+
+// WithReadOnlyClient returns a context which makes the command executed with it
+// send the requests by the client, and the requests other than GET are refused.
+// It is used to execute a command against one of the clusters concurrently.
+func WithReadOnlyClient(ctx context.Context, client *http.Client) context.Context {
+	return context.WithValue(ctx, readOnlyClientKey{}, client)
+}
+
+type requestTimeoutKey struct{}
+
+// newRequest creates a request with the context of the command, and the
+// timeout of the `--timeout` flag. The commands which define a local timeout
+// flag, like `member drain`, are not limited. The timing of the request is
+// reported if `--profile-requests` is set.
+func newRequest(cmd *cobra.Command, method, url string, body io.Reader) (*http.Request, error) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout, err := cmd.Flags().GetDuration("timeout"); err == nil && timeout > 0 {
+		ctx = context.WithValue(ctx, requestTimeoutKey{}, timeout)
+	}
+	if profile, err := cmd.Flags().GetBool("profile-requests"); err == nil && profile {
+		ctx = context.WithValue(ctx, requestProfileKey{}, cmd.ErrOrStderr())
 	}
+	ctx = context.WithValue(ctx, requestCommandKey{}, requestCommand(cmd))
+	return http.NewRequestWithContext(ctx, method, url, body)
+}
 
-	dialClient = &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: tlsConfig,
-		},
+// do sends the request by the client in its context if any.
+func do(req *http.Request) (*http.Response, error) {
+	client := dialClient
+	if c, ok := req.Context().Value(readOnlyClientKey{}).(*http.Client); ok {
+		if req.Method != http.MethodGet {
+			return nil, errReadOnly
+		}
+		client = c
 	}
+	if timeout, ok := req.Context().Value(requestTimeoutKey{}).(time.Duration); ok {
+		// The copy shares the transport, so the connections are still reused.
+		c := *client
+		c.Timeout = timeout
+		client = &c
+	}
+	req, profiled := profileRequest(req)
+	start := time.Now()
+	resp, err := client.Do(req)
+	observeRequest(req, resp, start)
+	if err != nil {
+		return nil, err
+	}
+	return profiled(resp), nil
+}
 
-	return nil
+// newDialTransport returns a transport which keeps the connections alive and
+// negotiates HTTP/2 over TLS, the customized TLS config disables HTTP/2 unless
+// it is forced.
+func newDialTransport(tlsConfig *tls.Config) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsConfig
+	t.ForceAttemptHTTP2 = true
+	t.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	return t
 }
 
 type bodyOption struct {
 	contentType string
 	body        io.Reader
+	// output receives the response body as it is read, instead of buffering
+	// the whole response.
+	output io.Writer
 }
 
 // BodyOption sets the type and content of the body
@@ -70,6 +179,27 @@ func WithBody(contentType string, body io.Reader) BodyOption {
 	}
 }
 
+// WithResponseWriter returns a BodyOption which streams the response body to
+// the writer, the response returned by doRequest is empty then.
+func WithResponseWriter(w io.Writer) BodyOption {
+	return func(bo *bodyOption) {
+		bo.output = w
+	}
+}
+
+// countingWriter counts the bytes written, so that the request is not retried
+// with another endpoint after a part of the response is written.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
 func doRequest(cmd *cobra.Command, prefix string, method string,
 	opts ...BodyOption) (string, error) {
 	b := &bodyOption{}
@@ -77,9 +207,9 @@ func doRequest(cmd *cobra.Command, prefix string, method string,
 		o(b)
 	}
 	var resp string
+	var streamErr error
 
-	endpoints := getEndpoints(cmd)
-	err := tryURLs(cmd, endpoints, func(endpoint string) error {
+	try := func(endpoint string) error {
 		var err error
 		url := endpoint + "/" + prefix
 		if method == "" {
@@ -87,43 +217,105 @@ func doRequest(cmd *cobra.Command, prefix string, method string,
 		}
 		var req *http.Request
 
-		req, err = http.NewRequest(method, url, b.body)
+		req, err = newRequest(cmd, method, url, b.body)
 		if err != nil {
 			return err
 		}
 		if b.contentType != "" {
 			req.Header.Set("Content-Type", b.contentType)
 		}
+		if b.output != nil {
+			w := &countingWriter{w: b.output}
+			if err = dialStream(req, w); err != nil && w.n > 0 {
+				streamErr = err
+				return nil
+			}
+			return err
+		}
 		// the resp would be returned by the outer function
 		resp, err = dial(req)
 		if err != nil {
 			return err
 		}
 		return nil
-	})
-	return resp, err
+	}
+	err := retryGet(cmd, method, func() error { return tryEndpoints(cmd, try) })
+	if streamErr != nil {
+		err = streamErr
+	}
+	if err != nil {
+		return "", err
+	}
+	return resp, nil
+}
+
+// retryGet calls f until it succeeds or `--max-retries` is exceeded with an
+// exponential backoff, only the GET requests are retried because they are
+// idempotent.
+func retryGet(cmd *cobra.Command, method string, f func() error) error {
+	maxRetries, _ := cmd.Flags().GetInt("max-retries")
+	backoff := retryBackoff
+	for i := 0; ; i++ {
+		err := f()
+		if err == nil || (method != "" && method != http.MethodGet) || i >= maxRetries || !isRetryable(err) {
+			return err
+		}
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
 }
 
 func dial(req *http.Request) (string, error) {
-	resp, err := dialClient.Do(req)
-	if err != nil {
+	var content strings.Builder
+	if err := dialStream(req, &content); err != nil {
 		return "", err
 	}
+	return content.String(), nil
+}
+
+// dialStream copies the response body to the writer, the memory usage does not
+// grow with the size of the response.
+func dialStream(req *http.Request, w io.Writer) error {
+	resp, err := do(req)
+	if err != nil {
+		return err
+	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		var msg []byte
-		msg, err = ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return "", err
-		}
-		return "", errors.Errorf("[%d] %s", resp.StatusCode, msg)
+		return readStatusError(resp)
 	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// statusError is the error returned by PD with the status code.
+type statusError struct {
+	code int
+	msg  []byte
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("[%d] %s", e.code, e.msg)
+}
 
-	content, err := ioutil.ReadAll(resp.Body)
+func readStatusError(resp *http.Response) error {
+	msg, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return err
 	}
-	return string(content), nil
+	return unsupportedAPIError(resp.Request, &statusError{code: resp.StatusCode, msg: msg})
+}
+
+// isRetryable returns true if the request can be sent to another member, like
+// the member is down or it has no leader. The request rejected by PD is not
+// retried, because another member will reject it too.
+func isRetryable(err error) bool {
+	if e, ok := err.(*statusError); ok {
+		return e.code >= http.StatusInternalServerError
+	}
+	return true
 }
 
 // DoFunc receives an endpoint which you can issue request to
@@ -137,34 +329,41 @@ func tryURLs(cmd *cobra.Command, endpoints []string, f DoFunc) error {
 		var u *url.URL
 		u, err = url.Parse(endpoint)
 		if err != nil {
-			cmd.Println("address format is wrong, should like 'http://127.0.0.1:2379' or '127.0.0.1:2379'")
-			os.Exit(1)
+			return usageErrorln("address format is wrong, should like 'http://127.0.0.1:2379' or '127.0.0.1:2379'")
 		}
 		// tolerate some schemes that will be used by users, the TiKV SDK
 		// use 'tikv' as the scheme, it is really confused if we do not
 		// support it by pd-ctl
 		if u.Scheme == "" || u.Scheme == "pd" || u.Scheme == "tikv" {
-			u.Scheme = "http"
+			u.Scheme = defaultScheme()
 		}
 
 		endpoint = u.String()
 		err = f(endpoint)
-		if err != nil {
-			continue
+		if err == nil || !isRetryable(err) {
+			return err
 		}
-		break
 	}
 	if len(endpoints) > 1 && err != nil {
-		err = errors.Errorf("after trying all endpoints, no endpoint is available, the last error we met: %s", err)
+		err = errors.Wrap(err, "after trying all endpoints, no endpoint is available, the last error we met")
 	}
 	return err
 }
 
-func getEndpoints(cmd *cobra.Command) []string {
+// defaultScheme is the scheme of the address without one, it is https if the
+// https client is used.
+func defaultScheme() string {
+	if tlsPaths != [3]string{} {
+		return "https"
+	}
+	return "http"
+}
+
+// getEndpoints returns the addresses specified by the `-u` flag.
+func getEndpoints(cmd *cobra.Command) ([]string, error) {
 	addrs, err := cmd.Flags().GetString("pd")
 	if err != nil {
-		cmd.Println("get pd address failed, should set flag with '-u'")
-		os.Exit(1)
+		return nil, usageErrorln("get pd address failed, should set flag with '-u'")
 	}
 	eps := strings.Split(addrs, ",")
 	for i, ep := range eps {
@@ -172,38 +371,133 @@ func getEndpoints(cmd *cobra.Command) []string {
 			eps[i] = "//" + ep
 		}
 	}
-	return eps
+	return eps, nil
 }
 
-func postJSON(cmd *cobra.Command, prefix string, input map[string]interface{}) {
-	data, err := json.Marshal(input)
+// memberEndpoints caches the client URLs of the PD members discovered by the
+// addresses of `-u`. The members are requested once the addresses are
+// reachable, so they can be tried after the addresses are down in the
+// interactive and batch mode.
+var memberEndpoints = struct {
+	sync.Mutex
+	m map[string][]string
+}{m: make(map[string][]string)}
+
+// tryEndpoints issues the request to the addresses of `-u`. The client URLs
+// advertised by the members are only tried after none of the addresses can
+// handle the request, because they may be unreachable behind a proxy or NAT.
+func tryEndpoints(cmd *cobra.Command, f DoFunc) error {
+	eps, err := getEndpoints(cmd)
+	if err != nil {
+		return err
+	}
+	err = tryURLs(cmd, eps, f)
+	if err == nil {
+		cacheMemberEndpoints(cmd, eps)
+		return nil
+	}
+	if !isRetryable(err) {
+		return err
+	}
+	tried := make(map[string]struct{}, len(eps))
+	for _, ep := range eps {
+		tried[strings.TrimPrefix(strings.TrimPrefix(ep, "//"), defaultScheme()+"://")] = struct{}{}
+	}
+	var rest []string
+	for _, u := range getMemberEndpoints(eps) {
+		if _, ok := tried[strings.TrimPrefix(u, defaultScheme()+"://")]; !ok {
+			rest = append(rest, u)
+		}
+	}
+	if len(rest) == 0 {
+		return err
+	}
+	return tryURLs(cmd, rest, f)
+}
+
+// getMemberEndpoints returns the cached client URLs of the PD members
+// discovered by the addresses, the leader is the first one.
+func getMemberEndpoints(eps []string) []string {
+	memberEndpoints.Lock()
+	defer memberEndpoints.Unlock()
+	return memberEndpoints.m[strings.Join(eps, ",")]
+}
+
+// cacheMemberEndpoints requests the members by the addresses and caches their
+// client URLs if they are not cached yet.
+func cacheMemberEndpoints(cmd *cobra.Command, eps []string) {
+	key := strings.Join(eps, ",")
+	memberEndpoints.Lock()
+	defer memberEndpoints.Unlock()
+	if _, ok := memberEndpoints.m[key]; ok {
+		return
+	}
+	var members struct {
+		Members []struct {
+			ClientUrls []string `json:"client_urls"`
+		} `json:"members"`
+		Leader struct {
+			ClientUrls []string `json:"client_urls"`
+		} `json:"leader"`
+	}
+	err := tryURLs(cmd, eps, func(endpoint string) error {
+		req, err := newRequest(cmd, http.MethodGet, endpoint+"/"+membersPrefix, nil)
+		if err != nil {
+			return err
+		}
+		r, err := dial(req)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal([]byte(r), &members)
+	})
 	if err != nil {
-		cmd.Println(err)
 		return
 	}
+	var endpoints []string
+	seen := make(map[string]struct{})
+	add := func(urls []string) {
+		for _, u := range urls {
+			if _, ok := seen[u]; !ok {
+				seen[u] = struct{}{}
+				endpoints = append(endpoints, u)
+			}
+		}
+	}
+	add(members.Leader.ClientUrls)
+	for _, m := range members.Members {
+		add(m.ClientUrls)
+	}
+	memberEndpoints.m[key] = endpoints
+}
 
-	endpoints := getEndpoints(cmd)
-	err = tryURLs(cmd, endpoints, func(endpoint string) error {
-		var msg []byte
-		var r *http.Response
-		url := endpoint + "/" + prefix
-		r, err = dialClient.Post(url, "application/json", bytes.NewBuffer(data))
+func postJSON(cmd *cobra.Command, prefix string, input map[string]interface{}) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return failln(err)
+	}
+
+	err = tryEndpoints(cmd, func(endpoint string) error {
+		req, err := newRequest(cmd, http.MethodPost, endpoint+"/"+prefix, bytes.NewBuffer(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		r, err := do(req)
 		if err != nil {
 			return err
 		}
 		defer r.Body.Close()
 		if r.StatusCode != http.StatusOK {
-			msg, err = ioutil.ReadAll(r.Body)
-			if err != nil {
-				return err
-			}
-			return errors.Errorf("[%d] %s", r.StatusCode, msg)
+			return readStatusError(r)
 		}
-		return nil
+		// The body is drained so that the connection can be reused.
+		_, err = io.Copy(ioutil.Discard, r.Body)
+		return err
 	})
 	if err != nil {
-		cmd.Printf("Failed! %s", err)
-		return
+		return failf("Failed! %s", err)
 	}
 	cmd.Println("Success!")
+	return nil
 }
@@ -20,7 +20,8 @@ import (
 )
 
 var (
-	healthPrefix = "pd/api/v1/health"
+	healthPrefix       = "pd/api/v1/health"
+	healthDetailPrefix = "pd/api/v1/health/detail"
 )
 
 // NewHealthCommand return a health subcommand of rootCmd
@@ -28,16 +29,20 @@ func NewHealthCommand() *cobra.Command {
 	m := &cobra.Command{
 		Use:   "health",
 		Short: "show all node's health information of the pd cluster",
-		Run:   showHealthCommandFunc,
+		RunE:  showHealthCommandFunc,
 	}
+	m.Flags().Bool("detail", false, "show the status of subsystems as well")
 	return m
 }
 
-func showHealthCommandFunc(cmd *cobra.Command, args []string) {
-	r, err := doRequest(cmd, healthPrefix, http.MethodGet)
+func showHealthCommandFunc(cmd *cobra.Command, args []string) error {
+	prefix := healthPrefix
+	if detail, _ := cmd.Flags().GetBool("detail"); detail {
+		prefix = healthDetailPrefix
+	}
+	r, err := doRequest(cmd, prefix, http.MethodGet)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
@@ -15,14 +15,20 @@ package command
 
 import (
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/pingcap/errors"
 	"github.com/spf13/cobra"
 )
 
 const (
-	hotReadRegionsPrefix  = "pd/api/v1/hotspot/regions/read"
-	hotWriteRegionsPrefix = "pd/api/v1/hotspot/regions/write"
-	hotStoresPrefix       = "pd/api/v1/hotspot/stores"
+	hotReadRegionsPrefix   = "pd/api/v1/hotspot/regions/read"
+	hotWriteRegionsPrefix  = "pd/api/v1/hotspot/regions/write"
+	hotStoresPrefix        = "pd/api/v1/hotspot/stores"
+	hotStoresSummaryPrefix = "pd/api/v1/hotspot/stores/summary"
+	hotHistoryPrefix       = "pd/api/v1/hotspot/regions/history"
 )
 
 // NewHotSpotCommand return a hot subcommand of rootCmd
@@ -34,6 +40,7 @@ func NewHotSpotCommand() *cobra.Command {
 	cmd.AddCommand(NewHotWriteRegionCommand())
 	cmd.AddCommand(NewHotReadRegionCommand())
 	cmd.AddCommand(NewHotStoreCommand())
+	cmd.AddCommand(NewHotHistoryCommand())
 	return cmd
 }
 
@@ -42,18 +49,17 @@ func NewHotWriteRegionCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "write",
 		Short: "show the hot write regions",
-		Run:   showHotWriteRegionsCommandFunc,
+		RunE:  showHotWriteRegionsCommandFunc,
 	}
 	return cmd
 }
 
-func showHotWriteRegionsCommandFunc(cmd *cobra.Command, args []string) {
+func showHotWriteRegionsCommandFunc(cmd *cobra.Command, args []string) error {
 	r, err := doRequest(cmd, hotWriteRegionsPrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get hotspot: %s\n", err)
-		return
+		return failf("Failed to get hotspot: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
 // NewHotReadRegionCommand return a hot read regions subcommand of hotSpotCmd
@@ -61,18 +67,17 @@ func NewHotReadRegionCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "read",
 		Short: "show the hot read regions",
-		Run:   showHotReadRegionsCommandFunc,
+		RunE:  showHotReadRegionsCommandFunc,
 	}
 	return cmd
 }
 
-func showHotReadRegionsCommandFunc(cmd *cobra.Command, args []string) {
+func showHotReadRegionsCommandFunc(cmd *cobra.Command, args []string) error {
 	r, err := doRequest(cmd, hotReadRegionsPrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get hotspot: %s\n", err)
-		return
+		return failf("Failed to get hotspot: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
 // NewHotStoreCommand return a hot stores subcommand of hotSpotCmd
@@ -80,16 +85,97 @@ func NewHotStoreCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "store",
 		Short: "show the hot stores",
-		Run:   showHotStoresCommandFunc,
+		RunE:  showHotStoresCommandFunc,
 	}
+	summary := &cobra.Command{
+		Use:   "summary [--sort-by=write-bytes|write-keys|read-bytes|read-keys]",
+		Short: "show the read and write rates of every store, the rates of the hot peers are split by leaders and followers, e.g. `--output=table` shows the load imbalance in one screen",
+		RunE:  showHotStoresSummaryCommandFunc,
+	}
+	summary.Flags().String("sort-by", "write-bytes", "the rate to sort the stores in descending order")
+	cmd.AddCommand(summary)
 	return cmd
 }
 
-func showHotStoresCommandFunc(cmd *cobra.Command, args []string) {
+func showHotStoresSummaryCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	sortBy, _ := cmd.Flags().GetString("sort-by")
+	switch sortBy {
+	case "write-bytes", "write-keys", "read-bytes", "read-keys":
+	default:
+		return usageErrorf("Invalid sort-by %s, should be one of write-bytes, write-keys, read-bytes and read-keys\n", sortBy)
+	}
+	r, err := doRequest(cmd, hotStoresSummaryPrefix+"?sort-by="+sortBy, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get hot store summary: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+func showHotStoresCommandFunc(cmd *cobra.Command, args []string) error {
 	r, err := doRequest(cmd, hotStoresPrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get hotspot: %s\n", err)
-		return
+		return failf("Failed to get hotspot: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+// NewHotHistoryCommand return a hot history subcommand of hotSpotCmd
+func NewHotHistoryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history [--start=<time>] [--end=<time>] [--type=read|write]",
+		Short: "show the hot regions in a time range of the last 24 hours",
+		Long:  "show the hot regions in a time range of the last 24 hours, the snapshots of the hottest peers are taken every minute. The history is kept in the memory of the PD leader only, so it starts over when the leader changes. The time can be unix seconds, RFC3339 like 2020-11-20T23:00:00+08:00 or local time like '2020-11-20 23:00:00'",
+		RunE:  showHotHistoryCommandFunc,
+	}
+	cmd.Flags().String("start", "", "the start of the time range")
+	cmd.Flags().String("end", "", "the end of the time range, it is now by default")
+	cmd.Flags().String("type", "", "the type of the hot regions, read or write, both are shown by default")
+	return cmd
+}
+
+func showHotHistoryCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	query := url.Values{}
+	for _, name := range []string{"start", "end"} {
+		value, _ := cmd.Flags().GetString(name)
+		if value == "" {
+			continue
+		}
+		t, err := parseTime(value)
+		if err != nil {
+			return failln(err)
+		}
+		query.Set(name, strconv.FormatInt(t.Unix(), 10))
+	}
+	if typ, _ := cmd.Flags().GetString("type"); typ != "" {
+		query.Set("type", typ)
+	}
+	prefix := hotHistoryPrefix
+	if len(query) > 0 {
+		prefix += "?" + query.Encode()
+	}
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get hot region history: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+// parseTime parses the time in unix seconds, RFC3339 or local time.
+func parseTime(s string) (time.Time, error) {
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", s, time.Local); err == nil {
+		return t, nil
 	}
-	cmd.Println(r)
+	return time.Time{}, errors.Errorf("invalid time %s", s)
 }
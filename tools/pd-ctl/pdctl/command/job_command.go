@@ -0,0 +1,158 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/pingcap/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	jobsPrefix = "pd/api/v1/jobs"
+)
+
+// The types of the jobs submitted by the commands.
+const (
+	scatterRangeJob = "scatter-range"
+	drainStoreJob   = "drain-store"
+)
+
+// NewJobCommand return a job subcommand of rootCmd
+func NewJobCommand() *cobra.Command {
+	j := &cobra.Command{
+		Use:   "job <subcommand>",
+		Short: "long-running admin jobs",
+	}
+	j.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "list all jobs",
+		RunE:  listJobsCommandFunc,
+	})
+	j.AddCommand(&cobra.Command{
+		Use:   "show <job_id>",
+		Short: "show the state and progress of a job",
+		RunE:  showJobCommandFunc,
+	})
+	j.AddCommand(&cobra.Command{
+		Use:   "cancel <job_id>",
+		Short: "cancel a pending or running job",
+		RunE:  cancelJobCommandFunc,
+	})
+	return j
+}
+
+func listJobsCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	r, err := doRequest(cmd, jobsPrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get jobs: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+func showJobCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return usageErrorln(cmd.UsageString())
+	}
+	if _, err := strconv.ParseUint(args[0], 10, 64); err != nil {
+		return usageErrorln("job_id should be a number")
+	}
+	r, err := doRequest(cmd, jobsPrefix+"/"+args[0], http.MethodGet)
+	if err != nil {
+		return failf("Failed to get job: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+func cancelJobCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return usageErrorln(cmd.UsageString())
+	}
+	if _, err := strconv.ParseUint(args[0], 10, 64); err != nil {
+		return usageErrorln("job_id should be a number")
+	}
+	_, err := doRequest(cmd, jobsPrefix+"/"+args[0], http.MethodDelete)
+	if err != nil {
+		return failf("Failed to cancel job: %s\n", err)
+	}
+	cmd.Println("Success!")
+	return nil
+}
+
+// jobState is the part of a job to poll its state.
+type jobState struct {
+	ID       uint64  `json:"id"`
+	State    string  `json:"state"`
+	Progress float64 `json:"progress"`
+	Error    string  `json:"error"`
+	// Checkpoint is decoded by the command to show the step of the job.
+	Checkpoint json.RawMessage `json:"checkpoint"`
+}
+
+// submitJob submits a job of the type with the params, and returns its ID.
+func submitJob(cmd *cobra.Command, typ string, params interface{}) (uint64, error) {
+	data, err := json.Marshal(map[string]interface{}{
+		"type":   typ,
+		"params": params,
+	})
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	r, err := doRequest(cmd, jobsPrefix, http.MethodPost, WithBody("application/json", bytes.NewBuffer(data)))
+	if err != nil {
+		return 0, err
+	}
+	var j jobState
+	if err := json.Unmarshal([]byte(r), &j); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return j.ID, nil
+}
+
+// waitForJob polls the job until it is done, and returns the last response
+// and the state of the job. The step of the progress is described by step if
+// it is not nil.
+func waitForJob(cmd *cobra.Command, id uint64, step func(*jobState) string) (string, *jobState, error) {
+	var (
+		r string
+		j jobState
+	)
+	prefix := fmt.Sprintf("%s/%d", jobsPrefix, id)
+	err := waitFor(cmd, func() (float64, string, bool, error) {
+		var err error
+		if r, err = doRequest(cmd, prefix, http.MethodGet); err != nil {
+			return 0, "", false, err
+		}
+		if err := json.Unmarshal([]byte(r), &j); err != nil {
+			return 0, "", false, errors.WithStack(err)
+		}
+		done := j.State == "finished" || j.State == "failed" || j.State == "cancelled"
+		if step != nil {
+			return j.Progress * 100, step(&j), done, nil
+		}
+		return j.Progress * 100, fmt.Sprintf("job %d is %s", id, j.State), done, nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return r, &j, nil
+}
@@ -0,0 +1,253 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pingcap/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewKeyRangeCommand returns a keyrange subcommand of rootCmd.
+func NewKeyRangeCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "keyrange <subcommand>",
+		Short: "key range utilities",
+	}
+	c.AddCommand(NewSplitEvenCommand())
+	return c
+}
+
+// NewSplitEvenCommand returns a split-even subcommand of keyrangeCmd.
+func NewSplitEvenCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "split-even [--format=raw|encode|hex] --start-key=<key> [--end-key=<key>] [--file=<file>] <n>",
+		Short: "generate n split keys which divide the key range [start-key, end-key) evenly by the region sizes, the output can be used by `region split-keys --file`",
+		RunE:  splitEvenCommandFunc,
+	}
+	c.Flags().String("format", "hex", "the key format")
+	c.Flags().String("start-key", "", "the start key of the range")
+	c.Flags().String("end-key", "", "the end key of the range, empty means unbounded")
+	c.Flags().String("file", "", "the file to write the split keys, they are printed if it is empty")
+	return c
+}
+
+// splitKeysFile is the file of split keys, it is the same as the body of the
+// split regions API.
+type splitKeysFile struct {
+	SplitKeys []string `json:"split_keys"`
+}
+
+func splitEvenCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return usageErrorln(cmd.UsageString())
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		return usageErrorln("n should be a positive number")
+	}
+	startKey, err := parseKey(cmd.Flags(), cmd.Flag("start-key").Value.String())
+	if err != nil {
+		return usageErrorln("Error: ", err)
+	}
+	endKey, err := parseKey(cmd.Flags(), cmd.Flag("end-key").Value.String())
+	if err != nil {
+		return usageErrorln("Error: ", err)
+	}
+	if len(endKey) > 0 && startKey >= endKey {
+		return usageErrorln("start-key should be less than end-key")
+	}
+	segments, err := loadKeyRangeSegments(cmd, []byte(startKey), []byte(endKey))
+	if err != nil {
+		return failf("Failed to get regions: %s\n", err)
+	}
+	keys := splitKeyRangeEvenly(segments, n)
+	output := splitKeysFile{SplitKeys: make([]string, 0, len(keys))}
+	for _, key := range keys {
+		output.SplitKeys = append(output.SplitKeys, hex.EncodeToString(key))
+	}
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return failf("Failed to marshal split keys: %s\n", err)
+	}
+	file := cmd.Flag("file").Value.String()
+	if file == "" {
+		return printResponse(cmd, string(data))
+	}
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		return failf("Failed to write split keys: %s\n", err)
+	}
+	cmd.Printf("Wrote %d split keys to %s\n", len(keys), file)
+	return nil
+}
+
+// keyRangeSegment is the part of a region in the key range.
+type keyRangeSegment struct {
+	startKey []byte
+	endKey   []byte
+	size     int64
+}
+
+// loadKeyRangeSegments loads the regions in the key range page by page, and
+// clips the first and the last regions by the range.
+func loadKeyRangeSegments(cmd *cobra.Command, startKey, endKey []byte) ([]keyRangeSegment, error) {
+	var segments []keyRangeSegment
+	key := startKey
+	for {
+		query := url.Values{}
+		query.Set("start_key", string(key))
+		query.Set("end_key", string(endKey))
+		r, err := doRequest(cmd, regionsRangePrefix+"?"+query.Encode(), http.MethodGet)
+		if err != nil {
+			return nil, err
+		}
+		var regions struct {
+			Regions []struct {
+				StartKey        string `json:"start_key"`
+				EndKey          string `json:"end_key"`
+				ApproximateSize int64  `json:"approximate_size"`
+			} `json:"regions"`
+		}
+		if err := json.Unmarshal([]byte(r), &regions); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if len(regions.Regions) == 0 {
+			return segments, nil
+		}
+		for _, region := range regions.Regions {
+			s := keyRangeSegment{size: region.ApproximateSize}
+			if s.startKey, err = hex.DecodeString(region.StartKey); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			if s.endKey, err = hex.DecodeString(region.EndKey); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			if bytes.Compare(s.startKey, startKey) < 0 {
+				s.startKey = startKey
+			}
+			if len(endKey) > 0 && (len(s.endKey) == 0 || bytes.Compare(s.endKey, endKey) > 0) {
+				s.endKey = endKey
+			}
+			// The empty regions still take a part of the range.
+			if s.size <= 0 {
+				s.size = 1
+			}
+			segments = append(segments, s)
+			key = s.endKey
+		}
+		if len(key) == 0 || (len(endKey) > 0 && bytes.Compare(key, endKey) >= 0) {
+			return segments, nil
+		}
+	}
+}
+
+// splitKeyRangeEvenly returns n keys which divide the segments into n+1 parts
+// with the same size. The region boundaries are used if there are enough of
+// them, otherwise the keys are interpolated in the regions. Fewer keys are
+// returned if the range is too narrow to hold n distinct keys.
+func splitKeyRangeEvenly(segments []keyRangeSegment, n int) [][]byte {
+	if len(segments) == 0 {
+		return nil
+	}
+	// offsets[i] is the total size of the segments before the i-th one, the
+	// sizes are scaled by n+1 so the targets are integers.
+	offsets := make([]int64, len(segments)+1)
+	for i, s := range segments {
+		offsets[i+1] = offsets[i] + s.size*int64(n+1)
+	}
+	total := offsets[len(segments)] / int64(n+1)
+
+	var keys [][]byte
+	if len(segments)-1 >= n {
+		// Choose the nearest boundary for each target, and leave enough
+		// boundaries for the rest targets.
+		last := 0
+		for i := 1; i <= n; i++ {
+			target := total * int64(i)
+			best := last + 1
+			for j := best + 1; j <= len(segments)-1-(n-i); j++ {
+				if abs64(offsets[j]-target) < abs64(offsets[best]-target) {
+					best = j
+				}
+			}
+			keys = append(keys, segments[best].startKey)
+			last = best
+		}
+		return keys
+	}
+
+	rangeStart, rangeEnd := segments[0].startKey, segments[len(segments)-1].endKey
+	j := 0
+	for i := 1; i <= n; i++ {
+		target := total * int64(i)
+		for j < len(segments)-1 && offsets[j+1] <= target {
+			j++
+		}
+		s := segments[j]
+		key := interpolateKey(s.startKey, s.endKey, target-offsets[j], offsets[j+1]-offsets[j])
+		if bytes.Compare(key, rangeStart) <= 0 || (len(rangeEnd) > 0 && bytes.Compare(key, rangeEnd) >= 0) {
+			continue
+		}
+		if len(keys) > 0 && bytes.Compare(key, keys[len(keys)-1]) <= 0 {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// interpolateKeyPadding is the number of bytes appended to the keys, so the
+// keys can be interpolated between two adjacent keys.
+const interpolateKeyPadding = 4
+
+// interpolateKey returns the key at num/den of [startKey, endKey) by treating
+// the keys as big-endian numbers, an empty endKey means the end of key space.
+func interpolateKey(startKey, endKey []byte, num, den int64) []byte {
+	length := len(startKey)
+	if len(endKey) > length {
+		length = len(endKey)
+	}
+	length += interpolateKeyPadding
+	toInt := func(key []byte) *big.Int {
+		padded := make([]byte, length)
+		copy(padded, key)
+		return new(big.Int).SetBytes(padded)
+	}
+	start := toInt(startKey)
+	end := new(big.Int).Lsh(big.NewInt(1), uint(length*8))
+	if len(endKey) > 0 {
+		end = toInt(endKey)
+	}
+	delta := new(big.Int).Sub(end, start)
+	delta.Mul(delta, big.NewInt(num)).Div(delta, big.NewInt(den))
+	b := new(big.Int).Add(start, delta).Bytes()
+	key := make([]byte, length)
+	copy(key[length-len(b):], b)
+	return bytes.TrimRight(key, "\x00")
+}
+
+func abs64(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
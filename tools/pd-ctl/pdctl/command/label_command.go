@@ -30,7 +30,7 @@ func NewLabelCommand() *cobra.Command {
 	l := &cobra.Command{
 		Use:   "label [store]",
 		Short: "show the labels",
-		Run:   showLabelsCommandFunc,
+		RunE:  showLabelsCommandFunc,
 	}
 	l.AddCommand(NewLabelListStoresCommand())
 	return l
@@ -41,18 +41,17 @@ func NewLabelListStoresCommand() *cobra.Command {
 	l := &cobra.Command{
 		Use:   "store <name> [value]",
 		Short: "show the stores with specify label",
-		Run:   showLabelListStoresCommandFunc,
+		RunE:  showLabelListStoresCommandFunc,
 	}
 	return l
 }
 
-func showLabelsCommandFunc(cmd *cobra.Command, args []string) {
+func showLabelsCommandFunc(cmd *cobra.Command, args []string) error {
 	r, err := doRequest(cmd, labelsPrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get labels: %s\n", err)
-		return
+		return failf("Failed to get labels: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
 func getValue(args []string, i int) string {
@@ -62,18 +61,16 @@ func getValue(args []string, i int) string {
 	return args[i]
 }
 
-func showLabelListStoresCommandFunc(cmd *cobra.Command, args []string) {
+func showLabelListStoresCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) > 2 {
-		cmd.Println("Usage: label store name [value]")
-		return
+		return usageErrorln("Usage: label store name [value]")
 	}
 	namePrefix := fmt.Sprintf("name=%s", getValue(args, 0))
 	valuePrefix := fmt.Sprintf("value=%s", getValue(args, 1))
 	prefix := fmt.Sprintf("%s?%s&%s", labelsStorePrefix, namePrefix, valuePrefix)
 	r, err := doRequest(cmd, prefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get stores through label: %s\n", err)
-		return
+		return failf("Failed to get stores through label: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
@@ -17,12 +17,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	logPrefix = "pd/api/v1/admin/log"
+	logPrefix         = "pd/api/v1/admin/log"
+	logSamplingPrefix = "pd/api/v1/admin/log/sampling"
 )
 
 // NewLogCommand New a log subcommand of the rootCmd
@@ -30,28 +32,86 @@ func NewLogCommand() *cobra.Command {
 	conf := &cobra.Command{
 		Use:   "log [fatal|error|warn|info|debug]",
 		Short: "set log level",
-		Run:   logCommandFunc,
+		RunE:  logCommandFunc,
 	}
+	conf.AddCommand(NewLogSamplingCommand())
 	return conf
 }
 
-func logCommandFunc(cmd *cobra.Command, args []string) {
+// NewLogSamplingCommand returns a sampling subcommand of logCmd.
+func NewLogSamplingCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "sampling",
+		Short: "the sampling of the logs on the hot paths, like the rejected region heartbeats and the steps of the operators",
+	}
+	c.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "show the config of the log sampling and the number of the suppressed messages",
+		RunE:  showLogSamplingCommandFunc,
+	})
+	set := &cobra.Command{
+		Use:   "set [--enable=<bool>] [--interval=<duration>] [--initial=<count>] [--thereafter=<count>]",
+		Short: "in every interval, log the first initial messages of a key, then only every thereafter-th of them",
+		RunE:  setLogSamplingCommandFunc,
+	}
+	set.Flags().Bool("enable", true, "whether to sample the logs")
+	set.Flags().Duration("interval", time.Second, "the interval of sampling")
+	set.Flags().Uint64("initial", 100, "the number of the messages logged in an interval before sampling")
+	set.Flags().Uint64("thereafter", 100, "log every thereafter-th message after the initial ones")
+	c.AddCommand(set)
+	return c
+}
+
+func logCommandFunc(cmd *cobra.Command, args []string) error {
 	var err error
 	if len(args) != 1 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	data, err := json.Marshal(args[0])
 	if err != nil {
-		cmd.Printf("Failed to set log level: %s\n", err)
-		return
+		return failf("Failed to set log level: %s\n", err)
 	}
 	_, err = doRequest(cmd, logPrefix, http.MethodPost,
 		WithBody("application/json", bytes.NewBuffer(data)))
 	if err != nil {
-		cmd.Printf("Failed to set log level: %s\n", err)
-		return
+		return failf("Failed to set log level: %s\n", err)
 	}
 	cmd.Println("Success!")
+	return nil
+}
+
+func showLogSamplingCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	r, err := doRequest(cmd, logSamplingPrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get log sampling: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+func setLogSamplingCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	// Only the specified flags are changed.
+	input := make(map[string]interface{})
+	if cmd.Flags().Changed("enable") {
+		input["enable"], _ = cmd.Flags().GetBool("enable")
+	}
+	if cmd.Flags().Changed("interval") {
+		interval, _ := cmd.Flags().GetDuration("interval")
+		input["interval"] = interval.String()
+	}
+	for _, name := range []string{"initial", "thereafter"} {
+		if cmd.Flags().Changed(name) {
+			input[name], _ = cmd.Flags().GetUint64(name)
+		}
+	}
+	if len(input) == 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	return postJSON(cmd, logSamplingPrefix, input)
 }
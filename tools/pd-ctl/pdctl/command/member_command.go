@@ -17,30 +17,37 @@ import (
 	"bytes"
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"strconv"
 
+	"github.com/pingcap/errors"
 	"github.com/spf13/cobra"
+	"github.com/tikv/pd/pkg/apiutil/serverapi"
 )
 
 var (
 	membersPrefix      = "pd/api/v1/members"
 	leaderMemberPrefix = "pd/api/v1/leader"
+	drainPrefix        = "pd/api/v1/admin/drain"
 )
 
 // NewMemberCommand return a member subcommand of rootCmd
 func NewMemberCommand() *cobra.Command {
 	m := &cobra.Command{
-		Use:   "member [leader|delete|leader_priority]",
+		Use:   "member [leader|delete|leader_priority|drain|undrain]",
 		Short: "show the pd member status",
-		Run:   showMemberCommandFunc,
+		RunE:  showMemberCommandFunc,
 	}
 	m.AddCommand(NewLeaderMemberCommand())
 	m.AddCommand(NewDeleteMemberCommand())
+	m.AddCommand(NewDrainMemberCommand())
+	m.AddCommand(NewUndrainMemberCommand())
 
 	m.AddCommand(&cobra.Command{
-		Use:   "leader_priority <member_name> <priority>",
-		Short: "set the member's priority to be elected as etcd leader",
-		Run:   setLeaderPriorityFunc,
+		Use:     "leader_priority <member_name> <priority>",
+		Aliases: []string{"leader-priority"},
+		Short:   "set the member's priority to be elected as etcd leader, the member of the highest priority is elected, e.g. set a lower priority before the maintenance of the member",
+		RunE:    setLeaderPriorityFunc,
 	})
 	return m
 }
@@ -51,19 +58,40 @@ func NewDeleteMemberCommand() *cobra.Command {
 		Use:   "delete <subcommand>",
 		Short: "delete a member",
 	}
+	addConfirmFlag(d)
 	d.AddCommand(&cobra.Command{
 		Use:   "name <member_name>",
 		Short: "delete a member by name",
-		Run:   deleteMemberByNameCommandFunc,
+		RunE:  deleteMemberByNameCommandFunc,
 	})
 	d.AddCommand(&cobra.Command{
 		Use:   "id <member_id>",
 		Short: "delete a member by id",
-		Run:   deleteMemberByIDCommandFunc,
+		RunE:  deleteMemberByIDCommandFunc,
 	})
 	return d
 }
 
+// NewDrainMemberCommand return a drain subcommand of memberCmd
+func NewDrainMemberCommand() *cobra.Command {
+	d := &cobra.Command{
+		Use:   "drain <member_name>",
+		Short: "hand off the leadership of a member and wait until it is ready for shutdown",
+		RunE:  drainMemberCommandFunc,
+	}
+	d.Flags().String("timeout", "30s", "how long to wait for the in-flight requests")
+	return d
+}
+
+// NewUndrainMemberCommand return an undrain subcommand of memberCmd
+func NewUndrainMemberCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "undrain <member_name>",
+		Short: "cancel the draining of a member, it serves the requests and campaigns for the leadership again",
+		RunE:  undrainMemberCommandFunc,
+	}
+}
+
 // NewLeaderMemberCommand return a leader subcommand of memberCmd
 func NewLeaderMemberCommand() *cobra.Command {
 	d := &cobra.Command{
@@ -73,108 +101,166 @@ func NewLeaderMemberCommand() *cobra.Command {
 	d.AddCommand(&cobra.Command{
 		Use:   "show",
 		Short: "show the leader member status",
-		Run:   getLeaderMemberCommandFunc,
+		RunE:  getLeaderMemberCommandFunc,
 	})
 	d.AddCommand(&cobra.Command{
 		Use:   "resign",
 		Short: "resign current leader pd's leadership",
-		Run:   resignLeaderCommandFunc,
+		RunE:  resignLeaderCommandFunc,
 	})
 	d.AddCommand(&cobra.Command{
 		Use:   "transfer <member_name>",
 		Short: "transfer leadership to another pd",
-		Run:   transferPDLeaderCommandFunc,
+		RunE:  transferPDLeaderCommandFunc,
 	})
 	return d
 }
 
-func showMemberCommandFunc(cmd *cobra.Command, args []string) {
+func showMemberCommandFunc(cmd *cobra.Command, args []string) error {
 	r, err := doRequest(cmd, membersPrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get pd members: %s\n", err)
-		return
+		return failf("Failed to get pd members: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
-func deleteMemberByNameCommandFunc(cmd *cobra.Command, args []string) {
+func deleteMemberByNameCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Println("Usage: member delete <member_name>")
-		return
+		return usageErrorln("Usage: member delete <member_name>")
+	}
+	if err := confirm(cmd, "delete member "+args[0], args[0]); err != nil {
+		return err
 	}
 	prefix := membersPrefix + "/name/" + args[0]
 	_, err := doRequest(cmd, prefix, http.MethodDelete)
 	if err != nil {
-		cmd.Printf("Failed to delete member %s: %s\n", args[0], err)
-		return
+		return failf("Failed to delete member %s: %s\n", args[0], err)
 	}
 	cmd.Println("Success!")
+	return nil
 }
 
-func deleteMemberByIDCommandFunc(cmd *cobra.Command, args []string) {
+func deleteMemberByIDCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Println("Usage: member delete id <member_id>")
-		return
+		return usageErrorln("Usage: member delete id <member_id>")
+	}
+	if err := confirm(cmd, "delete member "+args[0], args[0]); err != nil {
+		return err
 	}
 	prefix := membersPrefix + "/id/" + args[0]
 	_, err := doRequest(cmd, prefix, http.MethodDelete)
 	if err != nil {
-		cmd.Printf("Failed to delete member %s: %s\n", args[0], err)
-		return
+		return failf("Failed to delete member %s: %s\n", args[0], err)
 	}
 	cmd.Println("Success!")
+	return nil
 }
 
-func getLeaderMemberCommandFunc(cmd *cobra.Command, args []string) {
+func getLeaderMemberCommandFunc(cmd *cobra.Command, args []string) error {
 	r, err := doRequest(cmd, leaderMemberPrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get the leader of pd members: %s\n", err)
-		return
+		return failf("Failed to get the leader of pd members: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
-func resignLeaderCommandFunc(cmd *cobra.Command, args []string) {
+func resignLeaderCommandFunc(cmd *cobra.Command, args []string) error {
 	prefix := leaderMemberPrefix + "/resign"
 	_, err := doRequest(cmd, prefix, http.MethodPost)
 	if err != nil {
-		cmd.Printf("Failed to resign: %s\n", err)
-		return
+		return failf("Failed to resign: %s\n", err)
 	}
 	cmd.Println("Success!")
+	return nil
 }
 
-func transferPDLeaderCommandFunc(cmd *cobra.Command, args []string) {
+func transferPDLeaderCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Println("Usage: leader transfer <member_name>")
-		return
+		return usageErrorln("Usage: leader transfer <member_name>")
 	}
 	prefix := leaderMemberPrefix + "/transfer/" + args[0]
 	_, err := doRequest(cmd, prefix, http.MethodPost)
 	if err != nil {
-		cmd.Printf("Failed to transfer leadership: %s\n", err)
-		return
+		return failf("Failed to transfer leadership: %s\n", err)
 	}
 	cmd.Println("Success!")
+	return nil
 }
 
-func setLeaderPriorityFunc(cmd *cobra.Command, args []string) {
+func setLeaderPriorityFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 2 {
-		cmd.Println("Usage: leader_priority <member_name> <priority>")
-		return
+		return usageErrorln("Usage: leader_priority <member_name> <priority>")
 	}
 	prefix := membersPrefix + "/name/" + args[0]
 	priority, err := strconv.ParseFloat(args[1], 64)
 	if err != nil {
-		cmd.Printf("failed to parse priority: %v\n", err)
-		return
+		return usageErrorf("failed to parse priority: %v\n", err)
 	}
 	data := map[string]interface{}{"leader-priority": priority}
 	reqData, _ := json.Marshal(data)
 	_, err = doRequest(cmd, prefix, http.MethodPost, WithBody("application/json", bytes.NewBuffer(reqData)))
 	if err != nil {
-		cmd.Printf("failed to set leader priority: %v\n", err)
-		return
+		return failf("failed to set leader priority: %v\n", err)
 	}
 	cmd.Println("Success!")
+	return nil
+}
+
+func drainMemberCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return usageErrorln("Usage: member drain <member_name>")
+	}
+	timeout, _ := cmd.Flags().GetString("timeout")
+	r, err := requestMember(cmd, args[0], http.MethodPost, drainPrefix+"?timeout="+url.QueryEscape(timeout))
+	if err != nil {
+		return failf("Failed to drain member %s: %s\n", args[0], err)
+	}
+	return printResponse(cmd, r)
+}
+
+func undrainMemberCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return usageErrorln("Usage: member undrain <member_name>")
+	}
+	r, err := requestMember(cmd, args[0], http.MethodDelete, drainPrefix)
+	if err != nil {
+		return failf("Failed to undrain member %s: %s\n", args[0], err)
+	}
+	return printResponse(cmd, r)
+}
+
+// requestMember sends the request to the member itself rather than the leader.
+func requestMember(cmd *cobra.Command, name, method, prefix string) (string, error) {
+	r, err := doRequest(cmd, membersPrefix, http.MethodGet)
+	if err != nil {
+		return "", err
+	}
+	members := struct {
+		Members []struct {
+			Name       string   `json:"name"`
+			ClientUrls []string `json:"client_urls"`
+		} `json:"members"`
+	}{}
+	if err = json.Unmarshal([]byte(r), &members); err != nil {
+		return "", err
+	}
+	var endpoints []string
+	for _, m := range members.Members {
+		if m.Name == name {
+			endpoints = m.ClientUrls
+		}
+	}
+	if len(endpoints) == 0 {
+		return "", errors.New("member not found")
+	}
+	err = tryURLs(cmd, endpoints, func(endpoint string) error {
+		req, err := newRequest(cmd, method, endpoint+"/"+prefix, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(serverapi.AllowFollowerHandle, "true")
+		r, err = dial(req)
+		return err
+	})
+	return r, err
 }
@@ -0,0 +1,118 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+)
+
+type requestCommandKey struct{}
+
+// The metrics are registered to a registry of pd-ctl, so the metrics of the
+// PD server registered to the default registry are not exposed.
+var (
+	metricsRegistry = prometheus.NewRegistry()
+
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "pd_ctl",
+			Subsystem: "request",
+			Name:      "duration_seconds",
+			Help:      "Bucketed histogram of the time until the response headers of the requests to PD are received.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 16), // 1ms ~ 32s
+		}, []string{"command", "method"})
+
+	requestCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd_ctl",
+			Subsystem: "request",
+			Name:      "total",
+			Help:      "Counter of the requests to PD by the status codes, the code is error if no response is received.",
+		}, []string{"command", "method", "code"})
+
+	requestErrorCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd_ctl",
+			Subsystem: "request",
+			Name:      "errors_total",
+			Help:      "Counter of the failed requests to PD, the type is network, client for 4xx or server for 5xx.",
+		}, []string{"command", "type"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(requestDuration)
+	metricsRegistry.MustRegister(requestCounter)
+	metricsRegistry.MustRegister(requestErrorCounter)
+}
+
+var metricsServer struct {
+	sync.Mutex
+	addr string
+}
+
+// ServeMetrics exposes the metrics of the requests to PD on the address at
+// /metrics, it is used to observe pd-ctl running in the watch or interactive
+// mode. The server is started only once, it returns the address actually
+// listened on, which is useful if the port is 0.
+func ServeMetrics(addr string) (string, error) {
+	metricsServer.Lock()
+	defer metricsServer.Unlock()
+	if metricsServer.addr != "" {
+		return metricsServer.addr, nil
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+	go http.Serve(l, mux) // nolint:errcheck
+	metricsServer.addr = l.Addr().String()
+	return metricsServer.addr, nil
+}
+
+// requestCommand returns the command sending the requests, the root command
+// is omitted, like `store limit`.
+func requestCommand(cmd *cobra.Command) string {
+	return strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()))
+}
+
+// observeRequest updates the metrics of the request, the response is nil if
+// the request fails.
+func observeRequest(req *http.Request, resp *http.Response, start time.Time) {
+	command, _ := req.Context().Value(requestCommandKey{}).(string)
+	requestDuration.WithLabelValues(command, req.Method).Observe(time.Since(start).Seconds())
+	if resp == nil {
+		requestCounter.WithLabelValues(command, req.Method, "error").Inc()
+		requestErrorCounter.WithLabelValues(command, "network").Inc()
+		return
+	}
+	requestCounter.WithLabelValues(command, req.Method, strconv.Itoa(resp.StatusCode)).Inc()
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		requestErrorCounter.WithLabelValues(command, "server").Inc()
+	// The two-phase confirmation of the destructive requests is expected.
+	case resp.StatusCode >= http.StatusBadRequest && resp.StatusCode != http.StatusPreconditionRequired:
+		requestErrorCounter.WithLabelValues(command, "client").Inc()
+	}
+}
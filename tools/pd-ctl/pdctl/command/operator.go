@@ -14,9 +14,13 @@
 package command
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/pingcap/errors"
 	"github.com/spf13/cobra"
@@ -41,7 +45,9 @@ func NewOperatorCommand() *cobra.Command {
 	c.AddCommand(NewShowOperatorCommand())
 	c.AddCommand(NewCheckOperatorCommand())
 	c.AddCommand(NewAddOperatorCommand())
+	c.AddCommand(NewAddBulkOperatorCommand())
 	c.AddCommand(NewRemoveOperatorCommand())
+	c.AddCommand(NewOperatorHistoryCommand())
 	return c
 }
 
@@ -50,7 +56,7 @@ func NewCheckOperatorCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "check [region_id]",
 		Short: "checks the status of operator",
-		Run:   checkOperatorCommandFunc,
+		RunE:  checkOperatorCommandFunc,
 	}
 	return c
 }
@@ -60,55 +66,121 @@ func NewShowOperatorCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "show [kind]",
 		Short: "show operators",
-		Run:   showOperatorCommandFunc,
+		RunE:  showOperatorCommandFunc,
 	}
+	c.Flags().String("creator", "", "only show the operators created by the scheduler or checker, use manual for the operators added by users")
+	c.Flags().Duration("min-age", 0, "only show the operators created before the duration, like 10m")
+	c.Flags().String("sort-by", "", "sort the operators by age, region or creator")
 	return c
 }
 
-func showOperatorCommandFunc(cmd *cobra.Command, args []string) {
-	var path string
-	if len(args) == 0 {
-		path = operatorsPrefix
-	} else if len(args) == 1 {
-		path = fmt.Sprintf("%s?kind=%s", operatorsPrefix, args[0])
-	} else {
-		cmd.Println(cmd.UsageString())
-		return
+func showOperatorCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return usageErrorln(cmd.UsageString())
+	}
+	query := url.Values{}
+	if len(args) == 1 {
+		query.Set("kind", args[0])
+	}
+	if creator, _ := cmd.Flags().GetString("creator"); creator != "" {
+		query.Set("creator", creator)
+	}
+	if minAge, _ := cmd.Flags().GetDuration("min-age"); minAge > 0 {
+		query.Set("min_age", minAge.String())
+	}
+	if by, _ := cmd.Flags().GetString("sort-by"); by != "" {
+		query.Set("sort", by)
+	}
+	path := operatorsPrefix
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	r, err := doRequest(cmd, path, http.MethodGet)
+	if err != nil {
+		return failln(err)
+	}
+	return printResponse(cmd, r)
+}
+
+// NewOperatorHistoryCommand returns a command to show the finished operators.
+func NewOperatorHistoryCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "history [--region <region_id>] [--since <duration>] [--until <duration>] [--start-key <key>] [--end-key <key>] [--format=raw|encode|hex]",
+		Short: "show the operators finished recently, including the canceled and timeout ones",
+		Long:  "show the operators finished recently, including the canceled and timeout ones. With --start-key and --end-key, it shows the operators of the regions overlapping the key range, like `operator history --start-key=<table start> --end-key=<table end> --since=24h` for what moved the table last night",
+		RunE:  showOperatorHistoryCommandFunc,
+	}
+	c.Flags().Uint64("region", 0, "only show the operators of the region")
+	c.Flags().Duration("since", 0, "only show the operators finished within the duration, like 24h")
+	c.Flags().Duration("until", 0, "only show the operators finished before the duration ago, like 12h")
+	c.Flags().String("start-key", "", "only show the operators of the regions overlapping the key range from the key")
+	c.Flags().String("end-key", "", "only show the operators of the regions overlapping the key range to the key, the end of the key space if it is empty")
+	c.Flags().String("format", "hex", "the key format")
+	return c
+}
+
+func showOperatorHistoryCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	query := url.Values{}
+	if regionID, _ := cmd.Flags().GetUint64("region"); regionID != 0 {
+		query.Set("region_id", strconv.FormatUint(regionID, 10))
+	}
+	if since, _ := cmd.Flags().GetDuration("since"); since > 0 {
+		query.Set("since", since.String())
+	}
+	if until, _ := cmd.Flags().GetDuration("until"); until > 0 {
+		query.Set("until", until.String())
+	}
+	for _, name := range []string{"start-key", "end-key"} {
+		key, err := parseKey(cmd.Flags(), cmd.Flag(name).Value.String())
+		if err != nil {
+			return usageErrorln("Error: ", err)
+		}
+		if key != "" {
+			query.Set(strings.Replace(name, "-", "_", 1), key)
+		}
+	}
+	path := operatorsPrefix + "/history"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
 	}
 
 	r, err := doRequest(cmd, path, http.MethodGet)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
-func checkOperatorCommandFunc(cmd *cobra.Command, args []string) {
+func checkOperatorCommandFunc(cmd *cobra.Command, args []string) error {
 	var path string
 	if len(args) == 0 {
 		path = operatorsPrefix
 	} else if len(args) == 1 {
 		path = fmt.Sprintf("%s/%s", operatorsPrefix, args[0])
 	} else {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	r, err := doRequest(cmd, path, http.MethodGet)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
 // NewAddOperatorCommand returns a command to add operators.
 func NewAddOperatorCommand() *cobra.Command {
 	c := &cobra.Command{
-		Use:   "add <operator>",
+		Use:   "add <operator> [--wait [--progress=text|json]]",
 		Short: "add an operator",
+		// The operator is waited after it is added by the subcommands.
+		PersistentPostRunE: waitOperatorCommandFunc,
 	}
+	addWaitFlags(c.PersistentFlags(), "")
 	c.AddCommand(NewTransferLeaderCommand())
 	c.AddCommand(NewTransferRegionCommand())
 	c.AddCommand(NewTransferPeerCommand())
@@ -117,37 +189,77 @@ func NewAddOperatorCommand() *cobra.Command {
 	c.AddCommand(NewRemovePeerCommand())
 	c.AddCommand(NewMergeRegionCommand())
 	c.AddCommand(NewSplitRegionCommand())
+	c.AddCommand(NewSplitRegionByKeyCommand())
 	c.AddCommand(NewScatterRegionCommand())
 	return c
 }
 
+// waitOperatorCommandFunc waits until the operator of the region is finished,
+// the first argument of the subcommands is the region id, except the split
+// keys of split-region-by-key which may create several operators.
+func waitOperatorCommandFunc(cmd *cobra.Command, args []string) error {
+	wait, err := shouldWait(cmd)
+	if err != nil {
+		return failln(err)
+	}
+	if !wait || len(args) == 0 || cmd.Name() == "split-region-by-key" {
+		return nil
+	}
+	if _, err := strconv.ParseUint(args[0], 10, 64); err != nil {
+		return nil
+	}
+	var status string
+	if err := waitFor(cmd, func() (float64, string, bool, error) {
+		r, err := doRequest(cmd, operatorsPrefix+"/"+args[0], http.MethodGet)
+		if err != nil {
+			return 0, "", false, err
+		}
+		// The operator is shown as "status: <status>, operator: <operator>".
+		var desc string
+		if err := json.Unmarshal([]byte(r), &desc); err != nil {
+			return 0, "", false, errors.WithStack(err)
+		}
+		status = strings.TrimPrefix(strings.SplitN(desc, ",", 2)[0], "status: ")
+		switch status {
+		case "RUNNING":
+			return 0, status, false, nil
+		case "SUCCESS":
+			return 100, status, true, nil
+		default:
+			return 0, status, true, nil
+		}
+	}); err != nil {
+		return failf("Failed to wait the operator of region %s: %s\n", args[0], err)
+	}
+	cmd.Printf("The operator of region %s is finished with status %s\n", args[0], status)
+	return nil
+}
+
 // NewTransferLeaderCommand returns a command to transfer leader.
 func NewTransferLeaderCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "transfer-leader <region_id> <to_store_id>",
 		Short: "transfer a region's leader to the specified store",
-		Run:   transferLeaderCommandFunc,
+		RunE:  transferLeaderCommandFunc,
 	}
 	return c
 }
 
-func transferLeaderCommandFunc(cmd *cobra.Command, args []string) {
+func transferLeaderCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 2 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	ids, err := parseUint64s(args)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 
 	input := make(map[string]interface{})
 	input["name"] = cmd.Name()
 	input["region_id"] = ids[0]
 	input["to_store_id"] = ids[1]
-	postJSON(cmd, operatorsPrefix, input)
+	return postJSON(cmd, operatorsPrefix, input)
 }
 
 // NewTransferRegionCommand returns a command to transfer region.
@@ -155,26 +267,23 @@ func NewTransferRegionCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "transfer-region <region_id> <to_store_id> [leader|voter|follower|learner] ...",
 		Short: "transfer a region's peers to the specified stores",
-		Run:   transferRegionCommandFunc,
+		RunE:  transferRegionCommandFunc,
 	}
 	return c
 }
 
-func transferRegionCommandFunc(cmd *cobra.Command, args []string) {
+func transferRegionCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) <= 2 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	ids, roles, err := parseUit64sAndPeerRole(args)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 
 	if len(roles) > 0 && len(roles)+1 != len(ids) {
-		cmd.Println("peer role is not match with store")
-		return
+		return usageErrorln("peer role is not match with store")
 	}
 
 	input := make(map[string]interface{})
@@ -184,7 +293,7 @@ func transferRegionCommandFunc(cmd *cobra.Command, args []string) {
 	if len(roles) > 0 {
 		input["peer_roles"] = roles
 	}
-	postJSON(cmd, operatorsPrefix, input)
+	return postJSON(cmd, operatorsPrefix, input)
 }
 
 // NewTransferPeerCommand returns a command to transfer region.
@@ -192,21 +301,19 @@ func NewTransferPeerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "transfer-peer <region_id> <from_store_id> <to_store_id>",
 		Short: "transfer a region's peer from the specified store to another store",
-		Run:   transferPeerCommandFunc,
+		RunE:  transferPeerCommandFunc,
 	}
 	return c
 }
 
-func transferPeerCommandFunc(cmd *cobra.Command, args []string) {
+func transferPeerCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 3 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	ids, err := parseUint64s(args)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 
 	input := make(map[string]interface{})
@@ -214,7 +321,7 @@ func transferPeerCommandFunc(cmd *cobra.Command, args []string) {
 	input["region_id"] = ids[0]
 	input["from_store_id"] = ids[1]
 	input["to_store_id"] = ids[2]
-	postJSON(cmd, operatorsPrefix, input)
+	return postJSON(cmd, operatorsPrefix, input)
 }
 
 // NewAddPeerCommand returns a command to add region peer.
@@ -222,28 +329,26 @@ func NewAddPeerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "add-peer <region_id> <to_store_id>",
 		Short: "add a region peer on specified store",
-		Run:   addPeerCommandFunc,
+		RunE:  addPeerCommandFunc,
 	}
 	return c
 }
 
-func addPeerCommandFunc(cmd *cobra.Command, args []string) {
+func addPeerCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 2 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	ids, err := parseUint64s(args)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 
 	input := make(map[string]interface{})
 	input["name"] = cmd.Name()
 	input["region_id"] = ids[0]
 	input["store_id"] = ids[1]
-	postJSON(cmd, operatorsPrefix, input)
+	return postJSON(cmd, operatorsPrefix, input)
 }
 
 // NewAddLearnerCommand returns a command to add region learner.
@@ -251,28 +356,26 @@ func NewAddLearnerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "add-learner <region_id> <to_store_id>",
 		Short: "add a region learner on specified store",
-		Run:   addLearnerCommandFunc,
+		RunE:  addLearnerCommandFunc,
 	}
 	return c
 }
 
-func addLearnerCommandFunc(cmd *cobra.Command, args []string) {
+func addLearnerCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 2 {
-		fmt.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	ids, err := parseUint64s(args)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return failln(err)
 	}
 
 	input := make(map[string]interface{})
 	input["name"] = cmd.Name()
 	input["region_id"] = ids[0]
 	input["store_id"] = ids[1]
-	postJSON(cmd, operatorsPrefix, input)
+	return postJSON(cmd, operatorsPrefix, input)
 }
 
 // NewMergeRegionCommand returns a command to merge two regions.
@@ -280,28 +383,26 @@ func NewMergeRegionCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "merge-region <source_region_id> <target_region_id>",
 		Short: "merge source region into target region",
-		Run:   mergeRegionCommandFunc,
+		RunE:  mergeRegionCommandFunc,
 	}
 	return c
 }
 
-func mergeRegionCommandFunc(cmd *cobra.Command, args []string) {
+func mergeRegionCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 2 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	ids, err := parseUint64s(args)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 
 	input := make(map[string]interface{})
 	input["name"] = cmd.Name()
 	input["source_region_id"] = ids[0]
 	input["target_region_id"] = ids[1]
-	postJSON(cmd, operatorsPrefix, input)
+	return postJSON(cmd, operatorsPrefix, input)
 }
 
 // NewRemovePeerCommand returns a command to add region peer.
@@ -309,28 +410,26 @@ func NewRemovePeerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "remove-peer <region_id> <from_store_id>",
 		Short: "remove a region peer on specified store",
-		Run:   removePeerCommandFunc,
+		RunE:  removePeerCommandFunc,
 	}
 	return c
 }
 
-func removePeerCommandFunc(cmd *cobra.Command, args []string) {
+func removePeerCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 2 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	ids, err := parseUint64s(args)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 
 	input := make(map[string]interface{})
 	input["name"] = cmd.Name()
 	input["region_id"] = ids[0]
 	input["store_id"] = ids[1]
-	postJSON(cmd, operatorsPrefix, input)
+	return postJSON(cmd, operatorsPrefix, input)
 }
 
 // NewSplitRegionCommand returns a command to split a region.
@@ -338,22 +437,20 @@ func NewSplitRegionCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "split-region <region_id> [--policy=scan|approximate]",
 		Short: "split a region",
-		Run:   splitRegionCommandFunc,
+		RunE:  splitRegionCommandFunc,
 	}
 	c.Flags().String("policy", "scan", "the policy to get region split key")
 	return c
 }
 
-func splitRegionCommandFunc(cmd *cobra.Command, args []string) {
+func splitRegionCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	ids, err := parseUint64s(args)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 
 	policy := cmd.Flags().Lookup("policy").Value.String()
@@ -361,15 +458,45 @@ func splitRegionCommandFunc(cmd *cobra.Command, args []string) {
 	case "scan", "approximate":
 		break
 	default:
-		cmd.Println("Error: unknown policy")
-		return
+		return usageErrorln("Error: unknown policy")
 	}
 
 	input := make(map[string]interface{})
 	input["name"] = cmd.Name()
 	input["region_id"] = ids[0]
 	input["policy"] = policy
-	postJSON(cmd, operatorsPrefix, input)
+	return postJSON(cmd, operatorsPrefix, input)
+}
+
+// NewSplitRegionByKeyCommand returns a command to split the regions at keys.
+func NewSplitRegionByKeyCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "split-region-by-key <key>... [--format=raw|encode|hex]",
+		Short: "split the regions containing the keys at the keys, e.g. to pre-split the boundaries before a bulk load",
+		RunE:  splitRegionByKeyCommandFunc,
+	}
+	c.Flags().String("format", "hex", "the key format")
+	return c
+}
+
+func splitRegionByKeyCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+
+	keys := make([]string, 0, len(args))
+	for _, arg := range args {
+		key, err := parseKey(cmd.Flags(), arg)
+		if err != nil {
+			return usageErrorln("Error: ", err)
+		}
+		keys = append(keys, hex.EncodeToString([]byte(key)))
+	}
+
+	input := make(map[string]interface{})
+	input["name"] = cmd.Name()
+	input["keys"] = keys
+	return postJSON(cmd, operatorsPrefix, input)
 }
 
 // NewScatterRegionCommand returns a command to scatter a region.
@@ -378,27 +505,25 @@ func NewScatterRegionCommand() *cobra.Command {
 		Use:   "scatter-region <region_id>",
 		Short: "usually used for a batch of adjacent regions",
 		Long:  "usually used for a batch of adjacent regions, for example, scatter the regions for 1 to 100, need to use the following commands in order: \"scatter-region 1; scatter-region 2; ...; scatter-region 100;\"",
-		Run:   scatterRegionCommandFunc,
+		RunE:  scatterRegionCommandFunc,
 	}
 	return c
 }
 
-func scatterRegionCommandFunc(cmd *cobra.Command, args []string) {
+func scatterRegionCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	ids, err := parseUint64s(args)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 
 	input := make(map[string]interface{})
 	input["name"] = cmd.Name()
 	input["region_id"] = ids[0]
-	postJSON(cmd, operatorsPrefix, input)
+	return postJSON(cmd, operatorsPrefix, input)
 }
 
 // NewRemoveOperatorCommand returns a command to remove operators.
@@ -406,24 +531,23 @@ func NewRemoveOperatorCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "remove <region_id>",
 		Short: "remove the region operator",
-		Run:   removeOperatorCommandFunc,
+		RunE:  removeOperatorCommandFunc,
 	}
 	return c
 }
 
-func removeOperatorCommandFunc(cmd *cobra.Command, args []string) {
+func removeOperatorCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	path := operatorsPrefix + "/" + args[0]
 	_, err := doRequest(cmd, path, http.MethodDelete)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 	cmd.Println("Success!")
+	return nil
 }
 
 func parseUint64s(args []string) ([]uint64, error) {
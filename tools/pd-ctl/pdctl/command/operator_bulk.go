@@ -0,0 +1,174 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/itchyny/gojq"
+	"github.com/pingcap/errors"
+	"github.com/spf13/cobra"
+)
+
+// bulkActions are the operators which can be added by `operator add-bulk`,
+// and the store flag of each of them.
+var bulkActions = map[string]string{
+	"transfer-leader": "to-store",
+	"add-peer":        "to-store",
+	"add-learner":     "to-store",
+	"remove-peer":     "from-store",
+}
+
+// NewAddBulkOperatorCommand returns a command to add operators to the regions
+// selected by a jq filter.
+func NewAddBulkOperatorCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "add-bulk --select-jq=<filter> --action=transfer-leader|add-peer|add-learner|remove-peer [--to-store=<store_id>] [--from-store=<store_id>] [--limit=<count>] [--dry-run] [--yes]",
+		Short: "add an operator to each region selected by the jq filter, e.g. `add-bulk --select-jq='.leader.store_id == 1' --action=transfer-leader --to-store=2`, the filter is evaluated against every region of `region` and selects the region if its result is neither false nor null",
+		RunE:  addBulkOperatorCommandFunc,
+	}
+	c.Flags().String("select-jq", "", "the jq filter to select the regions")
+	c.Flags().String("action", "", "the operator to add, one of transfer-leader, add-peer, add-learner and remove-peer")
+	c.Flags().Uint64("to-store", 0, "the target store of transfer-leader, add-peer and add-learner")
+	c.Flags().Uint64("from-store", 0, "the source store of remove-peer")
+	c.Flags().Int("limit", 100, "refuse to add the operators if more regions are selected")
+	c.Flags().Bool("dry-run", false, "only show the selected regions")
+	addConfirmFlag(c)
+	return c
+}
+
+func addBulkOperatorCommandFunc(cmd *cobra.Command, args []string) error {
+	filter, _ := cmd.Flags().GetString("select-jq")
+	action, _ := cmd.Flags().GetString("action")
+	if len(args) != 0 || filter == "" || action == "" {
+		return usageErrorln(cmd.UsageString())
+	}
+	storeFlag, ok := bulkActions[action]
+	if !ok {
+		return usageErrorf("Invalid action %s, should be one of transfer-leader, add-peer, add-learner and remove-peer\n", action)
+	}
+	storeID, _ := cmd.Flags().GetUint64(storeFlag)
+	if storeID == 0 {
+		return usageErrorf("--%s is required by %s\n", storeFlag, action)
+	}
+	query, err := gojq.Parse(filter)
+	if err != nil {
+		return usageErrorf("Failed to parse jq query: %s\n", err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return usageErrorf("Failed to compile jq query: %s\n", err)
+	}
+
+	r, err := doRequest(cmd, regionsPrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get regions: %s\n", err)
+	}
+	regionIDs, err := selectRegions(r, code)
+	if err != nil {
+		return failf("Failed to select regions: %s\n", err)
+	}
+	if len(regionIDs) == 0 {
+		cmd.Println("No region is selected.")
+		return nil
+	}
+	ids := make([]string, 0, len(regionIDs))
+	for _, id := range regionIDs {
+		ids = append(ids, strconv.FormatUint(id, 10))
+	}
+	cmd.Printf("%d regions are selected: %s\n", len(regionIDs), strings.Join(ids, ", "))
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		return nil
+	}
+	if limit, _ := cmd.Flags().GetInt("limit"); len(regionIDs) > limit {
+		return failf("Failed! The selected regions exceed the limit %d, narrow down the filter or raise --limit\n", limit)
+	}
+	target := fmt.Sprintf("to store %d", storeID)
+	if storeFlag == "from-store" {
+		target = fmt.Sprintf("from store %d", storeID)
+	}
+	count := strconv.Itoa(len(regionIDs))
+	if err := confirm(cmd, fmt.Sprintf("add %s operators %s for %s regions", action, target, count), count); err != nil {
+		return err
+	}
+
+	var failed int
+	for _, id := range regionIDs {
+		input := map[string]interface{}{
+			"name":      action,
+			"region_id": id,
+		}
+		if action == "transfer-leader" {
+			input["to_store_id"] = storeID
+		} else {
+			input["store_id"] = storeID
+		}
+		data, err := json.Marshal(input)
+		if err != nil {
+			return failln(err)
+		}
+		if _, err := doRequest(cmd, operatorsPrefix, http.MethodPost, WithBody("application/json", bytes.NewBuffer(data))); err != nil {
+			printErrf(cmd, "Failed to add operator for region %d: %s\n", id, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return failf("Failed! %d of %d operators are added\n", len(regionIDs)-failed, len(regionIDs))
+	}
+	cmd.Printf("Success! %d operators are added\n", len(regionIDs))
+	return nil
+}
+
+// selectRegions returns the IDs of the regions in the response of the region
+// list which are selected by the jq filter, the region is selected if any
+// result of the filter is neither false nor null.
+func selectRegions(data string, code *gojq.Code) ([]uint64, error) {
+	var regions struct {
+		Regions []map[string]interface{} `json:"regions"`
+	}
+	if err := json.Unmarshal([]byte(data), &regions); err != nil {
+		return nil, err
+	}
+	var ids []uint64
+	for _, region := range regions.Regions {
+		selected := false
+		iter := code.Run(region)
+		for {
+			v, ok := iter.Next()
+			if !ok {
+				break
+			}
+			if err, ok := v.(error); ok {
+				return nil, err
+			}
+			if v != nil && v != false {
+				selected = true
+			}
+		}
+		if !selected {
+			continue
+		}
+		id, ok := region["id"].(float64)
+		if !ok {
+			return nil, errors.Errorf("invalid region id %v", region["id"])
+		}
+		ids = append(ids, uint64(id))
+	}
+	return ids, nil
+}
@@ -0,0 +1,559 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pingcap/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// The formats supported by the output flag.
+const (
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+	OutputTable = "table"
+	OutputCSV   = "csv"
+)
+
+// orderedObject is a JSON object which keeps the order of its fields, so that
+// the columns and keys are rendered in the order returned by PD.
+type orderedObject []orderedField
+
+type orderedField struct {
+	key   string
+	value interface{}
+}
+
+// printResponse renders the response of PD in the format specified by the
+// output flag. The response which is not JSON is printed as it is.
+func printResponse(cmd *cobra.Command, data string) error {
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = OutputJSON
+	}
+	sortBy, _ := cmd.Flags().GetString("sort")
+	human, _ := cmd.Flags().GetBool("human")
+	// Only the region commands have the decode flag.
+	decode, _ := cmd.Flags().GetBool("decode")
+	out, err := renderOutput(data, format, sortBy, human, decode)
+	if err != nil {
+		return failf("Failed to render the output: %s\n", err)
+	}
+	cmd.Println(out)
+	return nil
+}
+
+// renderOutput renders the data in the format. The list is sorted by the field
+// if it is not empty, so that the outputs of successive commands can be
+// compared. The sizes and flows are rendered with units if human is set, they
+// are sorted by the raw values. The regions are annotated with the table and
+// index if decode is set.
+func renderOutput(data, format, sortBy string, human, decode bool) (string, error) {
+	switch format {
+	case OutputJSON, OutputYAML, OutputTable, OutputCSV:
+	default:
+		return "", errors.Errorf("unknown output format %s, should be one of %s, %s, %s and %s", format, OutputTable, OutputJSON, OutputYAML, OutputCSV)
+	}
+	trimmed := strings.TrimSpace(data)
+	v, err := decodeOrdered(trimmed)
+	if err != nil {
+		return data, nil
+	}
+	if decode {
+		v = annotateRegions(v)
+	}
+	if sortBy != "" {
+		if err := sortList(v, sortBy); err != nil {
+			return "", err
+		}
+	}
+	if human {
+		humanize(v)
+	}
+	if sortBy != "" || human || decode {
+		var buf bytes.Buffer
+		writeCompact(&buf, v)
+		trimmed = buf.String()
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case OutputJSON:
+		if err := json.Indent(&buf, []byte(trimmed), "", "  "); err != nil {
+			return "", err
+		}
+	case OutputYAML:
+		b, err := yaml.Marshal(toYAML(v))
+		if err != nil {
+			return "", err
+		}
+		buf.Write(bytes.TrimRight(b, "\n"))
+	case OutputTable:
+		writeTable(&buf, v)
+	case OutputCSV:
+		if err := writeCSV(&buf, v); err != nil {
+			return "", err
+		}
+		return strings.TrimRight(buf.String(), "\n"), nil
+	}
+	return buf.String(), nil
+}
+
+// regionFlowInterval is the interval in seconds of the region heartbeats, the
+// flows of the regions are the bytes in the interval.
+const regionFlowInterval = 60
+
+// humanFields are the fields rendered with units by `--human`, the sizes of
+// the regions and stores are in MiB.
+var humanFields = map[string]func(float64) string{
+	"written_bytes":    formatFlow,
+	"read_bytes":       formatFlow,
+	"approximate_size": formatSize,
+	"region_size":      formatSize,
+	"leader_size":      formatSize,
+}
+
+func formatFlow(b float64) string {
+	return fmt.Sprintf("%.2f MiB/s", b/regionFlowInterval/(1<<20))
+}
+
+func formatSize(mib float64) string {
+	return fmt.Sprintf("%.2f GiB", mib/(1<<10))
+}
+
+// humanize replaces the numbers of humanFields with the strings with units in
+// place.
+func humanize(v interface{}) {
+	switch v := v.(type) {
+	case orderedObject:
+		for i, f := range v {
+			if format, ok := humanFields[f.key]; ok {
+				if n, ok := f.value.(json.Number); ok {
+					if x, err := n.Float64(); err == nil {
+						v[i].value = format(x)
+						continue
+					}
+				}
+			}
+			humanize(f.value)
+		}
+	case []interface{}:
+		for _, e := range v {
+			humanize(e)
+		}
+	}
+}
+
+func decodeOrdered(data string) (interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return v, nil
+}
+
+func decodeValue(dec *json.Decoder) (interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t {
+	case json.Delim('{'):
+		obj := orderedObject{}
+		for dec.More() {
+			k, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, orderedField{key: k.(string), value: v})
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return arr, nil
+	}
+	return t, nil
+}
+
+func toYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case orderedObject:
+		m := make(yaml.MapSlice, 0, len(v))
+		for _, f := range v {
+			m = append(m, yaml.MapItem{Key: f.key, Value: toYAML(f.value)})
+		}
+		return m
+	case []interface{}:
+		arr := make([]interface{}, 0, len(v))
+		for _, e := range v {
+			arr = append(arr, toYAML(e))
+		}
+		return arr
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	}
+	return v
+}
+
+// writeTable renders a list as a table with a row for each element. The list
+// can be wrapped in an object, like the regions and the stores. Other objects
+// are rendered as a table of keys and values.
+func writeTable(w io.Writer, v interface{}) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+
+	rows, ok := v.([]interface{})
+	if !ok {
+		rows, ok = unwrapList(v)
+	}
+	if !ok {
+		fmt.Fprintln(tw, "KEY\tVALUE")
+		fields := orderedObject{}
+		flatten("", v, &fields)
+		for _, f := range fields {
+			fmt.Fprintf(tw, "%s\t%s\n", f.key, formatCell(f.value))
+		}
+		return
+	}
+
+	columns, flattened := flattenRows(rows, false)
+	if len(columns) == 0 {
+		return
+	}
+	header := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if c == "" {
+			c = "value"
+		}
+		header = append(header, strings.ToUpper(c))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, cells := range flattened {
+		line := make([]string, 0, len(columns))
+		for _, c := range columns {
+			line = append(line, formatCell(cells[c]))
+		}
+		fmt.Fprintln(tw, strings.Join(line, "\t"))
+	}
+}
+
+// writeCSV renders a list as CSV rows like writeTable, so that it can be
+// imported into spreadsheets. The lists of objects in a row, like the peers of
+// a region, are flattened into the columns of their fields, like
+// `peers.store_id`, whose values are joined by spaces.
+func writeCSV(w io.Writer, v interface{}) error {
+	cw := csv.NewWriter(w)
+	rows, ok := v.([]interface{})
+	if !ok {
+		rows, ok = unwrapList(v)
+	}
+	if !ok {
+		rows = []interface{}{v}
+	}
+	columns, flattened := flattenRows(rows, true)
+	if len(columns) == 0 {
+		return nil
+	}
+	header := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if c == "" {
+			c = "value"
+		}
+		header = append(header, c)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, cells := range flattened {
+		line := make([]string, 0, len(columns))
+		for _, c := range columns {
+			line = append(line, formatCell(cells[c]))
+		}
+		if err := cw.Write(line); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// flattenRows flattens each row and returns the union of their columns in the
+// order they first appear.
+func flattenRows(rows []interface{}, joinLists bool) ([]string, []map[string]interface{}) {
+	var columns []string
+	seen := make(map[string]struct{})
+	flattened := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		fields := orderedObject{}
+		if joinLists {
+			flattenJoined("", row, &fields)
+		} else {
+			flatten("", row, &fields)
+		}
+		cells := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			if _, ok := seen[f.key]; !ok {
+				seen[f.key] = struct{}{}
+				columns = append(columns, f.key)
+			}
+			cells[f.key] = f.value
+		}
+		flattened = append(flattened, cells)
+	}
+	return columns, flattened
+}
+
+// unwrapList returns the only list field of an object, such as the regions in
+// `{"count": 1, "regions": [...]}`. The object with nested objects, like a
+// region with its peers, is not a wrapper.
+func unwrapList(v interface{}) ([]interface{}, bool) {
+	obj, ok := v.(orderedObject)
+	if !ok {
+		return nil, false
+	}
+	var list []interface{}
+	for _, f := range obj {
+		switch value := f.value.(type) {
+		case []interface{}:
+			if list != nil {
+				return nil, false
+			}
+			list = value
+		case orderedObject:
+			return nil, false
+		}
+	}
+	return list, list != nil
+}
+
+// sortList sorts a list, which can be wrapped in an object, by the field of its
+// elements in place. The field is a flattened key like `store.id`, or the last
+// part of it like `id` if there is no such key. The numbers are sorted by their
+// values and the elements without the field are put at the end.
+func sortList(v interface{}, field string) error {
+	rows, ok := v.([]interface{})
+	if !ok {
+		if rows, ok = unwrapList(v); !ok {
+			return errors.Errorf("the output is not a list, cannot sort it by %s", field)
+		}
+	}
+	keys := make([]interface{}, len(rows))
+	var found bool
+	for i, row := range rows {
+		fields := orderedObject{}
+		flatten("", row, &fields)
+		keys[i] = lookupField(fields, field)
+		found = found || keys[i] != nil
+	}
+	if len(rows) > 0 && !found {
+		return errors.Errorf("unknown sort field %s", field)
+	}
+	indexes := make([]int, len(rows))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return lessValue(keys[indexes[i]], keys[indexes[j]])
+	})
+	sorted := make([]interface{}, len(rows))
+	for i, idx := range indexes {
+		sorted[i] = rows[idx]
+	}
+	copy(rows, sorted)
+	return nil
+}
+
+func lookupField(fields orderedObject, field string) interface{} {
+	for _, f := range fields {
+		if f.key == field {
+			return f.value
+		}
+	}
+	for _, f := range fields {
+		if strings.HasSuffix(f.key, "."+field) {
+			return f.value
+		}
+	}
+	return nil
+}
+
+// lessValue orders the numbers before the other values, and nil at the end.
+func lessValue(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return b == nil && a != nil
+	}
+	an, aok := a.(json.Number)
+	bn, bok := b.(json.Number)
+	switch {
+	case aok && bok:
+		ai, aerr := strconv.ParseUint(an.String(), 10, 64)
+		bi, berr := strconv.ParseUint(bn.String(), 10, 64)
+		if aerr == nil && berr == nil {
+			return ai < bi
+		}
+		af, _ := an.Float64()
+		bf, _ := bn.Float64()
+		return af < bf
+	case aok != bok:
+		return aok
+	}
+	return formatCell(a) < formatCell(b)
+}
+
+// flatten collects the fields of the nested objects with the joined keys, like
+// `store.id`.
+func flatten(prefix string, v interface{}, fields *orderedObject) {
+	obj, ok := v.(orderedObject)
+	if !ok {
+		*fields = append(*fields, orderedField{key: prefix, value: v})
+		return
+	}
+	for _, f := range obj {
+		key := f.key
+		if prefix != "" {
+			key = prefix + "." + f.key
+		}
+		flatten(key, f.value, fields)
+	}
+}
+
+// flattenJoined is like flatten, but it also flattens the lists of objects,
+// the values of the same field are joined by spaces.
+func flattenJoined(prefix string, v interface{}, fields *orderedObject) {
+	switch v := v.(type) {
+	case orderedObject:
+		for _, f := range v {
+			key := f.key
+			if prefix != "" {
+				key = prefix + "." + f.key
+			}
+			flattenJoined(key, f.value, fields)
+		}
+		return
+	case []interface{}:
+		if len(v) == 0 || !isObjectList(v) {
+			break
+		}
+		var keys []string
+		values := make(map[string][]string)
+		for _, e := range v {
+			elem := orderedObject{}
+			flattenJoined(prefix, e, &elem)
+			for _, f := range elem {
+				if _, ok := values[f.key]; !ok {
+					keys = append(keys, f.key)
+				}
+				values[f.key] = append(values[f.key], formatCell(f.value))
+			}
+		}
+		for _, k := range keys {
+			*fields = append(*fields, orderedField{key: k, value: strings.Join(values[k], " ")})
+		}
+		return
+	}
+	*fields = append(*fields, orderedField{key: prefix, value: v})
+}
+
+func isObjectList(v []interface{}) bool {
+	for _, e := range v {
+		if _, ok := e.(orderedObject); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func formatCell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case orderedObject, []interface{}:
+		var buf bytes.Buffer
+		writeCompact(&buf, v)
+		return buf.String()
+	}
+	return fmt.Sprint(v)
+}
+
+func writeCompact(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case orderedObject:
+		buf.WriteByte('{')
+		for i, f := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			k, _ := json.Marshal(f.key)
+			buf.Write(k)
+			buf.WriteByte(':')
+			writeCompact(buf, f.value)
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCompact(buf, e)
+		}
+		buf.WriteByte(']')
+	default:
+		b, _ := json.Marshal(v)
+		buf.Write(b)
+	}
+}
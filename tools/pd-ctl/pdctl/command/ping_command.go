@@ -25,18 +25,18 @@ func NewPingCommand() *cobra.Command {
 	m := &cobra.Command{
 		Use:   "ping",
 		Short: "show the total time spend ping the pd",
-		Run:   showPingCommandFunc,
+		RunE:  showPingCommandFunc,
 	}
 	return m
 }
 
-func showPingCommandFunc(cmd *cobra.Command, args []string) {
+func showPingCommandFunc(cmd *cobra.Command, args []string) error {
 	start := time.Now()
 	_, err := doRequest(cmd, pingPrefix, http.MethodGet)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 	elapsed := time.Since(start)
 	cmd.Println("time:", elapsed)
+	return nil
 }
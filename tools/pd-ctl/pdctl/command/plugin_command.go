@@ -42,7 +42,7 @@ func NewLoadPluginCommand() *cobra.Command {
 	r := &cobra.Command{
 		Use:   "load <plugin_path>",
 		Short: "load a plugin, path must begin with ./pd/plugin/",
-		Run:   loadPluginCommandFunc,
+		RunE:  loadPluginCommandFunc,
 	}
 	return r
 }
@@ -52,31 +52,29 @@ func NewUnloadPluginCommand() *cobra.Command {
 	r := &cobra.Command{
 		Use:   "unload <plugin_path>",
 		Short: "unload a plugin, path must begin with ./pd/plugin/",
-		Run:   unloadPluginCommandFunc,
+		RunE:  unloadPluginCommandFunc,
 	}
 	return r
 }
 
-func loadPluginCommandFunc(cmd *cobra.Command, args []string) {
-	sendPluginCommand(cmd, cluster.PluginLoad, args)
+func loadPluginCommandFunc(cmd *cobra.Command, args []string) error {
+	return sendPluginCommand(cmd, cluster.PluginLoad, args)
 }
 
-func unloadPluginCommandFunc(cmd *cobra.Command, args []string) {
-	sendPluginCommand(cmd, cluster.PluginUnload, args)
+func unloadPluginCommandFunc(cmd *cobra.Command, args []string) error {
+	return sendPluginCommand(cmd, cluster.PluginUnload, args)
 }
 
-func sendPluginCommand(cmd *cobra.Command, action string, args []string) {
+func sendPluginCommand(cmd *cobra.Command, action string, args []string) error {
 	if len(args) != 1 {
-		cmd.Println(cmd.Usage())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	data := map[string]interface{}{
 		"plugin-path": args[0],
 	}
 	reqData, err := json.Marshal(data)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 	switch action {
 	case cluster.PluginLoad:
@@ -84,12 +82,11 @@ func sendPluginCommand(cmd *cobra.Command, action string, args []string) {
 	case cluster.PluginUnload:
 		_, err = doRequest(cmd, pluginPrefix, http.MethodDelete, WithBody("application/json", bytes.NewBuffer(reqData)))
 	default:
-		cmd.Printf("Unknown action %s\n", action)
-		return
+		return usageErrorf("Unknown action %s\n", action)
 	}
 	if err != nil {
-		cmd.Printf("Failed to %s plugin %s: %s\n", action, args[0], err)
-		return
+		return failf("Failed to %s plugin %s: %s\n", action, args[0], err)
 	}
 	cmd.Println("Success!")
+	return nil
 }
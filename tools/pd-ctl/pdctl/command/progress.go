@@ -0,0 +1,100 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// waitInterval is the interval to poll the state of a waiting command.
+var waitInterval = time.Second
+
+// progress is a line of the progress of a waiting command, which is printed
+// to stderr with `--progress=json` or `--progress=text`.
+type progress struct {
+	Time    time.Time `json:"time"`
+	Percent float64   `json:"percent"`
+	ETA     string    `json:"eta,omitempty"`
+	Step    string    `json:"step"`
+}
+
+// pollFunc returns the progress of a waiting command, done means there is
+// nothing to wait.
+type pollFunc func() (percent float64, step string, done bool, err error)
+
+// addWaitFlags adds the flags of a waiting command, the progress is printed in
+// the format by default, nothing is printed if it is empty.
+func addWaitFlags(flags *pflag.FlagSet, format string) {
+	flags.Bool("wait", false, "wait until the command is finished")
+	flags.String("progress", format, "print the progress to stderr while waiting, one of text|json")
+}
+
+// shouldWait returns whether the command waits until it is finished.
+func shouldWait(cmd *cobra.Command) (bool, error) {
+	wait, _ := cmd.Flags().GetBool("wait")
+	if err := checkProgressFormat(cmd); err != nil {
+		return false, err
+	}
+	return wait, nil
+}
+
+// checkProgressFormat checks the format of the progress flag.
+func checkProgressFormat(cmd *cobra.Command) error {
+	if format, _ := cmd.Flags().GetString("progress"); format != "" && format != "text" && format != "json" {
+		return errors.Errorf("unknown progress format %s", format)
+	}
+	return nil
+}
+
+// waitFor polls until it is done, and prints the progress of each poll with
+// `--progress`. The ETA is estimated by the rate of the progress so far.
+func waitFor(cmd *cobra.Command, poll pollFunc) error {
+	format, _ := cmd.Flags().GetString("progress")
+	start := time.Now()
+	for {
+		percent, step, done, err := poll()
+		if err != nil {
+			return err
+		}
+		p := progress{Time: time.Now(), Percent: percent, Step: step}
+		if percent > 0 && percent < 100 && !done {
+			elapsed := float64(time.Since(start))
+			p.ETA = time.Duration(elapsed * (100 - percent) / percent).Round(time.Second).String()
+		}
+		switch format {
+		case "json":
+			data, err := json.Marshal(p)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			fmt.Fprintln(cmd.ErrOrStderr(), string(data))
+		case "text":
+			line := fmt.Sprintf("%.1f%%, %s", p.Percent, p.Step)
+			if p.ETA != "" {
+				line += ", ETA " + p.ETA
+			}
+			fmt.Fprintln(cmd.ErrOrStderr(), line)
+		}
+		if done {
+			return nil
+		}
+		time.Sleep(waitInterval)
+	}
+}
@@ -19,91 +19,140 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
-	"os/exec"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/itchyny/gojq"
 	"github.com/pingcap/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"github.com/tikv/pd/pkg/codec"
 )
 
 var (
 	regionsPrefix          = "pd/api/v1/regions"
 	regionsStorePrefix     = "pd/api/v1/regions/store"
+	regionsLabelPrefix     = "pd/api/v1/regions/label"
+	regionsSplitPrefix     = "pd/api/v1/regions/split"
 	regionsCheckPrefix     = "pd/api/v1/regions/check"
 	regionsWriteFlowPrefix = "pd/api/v1/regions/writeflow"
 	regionsReadFlowPrefix  = "pd/api/v1/regions/readflow"
 	regionsConfVerPrefix   = "pd/api/v1/regions/confver"
 	regionsVersionPrefix   = "pd/api/v1/regions/version"
 	regionsSizePrefix      = "pd/api/v1/regions/size"
+	regionsKeysPrefix      = "pd/api/v1/regions/keys"
 	regionsKeyPrefix       = "pd/api/v1/regions/key"
+	regionsRangePrefix     = "pd/api/v1/regions/range"
+	regionsGCRangePrefix   = "pd/api/v1/regions/gc-range"
+	regionsFreezePrefix    = "pd/api/v1/regions/freeze"
 	regionsSiblingPrefix   = "pd/api/v1/regions/sibling"
+	regionsMergePrefix     = "pd/api/v1/regions/merge"
+	regionsByIDsPrefix     = "pd/api/v1/regions/by-ids"
+	regionsTopologyPrefix  = "pd/api/v1/regions/topology"
+	regionsVerifyPrefix    = "pd/api/v1/regions/verify"
 	regionIDPrefix         = "pd/api/v1/region/id"
+	regionsStatsPrefix     = "pd/api/v1/stats/distribution"
 	regionKeyPrefix        = "pd/api/v1/region/key"
 )
 
 // NewRegionCommand returns a region subcommand of rootCmd
 func NewRegionCommand() *cobra.Command {
 	r := &cobra.Command{
-		Use:   `region <region_id> [-jq="<query string>"]`,
+		Use:   `region <region_id> [-jq="<query string>"] | region [--min-size=<size>] [--max-size=<size>] [--min-keys=<keys>] [--max-keys=<keys>] [--store=<store_id>] [--key-prefix=<prefix>] [--format=raw|encode|hex]`,
 		Short: "show the region status",
-		Run:   showRegionCommandFunc,
+		RunE:  showRegionCommandFunc,
 	}
+	r.Flags().Int64("min-size", 0, "only show the regions whose approximate sizes are not less than it in MiB")
+	r.Flags().Int64("max-size", 0, "only show the regions whose approximate sizes are not greater than it in MiB")
+	r.Flags().Int64("min-keys", 0, "only show the regions whose approximate keys are not less than it")
+	r.Flags().Int64("max-keys", 0, "only show the regions whose approximate keys are not greater than it")
+	r.Flags().Uint64("store", 0, "only show the regions with a peer on the store")
+	r.Flags().String("key-prefix", "", "only show the regions containing the keys with the prefix")
+	r.Flags().String("format", "hex", "the key format of the key prefix")
+	r.PersistentFlags().Bool("decode", false, "annotate the regions with the table and index which their start keys belong to")
+	r.PersistentFlags().Bool("human", false, "render the written and read bytes in MiB/s and the approximate size in GiB")
 	r.AddCommand(NewRegionWithKeyCommand())
 	r.AddCommand(NewRegionWithCheckCommand())
 	r.AddCommand(NewRegionWithSiblingCommand())
+	r.AddCommand(NewRegionMergeCommand())
+	r.AddCommand(NewRegionHistoryCommand())
+	r.AddCommand(NewRegionAtCommand())
+	r.AddCommand(NewRegionVerifyCommand())
+	r.AddCommand(NewRegionTopCommand())
+	r.AddCommand(NewRegionsWithIDsCommand())
+	r.AddCommand(NewRegionFlatCommand())
+	r.AddCommand(NewRegionCompareCommand())
 	r.AddCommand(NewRegionWithStoreCommand())
 	r.AddCommand(NewRegionsWithStartKeyCommand())
+	r.AddCommand(NewRegionsWithKeyRangeCommand())
+	r.AddCommand(NewRegionGCRangeCommand())
+	r.AddCommand(NewRegionFreezeCommand())
+	r.AddCommand(NewRegionUnfreezeCommand())
+	r.AddCommand(NewRegionScatterCommand())
+	r.AddCommand(NewRegionSplitKeysCommand())
+	r.AddCommand(NewRegionStatsCommand())
 
 	topRead := &cobra.Command{
 		Use:   `topread <limit> [--jq="<query string>"]`,
-		Short: "show regions with top read flow",
-		Run:   showRegionTopReadCommandFunc,
+		Short: "show regions with top read flow, same as `top --by=read`",
+		RunE:  newRegionTopAliasCommandFunc(regionsReadFlowPrefix),
 	}
 	topRead.Flags().String("jq", "", "jq query")
 	r.AddCommand(topRead)
 
 	topWrite := &cobra.Command{
 		Use:   `topwrite <limit> [--jq="<query string>"]`,
-		Short: "show regions with top write flow",
-		Run:   showRegionTopWriteCommandFunc,
+		Short: "show regions with top write flow, same as `top --by=write`",
+		RunE:  newRegionTopAliasCommandFunc(regionsWriteFlowPrefix),
 	}
 	topWrite.Flags().String("jq", "", "jq query")
 	r.AddCommand(topWrite)
 
 	topConfVer := &cobra.Command{
 		Use:   `topconfver <limit> [--jq="<query string>"]`,
-		Short: "show regions with top conf version",
-		Run:   showRegionTopConfVerCommandFunc,
+		Short: "show regions with top conf version, same as `top --by=confver`",
+		RunE:  newRegionTopAliasCommandFunc(regionsConfVerPrefix),
 	}
 	topConfVer.Flags().String("jq", "", "jq query")
 	r.AddCommand(topConfVer)
 
 	topVersion := &cobra.Command{
 		Use:   `topversion <limit> [--jq="<query string>"]`,
-		Short: "show regions with top version",
-		Run:   showRegionTopVersionCommandFunc,
+		Short: "show regions with top version, same as `top --by=version`",
+		RunE:  newRegionTopAliasCommandFunc(regionsVersionPrefix),
 	}
 	topVersion.Flags().String("jq", "", "jq query")
 	r.AddCommand(topVersion)
 
 	topSize := &cobra.Command{
 		Use:   `topsize <limit> [--jq="<query string>"]`,
-		Short: "show regions with top size",
-		Run:   showRegionTopSizeCommandFunc,
+		Short: "show regions with top size, same as `top --by=size`",
+		RunE:  newRegionTopAliasCommandFunc(regionsSizePrefix),
 	}
 	topSize.Flags().String("jq", "", "jq query")
 	r.AddCommand(topSize)
 
+	topKeys := &cobra.Command{
+		Use:   `topkeys <limit> [--jq="<query string>"]`,
+		Short: "show regions with top keys, same as `top --by=keys`",
+		RunE:  newRegionTopAliasCommandFunc(regionsKeysPrefix),
+	}
+	topKeys.Flags().String("jq", "", "jq query")
+	r.AddCommand(topKeys)
+
 	scanRegion := &cobra.Command{
-		Use:   `scan [--jq="<query string>"]`,
-		Short: "scan all regions",
-		Run:   scanRegionCommandFunc,
+		Use:   `scan [--limit=<limit>] [--start-key=<key>] [--format=raw|encode|hex] [--jq="<query string>"]`,
+		Short: "scan all regions page by page",
+		RunE:  scanRegionCommandFunc,
 	}
 	scanRegion.Flags().String("jq", "", "jq query")
+	scanRegion.Flags().Int("limit", 1024, "the count of regions in a page")
+	scanRegion.Flags().String("start-key", "", "resume the scan from the key")
+	scanRegion.Flags().String("format", "hex", "the key format of the start key")
 	r.AddCommand(scanRegion)
 
 	r.Flags().String("jq", "", "jq query")
@@ -111,43 +160,93 @@ func NewRegionCommand() *cobra.Command {
 	return r
 }
 
-func showRegionCommandFunc(cmd *cobra.Command, args []string) {
+func showRegionCommandFunc(cmd *cobra.Command, args []string) error {
+	query, err := regionFilterQuery(cmd)
+	if err != nil {
+		return usageErrorln("Error: ", err)
+	}
 	prefix := regionsPrefix
+	if len(query) > 0 {
+		prefix += "?" + query.Encode()
+	}
 	if len(args) == 1 {
 		if _, err := strconv.Atoi(args[0]); err != nil {
-			cmd.Println("region_id should be a number")
-			return
+			return usageErrorln("region_id should be a number")
+		}
+		if len(query) > 0 {
+			return usageErrorln("the filters can not be used with region_id")
 		}
 		prefix = regionIDPrefix + "/" + args[0]
 	}
 	r, err := doRequest(cmd, prefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get region: %s\n", err)
-		return
+		return failf("Failed to get region: %s\n", err)
 	}
 	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-		printWithJQFilter(r, flag.Value.String())
-		return
+		return printWithJQFilter(cmd, r, flag.Value.String())
 	}
 
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
-func scanRegionCommandFunc(cmd *cobra.Command, args []string) {
-	const limit = 1024
-	var key []byte
+// regionFilterQuery returns the query parameters of the regions API for the
+// filter flags which are set.
+func regionFilterQuery(cmd *cobra.Command) (url.Values, error) {
+	query := url.Values{}
+	flags := cmd.Flags()
+	for flag, param := range map[string]string{
+		"min-size": "min_size",
+		"max-size": "max_size",
+		"min-keys": "min_keys",
+		"max-keys": "max_keys",
+	} {
+		if !flags.Changed(flag) {
+			continue
+		}
+		value, _ := flags.GetInt64(flag)
+		if value < 0 {
+			return nil, errors.Errorf("%s should be a non-negative number", flag)
+		}
+		query.Set(param, strconv.FormatInt(value, 10))
+	}
+	if flags.Changed("store") {
+		storeID, _ := flags.GetUint64("store")
+		query.Set("store_id", strconv.FormatUint(storeID, 10))
+	}
+	if flags.Changed("key-prefix") {
+		keyPrefix, err := parseKey(flags, flags.Lookup("key-prefix").Value.String())
+		if err != nil {
+			return nil, err
+		}
+		query.Set("key_prefix", keyPrefix)
+	}
+	return query, nil
+}
+
+func scanRegionCommandFunc(cmd *cobra.Command, args []string) error {
+	limit, _ := cmd.Flags().GetInt("limit")
+	if limit <= 0 {
+		return usageErrorln("limit should be a positive number")
+	}
+	startKey, err := parseKey(cmd.Flags(), cmd.Flag("start-key").Value.String())
+	if err != nil {
+		return usageErrorln("Error: ", err)
+	}
+	key := []byte(startKey)
 	for {
-		uri := fmt.Sprintf("%s?key=%s&limit=%d", regionsKeyPrefix, url.QueryEscape(string(key)), limit)
+		uri := fmt.Sprintf("%s?start_key=%s&limit=%d", regionsPrefix, url.QueryEscape(string(key)), limit)
 		r, err := doRequest(cmd, uri, http.MethodGet)
 		if err != nil {
-			cmd.Printf("Failed to scan regions: %s\n", err)
-			return
+			return failf("Failed to scan regions: %s, resume with --start-key=%s\n", err, hex.EncodeToString(key))
 		}
-
 		if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-			printWithJQFilter(r, flag.Value.String())
+			if err := printWithJQFilter(cmd, r, flag.Value.String()); err != nil {
+				return err
+			}
 		} else {
-			cmd.Println(r)
+			if err := printResponse(cmd, r); err != nil {
+				return err
+			}
 		}
 
 		// Extract last region's endkey for next batch.
@@ -159,129 +258,103 @@ func scanRegionCommandFunc(cmd *cobra.Command, args []string) {
 
 		var regions regionsInfo
 		if err = json.Unmarshal([]byte(r), &regions); err != nil {
-			cmd.Printf("Failed to unmarshal regions: %s\n", err)
-			return
+			return failf("Failed to unmarshal regions: %s\n", err)
 		}
 		if len(regions.Regions) == 0 {
-			return
+			return nil
 		}
 
 		lastEndKey := regions.Regions[len(regions.Regions)-1].EndKey
 		if lastEndKey == "" {
-			return
+			return nil
 		}
 
 		key, err = hex.DecodeString(lastEndKey)
 		if err != nil {
-			cmd.Println("Bad format region key: ", key)
-			return
+			return usageErrorln("Bad format region key: ", key)
 		}
 	}
 }
 
-func showRegionTopWriteCommandFunc(cmd *cobra.Command, args []string) {
-	prefix := regionsWriteFlowPrefix
-	if len(args) == 1 {
-		if _, err := strconv.Atoi(args[0]); err != nil {
-			cmd.Println("limit should be a number")
-			return
-		}
-		prefix += "?limit=" + args[0]
-	}
-	r, err := doRequest(cmd, prefix, http.MethodGet)
-	if err != nil {
-		cmd.Printf("Failed to get regions: %s\n", err)
-		return
-	}
-	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-		printWithJQFilter(r, flag.Value.String())
-		return
-	}
-	cmd.Println(r)
+// regionTopMetrics are the metrics supported by `region top --by`, each of
+// them is served by its own API.
+var regionTopMetrics = []struct {
+	name   string
+	prefix string
+}{
+	{"read", regionsReadFlowPrefix},
+	{"write", regionsWriteFlowPrefix},
+	{"size", regionsSizePrefix},
+	{"keys", regionsKeysPrefix},
+	{"version", regionsVersionPrefix},
+	{"confver", regionsConfVerPrefix},
 }
 
-func showRegionTopReadCommandFunc(cmd *cobra.Command, args []string) {
-	prefix := regionsReadFlowPrefix
-	if len(args) == 1 {
-		if _, err := strconv.Atoi(args[0]); err != nil {
-			cmd.Println("limit should be a number")
-			return
-		}
-		prefix += "?limit=" + args[0]
-	}
-	r, err := doRequest(cmd, prefix, http.MethodGet)
-	if err != nil {
-		cmd.Printf("Failed to get regions: %s\n", err)
-		return
-	}
-	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-		printWithJQFilter(r, flag.Value.String())
-		return
+// NewRegionTopCommand returns a region top subcommand of regionCmd
+func NewRegionTopCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   `top --by=read|write|size|keys|version|confver [--limit=<limit>] [--asc] [--jq="<query string>"]`,
+		Short: "show regions with the top values of the metric",
+		RunE:  showRegionTopCommandFunc,
 	}
-	cmd.Println(r)
+	r.Flags().String("by", "", "the metric to sort the regions by, one of read|write|size|keys|version|confver")
+	r.Flags().Int("limit", 0, "the max number of regions, 16 if not set")
+	r.Flags().Bool("asc", false, "show the regions with the lowest values instead, like the coldest or smallest ones")
+	r.Flags().String("jq", "", "jq query")
+	return r
 }
 
-func showRegionTopConfVerCommandFunc(cmd *cobra.Command, args []string) {
-	prefix := regionsConfVerPrefix
-	if len(args) == 1 {
-		if _, err := strconv.Atoi(args[0]); err != nil {
-			cmd.Println("limit should be a number")
-			return
-		}
-		prefix += "?limit=" + args[0]
+func showRegionTopCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
 	}
-	r, err := doRequest(cmd, prefix, http.MethodGet)
-	if err != nil {
-		cmd.Printf("Failed to get regions: %s\n", err)
-		return
+	by, _ := cmd.Flags().GetString("by")
+	limit, _ := cmd.Flags().GetInt("limit")
+	asc, _ := cmd.Flags().GetBool("asc")
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
 	}
-	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-		printWithJQFilter(r, flag.Value.String())
-		return
+	if asc {
+		query.Set("asc", "true")
 	}
-	cmd.Println(r)
+	names := make([]string, 0, len(regionTopMetrics))
+	for _, metric := range regionTopMetrics {
+		if metric.name == by {
+			return showRegionTop(cmd, metric.prefix, query)
+		}
+		names = append(names, metric.name)
+	}
+	return usageErrorf("--by should be one of %s\n", strings.Join(names, "|"))
 }
 
-func showRegionTopVersionCommandFunc(cmd *cobra.Command, args []string) {
-	prefix := regionsVersionPrefix
-	if len(args) == 1 {
-		if _, err := strconv.Atoi(args[0]); err != nil {
-			cmd.Println("limit should be a number")
-			return
+// newRegionTopAliasCommandFunc returns the function of the old `region topxxx
+// <limit>` commands, which are kept as the aliases of `region top --by`.
+func newRegionTopAliasCommandFunc(prefix string) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		query := url.Values{}
+		if len(args) == 1 {
+			if _, err := strconv.Atoi(args[0]); err != nil {
+				return usageErrorln("limit should be a number")
+			}
+			query.Set("limit", args[0])
 		}
-		prefix += "?limit=" + args[0]
+		return showRegionTop(cmd, prefix, query)
 	}
-	r, err := doRequest(cmd, prefix, http.MethodGet)
-	if err != nil {
-		cmd.Printf("Failed to get regions: %s\n", err)
-		return
-	}
-	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-		printWithJQFilter(r, flag.Value.String())
-		return
-	}
-	cmd.Println(r)
 }
 
-func showRegionTopSizeCommandFunc(cmd *cobra.Command, args []string) {
-	prefix := regionsSizePrefix
-	if len(args) == 1 {
-		if _, err := strconv.Atoi(args[0]); err != nil {
-			cmd.Println("limit should be a number")
-			return
-		}
-		prefix += "?limit=" + args[0]
+func showRegionTop(cmd *cobra.Command, prefix string, query url.Values) error {
+	if len(query) > 0 {
+		prefix += "?" + query.Encode()
 	}
 	r, err := doRequest(cmd, prefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get regions: %s\n", err)
-		return
+		return failf("Failed to get regions: %s\n", err)
 	}
 	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-		printWithJQFilter(r, flag.Value.String())
-		return
+		return printWithJQFilter(cmd, r, flag.Value.String())
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
 // NewRegionWithKeyCommand return a region with key subcommand of regionCmd
@@ -289,30 +362,27 @@ func NewRegionWithKeyCommand() *cobra.Command {
 	r := &cobra.Command{
 		Use:   "key [--format=raw|encode|hex] <key>",
 		Short: "show the region with key",
-		Run:   showRegionWithTableCommandFunc,
+		RunE:  showRegionWithTableCommandFunc,
 	}
 	r.Flags().String("format", "hex", "the key format")
 	return r
 }
 
-func showRegionWithTableCommandFunc(cmd *cobra.Command, args []string) {
+func showRegionWithTableCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	key, err := parseKey(cmd.Flags(), args[0])
 	if err != nil {
-		cmd.Println("Error: ", err)
-		return
+		return usageErrorln("Error: ", err)
 	}
 	key = url.QueryEscape(key)
 	prefix := regionKeyPrefix + "/" + key
 	r, err := doRequest(cmd, prefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get region: %s\n", err)
-		return
+		return failf("Failed to get region: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
 func parseKey(flags *pflag.FlagSet, key string) (string, error) {
@@ -377,62 +447,367 @@ func NewRegionsWithStartKeyCommand() *cobra.Command {
 	r := &cobra.Command{
 		Use:   "startkey [--format=raw|encode|hex] <key> <limit>",
 		Short: "show regions from start key",
-		Run:   showRegionsFromStartKeyCommandFunc,
+		RunE:  showRegionsFromStartKeyCommandFunc,
 	}
 
 	r.Flags().String("format", "hex", "the key format")
 	return r
 }
 
-func showRegionsFromStartKeyCommandFunc(cmd *cobra.Command, args []string) {
+func showRegionsFromStartKeyCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 || len(args) > 2 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	key, err := parseKey(cmd.Flags(), args[0])
 	if err != nil {
-		cmd.Println("Error: ", err)
-		return
+		return usageErrorln("Error: ", err)
 	}
 	key = url.QueryEscape(key)
 	prefix := regionsKeyPrefix + "?key=" + key
 	if len(args) == 2 {
 		if _, err = strconv.Atoi(args[1]); err != nil {
-			cmd.Println("limit should be a number")
-			return
+			return usageErrorln("limit should be a number")
 		}
 		prefix += "&limit=" + args[1]
 	}
 	r, err := doRequest(cmd, prefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get region: %s\n", err)
-		return
+		return failf("Failed to get region: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+// NewRegionsWithKeyRangeCommand returns regions in a key range subcommand of regionCmd.
+func NewRegionsWithKeyRangeCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "keys [--format=raw|encode|hex] --start-key=<key> [--end-key=<key>] [--limit=<limit>]",
+		Short: "show regions overlapping the key range [start-key, end-key)",
+		RunE:  showRegionsWithKeyRangeCommandFunc,
+	}
+	r.Flags().String("format", "hex", "the key format")
+	r.Flags().String("start-key", "", "the start key of the range")
+	r.Flags().String("end-key", "", "the end key of the range, empty means unbounded")
+	r.Flags().Int("limit", 0, "the max count of regions, 0 means the default limit of PD")
+	return r
+}
+
+func showRegionsWithKeyRangeCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	startKey, err := parseKey(cmd.Flags(), cmd.Flag("start-key").Value.String())
+	if err != nil {
+		return usageErrorln("Error: ", err)
+	}
+	endKey, err := parseKey(cmd.Flags(), cmd.Flag("end-key").Value.String())
+	if err != nil {
+		return usageErrorln("Error: ", err)
+	}
+	query := url.Values{}
+	query.Set("start_key", startKey)
+	query.Set("end_key", endKey)
+	if limit, _ := cmd.Flags().GetInt("limit"); limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	r, err := doRequest(cmd, regionsRangePrefix+"?"+query.Encode(), http.MethodGet)
+	if err != nil {
+		return failf("Failed to get regions: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+// NewRegionGCRangeCommand returns a gc-range subcommand of regionCmd.
+func NewRegionGCRangeCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "gc-range [--format=raw|encode|hex] --start-key=<key> --end-key=<key> [--ttl=<duration>]",
+		Short: "mark the key range [start-key, end-key) as dropped, its regions will be merged soon and not be balanced",
+		RunE:  addRegionGCRangeCommandFunc,
+	}
+	r.Flags().String("format", "hex", "the key format")
+	r.Flags().String("start-key", "", "the start key of the dropped range")
+	r.Flags().String("end-key", "", "the end key of the dropped range")
+	r.Flags().Duration("ttl", 0, "how long the range is treated as dropped, 0 means the default of PD")
+	r.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "show the dropped key ranges which are not expired",
+		RunE:  showRegionGCRangesCommandFunc,
+	})
+	return r
+}
+
+func addRegionGCRangeCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 || !cmd.Flags().Changed("start-key") || !cmd.Flags().Changed("end-key") {
+		return usageErrorln(cmd.UsageString())
+	}
+	startKey, err := parseKey(cmd.Flags(), cmd.Flag("start-key").Value.String())
+	if err != nil {
+		return usageErrorln("Error: ", err)
+	}
+	endKey, err := parseKey(cmd.Flags(), cmd.Flag("end-key").Value.String())
+	if err != nil {
+		return usageErrorln("Error: ", err)
+	}
+	input := map[string]interface{}{
+		"start_key": hex.EncodeToString([]byte(startKey)),
+		"end_key":   hex.EncodeToString([]byte(endKey)),
+	}
+	if ttl, _ := cmd.Flags().GetDuration("ttl"); ttl > 0 {
+		input["ttl"] = ttl.String()
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return failln(err)
+	}
+	r, err := doRequest(cmd, regionsGCRangePrefix, http.MethodPost, WithBody("application/json", bytes.NewBuffer(data)))
+	if err != nil {
+		return failf("Failed to add gc range: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+func showRegionGCRangesCommandFunc(cmd *cobra.Command, args []string) error {
+	r, err := doRequest(cmd, regionsGCRangePrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get gc ranges: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+// NewRegionFreezeCommand returns a freeze subcommand of regionCmd.
+func NewRegionFreezeCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "freeze [--format=raw|encode|hex] --start-key=<key> --end-key=<key> [--ttl=<duration>]",
+		Short: "freeze the scheduling of the regions overlapping with the key range [start-key, end-key), no leader transfers or peer moves are made until it expires, it is shown as a schedule=deny rule by `region-label show`",
+		RunE:  freezeRegionsCommandFunc,
+	}
+	r.Flags().String("format", "hex", "the key format")
+	r.Flags().String("start-key", "", "the start key of the frozen range")
+	r.Flags().String("end-key", "", "the end key of the frozen range")
+	r.Flags().Duration("ttl", 0, "how long the range is frozen, 0 means the default of PD")
+	r.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "show the frozen key ranges which are not expired",
+		RunE:  showFrozenRangesCommandFunc,
+	})
+	return r
+}
+
+// NewRegionUnfreezeCommand returns an unfreeze subcommand of regionCmd.
+func NewRegionUnfreezeCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "unfreeze [--format=raw|encode|hex] --start-key=<key> --end-key=<key>",
+		Short: "unfreeze the scheduling of the key range [start-key, end-key) before it expires",
+		RunE:  unfreezeRegionsCommandFunc,
+	}
+	r.Flags().String("format", "hex", "the key format")
+	r.Flags().String("start-key", "", "the start key of the frozen range")
+	r.Flags().String("end-key", "", "the end key of the frozen range")
+	return r
+}
+
+// NewRegionScatterCommand returns a scatter subcommand of regionCmd.
+func NewRegionScatterCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "scatter [--format=raw|encode|hex] --start-key=<key> --end-key=<key> [--group=<name>] [--progress=text|json]",
+		Short: "scatter the leaders and peers of the regions in the key range [start-key, end-key) uniformly across the stores, e.g. after pre-splitting the range before a bulk load. It runs as a scatter-range job and waits until the job is done",
+		RunE:  scatterRegionsCommandFunc,
+	}
+	r.Flags().String("format", "hex", "the key format")
+	r.Flags().String("start-key", "", "the start key of the range")
+	r.Flags().String("end-key", "", "the end key of the range, empty means the end of the key space")
+	r.Flags().String("group", "", "the group of the regions, the regions of a group are scattered independently of the other groups")
+	r.Flags().String("progress", "", "print the progress to stderr while waiting, one of text|json")
+	return r
+}
+
+func scatterRegionsCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 || !cmd.Flags().Changed("start-key") || !cmd.Flags().Changed("end-key") {
+		return usageErrorln(cmd.UsageString())
+	}
+	if err := checkProgressFormat(cmd); err != nil {
+		return usageErrorln("Error: ", err)
+	}
+	startKey, endKey, err := parseKeyRangeFlags(cmd)
+	if err != nil {
+		return usageErrorln("Error: ", err)
+	}
+	if startKey == "" && endKey == "" {
+		return usageErrorln("Error: the key range should not be empty")
+	}
+	group, _ := cmd.Flags().GetString("group")
+	id, err := submitJob(cmd, scatterRangeJob, map[string]interface{}{
+		"start_key": startKey,
+		"end_key":   endKey,
+		"group":     group,
+	})
+	if err != nil {
+		return failf("Failed to scatter regions: %s\n", err)
+	}
+	// The job keeps running if the command is interrupted, it can be
+	// followed by `job show` or cancelled by `job cancel`.
+	r, j, err := waitForJob(cmd, id, nil)
+	if err != nil {
+		return failf("Failed to wait the scatter-range job %d: %s\n", id, err)
+	}
+	if j.State != "finished" {
+		return failf("The scatter-range job %d is %s: %s\n", id, j.State, j.Error)
+	}
+	return printResponse(cmd, r)
+}
+
+// parseKeyRangeFlags parses the start-key and end-key flags into hex.
+func parseKeyRangeFlags(cmd *cobra.Command) (string, string, error) {
+	startKey, err := parseKey(cmd.Flags(), cmd.Flag("start-key").Value.String())
+	if err != nil {
+		return "", "", err
+	}
+	endKey, err := parseKey(cmd.Flags(), cmd.Flag("end-key").Value.String())
+	if err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString([]byte(startKey)), hex.EncodeToString([]byte(endKey)), nil
+}
+
+func freezeRegionsCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 || !cmd.Flags().Changed("start-key") || !cmd.Flags().Changed("end-key") {
+		return usageErrorln(cmd.UsageString())
+	}
+	startKey, endKey, err := parseKeyRangeFlags(cmd)
+	if err != nil {
+		return usageErrorln("Error: ", err)
+	}
+	input := map[string]interface{}{
+		"start_key": startKey,
+		"end_key":   endKey,
+	}
+	if ttl, _ := cmd.Flags().GetDuration("ttl"); ttl > 0 {
+		input["ttl"] = ttl.String()
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return failln(err)
+	}
+	r, err := doRequest(cmd, regionsFreezePrefix, http.MethodPost, WithBody("application/json", bytes.NewBuffer(data)))
+	if err != nil {
+		return failf("Failed to freeze key range: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+func unfreezeRegionsCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 || !cmd.Flags().Changed("start-key") || !cmd.Flags().Changed("end-key") {
+		return usageErrorln(cmd.UsageString())
+	}
+	startKey, endKey, err := parseKeyRangeFlags(cmd)
+	if err != nil {
+		return usageErrorln("Error: ", err)
+	}
+	query := make(url.Values)
+	query.Set("start_key", startKey)
+	query.Set("end_key", endKey)
+	r, err := doRequest(cmd, regionsFreezePrefix+"?"+query.Encode(), http.MethodDelete)
+	if err != nil {
+		return failf("Failed to unfreeze key range: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+func showFrozenRangesCommandFunc(cmd *cobra.Command, args []string) error {
+	r, err := doRequest(cmd, regionsFreezePrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get frozen ranges: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+// NewRegionSplitKeysCommand returns a split-keys subcommand of regionCmd.
+func NewRegionSplitKeysCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "split-keys [--format=raw|encode|hex] [--file=<file>] [<key>...]",
+		Short: "split the regions by the keys, the file can be generated by `keyrange split-even`",
+		RunE:  splitRegionsWithKeysCommandFunc,
+	}
+	r.Flags().String("format", "hex", "the key format of the arguments")
+	r.Flags().String("file", "", "the file of the split keys in hex, like {\"split_keys\": [\"7480\"]}")
+	return r
+}
+
+func splitRegionsWithKeysCommandFunc(cmd *cobra.Command, args []string) error {
+	var splitKeys []string
+	if file := cmd.Flag("file").Value.String(); file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return failf("Failed to read split keys: %s\n", err)
+		}
+		var input splitKeysFile
+		if err := json.Unmarshal(data, &input); err != nil {
+			return failf("Failed to unmarshal split keys: %s\n", err)
+		}
+		splitKeys = append(splitKeys, input.SplitKeys...)
+	}
+	for _, arg := range args {
+		key, err := parseKey(cmd.Flags(), arg)
+		if err != nil {
+			return usageErrorln("Error: ", err)
+		}
+		splitKeys = append(splitKeys, hex.EncodeToString([]byte(key)))
+	}
+	if len(splitKeys) == 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	data, err := json.Marshal(splitKeysFile{SplitKeys: splitKeys})
+	if err != nil {
+		return failf("Failed to marshal split keys: %s\n", err)
 	}
-	cmd.Println(r)
+	r, err := doRequest(cmd, regionsSplitPrefix, http.MethodPost,
+		WithBody("application/json", bytes.NewBuffer(data)))
+	if err != nil {
+		return failf("Failed to split regions: %s\n", err)
+	}
+	return printResponse(cmd, r)
 }
 
 // NewRegionWithCheckCommand returns a region with check subcommand of regionCmd
 func NewRegionWithCheckCommand() *cobra.Command {
 	r := &cobra.Command{
-		Use:   "check [miss-peer|extra-peer|down-peer|learner-peer|pending-peer|offline-peer|empty-region|hist-size|hist-keys]",
+		Use:   "check [miss-peer|extra-peer|down-peer|learner-peer|pending-peer|offline-peer|empty-region|oversized-region|undersized-region|stale-heartbeat|hist-size|hist-keys|leader-on-store|follower-on-store|learner-on-store]",
 		Short: "show the region with check specific status",
-		Run:   showRegionWithCheckCommandFunc,
+		RunE:  showRegionWithCheckCommandFunc,
 	}
+	r.Flags().Duration("threshold", 0, "the duration since the last heartbeat of the stale-heartbeat regions, 0 means the default of PD")
+	r.Flags().Duration("since", 0, "only show the stale-heartbeat regions last reported within the duration, like 1h")
+	r.Flags().Duration("until", 0, "only show the stale-heartbeat regions last reported before the duration ago, like 10m, which overrides the threshold")
 	return r
 }
 
-func showRegionWithCheckCommandFunc(cmd *cobra.Command, args []string) {
+func showRegionWithCheckCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 || len(args) > 2 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	state := args[0]
 	prefix := regionsCheckPrefix + "/" + state
-	if strings.EqualFold(state, "hist-size") {
+	if role := strings.TrimSuffix(strings.ToLower(state), "-on-store"); role != strings.ToLower(state) {
+		if len(args) != 2 {
+			return usageErrorln(cmd.UsageString())
+		}
+		if _, err := strconv.ParseUint(args[1], 10, 64); err != nil {
+			return usageErrorln("store id should be a number")
+		}
+		prefix = regionsStorePrefix + "/" + args[1] + "?role=" + role
+	} else if strings.EqualFold(state, "stale-heartbeat") {
+		query := url.Values{}
+		for _, name := range []string{"threshold", "since", "until"} {
+			if d, _ := cmd.Flags().GetDuration(name); d > 0 {
+				query.Set(name, d.String())
+			}
+		}
+		if len(query) > 0 {
+			prefix += "?" + query.Encode()
+		}
+	} else if strings.EqualFold(state, "hist-size") {
 		if len(args) == 2 {
 			if _, err := strconv.Atoi(args[1]); err != nil {
-				cmd.Println("region size histogram bound should be a number")
-				return
+				return usageErrorln("region size histogram bound should be a number")
 			}
 			prefix += "?bound=" + args[1]
 		} else {
@@ -441,8 +816,7 @@ func showRegionWithCheckCommandFunc(cmd *cobra.Command, args []string) {
 	} else if strings.EqualFold(state, "hist-keys") {
 		if len(args) == 2 {
 			if _, err := strconv.Atoi(args[1]); err != nil {
-				cmd.Println("region keys histogram bound should be a number")
-				return
+				return usageErrorln("region keys histogram bound should be a number")
 			}
 			prefix += "?bound=" + args[1]
 		} else {
@@ -451,10 +825,9 @@ func showRegionWithCheckCommandFunc(cmd *cobra.Command, args []string) {
 	}
 	r, err := doRequest(cmd, prefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get region: %s\n", err)
-		return
+		return failf("Failed to get region: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
 // NewRegionWithSiblingCommand returns a region with sibling subcommand of regionCmd
@@ -462,72 +835,463 @@ func NewRegionWithSiblingCommand() *cobra.Command {
 	r := &cobra.Command{
 		Use:   "sibling <region_id>",
 		Short: "show the sibling regions of specific region",
-		Run:   showRegionWithSiblingCommandFunc,
+		RunE:  showRegionWithSiblingCommandFunc,
 	}
 	return r
 }
 
-func showRegionWithSiblingCommandFunc(cmd *cobra.Command, args []string) {
+func showRegionWithSiblingCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	regionID := args[0]
 	prefix := regionsSiblingPrefix + "/" + regionID
 	r, err := doRequest(cmd, prefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get region sibling: %s\n", err)
-		return
+		return failf("Failed to get region sibling: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+// NewRegionMergeCommand returns a region merge subcommand of regionCmd
+func NewRegionMergeCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "merge <region_id> [--with=left|right]",
+		Short: "merge the region into its sibling, the smaller sibling which can be merged is picked if --with is omitted, the region should not be larger than max-merge-region-size and max-merge-region-keys",
+		RunE:  mergeRegionWithSiblingCommandFunc,
+	}
+	r.Flags().String("with", "", "the side of the sibling, left or right")
+	return r
+}
+
+func mergeRegionWithSiblingCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return usageErrorln(cmd.UsageString())
+	}
+	if _, err := strconv.ParseUint(args[0], 10, 64); err != nil {
+		return usageErrorln("region_id should be a number")
+	}
+	prefix := regionsMergePrefix + "/" + args[0]
+	switch with, _ := cmd.Flags().GetString("with"); with {
+	case "":
+	case "left", "right":
+		prefix += "?with=" + with
+	default:
+		return usageErrorf("Invalid side %s, should be left or right\n", with)
+	}
+	r, err := doRequest(cmd, prefix, http.MethodPost)
+	if err != nil {
+		return failf("Failed to merge region: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
+}
+
+// NewRegionHistoryCommand returns a region history subcommand of regionCmd
+func NewRegionHistoryCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "history <region_id>",
+		Short: "show the latest events of specific region, like leader transfers, peer changes, splits and merges",
+		RunE:  showRegionHistoryCommandFunc,
+	}
+	return r
+}
+
+func showRegionHistoryCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return usageErrorln(cmd.UsageString())
+	}
+	if _, err := strconv.ParseUint(args[0], 10, 64); err != nil {
+		return usageErrorln("region_id should be a number")
+	}
+	prefix := regionIDPrefix + "/" + args[0] + "/history"
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get region history: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+// NewRegionAtCommand returns a region at subcommand of regionCmd
+func NewRegionAtCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "at --time=<time> [--id=<region_id>]",
+		Short: "show the region topology at a past time, from the latest snapshot taken no later than the time",
+		RunE:  showRegionAtCommandFunc,
+	}
+	r.Flags().String("time", "", "the time in unix seconds, RFC3339 or \"2006-01-02 15:04:05\"")
+	r.Flags().Uint64("id", 0, "only show the region with the id")
+	r.Flags().String("jq", "", "jq query")
+	return r
+}
+
+func showRegionAtCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	s, _ := cmd.Flags().GetString("time")
+	if s == "" {
+		return usageErrorln("time should be specified")
+	}
+	t, err := parseTime(s)
+	if err != nil {
+		return failln(err)
+	}
+	query := make(url.Values)
+	query.Set("time", strconv.FormatInt(t.Unix(), 10))
+	if id, _ := cmd.Flags().GetUint64("id"); id != 0 {
+		query.Set("id", strconv.FormatUint(id, 10))
+	}
+	r, err := doRequest(cmd, regionsTopologyPrefix+"?"+query.Encode(), http.MethodGet)
+	if err != nil {
+		return failf("Failed to get region topology: %s\n", err)
+	}
+	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
+		return printWithJQFilter(cmd, r, flag.Value.String())
+	}
+	return printResponse(cmd, r)
+}
+
+// NewRegionVerifyCommand returns a region verify subcommand of regionCmd
+func NewRegionVerifyCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "verify [--store=<store_id>]",
+		Short: "verify the region epochs in PD against the local region epochs of the stores by their status servers, and show the stale, divergent or missing ones",
+		RunE:  verifyRegionsCommandFunc,
+	}
+	r.Flags().Uint64("store", 0, "only verify the store with the id")
+	r.Flags().String("jq", "", "jq query")
+	return r
+}
+
+func verifyRegionsCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	prefix := regionsVerifyPrefix
+	if storeID, _ := cmd.Flags().GetUint64("store"); storeID != 0 {
+		prefix += "?store=" + strconv.FormatUint(storeID, 10)
+	}
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to verify regions: %s\n", err)
+	}
+	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
+		return printWithJQFilter(cmd, r, flag.Value.String())
+	}
+	return printResponse(cmd, r)
+}
+
+// NewRegionsWithIDsCommand returns regions with ids subcommand of regionCmd
+func NewRegionsWithIDsCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "ids <region_id>[,<region_id>...]",
+		Short: "show the regions with the given ids",
+		RunE:  showRegionsWithIDsCommandFunc,
+	}
+	return r
+}
+
+func showRegionsWithIDsCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	var ids []string
+	for _, arg := range args {
+		for _, id := range strings.Split(arg, ",") {
+			if id = strings.TrimSpace(id); id == "" {
+				continue
+			}
+			if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+				return usageErrorln("region_id should be a number")
+			}
+			ids = append(ids, id)
+		}
+	}
+	prefix := regionsByIDsPrefix + "?ids=" + strings.Join(ids, ",")
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get regions: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+// NewRegionStatsCommand returns a stats subcommand of regionCmd
+func NewRegionStatsCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "stats [--by-store|--by-table]",
+		Short: "show the summary of the region distribution, including the size histogram and the skew among stores or tables",
+		RunE:  showRegionStatsCommandFunc,
+	}
+	r.Flags().Bool("by-store", false, "show the leaders and peers of each store")
+	r.Flags().Bool("by-table", false, "show the regions of each table")
+	return r
+}
+
+func showRegionStatsCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	byStore, _ := cmd.Flags().GetBool("by-store")
+	byTable, _ := cmd.Flags().GetBool("by-table")
+	prefix := regionsStatsPrefix
+	switch {
+	case byStore && byTable:
+		return usageErrorln("only one of --by-store and --by-table can be set")
+	case byStore:
+		prefix += "?by=store"
+	case byTable:
+		prefix += "?by=table"
+	}
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get region stats: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+// NewRegionFlatCommand returns a flat subcommand of regionCmd
+func NewRegionFlatCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "flat [--anonymize --anonymize-map=<file>]",
+		Short: "dump all regions with a region per line, the output is streamed with constant memory usage",
+		RunE:  showRegionFlatCommandFunc,
+	}
+	addAnonymizeFlags(r)
+	return r
+}
+
+func showRegionFlatCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	anonymizer, err := newAnonymizer(cmd)
+	if err != nil {
+		return failf("Failed to load the mapping file: %s\n", err)
+	}
+	if anonymizer == nil {
+		_, err := doRequest(cmd, regionsPrefix+"?format=ndjson", http.MethodGet, WithResponseWriter(cmd.OutOrStdout()))
+		if err != nil {
+			return failf("Failed to dump regions: %s\n", err)
+		}
+		return nil
+	}
+	w := &anonymizeWriter{a: anonymizer, w: cmd.OutOrStdout()}
+	if _, err := doRequest(cmd, regionsPrefix+"?format=ndjson", http.MethodGet, WithResponseWriter(w)); err != nil {
+		return failf("Failed to dump regions: %s\n", err)
+	}
+	if err := w.Flush(); err != nil {
+		return failf("Failed to dump regions: %s\n", err)
+	}
+	if err := anonymizer.save(); err != nil {
+		return failf("Failed to write the mapping file: %s\n", err)
+	}
+	return nil
 }
 
 // NewRegionWithStoreCommand returns regions with store subcommand of regionCmd
 func NewRegionWithStoreCommand() *cobra.Command {
 	r := &cobra.Command{
-		Use:   "store <store_id>",
-		Short: "show the regions of a specific store",
-		Run:   showRegionWithStoreCommandFunc,
+		Use:   "store <store_id> | --label <key>=<value>[,<key>=<value>...] | --label <key>",
+		Short: "show the regions of a specific store or the stores with the labels",
+		RunE:  showRegionWithStoreCommandFunc,
 	}
+	r.Flags().StringSlice("label", nil, "show the regions which have peers on the stores with all the labels, or the region distribution per value if only a key is given")
 	return r
 }
 
-func showRegionWithStoreCommandFunc(cmd *cobra.Command, args []string) {
+func showRegionWithStoreCommandFunc(cmd *cobra.Command, args []string) error {
+	if labels, _ := cmd.Flags().GetStringSlice("label"); len(labels) > 0 && len(args) == 0 {
+		if len(labels) == 1 && !strings.Contains(labels[0], "=") {
+			return showRegionDistributionByLabel(cmd, labels[0])
+		}
+		return showRegionWithStoreLabels(cmd, labels)
+	}
 	if len(args) != 1 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	storeID := args[0]
 	prefix := regionsStorePrefix + "/" + storeID
 	r, err := doRequest(cmd, prefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get regions with the given storeID: %s\n", err)
-		return
+		return failf("Failed to get regions with the given storeID: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
-func printWithJQFilter(data, filter string) {
-	cmd := exec.Command("jq", "-c", filter)
-	stdin, err := cmd.StdinPipe()
+func showRegionWithStoreLabels(cmd *cobra.Command, labels []string) error {
+	query := url.Values{}
+	for _, label := range labels {
+		if !strings.Contains(label, "=") {
+			return usageErrorf("Invalid label %s, it should be key=value\n", label)
+		}
+		query.Add("label", label)
+	}
+	r, err := doRequest(cmd, regionsLabelPrefix+"?"+query.Encode(), http.MethodGet)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return failf("Failed to get regions with the given labels: %s\n", err)
 	}
+	return printResponse(cmd, r)
+}
 
-	go func() {
-		defer stdin.Close()
-		_, err = io.WriteString(stdin, data)
-		if err != nil {
-			fmt.Println(err)
+// labelDistribution is the sum of the regions on the stores with a label value.
+type labelDistribution struct {
+	Label       string `json:"label"`
+	StoreCount  int    `json:"store_count"`
+	LeaderCount int    `json:"leader_count"`
+	RegionCount int    `json:"region_count"`
+	LeaderSize  int64  `json:"leader_size"`
+	RegionSize  int64  `json:"region_size"`
+}
+
+// showRegionDistributionByLabel shows the region distribution per value of the
+// label key, the stores without the label are counted as an empty value.
+func showRegionDistributionByLabel(cmd *cobra.Command, key string) error {
+	r, err := doRequest(cmd, storesPrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get stores: %s\n", err)
+	}
+	var stores struct {
+		Stores []struct {
+			Store struct {
+				Labels []struct {
+					Key   string `json:"key"`
+					Value string `json:"value"`
+				} `json:"labels"`
+			} `json:"store"`
+			Status struct {
+				LeaderCount int   `json:"leader_count"`
+				RegionCount int   `json:"region_count"`
+				LeaderSize  int64 `json:"leader_size"`
+				RegionSize  int64 `json:"region_size"`
+			} `json:"status"`
+		} `json:"stores"`
+	}
+	if err := json.Unmarshal([]byte(r), &stores); err != nil {
+		return failf("Failed to unmarshal stores: %s\n", err)
+	}
+	distributions := make(map[string]*labelDistribution)
+	for _, store := range stores.Stores {
+		var value string
+		for _, label := range store.Store.Labels {
+			if label.Key == key {
+				value = label.Value
+			}
+		}
+		d, ok := distributions[value]
+		if !ok {
+			d = &labelDistribution{Label: key + "=" + value}
+			distributions[value] = d
 		}
-	}()
+		d.StoreCount++
+		d.LeaderCount += store.Status.LeaderCount
+		d.RegionCount += store.Status.RegionCount
+		d.LeaderSize += store.Status.LeaderSize
+		d.RegionSize += store.Status.RegionSize
+	}
+	result := make([]*labelDistribution, 0, len(distributions))
+	for _, d := range distributions {
+		result = append(result, d)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Label < result[j].Label })
+	data, err := json.Marshal(result)
+	if err != nil {
+		return failf("Failed to marshal distribution: %s\n", err)
+	}
+	return printResponse(cmd, string(data))
+}
 
-	out, err := cmd.CombinedOutput()
+// decodeRegionKeys annotates the regions in the response with the table and
+// index which their start keys belong to, if the decode flag is set.
+func decodeRegionKeys(cmd *cobra.Command, data string) string {
+	if decode, _ := cmd.Flags().GetBool("decode"); !decode {
+		return data
+	}
+	v, err := decodeOrdered(strings.TrimSpace(data))
 	if err != nil {
-		fmt.Println(string(out), err)
-		return
+		return data
+	}
+	var buf bytes.Buffer
+	writeCompact(&buf, annotateRegions(v))
+	return buf.String()
+}
+
+// annotateRegions annotates a region or a list of regions decoded from the
+// response, the list may be wrapped in an object like the regions API does.
+func annotateRegions(v interface{}) interface{} {
+	if regions, ok := unwrapList(v); ok {
+		for i := range regions {
+			regions[i] = annotateRegion(regions[i])
+		}
+		return v
 	}
+	return annotateRegion(v)
+}
 
-	fmt.Printf("%s\n", out)
+// annotateRegion adds the table_id and index_id fields after the end key of
+// the region, they are omitted if the start key is not a table key.
+func annotateRegion(v interface{}) interface{} {
+	region, ok := v.(orderedObject)
+	if !ok {
+		return v
+	}
+	var startKey string
+	for _, f := range region {
+		if f.key == "start_key" {
+			startKey, _ = f.value.(string)
+		}
+	}
+	key, err := hex.DecodeString(startKey)
+	if err != nil {
+		return v
+	}
+	tableID := codec.Key(key).TableID()
+	if tableID == 0 {
+		return v
+	}
+	fields := orderedObject{{key: "table_id", value: tableID}}
+	if indexID := codec.Key(key).IndexID(); indexID != 0 {
+		fields = append(fields, orderedField{key: "index_id", value: indexID})
+	}
+	annotated := make(orderedObject, 0, len(region)+len(fields))
+	for _, f := range region {
+		annotated = append(annotated, f)
+		if f.key == "end_key" {
+			annotated = append(annotated, fields...)
+			fields = nil
+		}
+	}
+	return append(annotated, fields...)
+}
+
+// printWithJQFilter filters the JSON data with a jq query and prints each
+// result in a compact line, which is the same as `jq -c`.
+func printWithJQFilter(cmd *cobra.Command, data, filter string) error {
+	query, err := gojq.Parse(filter)
+	if err != nil {
+		return failf("Failed to parse jq query: %s\n", err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return failf("Failed to compile jq query: %s\n", err)
+	}
+	data = decodeRegionKeys(cmd, data)
+	var input interface{}
+	if err := json.Unmarshal([]byte(data), &input); err != nil {
+		return failf("Failed to parse the response as JSON: %s\n", err)
+	}
+	iter := code.Run(input)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			return nil
+		}
+		if err, ok := v.(error); ok {
+			return failln(err)
+		}
+		out, err := json.Marshal(v)
+		if err != nil {
+			return failln(err)
+		}
+		cmd.Println(string(out))
+	}
 }
@@ -0,0 +1,269 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/spf13/cobra"
+)
+
+// dumpedRegion is a region in the output of `region` or `region flat`.
+type dumpedRegion struct {
+	ID       uint64         `json:"id"`
+	StartKey string         `json:"start_key"`
+	EndKey   string         `json:"end_key"`
+	Peers    []*metapb.Peer `json:"peers"`
+	Leader   *metapb.Peer   `json:"leader"`
+}
+
+// regionSplit is a region of the earlier dump which is split into several
+// regions of the later one.
+type regionSplit struct {
+	RegionID uint64   `json:"region_id"`
+	Into     []uint64 `json:"into"`
+}
+
+// regionMerge is a region of the later dump which several regions of the
+// earlier one are merged into.
+type regionMerge struct {
+	RegionID uint64   `json:"region_id"`
+	From     []uint64 `json:"from"`
+}
+
+type regionLeaderMove struct {
+	RegionID  uint64 `json:"region_id"`
+	FromStore uint64 `json:"from_store"`
+	ToStore   uint64 `json:"to_store"`
+}
+
+type regionPeerChange struct {
+	RegionID      uint64   `json:"region_id"`
+	AddedStores   []uint64 `json:"added_stores,omitempty"`
+	RemovedStores []uint64 `json:"removed_stores,omitempty"`
+}
+
+// regionComparison is the difference between two dumps of the regions.
+type regionComparison struct {
+	BeforeCount int                 `json:"before_count"`
+	AfterCount  int                 `json:"after_count"`
+	Splits      []*regionSplit      `json:"splits"`
+	Merges      []*regionMerge      `json:"merges"`
+	LeaderMoves []*regionLeaderMove `json:"leader_moves"`
+	PeerChanges []*regionPeerChange `json:"peer_changes"`
+}
+
+// NewRegionCompareCommand returns a compare subcommand of regionCmd.
+func NewRegionCompareCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "compare --before=<file> --after=<file>",
+		Short: "compare two dumps of the regions and show the splits, merges, leader moves and peer changes between them",
+		Long: "compare two dumps of the regions and show the splits, merges, leader moves and peer changes between them. " +
+			"The dumps are the outputs of `region` or `region flat` without --decode or --anonymize. " +
+			"A region is reported as split if it overlaps several regions of the later dump, and merged if it overlaps several regions of the earlier dump.",
+		RunE: compareRegionsCommandFunc,
+	}
+	r.Flags().String("before", "", "the earlier dump of the regions")
+	r.Flags().String("after", "", "the later dump of the regions")
+	return r
+}
+
+func compareRegionsCommandFunc(cmd *cobra.Command, args []string) error {
+	beforeFile, _ := cmd.Flags().GetString("before")
+	afterFile, _ := cmd.Flags().GetString("after")
+	if len(args) != 0 || beforeFile == "" || afterFile == "" {
+		return usageErrorln(cmd.UsageString())
+	}
+	before, err := loadRegionDump(beforeFile)
+	if err != nil {
+		return failf("Failed to load %s: %s\n", beforeFile, err)
+	}
+	after, err := loadRegionDump(afterFile)
+	if err != nil {
+		return failf("Failed to load %s: %s\n", afterFile, err)
+	}
+	data, err := json.MarshalIndent(compareRegions(before, after), "", "  ")
+	if err != nil {
+		return failf("Failed to marshal the comparison: %s\n", err)
+	}
+	return printResponse(cmd, string(data))
+}
+
+// loadRegionDump loads the regions from the output of `region`, which is a
+// JSON object with all the regions, or `region flat`, which has a region per
+// line.
+func loadRegionDump(file string) ([]*dumpedRegion, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	head, err := r.Peek(64)
+	if err != nil && err != io.EOF {
+		return nil, errors.WithStack(err)
+	}
+	d := json.NewDecoder(r)
+	if bytes.Contains(head, []byte(`"count"`)) {
+		var dump struct {
+			Regions []*dumpedRegion `json:"regions"`
+		}
+		if err := d.Decode(&dump); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return dump.Regions, nil
+	}
+	var regions []*dumpedRegion
+	for d.More() {
+		region := &dumpedRegion{}
+		if err := d.Decode(region); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		regions = append(regions, region)
+	}
+	return regions, nil
+}
+
+// compareRegions matches the regions of the two dumps by their key ranges for
+// the splits and the merges, and by their IDs for the leaders and the peers.
+func compareRegions(before, after []*dumpedRegion) *regionComparison {
+	result := &regionComparison{
+		BeforeCount: len(before),
+		AfterCount:  len(after),
+		Splits:      []*regionSplit{},
+		Merges:      []*regionMerge{},
+		LeaderMoves: []*regionLeaderMove{},
+		PeerChanges: []*regionPeerChange{},
+	}
+
+	overlapsAfter, overlapsBefore := overlapRegions(before, after)
+	for _, b := range before {
+		if ids := overlapsAfter[b.ID]; len(ids) > 1 {
+			result.Splits = append(result.Splits, &regionSplit{RegionID: b.ID, Into: ids})
+		}
+	}
+	for _, a := range after {
+		if ids := overlapsBefore[a.ID]; len(ids) > 1 {
+			result.Merges = append(result.Merges, &regionMerge{RegionID: a.ID, From: ids})
+		}
+	}
+
+	afterByID := make(map[uint64]*dumpedRegion, len(after))
+	for _, a := range after {
+		afterByID[a.ID] = a
+	}
+	for _, b := range before {
+		a, ok := afterByID[b.ID]
+		if !ok {
+			continue
+		}
+		if from, to := b.Leader.GetStoreId(), a.Leader.GetStoreId(); from != 0 && to != 0 && from != to {
+			result.LeaderMoves = append(result.LeaderMoves, &regionLeaderMove{RegionID: b.ID, FromStore: from, ToStore: to})
+		}
+		added, removed := diffPeerStores(b.Peers, a.Peers)
+		if len(added) > 0 || len(removed) > 0 {
+			result.PeerChanges = append(result.PeerChanges, &regionPeerChange{RegionID: b.ID, AddedStores: added, RemovedStores: removed})
+		}
+	}
+	sort.Slice(result.Splits, func(i, j int) bool { return result.Splits[i].RegionID < result.Splits[j].RegionID })
+	sort.Slice(result.Merges, func(i, j int) bool { return result.Merges[i].RegionID < result.Merges[j].RegionID })
+	sort.Slice(result.LeaderMoves, func(i, j int) bool { return result.LeaderMoves[i].RegionID < result.LeaderMoves[j].RegionID })
+	sort.Slice(result.PeerChanges, func(i, j int) bool { return result.PeerChanges[i].RegionID < result.PeerChanges[j].RegionID })
+	return result
+}
+
+// overlapRegions returns the IDs of the regions of the later dump which each
+// region of the earlier dump overlaps, and the reverse, in the key order. The
+// keys are hex encoded, so they are ordered as the raw keys.
+func overlapRegions(before, after []*dumpedRegion) (map[uint64][]uint64, map[uint64][]uint64) {
+	before, after = sortRegionsByKey(before), sortRegionsByKey(after)
+	overlapsAfter := make(map[uint64][]uint64)
+	overlapsBefore := make(map[uint64][]uint64)
+	for i, j := 0, 0; i < len(before) && j < len(after); {
+		b, a := before[i], after[j]
+		if startsBefore(b.StartKey, a.EndKey) && startsBefore(a.StartKey, b.EndKey) {
+			overlapsAfter[b.ID] = append(overlapsAfter[b.ID], a.ID)
+			overlapsBefore[a.ID] = append(overlapsBefore[a.ID], b.ID)
+		}
+		switch c := compareEndKey(b.EndKey, a.EndKey); {
+		case c < 0:
+			i++
+		case c > 0:
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return overlapsAfter, overlapsBefore
+}
+
+func sortRegionsByKey(regions []*dumpedRegion) []*dumpedRegion {
+	sorted := append([]*dumpedRegion(nil), regions...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return strings.ToUpper(sorted[i].StartKey) < strings.ToUpper(sorted[j].StartKey)
+	})
+	return sorted
+}
+
+// startsBefore returns whether the range with the start key begins before the
+// end key, the keys are hex encoded and the empty end key is the greatest.
+func startsBefore(startKey, endKey string) bool {
+	return endKey == "" || strings.ToUpper(startKey) < strings.ToUpper(endKey)
+}
+
+// compareEndKey compares the hex encoded end keys, the empty one is the
+// greatest.
+func compareEndKey(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	return strings.Compare(strings.ToUpper(a), strings.ToUpper(b))
+}
+
+// diffPeerStores returns the stores which the peers are added to and removed
+// from.
+func diffPeerStores(before, after []*metapb.Peer) (added, removed []uint64) {
+	stores := make(map[uint64]int)
+	for _, p := range before {
+		stores[p.GetStoreId()]--
+	}
+	for _, p := range after {
+		stores[p.GetStoreId()]++
+	}
+	for storeID, n := range stores {
+		switch {
+		case n > 0:
+			added = append(added, storeID)
+		case n < 0:
+			removed = append(removed, storeID)
+		}
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i] < added[j] })
+	sort.Slice(removed, func(i, j int) bool { return removed[i] < removed[j] })
+	return added, removed
+}
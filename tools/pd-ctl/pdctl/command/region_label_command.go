@@ -0,0 +1,128 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	regionLabelRulesPrefix = "pd/api/v1/config/region-label/rules"
+	regionLabelRulePrefix  = "pd/api/v1/config/region-label/rule"
+)
+
+// NewRegionLabelCommand returns a region-label subcommand of rootCmd and
+// configCmd.
+func NewRegionLabelCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "region-label",
+		Short: "region label rules, the regions overlapping with a key range labeled with `schedule=deny` are not scheduled",
+	}
+	show := &cobra.Command{
+		Use:   "show [id]",
+		Short: "show region label rule(s)",
+		RunE:  showRegionLabelRuleCommandFunc,
+	}
+	set := &cobra.Command{
+		Use:   "set [<id>] <key>=<value>... [--format=raw|encode|hex] [--start-key=<key>] [--end-key=<key>] [--ttl=<duration>]",
+		Short: "add or update a region label rule which attaches the labels to the regions overlapping with the key range [start-key, end-key), e.g. `set schedule=deny --start-key=7480 --end-key=7481 --ttl=2h` pins the range in place for 2 hours, the id is generated by the range if it is omitted",
+		RunE:  setRegionLabelRuleCommandFunc,
+	}
+	set.Flags().String("format", "hex", "the key format")
+	set.Flags().String("start-key", "", "the start key of the labeled range")
+	set.Flags().String("end-key", "", "the end key of the labeled range")
+	set.Flags().Duration("ttl", 0, "how long the rule lasts, 0 means it never expires")
+	del := &cobra.Command{
+		Use:   "delete <id>",
+		Short: "delete a region label rule",
+		RunE:  deleteRegionLabelRuleCommandFunc,
+	}
+	c.AddCommand(show, set, del)
+	return c
+}
+
+func showRegionLabelRuleCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return usageErrorln(cmd.UsageString())
+	}
+	reqPath := regionLabelRulesPrefix
+	if len(args) > 0 {
+		reqPath = path.Join(regionLabelRulePrefix, args[0])
+	}
+	r, err := doRequest(cmd, reqPath, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get region label rules: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+func setRegionLabelRuleCommandFunc(cmd *cobra.Command, args []string) error {
+	var id string
+	if len(args) > 0 && !strings.Contains(args[0], "=") {
+		id, args = args[0], args[1:]
+	}
+	if len(args) == 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	labels := make([]map[string]string, 0, len(args))
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return usageErrorf("Invalid label %s, should be <key>=<value>\n", arg)
+		}
+		labels = append(labels, map[string]string{"key": kv[0], "value": kv[1]})
+	}
+	startKey, endKey, err := parseKeyRangeFlags(cmd)
+	if err != nil {
+		return usageErrorln("Error: ", err)
+	}
+	if id == "" {
+		id = "range-" + startKey + "-" + endKey
+	}
+	input := map[string]interface{}{
+		"id":        id,
+		"labels":    labels,
+		"start_key": startKey,
+		"end_key":   endKey,
+	}
+	if ttl, _ := cmd.Flags().GetDuration("ttl"); ttl > 0 {
+		input["ttl"] = ttl.String()
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return failln(err)
+	}
+	if _, err := doRequest(cmd, regionLabelRulePrefix, http.MethodPost, WithBody("application/json", bytes.NewBuffer(data))); err != nil {
+		return failf("Failed! %s\n", err)
+	}
+	cmd.Printf("Success! The region label rule is %s\n", id)
+	return nil
+}
+
+func deleteRegionLabelRuleCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return usageErrorln(cmd.UsageString())
+	}
+	r, err := doRequest(cmd, path.Join(regionLabelRulePrefix, args[0]), http.MethodDelete)
+	if err != nil {
+		return failf("Failed to delete region label rule: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
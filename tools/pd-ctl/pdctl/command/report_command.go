@@ -0,0 +1,60 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"net/http"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportReplicationPrefix = "pd/api/v1/report/replication"
+)
+
+// NewReportCommand return a report subcommand of rootCmd
+func NewReportCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "report <subcommand>",
+		Short: "show the reports of the cluster",
+	}
+	r.AddCommand(NewReportReplicationCommand())
+	return r
+}
+
+// NewReportReplicationCommand return a replication subcommand of reportCmd
+func NewReportReplicationCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "replication [--by-table]",
+		Short: "show the replication health of the cluster",
+		RunE:  showReportReplicationCommandFunc,
+	}
+	r.Flags().Bool("by-table", false, "group the regions by table")
+	return r
+}
+
+func showReportReplicationCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	prefix := reportReplicationPrefix
+	if byTable, _ := cmd.Flags().GetBool("by-table"); byTable {
+		prefix += "?by=table"
+	}
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get replication report: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tikv/pd/pkg/apiutil/serverapi"
+)
+
+type requestProfileKey struct{}
+
+// requestProfile is the timing of a request, the phases of the connection are
+// absent if the connection is reused.
+type requestProfile struct {
+	sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	firstByte    time.Time
+	reused       bool
+}
+
+func (p *requestProfile) trace() *httptrace.ClientTrace {
+	record := func(t *time.Time) {
+		p.Lock()
+		defer p.Unlock()
+		*t = time.Now()
+	}
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			p.Lock()
+			defer p.Unlock()
+			p.reused = info.Reused
+		},
+		DNSStart:             func(httptrace.DNSStartInfo) { record(&p.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { record(&p.dnsDone) },
+		ConnectStart:         func(string, string) { record(&p.connectStart) },
+		ConnectDone:          func(string, string, error) { record(&p.connectDone) },
+		TLSHandshakeStart:    func() { record(&p.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { record(&p.tlsDone) },
+		GotFirstResponseByte: func() { record(&p.firstByte) },
+	}
+}
+
+// report formats the timing of the request which is finished at the end.
+func (p *requestProfile) report(req *http.Request, resp *http.Response, end time.Time) string {
+	p.Lock()
+	defer p.Unlock()
+	phase := func(start, done time.Time) string {
+		if start.IsZero() || done.IsZero() {
+			return "-"
+		}
+		return done.Sub(start).Round(time.Microsecond).String()
+	}
+	parts := []string{
+		"dns " + phase(p.dnsStart, p.dnsDone),
+		"connect " + phase(p.connectStart, p.connectDone),
+		"tls " + phase(p.tlsStart, p.tlsDone),
+		"ttfb " + phase(p.start, p.firstByte),
+		"total " + phase(p.start, end),
+	}
+	server := "-"
+	if d, err := time.ParseDuration(resp.Header.Get(serverapi.HandleTimeHeader)); err == nil {
+		server = d.Round(time.Microsecond).String()
+	}
+	parts = append(parts, "server "+server)
+	if p.reused {
+		parts = append(parts, "reused connection")
+	}
+	return fmt.Sprintf("[profile] %s %s [%d]: %s\n", req.Method, req.URL.Path, resp.StatusCode, strings.Join(parts, ", "))
+}
+
+// profiledBody reports the timing of the request after the response body is
+// read and closed, so the total time includes receiving the body.
+type profiledBody struct {
+	io.ReadCloser
+	once    sync.Once
+	w       io.Writer
+	req     *http.Request
+	resp    *http.Response
+	profile *requestProfile
+}
+
+func (b *profiledBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		fmt.Fprint(b.w, b.profile.report(b.req, b.resp, time.Now()))
+	})
+	return err
+}
+
+// profileRequest traces the request if `--profile-requests` is set for the
+// command sending it, the timing is written to the writer in the context when
+// the response body is closed.
+func profileRequest(req *http.Request) (*http.Request, func(*http.Response) *http.Response) {
+	w, ok := req.Context().Value(requestProfileKey{}).(io.Writer)
+	if !ok {
+		return req, func(resp *http.Response) *http.Response { return resp }
+	}
+	profile := &requestProfile{start: time.Now()}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), profile.trace()))
+	return req, func(resp *http.Response) *http.Response {
+		resp.Body = &profiledBody{ReadCloser: resp.Body, w: w, req: req, resp: resp, profile: profile}
+		return resp
+	}
+}
@@ -22,7 +22,9 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/pingcap/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -51,30 +53,47 @@ func NewSchedulerCommand() *cobra.Command {
 // NewPauseSchedulerCommand returns a command to pause a scheduler.
 func NewPauseSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
-		Use:   "pause <scheduler> <delay>",
-		Short: "pause a scheduler",
-		Run:   pauseOrResumeSchedulerCommandFunc,
+		Use:               "pause <scheduler> <delay>",
+		Short:             "pause a scheduler",
+		Long:              "pause a scheduler for a while, it is resumed automatically when the delay expires. <delay> can be seconds like '600' or a duration like '30m'",
+		RunE:              pauseOrResumeSchedulerCommandFunc,
+		ValidArgsFunction: completeSchedulerNames,
 	}
 	return c
 }
 
-func pauseOrResumeSchedulerCommandFunc(cmd *cobra.Command, args []string) {
+func pauseOrResumeSchedulerCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 2 && len(args) != 1 {
-		cmd.Usage()
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	path := schedulersPrefix + "/" + args[0]
 	input := make(map[string]interface{})
 	input["delay"] = 0
 	if len(args) == 2 {
-		delay, err := strconv.Atoi(args[1])
+		delay, err := parseSchedulerDelay(args[1])
 		if err != nil {
-			cmd.Usage()
-			return
+			return failln(err)
 		}
 		input["delay"] = delay
 	}
-	postJSON(cmd, path, input)
+	return postJSON(cmd, path, input)
+}
+
+// parseSchedulerDelay parses the delay in seconds, the delay can be seconds
+// or a duration like "30m".
+func parseSchedulerDelay(s string) (int64, error) {
+	delay, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		d, e := time.ParseDuration(s)
+		if e != nil {
+			return 0, errors.Errorf("invalid delay %s, it should be seconds or a duration like 30m", s)
+		}
+		delay = int64(d / time.Second)
+	}
+	if delay <= 0 {
+		return 0, errors.Errorf("invalid delay %s, it should be at least 1 second", s)
+	}
+	return delay, nil
 }
 
 // NewShowSchedulerCommand returns a command to show schedulers.
@@ -82,7 +101,7 @@ func NewShowSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "show",
 		Short: "show schedulers",
-		Run:   showSchedulerCommandFunc,
+		RunE:  showSchedulerCommandFunc,
 	}
 	c.Flags().String("status", "", "the scheduler status")
 	return c
@@ -91,17 +110,17 @@ func NewShowSchedulerCommand() *cobra.Command {
 // NewResumeSchedulerCommand returns a command to resume a scheduler.
 func NewResumeSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
-		Use:   "resume <scheduler>",
-		Short: "resume a scheduler",
-		Run:   pauseOrResumeSchedulerCommandFunc,
+		Use:               "resume <scheduler>",
+		Short:             "resume a scheduler",
+		RunE:              pauseOrResumeSchedulerCommandFunc,
+		ValidArgsFunction: completeSchedulerNames,
 	}
 	return c
 }
 
-func showSchedulerCommandFunc(cmd *cobra.Command, args []string) {
+func showSchedulerCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 0 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	url := schedulersPrefix
@@ -110,10 +129,9 @@ func showSchedulerCommandFunc(cmd *cobra.Command, args []string) {
 	}
 	r, err := doRequest(cmd, url, http.MethodGet)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
 // NewAddSchedulerCommand returns a command to add scheduler.
@@ -139,9 +157,10 @@ func NewAddSchedulerCommand() *cobra.Command {
 // NewGrantLeaderSchedulerCommand returns a command to add a grant-leader-scheduler.
 func NewGrantLeaderSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
-		Use:   "grant-leader-scheduler <store_id>",
-		Short: "add a scheduler to grant leader to a store",
-		Run:   addSchedulerForStoreCommandFunc,
+		Use:               "grant-leader-scheduler <store_id>",
+		Short:             "add a scheduler to grant leader to a store",
+		RunE:              addSchedulerForStoreCommandFunc,
+		ValidArgsFunction: completeStoreIDs,
 	}
 	return c
 }
@@ -149,9 +168,10 @@ func NewGrantLeaderSchedulerCommand() *cobra.Command {
 // NewEvictLeaderSchedulerCommand returns a command to add a evict-leader-scheduler.
 func NewEvictLeaderSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
-		Use:   "evict-leader-scheduler <store_id>",
-		Short: "add a scheduler to evict leader from a store",
-		Run:   addSchedulerForStoreCommandFunc,
+		Use:               "evict-leader-scheduler <store_id>",
+		Short:             "add a scheduler to evict leader from a store",
+		RunE:              addSchedulerForStoreCommandFunc,
+		ValidArgsFunction: completeStoreIDs,
 	}
 	return c
 }
@@ -159,7 +179,6 @@ func NewEvictLeaderSchedulerCommand() *cobra.Command {
 func checkSchedulerExist(cmd *cobra.Command, schedulerName string) (bool, error) {
 	r, err := doRequest(cmd, schedulersPrefix, http.MethodGet)
 	if err != nil {
-		cmd.Println(err)
 		return false, err
 	}
 	var schedulerList []string
@@ -172,10 +191,9 @@ func checkSchedulerExist(cmd *cobra.Command, schedulerName string) (bool, error)
 	return false, nil
 }
 
-func addSchedulerForStoreCommandFunc(cmd *cobra.Command, args []string) {
+func addSchedulerForStoreCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	// we should ensure whether it is the first time to create evict-leader-scheduler
 	// or just update the evict-leader. But is add one ttl time.
@@ -183,25 +201,26 @@ func addSchedulerForStoreCommandFunc(cmd *cobra.Command, args []string) {
 	case evictLeaderSchedulerName, grantLeaderSchedulerName:
 		exist, err := checkSchedulerExist(cmd, cmd.Name())
 		if err != nil {
-			return
+			return failln(err)
 		}
 		if exist {
-			addStoreToSchedulerConfig(cmd, cmd.Name(), args)
-			return
+			return addStoreToSchedulerConfig(cmd, cmd.Name(), args)
 		}
 		fallthrough
 	default:
 		storeID, err := strconv.ParseUint(args[0], 10, 64)
 		if err != nil {
-			cmd.Println(err)
-			return
+			return failln(err)
 		}
 
 		input := make(map[string]interface{})
 		input["name"] = cmd.Name()
 		input["store_id"] = storeID
-		postJSON(cmd, schedulersPrefix, input)
+		if err := postJSON(cmd, schedulersPrefix, input); err != nil {
+			return err
+		}
 	}
+	return nil
 
 }
 
@@ -210,7 +229,7 @@ func NewShuffleLeaderSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "shuffle-leader-scheduler",
 		Short: "add a scheduler to shuffle leaders between stores",
-		Run:   addSchedulerCommandFunc,
+		RunE:  addSchedulerCommandFunc,
 	}
 	return c
 }
@@ -220,7 +239,7 @@ func NewShuffleRegionSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "shuffle-region-scheduler",
 		Short: "add a scheduler to shuffle regions between stores",
-		Run:   addSchedulerCommandFunc,
+		RunE:  addSchedulerCommandFunc,
 	}
 	return c
 }
@@ -230,29 +249,27 @@ func NewShuffleHotRegionSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "shuffle-hot-region-scheduler [limit]",
 		Short: "add a scheduler to shuffle hot regions",
-		Run:   addSchedulerForShuffleHotRegionCommandFunc,
+		RunE:  addSchedulerForShuffleHotRegionCommandFunc,
 	}
 	return c
 }
 
-func addSchedulerForShuffleHotRegionCommandFunc(cmd *cobra.Command, args []string) {
+func addSchedulerForShuffleHotRegionCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) > 1 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	limit := uint64(1)
 	if len(args) == 1 {
 		l, err := strconv.ParseUint(args[0], 10, 64)
 		if err != nil {
-			cmd.Println("Error: ", err)
-			return
+			return usageErrorln("Error: ", err)
 		}
 		limit = l
 	}
 	input := make(map[string]interface{})
 	input["name"] = cmd.Name()
 	input["limit"] = limit
-	postJSON(cmd, schedulersPrefix, input)
+	return postJSON(cmd, schedulersPrefix, input)
 }
 
 // NewBalanceLeaderSchedulerCommand returns a command to add a balance-leader-scheduler.
@@ -260,7 +277,7 @@ func NewBalanceLeaderSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "balance-leader-scheduler",
 		Short: "add a scheduler to balance leaders between stores",
-		Run:   addSchedulerCommandFunc,
+		RunE:  addSchedulerCommandFunc,
 	}
 	return c
 }
@@ -270,7 +287,7 @@ func NewBalanceRegionSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "balance-region-scheduler",
 		Short: "add a scheduler to balance regions between stores",
-		Run:   addSchedulerCommandFunc,
+		RunE:  addSchedulerCommandFunc,
 	}
 	return c
 }
@@ -280,7 +297,7 @@ func NewBalanceHotRegionSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "balance-hot-region-scheduler",
 		Short: "add a scheduler to balance hot regions between stores",
-		Run:   addSchedulerCommandFunc,
+		RunE:  addSchedulerCommandFunc,
 	}
 	return c
 }
@@ -290,7 +307,7 @@ func NewRandomMergeSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "random-merge-scheduler",
 		Short: "add a scheduler to merge regions randomly",
-		Run:   addSchedulerCommandFunc,
+		RunE:  addSchedulerCommandFunc,
 	}
 	return c
 }
@@ -300,20 +317,19 @@ func NewLabelSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "label-scheduler",
 		Short: "add a scheduler to schedule regions according to the label",
-		Run:   addSchedulerCommandFunc,
+		RunE:  addSchedulerCommandFunc,
 	}
 	return c
 }
 
-func addSchedulerCommandFunc(cmd *cobra.Command, args []string) {
+func addSchedulerCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 0 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 
 	input := make(map[string]interface{})
 	input["name"] = cmd.Name()
-	postJSON(cmd, schedulersPrefix, input)
+	return postJSON(cmd, schedulersPrefix, input)
 }
 
 // NewScatterRangeSchedulerCommand returns a command to add a scatter-range-scheduler.
@@ -321,26 +337,23 @@ func NewScatterRangeSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "scatter-range [--format=raw|encode|hex] <start_key> <end_key> <range_name>",
 		Short: "add a scheduler to scatter range",
-		Run:   addSchedulerForScatterRangeCommandFunc,
+		RunE:  addSchedulerForScatterRangeCommandFunc,
 	}
 	c.Flags().String("format", "hex", "the key format")
 	return c
 }
 
-func addSchedulerForScatterRangeCommandFunc(cmd *cobra.Command, args []string) {
+func addSchedulerForScatterRangeCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 3 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	startKey, err := parseKey(cmd.Flags(), args[0])
 	if err != nil {
-		cmd.Println("Error: ", err)
-		return
+		return usageErrorln("Error: ", err)
 	}
 	endKey, err := parseKey(cmd.Flags(), args[1])
 	if err != nil {
-		cmd.Println("Error: ", err)
-		return
+		return usageErrorln("Error: ", err)
 	}
 
 	input := make(map[string]interface{})
@@ -348,46 +361,45 @@ func addSchedulerForScatterRangeCommandFunc(cmd *cobra.Command, args []string) {
 	input["start_key"] = url.QueryEscape(startKey)
 	input["end_key"] = url.QueryEscape(endKey)
 	input["range_name"] = args[2]
-	postJSON(cmd, schedulersPrefix, input)
+	return postJSON(cmd, schedulersPrefix, input)
 }
 
 // NewRemoveSchedulerCommand returns a command to remove scheduler.
 func NewRemoveSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
-		Use:   "remove <scheduler>",
-		Short: "remove a scheduler",
-		Run:   removeSchedulerCommandFunc,
+		Use:               "remove <scheduler>",
+		Short:             "remove a scheduler",
+		RunE:              removeSchedulerCommandFunc,
+		ValidArgsFunction: completeSchedulerNames,
 	}
 	return c
 }
 
-func redirectRemoveSchedulerToDeleteConfig(cmd *cobra.Command, schedulerName string, args []string) {
+func redirectRemoveSchedulerToDeleteConfig(cmd *cobra.Command, schedulerName string, args []string) error {
 	args = strings.Split(args[0], "-")
 	args = args[len(args)-1:]
-	deleteStoreFromSchedulerConfig(cmd, schedulerName, args)
+	return deleteStoreFromSchedulerConfig(cmd, schedulerName, args)
 }
 
-func removeSchedulerCommandFunc(cmd *cobra.Command, args []string) {
+func removeSchedulerCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Println(cmd.Usage())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	// FIXME: maybe there is a more graceful method to handler it
 	switch {
 	case strings.HasPrefix(args[0], evictLeaderSchedulerName) && args[0] != evictLeaderSchedulerName:
-		redirectRemoveSchedulerToDeleteConfig(cmd, evictLeaderSchedulerName, args)
+		return redirectRemoveSchedulerToDeleteConfig(cmd, evictLeaderSchedulerName, args)
 	case strings.HasPrefix(args[0], grantLeaderSchedulerName) && args[0] != grantLeaderSchedulerName:
-		redirectRemoveSchedulerToDeleteConfig(cmd, grantLeaderSchedulerName, args)
+		return redirectRemoveSchedulerToDeleteConfig(cmd, grantLeaderSchedulerName, args)
 	default:
 		path := schedulersPrefix + "/" + args[0]
 		_, err := doRequest(cmd, path, http.MethodDelete)
 		if err != nil {
-			cmd.Println(err)
-			return
+			return failln(err)
 		}
 		cmd.Println("Success!")
 	}
-
+	return nil
 }
 
 // NewConfigSchedulerCommand returns commands to config scheduler.
@@ -409,16 +421,18 @@ func newConfigHotRegionCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "balance-hot-region-scheduler",
 		Short: "evict-leader-scheduler config",
-		Run:   listSchedulerConfigCommandFunc,
+		RunE:  listSchedulerConfigCommandFunc,
 	}
 	c.AddCommand(&cobra.Command{
 		Use:   "list",
 		Short: "list the config item",
-		Run:   listSchedulerConfigCommandFunc})
+		RunE:  listSchedulerConfigCommandFunc})
 	c.AddCommand(&cobra.Command{
 		Use:   "set <key> <value>",
 		Short: "set the config item",
-		Run:   func(cmd *cobra.Command, args []string) { postSchedulerConfigCommandFunc(cmd, c.Name(), args) }})
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return postSchedulerConfigCommandFunc(cmd, c.Name(), args)
+		}})
 	return c
 }
 
@@ -426,16 +440,19 @@ func newConfigEvictLeaderCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "evict-leader-scheduler",
 		Short: "evict-leader-scheduler config",
-		Run:   listSchedulerConfigCommandFunc,
+		RunE:  listSchedulerConfigCommandFunc,
 	}
 	c.AddCommand(&cobra.Command{
-		Use:   "add-store <store-id>",
-		Short: "add a store to evict leader list",
-		Run:   func(cmd *cobra.Command, args []string) { addStoreToSchedulerConfig(cmd, c.Name(), args) },
+		Use:               "add-store <store-id>",
+		Short:             "add a store to evict leader list",
+		RunE:              func(cmd *cobra.Command, args []string) error { return addStoreToSchedulerConfig(cmd, c.Name(), args) },
+		ValidArgsFunction: completeStoreIDs,
 	}, &cobra.Command{
 		Use:   "delete-store <store-id>",
 		Short: "delete a store from evict leader list",
-		Run:   func(cmd *cobra.Command, args []string) { deleteStoreFromSchedulerConfig(cmd, c.Name(), args) },
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return deleteStoreFromSchedulerConfig(cmd, c.Name(), args)
+		},
 	})
 	return c
 }
@@ -444,16 +461,19 @@ func newConfigGrantLeaderCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "grant-leader-scheduler",
 		Short: "grant-leader-scheduler config",
-		Run:   listSchedulerConfigCommandFunc,
+		RunE:  listSchedulerConfigCommandFunc,
 	}
 	c.AddCommand(&cobra.Command{
-		Use:   "add-store <store-id>",
-		Short: "add a store to grant leader list",
-		Run:   func(cmd *cobra.Command, args []string) { addStoreToSchedulerConfig(cmd, c.Name(), args) },
+		Use:               "add-store <store-id>",
+		Short:             "add a store to grant leader list",
+		RunE:              func(cmd *cobra.Command, args []string) error { return addStoreToSchedulerConfig(cmd, c.Name(), args) },
+		ValidArgsFunction: completeStoreIDs,
 	}, &cobra.Command{
 		Use:   "delete-store <store-id>",
 		Short: "delete a store from grant leader list",
-		Run:   func(cmd *cobra.Command, args []string) { deleteStoreFromSchedulerConfig(cmd, c.Name(), args) },
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return deleteStoreFromSchedulerConfig(cmd, c.Name(), args)
+		},
 	})
 	return c
 }
@@ -462,41 +482,38 @@ func newConfigShuffleRegionCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "shuffle-region-scheduler",
 		Short: "shuffle-region-scheduler config",
-		Run:   showShuffleRegionSchedulerRolesCommandFunc,
+		RunE:  showShuffleRegionSchedulerRolesCommandFunc,
 	}
 	c.AddCommand(&cobra.Command{
 		Use:   "show-roles",
 		Short: "show affected roles (leader, follower, learner)",
-		Run:   showShuffleRegionSchedulerRolesCommandFunc,
+		RunE:  showShuffleRegionSchedulerRolesCommandFunc,
 	}, &cobra.Command{
 		Use:   "set-roles [leader,][follower,][learner]",
 		Short: "set affected roles",
-		Run:   setShuffleRegionSchedulerRolesCommandFunc,
+		RunE:  setShuffleRegionSchedulerRolesCommandFunc,
 	})
 	return c
 }
 
-func addStoreToSchedulerConfig(cmd *cobra.Command, schedulerName string, args []string) {
+func addStoreToSchedulerConfig(cmd *cobra.Command, schedulerName string, args []string) error {
 	if len(args) != 1 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	storeID, err := strconv.ParseUint(args[0], 10, 64)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 	input := make(map[string]interface{})
 	input["name"] = schedulerName
 	input["store_id"] = storeID
 
-	postJSON(cmd, path.Join(schedulerConfigPrefix, schedulerName, "config"), input)
+	return postJSON(cmd, path.Join(schedulerConfigPrefix, schedulerName, "config"), input)
 }
 
-func listSchedulerConfigCommandFunc(cmd *cobra.Command, args []string) {
+func listSchedulerConfigCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 0 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	p := cmd.Name()
 	if p == "list" {
@@ -505,16 +522,14 @@ func listSchedulerConfigCommandFunc(cmd *cobra.Command, args []string) {
 	path := path.Join(schedulerConfigPrefix, p, "list")
 	r, err := doRequest(cmd, path, http.MethodGet)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
-func postSchedulerConfigCommandFunc(cmd *cobra.Command, schedulerName string, args []string) {
+func postSchedulerConfigCommandFunc(cmd *cobra.Command, schedulerName string, args []string) error {
 	if len(args) != 2 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	var val interface{}
 	input := make(map[string]interface{})
@@ -524,27 +539,25 @@ func postSchedulerConfigCommandFunc(cmd *cobra.Command, schedulerName string, ar
 		val = value
 	}
 	input[key] = val
-	postJSON(cmd, path.Join(schedulerConfigPrefix, schedulerName, "config"), input)
+	return postJSON(cmd, path.Join(schedulerConfigPrefix, schedulerName, "config"), input)
 }
 
-func deleteStoreFromSchedulerConfig(cmd *cobra.Command, schedulerName string, args []string) {
+func deleteStoreFromSchedulerConfig(cmd *cobra.Command, schedulerName string, args []string) error {
 	if len(args) != 1 {
-		cmd.Println(cmd.Usage())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	path := path.Join(schedulerConfigPrefix, "/", schedulerName, "delete", args[0])
 	_, err := doRequest(cmd, path, http.MethodDelete)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 	cmd.Println("Success!")
+	return nil
 }
 
-func showShuffleRegionSchedulerRolesCommandFunc(cmd *cobra.Command, args []string) {
+func showShuffleRegionSchedulerRolesCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 0 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	p := cmd.Name()
 	if p == "show-roles" {
@@ -553,16 +566,14 @@ func showShuffleRegionSchedulerRolesCommandFunc(cmd *cobra.Command, args []strin
 	path := path.Join(schedulerConfigPrefix, p, "roles")
 	r, err := doRequest(cmd, path, http.MethodGet)
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
-func setShuffleRegionSchedulerRolesCommandFunc(cmd *cobra.Command, args []string) {
+func setShuffleRegionSchedulerRolesCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Println(cmd.UsageString())
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	var roles []string
 	fields := strings.Split(strings.ToLower(args[0]), ",")
@@ -576,8 +587,8 @@ func setShuffleRegionSchedulerRolesCommandFunc(cmd *cobra.Command, args []string
 	_, err := doRequest(cmd, path, http.MethodPost,
 		WithBody("application/json", bytes.NewBuffer(b)))
 	if err != nil {
-		cmd.Println(err)
-		return
+		return failln(err)
 	}
 	cmd.Println("Success!")
+	return nil
 }
@@ -0,0 +1,49 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"net/http"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsReplicationPrefix = "pd/api/v1/stats/replication"
+)
+
+// NewStatsCommand return a stats subcommand of rootCmd
+func NewStatsCommand() *cobra.Command {
+	s := &cobra.Command{
+		Use:   "stats <subcommand>",
+		Short: "show the statistics of the cluster",
+	}
+	s.AddCommand(&cobra.Command{
+		Use:   "replication",
+		Short: "show the distribution of regions by replica count and health class",
+		RunE:  showStatsReplicationCommandFunc,
+	})
+	return s
+}
+
+func showStatsReplicationCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	r, err := doRequest(cmd, statsReplicationPrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get replication stats: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
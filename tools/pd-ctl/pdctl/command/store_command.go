@@ -17,10 +17,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/pingcap/errors"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/spf13/cobra"
 )
@@ -29,23 +32,35 @@ var (
 	storesPrefix      = "pd/api/v1/stores"
 	storesLimitPrefix = "pd/api/v1/stores/limit"
 	storePrefix       = "pd/api/v1/store/%v"
+	storeAddrPrefix   = "pd/api/v1/store/address/%v"
+	labelsLintPrefix  = "pd/api/v1/labels/lint"
 )
 
 // NewStoreCommand return a stores subcommand of rootCmd
 func NewStoreCommand() *cobra.Command {
 	s := &cobra.Command{
-		Use:   `store [command] [flags]`,
-		Short: "manipulate or query stores",
-		Run:   showStoreCommandFunc,
+		Use:               `store [command] [flags]`,
+		Short:             "manipulate or query stores",
+		RunE:              showStoreCommandFunc,
+		ValidArgsFunction: completeStoreIDs,
 	}
+	s.PersistentFlags().Bool("human", false, "render the region size and leader size in GiB")
 	s.AddCommand(NewDeleteStoreCommand())
+	s.AddCommand(NewCheckDeleteStoreCommand())
+	s.AddCommand(NewDrainStoreCommand())
 	s.AddCommand(NewLabelStoreCommand())
 	s.AddCommand(NewSetStoreWeightCommand())
 	s.AddCommand(NewStoreLimitCommand())
 	s.AddCommand(NewRemoveTombStoneCommand())
 	s.AddCommand(NewStoreLimitSceneCommand())
+	s.AddCommand(NewLintStoreLabelsCommand())
+	s.AddCommand(NewStoreConfigCommand())
+	s.AddCommand(NewStoreScoresCommand())
 	s.Flags().String("jq", "", "jq query")
 	s.Flags().StringSlice("state", nil, "state filter")
+	s.Flags().String("addr", "", "show the store with the given address")
+	s.Flags().StringSlice("labels", nil, "only show the stores with all the labels, like zone=us-west-1,disk=ssd")
+	addAnonymizeFlags(s)
 	return s
 }
 
@@ -54,7 +69,7 @@ func NewDeleteStoreByAddrCommand() *cobra.Command {
 	d := &cobra.Command{
 		Use:   "addr <address>",
 		Short: "delete store by its address",
-		Run:   deleteStoreCommandByAddrFunc,
+		RunE:  deleteStoreCommandByAddrFunc,
 	}
 	return d
 }
@@ -62,20 +77,49 @@ func NewDeleteStoreByAddrCommand() *cobra.Command {
 // NewDeleteStoreCommand return a  delete subcommand of storeCmd
 func NewDeleteStoreCommand() *cobra.Command {
 	d := &cobra.Command{
-		Use:   "delete <store_id>",
-		Short: "delete the store",
-		Run:   deleteStoreCommandFunc,
-	}
+		Use:               "delete <store_id> [--wait [--progress=text|json]]",
+		Short:             "delete the store",
+		RunE:              deleteStoreCommandFunc,
+		ValidArgsFunction: completeStoreIDs,
+	}
+	d.Flags().Bool("force", false, "set the store as Tombstone directly, only use it when the store is physically destroyed")
+	addWaitFlags(d.Flags(), "")
+	addConfirmFlag(d)
 	d.AddCommand(NewDeleteStoreByAddrCommand())
 	return d
 }
 
+// NewDrainStoreCommand returns a drain subcommand of storeCmd.
+func NewDrainStoreCommand() *cobra.Command {
+	d := &cobra.Command{
+		Use:               "drain <store_id> [--wait [--progress=text|json]]",
+		Short:             "set the store offline to move all its regions out by a drain-store job of PD, and show the progress",
+		RunE:              drainStoreCommandFunc,
+		ValidArgsFunction: completeStoreIDs,
+	}
+	addWaitFlags(d.Flags(), "text")
+	addConfirmFlag(d)
+	return d
+}
+
+// NewCheckDeleteStoreCommand returns a check-delete subcommand of storeCmd.
+func NewCheckDeleteStoreCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "check-delete <store_id>",
+		Short:             "simulate deleting the store, check whether the remaining stores can accept its regions and estimate the data to move",
+		Long:              "simulate deleting the store, check whether the remaining stores can accept its regions and estimate the data to move, it exits with 2 if the deletion is unsafe",
+		RunE:              checkDeleteStoreCommandFunc,
+		ValidArgsFunction: completeStoreIDs,
+	}
+}
+
 // NewLabelStoreCommand returns a label subcommand of storeCmd.
 func NewLabelStoreCommand() *cobra.Command {
 	l := &cobra.Command{
-		Use:   "label <store_id> <key> <value> [<key> <value>]...",
-		Short: "set a store's label value",
-		Run:   labelStoreCommandFunc,
+		Use:               "label <store_id> <key> <value> [<key> <value>]...",
+		Short:             "set a store's label value",
+		RunE:              labelStoreCommandFunc,
+		ValidArgsFunction: completeStoreIDs,
 	}
 	l.Flags().BoolP("force", "f", false, "overwrite the label forcibly")
 	return l
@@ -84,23 +128,35 @@ func NewLabelStoreCommand() *cobra.Command {
 // NewSetStoreWeightCommand returns a weight subcommand of storeCmd.
 func NewSetStoreWeightCommand() *cobra.Command {
 	return &cobra.Command{
-		Use:   "weight <store_id> <leader_weight> <region_weight>",
-		Short: "set a store's leader and region balance weight",
-		Run:   setStoreWeightCommandFunc,
+		Use:               "weight <store_id> <leader_weight> <region_weight>",
+		Short:             "set a store's leader and region balance weight",
+		RunE:              setStoreWeightCommandFunc,
+		ValidArgsFunction: completeStoreIDs,
 	}
 }
 
 // NewStoreLimitCommand returns a limit subcommand of storeCmd.
 func NewStoreLimitCommand() *cobra.Command {
 	c := &cobra.Command{
-		Use:   "limit [<type>]|[<store_id>|<all> [<key> <value>]... <limit> <type>]",
+		Use:   "limit [<type>]|[<store_id>|<all> [<key> <value>]... <limit> <type>] [--type add-peer|remove-peer]",
 		Short: "show or set a store's rate limit",
-		Long:  "show or set a store's rate limit, <type> can be 'add-peer'(default) or 'remove-peer'",
-		Run:   storeLimitCommandFunc,
+		Long:  "show or set a store's rate limit, <type> can be 'add-peer'(default) or 'remove-peer', it can also be specified by --type",
+		RunE:  storeLimitCommandFunc,
 	}
+	c.Flags().String("type", "", "the type of the limit, 'add-peer' or 'remove-peer'")
 	return c
 }
 
+// NewStoreConfigCommand returns a config subcommand of storeCmd.
+func NewStoreConfigCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "config <store_id> [set <key> <value> [<key> <value>]...|delete <key>]",
+		Short:             "show or set a store's dynamic config items",
+		RunE:              storeConfigCommandFunc,
+		ValidArgsFunction: completeStoreIDs,
+	}
+}
+
 // NewStoresCommand returns a store subcommand of rootCmd
 func NewStoresCommand() *cobra.Command {
 	s := &cobra.Command{
@@ -121,7 +177,16 @@ func NewRemoveTombStoneCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "remove-tombstone",
 		Short: "remove tombstone record if only safe",
-		Run:   removeTombStoneCommandFunc,
+		RunE:  removeTombStoneCommandFunc,
+	}
+}
+
+// NewLintStoreLabelsCommand returns a lint-labels subcommand of storeCmd.
+func NewLintStoreLabelsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "lint-labels",
+		Short: "report the label inconsistencies of stores as advice, the stores are not rejected for them",
+		RunE:  lintStoreLabelsCommandFunc,
 	}
 }
 
@@ -131,7 +196,7 @@ func NewRemoveTombStoneCommandDeprecated() *cobra.Command {
 		Use:        "remove-tombstone",
 		Short:      "remove tombstone record if only safe",
 		Deprecated: "use store remove-tombstone instead",
-		Run:        removeTombStoneCommandFunc,
+		RunE:       removeTombStoneCommandFunc,
 	}
 }
 
@@ -140,7 +205,7 @@ func NewShowStoresCommand() *cobra.Command {
 	sc := &cobra.Command{
 		Use:        "show [limit]",
 		Short:      "show the stores",
-		Run:        showStoresCommandFunc,
+		RunE:       showStoresCommandFunc,
 		Deprecated: "use store [limit] instead",
 	}
 	sc.AddCommand(NewShowAllStoresLimitCommand())
@@ -153,7 +218,7 @@ func NewShowAllStoresLimitCommand() *cobra.Command {
 		Use:        "limit <type>",
 		Short:      "show all stores' limit, <type> can be 'add-peer'(default) or 'remove-peer'",
 		Deprecated: "use store limit instead",
-		Run:        showAllStoresLimitCommandFunc,
+		RunE:       showAllStoresLimitCommandFunc,
 	}
 	return sc
 }
@@ -176,7 +241,7 @@ func NewSetAllLimitCommand() *cobra.Command {
 		Short:      "set all store's rate limit",
 		Long:       "set all store's rate limit, <type> can be 'add-peer'(default) or 'remove-peer'",
 		Deprecated: "use store limit all <rate> instead",
-		Run:        setAllLimitCommandFunc,
+		RunE:       setAllLimitCommandFunc,
 	}
 }
 
@@ -186,11 +251,11 @@ func NewStoreLimitSceneCommand() *cobra.Command {
 		Use:   "limit-scene [<type>]|[<scene> <rate> <type>]",
 		Short: "show or set the limit value for a scene",
 		Long:  "show or set the limit value for a scene, <type> can be 'add-peer'(default) or 'remove-peer'",
-		Run:   storeLimitSceneCommandFunc,
+		RunE:  storeLimitSceneCommandFunc,
 	}
 }
 
-func storeLimitSceneCommandFunc(cmd *cobra.Command, args []string) {
+func storeLimitSceneCommandFunc(cmd *cobra.Command, args []string) error {
 	var resp string
 	var err error
 	prefix := fmt.Sprintf("%s/limit/scene", storesPrefix)
@@ -203,8 +268,7 @@ func storeLimitSceneCommandFunc(cmd *cobra.Command, args []string) {
 		}
 		resp, err = doRequest(cmd, prefix, http.MethodGet)
 		if err != nil {
-			cmd.Println(err)
-			return
+			return failln(err)
 		}
 		cmd.Println(resp)
 	case 2, 3:
@@ -214,95 +278,256 @@ func storeLimitSceneCommandFunc(cmd *cobra.Command, args []string) {
 			scene != "low" &&
 			scene != "normal" &&
 			scene != "high" {
-			cmd.Println("invalid scene")
-			return
+			return usageErrorln("invalid scene")
 		}
 
 		rate, err := strconv.Atoi(args[1])
 		if err != nil {
-			cmd.Println(err)
-			return
+			return failln(err)
 		}
 		if len(args) == 3 {
-			prefix = path.Join(prefix, fmt.Sprintf("?type=%s", args[2]))
+			prefix += "?type=" + args[2]
+		}
+		if err := postJSON(cmd, prefix, map[string]interface{}{scene: rate}); err != nil {
+			return err
 		}
-		postJSON(cmd, prefix, map[string]interface{}{scene: rate})
 	}
+	return nil
 }
 
-func showStoreCommandFunc(cmd *cobra.Command, args []string) {
+func showStoreCommandFunc(cmd *cobra.Command, args []string) error {
 	prefix := storesPrefix
 	if len(args) > 1 {
-		cmd.Usage()
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	if len(args) == 1 {
 		if _, err := strconv.Atoi(args[0]); err != nil {
-			cmd.Println("store_id should be a number")
-			return
+			return usageErrorln("store_id should be a number")
 		}
 		prefix = fmt.Sprintf(storePrefix, args[0])
+	} else if addr, _ := cmd.Flags().GetString("addr"); addr != "" {
+		prefix = fmt.Sprintf(storeAddrPrefix, url.PathEscape(addr))
 	} else {
 		flags := cmd.Flags()
 		states, err := flags.GetStringSlice("state")
 		if err != nil {
-			cmd.Printf("Failed to get state: %s\n", err)
+			return failf("Failed to get state: %s\n", err)
 		}
-		stateValues := make([]string, 0, len(states))
+		query := url.Values{}
 		for _, state := range states {
 			stateValue, ok := metapb.StoreState_value[state]
 			if !ok {
-				cmd.Println("Unknown state: " + state)
-				return
+				return usageErrorln("Unknown state: " + state)
 			}
-			stateValues = append(stateValues, fmt.Sprintf("state=%v", stateValue))
+			query.Add("state", fmt.Sprint(stateValue))
 		}
-		if len(stateValues) != 0 {
-			prefix = fmt.Sprintf("%v?%v", storesPrefix, strings.Join(stateValues, "&"))
+		labels, _ := flags.GetStringSlice("labels")
+		for _, label := range labels {
+			if !strings.Contains(label, "=") {
+				return usageErrorf("Invalid label %s, it should be key=value\n", label)
+			}
+			query.Add("label", label)
+		}
+		if len(query) != 0 {
+			prefix = fmt.Sprintf("%v?%v", storesPrefix, query.Encode())
 		}
 	}
 	r, err := doRequest(cmd, prefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get store: %s\n", err)
-		return
+		return failf("Failed to get store: %s\n", err)
+	}
+	anonymizer, err := newAnonymizer(cmd)
+	if err != nil {
+		return failf("Failed to load the mapping file: %s\n", err)
+	}
+	if anonymizer != nil {
+		data, err := anonymizer.anonymize([]byte(r))
+		if err != nil {
+			return failf("Failed to anonymize store: %s\n", err)
+		}
+		r = string(data)
+		if err := anonymizer.save(); err != nil {
+			return failf("Failed to write the mapping file: %s\n", err)
+		}
 	}
 	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-		printWithJQFilter(r, flag.Value.String())
-		return
+		return printWithJQFilter(cmd, r, flag.Value.String())
+	}
+	return printResponse(cmd, r)
+}
+
+func checkDeleteStoreCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return usageErrorln(cmd.UsageString())
+	}
+	if _, err := strconv.Atoi(args[0]); err != nil {
+		return usageErrorln("store_id should be a number")
+	}
+	r, err := doRequest(cmd, fmt.Sprintf(storePrefix, args[0])+"/check-delete", http.MethodGet)
+	if err != nil {
+		return failf("Failed to check deleting store %s: %s\n", args[0], err)
+	}
+	if err := printResponse(cmd, r); err != nil {
+		return err
+	}
+	var check struct {
+		Safe bool `json:"safe"`
 	}
-	cmd.Println(r)
+	if err := json.Unmarshal([]byte(r), &check); err != nil {
+		return failf("Failed to parse the result: %s\n", err)
+	}
+	if !check.Safe {
+		return checkError(ExitCheckFailed)
+	}
+	return nil
 }
 
-func deleteStoreCommandFunc(cmd *cobra.Command, args []string) {
+func deleteStoreCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Usage()
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	if _, err := strconv.Atoi(args[0]); err != nil {
-		cmd.Println("store_id should be a number")
-		return
+		return usageErrorln("store_id should be a number")
 	}
 	prefix := fmt.Sprintf(storePrefix, args[0])
-	_, err := doRequest(cmd, prefix, http.MethodDelete)
+	wait, err := shouldWait(cmd)
 	if err != nil {
-		cmd.Printf("Failed to delete store %s: %s\n", args[0], err)
-		return
+		return failln(err)
+	}
+	var regionCount int
+	if wait {
+		state, err := getStoreRemovingState(cmd, prefix)
+		if err != nil {
+			return failf("Failed to get store %s: %s\n", args[0], err)
+		}
+		regionCount = state.Status.RegionCount
+	}
+	if force, _ := cmd.Flags().GetBool("force"); force {
+		if err := confirm(cmd, fmt.Sprintf("set store %s as Tombstone and it can never be brought back", args[0]), args[0]); err != nil {
+			return err
+		}
+		_, err = doConfirmedRequest(cmd, prefix+"?force=true", http.MethodDelete, nil)
+	} else {
+		if err := confirm(cmd, fmt.Sprintf("delete store %s", args[0]), args[0]); err != nil {
+			return err
+		}
+		_, err = doRequest(cmd, prefix, http.MethodDelete)
+	}
+	if err != nil {
+		return failf("Failed to delete store %s: %s\n", args[0], err)
 	}
 	cmd.Println("Success!")
+	if !wait {
+		return nil
+	}
+	if err := waitFor(cmd, func() (float64, string, bool, error) {
+		state, err := getStoreRemovingState(cmd, prefix)
+		if err != nil {
+			return 0, "", false, err
+		}
+		if state.Store.StateName == metapb.StoreState_Tombstone.String() {
+			return 100, state.Store.StateName, true, nil
+		}
+		var percent float64
+		if regionCount > 0 && state.Status.RegionCount < regionCount {
+			percent = float64(regionCount-state.Status.RegionCount) * 100 / float64(regionCount)
+		}
+		return percent, fmt.Sprintf("%s, %d regions left", state.Store.StateName, state.Status.RegionCount), false, nil
+	}); err != nil {
+		return failf("Failed to wait store %s: %s\n", args[0], err)
+	}
+	cmd.Printf("Store %s is Tombstone now\n", args[0])
+	return nil
 }
 
-func deleteStoreCommandByAddrFunc(cmd *cobra.Command, args []string) {
+func drainStoreCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Usage()
-		return
+		return usageErrorln(cmd.UsageString())
+	}
+	storeID, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return usageErrorln("store_id should be a number")
+	}
+	prefix := fmt.Sprintf(storePrefix, args[0])
+	wait, err := shouldWait(cmd)
+	if err != nil {
+		return failln(err)
+	}
+	state, err := getStoreRemovingState(cmd, prefix)
+	if err != nil {
+		return failf("Failed to get store %s: %s\n", args[0], err)
+	}
+	switch state.Store.StateName {
+	case metapb.StoreState_Tombstone.String():
+		cmd.Printf("Store %s is already Tombstone\n", args[0])
+		return nil
+	case metapb.StoreState_Up.String():
+		if err := confirm(cmd, fmt.Sprintf("drain store %s", args[0]), args[0]); err != nil {
+			return err
+		}
+	}
+	// The store is drained by a job of PD, which keeps running if the
+	// command is interrupted.
+	id, err := submitJob(cmd, drainStoreJob, map[string]interface{}{"store_id": storeID})
+	if err != nil {
+		return failf("Failed to drain store %s: %s\n", args[0], err)
+	}
+	cmd.Printf("Store %s is draining by job %d, %d regions left\n", args[0], id, state.Status.RegionCount)
+	if !wait {
+		return nil
+	}
+	_, j, err := waitForJob(cmd, id, func(j *jobState) string {
+		var cp struct {
+			Left int `json:"left"`
+		}
+		if err := json.Unmarshal(j.Checkpoint, &cp); err != nil {
+			return fmt.Sprintf("job %d is %s", id, j.State)
+		}
+		return fmt.Sprintf("%d regions left", cp.Left)
+	})
+	if err != nil {
+		return failf("Failed to wait store %s: %s\n", args[0], err)
+	}
+	if j.State != "finished" {
+		return failf("The drain-store job %d is %s: %s\n", id, j.State, j.Error)
+	}
+	cmd.Printf("Store %s is drained\n", args[0])
+	return nil
+}
+
+// storeRemovingState is the part of the store info to show the progress of
+// removing a store.
+type storeRemovingState struct {
+	Store struct {
+		StateName string `json:"state_name"`
+	} `json:"store"`
+	Status struct {
+		RegionCount int `json:"region_count"`
+	} `json:"status"`
+}
+
+func getStoreRemovingState(cmd *cobra.Command, prefix string) (*storeRemovingState, error) {
+	r, err := doRequest(cmd, prefix, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+	state := &storeRemovingState{}
+	if err := json.Unmarshal([]byte(r), state); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return state, nil
+}
+
+func deleteStoreCommandByAddrFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return usageErrorln(cmd.UsageString())
 	}
 	addr := args[0]
 
 	// fetch all the stores
 	r, err := doRequest(cmd, storesPrefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get store: %s\n", err)
-		return
+		return failf("Failed to get store: %s\n", err)
 	}
 
 	storeInfo := struct {
@@ -314,8 +539,7 @@ func deleteStoreCommandByAddrFunc(cmd *cobra.Command, args []string) {
 		} `json:"stores"`
 	}{}
 	if err = json.Unmarshal([]byte(r), &storeInfo); err != nil {
-		cmd.Printf("Failed to parse store info: %s\n", err)
-		return
+		return failf("Failed to parse store info: %s\n", err)
 	}
 
 	// filter by the addr
@@ -328,31 +552,32 @@ func deleteStoreCommandByAddrFunc(cmd *cobra.Command, args []string) {
 	}
 
 	if id == -1 {
-		cmd.Printf("address not found: %s\n", addr)
-		return
+		return failf("address not found: %s\n", addr)
+	}
+
+	if err := confirm(cmd, fmt.Sprintf("delete store %d", id), addr); err != nil {
+		return err
 	}
 
 	// delete store by its ID
 	prefix := fmt.Sprintf(storePrefix, id)
 	_, err = doRequest(cmd, prefix, http.MethodDelete)
 	if err != nil {
-		cmd.Printf("Failed to delete store %s: %s\n", args[0], err)
-		return
+		return failf("Failed to delete store %s: %s\n", args[0], err)
 	}
 	cmd.Println("Success!")
+	return nil
 }
 
-func labelStoreCommandFunc(cmd *cobra.Command, args []string) {
+func labelStoreCommandFunc(cmd *cobra.Command, args []string) error {
 	// The least args' numbers is 1, which means users can set empty key and value
 	// In this way, if force flag is set then it means clear all labels,
 	// if force flag isn't set then it means do nothing
 	if len(args) < 1 || len(args)%2 != 1 {
-		cmd.Usage()
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	if _, err := strconv.Atoi(args[0]); err != nil {
-		cmd.Println("store_id should be a number")
-		return
+		return usageErrorln("store_id should be a number")
 	}
 	prefix := fmt.Sprintf(path.Join(storePrefix, "label"), args[0])
 	labels := make(map[string]interface{})
@@ -362,49 +587,87 @@ func labelStoreCommandFunc(cmd *cobra.Command, args []string) {
 	if force, _ := cmd.Flags().GetBool("force"); force {
 		prefix += "?force=true"
 	}
-	postJSON(cmd, prefix, labels)
+	return postJSON(cmd, prefix, labels)
 }
 
-func setStoreWeightCommandFunc(cmd *cobra.Command, args []string) {
+func storeConfigCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return usageErrorln(cmd.UsageString())
+	}
+	if _, err := strconv.Atoi(args[0]); err != nil {
+		return usageErrorln("store_id should be a number")
+	}
+	prefix := fmt.Sprintf(path.Join(storePrefix, "config"), args[0])
+	switch {
+	case len(args) == 1:
+		r, err := doRequest(cmd, prefix, http.MethodGet)
+		if err != nil {
+			return failf("Failed to get store config: %s\n", err)
+		}
+		cmd.Println(r)
+	case args[1] == "set" && len(args) >= 4 && len(args)%2 == 0:
+		items := make(map[string]interface{})
+		for i := 2; i < len(args); i += 2 {
+			items[args[i]] = args[i+1]
+		}
+		if err := postJSON(cmd, prefix, items); err != nil {
+			return err
+		}
+	case args[1] == "delete" && len(args) == 3:
+		_, err := doRequest(cmd, path.Join(prefix, url.PathEscape(args[2])), http.MethodDelete)
+		if err != nil {
+			return failf("Failed to delete store config: %s\n", err)
+		}
+		cmd.Println("Success!")
+	default:
+		return usageErrorln(cmd.UsageString())
+	}
+	return nil
+}
+
+func setStoreWeightCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 3 {
-		cmd.Usage()
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	leader, err := strconv.ParseFloat(args[1], 64)
 	if err != nil || leader < 0 {
-		cmd.Println("leader_weight should be a number that >= 0.")
-		return
+		return usageErrorln("leader_weight should be a number that >= 0.")
 	}
 	region, err := strconv.ParseFloat(args[2], 64)
 	if err != nil || region < 0 {
-		cmd.Println("region_weight should be a number that >= 0")
-		return
+		return usageErrorln("region_weight should be a number that >= 0")
 	}
 	prefix := fmt.Sprintf(path.Join(storePrefix, "weight"), args[0])
-	postJSON(cmd, prefix, map[string]interface{}{
+	return postJSON(cmd, prefix, map[string]interface{}{
 		"leader": leader,
 		"region": region,
 	})
 }
 
-func storeLimitCommandFunc(cmd *cobra.Command, args []string) {
+func storeLimitCommandFunc(cmd *cobra.Command, args []string) error {
 	argsCount := len(args)
+	limitType, _ := cmd.Flags().GetString("type")
+	if limitType != "" && limitType != "add-peer" && limitType != "remove-peer" {
+		return usageErrorln("type should be 'add-peer' or 'remove-peer'.")
+	}
 	if argsCount <= 1 {
 		prefix := storesLimitPrefix
 		if argsCount == 1 {
 			prefix += fmt.Sprintf("?type=%s", args[0])
+		} else if limitType != "" {
+			prefix += fmt.Sprintf("?type=%s", limitType)
 		}
 		r, err := doRequest(cmd, prefix, http.MethodGet)
 		if err != nil {
-			cmd.Printf("Failed to get store limit: %s\n", err)
-			return
+			return failf("Failed to get store limit: %s\n", err)
+		}
+		if err := printResponse(cmd, r); err != nil {
+			return err
 		}
-		cmd.Println(r)
 	} else if argsCount <= 3 {
 		rate, err := strconv.ParseFloat(args[1], 64)
 		if err != nil || rate <= 0 {
-			cmd.Println("rate should be a number that > 0.")
-			return
+			return usageErrorln("rate should be a number that > 0.")
 		}
 		// if the store id is "all", set limits for all stores
 		var prefix string
@@ -418,53 +681,140 @@ func storeLimitCommandFunc(cmd *cobra.Command, args []string) {
 		}
 		if argsCount == 3 {
 			postInput["type"] = args[2]
+		} else if limitType != "" {
+			postInput["type"] = limitType
+		}
+		if err := postJSON(cmd, prefix, postInput); err != nil {
+			return err
 		}
-		postJSON(cmd, prefix, postInput)
+	} else if args[0] != "all" {
+		return usageErrorln("Labels are an option of set all stores limit.")
 	} else {
-		if args[0] != "all" {
-			cmd.Println("Labels are an option of set all stores limit.")
-		} else {
-			postInput := map[string]interface{}{}
-			prefix := storesLimitPrefix
-			ratePos := argsCount - 1
-			if argsCount%2 == 1 {
-				postInput["type"] = args[argsCount-1]
-				ratePos = argsCount - 2
-			}
-			rate, err := strconv.ParseFloat(args[ratePos], 64)
-			if err != nil || rate <= 0 {
-				cmd.Println("rate should be a number that > 0.")
-				return
-			}
-			postInput["rate"] = rate
-			labels := make(map[string]interface{})
-			for i := 1; i < ratePos; i += 2 {
-				labels[args[i]] = args[i+1]
-			}
-			postInput["labels"] = labels
-			postJSON(cmd, prefix, postInput)
+		postInput := map[string]interface{}{}
+		prefix := storesLimitPrefix
+		ratePos := argsCount - 1
+		if argsCount%2 == 1 {
+			postInput["type"] = args[argsCount-1]
+			ratePos = argsCount - 2
+		} else if limitType != "" {
+			postInput["type"] = limitType
+		}
+		rate, err := strconv.ParseFloat(args[ratePos], 64)
+		if err != nil || rate <= 0 {
+			return usageErrorln("rate should be a number that > 0.")
+		}
+		postInput["rate"] = rate
+		labels := make(map[string]interface{})
+		for i := 1; i < ratePos; i += 2 {
+			labels[args[i]] = args[i+1]
+		}
+		postInput["labels"] = labels
+		if err := postJSON(cmd, prefix, postInput); err != nil {
+			return err
 		}
 	}
+	return nil
 }
 
-func showStoresCommandFunc(cmd *cobra.Command, args []string) {
+func showStoresCommandFunc(cmd *cobra.Command, args []string) error {
 	prefix := storesPrefix
 	r, err := doRequest(cmd, prefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get store: %s\n", err)
-		return
+		return failf("Failed to get store: %s\n", err)
+	}
+	anonymizer, err := newAnonymizer(cmd)
+	if err != nil {
+		return failf("Failed to load the mapping file: %s\n", err)
+	}
+	if anonymizer != nil {
+		data, err := anonymizer.anonymize([]byte(r))
+		if err != nil {
+			return failf("Failed to anonymize store: %s\n", err)
+		}
+		r = string(data)
+		if err := anonymizer.save(); err != nil {
+			return failf("Failed to write the mapping file: %s\n", err)
+		}
 	}
 	if flag := cmd.Flag("jq"); flag != nil && flag.Value.String() != "" {
-		printWithJQFilter(r, flag.Value.String())
-		return
+		return printWithJQFilter(cmd, r, flag.Value.String())
+	}
+	return printResponse(cmd, r)
+}
+
+// NewStoreScoresCommand returns a scores subcommand of storeCmd.
+func NewStoreScoresCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "scores",
+		Short: "show the leader and region scores of stores, with the utilization which is used by the utilization leader schedule policy",
+		RunE:  showStoreScoresCommandFunc,
+	}
+}
+
+// storeScore is the scores and the utilization of a store.
+type storeScore struct {
+	ID          uint64  `json:"id"`
+	Address     string  `json:"address"`
+	LeaderScore float64 `json:"leader_score"`
+	RegionScore float64 `json:"region_score"`
+	CPUUsage    float64 `json:"cpu_usage"`
+	DiskIORate  string  `json:"disk_io_rate"`
+	Utilization float64 `json:"utilization"`
+}
+
+func showStoreScoresCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
 	}
-	cmd.Println(r)
+	r, err := doRequest(cmd, storesPrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get stores: %s\n", err)
+	}
+	storesInfo := struct {
+		Stores []struct {
+			Store struct {
+				ID      uint64 `json:"id"`
+				Address string `json:"address"`
+			} `json:"store"`
+			Status struct {
+				LeaderScore float64 `json:"leader_score"`
+				RegionScore float64 `json:"region_score"`
+				CPUUsage    float64 `json:"cpu_usage"`
+				DiskIORate  string  `json:"disk_io_rate"`
+				Utilization float64 `json:"utilization"`
+			} `json:"status"`
+		} `json:"stores"`
+	}{}
+	if err := json.Unmarshal([]byte(r), &storesInfo); err != nil {
+		return failf("Failed to parse stores: %s\n", err)
+	}
+	scores := make([]storeScore, 0, len(storesInfo.Stores))
+	for _, s := range storesInfo.Stores {
+		score := storeScore{
+			ID:          s.Store.ID,
+			Address:     s.Store.Address,
+			LeaderScore: s.Status.LeaderScore,
+			RegionScore: s.Status.RegionScore,
+			CPUUsage:    s.Status.CPUUsage,
+			DiskIORate:  s.Status.DiskIORate,
+			Utilization: s.Status.Utilization,
+		}
+		if score.DiskIORate == "" {
+			score.DiskIORate = "0B"
+		}
+		scores = append(scores, score)
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i].ID < scores[j].ID })
+	data, err := json.MarshalIndent(scores, "", "  ")
+	if err != nil {
+		return failf("Failed to marshal store scores: %s\n", err)
+	}
+	return printResponse(cmd, string(data))
 }
 
-func showAllStoresLimitCommandFunc(cmd *cobra.Command, args []string) {
+func showAllStoresLimitCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) > 1 {
-		cmd.Usage()
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	prefix := storesLimitPrefix
 	if len(args) == 1 {
@@ -472,32 +822,37 @@ func showAllStoresLimitCommandFunc(cmd *cobra.Command, args []string) {
 	}
 	r, err := doRequest(cmd, prefix, http.MethodGet)
 	if err != nil {
-		cmd.Printf("Failed to get all stores' limit: %s\n", err)
-		return
+		return failf("Failed to get all stores' limit: %s\n", err)
 	}
-	cmd.Println(r)
+	return printResponse(cmd, r)
 }
 
-func removeTombStoneCommandFunc(cmd *cobra.Command, args []string) {
+func removeTombStoneCommandFunc(cmd *cobra.Command, args []string) error {
 	prefix := path.Join(storesPrefix, "remove-tombstone")
 	_, err := doRequest(cmd, prefix, http.MethodDelete)
 	if err != nil {
-		cmd.Printf("Failed to remove tombstone store %s \n", err)
-		return
+		return failf("Failed to remove tombstone store %s \n", err)
 	}
 	cmd.Println("Success!")
+	return nil
+}
+
+func lintStoreLabelsCommandFunc(cmd *cobra.Command, args []string) error {
+	r, err := doRequest(cmd, labelsLintPrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to lint store labels: %s\n", err)
+	}
+	return printResponse(cmd, r)
 }
 
-func setAllLimitCommandFunc(cmd *cobra.Command, args []string) {
+func setAllLimitCommandFunc(cmd *cobra.Command, args []string) error {
 	argsCount := len(args)
 	if argsCount != 1 && argsCount != 2 {
-		cmd.Usage()
-		return
+		return usageErrorln(cmd.UsageString())
 	}
 	rate, err := strconv.ParseFloat(args[0], 64)
 	if err != nil || rate <= 0 {
-		cmd.Println("rate should be a number that > 0.")
-		return
+		return usageErrorln("rate should be a number that > 0.")
 	}
 	prefix := storesLimitPrefix
 	input := map[string]interface{}{
@@ -506,5 +861,5 @@ func setAllLimitCommandFunc(cmd *cobra.Command, args []string) {
 	if len(args) == 2 {
 		input["type"] = args[1]
 	}
-	postJSON(cmd, prefix, input)
+	return postJSON(cmd, prefix, input)
 }
@@ -14,34 +14,65 @@
 package command
 
 import (
+	"encoding/json"
+	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tikv/pd/pkg/tsoutil"
 )
 
+var tsoPrefix = "pd/api/v1/tso"
+
 // NewTSOCommand return a ping subcommand of rootCmd
 func NewTSOCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "tso <timestamp>",
 		Short: "parse TSO to the system and logic time",
-		Run:   showTSOCommandFunc,
+		RunE:  showTSOCommandFunc,
 	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "now",
+		Short: "allocate a timestamp from PD and parse it to the system and logic time",
+		RunE:  allocTSOCommandFunc,
+	})
 	return cmd
 }
 
-func showTSOCommandFunc(cmd *cobra.Command, args []string) {
+func showTSOCommandFunc(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
-		cmd.Println("Usage: tso <timestamp>")
-		return
+		return usageErrorln("Usage: tso <timestamp>")
 	}
 	ts, err := strconv.ParseUint(args[0], 10, 64)
 	if err != nil {
-		cmd.Printf("Failed to parse TSO: %s\n", err)
-		return
+		return failf("Failed to parse TSO: %s\n", err)
 	}
 
 	physicalTime, logical := tsoutil.ParseTS(ts)
 	cmd.Println("system: ", physicalTime)
 	cmd.Println("logic: ", logical)
+	return nil
+}
+
+func allocTSOCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln("Usage: tso now")
+	}
+	r, err := doRequest(cmd, tsoPrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to allocate TSO: %s\n", err)
+	}
+	var ts struct {
+		TSO      uint64    `json:"tso"`
+		Physical time.Time `json:"physical"`
+		Logical  uint64    `json:"logical"`
+	}
+	if err := json.Unmarshal([]byte(r), &ts); err != nil {
+		return failf("Failed to allocate TSO: %s\n", err)
+	}
+	cmd.Println("tso: ", ts.TSO)
+	cmd.Println("system: ", ts.Physical.Local())
+	cmd.Println("logic: ", ts.Logical)
+	return nil
 }
@@ -0,0 +1,86 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var unsafePlanRemoveFailedStoresPrefix = "pd/api/v1/admin/unsafe/plan-remove-failed-stores"
+
+// NewUnsafeCommand returns an unsafe subcommand of rootCmd.
+func NewUnsafeCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "unsafe",
+		Short: "unsafe operations which may lose data, only for disaster recovery",
+	}
+	remove := &cobra.Command{
+		Use:   "plan-remove-failed-stores <store_id>[,<store_id>...] [--timeout=<duration>]",
+		Short: "plan the recovery of the regions which lose the majority of the voters on the failed stores, PD does not run the plan, you must run the printed tikv-ctl commands on the surviving stores while they are stopped, the progress is monitored by a job until the timeout",
+		RunE:  planRemoveFailedStoresCommandFunc,
+	}
+	remove.Flags().String("timeout", "", "how long the job monitors the recovery, 10m by default")
+	addConfirmFlag(remove)
+	show := &cobra.Command{
+		Use:   "show",
+		Short: "show the progress of the latest unsafe recovery",
+		RunE:  showUnsafeRecoveryCommandFunc,
+	}
+	remove.AddCommand(show)
+	c.AddCommand(remove)
+	return c
+}
+
+func planRemoveFailedStoresCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return usageErrorln(cmd.UsageString())
+	}
+	var stores []uint64
+	for _, s := range strings.Split(args[0], ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			return usageErrorln("store_id should be a number")
+		}
+		stores = append(stores, id)
+	}
+	input := map[string]interface{}{"stores": stores}
+	if timeout, _ := cmd.Flags().GetString("timeout"); timeout != "" {
+		input["timeout"] = timeout
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return failln(err)
+	}
+	if err := confirm(cmd, "plan removing the failed stores "+args[0]+" from the regions which lose the majority of the voters, the latest writes on them may be lost once the plan is run", args[0]); err != nil {
+		return err
+	}
+	r, err := doConfirmedRequest(cmd, unsafePlanRemoveFailedStoresPrefix, http.MethodPost, data)
+	if err != nil {
+		return failf("Failed to plan removing failed stores: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
+
+func showUnsafeRecoveryCommandFunc(cmd *cobra.Command, args []string) error {
+	r, err := doRequest(cmd, unsafePlanRemoveFailedStoresPrefix+"/show", http.MethodGet)
+	if err != nil {
+		return failf("Failed to get unsafe recovery status: %s\n", err)
+	}
+	return printResponse(cmd, r)
+}
@@ -0,0 +1,153 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/spf13/cobra"
+	"github.com/tikv/pd/server/versioninfo"
+)
+
+var statusPrefix = "pd/api/v1/status"
+
+// routeNotFound is the body of the 404 response for an unknown path, it is
+// returned by a PD server which is older than the API.
+const routeNotFound = "404 page not found\n"
+
+// serverVersions caches the versions of the PD servers by the hosts, so the
+// version is only requested once in a session.
+var serverVersions sync.Map
+
+// NewVersionCommand returns a version subcommand of rootCmd.
+func NewVersionCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "version [--check-compat]",
+		Short: "show the versions of pd-ctl and the PD servers",
+		RunE:  showVersionCommandFunc,
+	}
+	c.Flags().Bool("check-compat", false, "check whether pd-ctl is compatible with the PD servers")
+	return c
+}
+
+func showVersionCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageErrorln(cmd.UsageString())
+	}
+	checkCompat, _ := cmd.Flags().GetBool("check-compat")
+	cmd.Printf("pd-ctl: %s (git hash: %s)\n", versioninfo.PDReleaseVersion, versioninfo.PDGitHash)
+
+	r, err := doRequest(cmd, membersPrefix, http.MethodGet)
+	if err != nil {
+		return failf("Failed to get the versions of the PD servers: %s\n", err)
+	}
+	var members struct {
+		Members []*pdpb.Member `json:"members"`
+		Leader  *pdpb.Member   `json:"leader"`
+	}
+	if err := json.Unmarshal([]byte(r), &members); err != nil {
+		return failf("Failed to get the versions of the PD servers: %s\n", err)
+	}
+	incompatible := 0
+	for _, m := range members.Members {
+		name := m.GetName()
+		if m.GetMemberId() == members.Leader.GetMemberId() {
+			name += " (leader)"
+		}
+		line := fmt.Sprintf("%s %s: %s", name, strings.Join(m.GetClientUrls(), ","), m.GetBinaryVersion())
+		if checkCompat {
+			ok, msg := checkVersionCompat(versioninfo.PDReleaseVersion, m.GetBinaryVersion())
+			if !ok {
+				incompatible++
+			}
+			line += ", " + msg
+		}
+		cmd.Println(line)
+	}
+	if incompatible > 0 {
+		cmd.Printf("Warning: pd-ctl is not compatible with %d PD server(s), please use pd-ctl of the same version as the servers\n", incompatible)
+	}
+	return nil
+}
+
+// checkVersionCompat returns whether pd-ctl of the client version is
+// compatible with the PD server of the server version. They are compatible if
+// the major and minor versions are the same, the unknown versions, like the
+// ones built without the release version, are regarded as compatible.
+func checkVersionCompat(client, server string) (bool, string) {
+	if client == "" || server == "" {
+		return true, "unknown"
+	}
+	c, err := versioninfo.ParseVersion(client)
+	if err != nil {
+		return true, "unknown"
+	}
+	s, err := versioninfo.ParseVersion(server)
+	if err != nil {
+		return true, "unknown"
+	}
+	switch {
+	case c.Major == s.Major && c.Minor == s.Minor:
+		return true, "compatible"
+	case s.LessThan(*c):
+		return false, "incompatible, pd-ctl is newer than the server and the commands of the new features are not supported"
+	default:
+		return false, "incompatible, pd-ctl is older than the server and some commands may behave differently"
+	}
+}
+
+// getServerVersion returns the release version of the PD server which serves
+// the request, it returns an empty string if the version is unknown.
+func getServerVersion(req *http.Request) string {
+	if v, ok := serverVersions.Load(req.URL.Host); ok {
+		return v.(string)
+	}
+	statusReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, fmt.Sprintf("%s://%s/%s", req.URL.Scheme, req.URL.Host, statusPrefix), nil)
+	if err != nil {
+		return ""
+	}
+	resp, err := do(statusReq)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	var status struct {
+		Version string `json:"version"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&status) != nil {
+		return ""
+	}
+	serverVersions.Store(req.URL.Host, status.Version)
+	return status.Version
+}
+
+// unsupportedAPIError explains the 404 response of an unknown path, which
+// usually means the API is newer than the PD server.
+func unsupportedAPIError(req *http.Request, err *statusError) error {
+	if err.code != http.StatusNotFound || string(err.msg) != routeNotFound {
+		return err
+	}
+	server := getServerVersion(req)
+	if server == "" {
+		server = "unknown"
+	}
+	err.msg = []byte(fmt.Sprintf("the API %s is not supported by the PD server of version %s, the version of pd-ctl is %s, try `version --check-compat`",
+		req.URL.Path, server, versioninfo.PDReleaseVersion))
+	return err
+}
@@ -14,25 +14,40 @@
 package pdctl
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/chzyer/readline"
 	"github.com/mattn/go-shellwords"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/tools/pd-ctl/pdctl/command"
 )
 
 // CommandFlags are flags that used in all Commands
 type CommandFlags struct {
-	URL      string
-	CAPath   string
-	CertPath string
-	KeyPath  string
-	Help     bool
+	URL             string
+	CAPath          string
+	CertPath        string
+	KeyPath         string
+	Output          string
+	Sort            string
+	Watch           time.Duration
+	Timeout         time.Duration
+	MaxRetries      int
+	ProfileRequests bool
+	Config          string
+	Clusters        string
+	MetricsAddr     string
+	Help            bool
 }
 
 var (
@@ -46,6 +61,13 @@ var (
 	readlineCompleter *readline.PrefixCompleter
 )
 
+// historyFileName is the file in the home directory to keep the command history
+// of the interactive mode across sessions.
+const historyFileName = ".pd_ctl_history"
+
+// clearScreen moves the cursor to the top left and clears the terminal.
+const clearScreen = "\033[H\033[2J"
+
 func init() {
 	cobra.EnablePrefixMatching = true
 }
@@ -61,20 +83,36 @@ func pdctlRun(cmd *cobra.Command, args []string) {
 }
 
 func getBasicCmd() *cobra.Command {
+	return newRootCmd(&commandFlags)
+}
+
+// newRootCmd returns a root command which parses the flags into the given
+// CommandFlags.
+func newRootCmd(flags *CommandFlags) *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:   "pd-ctl",
 		Short: "Placement Driver control",
 	}
 
-	rootCmd.PersistentFlags().StringVarP(&commandFlags.URL, "pd", "u", commandFlags.URL, "address of pd")
-	rootCmd.PersistentFlags().StringVar(&commandFlags.CAPath, "cacert", commandFlags.CAPath, "path of file that contains list of trusted SSL CAs")
-	rootCmd.PersistentFlags().StringVar(&commandFlags.CertPath, "cert", commandFlags.CertPath, "path of file that contains X509 certificate in PEM format")
-	rootCmd.PersistentFlags().StringVar(&commandFlags.KeyPath, "key", commandFlags.KeyPath, "path of file that contains X509 key in PEM format")
-	rootCmd.PersistentFlags().BoolVarP(&commandFlags.Help, "help", "h", false, "help message")
+	rootCmd.PersistentFlags().StringVarP(&flags.URL, "pd", "u", flags.URL, "address of pd")
+	rootCmd.PersistentFlags().StringVar(&flags.CAPath, "cacert", flags.CAPath, "path of file that contains list of trusted SSL CAs")
+	rootCmd.PersistentFlags().StringVar(&flags.CertPath, "cert", flags.CertPath, "path of file that contains X509 certificate in PEM format")
+	rootCmd.PersistentFlags().StringVar(&flags.KeyPath, "key", flags.KeyPath, "path of file that contains X509 key in PEM format")
+	rootCmd.PersistentFlags().StringVarP(&flags.Output, "output", "o", command.OutputJSON, "output format, one of table, json, yaml and csv")
+	rootCmd.PersistentFlags().StringVar(&flags.Sort, "sort", "", "sort the listed items by a field, like id, start_key or store.id")
+	rootCmd.PersistentFlags().DurationVar(&flags.Watch, "watch", 0, "re-execute the command periodically with the interval, like 2s")
+	rootCmd.PersistentFlags().DurationVar(&flags.Timeout, "timeout", 0, "the timeout of each request to pd, like 10s, no timeout by default")
+	rootCmd.PersistentFlags().IntVar(&flags.MaxRetries, "max-retries", 0, "the max number of retries of the read requests with exponential backoff if pd is unavailable")
+	rootCmd.PersistentFlags().BoolVar(&flags.ProfileRequests, "profile-requests", false, "report the timing of each request to pd to stderr, including dns, connect, tls, time to first byte, total and the handling time of pd")
+	rootCmd.PersistentFlags().StringVar(&flags.Config, "config", "", "path of the config file with the cluster profiles, it is ~/"+configFileName+" by default")
+	rootCmd.PersistentFlags().StringVar(&flags.Clusters, "clusters", "", "execute the read-only command against the clusters in the config file concurrently, like prod-a,prod-b")
+	rootCmd.PersistentFlags().StringVar(&flags.MetricsAddr, "metrics-addr", "", "expose the metrics of the requests to pd at /metrics on the address, like 127.0.0.1:9100, it requires --watch or --interact")
+	rootCmd.PersistentFlags().BoolVarP(&flags.Help, "help", "h", false, "help message")
 
 	rootCmd.AddCommand(
 		command.NewConfigCommand(),
 		command.NewRegionCommand(),
+		command.NewRegionLabelCommand(),
 		command.NewStoreCommand(),
 		command.NewStoresCommand(),
 		command.NewMemberCommand(),
@@ -87,9 +125,18 @@ func getBasicCmd() *cobra.Command {
 		command.NewHotSpotCommand(),
 		command.NewClusterCommand(),
 		command.NewHealthCommand(),
+		command.NewReportCommand(),
 		command.NewLogCommand(),
 		command.NewPluginCommand(),
 		command.NewServiceGCSafepointCommand(),
+		command.NewJobCommand(),
+		command.NewKeyRangeCommand(),
+		command.NewDebugCommand(),
+		command.NewStatsCommand(),
+		command.NewFeaturesCommand(),
+		command.NewUnsafeCommand(),
+		command.NewVersionCommand(),
+		command.NewCheckCommand(),
 		command.NewCompletionCommand(),
 	)
 
@@ -104,7 +151,8 @@ func getInteractCmd(args []string) *cobra.Command {
 
 	rootCmd.SetArgs(args)
 	rootCmd.ParseFlags(args)
-	rootCmd.SetOutput(os.Stdout)
+	rootCmd.SetOut(os.Stdout)
+	rootCmd.SetErr(os.Stderr)
 	hiddenFlag(rootCmd)
 
 	return rootCmd
@@ -116,11 +164,14 @@ func getMainCmd(args []string) *cobra.Command {
 	rootCmd.Flags().BoolVarP(&detach, "detach", "d", true, "Run pdctl without readline.")
 	rootCmd.Flags().BoolVarP(&interact, "interact", "i", false, "Run pdctl with readline.")
 	rootCmd.Flags().BoolVarP(&version, "version", "V", false, "Print version information and exit.")
+	rootCmd.Flags().StringVar(&batchFile, "file", "", "Execute the commands in the file, one per line, they are read from stdin by pd-ctl -.")
+	rootCmd.Flags().BoolVar(&continueOnError, "continue-on-error", false, "Continue to execute the commands in the file after a command fails.")
 	rootCmd.Run = pdctlRun
 
 	rootCmd.SetArgs(args)
 	rootCmd.ParseFlags(args)
-	rootCmd.SetOutput(os.Stdout)
+	rootCmd.SetOut(os.Stdout)
+	rootCmd.SetErr(os.Stderr)
 
 	readlineCompleter = readline.NewPrefixCompleter(genCompleter(rootCmd)...)
 	return rootCmd
@@ -134,34 +185,99 @@ func hiddenFlag(cmd *cobra.Command) {
 	cmd.LocalFlags().MarkHidden("key")
 }
 
-// MainStart start main command
+// MainStart start main command, it exits with the exit code of the command if
+// it is not 0.
 func MainStart(args []string) {
-	startCmd(getMainCmd, args)
+	var code int
+	if file, ok := batchInput(args); ok {
+		code = startBatch(file)
+	} else {
+		code = startCmd(getMainCmd, args)
+	}
+	if code != command.ExitOK {
+		os.Exit(code)
+	}
 }
 
-// Start start interact command
-func Start(args []string) {
-	startCmd(getInteractCmd, args)
+// Start start interact command, and returns the exit code of the command.
+func Start(args []string) int {
+	return startCmd(getInteractCmd, args)
 }
 
-func startCmd(getCmd func([]string) *cobra.Command, args []string) {
+// startCmd executes the command, the error is printed to stderr and the exit
+// code of it is returned.
+func startCmd(getCmd func([]string) *cobra.Command, args []string) int {
 	rootCmd := getCmd(args)
-	if len(commandFlags.CAPath) != 0 {
+	if len(commandFlags.CAPath) != 0 || len(commandFlags.CertPath) != 0 || len(commandFlags.KeyPath) != 0 {
 		if err := command.InitHTTPSClient(commandFlags.CAPath, commandFlags.CertPath, commandFlags.KeyPath); err != nil {
-			rootCmd.Println(err)
-			return
+			fmt.Fprintln(rootCmd.ErrOrStderr(), err)
+			return command.ExitUsage
+		}
+	}
+
+	if commandFlags.MetricsAddr != "" {
+		if commandFlags.Watch <= 0 && !interact {
+			fmt.Fprintln(rootCmd.ErrOrStderr(), "--metrics-addr requires --watch or --interact")
+			return command.ExitUsage
+		}
+		if _, err := command.ServeMetrics(commandFlags.MetricsAddr); err != nil {
+			fmt.Fprintln(rootCmd.ErrOrStderr(), "Failed to serve metrics:", err)
+			return command.ExitUsage
+		}
+	}
+
+	if commandFlags.Clusters != "" {
+		if commandFlags.Watch > 0 {
+			fmt.Fprintln(rootCmd.ErrOrStderr(), "--watch can not be used with --clusters")
+			return command.ExitUsage
 		}
+		return fanOut(rootCmd, args)
 	}
 
-	if err := rootCmd.Execute(); err != nil {
-		rootCmd.Println(err)
+	if commandFlags.Watch > 0 {
+		sc := make(chan os.Signal, 1)
+		signal.Notify(sc, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sc)
+		return watch(rootCmd, args, sc)
 	}
+
+	return command.PrintError(rootCmd, command.Execute(context.Background(), rootCmd))
+}
+
+// watch re-executes the command every interval until it is interrupted or the
+// command fails. The table output is redrawn in place, and the other formats
+// are appended.
+func watch(rootCmd *cobra.Command, args []string, stop <-chan os.Signal) int {
+	ticker := time.NewTicker(commandFlags.Watch)
+	defer ticker.Stop()
+	for {
+		if commandFlags.Output == command.OutputTable {
+			rootCmd.Print(clearScreen)
+		}
+		rootCmd.Printf("Every %s: %s\t%s\n\n", commandFlags.Watch, strings.Join(args, " "), time.Now().Format("2006-01-02 15:04:05"))
+		if err := command.Execute(context.Background(), rootCmd); err != nil {
+			return command.PrintError(rootCmd, err)
+		}
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return command.ExitOK
+		}
+	}
+}
+
+func historyFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), historyFileName)
+	}
+	return filepath.Join(home, historyFileName)
 }
 
 func loop() {
 	l, err := readline.NewEx(&readline.Config{
 		Prompt:            "\033[31m»\033[0m ",
-		HistoryFile:       "/tmp/readline.tmp",
+		HistoryFile:       historyFile(),
 		AutoComplete:      readlineCompleter,
 		InterruptPrompt:   "^C",
 		EOFPrompt:         "^D",
@@ -176,13 +292,21 @@ func loop() {
 		line, err := l.Readline()
 		if err != nil {
 			if err == readline.ErrInterrupt {
-				break
+				// ^C discards the current line, and exits on an empty line.
+				if len(line) == 0 {
+					break
+				}
+				continue
 			} else if err == io.EOF {
 				break
 			}
 			continue
 		}
-		if line == "exit" {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if line == "exit" || line == "quit" {
 			os.Exit(0)
 		}
 		args, err := shellwords.Parse(line)
@@ -198,17 +322,17 @@ func genCompleter(cmd *cobra.Command) []readline.PrefixCompleterInterface {
 	pc := []readline.PrefixCompleterInterface{}
 
 	for _, v := range cmd.Commands() {
-		if v.HasFlags() {
-			flagsPc := []readline.PrefixCompleterInterface{}
-			flagUsages := strings.Split(strings.Trim(v.Flags().FlagUsages(), " "), "\n")
-			for i := 0; i < len(flagUsages)-1; i++ {
-				flagsPc = append(flagsPc, readline.PcItem(strings.Split(strings.Trim(flagUsages[i], " "), " ")[0]))
-			}
-			flagsPc = append(flagsPc, genCompleter(v)...)
-			pc = append(pc, readline.PcItem(strings.Split(v.Use, " ")[0], flagsPc...))
-		} else {
-			pc = append(pc, readline.PcItem(strings.Split(v.Use, " ")[0], genCompleter(v)...))
+		if v.Hidden || v.Deprecated != "" {
+			continue
 		}
+		children := []readline.PrefixCompleterInterface{}
+		v.LocalFlags().VisitAll(func(f *pflag.Flag) {
+			if !f.Hidden {
+				children = append(children, readline.PcItem("--"+f.Name))
+			}
+		})
+		children = append(children, genCompleter(v)...)
+		pc = append(pc, readline.PcItem(v.Name(), children...))
 	}
 	return pc
 }
@@ -14,9 +14,24 @@
 package pdctl
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/tikv/pd/tools/pd-ctl/pdctl/command"
 )
 
 func newCommand(usage, short string) *cobra.Command {
@@ -68,3 +83,404 @@ func TestGenCompleter(t *testing.T) {
 	}
 
 }
+
+func TestGenCompleterWithFlags(t *testing.T) {
+	rootCmd := &cobra.Command{
+		Use:   "roottest",
+		Short: "test root cmd",
+	}
+	cmdA := newCommand("testa <id>", "test a command")
+	cmdA.Flags().String("jq", "", "jq query")
+	cmdB := newCommand("testb", "test b command")
+	cmdB.Hidden = true
+	rootCmd.AddCommand(cmdA, cmdB)
+
+	pc := genCompleter(rootCmd)
+	if len(pc) != 1 {
+		t.Fatalf("expect 1 command, got %d", len(pc))
+	}
+	if string(pc[0].GetName()) != "testa " {
+		t.Errorf("expect testa, got %s", string(pc[0].GetName()))
+	}
+	children := pc[0].GetChildren()
+	if len(children) != 1 || string(children[0].GetName()) != "--jq " {
+		t.Errorf("expect the flag --jq to be completed")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	stop := make(chan os.Signal, 1)
+	var count int
+	rootCmd := &cobra.Command{
+		Use: "roottest",
+		Run: func(cmd *cobra.Command, args []string) {
+			count++
+			cmd.Println("output")
+			if count == 3 {
+				stop <- os.Interrupt
+			}
+		},
+	}
+	var buf bytes.Buffer
+	rootCmd.SetOutput(&buf)
+	rootCmd.SetArgs(nil)
+
+	commandFlags.Watch, commandFlags.Output = time.Millisecond, command.OutputTable
+	defer func() { commandFlags.Watch, commandFlags.Output = 0, command.OutputJSON }()
+	watch(rootCmd, []string{"roottest"}, stop)
+	if count != 3 {
+		t.Fatalf("expect the command to be executed 3 times, got %d", count)
+	}
+	output := buf.String()
+	if strings.Count(output, clearScreen) != 3 || strings.Count(output, "Every 1ms: roottest") != 3 {
+		t.Errorf("expect the screen to be redrawn 3 times, got %q", output)
+	}
+}
+
+func TestConnectionReuse(t *testing.T) {
+	var conns int32
+	// The response is large enough so that it is not read by the transport
+	// unless the body is drained.
+	body := []byte(`"` + strings.Repeat("x", 64*1024) + `"`)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	for i := 0; i < 3; i++ {
+		for _, args := range [][]string{
+			{"-u", ts.URL, "ping"},
+			{"-u", ts.URL, "store", "label", "1", "zone", "z1"},
+			{"-u", ts.URL, "region", "1"},
+		} {
+			var buf bytes.Buffer
+			rootCmd := getMainCmd(args)
+			rootCmd.SetOutput(&buf)
+			if err := command.Execute(context.Background(), rootCmd); err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(buf.String(), "Failed") {
+				t.Fatalf("unexpected output %q", buf.String())
+			}
+		}
+	}
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Errorf("expect the connection to be reused, got %d connections", n)
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	var posts int32
+	newServer := func(id int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				atomic.AddInt32(&posts, 1)
+			}
+			fmt.Fprintf(w, `{"id":%d}`, id)
+		}))
+	}
+	ts1, ts2 := newServer(1), newServer(2)
+	defer ts1.Close()
+	defer ts2.Close()
+
+	dir, err := ioutil.TempDir("", "pd-ctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, configFileName)
+	data := fmt.Sprintf("[clusters.prod-a]\npd = %q\n[clusters.prod-b]\npd = %q\n", ts1.URL, ts2.URL)
+	if err := ioutil.WriteFile(config, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) string {
+		var buf bytes.Buffer
+		rootCmd := getMainCmd(args)
+		rootCmd.SetOutput(&buf)
+		defer func() { commandFlags.Config, commandFlags.Clusters = "", "" }()
+		fanOut(rootCmd, args)
+		return buf.String()
+	}
+
+	output := run("--config", config, "--clusters", "prod-a,prod-b", "cluster")
+	results := make(map[string]struct {
+		ID int `json:"id"`
+	})
+	if err := json.Unmarshal([]byte(output), &results); err != nil {
+		t.Fatalf("unexpected output %q: %v", output, err)
+	}
+	if len(results) != 2 || results["prod-a"].ID != 1 || results["prod-b"].ID != 2 {
+		t.Errorf("unexpected results %q", output)
+	}
+
+	output = run("--config", config, "--clusters", "prod-a,prod-b", "store", "label", "1", "zone", "z1")
+	if strings.Count(output, "only the read-only commands are allowed") != 2 || atomic.LoadInt32(&posts) != 0 {
+		t.Errorf("expect the write command to be refused, got %q", output)
+	}
+
+	output = run("--config", config, "--clusters", "prod-c", "cluster")
+	if !strings.Contains(output, "cluster prod-c is not found") {
+		t.Errorf("unexpected output %q", output)
+	}
+}
+
+func TestRetryAndTimeout(t *testing.T) {
+	var requests, failures int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The members are requested once the address is reachable.
+		if r.URL.Path == "/pd/api/v1/members" {
+			w.Write([]byte(`{}`))
+			return
+		}
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path == "/pd/api/v1/region/id/2" {
+			time.Sleep(time.Second)
+		}
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	run := func(fails int32, args ...string) string {
+		atomic.StoreInt32(&requests, 0)
+		atomic.StoreInt32(&failures, fails)
+		var buf bytes.Buffer
+		rootCmd := getMainCmd(args)
+		rootCmd.SetOutput(&buf)
+		command.PrintError(rootCmd, command.Execute(context.Background(), rootCmd))
+		return buf.String()
+	}
+
+	if output := run(2, "-u", ts.URL, "--max-retries", "2", "region", "1"); strings.Contains(output, "Failed") || atomic.LoadInt32(&requests) != 3 {
+		t.Errorf("expect the request to be retried, got %q with %d requests", output, requests)
+	}
+	if output := run(3, "-u", ts.URL, "--max-retries", "0", "region", "1"); !strings.Contains(output, "503") {
+		t.Errorf("expect the request to fail without retries, got %q", output)
+	}
+	// The write requests are not retried.
+	if output := run(3, "-u", ts.URL, "--max-retries", "2", "store", "label", "1", "zone", "z1"); !strings.Contains(output, "Failed") || atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("expect the write request not to be retried, got %q with %d requests", output, requests)
+	}
+	if output := run(0, "-u", ts.URL, "--timeout", "100ms", "region", "2"); !strings.Contains(output, "Timeout") {
+		t.Errorf("expect the request to time out, got %q", output)
+	}
+}
+
+func TestProfileRequests(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("PD-Handle-Time", "1.5ms")
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	run := func(args ...string) string {
+		var buf bytes.Buffer
+		rootCmd := getMainCmd(args)
+		rootCmd.SetOutput(&buf)
+		if err := command.Execute(context.Background(), rootCmd); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	output := run("-u", ts.URL, "--profile-requests", "region", "1")
+	if !strings.Contains(output, "[profile] GET /pd/api/v1/region/id/1 [200]: dns ") || !strings.Contains(output, "server 1.5ms") {
+		t.Errorf("expect the request to be profiled, got %q", output)
+	}
+	if output := run("-u", ts.URL, "region", "1"); strings.Contains(output, "[profile]") {
+		t.Errorf("expect the request not to be profiled, got %q", output)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pd/api/v1/region/id/2":
+			w.WriteHeader(http.StatusNotFound)
+		case "/pd/api/v1/region/id/3":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	run := func(args ...string) (string, string, int) {
+		var stdout, stderr bytes.Buffer
+		rootCmd := getMainCmd(args)
+		rootCmd.SetOut(&stdout)
+		rootCmd.SetErr(&stderr)
+		code := command.PrintError(rootCmd, command.Execute(context.Background(), rootCmd))
+		return stdout.String(), stderr.String(), code
+	}
+
+	for _, tc := range []struct {
+		args []string
+		code int
+	}{
+		{[]string{"-u", ts.URL, "region", "1"}, command.ExitOK},
+		{[]string{"-u", ts.URL, "region", "2"}, command.ExitClientError},
+		{[]string{"-u", ts.URL, "region", "3"}, command.ExitServerError},
+		{[]string{"-u", "http://127.0.0.1:1", "region", "1"}, command.ExitNetworkError},
+		{[]string{"-u", ts.URL, "region", "a"}, command.ExitUsage},
+		{[]string{"-u", ts.URL, "store", "limit", "1", "1", "2", "3", "4"}, command.ExitUsage},
+	} {
+		stdout, stderr, code := run(tc.args...)
+		if code != tc.code {
+			t.Errorf("expect %v to exit with %d, got %d", tc.args, tc.code, code)
+		}
+		if (tc.code == command.ExitOK) != (stderr == "") {
+			t.Errorf("expect %v to print errors to stderr only if it fails, got stdout %q and stderr %q", tc.args, stdout, stderr)
+		}
+	}
+	// The flag errors of cobra are returned as usage errors.
+	if _, _, code := run("-u", ts.URL, "region", "--unknown-flag"); code != command.ExitUsage {
+		t.Errorf("expect the unknown flag to exit with %d, got %d", command.ExitUsage, code)
+	}
+	// The usage printed by the help is not an error.
+	if _, _, code := run("-u", ts.URL, "region", "--help"); code != command.ExitOK {
+		t.Errorf("expect the help to exit with 0, got %d", code)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/pd/api/v1/store/2" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	// The metrics are only exposed in the watch or interactive mode.
+	defer func() { commandFlags.MetricsAddr = "" }()
+	if code := startCmd(getMainCmd, []string{"-u", ts.URL, "--metrics-addr", "127.0.0.1:0", "region", "1"}); code != command.ExitUsage {
+		t.Fatalf("expect --metrics-addr without --watch to exit with %d, got %d", command.ExitUsage, code)
+	}
+
+	addr, err := command.ServeMetrics("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"-u", ts.URL, "store", "1"},
+		{"-u", ts.URL, "store", "2"},
+		{"-u", "http://127.0.0.1:1", "store", "1"},
+	} {
+		rootCmd := getMainCmd(args)
+		rootCmd.SetOutput(ioutil.Discard)
+		rootCmd.Execute()
+	}
+	resp, err := http.Get(fmt.Sprintf("http://%s/metrics", addr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The metrics are shared by the tests, so the command is not sent by others.
+	// The members are requested once after the first request succeeds.
+	for _, metric := range []string{
+		`pd_ctl_request_duration_seconds_count{command="store",method="GET"} 4`,
+		`pd_ctl_request_total{code="200",command="store",method="GET"} 2`,
+		`pd_ctl_request_total{code="404",command="store",method="GET"} 1`,
+		`pd_ctl_request_total{code="error",command="store",method="GET"} 1`,
+		`pd_ctl_request_errors_total{command="store",type="client"} 1`,
+		`pd_ctl_request_errors_total{command="store",type="network"} 1`,
+	} {
+		if !strings.Contains(string(body), metric) {
+			t.Errorf("expect %s in the metrics, got %s", metric, body)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	var conns int32
+	var requested sync.Map
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested.Store(r.URL.Path, struct{}{})
+		if r.URL.Path == "/pd/api/v1/region/id/2" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+	defer func() { commandFlags.URL = "http://127.0.0.1:2379" }()
+	commandFlags.URL = ts.URL
+
+	commands := "# the regions\n\nregion 1\nregion 2\nregion 3\n"
+	var stderr bytes.Buffer
+	if code := runBatch(strings.NewReader(commands), false, &stderr); code != command.ExitClientError {
+		t.Errorf("expect the batch to exit with %d, got %d", command.ExitClientError, code)
+	}
+	if _, ok := requested.Load("/pd/api/v1/region/id/3"); ok {
+		t.Error("expect the batch to stop at the failed command")
+	}
+	if !strings.Contains(stderr.String(), "Stopped at line 4: region 2") {
+		t.Errorf("unexpected stderr %q", stderr.String())
+	}
+
+	if code := runBatch(strings.NewReader(commands), true, &stderr); code != command.ExitClientError {
+		t.Errorf("expect the batch to exit with %d, got %d", command.ExitClientError, code)
+	}
+	if _, ok := requested.Load("/pd/api/v1/region/id/3"); !ok {
+		t.Error("expect the batch to continue after the failed command")
+	}
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Errorf("expect the connection to be shared, got %d connections", n)
+	}
+
+	if code := runBatch(strings.NewReader("region 1\n\"region\n"), true, &stderr); code != command.ExitUsage {
+		t.Errorf("expect the invalid line to exit with %d, got %d", command.ExitUsage, code)
+	}
+
+	// The destructive commands need --yes instead of reading the confirmation
+	// from the next lines.
+	if code := runBatch(strings.NewReader("member delete name pd2\npd2\nregion 5\n"), true, &stderr); code != command.ExitUsage {
+		t.Errorf("expect the unconfirmed command to exit with %d, got %d", command.ExitUsage, code)
+	}
+	if _, ok := requested.Load("/pd/api/v1/members/name/pd2"); ok {
+		t.Error("expect the unconfirmed command not to be executed")
+	}
+	if _, ok := requested.Load("/pd/api/v1/region/id/5"); !ok {
+		t.Error("expect the next line not to be consumed as the confirmation")
+	}
+	if code := runBatch(strings.NewReader("member delete name pd2 --yes\n"), false, &stderr); code != command.ExitOK {
+		t.Errorf("expect the confirmed command to exit with %d, got %d", command.ExitOK, code)
+	}
+	if _, ok := requested.Load("/pd/api/v1/members/name/pd2"); !ok {
+		t.Error("expect the confirmed command to be executed")
+	}
+
+	for _, tc := range []struct {
+		args  []string
+		batch bool
+	}{
+		{[]string{"-u", ts.URL, "-"}, true},
+		{[]string{"--file", "commands.txt"}, true},
+		{[]string{"region", "split", "--file", "keys.json"}, false},
+		{[]string{"region", "1"}, false},
+	} {
+		if IsBatch(tc.args) != tc.batch {
+			t.Errorf("expect %v to be batch %v", tc.args, tc.batch)
+		}
+	}
+	batchFile = ""
+}